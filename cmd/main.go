@@ -3,16 +3,30 @@ package main
 import (
 	"context"
 	"log"
+	"memorydb/internal/auth"
+	"memorydb/internal/certs"
+	"memorydb/internal/cluster"
 	"memorydb/internal/config"
 	"memorydb/internal/db"
+	"memorydb/internal/enums"
 	"memorydb/internal/logger"
+	"memorydb/internal/memcache"
+	"memorydb/internal/metrics"
+	"memorydb/internal/ratelimit"
+	"memorydb/internal/replication"
+	"memorydb/internal/resp"
+	"memorydb/internal/slots"
+	"memorydb/internal/tracing"
 	"memorydb/internal/transport"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	_ "go.uber.org/automaxprocs"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -29,31 +43,199 @@ func main() {
 	// start the in-memory database
 	logger.Info("Starting MemoryDB application", "version", "1.0.0")
 
-	dbOpts := []db.DBOptions{db.WithCleanupInterval(configuration.DefaultCleanupInterval)}
+	// the spans of the HTTP API and of the database are exported until the server stops
+	shutdownTracing, err := tracing.Setup(ctx, configuration.TracingExporter,
+		tracing.WithEndpoint(configuration.TracingEndpoint),
+		tracing.WithFile(configuration.TracingFile),
+		tracing.WithSampleRatio(configuration.TracingSampleRatio),
+	)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Warn("failed to flush spans", "error", err)
+		}
+	}()
+	if configuration.TracingExporter != enums.TracingExporterNone {
+		logger.Info("Tracing is enabled", "exporter", configuration.TracingExporter, "sample_ratio", configuration.TracingSampleRatio)
+	}
+
+	// the metrics of the database and of the HTTP API are served by the health server
+	collectors := metrics.New()
+
+	dbOpts := []db.DBOptions{db.WithCleanupInterval(configuration.DefaultCleanupInterval), db.WithMetrics{Metrics: collectors}}
 	if configuration.PersistenceEnabled {
 		logger.Info("Persistence is enabled, setting up database with persistence options")
 		dbOpts = append(dbOpts, db.WithPersistenceEnabled(configuration.DBPath))
 	}
-	db := db.NewMemoryDB(logger, dbOpts...)
+	if configuration.MaxMemory > 0 {
+		logger.Info("Memory limit is enabled", "max_memory", configuration.MaxMemory, "eviction_policy", configuration.EvictionPolicy)
+		dbOpts = append(dbOpts, db.WithMaxMemory(configuration.MaxMemory), db.WithEvictionPolicy(configuration.EvictionPolicy))
+	}
+	dbOpts = append(dbOpts, db.WithReplicationBacklog(configuration.ReplicationBacklogSize))
+
+	serverOpts := []transport.ServerOptions{
+		transport.WithPubSubBufferSize(configuration.PubSubBufferSize),
+		transport.WithRESPPort(configuration.RESPPort),
+		transport.WithGRPCPort(configuration.GRPCPort),
+		transport.WithMemcachedPort(configuration.MemcachedPort),
+		transport.WithMetrics{Metrics: collectors},
+	}
+
+	// If TLS is enabled, the HTTP servers serve the certificates, which are reloaded when their files change
+	var reloader *certs.Reloader
+	if configuration.TLSCertFile != "" {
+		reloader, err = certs.NewReloader(
+			logger,
+			configuration.TLSCertFile,
+			configuration.TLSKeyFile,
+			certs.WithClientCA(configuration.TLSClientCAFile),
+			certs.WithClientAuth(certs.ClientAuthType(configuration.TLSClientAuth)),
+		)
+		if err != nil {
+			log.Fatal("Failed to load TLS certificates:", err)
+		}
+		if configuration.TLSReloadInterval > 0 {
+			go reloader.Watch(ctx, configuration.TLSReloadInterval)
+		}
+		logger.Info("TLS is enabled", "cert", configuration.TLSCertFile, "client_auth", configuration.TLSClientAuth)
+		serverOpts = append(serverOpts, transport.WithTLS{Reloader: reloader})
+	}
+
+	// The probes are served while the data is loaded, reporting the server alive but not ready until it is created
+	startup := transport.NewStartupHealth(logger, *configuration.HealthPort, reloader)
+	serverOpts = append(serverOpts, transport.WithStartupHealth{StartupHealth: startup})
+	go func() {
+		if err := startup.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start health HTTP server", "error", err)
+			cancel() // Cancel the context to trigger shutdown
+		}
+	}()
+
+	// In cluster mode, the writes go through the Raft log of the cluster before they are applied to the database
+	var database db.DBClient
+	if configuration.ClusterNodeID != "" {
+		logger.Info("Cluster mode is enabled", "node_id", configuration.ClusterNodeID)
+		peers, err := cluster.ParsePeers(configuration.ClusterPeers)
+		if err != nil {
+			log.Fatal("Failed to parse cluster peers:", err)
+		}
+		nodeOpts := []cluster.NodeOptions{
+			cluster.WithDataDir(configuration.ClusterDataDir),
+			cluster.WithApplyTimeout(configuration.ClusterApplyTimeout),
+			cluster.WithDBOptions(dbOpts),
+		}
+		if configuration.AuthEnabled {
+			// the leader requires the credentials of the administrator, which are the same in every node
+			nodeOpts = append(nodeOpts, cluster.WithBasicAuth{Username: configuration.AuthAdminUsername, Password: configuration.AuthAdminPassword})
+		}
+		node, err := cluster.NewNode(logger, configuration.ClusterNodeID, peers, nodeOpts...)
+		if err != nil {
+			log.Fatal("Failed to start cluster node:", err)
+		}
+		database = node
+		serverOpts = append(serverOpts, transport.WithClusterNode{Node: node})
+	} else {
+		database = db.NewMemoryDB(logger, dbOpts...)
+	}
+
+	// If the server is part of a sharded topology, redirect the requests for the keys of the slots of other nodes
+	if configuration.SlotsNodeID != "" {
+		nodes, err := slots.ParseNodes(configuration.SlotsNodes)
+		if err != nil {
+			log.Fatal("Failed to parse slot nodes:", err)
+		}
+		var routerOpts []slots.RouterOptions
+		if configuration.AuthEnabled {
+			routerOpts = append(routerOpts, slots.WithBasicAuth{Username: configuration.AuthAdminUsername, Password: configuration.AuthAdminPassword})
+		}
+		router, err := slots.NewRouter(logger, database, configuration.SlotsNodeID, slots.NewTopology(nodes), routerOpts...)
+		if err != nil {
+			log.Fatal("Failed to create slot router:", err)
+		}
+		logger.Info("Hash slots are enabled", "node_id", configuration.SlotsNodeID, "nodes", len(nodes))
+		serverOpts = append(serverOpts, transport.WithSlotRouter{Router: router})
+	}
+
+	// If authentication is enabled, the data routes of the HTTP API require an access token
+	if configuration.AuthEnabled {
+		manager, err := auth.NewManager(
+			logger,
+			database,
+			configuration.AuthSecret,
+			configuration.AuthAdminUsername,
+			configuration.AuthAdminPassword,
+			auth.WithAccessTokenTTL(configuration.AuthAccessTokenTTL),
+			auth.WithRefreshTokenTTL(configuration.AuthRefreshTokenTTL),
+		)
+		if err != nil {
+			log.Fatal("Failed to create authentication manager:", err)
+		}
+		logger.Info("Authentication is enabled", "admin", configuration.AuthAdminUsername)
+		serverOpts = append(serverOpts, transport.WithAuthManager{AuthManager: manager})
+	}
+
+	// If the rate limits are enabled, every client has its own rate of reads and writes
+	if configuration.RateLimitReads > 0 || configuration.RateLimitWrites > 0 {
+		limiter := ratelimit.NewLimiter(
+			ratelimit.Limits{Rate: configuration.RateLimitReads, Burst: configuration.RateLimitReadBurst},
+			ratelimit.Limits{Rate: configuration.RateLimitWrites, Burst: configuration.RateLimitWriteBurst},
+		)
+		logger.Info("Rate limits are enabled", "reads", configuration.RateLimitReads, "writes", configuration.RateLimitWrites)
+		serverOpts = append(serverOpts, transport.WithRateLimiter{Limiter: limiter})
+	}
+	if configuration.MaxInFlight > 0 {
+		logger.Info("Limit of requests in flight is enabled", "max_in_flight", configuration.MaxInFlight)
+		serverOpts = append(serverOpts, transport.WithInFlightLimit{InFlight: ratelimit.NewInFlight(configuration.MaxInFlight)})
+	}
+
+	// If the server is a replica, keep the database in sync with the primary
+	var replica *replication.Replica
+	if configuration.ReplicaOf != "" {
+		logger.Info("Replica mode is enabled, replicating from primary", "primary", configuration.ReplicaOf)
+		replicaOpts := []replication.ReplicaOptions{replication.WithRetryInterval(configuration.ReplicationRetryInterval)}
+		if configuration.AuthEnabled {
+			replicaOpts = append(replicaOpts, replication.WithBasicAuth{Username: configuration.AuthAdminUsername, Password: configuration.AuthAdminPassword})
+		}
+		replica = replication.NewReplica(logger, database, strings.TrimSuffix(configuration.ReplicaOf, "/"), replicaOpts...)
+		replica.Start(ctx)
+		serverOpts = append(serverOpts, transport.WithReplica{Replica: replica})
+	}
 
 	// Start the HTTP server with the loaded configuration and database instance
 	httpServer := transport.NewServer(
 		logger,
 		*configuration.Port,
 		*configuration.HealthPort,
-		db,
+		database,
+		serverOpts...,
 	)
 
 	go func() {
-		if err := httpServer.StartHealth(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start health HTTP server", "error", err)
+		if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start HTTP server", "error", err)
 			cancel() // Cancel the context to trigger shutdown
 		}
 	}()
 
 	go func() {
-		if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start HTTP server", "error", err)
+		if err := httpServer.StartGRPC(); err != nil && err != grpc.ErrServerStopped {
+			logger.Error("Failed to start gRPC server", "error", err)
+			cancel() // Cancel the context to trigger shutdown
+		}
+	}()
+
+	go func() {
+		if err := httpServer.StartRESP(); err != nil && err != resp.ErrServerClosed {
+			logger.Error("Failed to start RESP server", "error", err)
+			cancel() // Cancel the context to trigger shutdown
+		}
+	}()
+
+	go func() {
+		if err := httpServer.StartMemcached(); err != nil && err != memcache.ErrServerClosed {
+			logger.Error("Failed to start memcached server", "error", err)
 			cancel() // Cancel the context to trigger shutdown
 		}
 	}()
@@ -61,7 +243,12 @@ func main() {
 	// Wait for shutdown signal and gracefully shut down the db and server
 	<-ctx.Done()
 	logger.Info("Received shutdown signal, shutting down...")
-	db.Close() // Close the in-memory database
+	httpServer.BeginShutdown() // Fail the readiness probe so the load balancers stop sending requests
+	time.Sleep(configuration.ShutdownDelay)
+	if replica != nil {
+		replica.Stop() // Stop applying operations before the database is closed
+	}
+	database.Close() // Close the in-memory database, leaving the cluster in cluster mode
 	if err := httpServer.Shutdown(); err != nil {
 		logger.Error("Error shutting down HTTP server", "error", err)
 		os.Exit(1) // Exit if server fails to shut down gracefully
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"os"
+)
+
+const usage = `Backs up and restores the data directory of a stopped MemoryDB server.
+
+Usage:
+  memdb-backup backup -data-dir <dir> [-file <backup>]
+  memdb-backup restore -data-dir <dir> [-file <backup>] [-mode replace|merge]
+
+The backup is written to stdout and read from stdin when -file is not set.
+`
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "backup":
+		err = backup(os.Args[2:])
+	case "restore":
+		err = restore(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// backup writes a backup of the data directory to the file or to stdout.
+func backup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "data directory of the server (DB_PATH)")
+	file := flags.String("file", "", "file the backup is written to, stdout if not set")
+	_ = flags.Parse(args)
+
+	if *dataDir == "" {
+		return fmt.Errorf("the data directory is required")
+	}
+	// do not create an empty data directory for a mistyped path
+	if _, err := os.Stat(*dataDir); err != nil {
+		return fmt.Errorf("failed to open data directory: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	database := openDB(*dataDir)
+	defer database.Close()
+
+	header, err := database.Backup(w)
+	if err != nil {
+		return err
+	}
+	log.Printf("backed up %d keys from %s", header.Keys, *dataDir)
+	return nil
+}
+
+// restore restores a backup read from the file or from stdin into the data directory.
+func restore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "data directory of the server (DB_PATH), created if it does not exist")
+	file := flags.String("file", "", "file the backup is read from, stdin if not set")
+	mode := flags.String("mode", string(enums.RestoreModeMerge), "replace the data of the directory or merge the backup into it")
+	_ = flags.Parse(args)
+
+	if *dataDir == "" {
+		return fmt.Errorf("the data directory is required")
+	}
+	restoreMode := enums.RestoreMode(*mode)
+	if !restoreMode.IsValid() {
+		return fmt.Errorf("invalid restore mode %s, it must be replace or merge", *mode)
+	}
+
+	var r io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return fmt.Errorf("failed to open backup file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	database := openDB(*dataDir)
+	defer database.Close()
+
+	keys, err := database.RestoreBackup(r, restoreMode)
+	if err != nil {
+		return err
+	}
+	log.Printf("restored %d keys into %s (%s)", keys, *dataDir, restoreMode)
+	return nil
+}
+
+// openDB loads the data directory. The logs go to stderr, since the backup may be written to stdout.
+func openDB(dataDir string) db.DBClient {
+	logger := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	return db.NewMemoryDB(logger, db.WithPersistenceEnabled(dataDir))
+}
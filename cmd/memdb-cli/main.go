@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"memorydb/internal/cli"
+	"memorydb/pkg/godb"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+func main() {
+	log.SetFlags(0)
+	url := flag.String("url", "http://localhost:8080", "URL of the server or of the sharding proxy")
+	version := flag.String("version", "v1", "version of the API")
+	format := flag.String("format", string(cli.FormatTable), "output format, table or json")
+	history := flag.String("history", defaultHistoryPath(), "file the history is saved to, empty to disable it")
+	flag.Parse()
+
+	if !cli.Format(*format).IsValid() {
+		log.Fatalf("invalid format %s, it must be table or json", *format)
+	}
+	client := godb.NewClient(strings.TrimSuffix(*url, "/"), *version)
+
+	// run a single command given in the arguments, or the commands of a script when stdin is not a terminal
+	if args := flag.Args(); len(args) > 0 {
+		c := cli.New(client, os.Stdout, cli.WithFormat(*format))
+		if err := c.Execute(strings.Join(quoteArgs(args), " ")); err != nil && !errors.Is(err, cli.ErrExit) {
+			log.Fatal(err)
+		}
+		return
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		c := cli.New(client, os.Stdout, cli.WithFormat(*format))
+		failed, err := c.Run(os.Stdin, os.Stderr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := interactive(client, cli.Format(*format), *url, *history); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// interactive reads the commands from the terminal with line editing, tab completion and history.
+func interactive(client godb.ApiClient, format cli.Format, url string, historyPath string) error {
+	history, err := cli.LoadHistory(historyPath, cli.DefaultHistorySize)
+	if err != nil {
+		return err
+	}
+
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	terminal := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "memdb> ")
+	terminal.AutoCompleteCallback = cli.Complete
+	terminal.History = history
+	if width, height, err := term.GetSize(fd); err == nil {
+		_ = terminal.SetSize(width, height)
+	}
+
+	c := cli.New(client, terminal, cli.WithFormat(format))
+	fmt.Fprintf(terminal, "Connected to %s. Type 'help' to list the commands, tab to complete them.\n", url)
+	for {
+		line, err := terminal.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break // Ctrl-D
+			}
+			return fmt.Errorf("failed to read command: %w", err)
+		}
+		if err := c.Execute(line); err != nil {
+			if errors.Is(err, cli.ErrExit) {
+				break
+			}
+			fmt.Fprintf(terminal, "error: %v\n", err)
+		}
+	}
+	return history.Save()
+}
+
+// quoteArgs quotes the command-line arguments that contain spaces, so they are parsed as a single word.
+func quoteArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\"'\\") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return quoted
+}
+
+// defaultHistoryPath returns the history file in the home directory of the user.
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".memdb_history")
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log"
+	"memorydb/internal/config"
+	"memorydb/internal/logger"
+	"memorydb/internal/proxy"
+	"memorydb/internal/transport"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	_ "go.uber.org/automaxprocs"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	configuration, err := config.LoadProxyConfig()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	logger := logger.NewLogger(configuration.Verbose)
+	logger.Info("Starting MemoryDB proxy", "version", "1.0.0")
+
+	// the configuration has already been validated
+	backends, _ := proxy.ParseBackends(configuration.Backends)
+	pool := proxy.NewPool(
+		logger,
+		backends,
+		proxy.WithHealthInterval(configuration.HealthInterval),
+		proxy.WithHealthTimeout(configuration.HealthTimeout),
+		proxy.WithFailureThreshold(configuration.FailureThreshold),
+		proxy.WithVirtualNodes(configuration.VirtualNodes),
+	)
+	pool.Start(ctx)
+	logger.Info("Proxying requests to the backends", "backends", len(backends))
+
+	httpServer := transport.NewProxyServer(logger, *configuration.Port, *configuration.HealthPort, pool)
+
+	go func() {
+		if err := httpServer.StartHealth(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start health HTTP server", "error", err)
+			cancel() // Cancel the context to trigger shutdown
+		}
+	}()
+
+	go func() {
+		if err := httpServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Failed to start HTTP server", "error", err)
+			cancel() // Cancel the context to trigger shutdown
+		}
+	}()
+
+	// Wait for shutdown signal and gracefully shut down the proxy
+	<-ctx.Done()
+	logger.Info("Received shutdown signal, shutting down...")
+	pool.Stop()
+	if err := httpServer.Shutdown(); err != nil {
+		logger.Error("Error shutting down HTTP server", "error", err)
+		os.Exit(1) // Exit if server fails to shut down gracefully
+	}
+	logger.Info("Shut down complete, exiting application")
+}
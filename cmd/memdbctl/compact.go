@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// compactLog rewrites the log with a single set operation per live key, per user and per API key, which replays to
+// the same store.
+// Without -out, the log of the data directory is replaced once the compacted log has been written.
+func compactLog(args []string) error {
+	flags, dataDir := newFlagSet("compact")
+	out := flags.String("out", "", "file the compacted log is written to, the log of the data directory is replaced if not set")
+	_ = flags.Parse(args)
+
+	f, err := openLog(*dataDir)
+	if err != nil {
+		return err
+	}
+	replayer, records, err := replayLog(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("refusing to compact an invalid operation log: %w", err)
+	}
+
+	// write to a temporary file next to the destination, so it is renamed into place only once it is complete
+	destination := *out
+	if destination == "" {
+		destination = db.LogFilePath(*dataDir)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(destination), filepath.Base(destination)+".compact-*")
+	if err != nil {
+		return fmt.Errorf("failed to create compacted log: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once it has been renamed
+
+	live := replayer.Records(time.Now())
+	users := replayer.Users()
+	apiKeys := replayer.APIKeys()
+	if err := writeCompacted(tmp, live, users, apiKeys); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync compacted log: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close compacted log: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to set permissions of compacted log: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), destination); err != nil {
+		return fmt.Errorf("failed to replace operation log: %w", err)
+	}
+
+	fmt.Printf("compacted %d records into %d records in %s\n", records, len(live)+len(users)+len(apiKeys), destination)
+	return nil
+}
+
+// writeCompacted writes a set operation per key, per user and per API key, as the database logs them.
+func writeCompacted(w io.Writer, records []db.BackupRecord, users []*db.AuthItem, apiKeys []*db.APIKeyItem) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	for _, record := range records {
+		op := &db.Operation{Command: enums.DBCommandSet, Key: record.Key, Time: record.Item.UpdatedAt, Item: record.Item}
+		if err := encoder.Encode(op); err != nil {
+			return fmt.Errorf("failed to write key %s to compacted log: %w", record.Key, err)
+		}
+	}
+	for _, user := range users {
+		op := &db.Operation{Command: enums.DBCommandUserSet, Time: time.Now(), User: user}
+		if err := encoder.Encode(op); err != nil {
+			return fmt.Errorf("failed to write user %s to compacted log: %w", user.Username, err)
+		}
+	}
+	for _, key := range apiKeys {
+		op := &db.Operation{Command: enums.DBCommandAPIKeySet, Time: key.CreatedAt, APIKey: key}
+		if err := encoder.Encode(op); err != nil {
+			return fmt.Errorf("failed to write API key %s to compacted log: %w", key.ID, err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write compacted log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// dumpLog replays the log and prints the final value of every key as newline-delimited JSON, in the same
+// format as the records of a backup.
+func dumpLog(args []string) error {
+	flags, dataDir := newFlagSet("dump")
+	expired := flags.Bool("expired", false, "include the keys whose TTL has expired")
+	_ = flags.Parse(args)
+
+	f, err := openLog(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	replayer, _, err := replayLog(f)
+	if err != nil {
+		return fmt.Errorf("failed to replay operation log: %w", err)
+	}
+
+	now := time.Now()
+	if *expired {
+		now = time.Time{} // no key expires before the zero time
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	encoder := json.NewEncoder(w)
+	for _, record := range replayer.Records(now) {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to write key %s: %w", record.Key, err)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"memorydb/internal/db"
+	"os"
+)
+
+const usage = `Inspects and maintains the operation log of the data directory of a stopped MemoryDB server.
+
+Usage:
+  memdbctl print   -data-dir <dir> [-key <pattern>] [-command <commands>] [-since <time>] [-until <time>]
+  memdbctl verify  -data-dir <dir>
+  memdbctl stats   -data-dir <dir> [-top <n>]
+  memdbctl compact -data-dir <dir> [-out <file>]
+  memdbctl dump    -data-dir <dir> [-expired]
+
+Run 'memdbctl <command> -h' for the options of a command.
+`
+
+// commands are the subcommands of the tool.
+var commands = map[string]func(args []string) error{
+	"print":   printLog,
+	"verify":  verifyLog,
+	"stats":   statsLog,
+	"compact": compactLog,
+	"dump":    dumpLog,
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := command(os.Args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newFlagSet returns the flags of a subcommand with the data directory flag, which every subcommand needs.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	dataDir := flags.String("data-dir", "", "data directory of the server (DB_PATH)")
+	return flags, dataDir
+}
+
+// openLog opens the operation log of the data directory for reading. It never creates the log.
+func openLog(dataDir string) (*os.File, error) {
+	if dataDir == "" {
+		return nil, errors.New("the data directory is required")
+	}
+	f, err := os.Open(db.LogFilePath(dataDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open operation log: %w", err)
+	}
+	return f, nil
+}
+
+// readLog calls fn with every operation of the log, stopping at the first error.
+func readLog(r io.Reader, fn func(reader *db.LogReader, op *db.Operation) error) error {
+	reader := db.NewLogReader(r)
+	for {
+		op, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(reader, op); err != nil {
+			return err
+		}
+	}
+}
+
+// replayLog rebuilds the store from the whole log and returns the number of operations applied.
+// It fails at the first operation that cannot be decoded or applied.
+func replayLog(r io.Reader) (*db.LogReplayer, int, error) {
+	replayer := db.NewLogReplayer()
+	var records int
+	err := readLog(r, func(reader *db.LogReader, op *db.Operation) error {
+		if err := replayer.Apply(op); err != nil {
+			return &db.LogError{Record: reader.Record(), Offset: reader.Offset(), Err: err}
+		}
+		records++
+		return nil
+	})
+	return replayer, records, err
+}
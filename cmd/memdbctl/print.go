@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/glob"
+	"os"
+	"strings"
+	"time"
+)
+
+// printLog prints the operations of the log that match the filters as newline-delimited JSON.
+func printLog(args []string) error {
+	flags, dataDir := newFlagSet("print")
+	key := flags.String("key", "", "glob pattern of the keys, such as user:*")
+	commandList := flags.String("command", "", "comma separated list of commands, such as set,remove")
+	sinceRaw := flags.String("since", "", "only the operations at or after this RFC 3339 time")
+	untilRaw := flags.String("until", "", "only the operations before this RFC 3339 time")
+	_ = flags.Parse(args)
+
+	filter := operationFilter{key: *key, commands: make(map[enums.DBCommand]bool)}
+	if *commandList != "" {
+		for _, name := range strings.Split(*commandList, ",") {
+			command, ok := enums.MappedCommands[strings.TrimSpace(name)]
+			if !ok {
+				return fmt.Errorf("unknown command %s", name)
+			}
+			filter.commands[command] = true
+		}
+	}
+	var err error
+	if filter.since, err = parseTime(*sinceRaw); err != nil {
+		return err
+	}
+	if filter.until, err = parseTime(*untilRaw); err != nil {
+		return err
+	}
+
+	f, err := openLog(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	encoder := json.NewEncoder(w)
+	return readLog(f, func(_ *db.LogReader, op *db.Operation) error {
+		if !filter.match(op) {
+			return nil
+		}
+		return encoder.Encode(op)
+	})
+}
+
+// operationFilter selects the operations printed by printLog. The zero values match every operation.
+type operationFilter struct {
+	key      string
+	commands map[enums.DBCommand]bool
+	since    time.Time
+	until    time.Time
+}
+
+// match reports whether the operation passes every filter.
+func (f operationFilter) match(op *db.Operation) bool {
+	if f.key != "" && !glob.Match(f.key, op.Key) {
+		return false
+	}
+	if len(f.commands) > 0 && !f.commands[op.Command] {
+		return false
+	}
+	if !f.since.IsZero() && op.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !op.Time.Before(f.until) {
+		return false
+	}
+	return true
+}
+
+// parseTime parses an RFC 3339 time, returning the zero time if it is empty.
+func parseTime(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, it must be in RFC 3339 format: %w", raw, err)
+	}
+	return t, nil
+}
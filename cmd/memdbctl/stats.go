@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// statsLog prints the number of operations of the log per command and of the keys with the most operations.
+func statsLog(args []string) error {
+	flags, dataDir := newFlagSet("stats")
+	top := flags.Int("top", 10, "number of keys with the most operations to print")
+	_ = flags.Parse(args)
+
+	f, err := openLog(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var (
+		records     int
+		first, last time.Time
+		perCommand  = make(map[enums.DBCommand]int)
+		perKey      = make(map[string]int)
+	)
+	err = readLog(f, func(_ *db.LogReader, op *db.Operation) error {
+		records++
+		if first.IsZero() || op.Time.Before(first) {
+			first = op.Time
+		}
+		if op.Time.After(last) {
+			last = op.Time
+		}
+		perCommand[op.Command]++
+		if op.User == nil && op.APIKey == nil { // the users and the API keys have no key
+			perKey[op.Key]++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read operation log after %d records: %w", records, err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat operation log: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "records\t%d\n", records)
+	fmt.Fprintf(w, "bytes\t%d\n", info.Size())
+	fmt.Fprintf(w, "keys\t%d\n", len(perKey))
+	if records > 0 {
+		fmt.Fprintf(w, "first\t%s\n", first.Format(time.RFC3339))
+		fmt.Fprintf(w, "last\t%s\n", last.Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(w, "\nCOMMAND\tRECORDS\n")
+	for _, entry := range sortedCounts(perCommand) {
+		fmt.Fprintf(w, "%s\t%d\n", entry.name, entry.count)
+	}
+
+	fmt.Fprintf(w, "\nKEY\tRECORDS\n")
+	for i, entry := range sortedCounts(perKey) {
+		if i == *top {
+			break
+		}
+		fmt.Fprintf(w, "%s\t%d\n", entry.name, entry.count)
+	}
+	return w.Flush()
+}
+
+// count is the number of operations of a command or key.
+type count struct {
+	name  string
+	count int
+}
+
+// sortedCounts returns the counts from the highest to the lowest, breaking ties by name.
+func sortedCounts[K ~string](counts map[K]int) []count {
+	sorted := make([]count, 0, len(counts))
+	for name, n := range counts {
+		sorted = append(sorted, count{name: string(name), count: n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// verifyLog checks that every operation of the log can be decoded and replayed, as the server does when it starts.
+func verifyLog(args []string) error {
+	flags, dataDir := newFlagSet("verify")
+	_ = flags.Parse(args)
+
+	f, err := openLog(*dataDir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	replayer, records, err := replayLog(f)
+	if err != nil {
+		return fmt.Errorf("invalid operation log after %d valid records: %w", records, err)
+	}
+	live := len(replayer.Records(time.Now()))
+	fmt.Printf("ok: %d records, %d live keys, %d expired keys\n", records, live, replayer.Len()-live)
+	return nil
+}
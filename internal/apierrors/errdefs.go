@@ -23,4 +23,93 @@ var (
 
 	// ErrKeyHasExpired is returned when a key has expired in the database.
 	ErrKeyHasExpired = NewAPIError("key_has_expired", "key has expired", http.StatusGone)
+
+	// ErrInsufficientStorage is returned when the memory limit is reached and no key can be evicted.
+	ErrInsufficientStorage = NewAPIError("insufficient_storage", "insufficient storage", http.StatusInsufficientStorage)
+
+	// ErrWrongType is returned when an operation is applied to a key holding a value of another type.
+	ErrWrongType = NewAPIError("wrong_type", "wrong type", http.StatusBadRequest)
+
+	// ErrGroupNotFound is returned when a consumer group does not exist in a stream.
+	ErrGroupNotFound = NewAPIError("group_not_found", "consumer group not found", http.StatusNotFound)
+
+	// ErrGroupAlreadyExists is returned when a consumer group is created with the name of an existing one.
+	ErrGroupAlreadyExists = NewAPIError("group_already_exists", "consumer group already exists", http.StatusConflict)
+
+	// ErrReadOnly is returned when a write is sent to a read-only replica.
+	ErrReadOnly = NewAPIError("read_only", "read-only replica", http.StatusForbidden)
+
+	// ErrResyncRequired is returned when a replica resumes replication from an offset that is no longer available.
+	ErrResyncRequired = NewAPIError("resync_required", "full synchronization required", http.StatusGone)
+
+	// ErrNotReplica is returned when a server that is not a replica is promoted.
+	ErrNotReplica = NewAPIError("not_replica", "the server is not a replica", http.StatusConflict)
+
+	// ErrClusterUnavailable is returned when a write cannot be committed because the cluster has no leader.
+	ErrClusterUnavailable = NewAPIError("cluster_unavailable", "cluster unavailable", http.StatusServiceUnavailable)
+
+	// ErrMoved is returned when the slot of the key is owned by another node, named in the redirect.
+	ErrMoved = NewAPIError("moved", "the slot of the key is served by another node", http.StatusTemporaryRedirect)
+
+	// ErrAsk is returned when the slot of the key is migrating and the key must be requested to the target once.
+	ErrAsk = NewAPIError("ask", "the slot of the key is migrating to another node", http.StatusTemporaryRedirect)
+
+	// ErrCrossShard is returned when the keys of an atomic batch are not served by the same node.
+	ErrCrossShard = NewAPIError("cross_shard", "the keys of the request are not served by the same node", http.StatusBadRequest)
+
+	// ErrTopologyConflict is returned when a topology or a slot migration is not consistent with the topology of the node.
+	ErrTopologyConflict = NewAPIError("topology_conflict", "topology conflict", http.StatusConflict)
+
+	// ErrMigrationFailed is returned when the keys of a slot cannot be moved to the target node.
+	ErrMigrationFailed = NewAPIError("migration_failed", "slot migration failed", http.StatusBadGateway)
+
+	// ErrUnauthorized is returned when a protected endpoint is requested without a valid access token.
+	ErrUnauthorized = NewAPIError("unauthorized", "a valid access token is required", http.StatusUnauthorized)
+
+	// ErrInvalidCredentials is returned when the username or the password of a user or of the administrator are not valid.
+	ErrInvalidCredentials = NewAPIError("invalid_credentials", "invalid username or password", http.StatusUnauthorized)
+
+	// ErrInvalidToken is returned when a token is malformed, expired, revoked or not of the expected type.
+	ErrInvalidToken = NewAPIError("invalid_token", "invalid token", http.StatusUnauthorized)
+
+	// ErrUserAlreadyExists is returned when a user is registered with the username of another user.
+	ErrUserAlreadyExists = NewAPIError("user_already_exists", "user already exists", http.StatusConflict)
+
+	// ErrInvalidAPIKey is returned when an API key is malformed, unknown or revoked.
+	ErrInvalidAPIKey = NewAPIError("invalid_api_key", "invalid API key", http.StatusUnauthorized)
+
+	// ErrAPIKeyNotFound is returned when an API key that does not exist is revoked.
+	ErrAPIKeyNotFound = NewAPIError("api_key_not_found", "API key not found", http.StatusNotFound)
+
+	// ErrAPIKeySubjectConflict is returned when an API key is created with the subject of another API key.
+	ErrAPIKeySubjectConflict = NewAPIError("api_key_subject_conflict", "the subject is already used by another API key", http.StatusConflict)
+
+	// ErrNamespaceNotFound is returned when a namespace does not exist.
+	ErrNamespaceNotFound = NewAPIError("namespace_not_found", "namespace not found", http.StatusNotFound)
+
+	// ErrNamespaceAlreadyExists is returned when a namespace is created with the name of an existing namespace.
+	ErrNamespaceAlreadyExists = NewAPIError("namespace_already_exists", "namespace already exists", http.StatusConflict)
+
+	// ErrRateLimited is returned when a client exceeds its rate of reads or writes.
+	ErrRateLimited = NewAPIError("rate_limited", "too many requests, retry later", http.StatusTooManyRequests)
+
+	// ErrOverloaded is returned when the server is serving as many requests as it can at once.
+	ErrOverloaded = NewAPIError("overloaded", "the server is overloaded, retry later", http.StatusServiceUnavailable)
+
+	// ErrForbidden is returned when the rules of the API key of the request do not allow it.
+	ErrForbidden = NewAPIError("forbidden", "the API key is not allowed to access the key", http.StatusForbidden)
+
+	// ErrBackendUnavailable is returned by the proxy when the backend of the request cannot be reached.
+	ErrBackendUnavailable = NewAPIError("backend_unavailable", "backend unavailable", http.StatusBadGateway)
+
+	// ErrNoBackends is returned by the proxy when every backend is out of rotation.
+	ErrNoBackends = NewAPIError("no_backends", "no backend available", http.StatusServiceUnavailable)
+
+	// ErrVersionMismatch is returned when a write conditional on the version of an item is not applied, because the
+	// item was written since it was read or does not exist.
+	ErrVersionMismatch = NewAPIError("version_mismatch", "version mismatch", http.StatusPreconditionFailed)
+
+	// ErrRequestTooLarge is returned when a part of a request streamed by the client, such as a command of a pipeline,
+	// is larger than the server accepts.
+	ErrRequestTooLarge = NewAPIError("request_too_large", "request too large", http.StatusRequestEntityTooLarge)
 )
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidRule is returned when an API key is created with a rule that is not valid.
+var ErrInvalidRule = errors.New("invalid access rule")
+
+// FullAccess are the rules of the users, who can read and write every key of the database and of the namespaces.
+var FullAccess = []db.ACLRule{{Pattern: "*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}, Namespace: "*"}}
+
+// Principal is the identity a request is authenticated as, with the rules of the keys it can access.
+//
+// The pattern of a rule is either a whole key or a key prefix followed by `*`, so `billing:*` matches every key
+// that starts with `billing:` and `*` matches every key. A rule only matches the keys of the database, unless it
+// has a namespace: the rule with the namespace billing and the pattern `*` matches every key of the namespace billing,
+// and the namespace `*` matches the keys of the database and of every namespace.
+type Principal struct {
+	Name      string       // username of the user, or name of the API key
+	Rules     []db.ACLRule // rules of the keys the principal can access
+	Namespace string       // namespace of the keys the principal accesses, empty for the keys of the database
+}
+
+// InNamespace returns a copy of the principal that accesses the keys of the namespace.
+func (p *Principal) InNamespace(namespace string) *Principal {
+	principal := *p
+	principal.Namespace = namespace
+	return &principal
+}
+
+// Allows reports whether the principal has the permission on the key.
+func (p *Principal) Allows(permission enums.Permission, key string) bool {
+	for _, rule := range p.Rules {
+		if p.grants(rule, permission) && matchRule(rule.Pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPattern reports whether the principal has the permission on every key that matches the glob pattern,
+// as the ones of the keyspace events and the pub/sub subscriptions. An empty pattern matches every key.
+//
+// The keys of a pattern are only known to start with its literal prefix, up to its first special character,
+// so the pattern is allowed if a rule covers every key with that prefix.
+func (p *Principal) AllowsPattern(permission enums.Permission, pattern string) bool {
+	wildcard := strings.IndexAny(pattern, `*?[\`)
+	if pattern != "" && wildcard < 0 {
+		return p.Allows(permission, pattern)
+	}
+
+	literal := pattern
+	if wildcard >= 0 {
+		literal = pattern[:wildcard]
+	}
+	for _, rule := range p.Rules {
+		prefix, isPrefix := strings.CutSuffix(rule.Pattern, "*")
+		if p.grants(rule, permission) && isPrefix && strings.HasPrefix(literal, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// grants reports whether the rule applies to the namespace of the principal and has the permission.
+func (p *Principal) grants(rule db.ACLRule, permission enums.Permission) bool {
+	return (rule.Namespace == "*" || rule.Namespace == p.Namespace) && slices.Contains(rule.Permissions, permission)
+}
+
+// ValidateRules checks that there is at least one rule, and that every rule has a valid pattern and permissions.
+func ValidateRules(rules []db.ACLRule) error {
+	if len(rules) == 0 {
+		return fmt.Errorf("%w: at least one rule is required", ErrInvalidRule)
+	}
+	for _, rule := range rules {
+		prefix, _ := strings.CutSuffix(rule.Pattern, "*")
+		if rule.Pattern == "" || strings.ContainsAny(prefix, `*?[\`) {
+			return fmt.Errorf("%w: pattern '%s' must be a key or a key prefix followed by *", ErrInvalidRule, rule.Pattern)
+		}
+		if rule.Namespace != "" && rule.Namespace != "*" && !db.IsValidNamespaceName(rule.Namespace) {
+			return fmt.Errorf("%w: namespace '%s' must be a namespace name or *", ErrInvalidRule, rule.Namespace)
+		}
+		if len(rule.Permissions) == 0 {
+			return fmt.Errorf("%w: pattern '%s' has no permissions", ErrInvalidRule, rule.Pattern)
+		}
+		for _, permission := range rule.Permissions {
+			if !permission.IsValid() {
+				return fmt.Errorf("%w: unknown permission '%s', must be read or write", ErrInvalidRule, permission)
+			}
+		}
+	}
+	return nil
+}
+
+// matchRule reports whether the key matches the pattern of a rule.
+func matchRule(pattern, key string) bool {
+	if prefix, isPrefix := strings.CutSuffix(pattern, "*"); isPrefix {
+		return strings.HasPrefix(key, prefix)
+	}
+	return pattern == key
+}
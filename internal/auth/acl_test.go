@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+var (
+	readWrite = []enums.Permission{enums.PermissionRead, enums.PermissionWrite}
+	readOnly  = []enums.Permission{enums.PermissionRead}
+)
+
+type ACLSuite struct {
+	principal *Principal
+	suite.Suite
+}
+
+func (s *ACLSuite) SetupTest() {
+	s.principal = &Principal{Name: "billing", Rules: []db.ACLRule{
+		{Pattern: "billing:*", Permissions: readWrite},
+		{Pattern: "config:*", Permissions: readOnly},
+		{Pattern: "feature-flags", Permissions: readOnly},
+	}}
+}
+
+func (s *ACLSuite) TestAllows() {
+	s.True(s.principal.Allows(enums.PermissionWrite, "billing:invoice:1"))
+	s.True(s.principal.Allows(enums.PermissionRead, "billing:"))
+	s.True(s.principal.Allows(enums.PermissionRead, "config:timeout"))
+	s.False(s.principal.Allows(enums.PermissionWrite, "config:timeout"))
+	s.True(s.principal.Allows(enums.PermissionRead, "feature-flags"))
+	s.False(s.principal.Allows(enums.PermissionRead, "feature-flags:new"), "a pattern without * should only match its key")
+	s.False(s.principal.Allows(enums.PermissionRead, "billing"))
+	s.False(s.principal.Allows(enums.PermissionRead, "users:1"))
+
+	full := &Principal{Name: "alice", Rules: FullAccess}
+	s.True(full.Allows(enums.PermissionWrite, "users:1"))
+}
+
+func (s *ACLSuite) TestAllowsPattern() {
+	s.True(s.principal.AllowsPattern(enums.PermissionRead, "billing:*"))
+	s.True(s.principal.AllowsPattern(enums.PermissionRead, "billing:invoice:?"))
+	s.True(s.principal.AllowsPattern(enums.PermissionRead, "config:[ab]*"))
+	s.True(s.principal.AllowsPattern(enums.PermissionRead, "feature-flags"), "a pattern without wildcards is a key")
+	s.False(s.principal.AllowsPattern(enums.PermissionWrite, "config:*"))
+	s.False(s.principal.AllowsPattern(enums.PermissionRead, "billing*"), "billing* also matches keys outside billing:")
+	s.False(s.principal.AllowsPattern(enums.PermissionRead, "*"))
+	s.False(s.principal.AllowsPattern(enums.PermissionRead, ""), "an empty pattern matches every key")
+	s.False(s.principal.AllowsPattern(enums.PermissionRead, `billing\:*`), "escapes end the literal prefix")
+
+	full := &Principal{Name: "alice", Rules: FullAccess}
+	s.True(full.AllowsPattern(enums.PermissionRead, ""))
+}
+
+func (s *ACLSuite) TestNamespace() {
+	principal := &Principal{Name: "sessions", Rules: []db.ACLRule{
+		{Pattern: "*", Permissions: readWrite, Namespace: "sessions"},
+		{Pattern: "config:*", Permissions: readOnly, Namespace: "billing"},
+		{Pattern: "tenant/x", Permissions: readOnly},
+	}}
+
+	sessions := principal.InNamespace("sessions")
+	s.True(sessions.Allows(enums.PermissionWrite, "user:1"))
+	s.True(sessions.AllowsPattern(enums.PermissionRead, ""), "an empty pattern matches every key of the namespace")
+	s.True(sessions.AllowsPattern(enums.PermissionRead, "user:*"))
+	s.Empty(principal.Namespace, "the principal should not be changed")
+	s.False(principal.Allows(enums.PermissionRead, "user:1"), "the rules of a namespace should not match the keys of the database")
+
+	billing := principal.InNamespace("billing")
+	s.True(billing.Allows(enums.PermissionRead, "config:timeout"))
+	s.False(billing.Allows(enums.PermissionWrite, "config:timeout"))
+	s.False(billing.AllowsPattern(enums.PermissionRead, ""))
+	s.False(billing.Allows(enums.PermissionRead, "invoice:1"))
+
+	// the namespace is not part of the key, so the keys of the database cannot collide with the ones of a namespace
+	s.True(principal.Allows(enums.PermissionRead, "tenant/x"))
+	s.False(principal.InNamespace("tenant").Allows(enums.PermissionRead, "x"))
+	s.False(principal.Allows(enums.PermissionRead, "sessions/user:1"))
+
+	full := &Principal{Name: "alice", Rules: FullAccess}
+	s.True(full.InNamespace("billing").Allows(enums.PermissionWrite, "invoice:1"))
+}
+
+func (s *ACLSuite) TestValidateRules() {
+	s.NoError(ValidateRules(s.principal.Rules))
+	s.NoError(ValidateRules(FullAccess))
+
+	for name, rules := range map[string][]db.ACLRule{
+		"no rules":           nil,
+		"empty pattern":      {{Pattern: "", Permissions: readOnly}},
+		"inner wildcard":     {{Pattern: "billing:*:invoices", Permissions: readOnly}},
+		"glob pattern":       {{Pattern: "billing:?", Permissions: readOnly}},
+		"no permissions":     {{Pattern: "billing:*"}},
+		"unknown permission": {{Pattern: "billing:*", Permissions: []enums.Permission{"admin"}}},
+		"invalid namespace":  {{Pattern: "*", Permissions: readOnly, Namespace: "billing/*"}},
+	} {
+		s.ErrorIs(ValidateRules(rules), ErrInvalidRule, name)
+	}
+}
+
+func TestACLSuite(t *testing.T) {
+	suite.Run(t, new(ACLSuite))
+}
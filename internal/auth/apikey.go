@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"memorydb/internal/certs"
+	"memorydb/internal/db"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeySecretLength is the number of random bytes of the secret of the API keys.
+	apiKeySecretLength = 32
+)
+
+var (
+	// ErrInvalidAPIKey is returned when an API key is malformed, unknown or revoked.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrAPIKeyNotFound is returned when an API key that does not exist is revoked.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	// ErrSubjectAlreadyUsed is returned when an API key is created with the subject of another API key.
+	ErrSubjectAlreadyUsed = errors.New("the subject is already used by another API key")
+
+	// ErrUnknownIdentity is returned when no API key has the identity of a client certificate as subject.
+	ErrUnknownIdentity = errors.New("no API key has the identity of the certificate")
+)
+
+// CreateAPIKey stores a new API key with the rules and returns it with its key, which has the form <id>.<secret>.
+// If subject is not empty, the client certificates with that identity are authenticated as the key too.
+//
+// Only the hash of the secret is stored, so the key cannot be read again once it is returned.
+func (m *Manager) CreateAPIKey(name, subject string, rules []db.ACLRule) (*db.APIKeyItem, string, error) {
+	if err := ValidateRules(rules); err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, apiKeySecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate secret of API key %s: %w", name, err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+
+	item := &db.APIKeyItem{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Subject:   subject,
+		Hash:      hashSecret(encoded),
+		Rules:     rules,
+		CreatedAt: m.clock(),
+	}
+	m.registerMu.Lock()
+	defer m.registerMu.Unlock()
+
+	if subject != "" {
+		if _, err := m.keyOfSubject(subject); err == nil {
+			return nil, "", ErrSubjectAlreadyUsed
+		}
+	}
+	if err := m.store.SetAPIKey(item); err != nil {
+		return nil, "", fmt.Errorf("failed to store API key %s: %w", name, err)
+	}
+	m.logger.Info("created API key", "id", item.ID, "name", name, "subject", subject)
+	return item, item.ID + "." + encoded, nil
+}
+
+// APIKeys returns the API keys, without their secrets.
+func (m *Manager) APIKeys() []*db.APIKeyItem {
+	return m.store.APIKeys()
+}
+
+// RevokeAPIKey removes the API key with the ID, so it can no longer be used.
+func (m *Manager) RevokeAPIKey(id string) error {
+	if err := m.store.RemoveAPIKey(id); errors.Is(err, db.ErrAPIKeyNotFound) {
+		return ErrAPIKeyNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to revoke API key %s: %w", id, err)
+	}
+	m.logger.Info("revoked API key", "id", id)
+	return nil
+}
+
+// AuthenticateAPIKey verifies an API key and returns the principal of its name and rules.
+func (m *Manager) AuthenticateAPIKey(key string) (*Principal, error) {
+	id, secret, found := strings.Cut(key, ".")
+	if !found || id == "" || secret == "" {
+		return nil, fmt.Errorf("%w: malformed key", ErrInvalidAPIKey)
+	}
+
+	item, err := m.store.GetAPIKey(id)
+	if errors.Is(err, db.ErrAPIKeyNotFound) {
+		return nil, fmt.Errorf("%w: unknown or revoked key", ErrInvalidAPIKey)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get API key %s: %w", id, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(item.Hash)) != 1 {
+		return nil, fmt.Errorf("%w: unknown or revoked key", ErrInvalidAPIKey)
+	}
+	return &Principal{Name: item.Name, Rules: item.Rules}, nil
+}
+
+// AuthenticateCertificate returns the principal of the API key whose subject is the identity of a client
+// certificate, which must have been verified by the TLS handshake.
+func (m *Manager) AuthenticateCertificate(identity string) (*Principal, error) {
+	if identity == "" {
+		return nil, fmt.Errorf("%w: the certificate has no identity", ErrUnknownIdentity)
+	}
+	item, err := m.keyOfSubject(identity)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Name: item.Name, Rules: item.Rules}, nil
+}
+
+// AuthenticatePassword authenticates the credentials of the protocols whose clients send a username and a password:
+// the username default authenticates the password as an API key, as the clients of Redis send it when they only
+// have a password, and the other usernames authenticate a user.
+func AuthenticatePassword(manager AuthManager, username, password string) (*Principal, error) {
+	if username == "default" {
+		return manager.AuthenticateAPIKey(password)
+	}
+	return manager.AuthenticateUser(username, password)
+}
+
+// AuthenticateTLS returns the principal of the verified client certificate of a TLS connection, or nil if the
+// connection has no verified certificate or no API key has its identity.
+func AuthenticateTLS(manager AuthManager, state *tls.ConnectionState) (*Principal, error) {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return nil, nil
+	}
+	principal, err := manager.AuthenticateCertificate(certs.Identity(state.VerifiedChains[0][0]))
+	if errors.Is(err, ErrUnknownIdentity) {
+		return nil, nil
+	}
+	return principal, err
+}
+
+// keyOfSubject returns the API key with the subject. There are few API keys, so they are searched one by one.
+func (m *Manager) keyOfSubject(subject string) (*db.APIKeyItem, error) {
+	for _, item := range m.store.APIKeys() {
+		if item.Subject == subject {
+			return item, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownIdentity, subject)
+}
+
+// hashSecret returns the hex encoded SHA-256 of the secret of an API key. The secrets are random, so they do not
+// need a slow hash such as bcrypt, which would be paid by every request.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
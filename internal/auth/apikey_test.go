@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"strings"
+)
+
+func (s *AuthSuite) TestAPIKeys() {
+	rules := []db.ACLRule{{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead}}}
+	_, _, err := s.manager.CreateAPIKey("billing", "", nil)
+	s.ErrorIs(err, ErrInvalidRule)
+
+	item, key, err := s.manager.CreateAPIKey("billing", "", rules)
+	s.Require().NoError(err)
+	s.True(strings.HasPrefix(key, item.ID+"."))
+	s.NotContains(item.Hash, strings.TrimPrefix(key, item.ID+"."), "only the hash of the secret should be stored")
+	s.Equal(s.now, item.CreatedAt)
+	s.Len(s.manager.APIKeys(), 1)
+
+	principal, err := s.manager.AuthenticateAPIKey(key)
+	s.Require().NoError(err)
+	s.Equal("billing", principal.Name)
+	s.True(principal.Allows(enums.PermissionRead, "billing:1"))
+	s.False(principal.Allows(enums.PermissionWrite, "billing:1"))
+
+	for name, invalid := range map[string]string{
+		"empty":          "",
+		"no secret":      item.ID,
+		"unknown id":     "unknown." + strings.TrimPrefix(key, item.ID+"."),
+		"wrong secret":   item.ID + ".wrong",
+		"access token":   "a.b.c",
+		"missing id":     "." + strings.TrimPrefix(key, item.ID+"."),
+		"trailing point": item.ID + ".",
+	} {
+		_, err := s.manager.AuthenticateAPIKey(invalid)
+		s.ErrorIs(err, ErrInvalidAPIKey, name)
+	}
+
+	s.Require().NoError(s.manager.RevokeAPIKey(item.ID))
+	_, err = s.manager.AuthenticateAPIKey(key)
+	s.ErrorIs(err, ErrInvalidAPIKey, "a revoked key should not authenticate requests")
+	s.ErrorIs(s.manager.RevokeAPIKey(item.ID), ErrAPIKeyNotFound)
+}
+
+func (s *AuthSuite) TestAuthenticateAdmin() {
+	s.NoError(s.manager.AuthenticateAdmin("admin", "admin-password"))
+	s.ErrorIs(s.manager.AuthenticateAdmin("admin", "wrong"), ErrInvalidCredentials)
+	s.ErrorIs(s.manager.AuthenticateAdmin("alice", "secret"), ErrInvalidCredentials, "the users should not be administrators")
+}
+
+func (s *AuthSuite) TestAuthenticateCertificate() {
+	rules := []db.ACLRule{{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead}}}
+	_, _, err := s.manager.CreateAPIKey("billing", "billing-service", rules)
+	s.Require().NoError(err)
+	_, _, err = s.manager.CreateAPIKey("billing-copy", "billing-service", rules)
+	s.ErrorIs(err, ErrSubjectAlreadyUsed)
+
+	principal, err := s.manager.AuthenticateCertificate("billing-service")
+	s.Require().NoError(err)
+	s.Equal("billing", principal.Name)
+	s.True(principal.Allows(enums.PermissionRead, "billing:1"))
+
+	_, err = s.manager.AuthenticateCertificate("unknown")
+	s.ErrorIs(err, ErrUnknownIdentity)
+	_, err = s.manager.AuthenticateCertificate("")
+	s.ErrorIs(err, ErrUnknownIdentity, "a certificate without identity should not match the keys without subject")
+}
@@ -0,0 +1,393 @@
+/*
+The package auth implements the authentication of the users of the API.
+
+Users are registered by the administrator, whose credentials come from the configuration, and stored in the
+database with their password hashed with bcrypt. A user that logs in receives a pair of JWTs signed with the
+secret of the server: a short-lived access token, sent with the requests of the protected endpoints, and a
+refresh token, which is exchanged for a new pair once the access token expires.
+
+Every login starts a session, whose ID is carried by its tokens and stored with the user until it expires, so a user
+can be logged in from several clients at once. Refreshing the tokens replaces the ID of the session, which revokes
+its tokens issued before, and logging out removes it. Since the sessions are stored in the database, the
+revocations are persisted and replicated as any other write.
+
+The administrator can also issue API keys, which do not expire and carry the rules of the keys they can access,
+such as read and write on `billing:*` and only read on `config:*`. A request authenticated with an API key is
+only served if its rules allow it, while the users can access every key.
+*/
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/db"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// Ensure Manager implements AuthManager interface
+	_ AuthManager = (*Manager)(nil)
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute // default lifetime of the access tokens
+	defaultRefreshTokenTTL = 24 * time.Hour   // default lifetime of the refresh tokens
+
+	// maxPasswordLength is the maximum length of a password in bytes, bcrypt does not hash longer passwords.
+	maxPasswordLength = 72
+)
+
+var (
+	// ErrInvalidCredentials is returned when the username or the password are not valid.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+
+	// ErrUserAlreadyExists is returned when a user is registered with the username of another user.
+	ErrUserAlreadyExists = errors.New("user already exists")
+
+	// ErrPasswordTooLong is returned when a user is registered with a password longer than bcrypt supports.
+	ErrPasswordTooLong = fmt.Errorf("the password cannot be longer than %d bytes", maxPasswordLength)
+
+	// ErrInvalidToken is returned when a token is malformed, expired, revoked or not of the expected type.
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// AuthManager defines the interface of the authentication of the users.
+type AuthManager interface {
+	// Register stores a new user. Only the administrator can register users.
+	Register(adminUser, adminPassword, username, password string) error
+
+	// Login checks the credentials of the user and starts a new session, keeping the other sessions of the user.
+	Login(username, password string) (*Tokens, error)
+
+	// RefreshToken exchanges a refresh token for a new pair of tokens, revoking the tokens of the session.
+	RefreshToken(token string) (*Tokens, error)
+
+	// Logout ends the session of the token, revoking its access and refresh tokens.
+	Logout(token string) error
+
+	// Authenticate verifies an access token and returns its claims.
+	Authenticate(token string) (*Claims, error)
+
+	// AuthenticateUser checks the credentials of a user and returns its principal, without starting a session.
+	AuthenticateUser(username, password string) (*Principal, error)
+
+	// AuthenticateAdmin checks the credentials of the administrator.
+	AuthenticateAdmin(username, password string) error
+
+	// CreateAPIKey stores a new API key with the rules and returns it with its key, which is only returned once.
+	// The client certificates with the subject as identity are authenticated as the key, if subject is not empty.
+	CreateAPIKey(name, subject string, rules []db.ACLRule) (*db.APIKeyItem, string, error)
+
+	// APIKeys returns the API keys, without their secrets.
+	APIKeys() []*db.APIKeyItem
+
+	// RevokeAPIKey removes an API key, so it can no longer be used.
+	RevokeAPIKey(id string) error
+
+	// AuthenticateAPIKey verifies an API key and returns the principal of its rules.
+	AuthenticateAPIKey(key string) (*Principal, error)
+
+	// AuthenticateCertificate returns the principal of the API key of the identity of a verified client certificate.
+	AuthenticateCertificate(identity string) (*Principal, error)
+}
+
+// Store is where the users and the API keys are stored, which is the database.
+type Store interface {
+	GetUser(username string) (*db.AuthItem, error)
+	SetUser(user *db.AuthItem) error
+	GetAPIKey(id string) (*db.APIKeyItem, error)
+	SetAPIKey(key *db.APIKeyItem) error
+	RemoveAPIKey(id string) error
+	APIKeys() []*db.APIKeyItem
+}
+
+// Tokens is the pair of tokens of a session.
+type Tokens struct {
+	AccessToken  string        // token sent with the requests of the protected endpoints
+	RefreshToken string        // token exchanged for a new pair of tokens
+	ExpiresIn    time.Duration // lifetime of the access token
+}
+
+// ManagerOptions defines an interface for applying options to the Manager.
+type ManagerOptions interface {
+	apply(*Manager)
+}
+
+// WithAccessTokenTTL sets the lifetime of the access tokens.
+type WithAccessTokenTTL time.Duration
+
+func (o WithAccessTokenTTL) apply(m *Manager) {
+	m.accessTTL = time.Duration(o)
+}
+
+// WithRefreshTokenTTL sets the lifetime of the refresh tokens, which is how long a session lasts without logging in.
+type WithRefreshTokenTTL time.Duration
+
+func (o WithRefreshTokenTTL) apply(m *Manager) {
+	m.refreshTTL = time.Duration(o)
+}
+
+// WithClock sets the source of the current time used to issue and verify the tokens.
+type WithClock func() time.Time
+
+func (o WithClock) apply(m *Manager) {
+	m.clock = o
+}
+
+// Manager implements AuthManager on top of the users of the database.
+type Manager struct {
+	logger        *slog.Logger
+	store         Store
+	secret        []byte                            // secret the tokens are signed with
+	adminUsername string                            // username of the administrator
+	adminPassword []byte                            // bcrypt hash of the password of the administrator
+	adminVerified atomic.Pointer[[sha256.Size]byte] // SHA-256 of the password of the administrator once verified
+	accessTTL     time.Duration                     // lifetime of the access tokens
+	refreshTTL    time.Duration                     // lifetime of the refresh tokens
+	clock         func() time.Time                  // source of the current time
+	registerMu    sync.Mutex                        // serializes the registrations, so a username or a subject cannot be registered twice
+	sessionMu     sync.Mutex                        // serializes the changes of the sessions, so concurrent logins keep each other
+}
+
+// NewManager creates a manager that stores the users in the store and signs the tokens with the secret.
+// The administrator is not stored: its credentials are only used to register users.
+func NewManager(logger *slog.Logger, store Store, secret string, adminUsername, adminPassword string, opts ...ManagerOptions) (*Manager, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("the secret of the tokens cannot be empty")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash the password of the administrator: %w", err)
+	}
+
+	m := &Manager{
+		logger:        logger,
+		store:         store,
+		secret:        []byte(secret),
+		adminUsername: adminUsername,
+		adminPassword: hash,
+		accessTTL:     defaultAccessTokenTTL,
+		refreshTTL:    defaultRefreshTokenTTL,
+		clock:         time.Now,
+	}
+	for _, opt := range opts {
+		opt.apply(m)
+	}
+	return m, nil
+}
+
+// Register stores a new user with its password hashed, once the credentials of the administrator are checked.
+func (m *Manager) Register(adminUser, adminPassword, username, password string) error {
+	if err := m.AuthenticateAdmin(adminUser, adminPassword); err != nil {
+		return err
+	}
+	if len(password) > maxPasswordLength {
+		return ErrPasswordTooLong
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash the password of user %s: %w", username, err)
+	}
+
+	m.registerMu.Lock()
+	defer m.registerMu.Unlock()
+
+	if _, err := m.store.GetUser(username); err == nil {
+		return ErrUserAlreadyExists
+	} else if !errors.Is(err, db.ErrUserNotFound) {
+		return fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+
+	if err := m.store.SetUser(&db.AuthItem{UUID: uuid.New(), Username: username, Password: string(hash)}); err != nil {
+		return fmt.Errorf("failed to store user %s: %w", username, err)
+	}
+	m.logger.Info("registered user", "username", username)
+	return nil
+}
+
+// AuthenticateAdmin checks the credentials of the administrator.
+//
+// The other nodes send the credentials of the administrator with every request they forward, so once the password
+// is verified with bcrypt its SHA-256 is kept and the next requests with the same password skip bcrypt. The wrong
+// passwords are still checked with bcrypt, so guessing the password is as slow as before.
+func (m *Manager) AuthenticateAdmin(username, password string) error {
+	validAdmin := subtle.ConstantTimeCompare([]byte(username), []byte(m.adminUsername)) == 1
+	sum := sha256.Sum256([]byte(password))
+	if verified := m.adminVerified.Load(); verified != nil && subtle.ConstantTimeCompare(sum[:], verified[:]) == 1 {
+		if !validAdmin {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	// the password is compared even if the username is wrong, so both take the same time
+	if bcrypt.CompareHashAndPassword(m.adminPassword, []byte(password)) != nil || !validAdmin {
+		return ErrInvalidCredentials
+	}
+	m.adminVerified.Store(&sum)
+	return nil
+}
+
+// Login checks the credentials of the user and returns the tokens of a new session.
+func (m *Manager) Login(username, password string) (*Tokens, error) {
+	if _, err := m.AuthenticateUser(username, password); err != nil {
+		return nil, err
+	}
+	return m.startSession(username, "")
+}
+
+// AuthenticateUser checks the credentials of a user and returns its principal, which can access every key. It
+// authenticates the clients of the listeners that keep a connection instead of sending a token with each request.
+func (m *Manager) AuthenticateUser(username, password string) (*Principal, error) {
+	user, err := m.store.GetUser(username)
+	if errors.Is(err, db.ErrUserNotFound) {
+		return nil, ErrInvalidCredentials
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Principal{Name: username, Rules: FullAccess}, nil
+}
+
+// RefreshToken verifies the refresh token and returns the tokens of a new session that replaces its session,
+// so the refresh token can only be used once.
+func (m *Manager) RefreshToken(token string) (*Tokens, error) {
+	claims, _, err := m.verify(token, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+	return m.startSession(claims.Subject, claims.Session)
+}
+
+// Logout ends the session of the token, which can be its access or its refresh token. The other sessions of
+// the user are kept.
+func (m *Manager) Logout(token string) error {
+	claims, _, err := m.verify(token, "")
+	if err != nil {
+		return err
+	}
+
+	m.sessionMu.Lock()
+	defer m.sessionMu.Unlock()
+
+	user, err := m.currentSession(claims.Subject, claims.Session)
+	if err != nil {
+		return err
+	}
+	delete(user.Sessions, claims.Session)
+	if err := m.store.SetUser(user); err != nil {
+		return fmt.Errorf("failed to end session of user %s: %w", user.Username, err)
+	}
+	return nil
+}
+
+// Authenticate verifies an access token and returns its claims.
+func (m *Manager) Authenticate(token string) (*Claims, error) {
+	claims, _, err := m.verify(token, TokenTypeAccess)
+	return claims, err
+}
+
+// verify verifies the token and that its session is a session of its user, and returns its claims and its user.
+// Any type of token is accepted if tokenType is empty.
+func (m *Manager) verify(token string, tokenType TokenType) (*Claims, *db.AuthItem, error) {
+	claims, err := parseToken(token, m.secret, m.clock())
+	if err != nil {
+		return nil, nil, err
+	}
+	if tokenType != "" && claims.Type != tokenType {
+		return nil, nil, fmt.Errorf("%w: expected a %s token", ErrInvalidToken, tokenType)
+	}
+
+	user, err := m.currentSession(claims.Subject, claims.Session)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+// currentSession returns the user if the session is one of its sessions.
+func (m *Manager) currentSession(username, session string) (*db.AuthItem, error) {
+	user, err := m.store.GetUser(username)
+	if errors.Is(err, db.ErrUserNotFound) {
+		return nil, fmt.Errorf("%w: unknown user", ErrInvalidToken)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", username, err)
+	}
+	if _, exists := user.Sessions[session]; !exists {
+		return nil, fmt.Errorf("%w: token has been revoked", ErrInvalidToken)
+	}
+	return user, nil
+}
+
+// startSession stores a new session for the user and returns its tokens. The new session replaces the previous
+// one if it is not empty, which revokes its tokens, and the expired sessions are removed.
+func (m *Manager) startSession(username, previous string) (*Tokens, error) {
+	m.sessionMu.Lock()
+	defer m.sessionMu.Unlock()
+
+	var user *db.AuthItem
+	var err error
+	if previous != "" {
+		// the session is checked again with the lock held, so a refresh token cannot be used twice concurrently
+		user, err = m.currentSession(username, previous)
+	} else {
+		user, err = m.store.GetUser(username)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.clock()
+	delete(user.Sessions, previous)
+	for id, expiresAt := range user.Sessions {
+		if !expiresAt.After(now) {
+			delete(user.Sessions, id)
+		}
+	}
+	if user.Sessions == nil {
+		user.Sessions = make(map[string]time.Time)
+	}
+	session := uuid.NewString()
+	user.Sessions[session] = now.Add(m.refreshTTL)
+	if err := m.store.SetUser(user); err != nil {
+		return nil, fmt.Errorf("failed to start session of user %s: %w", user.Username, err)
+	}
+
+	access, err := m.issue(username, session, TokenTypeAccess, now, m.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := m.issue(username, session, TokenTypeRefresh, now, m.refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{AccessToken: access, RefreshToken: refresh, ExpiresIn: m.accessTTL}, nil
+}
+
+// issue signs a token of the session of the user.
+func (m *Manager) issue(username, session string, tokenType TokenType, now time.Time, ttl time.Duration) (string, error) {
+	claims := Claims{
+		Subject:   username,
+		ID:        uuid.NewString(),
+		Session:   session,
+		Type:      tokenType,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+	token, err := signToken(claims, m.secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign %s token of user %s: %w", tokenType, username, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+type AuthSuite struct {
+	db      db.DBClient
+	manager *Manager
+	now     time.Time
+	suite.Suite
+}
+
+func (s *AuthSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.now = time.Now()
+
+	var err error
+	s.manager, err = NewManager(slog.Default(), s.db, testSecret, "admin", "admin-password", WithClock(func() time.Time { return s.now }))
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.Register("admin", "admin-password", "alice", "secret"))
+}
+
+func (s *AuthSuite) TearDownTest() {
+	s.db.Close()
+}
+
+func (s *AuthSuite) TestRegister() {
+	s.ErrorIs(s.manager.Register("admin", "wrong", "bob", "secret"), ErrInvalidCredentials)
+	s.ErrorIs(s.manager.Register("alice", "secret", "bob", "secret"), ErrInvalidCredentials, "only the administrator can register users")
+	s.ErrorIs(s.manager.Register("admin", "admin-password", "alice", "other"), ErrUserAlreadyExists)
+
+	user, err := s.db.GetUser("alice")
+	s.Require().NoError(err)
+	s.NotEqual("secret", user.Password, "the password should be hashed")
+	s.NotZero(user.UUID)
+}
+
+func (s *AuthSuite) TestVerifiedAdminPassword() {
+	// the setup verified the password, so the next checks skip bcrypt but still reject the wrong credentials
+	s.Require().NotNil(s.manager.adminVerified.Load())
+	s.NoError(s.manager.AuthenticateAdmin("admin", "admin-password"))
+	s.ErrorIs(s.manager.AuthenticateAdmin("alice", "admin-password"), ErrInvalidCredentials)
+	s.ErrorIs(s.manager.AuthenticateAdmin("admin", "admin-passwor"), ErrInvalidCredentials)
+	s.ErrorIs(s.manager.AuthenticateAdmin("admin", ""), ErrInvalidCredentials)
+}
+
+func (s *AuthSuite) TestLogin() {
+	_, err := s.manager.Login("alice", "wrong")
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.manager.Login("unknown", "secret")
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.manager.Login("admin", "admin-password")
+	s.ErrorIs(err, ErrInvalidCredentials, "the administrator should not be a user")
+
+	tokens, err := s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	s.Equal(defaultAccessTokenTTL, tokens.ExpiresIn)
+
+	claims, err := s.manager.Authenticate(tokens.AccessToken)
+	s.Require().NoError(err)
+	s.Equal("alice", claims.Subject)
+	_, err = s.manager.Authenticate(tokens.RefreshToken)
+	s.ErrorIs(err, ErrInvalidToken, "a refresh token should not authenticate requests")
+
+	// a new login starts another session, which keeps the tokens of the first one
+	_, err = s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	_, err = s.manager.Authenticate(tokens.AccessToken)
+	s.NoError(err)
+}
+
+func (s *AuthSuite) TestAuthenticateUser() {
+	_, err := s.manager.AuthenticateUser("alice", "wrong")
+	s.ErrorIs(err, ErrInvalidCredentials)
+	_, err = s.manager.AuthenticateUser("unknown", "secret")
+	s.ErrorIs(err, ErrInvalidCredentials)
+
+	principal, err := s.manager.AuthenticateUser("alice", "secret")
+	s.Require().NoError(err)
+	s.Equal("alice", principal.Name)
+	s.True(principal.Allows(enums.PermissionWrite, "any"))
+}
+
+func (s *AuthSuite) TestConcurrentLogins() {
+	var wg sync.WaitGroup
+	logins := make([]*Tokens, 4)
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens, err := s.manager.Login("alice", "secret")
+			s.NoError(err)
+			logins[i] = tokens
+		}()
+	}
+	wg.Wait()
+
+	for _, tokens := range logins {
+		s.Require().NotNil(tokens)
+		_, err := s.manager.Authenticate(tokens.AccessToken)
+		s.NoError(err, "every login should keep its own session")
+	}
+
+	// logging out of a session keeps the others
+	s.Require().NoError(s.manager.Logout(logins[0].AccessToken))
+	_, err := s.manager.Authenticate(logins[0].AccessToken)
+	s.ErrorIs(err, ErrInvalidToken)
+	_, err = s.manager.Authenticate(logins[1].AccessToken)
+	s.NoError(err)
+
+	// the expired sessions are removed when the user logs in again
+	s.now = s.now.Add(defaultRefreshTokenTTL)
+	_, err = s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	user, err := s.db.GetUser("alice")
+	s.Require().NoError(err)
+	s.Len(user.Sessions, 1)
+}
+
+func (s *AuthSuite) TestRefreshToken() {
+	tokens, err := s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+
+	s.now = s.now.Add(defaultAccessTokenTTL)
+	_, err = s.manager.Authenticate(tokens.AccessToken)
+	s.ErrorIs(err, ErrInvalidToken, "the access token should expire")
+
+	_, err = s.manager.RefreshToken(tokens.AccessToken)
+	s.ErrorIs(err, ErrInvalidToken, "an access token should not be refreshed")
+	refreshed, err := s.manager.RefreshToken(tokens.RefreshToken)
+	s.Require().NoError(err)
+	_, err = s.manager.Authenticate(refreshed.AccessToken)
+	s.NoError(err)
+
+	_, err = s.manager.RefreshToken(tokens.RefreshToken)
+	s.ErrorIs(err, ErrInvalidToken, "a refresh token should be used once")
+
+	s.now = s.now.Add(defaultRefreshTokenTTL)
+	_, err = s.manager.RefreshToken(refreshed.RefreshToken)
+	s.ErrorIs(err, ErrInvalidToken, "the refresh token should expire")
+}
+
+func (s *AuthSuite) TestLogout() {
+	tokens, err := s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.manager.Logout(tokens.AccessToken))
+	_, err = s.manager.Authenticate(tokens.AccessToken)
+	s.ErrorIs(err, ErrInvalidToken)
+	_, err = s.manager.RefreshToken(tokens.RefreshToken)
+	s.ErrorIs(err, ErrInvalidToken, "the refresh token should be revoked too")
+	s.ErrorIs(s.manager.Logout(tokens.AccessToken), ErrInvalidToken)
+}
+
+func (s *AuthSuite) TestTamperedToken() {
+	tokens, err := s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	parts := strings.Split(tokens.AccessToken, ".")
+
+	// a token signed with another secret
+	other, err := NewManager(slog.Default(), s.db, strings.Repeat("x", 32), "admin", "admin-password")
+	s.Require().NoError(err)
+	forged, err := other.issue("alice", "", TokenTypeAccess, s.now, time.Hour)
+	s.Require().NoError(err)
+
+	for name, token := range map[string]string{
+		"empty":          "",
+		"malformed":      "a.b",
+		"alg none":       "eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0." + parts[1] + ".",
+		"other claims":   parts[0] + "." + strings.Split(tokens.RefreshToken, ".")[1] + "." + parts[2],
+		"other secret":   forged,
+		"bad signature":  parts[0] + "." + parts[1] + ".AAAA",
+		"bad base64 sig": parts[0] + "." + parts[1] + ".!",
+	} {
+		_, err := s.manager.Authenticate(token)
+		s.ErrorIs(err, ErrInvalidToken, name)
+	}
+}
+
+func TestAuthSuite(t *testing.T) {
+	suite.Run(t, new(AuthSuite))
+}
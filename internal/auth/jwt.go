@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenType is the kind of a token, which sets what it can be used for.
+type TokenType string
+
+const (
+	// TokenTypeAccess is the type of the tokens sent with the requests of the protected endpoints.
+	TokenTypeAccess TokenType = "access"
+	// TokenTypeRefresh is the type of the tokens exchanged for a new pair of tokens.
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// tokenHeader is the encoded header of the tokens, which are always signed with HMAC-SHA256.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the claims of the tokens issued by the Manager.
+type Claims struct {
+	Subject   string    `json:"sub"` // username of the user
+	ID        string    `json:"jti"` // unique ID of the token
+	Session   string    `json:"sid"` // ID of the session of the user the token belongs to
+	Type      TokenType `json:"typ"` // access or refresh
+	IssuedAt  int64     `json:"iat"` // issue time, in seconds since the Unix epoch
+	ExpiresAt int64     `json:"exp"` // expiration time, in seconds since the Unix epoch
+}
+
+// signToken encodes the claims as a JWT signed with the secret.
+func signToken(claims Claims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature(unsigned, secret)), nil
+}
+
+// parseToken verifies the signature and the expiration of the JWT and returns its claims.
+//
+// Only the header written by signToken is accepted, so a token cannot pick another algorithm, such as none.
+func parseToken(token string, secret []byte, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	signed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signed, signature(parts[0]+"."+parts[1], secret)) {
+		return nil, fmt.Errorf("%w: invalid signature", ErrInvalidToken)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+	var claims Claims
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: token has expired", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+// signature returns the HMAC-SHA256 of the encoded header and claims.
+func signature(unsigned string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
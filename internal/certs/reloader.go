@@ -0,0 +1,204 @@
+/*
+The package certs loads the TLS certificates of the servers and reloads them when their files change.
+
+A Reloader keeps the certificate and key of a server and, optionally, the pool of the certificate authorities that
+sign the certificates of its clients. The files are checked periodically, and a new certificate is used by the
+handshakes that start after it is loaded, so certificates rotated by tools such as cert-manager are picked up
+without restarting the server. A file that cannot be loaded keeps the previous certificate in use.
+*/
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/enums"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNoCertificates is returned when a CA file has no PEM certificates.
+var ErrNoCertificates = errors.New("no certificates found")
+
+// ReloaderOptions defines an interface for applying options to the Reloader.
+type ReloaderOptions interface {
+	apply(*Reloader)
+}
+
+// WithClientCA sets the file of the certificate authorities that sign the certificates of the clients.
+type WithClientCA string
+
+func (o WithClientCA) apply(r *Reloader) {
+	r.caFile = string(o)
+}
+
+// WithClientAuth sets whether the certificates of the clients are requested and verified against the client CA.
+type WithClientAuth tls.ClientAuthType
+
+func (o WithClientAuth) apply(r *Reloader) {
+	r.clientAuth = tls.ClientAuthType(o)
+}
+
+// Reloader keeps the certificate of a server and the CA of its clients up to date with their files.
+type Reloader struct {
+	logger     *slog.Logger
+	certFile   string             // PEM file of the certificate
+	keyFile    string             // PEM file of the private key of the certificate
+	caFile     string             // PEM file of the CA of the clients, empty if the clients are not verified
+	clientAuth tls.ClientAuthType // policy of the certificates of the clients
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate // certificate served in the handshakes
+	clientCA *x509.CertPool   // CA the certificates of the clients are verified against, nil without caFile
+	modTimes []time.Time      // modification times of the files when they were loaded, in the order of files
+}
+
+// NewReloader loads the certificate and key, and the client CA if it is set, and fails if any of them is not valid.
+func NewReloader(logger *slog.Logger, certFile, keyFile string, opts ...ReloaderOptions) (*Reloader, error) {
+	r := &Reloader{logger: logger, certFile: certFile, keyFile: keyFile}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	if r.clientAuth >= tls.VerifyClientCertIfGiven && r.caFile == "" {
+		return nil, fmt.Errorf("a client CA is required to verify the certificates of the clients")
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ServerConfig returns the TLS configuration of a server that serves the current certificate. If verifyClients is
+// true, the certificates of the clients are handled with the client authentication policy of the reloader.
+func (r *Reloader) ServerConfig(verifyClients bool) *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+	if !verifyClients {
+		base.GetCertificate = r.getCertificate
+		return base
+	}
+
+	// the client CA can change too, so the configuration of every handshake is built with the current one
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+		return &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{*r.cert},
+			ClientAuth:   r.clientAuth,
+			ClientCAs:    r.clientCA,
+		}, nil
+	}
+	return base
+}
+
+// Watch checks the files every interval and reloads them when any of them changes, until the context is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.reload()
+			if err != nil {
+				r.logger.Error("Failed to reload TLS certificates, keeping the previous ones", "error", err)
+			} else if changed {
+				r.logger.Info("Reloaded TLS certificates", "cert", r.certFile)
+			}
+		}
+	}
+}
+
+// getCertificate returns the current certificate.
+func (r *Reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reload loads the files if any of them changed since they were last loaded, and reports whether they changed.
+func (r *Reloader) reload() (bool, error) {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, fmt.Errorf("failed to stat %s: %w", file, err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	r.mu.RLock()
+	unchanged := slices.EqualFunc(modTimes, r.modTimes, time.Time.Equal)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load certificate %s: %w", r.certFile, err)
+	}
+	var clientCA *x509.CertPool
+	if r.caFile != "" {
+		if clientCA, err = LoadCertPool(r.caFile); err != nil {
+			return false, err
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCA = clientCA
+	r.modTimes = modTimes
+	r.mu.Unlock()
+	return true, nil
+}
+
+// ClientAuthType returns the TLS policy of the certificates of the clients of the configuration.
+func ClientAuthType(clientAuth enums.ClientAuth) tls.ClientAuthType {
+	switch clientAuth {
+	case enums.ClientAuthOptional:
+		return tls.VerifyClientCertIfGiven
+	case enums.ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert
+	}
+	return tls.NoClientCert
+}
+
+// LoadCertPool returns a pool with the PEM certificates of the file.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA %s: %w", file, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("failed to load CA %s: %w", file, ErrNoCertificates)
+	}
+	return pool, nil
+}
+
+// Identity returns the identity of a client certificate: its common name, or its first URI or DNS name if it has
+// no common name, as the SPIFFE certificates.
+func Identity(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package certs_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log/slog"
+	"memorydb/internal/certs"
+	"memorydb/internal/certs/certstest"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReloaderSuite struct {
+	ca       *certstest.CA
+	dir      string
+	client   tls.Certificate // certificate of the clients, issued by the CA
+	listener net.Listener
+	suite.Suite
+}
+
+func (s *ReloaderSuite) SetupTest() {
+	s.ca = certstest.NewCA(s.T(), "ca")
+	s.dir = s.T().TempDir()
+	s.ca.Issue("server", s.file("server.crt"), s.file("server.key"))
+	s.client = s.ca.Issue("client", s.file("client.crt"), s.file("client.key"))
+}
+
+func (s *ReloaderSuite) TearDownTest() {
+	if s.listener != nil {
+		s.listener.Close()
+	}
+}
+
+func (s *ReloaderSuite) file(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// serve accepts TLS connections with the configuration and completes their handshakes.
+func (s *ReloaderSuite) serve(config *tls.Config) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	s.Require().NoError(err)
+	s.listener = listener
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+}
+
+// dial connects to the server with the certificate, if any, and returns the common name of the certificate
+// of the server.
+func (s *ReloaderSuite) dial(cert *tls.Certificate) (string, error) {
+	config := &tls.Config{RootCAs: s.ca.Pool(), ServerName: "localhost"}
+	if cert != nil {
+		config.Certificates = []tls.Certificate{*cert}
+	}
+	conn, err := tls.Dial("tcp", s.listener.Addr().String(), config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	// the server rejects the certificate of the client after the handshake of the client, so read its answer
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func (s *ReloaderSuite) TestNewReloader() {
+	_, err := certs.NewReloader(slog.Default(), s.file("missing.crt"), s.file("server.key"))
+	s.Error(err)
+	_, err = certs.NewReloader(slog.Default(), s.file("server.crt"), s.file("server.key"), certs.WithClientAuth(tls.RequireAndVerifyClientCert))
+	s.ErrorContains(err, "client CA is required")
+	_, err = certs.NewReloader(slog.Default(), s.file("server.crt"), s.file("server.key"), certs.WithClientCA(s.file("server.key")),
+		certs.WithClientAuth(tls.RequireAndVerifyClientCert))
+	s.ErrorIs(err, certs.ErrNoCertificates)
+}
+
+func (s *ReloaderSuite) TestClientAuth() {
+	reloader, err := certs.NewReloader(slog.Default(), s.file("server.crt"), s.file("server.key"),
+		certs.WithClientCA(s.ca.File), certs.WithClientAuth(tls.RequireAndVerifyClientCert))
+	s.Require().NoError(err)
+	s.serve(reloader.ServerConfig(true))
+
+	name, err := s.dial(&s.client)
+	s.Require().NoError(err)
+	s.Equal("server", name)
+	_, err = s.dial(nil)
+	s.Error(err, "a client without certificate should be rejected")
+
+	other := certstest.NewCA(s.T(), "other")
+	untrusted := other.Issue("client", s.file("untrusted.crt"), s.file("untrusted.key"))
+	_, err = s.dial(&untrusted)
+	s.Error(err, "a certificate of another CA should be rejected")
+}
+
+func (s *ReloaderSuite) TestReload() {
+	reloader, err := certs.NewReloader(slog.Default(), s.file("server.crt"), s.file("server.key"))
+	s.Require().NoError(err)
+	s.serve(reloader.ServerConfig(false))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	// a certificate that cannot be loaded keeps the previous one
+	s.Require().NoError(os.WriteFile(s.file("server.crt"), []byte("invalid"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	name, err := s.dial(nil)
+	s.Require().NoError(err)
+	s.Equal("server", name)
+
+	s.ca.Issue("rotated", s.file("server.crt"), s.file("server.key"))
+	later := time.Now().Add(time.Minute)
+	s.Require().NoError(os.Chtimes(s.file("server.crt"), later, later))
+	s.Eventually(func() bool {
+		name, err := s.dial(nil)
+		return err == nil && name == "rotated"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *ReloaderSuite) TestIdentity() {
+	s.Equal("client", certs.Identity(s.client.Leaf))
+
+	spiffe, err := url.Parse("spiffe://example.org/billing")
+	s.Require().NoError(err)
+	s.Equal("spiffe://example.org/billing", certs.Identity(&x509.Certificate{URIs: []*url.URL{spiffe}, DNSNames: []string{"billing"}}))
+	s.Equal("billing", certs.Identity(&x509.Certificate{DNSNames: []string{"billing"}}))
+	s.Empty(certs.Identity(&x509.Certificate{}))
+}
+
+func TestReloaderSuite(t *testing.T) {
+	suite.Run(t, new(ReloaderSuite))
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"memorydb/internal/transport/schemas"
+	"memorydb/pkg/godb"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	// ErrExit is returned by Execute when the user asks to exit.
+	ErrExit = errors.New("exit")
+)
+
+// Format is the output format of the results.
+type Format string
+
+const (
+	// FormatTable prints the results as aligned columns.
+	FormatTable Format = "table"
+	// FormatJSON prints every result as a JSON document in a single line.
+	FormatJSON Format = "json"
+)
+
+// IsValid checks if the output format is valid.
+func (f Format) IsValid() bool {
+	return f == FormatTable || f == FormatJSON
+}
+
+// CLIOptions configure the client.
+type CLIOptions interface {
+	apply(*CLI)
+}
+
+// WithFormat sets the output format of the results, FormatTable by default.
+type WithFormat Format
+
+func (o WithFormat) apply(c *CLI) {
+	c.format = Format(o)
+}
+
+// CLI runs the commands of the command-line client against the database.
+type CLI struct {
+	client godb.ApiClient
+	out    io.Writer
+	format Format
+}
+
+// New creates a client that sends the commands to the database through the API client and writes the results to out.
+func New(client godb.ApiClient, out io.Writer, opts ...CLIOptions) *CLI {
+	c := &CLI{client: client, out: out, format: FormatTable}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	return c
+}
+
+// Format returns the current output format.
+func (c *CLI) Format() Format {
+	return c.format
+}
+
+// Execute parses and runs a command line. It returns ErrExit when the command is exit.
+func (c *CLI) Execute(line string) error {
+	cmd, err := Parse(line)
+	if err != nil || cmd == nil {
+		return err
+	}
+
+	switch cmd.Name {
+	case NameGet:
+		item, err := c.client.Get(cmd.Key())
+		if err != nil {
+			return err
+		}
+		return c.printItem(item)
+	case NameSet:
+		resp, err := c.client.Set(cmd.Key(), cmd.Value(), cmd.TTL)
+		if err != nil {
+			return err
+		}
+		return c.printMessage(resp.Message)
+	case NameUpdate:
+		resp, err := c.client.Update(cmd.Key(), cmd.Value(), cmd.TTL)
+		if err != nil {
+			return err
+		}
+		return c.printMessage(resp.Message)
+	case NamePush:
+		item, err := c.client.Push(cmd.Key(), cmd.Args[1], cmd.TTL)
+		if err != nil {
+			return err
+		}
+		return c.printItem(item)
+	case NamePop:
+		item, err := c.client.Pop(cmd.Key())
+		if err != nil {
+			return err
+		}
+		return c.printItem(item)
+	case NameDel:
+		resp, err := c.client.Remove(cmd.Key())
+		if err != nil {
+			return err
+		}
+		return c.printMessage(resp.Message)
+	case NameFormat:
+		format := Format(strings.ToLower(cmd.Args[0]))
+		if !format.IsValid() {
+			return fmt.Errorf("invalid format '%s', expected 'table' or 'json'", cmd.Args[0])
+		}
+		c.format = format
+		return nil
+	case NameHelp:
+		return c.printHelp()
+	case NameExit:
+		return ErrExit
+	}
+	return nil
+}
+
+// Run executes the commands read from r, one per line, as in a script. It stops at exit or at the end of the input,
+// and writes the errors to errOut without stopping. It returns the number of commands that failed.
+func (c *CLI) Run(r io.Reader, errOut io.Writer) (int, error) {
+	scanner := bufio.NewScanner(r)
+	var failed, lineNumber int
+	for scanner.Scan() {
+		lineNumber++
+		if err := c.Execute(scanner.Text()); err != nil {
+			if errors.Is(err, ErrExit) {
+				break
+			}
+			failed++
+			fmt.Fprintf(errOut, "line %d: %v\n", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return failed, fmt.Errorf("failed to read commands: %w", err)
+	}
+	return failed, nil
+}
+
+// printItem prints an item returned by the database.
+func (c *CLI) printItem(item *godb.ApiResponse) error {
+	if c.format == FormatJSON {
+		return c.printJSON(item)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tKIND\tVALUE\tTTL\tUPDATED")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", item.Key, item.Kind, formatValue(item.Value), formatTTL(item.TTL), formatTime(item.UpdatedAt))
+	return w.Flush()
+}
+
+// printMessage prints the message returned by the commands that do not return an item.
+func (c *CLI) printMessage(message string) error {
+	if c.format == FormatJSON {
+		return c.printJSON(schemas.OKResponse{Message: message})
+	}
+	_, err := fmt.Fprintln(c.out, message)
+	return err
+}
+
+// printJSON prints the value as a JSON document in a single line.
+func (c *CLI) printJSON(v any) error {
+	return json.NewEncoder(c.out).Encode(v)
+}
+
+// printHelp prints the usage of every command.
+func (c *CLI) printHelp() error {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", specs[Name(name)].usage)
+	}
+	fmt.Fprintln(w, "\nValues with spaces must be quoted. Several values, or a JSON array such as [\"a\",\"b\"], store a list.")
+	return w.Flush()
+}
+
+// formatValue formats a string or a list for the table output.
+func formatValue(value any) string {
+	if list, ok := value.([]string); ok {
+		return "[" + strings.Join(list, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+// formatTTL formats the time left until the item expires.
+func formatTTL(ttl time.Time) string {
+	if ttl.IsZero() {
+		return "-"
+	}
+	left := time.Until(ttl).Round(time.Second)
+	if left <= 0 {
+		return "expired"
+	}
+	return left.String()
+}
+
+// formatTime formats a timestamp of an item.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
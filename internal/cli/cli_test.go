@@ -0,0 +1,193 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/cli"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CLISuite struct {
+	database db.DBClient
+	server   *httptest.Server
+	out      *bytes.Buffer
+	cli      *cli.CLI
+	suite.Suite
+}
+
+func (s *CLISuite) SetupTest() {
+	s.database = db.NewMemoryDB(slog.Default())
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.database).Handler())
+	s.out = &bytes.Buffer{}
+	s.cli = cli.New(godb.NewClient(s.server.URL, "v1"), s.out)
+}
+
+func (s *CLISuite) TearDownTest() {
+	s.server.Close()
+	s.database.Close()
+}
+
+func (s *CLISuite) TestParse() {
+	tests := []struct {
+		line     string
+		expected *cli.Command
+	}{
+		{line: "", expected: nil},
+		{line: "  # a comment", expected: nil},
+		{line: "GET key", expected: &cli.Command{Name: cli.NameGet, Args: []string{"key"}}},
+		{line: `set key "hello world"`, expected: &cli.Command{Name: cli.NameSet, Args: []string{"key", "hello world"}}},
+		{line: `set key 'it''s' a\ b`, expected: &cli.Command{Name: cli.NameSet, Args: []string{"key", "its", "a b"}}},
+		{line: "quit", expected: &cli.Command{Name: cli.NameExit}},
+	}
+	for _, test := range tests {
+		cmd, err := cli.Parse(test.line)
+		s.Require().NoError(err, test.line)
+		s.Equal(test.expected, cmd, test.line)
+	}
+
+	cmd, err := cli.Parse("set key value ttl=30s")
+	s.Require().NoError(err)
+	s.Equal(30*time.Second, *cmd.TTL)
+	s.Equal("value", cmd.Value())
+
+	cmd, err = cli.Parse(`update key a b`)
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, cmd.Value())
+
+	cmd, err = cli.Parse(`set key '["a"]'`)
+	s.Require().NoError(err)
+	s.Equal([]string{"a"}, cmd.Value(), "a JSON array should be stored as a list")
+
+	for _, line := range []string{"unknown key", "get", "get a b", "pop key ttl=1s", "set key value ttl=abc", `set key "value`} {
+		_, err := cli.Parse(line)
+		s.Error(err, line)
+	}
+}
+
+func (s *CLISuite) TestExecute() {
+	s.Require().NoError(s.cli.Execute("set user John ttl=1h"))
+	s.Equal("ok\n", s.out.String())
+
+	s.out.Reset()
+	s.Require().NoError(s.cli.Execute("get user"))
+	lines := strings.Split(strings.TrimSpace(s.out.String()), "\n")
+	s.Require().Len(lines, 2)
+	s.Equal([]string{"KEY", "KIND", "VALUE", "TTL", "UPDATED"}, strings.Fields(lines[0]))
+	s.Equal([]string{"user", "string", "John", "1h0m0s"}, strings.Fields(lines[1])[:4])
+
+	s.Require().NoError(s.cli.Execute("set list a b"))
+	s.Require().NoError(s.cli.Execute("push list c"))
+	s.Require().NoError(s.cli.Execute("pop list"))
+	item, err := s.database.Get("list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+
+	s.Require().NoError(s.cli.Execute("update user Jane"))
+	s.Require().NoError(s.cli.Execute("del list"))
+	s.Error(s.cli.Execute("get list"))
+	s.ErrorIs(s.cli.Execute("exit"), cli.ErrExit)
+}
+
+func (s *CLISuite) TestFormat() {
+	s.Require().NoError(s.cli.Execute("format json"))
+	s.Equal(cli.FormatJSON, s.cli.Format())
+	s.Require().NoError(s.cli.Execute("set key value"))
+	s.Require().NoError(s.cli.Execute("get key"))
+
+	// a JSON document per line
+	lines := strings.Split(strings.TrimSpace(s.out.String()), "\n")
+	s.Require().Len(lines, 2)
+	s.JSONEq(`{"message":"ok"}`, lines[0])
+	var item godb.ApiResponse
+	s.Require().NoError(json.Unmarshal([]byte(lines[1]), &item))
+	s.Equal("value", item.Value)
+
+	s.Error(s.cli.Execute("format xml"))
+}
+
+func (s *CLISuite) TestRun() {
+	script := `# populate the database
+set key1 value1
+get missing
+set key2 value2
+exit
+set key3 value3
+`
+	var errOut bytes.Buffer
+	failed, err := s.cli.Run(strings.NewReader(script), &errOut)
+	s.Require().NoError(err)
+	s.Equal(1, failed)
+	s.Contains(errOut.String(), "line 3:")
+	s.ElementsMatch([]string{"key1", "key2"}, s.database.Keys("*"), "the script should stop at exit")
+}
+
+func (s *CLISuite) TestComplete() {
+	tests := []struct {
+		line        string
+		pos         int
+		newLine     string
+		newPos      int
+		completable bool
+	}{
+		{line: "ge", pos: 2, newLine: "get ", newPos: 4, completable: true},
+		{line: "p", pos: 1, newLine: "p", completable: false}, // push and pop share no longer prefix
+		{line: "f", pos: 1, newLine: "format ", newPos: 7, completable: true},
+		{line: "format j", pos: 8, newLine: "format json ", newPos: 12, completable: true},
+		{line: "set key value t", pos: 15, newLine: "set key value ttl=", newPos: 18, completable: true},
+		{line: "ex key", pos: 2, newLine: "exit key", newPos: 4, completable: true},
+		{line: "get k", pos: 5, completable: false},
+	}
+	for _, test := range tests {
+		newLine, newPos, ok := cli.Complete(test.line, test.pos, '\t')
+		s.Equal(test.completable, ok, test.line)
+		if ok {
+			s.Equal(test.newLine, newLine, test.line)
+			s.Equal(test.newPos, newPos, test.line)
+		}
+	}
+
+	_, _, ok := cli.Complete("ge", 2, 'x')
+	s.False(ok, "only tab should complete")
+}
+
+func (s *CLISuite) TestHistory() {
+	path := filepath.Join(s.T().TempDir(), "history")
+	history, err := cli.LoadHistory(path, 2)
+	s.Require().NoError(err)
+	s.Equal(0, history.Len())
+
+	history.Add("get a")
+	history.Add("get a")
+	history.Add("  ")
+	history.Add("get b")
+	history.Add("get c")
+	s.Equal(2, history.Len())
+	s.Equal("get c", history.At(0))
+	s.Equal("get b", history.At(1))
+
+	// the history is kept between sessions, up to its size
+	history, err = cli.LoadHistory(path, 2)
+	s.Require().NoError(err)
+	s.Equal(2, history.Len())
+	s.Equal("get c", history.At(0))
+
+	s.Require().NoError(history.Save())
+	content, err := os.ReadFile(path)
+	s.Require().NoError(err)
+	s.Equal("get b\nget c\n", string(content))
+}
+
+func TestCLISuite(t *testing.T) {
+	suite.Run(t, new(CLISuite))
+}
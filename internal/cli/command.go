@@ -0,0 +1,159 @@
+// Package cli implements the commands of the interactive command-line client of the database.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Name is the name of a command of the client.
+type Name string
+
+const (
+	NameGet    Name = "get"
+	NameSet    Name = "set"
+	NameUpdate Name = "update"
+	NamePush   Name = "push"
+	NamePop    Name = "pop"
+	NameDel    Name = "del"
+	NameFormat Name = "format"
+	NameHelp   Name = "help"
+	NameExit   Name = "exit"
+)
+
+// spec describes the arguments of a command.
+type spec struct {
+	usage   string
+	minArgs int
+	maxArgs int  // -1 for no limit
+	ttl     bool // whether the command accepts the ttl=<duration> option
+}
+
+// specs are the commands of the client. quit is an alias of exit.
+var specs = map[Name]spec{
+	NameGet:    {usage: "get <key>", minArgs: 1, maxArgs: 1},
+	NameSet:    {usage: "set <key> <value>... [ttl=<duration>]", minArgs: 2, maxArgs: -1, ttl: true},
+	NameUpdate: {usage: "update <key> <value>... [ttl=<duration>]", minArgs: 2, maxArgs: -1, ttl: true},
+	NamePush:   {usage: "push <key> <value> [ttl=<duration>]", minArgs: 2, maxArgs: 2, ttl: true},
+	NamePop:    {usage: "pop <key>", minArgs: 1, maxArgs: 1},
+	NameDel:    {usage: "del <key>", minArgs: 1, maxArgs: 1},
+	NameFormat: {usage: "format table|json", minArgs: 1, maxArgs: 1},
+	NameHelp:   {usage: "help", minArgs: 0, maxArgs: 0},
+	NameExit:   {usage: "exit", minArgs: 0, maxArgs: 0},
+}
+
+// Command is a parsed command line.
+type Command struct {
+	Name Name
+	Args []string
+	TTL  *time.Duration // TTL set with the ttl=<duration> option, nil if not set
+}
+
+// Key returns the key the command operates on.
+func (c *Command) Key() string {
+	return c.Args[0]
+}
+
+// Value returns the value of set and update: a string for a single value and a list for several values
+// or for a single JSON array, such as ["a","b"].
+func (c *Command) Value() any {
+	values := c.Args[1:]
+	if len(values) == 1 {
+		var list []string
+		if strings.HasPrefix(values[0], "[") && json.Unmarshal([]byte(values[0]), &list) == nil {
+			return list
+		}
+		return values[0]
+	}
+	return values
+}
+
+// Parse parses a command line. It returns a nil command for empty lines and comments.
+func Parse(line string) (*Command, error) {
+	tokens, err := tokenize(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 || strings.HasPrefix(tokens[0], "#") {
+		return nil, nil
+	}
+
+	name := Name(strings.ToLower(tokens[0]))
+	if name == "quit" {
+		name = NameExit
+	}
+	spec, ok := specs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown command '%s', run 'help' to list the commands", tokens[0])
+	}
+
+	cmd := &Command{Name: name}
+	for _, token := range tokens[1:] {
+		if raw, found := strings.CutPrefix(token, "ttl="); found && spec.ttl {
+			ttl, err := time.ParseDuration(raw)
+			if err != nil || ttl <= 0 {
+				return nil, fmt.Errorf("invalid ttl '%s', it must be a positive duration such as 30s", raw)
+			}
+			cmd.TTL = &ttl
+			continue
+		}
+		cmd.Args = append(cmd.Args, token)
+	}
+	if len(cmd.Args) < spec.minArgs || (spec.maxArgs >= 0 && len(cmd.Args) > spec.maxArgs) {
+		return nil, fmt.Errorf("usage: %s", spec.usage)
+	}
+	return cmd, nil
+}
+
+// tokenize splits a line into words separated by spaces. Single and double quotes group words, and a
+// backslash escapes the next character outside of single quotes.
+func tokenize(line string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		inToken bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("unterminated escape")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// completions are the words completed after the name of a command.
+var completions = map[Name][]string{
+	NameSet:    {"ttl="},
+	NameUpdate: {"ttl="},
+	NamePush:   {"ttl="},
+	NameFormat: {string(FormatJSON), string(FormatTable)},
+}
+
+// Complete completes the word under the cursor when tab is pressed. It has the signature of the
+// AutoCompleteCallback of golang.org/x/term.Terminal.
+//
+// The first word is completed with the names of the commands, and the next ones with the options of the command.
+// When several words match, the word is completed up to their common prefix.
+func Complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix, suffix := line[:pos], line[pos:]
+	start := strings.LastIndexAny(prefix, " \t") + 1
+	word := prefix[start:]
+
+	var candidates []string
+	if strings.TrimSpace(prefix[:start]) == "" {
+		for name := range specs {
+			candidates = append(candidates, string(name))
+		}
+	} else {
+		name := Name(strings.ToLower(strings.Fields(prefix)[0]))
+		candidates = completions[name]
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	sort.Strings(matches)
+
+	completed := matches[0]
+	if len(matches) > 1 {
+		completed = commonPrefix(matches)
+	} else if !strings.HasSuffix(completed, "=") && !strings.HasPrefix(suffix, " ") {
+		completed += " "
+	}
+	if completed == word {
+		return "", 0, false
+	}
+
+	newLine := prefix[:start] + completed + suffix
+	return newLine, start + len(completed), true
+}
+
+// commonPrefix returns the longest prefix shared by the sorted words.
+func commonPrefix(words []string) string {
+	first, last := words[0], words[len(words)-1]
+	i := 0
+	for i < len(first) && i < len(last) && first[i] == last[i] {
+		i++
+	}
+	return first[:i]
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultHistorySize is the number of lines kept in the history.
+	DefaultHistorySize = 1000
+)
+
+// History is the history of the command lines, saved in a file so it is kept between sessions.
+// It implements the History interface of golang.org/x/term.Terminal.
+type History struct {
+	path  string
+	size  int
+	lines []string // from the oldest to the newest
+}
+
+// LoadHistory loads the history saved in the file, keeping up to size lines. The file is created when the first
+// line is added, and an empty path disables the file.
+func LoadHistory(path string, size int) (*History, error) {
+	h := &History{path: path, size: size}
+	if path == "" {
+		return h, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.lines = append(h.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	h.trim()
+	return h, nil
+}
+
+// Add adds a line to the history and appends it to the file. Empty lines and repetitions of the last line are skipped.
+func (h *History) Add(entry string) {
+	if strings.TrimSpace(entry) == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == entry) {
+		return
+	}
+	h.lines = append(h.lines, entry)
+	h.trim()
+
+	if h.path == "" {
+		return
+	}
+	// the history is a convenience, failing to save it must not interrupt the session
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = fmt.Fprintln(f, entry)
+}
+
+// Len returns the number of lines in the history.
+func (h *History) Len() int {
+	return len(h.lines)
+}
+
+// At returns a line of the history, 0 being the newest one.
+func (h *History) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
+
+// Save rewrites the file with the lines kept in the history, so it does not grow without limit.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+	content := strings.Join(h.lines, "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := os.WriteFile(h.path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to save history file: %w", err)
+	}
+	return nil
+}
+
+// trim drops the oldest lines beyond the size of the history.
+func (h *History) trim() {
+	if h.size > 0 && len(h.lines) > h.size {
+		h.lines = h.lines[len(h.lines)-h.size:]
+	}
+}
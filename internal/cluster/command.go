@@ -0,0 +1,170 @@
+package cluster
+
+import (
+	"errors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"time"
+)
+
+// Command is a mutation of the database replicated through the Raft log.
+//
+// Every node applies the committed commands in the same order and with the clock set to the time the leader
+// assigned to the command, so the timestamps, the TTLs and the stream IDs are the same in every node.
+type Command struct {
+	Op   enums.DBCommand `json:"op"`
+	Time time.Time       `json:"time"` // time assigned by the leader when the command was proposed
+	Key  string          `json:"key"`
+
+	Value *db.StringOrSlice            `json:"value,omitempty"` // value of set, update and push
+	Items map[string]*db.StringOrSlice `json:"items,omitempty"` // values of mset, by key
+	TTL   *time.Duration               `json:"ttl,omitempty"`   // TTL of set, update, push and xadd
+
+	Fields   map[string]string `json:"fields,omitempty"`   // fields of the entry added with xadd
+	MaxLen   int               `json:"max_len,omitempty"`  // maximum number of entries kept by xtrim
+	MaxAge   time.Duration     `json:"max_age,omitempty"`  // maximum age of the entries kept by xtrim
+	Group    string            `json:"group,omitempty"`    // consumer group of the stream commands
+	Consumer string            `json:"consumer,omitempty"` // consumer of xdeliver and xclaim
+	Start    string            `json:"start,omitempty"`    // ID after which the group created with xgroup_create delivers entries
+	Count    int               `json:"count,omitempty"`    // maximum number of entries delivered by xdeliver
+	MinIdle  time.Duration     `json:"min_idle,omitempty"` // minimum idle time of the entries claimed with xclaim
+	IDs      []string          `json:"ids,omitempty"`      // IDs of the entries of xack and xclaim
+
+	User   *db.AuthItem   `json:"user,omitempty"`    // user stored by user_set
+	APIKey *db.APIKeyItem `json:"api_key,omitempty"` // API key stored by apikey_set, or only its ID for apikey_remove
+}
+
+// value returns the value of the command, or nil if it has none.
+func (c *Command) value() any {
+	if c.Value == nil {
+		return nil
+	}
+	return c.Value.Val
+}
+
+// items returns the values of the items of mset, by key.
+func (c *Command) items() map[string]any {
+	items := make(map[string]any, len(c.Items))
+	for key, value := range c.Items {
+		items[key] = value.Val
+	}
+	return items
+}
+
+// options returns the item options of the command.
+func (c *Command) options() []db.ItemOptions {
+	if c.TTL == nil {
+		return nil
+	}
+	return []db.ItemOptions{db.WithTTL(*c.TTL)}
+}
+
+// Result is the outcome of a command applied to the database.
+type Result struct {
+	Item    *db.Item         `json:"item,omitempty"`    // item returned by push and pop
+	ID      db.StreamID      `json:"id"`                // ID of the entry added with xadd
+	Entries []db.StreamEntry `json:"entries,omitempty"` // entries returned by xdeliver and xclaim
+	Count   int              `json:"count,omitempty"`   // number of entries removed by xtrim or acknowledged by xack
+	Error   *CommandError    `json:"error,omitempty"`   // error returned by the database, nil if the command succeeded
+}
+
+// err returns the error of the result, or nil if the command succeeded.
+func (r *Result) err() error {
+	if r.Error == nil {
+		return nil
+	}
+	return r.Error.decode()
+}
+
+// CommandError is the encoded form of an error returned by the database, so it can be sent to the node
+// that forwarded the command.
+type CommandError struct {
+	Sentinel   string `json:"sentinel,omitempty"` // name of the well-known database error, empty for other errors
+	Message    string `json:"message"`
+	SysMessage string `json:"sys_message,omitempty"`
+}
+
+// sentinels are the well-known database errors, which are compared by identity by the callers.
+var sentinels = map[string]*db.DBerror{
+	"data_not_found":         db.ErrDataNotFound,
+	"invalid_data_type":      db.ErrInvalidDataType,
+	"key_has_expired":        db.ErrKeyHasExpired,
+	"out_of_memory":          db.ErrOutOfMemory,
+	"not_a_stream":           db.ErrNotAStream,
+	"invalid_stream_id":      db.ErrInvalidStreamID,
+	"stream_group_not_found": db.ErrStreamGroupNotFound,
+	"stream_group_exists":    db.ErrStreamGroupExists,
+	"api_key_not_found":      db.ErrAPIKeyNotFound,
+	"read_only":              db.ErrReadOnly,
+	"no_leader":              db.ErrNoLeader,
+}
+
+// encodeError converts an error returned by the database into its encoded form.
+func encodeError(err error) *CommandError {
+	if err == nil {
+		return nil
+	}
+
+	if dbError, ok := err.(*db.DBerror); ok {
+		for name, sentinel := range sentinels {
+			if dbError == sentinel {
+				return &CommandError{Sentinel: name, Message: dbError.Message, SysMessage: dbError.SysMessage}
+			}
+		}
+		return &CommandError{Message: dbError.Message, SysMessage: dbError.SysMessage}
+	}
+	return &CommandError{Message: err.Error()}
+}
+
+// decode converts the encoded error back into an error, restoring the identity of the well-known database errors.
+func (e *CommandError) decode() error {
+	if sentinel, ok := sentinels[e.Sentinel]; ok {
+		return sentinel
+	}
+	if e.SysMessage != "" {
+		return db.NewDBError(e.Message, e.SysMessage)
+	}
+	return errors.New(e.Message)
+}
+
+// valueOf wraps the value of a set or update so it can be encoded in a command.
+func valueOf(value any) (*db.StringOrSlice, error) {
+	switch v := value.(type) {
+	case string, []string:
+		return &db.StringOrSlice{Val: v}, nil
+	case []any:
+		slice := make([]string, len(v))
+		for i, elem := range v {
+			str, ok := elem.(string)
+			if !ok {
+				return nil, db.ErrInvalidDataType
+			}
+			slice[i] = str
+		}
+		return &db.StringOrSlice{Val: slice}, nil
+	default:
+		return nil, db.ErrInvalidDataType
+	}
+}
+
+// ttlOf returns the TTL set by the item options, or nil if there is none.
+func ttlOf(opts []db.ItemOptions) *time.Duration {
+	var ttl *time.Duration
+	for _, opt := range opts {
+		if o, ok := opt.(db.WithTTL); ok {
+			d := time.Duration(o)
+			ttl = &d
+		}
+	}
+	return ttl
+}
+
+// isConditional reports whether the item options make the write conditional on the version of the item.
+func isConditional(opts []db.ItemOptions) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(db.WithVersion); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+var (
+	// Ensure fsm implements the raft.FSM interface
+	_ raft.FSM = (*fsm)(nil)
+)
+
+// fsm is the Raft state machine. It applies the committed commands to the local database.
+type fsm struct {
+	db       db.DBClient
+	applying atomic.Pointer[time.Time] // time of the command being applied, nil between commands
+}
+
+// now is the clock of the local database: the time of the command while it is applied, the current time otherwise.
+func (f *fsm) now() time.Time {
+	if t := f.applying.Load(); t != nil {
+		return *t
+	}
+	return time.Now()
+}
+
+// Apply applies a committed command to the database and returns its *Result.
+// Errors of the database are part of the result, since the command is committed even if it fails.
+func (f *fsm) Apply(log *raft.Log) any {
+	var cmd Command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		return &Result{Error: encodeError(fmt.Errorf("failed to decode command at index %d: %w", log.Index, err))}
+	}
+
+	f.applying.Store(&cmd.Time)
+	defer f.applying.Store(nil)
+
+	result := &Result{}
+	var err error
+	switch cmd.Op {
+	case enums.DBCommandSet:
+		err = f.db.Set(cmd.Key, cmd.value(), cmd.options()...)
+	case enums.DBCommandSetMany:
+		err = f.db.SetMany(cmd.items(), cmd.options()...)
+	case enums.DBCommandUpdate:
+		err = f.db.Update(cmd.Key, cmd.value(), cmd.options()...)
+	case enums.DBCommandRemove:
+		err = f.db.Remove(cmd.Key)
+	case enums.DBCommandPush:
+		element, _ := cmd.value().(string)
+		result.Item, err = f.db.Push(cmd.Key, element, cmd.options()...)
+	case enums.DBCommandPop:
+		result.Item, err = f.db.Pop(cmd.Key)
+	case enums.DBCommandStreamAdd:
+		result.ID, err = f.db.StreamAdd(cmd.Key, cmd.Fields, cmd.options()...)
+	case enums.DBCommandStreamTrim:
+		result.Count, err = f.db.StreamTrim(cmd.Key, cmd.MaxLen, cmd.MaxAge)
+	case enums.DBCommandStreamGroupCreate:
+		err = f.db.StreamGroupCreate(cmd.Key, cmd.Group, cmd.Start)
+	case enums.DBCommandStreamDeliver:
+		// blocking reads wait outside of the log, so the delivery itself never blocks
+		result.Entries, err = f.db.StreamReadGroup(context.Background(), cmd.Key, cmd.Group, cmd.Consumer, cmd.Count, 0)
+	case enums.DBCommandStreamAck:
+		result.Count, err = f.db.StreamAck(cmd.Key, cmd.Group, cmd.IDs...)
+	case enums.DBCommandStreamClaim:
+		result.Entries, err = f.db.StreamClaim(cmd.Key, cmd.Group, cmd.Consumer, cmd.MinIdle, cmd.IDs...)
+	case enums.DBCommandUserSet:
+		if cmd.User == nil {
+			err = fmt.Errorf("missing user for command %s at index %d", cmd.Op, log.Index)
+			break
+		}
+		err = f.db.SetUser(cmd.User)
+	case enums.DBCommandAPIKeySet, enums.DBCommandAPIKeyRemove:
+		if cmd.APIKey == nil {
+			err = fmt.Errorf("missing API key for command %s at index %d", cmd.Op, log.Index)
+			break
+		}
+		if cmd.Op == enums.DBCommandAPIKeySet {
+			err = f.db.SetAPIKey(cmd.APIKey)
+		} else {
+			err = f.db.RemoveAPIKey(cmd.APIKey.ID)
+		}
+	default:
+		err = fmt.Errorf("unknown command %s at index %d", cmd.Op, log.Index)
+	}
+
+	result.Error = encodeError(err)
+	return result
+}
+
+// Snapshot takes a snapshot of the database in the same format used by the replicas to do a full synchronization.
+// The snapshot is encoded here, while Raft does not apply commands, and written later by Persist.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	var buf bytes.Buffer
+	if _, err := f.db.WriteSnapshot(&buf); err != nil {
+		return nil, fmt.Errorf("failed to take snapshot: %w", err)
+	}
+	return &fsmSnapshot{data: buf.Bytes()}, nil
+}
+
+// Restore replaces the content of the database with a snapshot.
+func (f *fsm) Restore(snapshot io.ReadCloser) error {
+	defer snapshot.Close()
+
+	if _, err := f.db.LoadSnapshot(snapshot); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+	return nil
+}
+
+// fsmSnapshot is an encoded snapshot of the database.
+type fsmSnapshot struct {
+	data []byte
+}
+
+// Persist writes the snapshot to the sink.
+func (s *fsmSnapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s.data); err != nil {
+		_ = sink.Cancel()
+		return fmt.Errorf("failed to persist snapshot: %w", err)
+	}
+	return sink.Close()
+}
+
+// Release is called when Raft is done with the snapshot.
+func (s *fsmSnapshot) Release() {}
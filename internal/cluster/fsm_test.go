@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/suite"
+)
+
+type FSMSuite struct {
+	fsm *fsm
+	suite.Suite
+}
+
+// newFSM creates a state machine with its own database.
+func newFSM() *fsm {
+	f := &fsm{}
+	f.db = db.NewMemoryDB(slog.Default(), db.WithClock(f.now))
+	return f
+}
+
+func (s *FSMSuite) SetupTest() {
+	s.fsm = newFSM()
+}
+
+func (s *FSMSuite) TearDownTest() {
+	s.fsm.db.Close()
+}
+
+// apply applies the command as if it was committed at the index and returns its result.
+func (s *FSMSuite) apply(index uint64, cmd Command) *Result {
+	data, err := json.Marshal(cmd)
+	s.Require().NoError(err)
+	result, ok := s.fsm.Apply(&raft.Log{Index: index, Data: data}).(*Result)
+	s.Require().True(ok, "the state machine should return a *Result")
+	return result
+}
+
+func (s *FSMSuite) TestApply() {
+	committed := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	ttl := time.Hour
+
+	result := s.apply(1, Command{Op: enums.DBCommandSet, Time: committed, Key: "key", Value: &db.StringOrSlice{Val: "value"}, TTL: &ttl})
+	s.Nil(result.Error)
+
+	// the item is stamped with the time of the command, not with the time it was applied
+	item, err := s.fsm.db.Get("key")
+	s.Require().NoError(err)
+	s.True(item.CreatedAt.Equal(committed))
+	s.True(item.TTL.Equal(committed.Add(ttl)))
+
+	result = s.apply(2, Command{Op: enums.DBCommandStreamAdd, Time: committed, Key: "stream", Fields: map[string]string{"a": "b"}})
+	s.Nil(result.Error)
+	s.Equal(uint64(committed.UnixMilli()), result.ID.Ms, "the stream ID should derive from the time of the command")
+
+	// the errors are part of the result
+	result = s.apply(3, Command{Op: enums.DBCommandStreamAdd, Time: committed, Key: "key", Fields: map[string]string{"a": "b"}})
+	s.Require().NotNil(result.Error)
+	s.ErrorIs(result.err(), db.ErrNotAStream)
+
+	result = s.apply(4, Command{Op: enums.DBCommandSetMany, Time: committed, Items: map[string]*db.StringOrSlice{
+		"a": {Val: "1"},
+		"b": {Val: []string{"2"}},
+	}})
+	s.Nil(result.Error)
+	item, err = s.fsm.db.Get("b")
+	s.Require().NoError(err)
+	s.Equal([]string{"2"}, item.Value.Val)
+	s.True(item.CreatedAt.Equal(committed))
+}
+
+func (s *FSMSuite) TestSnapshotRestore() {
+	s.apply(1, Command{Op: enums.DBCommandSet, Time: time.Now(), Key: "key1", Value: &db.StringOrSlice{Val: "value"}})
+	s.apply(2, Command{Op: enums.DBCommandSet, Time: time.Now(), Key: "key2", Value: &db.StringOrSlice{Val: []string{"a", "b"}}})
+
+	snapshot, err := s.fsm.Snapshot()
+	s.Require().NoError(err)
+	sink := &snapshotSink{}
+	s.Require().NoError(snapshot.Persist(sink))
+	snapshot.Release()
+
+	// the snapshot uses the same format as the full synchronization of the replicas
+	var decoded db.Snapshot
+	s.Require().NoError(json.Unmarshal(sink.Bytes(), &decoded))
+	s.Len(decoded.Items, 2)
+
+	restored := newFSM()
+	defer restored.db.Close()
+	s.Require().NoError(restored.db.Set("stale", "value"))
+	s.Require().NoError(restored.Restore(io.NopCloser(bytes.NewReader(sink.Bytes()))))
+
+	item, err := restored.db.Get("key2")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+	_, err = restored.db.Get("stale")
+	s.Error(err, "the restore should replace the content of the database")
+}
+
+func TestFSMSuite(t *testing.T) {
+	suite.Run(t, new(FSMSuite))
+}
+
+// snapshotSink is an in-memory raft.SnapshotSink.
+type snapshotSink struct {
+	bytes.Buffer
+}
+
+func (s *snapshotSink) ID() string    { return "test" }
+func (s *snapshotSink) Cancel() error { return nil }
+func (s *snapshotSink) Close() error  { return nil }
@@ -0,0 +1,746 @@
+/*
+The package cluster implements a strongly consistent cluster of memorydb nodes replicated with Raft.
+
+Every write to the database is a Command appended to the Raft log. The leader acknowledges a write only after
+the command is committed by a quorum of the nodes, so an acknowledged write survives the failure of any minority
+of the nodes. Every node applies the committed commands to its local database in the same order, with the clock
+set to the time assigned by the leader, so all the nodes end up with the same content.
+
+A Node implements the db.DBClient interface, so the rest of the server does not know whether it runs in cluster
+mode. Writes received by a follower are forwarded to the leader over HTTP, while reads are served from the local
+database and can be slightly behind the leader on followers.
+*/
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+var (
+	// Ensure Node implements DBClient interface
+	_ db.DBClient = (*Node)(nil)
+)
+
+const (
+	defaultApplyTimeout   = 5 * time.Second // default time to wait until a command is committed
+	snapshotsRetained     = 2               // number of Raft snapshots kept in the data directory
+	transportPoolSize     = 3               // number of connections kept to every node
+	transportTimeout      = 10 * time.Second
+	deliveryRetryInterval = time.Second           // maximum time a blocking group read waits before retrying the delivery
+	leaderPollInterval    = 10 * time.Millisecond // interval to check whether a leader was elected
+
+	// ApplyPath is the path where the leader receives the commands forwarded by the followers.
+	ApplyPath = "/api/v1/cluster/apply"
+)
+
+var (
+	// ErrNotLeader is returned when a forwarded command is received by a node that is not the leader.
+	ErrNotLeader = errors.New("the node is not the leader of the cluster")
+
+	// errNotSupported is returned by the operations that would bypass the Raft log, and by the conditional writes,
+	// since the versions of the items are assigned by every node on its own.
+	errNotSupported = errors.New("the operation is not supported in cluster mode")
+)
+
+// Peer is a node of the cluster.
+type Peer struct {
+	ID       string `json:"id"`        // unique ID of the node
+	RaftAddr string `json:"raft_addr"` // address where the node listens for Raft traffic
+	HTTPURL  string `json:"http_url"`  // base URL of the HTTP API of the node, used to forward writes
+}
+
+// ParsePeers parses a comma separated list of peers in the form <id>=<raft address>=<HTTP URL>,
+// such as node1=10.0.0.1:7000=http://10.0.0.1:8080.
+func ParsePeers(raw string) ([]Peer, error) {
+	var peers []Peer
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.SplitN(part, "=", 3)
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" || fields[2] == "" {
+			return nil, fmt.Errorf("invalid peer '%s', it must have the form <id>=<raft address>=<HTTP URL>", part)
+		}
+		if seen[fields[0]] {
+			return nil, fmt.Errorf("duplicated peer ID '%s'", fields[0])
+		}
+		seen[fields[0]] = true
+		peers = append(peers, Peer{ID: fields[0], RaftAddr: fields[1], HTTPURL: strings.TrimSuffix(fields[2], "/")})
+	}
+	return peers, nil
+}
+
+// Status is a snapshot of the Raft state of a node.
+type Status struct {
+	ID           string `json:"id"`            // ID of the node
+	State        string `json:"state"`         // Leader, Follower, Candidate or Shutdown
+	LeaderID     string `json:"leader_id"`     // ID of the current leader, empty if there is none
+	LeaderAddr   string `json:"leader_addr"`   // Raft address of the current leader
+	Term         uint64 `json:"term"`          // current term
+	CommitIndex  uint64 `json:"commit_index"`  // index of the last committed command
+	AppliedIndex uint64 `json:"applied_index"` // index of the last command applied to the local database
+	LastIndex    uint64 `json:"last_index"`    // index of the last command in the local log
+	Peers        []Peer `json:"peers"`         // nodes of the cluster
+}
+
+// NodeOptions defines an interface for applying options to the Node.
+type NodeOptions interface {
+	apply(*Node)
+}
+
+// WithDataDir sets the directory where the Raft log and snapshots are stored.
+// If it is not set, they are kept in memory and lost when the node stops.
+type WithDataDir string
+
+func (o WithDataDir) apply(n *Node) {
+	n.dataDir = string(o)
+}
+
+// WithApplyTimeout sets the time to wait until a write is committed before failing it.
+type WithApplyTimeout time.Duration
+
+func (o WithApplyTimeout) apply(n *Node) {
+	if o > 0 {
+		n.applyTimeout = time.Duration(o)
+	}
+}
+
+// WithHeartbeatTimeout sets the time without contact with the leader after which a follower starts an election.
+// Lower values detect failures faster but make elections more likely on slow networks.
+type WithHeartbeatTimeout time.Duration
+
+func (o WithHeartbeatTimeout) apply(n *Node) {
+	if o > 0 {
+		n.heartbeatTimeout = time.Duration(o)
+	}
+}
+
+// WithTransport sets the Raft transport, instead of a TCP transport listening at the Raft address of the node.
+// It is used to run several nodes in the same process.
+type WithTransport struct{ raft.Transport }
+
+func (o WithTransport) apply(n *Node) {
+	n.transport = o.Transport
+}
+
+// WithDBOptions sets the options of the local database of the node.
+type WithDBOptions []db.DBOptions
+
+func (o WithDBOptions) apply(n *Node) {
+	n.dbOpts = o
+}
+
+// WithBasicAuth sets the credentials of the administrator sent with the writes forwarded to the leader, which
+// requires them when the authentication is enabled.
+type WithBasicAuth struct{ Username, Password string }
+
+func (o WithBasicAuth) apply(n *Node) {
+	n.username, n.password = o.Username, o.Password
+}
+
+// Node is a member of the cluster. It implements db.DBClient on top of the Raft log.
+type Node struct {
+	logger *slog.Logger
+	id     string
+	peers  map[string]Peer
+	db     db.DBClient // local database, only modified by the state machine
+	fsm    *fsm
+	raft   *raft.Raft
+	client *http.Client // client used to forward the writes to the leader
+
+	readOnly atomic.Bool // whether the node rejects writes
+
+	// Optional settings
+	dataDir          string
+	applyTimeout     time.Duration
+	heartbeatTimeout time.Duration
+	transport        raft.Transport
+	dbOpts           []db.DBOptions
+	username         string // username of the administrator of the other nodes, not sent if empty
+	password         string // password of the administrator of the other nodes
+
+	closers []io.Closer // stores closed when the node stops
+}
+
+// NewNode creates the node with the given ID and joins it to the cluster made of the peers, which must include it.
+// The first time the nodes start, they bootstrap the cluster with the peers as members.
+func NewNode(logger *slog.Logger, id string, peers []Peer, opts ...NodeOptions) (*Node, error) {
+	n := &Node{
+		logger:       logger,
+		id:           id,
+		peers:        make(map[string]Peer, len(peers)),
+		applyTimeout: defaultApplyTimeout,
+	}
+	for _, peer := range peers {
+		n.peers[peer.ID] = peer
+	}
+	self, ok := n.peers[id]
+	if !ok {
+		return nil, fmt.Errorf("node %s is not in the list of peers", id)
+	}
+
+	for _, opt := range opts {
+		opt.apply(n)
+	}
+
+	// the clock of the local database follows the time of the commands while they are applied
+	n.fsm = &fsm{}
+	n.db = db.NewMemoryDB(logger, append(n.dbOpts, db.WithClock(n.fsm.now))...)
+	n.fsm.db = n.db
+	n.client = &http.Client{Timeout: n.applyTimeout + transportTimeout}
+
+	raftLogger := hclog.FromStandardLogger(slog.NewLogLogger(logger.Handler(), slog.LevelInfo), &hclog.LoggerOptions{
+		Name:  "raft",
+		Level: hclog.Info,
+	})
+
+	config := raft.DefaultConfig()
+	config.LocalID = raft.ServerID(id)
+	config.Logger = raftLogger
+	if n.heartbeatTimeout > 0 {
+		config.HeartbeatTimeout = n.heartbeatTimeout
+		config.ElectionTimeout = n.heartbeatTimeout
+		config.LeaderLeaseTimeout = n.heartbeatTimeout / 2
+	}
+
+	logStore, stableStore, snapshotStore, err := n.stores(raftLogger)
+	if err != nil {
+		n.db.Close()
+		return nil, err
+	}
+
+	if n.transport == nil {
+		n.transport, err = newTCPTransport(self.RaftAddr, raftLogger)
+		if err != nil {
+			n.close()
+			return nil, err
+		}
+	}
+	if closer, ok := n.transport.(io.Closer); ok {
+		n.closers = append(n.closers, closer)
+	}
+
+	// bootstrap the cluster the first time, every node does it with the same configuration
+	hasState, err := raft.HasExistingState(logStore, stableStore, snapshotStore)
+	if err != nil {
+		n.close()
+		return nil, fmt.Errorf("failed to check existing Raft state: %w", err)
+	}
+	if !hasState {
+		configuration := raft.Configuration{}
+		for _, peer := range peers {
+			configuration.Servers = append(configuration.Servers, raft.Server{
+				ID:      raft.ServerID(peer.ID),
+				Address: raft.ServerAddress(peer.RaftAddr),
+			})
+		}
+		if err := raft.BootstrapCluster(config, logStore, stableStore, snapshotStore, n.transport, configuration); err != nil {
+			n.close()
+			return nil, fmt.Errorf("failed to bootstrap cluster: %w", err)
+		}
+	}
+
+	n.raft, err = raft.NewRaft(config, n.fsm, logStore, stableStore, snapshotStore, n.transport)
+	if err != nil {
+		n.close()
+		return nil, fmt.Errorf("failed to start Raft: %w", err)
+	}
+
+	logger.Info("cluster node started", "id", id, "raft_addr", self.RaftAddr, "peers", len(peers))
+	return n, nil
+}
+
+// stores returns the Raft stores, in the data directory if it is set or in memory otherwise.
+func (n *Node) stores(logger hclog.Logger) (raft.LogStore, raft.StableStore, raft.SnapshotStore, error) {
+	if n.dataDir == "" {
+		store := raft.NewInmemStore()
+		return store, store, raft.NewInmemSnapshotStore(), nil
+	}
+
+	if err := os.MkdirAll(n.dataDir, 0o755); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create Raft data directory: %w", err)
+	}
+	boltStore, err := raftboltdb.NewBoltStore(filepath.Join(n.dataDir, "raft.db"))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open Raft log: %w", err)
+	}
+	n.closers = append(n.closers, boltStore)
+
+	snapshotStore, err := raft.NewFileSnapshotStoreWithLogger(n.dataDir, snapshotsRetained, logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open Raft snapshot store: %w", err)
+	}
+	return boltStore, boltStore, snapshotStore, nil
+}
+
+// newTCPTransport creates a Raft transport that listens on the port of the address and advertises the address.
+func newTCPTransport(addr string, logger hclog.Logger) (raft.Transport, error) {
+	advertise, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve Raft address %s: %w", addr, err)
+	}
+	bind := ":" + strconv.Itoa(advertise.Port)
+
+	transport, err := raft.NewTCPTransportWithLogger(bind, advertise, transportPoolSize, transportTimeout, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for Raft traffic on %s: %w", bind, err)
+	}
+	return transport, nil
+}
+
+// IsLeader returns whether the node is the leader of the cluster.
+func (n *Node) IsLeader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+// Status returns the Raft state of the node.
+func (n *Node) Status() Status {
+	leaderAddr, leaderID := n.raft.LeaderWithID()
+	stats := n.raft.Stats()
+	term, _ := strconv.ParseUint(stats["term"], 10, 64)
+	commitIndex, _ := strconv.ParseUint(stats["commit_index"], 10, 64)
+
+	status := Status{
+		ID:           n.id,
+		State:        n.raft.State().String(),
+		LeaderID:     string(leaderID),
+		LeaderAddr:   string(leaderAddr),
+		Term:         term,
+		CommitIndex:  commitIndex,
+		AppliedIndex: n.raft.AppliedIndex(),
+		LastIndex:    n.raft.LastIndex(),
+		Peers:        make([]Peer, 0, len(n.peers)),
+	}
+	for _, peer := range n.peers {
+		status.Peers = append(status.Peers, peer)
+	}
+	return status
+}
+
+// Apply commits a command if the node is the leader and returns its result. It returns ErrNotLeader otherwise,
+// so the node that forwarded the command can retry with the new leader.
+func (n *Node) Apply(cmd Command) (*Result, error) {
+	if !n.IsLeader() {
+		return nil, ErrNotLeader
+	}
+
+	// the leader assigns the time of the command, which is the clock every node uses to apply it
+	cmd.Time = time.Now()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	future := n.raft.Apply(data, n.applyTimeout)
+	if err := future.Error(); err != nil {
+		switch {
+		case errors.Is(err, raft.ErrNotLeader):
+			return nil, ErrNotLeader
+		case errors.Is(err, raft.ErrLeadershipLost), errors.Is(err, raft.ErrEnqueueTimeout), errors.Is(err, raft.ErrRaftShutdown):
+			// the command may or may not be committed, it cannot be retried safely
+			n.logger.Warn("failed to commit command", "op", cmd.Op, "key", cmd.Key, "error", err)
+			return nil, db.ErrNoLeader
+		default:
+			return nil, fmt.Errorf("failed to commit command: %w", err)
+		}
+	}
+
+	result, ok := future.Response().(*Result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response of type %T from the state machine", future.Response())
+	}
+	return result, nil
+}
+
+// execute commits the command through the leader, forwarding it if the node is a follower, and returns its result.
+func (n *Node) execute(cmd Command) (*Result, error) {
+	if n.readOnly.Load() {
+		return nil, db.ErrReadOnly
+	}
+
+	result, err := n.Apply(cmd)
+	if errors.Is(err, ErrNotLeader) {
+		result, err = n.forward(cmd)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, result.err()
+}
+
+// forward sends the command to the leader and returns its result.
+func (n *Node) forward(cmd Command) (*Result, error) {
+	leader, ok := n.waitForLeader()
+	if !ok {
+		return nil, db.ErrNoLeader
+	}
+
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode command: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, leader.HTTPURL+ApplyPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to leader %s: %w", leader.ID, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.username != "" {
+		req.SetBasicAuth(n.username, n.password)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		n.logger.Warn("failed to forward command to leader", "leader", leader.ID, "error", err)
+		return nil, db.ErrNoLeader
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusServiceUnavailable:
+		// the leader changed while the command was being forwarded
+		return nil, db.ErrNoLeader
+	default:
+		return nil, fmt.Errorf("received status code %d when forwarding command to leader %s", resp.StatusCode, leader.ID)
+	}
+
+	var result Result
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode result from leader %s: %w", leader.ID, err)
+	}
+	return &result, nil
+}
+
+// waitForLeader waits up to the apply timeout until the node knows another node as leader, which happens
+// during the elections, and returns it.
+func (n *Node) waitForLeader() (Peer, bool) {
+	deadline := time.Now().Add(n.applyTimeout)
+	for {
+		_, leaderID := n.raft.LeaderWithID()
+		if leader, ok := n.peers[string(leaderID)]; ok && leaderID != raft.ServerID(n.id) {
+			return leader, true
+		}
+		if time.Now().After(deadline) || n.raft.State() == raft.Shutdown {
+			return Peer{}, false
+		}
+		time.Sleep(leaderPollInterval)
+	}
+}
+
+// Get retrieves an item from the local database.
+func (n *Node) Get(key string) (*db.Item, error) {
+	return n.db.Get(key)
+}
+
+// Set stores an item with the specified key once the write is committed.
+func (n *Node) Set(key string, value any, opts ...db.ItemOptions) error {
+	if isConditional(opts) {
+		return errNotSupported
+	}
+	v, err := valueOf(value)
+	if err != nil {
+		return fmt.Errorf("failed to create value for key %s: %w", key, err)
+	}
+	_, err = n.execute(Command{Op: enums.DBCommandSet, Key: key, Value: v, TTL: ttlOf(opts)})
+	return err
+}
+
+// SetMany stores the items, all of them or none of them, once the write is committed.
+func (n *Node) SetMany(items map[string]any, opts ...db.ItemOptions) error {
+	values := make(map[string]*db.StringOrSlice, len(items))
+	for key, value := range items {
+		v, err := valueOf(value)
+		if err != nil {
+			return fmt.Errorf("failed to create value for key %s: %w", key, err)
+		}
+		values[key] = v
+	}
+	_, err := n.execute(Command{Op: enums.DBCommandSetMany, Items: values, TTL: ttlOf(opts)})
+	return err
+}
+
+// Update modifies an existing item once the write is committed.
+func (n *Node) Update(key string, value any, opts ...db.ItemOptions) error {
+	if isConditional(opts) {
+		return errNotSupported
+	}
+	v, err := valueOf(value)
+	if err != nil {
+		return fmt.Errorf("failed to update key %s: %w", key, err)
+	}
+	_, err = n.execute(Command{Op: enums.DBCommandUpdate, Key: key, Value: v, TTL: ttlOf(opts)})
+	return err
+}
+
+// Remove deletes an item once the write is committed.
+func (n *Node) Remove(key string) error {
+	_, err := n.execute(Command{Op: enums.DBCommandRemove, Key: key})
+	return err
+}
+
+// Push appends a value to the slice stored at the key once the write is committed.
+func (n *Node) Push(key string, value string, opts ...db.ItemOptions) (*db.Item, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandPush, Key: key, Value: &db.StringOrSlice{Val: value}, TTL: ttlOf(opts)})
+	if err != nil {
+		return nil, err
+	}
+	return result.Item, nil
+}
+
+// Pop removes the last value of the slice stored at the key once the write is committed.
+func (n *Node) Pop(key string) (*db.Item, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandPop, Key: key})
+	if err != nil {
+		return nil, err
+	}
+	return result.Item, nil
+}
+
+// StreamAdd appends an entry to the stream once the write is committed.
+func (n *Node) StreamAdd(key string, fields map[string]string, opts ...db.ItemOptions) (db.StreamID, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandStreamAdd, Key: key, Fields: fields, TTL: ttlOf(opts)})
+	if err != nil {
+		return db.StreamID{}, err
+	}
+	return result.ID, nil
+}
+
+// StreamRange returns entries of the stream from the local database.
+func (n *Node) StreamRange(key string, start, end string, count int) ([]db.StreamEntry, error) {
+	return n.db.StreamRange(key, start, end, count)
+}
+
+// StreamRead returns entries of the stream from the local database, waiting up to block for new entries.
+func (n *Node) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) ([]db.StreamEntry, error) {
+	return n.db.StreamRead(ctx, key, after, count, block)
+}
+
+// StreamTrim removes the oldest entries of the stream once the write is committed.
+func (n *Node) StreamTrim(key string, maxLen int, maxAge time.Duration) (int, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandStreamTrim, Key: key, MaxLen: maxLen, MaxAge: maxAge})
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// StreamGroupCreate creates a consumer group once the write is committed.
+func (n *Node) StreamGroupCreate(key string, group string, start string) error {
+	_, err := n.execute(Command{Op: enums.DBCommandStreamGroupCreate, Key: key, Group: group, Start: start})
+	return err
+}
+
+// StreamReadGroup delivers new entries to the consumer of the group once the delivery is committed.
+//
+// The delivery is a write, since it changes the pending entries of the group, so a blocking read waits on the local
+// database for new entries and commits a new delivery when they arrive, instead of blocking the Raft log.
+func (n *Node) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]db.StreamEntry, error) {
+	deadline := time.Now().Add(block)
+	for {
+		result, err := n.execute(Command{Op: enums.DBCommandStreamDeliver, Key: key, Group: group, Consumer: consumer, Count: count})
+		if err != nil {
+			return nil, err
+		}
+		remaining := time.Until(deadline)
+		if len(result.Entries) > 0 || block <= 0 || remaining <= 0 {
+			return result.Entries, nil
+		}
+
+		// an entry added between the delivery and the wait is only noticed on the next attempt, which bounds the delay
+		if _, err := n.db.StreamRead(ctx, key, "$", 1, min(remaining, deliveryRetryInterval)); err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// StreamAck acknowledges entries pending in the group once the write is committed.
+func (n *Node) StreamAck(key string, group string, ids ...string) (int, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandStreamAck, Key: key, Group: group, IDs: ids})
+	if err != nil {
+		return 0, err
+	}
+	return result.Count, nil
+}
+
+// StreamPending returns the pending entries of the group from the local database.
+func (n *Node) StreamPending(key string, group string, consumer string) ([]db.PendingEntry, error) {
+	return n.db.StreamPending(key, group, consumer)
+}
+
+// StreamClaim transfers pending entries of the group to the consumer once the write is committed.
+func (n *Node) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]db.StreamEntry, error) {
+	result, err := n.execute(Command{Op: enums.DBCommandStreamClaim, Key: key, Group: group, Consumer: consumer, MinIdle: minIdle, IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	return result.Entries, nil
+}
+
+// Subscribe returns the keyspace events of the local database, which include the committed writes of every node.
+func (n *Node) Subscribe(pattern string, lastEventID uint64) (<-chan db.Event, func()) {
+	return n.db.Subscribe(pattern, lastEventID)
+}
+
+// Keys returns the keys of the local database that match the glob pattern.
+func (n *Node) Keys(match string) []string {
+	return n.db.Keys(match)
+}
+
+// Dump returns the encoded item stored at the key in the local database.
+func (n *Node) Dump(key string) ([]byte, error) {
+	return n.db.Dump(key)
+}
+
+// Restore is not supported in cluster mode, the content of the database is only changed through the Raft log.
+func (n *Node) Restore(key string, data []byte) error {
+	return errNotSupported
+}
+
+// Backup writes a backup of the local database, which has every committed write applied by the node.
+func (n *Node) Backup(w io.Writer) (db.BackupHeader, error) {
+	return n.db.Backup(w)
+}
+
+// RestoreBackup is not supported in cluster mode, the content of the database is only changed through the Raft log.
+func (n *Node) RestoreBackup(r io.Reader, mode enums.RestoreMode) (int, error) {
+	return 0, errNotSupported
+}
+
+// WriteSnapshot writes a snapshot of the local database.
+func (n *Node) WriteSnapshot(w io.Writer) (uint64, error) {
+	return n.db.WriteSnapshot(w)
+}
+
+// LoadSnapshot is not supported in cluster mode, the content of the database is only changed through the Raft log.
+func (n *Node) LoadSnapshot(r io.Reader) (uint64, error) {
+	return 0, errNotSupported
+}
+
+// ReplicationFeed returns the operations applied to the local database, so asynchronous replicas can follow a node.
+func (n *Node) ReplicationFeed(offset uint64) (<-chan db.ReplicatedOperation, func(), error) {
+	return n.db.ReplicationFeed(offset)
+}
+
+// ApplyReplicated is not supported in cluster mode, the content of the database is only changed through the Raft log.
+func (n *Node) ApplyReplicated(op db.ReplicatedOperation) error {
+	return errNotSupported
+}
+
+// SetReadOnly sets whether the node rejects writes. The committed writes are still applied to the local database.
+func (n *Node) SetReadOnly(readOnly bool) {
+	n.readOnly.Store(readOnly)
+}
+
+// GetUser retrieves a user of the authentication module from the local database.
+func (n *Node) GetUser(username string) (*db.AuthItem, error) {
+	return n.db.GetUser(username)
+}
+
+// SetUser stores a user of the authentication module once the write is committed.
+func (n *Node) SetUser(user *db.AuthItem) error {
+	_, err := n.execute(Command{Op: enums.DBCommandUserSet, User: user})
+	return err
+}
+
+// GetAPIKey retrieves an API key of the authentication module from the local database.
+func (n *Node) GetAPIKey(id string) (*db.APIKeyItem, error) {
+	return n.db.GetAPIKey(id)
+}
+
+// SetAPIKey stores an API key of the authentication module once the write is committed.
+func (n *Node) SetAPIKey(key *db.APIKeyItem) error {
+	_, err := n.execute(Command{Op: enums.DBCommandAPIKeySet, APIKey: key})
+	return err
+}
+
+// RemoveAPIKey removes an API key of the authentication module once the write is committed.
+func (n *Node) RemoveAPIKey(id string) error {
+	_, err := n.execute(Command{Op: enums.DBCommandAPIKeyRemove, APIKey: &db.APIKeyItem{ID: id}})
+	return err
+}
+
+// APIKeys returns the API keys of the local database.
+func (n *Node) APIKeys() []*db.APIKeyItem {
+	return n.db.APIKeys()
+}
+
+// CreateNamespace is not supported in cluster mode, the operations of the namespaces are not in the Raft log.
+func (n *Node) CreateNamespace(name string, config db.NamespaceConfig) error {
+	return errNotSupported
+}
+
+// DropNamespace is not supported in cluster mode, the operations of the namespaces are not in the Raft log.
+func (n *Node) DropNamespace(name string) error {
+	return errNotSupported
+}
+
+// Namespace is not supported in cluster mode, the operations of the namespaces are not in the Raft log.
+func (n *Node) Namespace(name string) (db.DBClient, error) {
+	return nil, errNotSupported
+}
+
+// Namespaces returns no namespaces, since they cannot be created in cluster mode.
+func (n *Node) Namespaces() []db.NamespaceItem {
+	return nil
+}
+
+// WithContext returns the node, whose operations are not traced: the writes are applied by the Raft log.
+func (n *Node) WithContext(ctx context.Context) db.DBClient {
+	return n
+}
+
+// Health runs the checks of the local database.
+func (n *Node) Health(timeout time.Duration) []db.HealthCheck {
+	return n.db.Health(timeout)
+}
+
+// Stats returns the counters of the local database.
+func (n *Node) Stats() db.Stats {
+	return n.db.Stats()
+}
+
+// Close leaves the cluster and releases the resources of the node. The other nodes keep the node as a member,
+// so it can rejoin when it restarts with the same data directory.
+func (n *Node) Close() {
+	if err := n.raft.Shutdown().Error(); err != nil {
+		n.logger.Warn("failed to shut down Raft", "error", err)
+	}
+	n.close()
+}
+
+// close closes the stores and the local database.
+func (n *Node) close() {
+	for _, closer := range n.closers {
+		if err := closer.Close(); err != nil {
+			n.logger.Warn("failed to close Raft store", "error", err)
+		}
+	}
+	n.db.Close()
+}
@@ -0,0 +1,262 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/cluster"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/suite"
+)
+
+type NodeSuite struct {
+	nodes      []*cluster.Node
+	transports []*raft.InmemTransport
+	servers    []*httptest.Server
+	suite.Suite
+}
+
+// SetupTest starts a cluster of 3 nodes in the same process. The nodes exchange the Raft traffic in memory
+// and forward the writes to the leader through HTTP servers on localhost, with the authentication enabled.
+func (s *NodeSuite) SetupTest() {
+	const size = 3
+	s.nodes = make([]*cluster.Node, size)
+	s.transports = make([]*raft.InmemTransport, size)
+	s.servers = make([]*httptest.Server, size)
+
+	peers := make([]cluster.Peer, size)
+	for i := range size {
+		r := chi.NewRouter()
+		s.servers[i] = httptest.NewServer(r)
+		peers[i] = cluster.Peer{ID: fmt.Sprintf("node%d", i+1), RaftAddr: fmt.Sprintf("raft%d", i+1), HTTPURL: s.servers[i].URL}
+		_, s.transports[i] = raft.NewInmemTransport(raft.ServerAddress(peers[i].RaftAddr))
+	}
+	for i := range size {
+		for j := range size {
+			if i != j {
+				s.transports[i].Connect(raft.ServerAddress(peers[j].RaftAddr), s.transports[j])
+			}
+		}
+	}
+
+	// the passwords are hashed and verified with bcrypt before the nodes start, so they do not delay the heartbeats
+	managers := make([]*auth.Manager, size)
+	users := db.NewMemoryDB(slog.Default())
+	s.T().Cleanup(users.Close)
+	for i := range size {
+		manager, err := auth.NewManager(slog.Default(), users, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+		s.Require().NoError(err)
+		s.Require().NoError(manager.AuthenticateAdmin("admin", "admin-password"))
+		managers[i] = manager
+	}
+
+	for i := range size {
+		node, err := cluster.NewNode(
+			slog.Default(),
+			peers[i].ID,
+			peers,
+			cluster.WithTransport{Transport: s.transports[i]},
+			cluster.WithHeartbeatTimeout(50*time.Millisecond),
+			cluster.WithApplyTimeout(500*time.Millisecond),
+			cluster.WithBasicAuth{Username: "admin", Password: "admin-password"},
+		)
+		s.Require().NoError(err)
+		s.nodes[i] = node
+
+		server := transport.NewServer(slog.Default(), 0, 0, node, transport.WithClusterNode{Node: node}, transport.WithAuthManager{AuthManager: managers[i]})
+		s.servers[i].Config.Handler.(*chi.Mux).Mount("/", server.Handler())
+	}
+}
+
+func (s *NodeSuite) TearDownTest() {
+	for i := range s.nodes {
+		s.nodes[i].Close()
+		s.servers[i].Close()
+	}
+}
+
+// leader waits until one of the nodes is the leader and the other nodes know it, and returns its index.
+// The excluded nodes are ignored.
+func (s *NodeSuite) leader(exclude ...int) int {
+	index := -1
+	s.Require().Eventually(func() bool {
+		index = -1
+		for i, node := range s.nodes {
+			if node.IsLeader() && !contains(exclude, i) {
+				index = i
+			}
+		}
+		if index < 0 {
+			return false
+		}
+		for i, node := range s.nodes {
+			if !contains(exclude, i) && node.Status().LeaderID != s.nodes[index].Status().ID {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "no leader was elected")
+	return index
+}
+
+// follower returns the index of a node that is not the leader.
+func (s *NodeSuite) follower(leader int) int {
+	return (leader + 1) % len(s.nodes)
+}
+
+// waitForValue waits until the key holds the value in every node.
+func (s *NodeSuite) waitForValue(key string, value any) {
+	for _, node := range s.nodes {
+		s.Eventually(func() bool {
+			item, err := node.Get(key)
+			return err == nil && item.Value.Val == value
+		}, 2*time.Second, 10*time.Millisecond, "key %s was not applied to %s", key, node.Status().ID)
+	}
+}
+
+func contains(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *NodeSuite) TestWrite() {
+	leader := s.leader()
+
+	s.Require().NoError(s.nodes[leader].Set("key", "value"))
+	s.waitForValue("key", "value")
+
+	// the leader applies the write before acknowledging it
+	item, err := s.nodes[leader].Get("key")
+	s.Require().NoError(err)
+	s.Equal("value", item.Value.Val)
+
+	status := s.nodes[leader].Status()
+	s.Equal(raft.Leader.String(), status.State)
+	s.Equal(status.ID, status.LeaderID)
+	s.Len(status.Peers, 3)
+}
+
+func (s *NodeSuite) TestForward() {
+	leader := s.leader()
+	follower := s.nodes[s.follower(leader)]
+
+	// the follower forwards the write, which is committed and visible in the leader once acknowledged
+	s.Require().NoError(follower.Set("key", []string{"a", "b"}))
+	item, err := s.nodes[leader].Get("key")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+
+	item, err = follower.Push("key", "c")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b", "c"}, item.Value.Val)
+
+	// the errors of the database are returned to the follower, keeping the identity of the well-known ones
+	s.EqualError(follower.Update("missing", "value"), "key missing not found for update")
+	_, err = follower.StreamAdd("key", map[string]string{"field": "value"})
+	s.ErrorIs(err, db.ErrNotAStream)
+}
+
+func (s *NodeSuite) TestForwardRequiresCredentials() {
+	leader := s.leader()
+
+	// only the other nodes, with the credentials of the administrator, can apply commands
+	body := `{"op": "set", "key": "key", "value": "value"}`
+	resp, err := http.Post(s.servers[leader].URL+cluster.ApplyPath, "application/json", strings.NewReader(body))
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusUnauthorized, resp.StatusCode)
+	_, err = s.nodes[leader].Get("key")
+	s.Error(err)
+}
+
+func (s *NodeSuite) TestStream() {
+	leader := s.leader()
+	follower := s.nodes[s.follower(leader)]
+
+	id, err := follower.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+	s.Require().NoError(follower.StreamGroupCreate("stream", "group", "0"))
+
+	// the ID assigned by the leader is the same in every node
+	for _, node := range s.nodes {
+		s.Eventually(func() bool {
+			entries, err := node.StreamRange("stream", "-", "+", 0)
+			return err == nil && len(entries) == 1 && entries[0].ID == id
+		}, 2*time.Second, 10*time.Millisecond)
+	}
+
+	entries, err := follower.StreamReadGroup(context.Background(), "stream", "group", "consumer", 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 1)
+	s.Equal(id, entries[0].ID)
+
+	count, err := s.nodes[leader].StreamAck("stream", "group", id.String())
+	s.Require().NoError(err)
+	s.Equal(1, count)
+}
+
+func (s *NodeSuite) TestFailover() {
+	leader := s.leader()
+	s.Require().NoError(s.nodes[leader].Set("key", "value"))
+	s.waitForValue("key", "value")
+
+	// isolate the leader, the other nodes elect a new one and keep every acknowledged write
+	s.transports[leader].DisconnectAll()
+	for i, t := range s.transports {
+		if i != leader {
+			t.Disconnect(raft.ServerAddress(fmt.Sprintf("raft%d", leader+1)))
+		}
+	}
+	newLeader := s.leader(leader)
+
+	item, err := s.nodes[newLeader].Get("key")
+	s.Require().NoError(err)
+	s.Equal("value", item.Value.Val)
+	s.Require().NoError(s.nodes[newLeader].Set("key", "updated"))
+
+	// the isolated node cannot commit writes without a quorum
+	s.ErrorIs(s.nodes[leader].Set("other", "value"), db.ErrNoLeader)
+	_, err = s.nodes[newLeader].Get("other")
+	s.ErrorIs(err, db.ErrDataNotFound)
+}
+
+func (s *NodeSuite) TestReadOnly() {
+	leader := s.leader()
+	s.nodes[leader].SetReadOnly(true)
+	s.ErrorIs(s.nodes[leader].Set("key", "value"), db.ErrReadOnly)
+
+	s.nodes[leader].SetReadOnly(false)
+	s.NoError(s.nodes[leader].Set("key", "value"))
+}
+
+func (s *NodeSuite) TestParsePeers() {
+	peers, err := cluster.ParsePeers("node1=10.0.0.1:7000=http://10.0.0.1:8080/, node2=10.0.0.2:7000=http://10.0.0.2:8080")
+	s.Require().NoError(err)
+	s.Equal([]cluster.Peer{
+		{ID: "node1", RaftAddr: "10.0.0.1:7000", HTTPURL: "http://10.0.0.1:8080"},
+		{ID: "node2", RaftAddr: "10.0.0.2:7000", HTTPURL: "http://10.0.0.2:8080"},
+	}, peers)
+
+	_, err = cluster.ParsePeers("node1=10.0.0.1:7000")
+	s.Error(err, "peers must have an HTTP URL")
+	_, err = cluster.ParsePeers("node1=a=http://a,node1=b=http://b")
+	s.Error(err, "peer IDs must be unique")
+}
+
+func TestNodeSuite(t *testing.T) {
+	suite.Run(t, new(NodeSuite))
+}
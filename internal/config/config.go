@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"memorydb/internal/cluster"
 	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"net/url"
 	"time"
 
 	"github.com/spf13/viper"
@@ -13,15 +16,71 @@ type Config struct {
 	Verbose enums.VerboseLevel `mapstructure:"VERBOSE" validate:"required"`
 
 	// API configuration
-	ApiVersion string `mapstructure:"API_VERSION" validate:"required"`
-	Port       *int   `mapstructure:"PORT" validate:"required"`
-	HealthPort *int   `mapstructure:"HEALTH_PORT" validate:"required"`
+	ApiVersion    string `mapstructure:"API_VERSION" validate:"required"`
+	Port          *int   `mapstructure:"PORT" validate:"required"`
+	HealthPort    *int   `mapstructure:"HEALTH_PORT" validate:"required"`
+	RESPPort      int    `mapstructure:"RESP_PORT"`      // TCP port of the RESP protocol for Redis clients, 0 disables it
+	GRPCPort      int    `mapstructure:"GRPC_PORT"`      // TCP port of the gRPC API, 0 disables it
+	MemcachedPort int    `mapstructure:"MEMCACHED_PORT"` // TCP port of the memcached text protocol, 0 disables it
+
+	// Time the server keeps serving while its readiness probe fails before it shuts down, 0 shuts down at once
+	ShutdownDelay time.Duration `mapstructure:"SHUTDOWN_DELAY"`
+
+	// TLS configuration
+	TLSCertFile       string           `mapstructure:"TLS_CERT_FILE"`       // PEM certificate of the HTTP servers, empty serves plain HTTP
+	TLSKeyFile        string           `mapstructure:"TLS_KEY_FILE"`        // PEM private key of the certificate
+	TLSClientCAFile   string           `mapstructure:"TLS_CLIENT_CA_FILE"`  // PEM CA the certificates of the clients are verified against
+	TLSClientAuth     enums.ClientAuth `mapstructure:"TLS_CLIENT_AUTH"`     // Whether the main server verifies the certificates of the clients
+	TLSReloadInterval time.Duration    `mapstructure:"TLS_RELOAD_INTERVAL"` // Interval the certificate files are checked for changes, 0 disables it
+
+	// Rate limit configuration
+	RateLimitReads      float64 `mapstructure:"RATE_LIMIT_READS"`       // Reads per second of every client, 0 disables the limit
+	RateLimitReadBurst  int     `mapstructure:"RATE_LIMIT_READ_BURST"`  // Reads a client can send at once, 0 uses the rate
+	RateLimitWrites     float64 `mapstructure:"RATE_LIMIT_WRITES"`      // Writes per second of every client, 0 disables the limit
+	RateLimitWriteBurst int     `mapstructure:"RATE_LIMIT_WRITE_BURST"` // Writes a client can send at once, 0 uses the rate
+	MaxInFlight         int     `mapstructure:"MAX_IN_FLIGHT"`          // Requests served at once before shedding load, 0 disables the limit
+
+	// Tracing configuration
+	TracingExporter    enums.TracingExporter `mapstructure:"TRACING_EXPORTER"`     // Where the spans are exported, none disables the export
+	TracingEndpoint    string                `mapstructure:"TRACING_ENDPOINT"`     // URL of the OTLP/HTTP collector, empty uses the OTEL_EXPORTER_OTLP_* variables
+	TracingFile        string                `mapstructure:"TRACING_FILE"`         // Path of the file the spans are written to, "stdout" writes them to the standard output
+	TracingSampleRatio float64               `mapstructure:"TRACING_SAMPLE_RATIO"` // Fraction of the traces started by the server that are sampled
 
 	// Database configuration
 	DefaultTTL             time.Duration `mapstructure:"DEFAULT_TTL" validate:"required"`
 	DefaultCleanupInterval time.Duration `mapstructure:"DEFAULT_CLEANUP_INTERVAL" validate:"required"`
 	PersistenceEnabled     bool          `mapstructure:"PERSISTENCE_ENABLED"`
 	DBPath                 string        `mapstructure:"DB_PATH"` // Optional field that indicates the path where the database is stored
+
+	// Memory limit configuration
+	MaxMemory      int64                `mapstructure:"MAX_MEMORY"`      // Approximate memory limit in bytes, 0 disables the limit
+	EvictionPolicy enums.EvictionPolicy `mapstructure:"EVICTION_POLICY"` // Policy applied when the memory limit is reached
+
+	// Publish/subscribe configuration
+	PubSubBufferSize int `mapstructure:"PUBSUB_BUFFER_SIZE"` // Number of messages buffered per subscriber before it is dropped
+
+	// Replication configuration
+	ReplicaOf                string        `mapstructure:"REPLICA_OF"`                 // URL of the primary to replicate, empty for a primary
+	ReplicationBacklogSize   int           `mapstructure:"REPLICATION_BACKLOG_SIZE"`   // Number of operations kept so replicas can resume replication
+	ReplicationRetryInterval time.Duration `mapstructure:"REPLICATION_RETRY_INTERVAL"` // Time a replica waits before reconnecting to the primary
+
+	// Cluster configuration
+	ClusterNodeID       string        `mapstructure:"CLUSTER_NODE_ID"`       // ID of the node in the cluster, empty disables cluster mode
+	ClusterPeers        string        `mapstructure:"CLUSTER_PEERS"`         // Nodes of the cluster as <id>=<raft address>=<HTTP URL>, separated by commas
+	ClusterDataDir      string        `mapstructure:"CLUSTER_DATA_DIR"`      // Directory of the Raft log and snapshots, empty keeps them in memory
+	ClusterApplyTimeout time.Duration `mapstructure:"CLUSTER_APPLY_TIMEOUT"` // Time to wait until a write is committed by a quorum
+
+	// Hash slots configuration
+	SlotsNodeID string `mapstructure:"SLOTS_NODE_ID"` // ID of the node in the sharded topology, empty disables hash slots
+	SlotsNodes  string `mapstructure:"SLOTS_NODES"`   // Nodes of the topology as <id>=<URL>, separated by commas
+
+	// Authentication configuration
+	AuthEnabled         bool          `mapstructure:"AUTH_ENABLED"`           // Whether the data routes of the HTTP API require an access token
+	AuthSecret          string        `mapstructure:"AUTH_SECRET"`            // Secret the tokens are signed with, at least 32 bytes long
+	AuthAdminUsername   string        `mapstructure:"AUTH_ADMIN_USERNAME"`    // Username of the administrator, who registers the users
+	AuthAdminPassword   string        `mapstructure:"AUTH_ADMIN_PASSWORD"`    // Password of the administrator
+	AuthAccessTokenTTL  time.Duration `mapstructure:"AUTH_ACCESS_TOKEN_TTL"`  // Lifetime of the access tokens
+	AuthRefreshTokenTTL time.Duration `mapstructure:"AUTH_REFRESH_TOKEN_TTL"` // Lifetime of the refresh tokens
 }
 
 func (c *Config) SetDefaults() {
@@ -29,10 +88,46 @@ func (c *Config) SetDefaults() {
 	viper.SetDefault("API_VERSION", "v1")
 	viper.SetDefault("PORT", 8080)
 	viper.SetDefault("HEALTH_PORT", 8081)
+	viper.SetDefault("RESP_PORT", 0)
+	viper.SetDefault("GRPC_PORT", 0)
+	viper.SetDefault("MEMCACHED_PORT", 0)
+	viper.SetDefault("SHUTDOWN_DELAY", 0)
+	viper.SetDefault("TLS_CERT_FILE", "")
+	viper.SetDefault("TLS_KEY_FILE", "")
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "")
+	viper.SetDefault("TLS_CLIENT_AUTH", enums.ClientAuthNone.String())
+	viper.SetDefault("TLS_RELOAD_INTERVAL", 30*time.Second)
+	viper.SetDefault("RATE_LIMIT_READS", 0)
+	viper.SetDefault("RATE_LIMIT_READ_BURST", 0)
+	viper.SetDefault("RATE_LIMIT_WRITES", 0)
+	viper.SetDefault("RATE_LIMIT_WRITE_BURST", 0)
+	viper.SetDefault("MAX_IN_FLIGHT", 0)
+	viper.SetDefault("TRACING_EXPORTER", enums.TracingExporterNone.String())
+	viper.SetDefault("TRACING_ENDPOINT", "")
+	viper.SetDefault("TRACING_FILE", "stdout")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1)
 	viper.SetDefault("DEFAULT_TTL", 5*time.Minute)
 	viper.SetDefault("DEFAULT_CLEANUP_INTERVAL", 10*time.Minute)
 	viper.SetDefault("PERSISTENCE_ENABLED", false)
 	viper.SetDefault("DB_PATH", "/tmp/memorydb.db") // Default path for the database file
+	viper.SetDefault("MAX_MEMORY", 0)
+	viper.SetDefault("EVICTION_POLICY", enums.EvictionPolicyNoEviction.String())
+	viper.SetDefault("PUBSUB_BUFFER_SIZE", 128)
+	viper.SetDefault("REPLICA_OF", "")
+	viper.SetDefault("REPLICATION_BACKLOG_SIZE", 10000)
+	viper.SetDefault("REPLICATION_RETRY_INTERVAL", time.Second)
+	viper.SetDefault("CLUSTER_NODE_ID", "")
+	viper.SetDefault("CLUSTER_PEERS", "")
+	viper.SetDefault("CLUSTER_DATA_DIR", "")
+	viper.SetDefault("CLUSTER_APPLY_TIMEOUT", 5*time.Second)
+	viper.SetDefault("SLOTS_NODE_ID", "")
+	viper.SetDefault("SLOTS_NODES", "")
+	viper.SetDefault("AUTH_ENABLED", false)
+	viper.SetDefault("AUTH_SECRET", "")
+	viper.SetDefault("AUTH_ADMIN_USERNAME", "admin")
+	viper.SetDefault("AUTH_ADMIN_PASSWORD", "")
+	viper.SetDefault("AUTH_ACCESS_TOKEN_TTL", 15*time.Minute)
+	viper.SetDefault("AUTH_REFRESH_TOKEN_TTL", 24*time.Hour)
 }
 
 // LoadConfig loads the configuration from environment variables and sets defaults.
@@ -50,9 +145,223 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid verbose level: %s", cfg.Verbose)
 	}
 
+	if !cfg.EvictionPolicy.IsValid() {
+		return nil, fmt.Errorf("invalid eviction policy: %s", cfg.EvictionPolicy)
+	}
+
+	if cfg.RESPPort < 0 {
+		return nil, fmt.Errorf("RESP_PORT must be greater than or equal to 0")
+	}
+	if cfg.RESPPort > 0 && (cfg.RESPPort == *cfg.Port || cfg.RESPPort == *cfg.HealthPort) {
+		return nil, fmt.Errorf("RESP_PORT must be different from PORT and HEALTH_PORT")
+	}
+
+	if cfg.GRPCPort < 0 {
+		return nil, fmt.Errorf("GRPC_PORT must be greater than or equal to 0")
+	}
+	if cfg.GRPCPort > 0 && (cfg.GRPCPort == *cfg.Port || cfg.GRPCPort == *cfg.HealthPort || cfg.GRPCPort == cfg.RESPPort) {
+		return nil, fmt.Errorf("GRPC_PORT must be different from PORT, HEALTH_PORT and RESP_PORT")
+	}
+
+	if cfg.MemcachedPort < 0 {
+		return nil, fmt.Errorf("MEMCACHED_PORT must be greater than or equal to 0")
+	}
+	if cfg.MemcachedPort > 0 && (cfg.MemcachedPort == *cfg.Port || cfg.MemcachedPort == *cfg.HealthPort ||
+		cfg.MemcachedPort == cfg.RESPPort || cfg.MemcachedPort == cfg.GRPCPort) {
+		return nil, fmt.Errorf("MEMCACHED_PORT must be different from PORT, HEALTH_PORT, RESP_PORT and GRPC_PORT")
+	}
+	// the clients of memcached pick the server of each key on their own, and the cluster does not version the items
+	if cfg.MemcachedPort > 0 && (cfg.ClusterNodeID != "" || cfg.SlotsNodeID != "") {
+		return nil, fmt.Errorf("MEMCACHED_PORT cannot be used with CLUSTER_NODE_ID or SLOTS_NODE_ID")
+	}
+
+	if cfg.ShutdownDelay < 0 {
+		return nil, fmt.Errorf("SHUTDOWN_DELAY must be greater than or equal to 0")
+	}
+
+	if err := cfg.validateTLS(); err != nil {
+		return nil, err
+	}
+
+	if cfg.RateLimitReads < 0 || cfg.RateLimitWrites < 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_READS and RATE_LIMIT_WRITES must be greater than or equal to 0")
+	}
+	if cfg.RateLimitReadBurst < 0 || cfg.RateLimitWriteBurst < 0 {
+		return nil, fmt.Errorf("RATE_LIMIT_READ_BURST and RATE_LIMIT_WRITE_BURST must be greater than or equal to 0")
+	}
+	if cfg.MaxInFlight < 0 {
+		return nil, fmt.Errorf("MAX_IN_FLIGHT must be greater than or equal to 0")
+	}
+
+	if err := cfg.validateTracing(); err != nil {
+		return nil, err
+	}
+
+	if cfg.MaxMemory < 0 {
+		return nil, fmt.Errorf("MAX_MEMORY must be greater than or equal to 0")
+	}
+
 	if cfg.PersistenceEnabled && cfg.DBPath == "" {
 		return nil, fmt.Errorf("DB_PATH must be set when persistence is enabled")
 	}
 
+	if cfg.PubSubBufferSize <= 0 {
+		return nil, fmt.Errorf("PUBSUB_BUFFER_SIZE must be greater than 0")
+	}
+
+	if cfg.ReplicationBacklogSize < 0 {
+		return nil, fmt.Errorf("REPLICATION_BACKLOG_SIZE must be greater than or equal to 0")
+	}
+
+	if cfg.ReplicaOf != "" {
+		primary, err := url.Parse(cfg.ReplicaOf)
+		if err != nil || (primary.Scheme != "http" && primary.Scheme != "https") || primary.Host == "" {
+			return nil, fmt.Errorf("REPLICA_OF must be the URL of the primary, such as http://primary:8080")
+		}
+		if cfg.ReplicationRetryInterval <= 0 {
+			return nil, fmt.Errorf("REPLICATION_RETRY_INTERVAL must be greater than 0")
+		}
+	}
+
+	if cfg.ClusterNodeID != "" {
+		if err := cfg.validateCluster(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.SlotsNodeID != "" {
+		if err := cfg.validateSlots(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.AuthEnabled {
+		if err := cfg.validateAuth(); err != nil {
+			return nil, err
+		}
+	}
+
 	return cfg, nil
 }
+
+// validateTLS validates the configuration of TLS.
+func (c *Config) validateTLS() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if !c.TLSClientAuth.IsValid() {
+		return fmt.Errorf("invalid TLS client auth: %s", c.TLSClientAuth)
+	}
+	if c.TLSClientAuth != enums.ClientAuthNone {
+		if c.TLSCertFile == "" {
+			return fmt.Errorf("TLS_CLIENT_AUTH requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		if c.TLSClientCAFile == "" {
+			return fmt.Errorf("TLS_CLIENT_CA_FILE must be set when TLS_CLIENT_AUTH is %s", c.TLSClientAuth)
+		}
+	}
+	if c.TLSReloadInterval < 0 {
+		return fmt.Errorf("TLS_RELOAD_INTERVAL must be greater than or equal to 0")
+	}
+	return nil
+}
+
+// validateTracing validates the configuration of the tracing.
+func (c *Config) validateTracing() error {
+	if !c.TracingExporter.IsValid() {
+		return fmt.Errorf("invalid tracing exporter: %s", c.TracingExporter)
+	}
+	if c.TracingExporter == enums.TracingExporterFile && c.TracingFile == "" {
+		return fmt.Errorf("TRACING_FILE must be set when TRACING_EXPORTER is %s", c.TracingExporter)
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+	return nil
+}
+
+// validateAuth validates the configuration of the authentication.
+func (c *Config) validateAuth() error {
+	if len(c.AuthSecret) < 32 {
+		return fmt.Errorf("AUTH_SECRET must be at least 32 bytes long")
+	}
+	if c.AuthAdminUsername == "" || c.AuthAdminPassword == "" {
+		return fmt.Errorf("AUTH_ADMIN_USERNAME and AUTH_ADMIN_PASSWORD must be set when authentication is enabled")
+	}
+	// bcrypt does not hash longer passwords
+	if len(c.AuthAdminPassword) > 72 {
+		return fmt.Errorf("AUTH_ADMIN_PASSWORD cannot be longer than 72 bytes")
+	}
+	if c.AuthAccessTokenTTL <= 0 || c.AuthRefreshTokenTTL <= 0 {
+		return fmt.Errorf("AUTH_ACCESS_TOKEN_TTL and AUTH_REFRESH_TOKEN_TTL must be greater than 0")
+	}
+	if c.AuthRefreshTokenTTL < c.AuthAccessTokenTTL {
+		return fmt.Errorf("AUTH_REFRESH_TOKEN_TTL must be greater than or equal to AUTH_ACCESS_TOKEN_TTL")
+	}
+	return nil
+}
+
+// validateSlots validates the configuration of the hash slots.
+func (c *Config) validateSlots() error {
+	nodes, err := slots.ParseNodes(c.SlotsNodes)
+	if err != nil {
+		return fmt.Errorf("invalid SLOTS_NODES: %w", err)
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("SLOTS_NODES must list the nodes of the topology")
+	}
+
+	found := false
+	for _, node := range nodes {
+		if node.ID == c.SlotsNodeID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("SLOTS_NODE_ID %s must be one of the nodes in SLOTS_NODES", c.SlotsNodeID)
+	}
+
+	// the keys are moved between nodes by restoring them, which the Raft log does not support
+	if c.ClusterNodeID != "" {
+		return fmt.Errorf("SLOTS_NODE_ID cannot be set in cluster mode")
+	}
+	return nil
+}
+
+// validateCluster validates the configuration of the cluster mode.
+//
+// Every node must apply the writes in the same way, so the features that change the database on their own,
+// such as eviction or the replication from a primary, cannot be combined with the cluster mode.
+func (c *Config) validateCluster() error {
+	peers, err := cluster.ParsePeers(c.ClusterPeers)
+	if err != nil {
+		return fmt.Errorf("invalid CLUSTER_PEERS: %w", err)
+	}
+	if len(peers) != 3 && len(peers) != 5 {
+		return fmt.Errorf("CLUSTER_PEERS must list 3 or 5 nodes, got %d", len(peers))
+	}
+
+	found := false
+	for _, peer := range peers {
+		if peer.ID == c.ClusterNodeID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("CLUSTER_NODE_ID %s must be one of the nodes in CLUSTER_PEERS", c.ClusterNodeID)
+	}
+
+	if c.ClusterApplyTimeout <= 0 {
+		return fmt.Errorf("CLUSTER_APPLY_TIMEOUT must be greater than 0")
+	}
+	if c.ReplicaOf != "" {
+		return fmt.Errorf("REPLICA_OF cannot be set in cluster mode")
+	}
+	if c.PersistenceEnabled {
+		return fmt.Errorf("PERSISTENCE_ENABLED cannot be set in cluster mode, the data is persisted in CLUSTER_DATA_DIR")
+	}
+	if c.MaxMemory > 0 && c.EvictionPolicy != enums.EvictionPolicyNoEviction {
+		return fmt.Errorf("EVICTION_POLICY must be %s in cluster mode", enums.EvictionPolicyNoEviction)
+	}
+	return nil
+}
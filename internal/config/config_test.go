@@ -2,7 +2,9 @@ package config_test
 
 import (
 	"memorydb/internal/config"
+	"memorydb/internal/enums"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/suite"
@@ -45,6 +47,215 @@ func (suite *ConfigSuite) TestLoadConfig() {
 		suite.Contains(err.Error(), "invalid verbose level")
 	})
 
+	suite.Run("Cluster", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("CLUSTER_NODE_ID", "node1")
+		defer viper.Set("CLUSTER_NODE_ID", "")
+
+		viper.Set("CLUSTER_PEERS", "node1=127.0.0.1:7001=http://127.0.0.1:8001,node2=127.0.0.1:7002=http://127.0.0.1:8002")
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "3 or 5 nodes", "a cluster needs an odd number of nodes")
+
+		viper.Set("CLUSTER_PEERS", "node2=127.0.0.1:7002=http://127.0.0.1:8002,node3=127.0.0.1:7003=http://127.0.0.1:8003,node4=127.0.0.1:7004=http://127.0.0.1:8004")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "must be one of the nodes")
+
+		viper.Set("CLUSTER_PEERS", "node1=127.0.0.1:7001=http://127.0.0.1:8001,node2=127.0.0.1:7002=http://127.0.0.1:8002,node3=127.0.0.1:7003=http://127.0.0.1:8003")
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal("node1", cfg.ClusterNodeID)
+
+		viper.Set("REPLICA_OF", "http://primary:8080")
+		defer viper.Set("REPLICA_OF", "")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "REPLICA_OF cannot be set in cluster mode")
+	})
+
+	suite.Run("Slots", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("SLOTS_NODE_ID", "node3")
+		defer viper.Set("SLOTS_NODE_ID", "")
+
+		viper.Set("SLOTS_NODES", "node1=http://127.0.0.1:8001,node2=http://127.0.0.1:8002")
+		defer viper.Set("SLOTS_NODES", "")
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "must be one of the nodes")
+
+		viper.Set("SLOTS_NODE_ID", "node1")
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal("node1", cfg.SlotsNodeID)
+	})
+
+	suite.Run("RESP", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("PORT", 8080)
+		defer viper.Set("RESP_PORT", 0)
+
+		viper.Set("RESP_PORT", 8080)
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "RESP_PORT must be different")
+
+		viper.Set("RESP_PORT", 6379)
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(6379, cfg.RESPPort)
+	})
+
+	suite.Run("gRPC", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("PORT", 8080)
+		defer viper.Set("GRPC_PORT", 0)
+
+		viper.Set("GRPC_PORT", 8080)
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "GRPC_PORT must be different")
+
+		viper.Set("GRPC_PORT", 9090)
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(9090, cfg.GRPCPort)
+	})
+
+	suite.Run("memcached", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("PORT", 8080)
+		defer viper.Set("MEMCACHED_PORT", 0)
+
+		viper.Set("MEMCACHED_PORT", 8080)
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "MEMCACHED_PORT must be different")
+
+		viper.Set("MEMCACHED_PORT", 11211)
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(11211, cfg.MemcachedPort)
+
+		viper.Set("SLOTS_NODE_ID", "node1")
+		defer viper.Set("SLOTS_NODE_ID", "")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "MEMCACHED_PORT cannot be used")
+	})
+
+	suite.Run("Auth", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("AUTH_ENABLED", true)
+		defer viper.Set("AUTH_ENABLED", false)
+
+		viper.Set("AUTH_SECRET", "short")
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "AUTH_SECRET must be at least 32 bytes")
+
+		viper.Set("AUTH_SECRET", "0123456789abcdef0123456789abcdef")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "AUTH_ADMIN_PASSWORD must be set", "the administrator has no default password")
+
+		viper.Set("AUTH_ADMIN_PASSWORD", "admin-password")
+		viper.Set("AUTH_REFRESH_TOKEN_TTL", "1m")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "AUTH_REFRESH_TOKEN_TTL must be greater than or equal")
+
+		viper.Set("AUTH_REFRESH_TOKEN_TTL", "1h")
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal("admin", cfg.AuthAdminUsername)
+		suite.Equal(15*time.Minute, cfg.AuthAccessTokenTTL)
+
+		// the listeners authenticate their clients too
+		for _, port := range []string{"RESP_PORT", "GRPC_PORT", "MEMCACHED_PORT"} {
+			viper.Set(port, 16379)
+			_, err = config.LoadConfig()
+			suite.NoError(err, port)
+			viper.Set(port, 0)
+		}
+	})
+
+	suite.Run("TLS", func() {
+		viper.Set("VERBOSE", "info")
+		viper.Set("TLS_CERT_FILE", "/certs/tls.crt")
+		defer viper.Set("TLS_CERT_FILE", "")
+		defer viper.Set("TLS_KEY_FILE", "")
+		defer viper.Set("TLS_CLIENT_AUTH", "none")
+
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+
+		viper.Set("TLS_KEY_FILE", "/certs/tls.key")
+		viper.Set("TLS_CLIENT_AUTH", "always")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "invalid TLS client auth")
+
+		viper.Set("TLS_CLIENT_AUTH", "require")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "TLS_CLIENT_CA_FILE must be set")
+
+		viper.Set("TLS_CLIENT_CA_FILE", "/certs/ca.crt")
+		defer viper.Set("TLS_CLIENT_CA_FILE", "")
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(enums.ClientAuthRequire, cfg.TLSClientAuth)
+		suite.Equal(30*time.Second, cfg.TLSReloadInterval)
+	})
+
+	suite.Run("Tracing", func() {
+		viper.Set("VERBOSE", "info")
+		defer viper.Set("TRACING_EXPORTER", "none")
+		defer viper.Set("TRACING_FILE", "stdout")
+		defer viper.Set("TRACING_SAMPLE_RATIO", 1)
+
+		viper.Set("TRACING_EXPORTER", "jaeger")
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "invalid tracing exporter")
+
+		viper.Set("TRACING_EXPORTER", "file")
+		viper.Set("TRACING_FILE", "")
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "TRACING_FILE must be set")
+
+		viper.Set("TRACING_FILE", "stdout")
+		viper.Set("TRACING_SAMPLE_RATIO", 1.5)
+		_, err = config.LoadConfig()
+		suite.ErrorContains(err, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+		viper.Set("TRACING_SAMPLE_RATIO", 0.25)
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(enums.TracingExporterFile, cfg.TracingExporter)
+		suite.Equal(0.25, cfg.TracingSampleRatio)
+	})
+
+	suite.Run("ShutdownDelay", func() {
+		viper.Set("VERBOSE", "info")
+		defer viper.Set("SHUTDOWN_DELAY", 0)
+
+		viper.Set("SHUTDOWN_DELAY", "-1s")
+		_, err := config.LoadConfig()
+		suite.ErrorContains(err, "SHUTDOWN_DELAY must be greater than or equal to 0")
+
+		viper.Set("SHUTDOWN_DELAY", "5s")
+		cfg, err := config.LoadConfig()
+		suite.Require().NoError(err)
+		suite.Equal(5*time.Second, cfg.ShutdownDelay)
+	})
+
+}
+
+func (suite *ConfigSuite) TestLoadProxyConfig() {
+	viper.Set("VERBOSE", "info")
+	defer viper.Set("PROXY_BACKENDS", "")
+
+	_, err := config.LoadProxyConfig()
+	suite.ErrorContains(err, "PROXY_BACKENDS must list the backends")
+
+	viper.Set("PROXY_BACKENDS", "http://db1:8080")
+	_, err = config.LoadProxyConfig()
+	suite.ErrorContains(err, "invalid PROXY_BACKENDS")
+
+	viper.Set("PROXY_BACKENDS", "http://db1:8080=http://db1:8081/health,http://db2:8080=http://db2:8081/health")
+	cfg, err := config.LoadProxyConfig()
+	suite.Require().NoError(err)
+	suite.Equal(3, cfg.FailureThreshold)
+	suite.Equal(160, cfg.VirtualNodes)
 }
 
 func TestConfigSuite(t *testing.T) {
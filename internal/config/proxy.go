@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"memorydb/internal/enums"
+	"memorydb/internal/proxy"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type ProxyConfig struct {
+	// Common configuration
+	Verbose enums.VerboseLevel `mapstructure:"VERBOSE" validate:"required"`
+
+	// API configuration
+	Port       *int `mapstructure:"PORT" validate:"required"`
+	HealthPort *int `mapstructure:"HEALTH_PORT" validate:"required"`
+
+	// Backends configuration
+	Backends         string        `mapstructure:"PROXY_BACKENDS"`          // Backends as <API URL>=<health check URL>, separated by commas
+	HealthInterval   time.Duration `mapstructure:"PROXY_HEALTH_INTERVAL"`   // Interval between the health checks of every backend
+	HealthTimeout    time.Duration `mapstructure:"PROXY_HEALTH_TIMEOUT"`    // Timeout of a health check
+	FailureThreshold int           `mapstructure:"PROXY_FAILURE_THRESHOLD"` // Failed checks in a row that take a backend out of rotation
+	VirtualNodes     int           `mapstructure:"PROXY_VIRTUAL_NODES"`     // Points of every backend in the hash ring
+}
+
+func (c *ProxyConfig) SetDefaults() {
+	viper.SetDefault("VERBOSE", enums.VerboseLevelInfo.String())
+	viper.SetDefault("PORT", 8080)
+	viper.SetDefault("HEALTH_PORT", 8081)
+	viper.SetDefault("PROXY_BACKENDS", "")
+	viper.SetDefault("PROXY_HEALTH_INTERVAL", 2*time.Second)
+	viper.SetDefault("PROXY_HEALTH_TIMEOUT", time.Second)
+	viper.SetDefault("PROXY_FAILURE_THRESHOLD", 3)
+	viper.SetDefault("PROXY_VIRTUAL_NODES", 160)
+}
+
+// LoadProxyConfig loads the configuration of the proxy from environment variables and sets defaults.
+func LoadProxyConfig() (*ProxyConfig, error) {
+	cfg := new(ProxyConfig)
+	cfg.SetDefaults()
+
+	viper.AutomaticEnv() // Automatically read environment variables
+	if err := viper.Unmarshal(cfg); err != nil {
+		return nil, err // Return error if unmarshalling fails
+	}
+
+	// Validate the configuration
+	if !cfg.Verbose.IsValid() {
+		return nil, fmt.Errorf("invalid verbose level: %s", cfg.Verbose)
+	}
+
+	backends, err := proxy.ParseBackends(cfg.Backends)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY_BACKENDS: %w", err)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("PROXY_BACKENDS must list the backends of the proxy")
+	}
+
+	if cfg.HealthInterval <= 0 {
+		return nil, fmt.Errorf("PROXY_HEALTH_INTERVAL must be greater than 0")
+	}
+	if cfg.HealthTimeout <= 0 {
+		return nil, fmt.Errorf("PROXY_HEALTH_TIMEOUT must be greater than 0")
+	}
+	if cfg.FailureThreshold <= 0 {
+		return nil, fmt.Errorf("PROXY_FAILURE_THRESHOLD must be greater than 0")
+	}
+	if cfg.VirtualNodes <= 0 {
+		return nil, fmt.Errorf("PROXY_VIRTUAL_NODES must be greater than 0")
+	}
+
+	return cfg, nil
+}
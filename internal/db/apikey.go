@@ -0,0 +1,139 @@
+package db
+
+import (
+	"fmt"
+	"memorydb/internal/enums"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ACLRule grants permissions on the keys that match its pattern.
+type ACLRule struct {
+	Pattern     string             `json:"pattern"`             // a key, or a key prefix followed by `*`, such as billing:*
+	Permissions []enums.Permission `json:"permissions"`         // permissions granted on the matching keys
+	Namespace   string             `json:"namespace,omitempty"` // namespace of the keys, empty for the database and `*` for all
+}
+
+// APIKeyItem is an API key of the authentication module. Like the users, the API keys are kept apart from the items.
+//
+// Only the hash of the secret of the key is stored, the secret is returned once when the key is created.
+type APIKeyItem struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`              // name given by the administrator, such as the team that uses the key
+	Subject   string    `json:"subject,omitempty"` // identity of the client certificates authenticated as the key, if any
+	Hash      string    `json:"hash"`              // SHA-256 of the secret of the key, hex encoded
+	Rules     []ACLRule `json:"rules"`             // keys the API key can access
+	CreatedAt time.Time `json:"created_at"`        // time the key was created
+}
+
+// clone returns a copy of the API key that does not share its rules.
+func (k *APIKeyItem) clone() *APIKeyItem {
+	copied := *k
+	copied.Rules = slices.Clone(k.Rules)
+	return &copied
+}
+
+// GetAPIKey retrieves an API key by its ID.
+func (db *memoryDB) GetAPIKey(id string) (*APIKeyItem, error) {
+	db.authMu.RLock()
+	defer db.authMu.RUnlock()
+
+	key, exists := db.apiKeys[id]
+	if !exists {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key.clone(), nil
+}
+
+// SetAPIKey stores an API key, replacing any previous key with the same ID.
+func (db *memoryDB) SetAPIKey(key *APIKeyItem) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	// the lock of the items serializes the writes to the operation log
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored := key.clone()
+	db.authMu.Lock()
+	db.apiKeys[stored.ID] = stored
+	db.authMu.Unlock()
+
+	db.logOperation(&Operation{Command: enums.DBCommandAPIKeySet, Time: db.now(), APIKey: stored})
+	return nil
+}
+
+// RemoveAPIKey removes an API key by its ID, so it can no longer be used.
+func (db *memoryDB) RemoveAPIKey(id string) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.authMu.Lock()
+	_, exists := db.apiKeys[id]
+	delete(db.apiKeys, id)
+	db.authMu.Unlock()
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+
+	db.logOperation(&Operation{Command: enums.DBCommandAPIKeyRemove, Time: db.now(), APIKey: &APIKeyItem{ID: id}})
+	return nil
+}
+
+// APIKeys returns the API keys sorted by creation time.
+func (db *memoryDB) APIKeys() []*APIKeyItem {
+	db.authMu.RLock()
+	defer db.authMu.RUnlock()
+
+	keys := make([]*APIKeyItem, 0, len(db.apiKeys))
+	for _, key := range db.apiKeys {
+		keys = append(keys, key.clone())
+	}
+	sortAPIKeys(keys)
+	return keys
+}
+
+// apiKeysByID returns a copy of the API keys, by ID.
+func (db *memoryDB) apiKeysByID() map[string]*APIKeyItem {
+	db.authMu.RLock()
+	defer db.authMu.RUnlock()
+
+	keys := make(map[string]*APIKeyItem, len(db.apiKeys))
+	for id, key := range db.apiKeys {
+		keys[id] = key.clone()
+	}
+	return keys
+}
+
+// replayAPIKey stores or removes the API key of an operation of the log.
+func (db *memoryDB) replayAPIKey(op *Operation) error {
+	if op.APIKey == nil {
+		return fmt.Errorf("missing API key for command %s", op.Command)
+	}
+
+	db.authMu.Lock()
+	defer db.authMu.Unlock()
+
+	if op.Command == enums.DBCommandAPIKeyRemove {
+		delete(db.apiKeys, op.APIKey.ID)
+		return nil
+	}
+	db.apiKeys[op.APIKey.ID] = op.APIKey.clone()
+	return nil
+}
+
+// sortAPIKeys sorts the API keys by creation time, and by ID the keys created at the same time.
+func sortAPIKeys(keys []*APIKeyItem) {
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+}
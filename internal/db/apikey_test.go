@@ -0,0 +1,114 @@
+package db
+
+import (
+	"bytes"
+	"log/slog"
+	"memorydb/internal/enums"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeySuite struct {
+	suite.Suite
+}
+
+// newAPIKey returns an API key that can read and write the keys with the prefix.
+func newAPIKey(id, prefix string) *APIKeyItem {
+	return &APIKeyItem{
+		ID:        id,
+		Name:      "team " + id,
+		Hash:      "hash",
+		Rules:     []ACLRule{{Pattern: prefix + "*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}}},
+		CreatedAt: time.Now(),
+	}
+}
+
+func (s *APIKeySuite) TestSetAPIKey() {
+	db := NewMemoryDB(slog.Default())
+	defer db.Close()
+
+	_, err := db.GetAPIKey("a")
+	s.ErrorIs(err, ErrAPIKeyNotFound)
+
+	key := newAPIKey("a", "billing:")
+	s.Require().NoError(db.SetAPIKey(key))
+	s.Require().NoError(db.SetAPIKey(newAPIKey("b", "config:")))
+	key.Rules[0].Pattern = "changed"
+
+	stored, err := db.GetAPIKey("a")
+	s.Require().NoError(err)
+	s.Equal("billing:*", stored.Rules[0].Pattern, "the stored key should be a copy")
+	s.Len(db.APIKeys(), 2)
+	s.Empty(db.Keys("*"), "the API keys should not be listed with the keys")
+
+	s.Require().NoError(db.RemoveAPIKey("a"))
+	s.ErrorIs(db.RemoveAPIKey("a"), ErrAPIKeyNotFound)
+	_, err = db.GetAPIKey("a")
+	s.ErrorIs(err, ErrAPIKeyNotFound)
+
+	db.SetReadOnly(true)
+	s.ErrorIs(db.SetAPIKey(key), ErrReadOnly)
+	s.ErrorIs(db.RemoveAPIKey("b"), ErrReadOnly)
+}
+
+func (s *APIKeySuite) TestPersistence() {
+	dbPath := s.T().TempDir()
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	s.Require().NoError(db.SetAPIKey(newAPIKey("a", "billing:")))
+	s.Require().NoError(db.SetAPIKey(newAPIKey("b", "config:")))
+	s.Require().NoError(db.RemoveAPIKey("a"))
+	s.Require().NoError(db.Set("key", "value"))
+	db.Close()
+
+	reloaded := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	defer reloaded.Close()
+	_, err := reloaded.GetAPIKey("a")
+	s.ErrorIs(err, ErrAPIKeyNotFound, "the revocation should be persisted")
+	key, err := reloaded.GetAPIKey("b")
+	s.Require().NoError(err)
+	s.Equal("config:*", key.Rules[0].Pattern)
+	item, err := reloaded.Get("key")
+	s.Require().NoError(err)
+	s.Equal(uint64(1), item.Version, "the API keys should not take versions of the items")
+}
+
+func (s *APIKeySuite) TestReplication() {
+	primary := NewMemoryDB(slog.Default())
+	defer primary.Close()
+	replica := NewMemoryDB(slog.Default())
+	defer replica.Close()
+
+	feed, cancel, err := primary.ReplicationFeed(0)
+	s.Require().NoError(err)
+	defer cancel()
+	s.Require().NoError(primary.SetAPIKey(newAPIKey("a", "billing:")))
+	s.Require().NoError(primary.RemoveAPIKey("a"))
+
+	replica.SetReadOnly(true)
+	s.Require().NoError(replica.ApplyReplicated(<-feed))
+	_, err = replica.GetAPIKey("a")
+	s.Require().NoError(err)
+	s.Require().NoError(replica.ApplyReplicated(<-feed))
+	_, err = replica.GetAPIKey("a")
+	s.ErrorIs(err, ErrAPIKeyNotFound)
+
+	// a full synchronization replaces the API keys, so the keys revoked in the primary are revoked in the replica
+	replica.SetReadOnly(false)
+	s.Require().NoError(replica.SetAPIKey(newAPIKey("stale", "")))
+	s.Require().NoError(primary.SetAPIKey(newAPIKey("b", "config:")))
+	var buf bytes.Buffer
+	_, err = primary.WriteSnapshot(&buf)
+	s.Require().NoError(err)
+	_, err = replica.LoadSnapshot(&buf)
+	s.Require().NoError(err)
+	_, err = replica.GetAPIKey("b")
+	s.NoError(err)
+	_, err = replica.GetAPIKey("stale")
+	s.ErrorIs(err, ErrAPIKeyNotFound)
+}
+
+func TestAPIKeySuite(t *testing.T) {
+	suite.Run(t, new(APIKeySuite))
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"fmt"
+	"maps"
+	"memorydb/internal/enums"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthItem is a user of the authentication module. The users are kept in their own store, apart from the items,
+// so they are never listed, expired or evicted with the keys.
+type AuthItem struct {
+	UUID     uuid.UUID            `json:"uuid"`
+	Username string               `json:"username"`
+	Password string               `json:"password"`           // bcrypt hash of the password
+	Sessions map[string]time.Time `json:"sessions,omitempty"` // sessions of the issued tokens by ID, with the time they expire
+}
+
+// clone returns a copy of the user that does not share its sessions.
+func (u *AuthItem) clone() *AuthItem {
+	copied := *u
+	copied.Sessions = maps.Clone(u.Sessions)
+	return &copied
+}
+
+// GetUser retrieves a user of the authentication module by its username.
+//
+// Only the lock of the users is held, so the authentication of the requests does not wait for the writes of the items.
+func (db *memoryDB) GetUser(username string) (*AuthItem, error) {
+	db.authMu.RLock()
+	defer db.authMu.RUnlock()
+
+	user, exists := db.authStore[username]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return user.clone(), nil
+}
+
+// SetUser stores a user of the authentication module, replacing any previous user with the same username.
+func (db *memoryDB) SetUser(user *AuthItem) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	// the lock of the items serializes the writes to the operation log
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	stored := user.clone()
+	db.authMu.Lock()
+	db.authStore[stored.Username] = stored
+	db.authMu.Unlock()
+
+	db.logOperation(&Operation{Command: enums.DBCommandUserSet, Time: db.now(), User: stored})
+	return nil
+}
+
+// replayUser stores the user of an operation of the log.
+func (db *memoryDB) replayUser(op *Operation) error {
+	if op.User == nil {
+		return fmt.Errorf("missing user for command %s", op.Command)
+	}
+
+	user := op.User.clone()
+	db.authMu.Lock()
+	db.authStore[user.Username] = user
+	db.authMu.Unlock()
+	return nil
+}
+
+// users returns a copy of the users of the authentication module, by username.
+func (db *memoryDB) users() map[string]*AuthItem {
+	db.authMu.RLock()
+	defer db.authMu.RUnlock()
+
+	users := make(map[string]*AuthItem, len(db.authStore))
+	for username, user := range db.authStore {
+		users[username] = user.clone()
+	}
+	return users
+}
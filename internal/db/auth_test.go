@@ -0,0 +1,103 @@
+package db
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthSuite struct {
+	suite.Suite
+}
+
+func (s *AuthSuite) TestSetUser() {
+	db := NewMemoryDB(slog.Default())
+	defer db.Close()
+
+	_, err := db.GetUser("alice")
+	s.ErrorIs(err, ErrUserNotFound)
+
+	user := &AuthItem{UUID: uuid.New(), Username: "alice", Password: "hash"}
+	s.Require().NoError(db.SetUser(user))
+	user.Sessions = map[string]time.Time{"changed": time.Now()}
+
+	stored, err := db.GetUser("alice")
+	s.Require().NoError(err)
+	s.Equal("hash", stored.Password)
+	s.Empty(stored.Sessions, "the stored user should be a copy")
+	s.Empty(db.Keys("*"), "the users should not be listed with the keys")
+	s.Zero(db.Stats().Keys)
+
+	db.SetReadOnly(true)
+	s.ErrorIs(db.SetUser(user), ErrReadOnly)
+}
+
+func (s *AuthSuite) TestPersistence() {
+	dbPath := s.T().TempDir()
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	s.Require().NoError(db.SetUser(&AuthItem{Username: "alice", Password: "hash"}))
+	s.Require().NoError(db.SetUser(&AuthItem{Username: "alice", Password: "hash", Sessions: map[string]time.Time{"session": {}}}))
+	s.Require().NoError(db.Set("key", "value"))
+	db.Close()
+
+	reloaded := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	defer reloaded.Close()
+	user, err := reloaded.GetUser("alice")
+	s.Require().NoError(err)
+	s.Contains(user.Sessions, "session", "the last write of the user should win")
+	item, err := reloaded.Get("key")
+	s.Require().NoError(err)
+	s.Equal(uint64(1), item.Version, "the users should not take versions of the items")
+
+	// the compacted log keeps the users
+	f, err := os.Open(LogFilePath(dbPath))
+	s.Require().NoError(err)
+	defer f.Close()
+	replayer := NewLogReplayer()
+	reader := NewLogReader(f)
+	for {
+		op, err := reader.Next()
+		if err != nil {
+			break
+		}
+		s.Require().NoError(replayer.Apply(op))
+	}
+	s.Require().Len(replayer.Users(), 1)
+	s.Equal("alice", replayer.Users()[0].Username)
+}
+
+func (s *AuthSuite) TestReplication() {
+	primary := NewMemoryDB(slog.Default())
+	defer primary.Close()
+	replica := NewMemoryDB(slog.Default())
+	defer replica.Close()
+
+	feed, cancel, err := primary.ReplicationFeed(0)
+	s.Require().NoError(err)
+	defer cancel()
+	s.Require().NoError(primary.SetUser(&AuthItem{Username: "alice", Password: "hash"}))
+
+	replica.SetReadOnly(true)
+	s.Require().NoError(replica.ApplyReplicated(<-feed))
+	_, err = replica.GetUser("alice")
+	s.Require().NoError(err)
+
+	// the users are part of the snapshots of the full synchronizations
+	s.Require().NoError(primary.SetUser(&AuthItem{Username: "bob", Password: "hash"}))
+	var buf bytes.Buffer
+	_, err = primary.WriteSnapshot(&buf)
+	s.Require().NoError(err)
+	_, err = replica.LoadSnapshot(&buf)
+	s.Require().NoError(err)
+	_, err = replica.GetUser("bob")
+	s.NoError(err)
+}
+
+func TestAuthSuite(t *testing.T) {
+	suite.Run(t, new(AuthSuite))
+}
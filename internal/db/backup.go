@@ -0,0 +1,169 @@
+package db
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"memorydb/internal/enums"
+	"sort"
+	"time"
+)
+
+const (
+	// BackupVersion is the version of the format of the backups written by Backup.
+	BackupVersion = 1
+)
+
+// BackupHeader is the first record of a backup, with the information of the whole backup.
+type BackupHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"` // time the copy of the store was taken
+	Offset    uint64    `json:"offset"`     // replication offset the copy was taken at
+	Keys      int       `json:"keys"`       // number of keys in the backup, used to detect truncated backups
+}
+
+// BackupRecord is a key of a backup.
+type BackupRecord struct {
+	Key  string `json:"key"`
+	Item *Item  `json:"item"`
+}
+
+// Backup writes a gzip compressed backup of the store to w and returns its header.
+//
+// The backup is a point-in-time copy of the store: a header followed by a record per key, encoded as
+// newline-delimited JSON. The lock is only held to copy the items, so writers are not blocked while the backup is
+// encoded and written.
+func (db *memoryDB) Backup(w io.Writer) (BackupHeader, error) {
+	db.mu.Lock()
+	now := db.now()
+	header := BackupHeader{Version: BackupVersion, CreatedAt: now, Offset: db.replication.currentOffset()}
+	records := make([]BackupRecord, 0, len(db.store))
+	for key, item := range db.store {
+		if item.isExpired(now) {
+			continue
+		}
+		records = append(records, BackupRecord{Key: key, Item: item.clone()})
+	}
+	db.mu.Unlock()
+
+	header.Keys = len(records)
+	sort.Slice(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+
+	zw := gzip.NewWriter(w)
+	encoder := json.NewEncoder(zw)
+	if err := encoder.Encode(header); err != nil {
+		return BackupHeader{}, fmt.Errorf("failed to write backup header: %w", err)
+	}
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return BackupHeader{}, fmt.Errorf("failed to write key %s to backup: %w", record.Key, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return BackupHeader{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return header, nil
+}
+
+// ReadBackup reads and validates a backup written by Backup. It fails if the backup is truncated.
+func ReadBackup(r io.Reader) (BackupHeader, []BackupRecord, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return BackupHeader{}, nil, fmt.Errorf("invalid backup: %w", err)
+	}
+	defer zr.Close()
+
+	decoder := json.NewDecoder(zr)
+	var header BackupHeader
+	if err := decoder.Decode(&header); err != nil {
+		return BackupHeader{}, nil, fmt.Errorf("invalid backup header: %w", err)
+	}
+	if header.Version != BackupVersion {
+		return BackupHeader{}, nil, fmt.Errorf("unsupported backup version %d", header.Version)
+	}
+
+	records := make([]BackupRecord, 0, header.Keys)
+	for {
+		var record BackupRecord
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return BackupHeader{}, nil, fmt.Errorf("invalid backup record %d: %w", len(records)+1, err)
+		}
+		if record.Item == nil || (record.Item.Kind == StreamType && record.Item.Stream == nil) {
+			return BackupHeader{}, nil, fmt.Errorf("invalid backup: key %s has no value", record.Key)
+		}
+		records = append(records, record)
+	}
+	if len(records) != header.Keys {
+		return BackupHeader{}, nil, fmt.Errorf("truncated backup: %d of %d keys", len(records), header.Keys)
+	}
+	return header, records, nil
+}
+
+// RestoreBackup restores a backup written by Backup and returns the number of keys restored. The keys that
+// expired since the backup was taken are skipped.
+//
+// With enums.RestoreModeReplace, the keys that are not in the backup are removed. With enums.RestoreModeMerge,
+// they are kept. The backup is read and validated before the store is changed, and the change is logged
+// and published as the removal and the set of the keys.
+func (db *memoryDB) RestoreBackup(r io.Reader, mode enums.RestoreMode) (int, error) {
+	if !mode.IsValid() {
+		return 0, fmt.Errorf("invalid restore mode '%s'", mode)
+	}
+	if db.readOnly.Load() {
+		return 0, ErrReadOnly
+	}
+
+	header, records, err := ReadBackup(r)
+	if err != nil {
+		return 0, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	defer db.startLoading()()
+
+	now := db.now()
+	restored := records[:0]
+	for _, record := range records {
+		if !record.Item.isExpired(now) {
+			restored = append(restored, record)
+		}
+	}
+
+	// check the memory limit before changing the store, so a restore that does not fit leaves it untouched
+	var delta int64
+	for _, record := range restored {
+		delta += entrySize(record.Key, record.Item)
+		if previous, exists := db.store[record.Key]; exists && mode == enums.RestoreModeMerge {
+			delta -= entrySize(record.Key, previous)
+		}
+	}
+	if mode == enums.RestoreModeReplace {
+		if db.maxMemory > 0 && delta > db.maxMemory {
+			return 0, ErrOutOfMemory
+		}
+		for key := range db.store {
+			db.logOperation(&Operation{Command: enums.DBCommandRemove, Key: key, Time: now})
+			db.events.publish(enums.KeyspaceEventRemove, key)
+		}
+		db.store = make(map[string]*Item, len(restored))
+		db.usedMemory = 0
+	} else if err := db.reserveMemory(delta, ""); err != nil {
+		return 0, err
+	}
+
+	for _, record := range restored {
+		record.Item.lastAccess = now
+		db.logOperation(&Operation{Command: enums.DBCommandSet, Key: record.Key, Time: now, Item: record.Item})
+		db.storeItem(record.Key, record.Item)
+		db.events.publish(enums.KeyspaceEventSet, record.Key)
+	}
+
+	db.logger.Info("restored backup", "mode", mode, "created_at", header.CreatedAt, "keys", len(restored), "used_memory", db.usedMemory)
+	return len(restored), nil
+}
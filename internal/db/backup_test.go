@@ -0,0 +1,247 @@
+package db
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/enums"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupSuite struct {
+	suite.Suite
+	source *memoryDB
+	target *memoryDB
+}
+
+func (s *BackupSuite) SetupTest() {
+	s.source = NewMemoryDB(slog.Default()).(*memoryDB)
+	s.target = NewMemoryDB(slog.Default()).(*memoryDB)
+}
+
+func (s *BackupSuite) TearDownTest() {
+	s.source.Close()
+	s.target.Close()
+}
+
+// backup takes a backup of the source database.
+func (s *BackupSuite) backup() []byte {
+	var buf bytes.Buffer
+	_, err := s.source.Backup(&buf)
+	s.Require().NoError(err)
+	return buf.Bytes()
+}
+
+func (s *BackupSuite) TestRoundTrip() {
+	s.Require().NoError(s.source.Set("string", "value", WithTTL(time.Hour)))
+	s.Require().NoError(s.source.Set("list", []string{"a", "b"}))
+	id, err := s.source.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.source.StreamGroupCreate("stream", "group", "0"))
+	_, err = s.source.StreamReadGroup(context.Background(), "stream", "group", "consumer", 10, 0)
+	s.Require().NoError(err)
+
+	var buf bytes.Buffer
+	header, err := s.source.Backup(&buf)
+	s.Require().NoError(err)
+	s.Equal(BackupVersion, header.Version)
+	s.Equal(3, header.Keys)
+
+	keys, err := s.target.RestoreBackup(&buf, enums.RestoreModeMerge)
+	s.Require().NoError(err)
+	s.Equal(3, keys)
+
+	item, err := s.target.Get("string")
+	s.Require().NoError(err)
+	s.Equal("value", item.Value.Val)
+	s.False(item.TTL.IsZero(), "the TTL should be restored")
+
+	item, err = s.target.Get("list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+
+	entries, err := s.target.StreamRange("stream", "-", "+", 0)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 1)
+	s.Equal(id, entries[0].ID)
+
+	pending, err := s.target.StreamPending("stream", "group", "consumer")
+	s.Require().NoError(err)
+	s.Len(pending, 1, "the consumer groups should be restored")
+	s.Equal(s.source.Stats().UsedMemory, s.target.Stats().UsedMemory)
+}
+
+func (s *BackupSuite) TestPointInTime() {
+	s.Require().NoError(s.source.Set("key", "before"))
+	_, err := s.source.StreamAdd("stream", map[string]string{"n": "1"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.source.StreamGroupCreate("stream", "group", "0"))
+
+	var buf bytes.Buffer
+	s.source.mu.Lock()
+	copied := *s.source.store["stream"]
+	copied.Stream = s.source.store["stream"].Stream.snapshot()
+	s.source.mu.Unlock()
+
+	// the writes after the copy do not change it
+	_, err = s.source.StreamAdd("stream", map[string]string{"n": "2"})
+	s.Require().NoError(err)
+	_, err = s.source.StreamReadGroup(context.Background(), "stream", "group", "consumer", 10, 0)
+	s.Require().NoError(err)
+	s.Len(copied.Stream.Entries, 1)
+	s.Empty(copied.Stream.Groups["group"].Pending)
+
+	_, err = s.source.Backup(&buf)
+	s.Require().NoError(err)
+	s.Require().NoError(s.source.Set("key", "after"))
+	s.Require().NoError(s.source.Set("other", "value"))
+
+	_, err = s.target.RestoreBackup(&buf, enums.RestoreModeMerge)
+	s.Require().NoError(err)
+	item, err := s.target.Get("key")
+	s.Require().NoError(err)
+	s.Equal("before", item.Value.Val)
+	s.Error(s.target.Remove("other"), "the keys set after the backup should not be restored")
+}
+
+func (s *BackupSuite) TestSliceAfterPop() {
+	s.Require().NoError(s.source.Set("list", []string{"a", "b"}))
+
+	s.source.mu.Lock()
+	copied := *s.source.store["list"]
+	s.source.mu.Unlock()
+
+	// the push after the pop does not write into the array of the copy
+	_, err := s.source.Pop("list")
+	s.Require().NoError(err)
+	_, err = s.source.Push("list", "CHANGED")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, copied.Value.Val)
+
+	item, err := s.source.Get("list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "CHANGED"}, item.Value.Val)
+}
+
+func (s *BackupSuite) TestModes() {
+	s.Require().NoError(s.source.Set("key1", "backup"))
+	s.Require().NoError(s.source.Set("key2", "backup"))
+	data := s.backup()
+
+	s.Run("Merge", func() {
+		s.Require().NoError(s.target.Set("key1", "live"))
+		s.Require().NoError(s.target.Set("key3", "live"))
+
+		_, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreModeMerge)
+		s.Require().NoError(err)
+		s.ElementsMatch([]string{"key1", "key2", "key3"}, s.target.Keys("*"))
+		item, err := s.target.Get("key1")
+		s.Require().NoError(err)
+		s.Equal("backup", item.Value.Val, "the keys of the backup should overwrite the live ones")
+	})
+
+	s.Run("Replace", func() {
+		events, cancel := s.target.Subscribe("*", 0)
+		defer cancel()
+
+		_, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreModeReplace)
+		s.Require().NoError(err)
+		s.ElementsMatch([]string{"key1", "key2"}, s.target.Keys("*"))
+		s.Equal(s.source.Stats().UsedMemory, s.target.Stats().UsedMemory)
+
+		// the removal of the keys that are not in the backup is published
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case event := <-events:
+				if event.Type == enums.KeyspaceEventRemove && event.Key == "key3" {
+					return
+				}
+			case <-timeout:
+				s.FailNow("timeout waiting for the removal of key3")
+			}
+		}
+	})
+
+	s.Run("Invalid mode", func() {
+		_, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreMode("append"))
+		s.Error(err)
+	})
+}
+
+func (s *BackupSuite) TestInvalidBackup() {
+	s.Require().NoError(s.source.Set("key1", "value"))
+	s.Require().NoError(s.source.Set("key2", "value"))
+	s.Require().NoError(s.target.Set("live", "value"))
+
+	// a backup without its last record
+	zr, err := gzip.NewReader(bytes.NewReader(s.backup()))
+	s.Require().NoError(err)
+	decoder := json.NewDecoder(zr)
+	var truncated bytes.Buffer
+	zw := gzip.NewWriter(&truncated)
+	encoder := json.NewEncoder(zw)
+	for range 2 {
+		var record json.RawMessage
+		s.Require().NoError(decoder.Decode(&record))
+		s.Require().NoError(encoder.Encode(record))
+	}
+	s.Require().NoError(zw.Close())
+
+	tests := map[string][]byte{
+		"Not compressed": []byte(`{"version":1,"keys":0}`),
+		"Truncated":      truncated.Bytes(),
+		"Corrupt":        s.backup()[:20],
+	}
+	for name, data := range tests {
+		s.Run(name, func() {
+			_, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreModeReplace)
+			s.Error(err)
+			s.Equal([]string{"live"}, s.target.Keys("*"), "an invalid backup should leave the store untouched")
+		})
+	}
+}
+
+func (s *BackupSuite) TestExpiredKeys() {
+	now := time.Now()
+	s.source.clock = func() time.Time { return now }
+	s.Require().NoError(s.source.Set("short", "value", WithTTL(time.Minute)))
+	s.Require().NoError(s.source.Set("long", "value", WithTTL(time.Hour)))
+	data := s.backup()
+
+	s.target.clock = func() time.Time { return now.Add(10 * time.Minute) }
+	keys, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreModeMerge)
+	s.Require().NoError(err)
+	s.Equal(1, keys, "the keys that expired since the backup should be skipped")
+	s.Equal([]string{"long"}, s.target.Keys("*"))
+}
+
+func (s *BackupSuite) TestLimits() {
+	s.Require().NoError(s.source.Set("key", "a long enough value to exceed the limit"))
+	data := s.backup()
+
+	s.Run("Read only", func() {
+		s.target.SetReadOnly(true)
+		defer s.target.SetReadOnly(false)
+		_, err := s.target.RestoreBackup(bytes.NewReader(data), enums.RestoreModeMerge)
+		s.ErrorIs(err, ErrReadOnly)
+	})
+
+	s.Run("Out of memory", func() {
+		db := NewMemoryDB(slog.Default(), WithMaxMemory(10)).(*memoryDB)
+		defer db.Close()
+		for _, mode := range []enums.RestoreMode{enums.RestoreModeMerge, enums.RestoreModeReplace} {
+			_, err := db.RestoreBackup(bytes.NewReader(data), mode)
+			s.ErrorIs(err, ErrOutOfMemory)
+		}
+	})
+}
+
+func TestBackupSuite(t *testing.T) {
+	suite.Run(t, new(BackupSuite))
+}
@@ -1,5 +1,12 @@
 package db
 
+import (
+	"context"
+	"io"
+	"memorydb/internal/enums"
+	"time"
+)
+
 // DBClient defines the interface for interacting with an in-memory database.
 type DBClient interface {
 	// Get retrieves an item by its key.
@@ -8,6 +15,9 @@ type DBClient interface {
 	// Set stores an item with the specified key and optional options.
 	Set(key string, value any, opts ...ItemOptions) error
 
+	// SetMany stores the items with the specified keys and optional options, all of them or none of them.
+	SetMany(items map[string]any, opts ...ItemOptions) error
+
 	// Update modifies an existing item with the specified key and value.
 	Update(key string, value any, opts ...ItemOptions) error
 
@@ -20,6 +30,106 @@ type DBClient interface {
 	// Pop removes and returns the last item from a slice stored at the specified key.
 	Pop(key string) (*Item, error)
 
+	// StreamAdd appends an entry to the stream stored at the key, creating the stream if needed, and returns its ID.
+	StreamAdd(key string, fields map[string]string, opts ...ItemOptions) (StreamID, error)
+
+	// StreamRange returns up to count entries of the stream with IDs between start and end, both included.
+	StreamRange(key string, start, end string, count int) ([]StreamEntry, error)
+
+	// StreamRead returns up to count entries of the stream after the given ID, waiting up to block for new entries.
+	StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) ([]StreamEntry, error)
+
+	// StreamTrim removes the oldest entries of the stream beyond maxLen entries or older than maxAge.
+	StreamTrim(key string, maxLen int, maxAge time.Duration) (int, error)
+
+	// StreamGroupCreate creates a consumer group that delivers the entries of the stream after start.
+	StreamGroupCreate(key string, group string, start string) error
+
+	// StreamReadGroup delivers up to count new entries to the consumer of the group, waiting up to block for them.
+	StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamEntry, error)
+
+	// StreamAck acknowledges entries pending in the group and returns how many were pending.
+	StreamAck(key string, group string, ids ...string) (int, error)
+
+	// StreamPending returns the entries of the group that have been delivered to the consumer but not acknowledged.
+	StreamPending(key string, group string, consumer string) ([]PendingEntry, error)
+
+	// StreamClaim transfers to the consumer the pending entries of the group idle for at least minIdle.
+	StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error)
+
+	// Subscribe returns a channel with the keyspace events of the keys that match the glob pattern,
+	// resuming after lastEventID if it is not zero, and a function that cancels the subscription.
+	Subscribe(pattern string, lastEventID uint64) (<-chan Event, func())
+
+	// Keys returns the keys that match the glob pattern, sorted.
+	Keys(match string) []string
+
+	// Dump returns the encoded item stored at the key, which can be stored in another database with Restore.
+	Dump(key string) ([]byte, error)
+
+	// Restore stores at the key an item encoded by Dump, replacing any previous value.
+	Restore(key string, data []byte) error
+
+	// Backup writes a compressed point-in-time backup of the store to w and returns its header.
+	Backup(w io.Writer) (BackupHeader, error)
+
+	// RestoreBackup restores a backup written by Backup, replacing or merging into the store, and returns the number of keys restored.
+	RestoreBackup(r io.Reader, mode enums.RestoreMode) (int, error)
+
+	// WriteSnapshot writes a snapshot of the store to w and returns the replication offset it was taken at.
+	WriteSnapshot(w io.Writer) (uint64, error)
+
+	// LoadSnapshot replaces the content of the store with the snapshot read from r and returns its replication offset.
+	LoadSnapshot(r io.Reader) (uint64, error)
+
+	// ReplicationFeed returns a channel with the operations logged after the offset and a function that cancels the feed.
+	ReplicationFeed(offset uint64) (<-chan ReplicatedOperation, func(), error)
+
+	// ApplyReplicated applies an operation received from the primary.
+	ApplyReplicated(op ReplicatedOperation) error
+
+	// GetUser retrieves a user of the authentication module by its username.
+	GetUser(username string) (*AuthItem, error)
+
+	// SetUser stores a user of the authentication module, replacing any previous user with the same username.
+	SetUser(user *AuthItem) error
+
+	// GetAPIKey retrieves an API key of the authentication module by its ID.
+	GetAPIKey(id string) (*APIKeyItem, error)
+
+	// SetAPIKey stores an API key of the authentication module, replacing any previous key with the same ID.
+	SetAPIKey(key *APIKeyItem) error
+
+	// RemoveAPIKey removes an API key of the authentication module by its ID.
+	RemoveAPIKey(id string) error
+
+	// APIKeys returns the API keys of the authentication module sorted by creation time.
+	APIKeys() []*APIKeyItem
+
+	// CreateNamespace creates an empty namespace, a keyspace isolated from the other keys with its own settings.
+	CreateNamespace(name string, config NamespaceConfig) error
+
+	// DropNamespace removes a namespace with all its keys.
+	DropNamespace(name string) error
+
+	// Namespace returns the database of the keys of a namespace.
+	Namespace(name string) (DBClient, error)
+
+	// Namespaces returns the namespaces with their settings, sorted by name.
+	Namespaces() []NamespaceItem
+
+	// SetReadOnly sets whether the database rejects writes.
+	SetReadOnly(readOnly bool)
+
+	// WithContext returns the database with the operations on the keys traced as children of the span of the context.
+	WithContext(ctx context.Context) DBClient
+
+	// Health runs the checks of the state of the database, waiting up to timeout for its lock.
+	Health(timeout time.Duration) []HealthCheck
+
+	// Stats returns a snapshot of the counters of the database.
+	Stats() Stats
+
 	// Close releases any resources held by the database client.
 	Close()
 }
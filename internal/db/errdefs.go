@@ -4,6 +4,25 @@ var (
 	ErrInvalidDataType = NewDBError("invalid data type", "data type must be string or []string")
 	ErrDataNotFound    = NewDBError("item not found", "the requested data does not exist in the database")
 	ErrKeyHasExpired   = NewDBError("key has expired", "the requested key has expired and is no longer available in the database")
+	ErrOutOfMemory     = NewDBError("out of memory", "the memory limit has been reached and the eviction policy does not allow freeing memory")
+	ErrVersionMismatch = NewDBError("version mismatch", "the item has been written since it was read, or does not exist, so the conditional write was not applied")
+
+	ErrNotAStream          = NewDBError("wrong type", "the key does not hold a stream")
+	ErrInvalidStreamID     = NewDBError("invalid stream ID", "stream IDs must have the form <ms>-<seq> and be greater than the last ID of the stream")
+	ErrStreamGroupNotFound = NewDBError("consumer group not found", "the consumer group does not exist in the stream")
+	ErrStreamGroupExists   = NewDBError("consumer group already exists", "a consumer group with the same name already exists in the stream")
+
+	ErrUserNotFound   = NewDBError("user not found", "the requested user does not exist in the authentication store")
+	ErrAPIKeyNotFound = NewDBError("API key not found", "the requested API key does not exist in the authentication store")
+
+	ErrNamespaceNotFound      = NewDBError("namespace not found", "the requested namespace does not exist in the database")
+	ErrNamespaceExists        = NewDBError("namespace already exists", "a namespace with the same name already exists in the database")
+	ErrInvalidNamespace       = NewDBError("invalid namespace", "namespace names must have 1 to 64 letters, digits, '-' or '_', and the settings must be valid")
+	ErrNamespaceNotPersistent = NewDBError("persistence is disabled", "a namespace can only be persisted if the persistence of the database is enabled")
+
+	ErrReadOnly         = NewDBError("read-only replica", "the database is a read-only replica, writes must be sent to the primary")
+	ErrNoLeader         = NewDBError("no cluster leader", "the cluster has no reachable leader, the write cannot be committed until a new leader is elected")
+	ErrOffsetOutOfRange = NewDBError("replication offset out of range", "the operations after the requested offset are no longer in the replication backlog, a full synchronization is needed")
 )
 
 type DBerror struct {
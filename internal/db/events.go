@@ -0,0 +1,230 @@
+package db
+
+import (
+	"log/slog"
+	"memorydb/internal/enums"
+	"memorydb/internal/glob"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	eventQueueSize         = 4096 // number of events that can wait to be dispatched before new ones are dropped
+	eventHistorySize       = 1024 // number of past events kept to resume subscriptions
+	eventSubscriberBufSize = 256  // number of events buffered per subscriber before it is disconnected
+)
+
+// Event describes a change of a key in the keyspace.
+//
+// Event IDs are assigned in increasing order, so a subscriber that reconnects can resume
+// from the last ID it received. If some of the events it should have received were dropped, or are no longer in the
+// history when it resumes, it receives an event of type lost instead.
+type Event struct {
+	ID   uint64              `json:"id"`
+	Type enums.KeyspaceEvent `json:"type"`
+	Key  string              `json:"key"`
+	Time time.Time           `json:"time"`
+}
+
+// eventSubscriber is a single subscription to the event bus.
+type eventSubscriber struct {
+	pattern string     // glob pattern the keys must match
+	ch      chan Event // channel where matching events are delivered
+}
+
+// eventBus fans out keyspace events to the subscribers.
+//
+// Publishing never blocks the write path: events are queued in a buffered channel and a dispatcher goroutine
+// delivers them. If the queue is full the event is dropped, and a lost event is queued before the next one so the
+// subscribers know. If a subscriber does not keep up it is disconnected so it can resume later from the last event
+// ID it received.
+type eventBus struct {
+	logger *slog.Logger
+	queue  chan Event    // events waiting to be dispatched
+	done   chan struct{} // closed once the dispatcher has stopped
+
+	queueMu sync.RWMutex  // serializes the publishers and protects the queue from being closed while they publish
+	closed  bool          // whether the bus has been closed
+	lost    bool          // whether events were dropped since the last lost event was queued
+	dropped atomic.Uint64 // number of events dropped because the queue was full
+
+	mu          sync.Mutex
+	lastID      uint64                      // ID of the last dispatched event
+	history     []Event                     // ring buffer with the last dispatched events
+	subscribers map[uint64]*eventSubscriber // active subscriptions by subscription ID
+	nextSubID   uint64                      // ID of the next subscription
+}
+
+// newEventBus creates an event bus and starts its dispatcher.
+func newEventBus(logger *slog.Logger) *eventBus {
+	bus := &eventBus{
+		logger:      logger,
+		queue:       make(chan Event, eventQueueSize),
+		done:        make(chan struct{}),
+		history:     make([]Event, 0, eventHistorySize),
+		subscribers: make(map[uint64]*eventSubscriber),
+	}
+	go bus.dispatch()
+	return bus
+}
+
+// publish queues an event for the given key without blocking.
+func (b *eventBus) publish(eventType enums.KeyspaceEvent, key string) {
+	// the publishers are serialized, so the lost event is queued once and before the events that follow the drop
+	b.queueMu.Lock()
+	defer b.queueMu.Unlock()
+	if b.closed {
+		return
+	}
+
+	now := time.Now()
+	if b.lost && b.enqueue(Event{Type: enums.KeyspaceEventLost, Time: now}) {
+		b.lost = false
+	}
+	if b.lost || !b.enqueue(Event{Type: eventType, Key: key, Time: now}) {
+		b.lost = true
+		b.dropped.Add(1)
+		b.logger.Warn("event queue is full, dropping keyspace event", "key", key, "event", eventType)
+	}
+}
+
+// enqueue queues the event if the queue is not full, and reports whether it was queued.
+func (b *eventBus) enqueue(event Event) bool {
+	select {
+	case b.queue <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatch delivers the queued events to the subscribers until the queue is closed.
+func (b *eventBus) dispatch() {
+	defer close(b.done)
+
+	for event := range b.queue {
+		b.mu.Lock()
+		b.lastID++
+		event.ID = b.lastID
+
+		if len(b.history) < eventHistorySize {
+			b.history = append(b.history, event)
+		} else {
+			b.history[(event.ID-1)%eventHistorySize] = event
+		}
+
+		for id, sub := range b.subscribers {
+			if !matches(sub.pattern, event) {
+				continue
+			}
+			select {
+			case sub.ch <- event:
+			default:
+				// the subscriber is too slow, disconnect it so it can resume from its last event
+				b.logger.Warn("keyspace subscriber is too slow, disconnecting it", "subscription", id, "pattern", sub.pattern)
+				delete(b.subscribers, id)
+				close(sub.ch)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// subscribe registers a subscription for the keys that match the pattern.
+//
+// If lastEventID is not zero, the events after that ID that are still in the history are delivered first, after
+// a lost event if some of them are no longer in the history. It returns the channel where the events are delivered and a function that cancels the subscription.
+func (b *eventBus) subscribe(pattern string, lastEventID uint64) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missed := b.missedEvents(pattern, lastEventID)
+	ch := make(chan Event, eventSubscriberBufSize+len(missed))
+	for _, event := range missed {
+		ch <- event
+	}
+
+	if b.isClosed() {
+		close(ch)
+		return ch, func() {}
+	}
+
+	b.nextSubID++
+	id := b.nextSubID
+	b.subscribers[id] = &eventSubscriber{pattern: pattern, ch: ch}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if sub, exists := b.subscribers[id]; exists {
+			delete(b.subscribers, id)
+			close(sub.ch)
+		}
+	}
+	return ch, cancel
+}
+
+// missedEvents returns the events in the history after lastEventID that match the pattern, in order. If the events
+// right after lastEventID are no longer in the history, they are replaced by a lost event with the ID of the last
+// of them, so the subscriber resumes from the history if it reconnects. An ID after the last event was not sent by
+// this bus, e.g. before a restart or by another node, so the events the subscriber missed are unknown and are
+// replaced by a lost event with the ID of the last event. It must be called with the lock held.
+func (b *eventBus) missedEvents(pattern string, lastEventID uint64) []Event {
+	if lastEventID == 0 || lastEventID == b.lastID {
+		return nil
+	}
+	if lastEventID > b.lastID {
+		return []Event{{ID: b.lastID, Type: enums.KeyspaceEventLost, Time: time.Now()}}
+	}
+
+	var missed []Event
+	oldest := b.lastID - uint64(len(b.history)) + 1
+	if lastEventID+1 < oldest {
+		missed = append(missed, Event{ID: oldest - 1, Type: enums.KeyspaceEventLost, Time: time.Now()})
+	} else {
+		oldest = lastEventID + 1
+	}
+	for id := oldest; id <= b.lastID; id++ {
+		event := b.history[(id-1)%eventHistorySize]
+		if matches(pattern, event) {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+// matches reports whether the event is delivered to the subscribers of the pattern. The lost events are delivered
+// to every subscriber, since the events they replace could match any pattern.
+func matches(pattern string, event Event) bool {
+	return event.Type == enums.KeyspaceEventLost || glob.Match(pattern, event.Key)
+}
+
+// close stops the dispatcher and closes the channels of all the subscribers.
+func (b *eventBus) close() {
+	b.queueMu.Lock()
+	if b.closed {
+		b.queueMu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.queueMu.Unlock()
+
+	// wait until the queued events are delivered
+	<-b.done
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, sub := range b.subscribers {
+		delete(b.subscribers, id)
+		close(sub.ch)
+	}
+}
+
+// isClosed reports whether the bus has been closed.
+func (b *eventBus) isClosed() bool {
+	b.queueMu.RLock()
+	defer b.queueMu.RUnlock()
+	return b.closed
+}
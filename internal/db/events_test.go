@@ -0,0 +1,212 @@
+package db
+
+import (
+	"log/slog"
+	"memorydb/internal/enums"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EventsSuite struct {
+	suite.Suite
+}
+
+// receive reads n events from the channel or fails the test after a timeout.
+func (s *EventsSuite) receive(ch <-chan Event, n int) []Event {
+	events := make([]Event, 0, n)
+	for len(events) < n {
+		select {
+		case event, ok := <-ch:
+			s.Require().True(ok, "channel closed after %d events, expected %d", len(events), n)
+			events = append(events, event)
+		case <-time.After(time.Second):
+			s.FailNow("timeout waiting for events", "received %d events, expected %d", len(events), n)
+		}
+	}
+	return events
+}
+
+func (s *EventsSuite) TestPublishedEvents() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	ch, cancel := db.Subscribe("list*", 0)
+	defer cancel()
+
+	s.Require().NoError(db.Set("list", []string{"a"}))
+	s.Require().NoError(db.Set("other", "ignored"))
+	_, err := db.Push("list", "b")
+	s.Require().NoError(err)
+	_, err = db.Pop("list")
+	s.Require().NoError(err)
+	s.Require().NoError(db.Update("list", "c"))
+	s.Require().NoError(db.Remove("list"))
+	s.Require().NoError(db.Set("list", "short", WithTTL(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	db.cleanExpired()
+
+	events := s.receive(ch, 7)
+	types := make([]enums.KeyspaceEvent, len(events))
+	for i, event := range events {
+		s.Equal("list", event.Key)
+		types[i] = event.Type
+		if i > 0 {
+			s.Greater(event.ID, events[i-1].ID, "event IDs must be increasing")
+		}
+	}
+	s.Equal([]enums.KeyspaceEvent{
+		enums.KeyspaceEventSet,
+		enums.KeyspaceEventPush,
+		enums.KeyspaceEventPop,
+		enums.KeyspaceEventUpdate,
+		enums.KeyspaceEventRemove,
+		enums.KeyspaceEventSet,
+		enums.KeyspaceEventExpire,
+	}, types)
+}
+
+func (s *EventsSuite) TestEvictEvent() {
+	limit := entrySize("key0", &Item{Value: &StringOrSlice{"value"}})
+	db := NewMemoryDB(slog.Default(), WithMaxMemory(limit), WithEvictionPolicy(enums.EvictionPolicyAllKeysLRU)).(*memoryDB)
+	defer db.Close()
+
+	ch, cancel := db.Subscribe("", 0)
+	defer cancel()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value"))
+
+	events := s.receive(ch, 3)
+	s.Equal(enums.KeyspaceEventEvict, events[1].Type)
+	s.Equal("key0", events[1].Key)
+}
+
+func (s *EventsSuite) TestResume() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	first, cancel := db.Subscribe("", 0)
+	for _, key := range []string{"a", "b", "c"} {
+		s.Require().NoError(db.Set(key, "value"))
+	}
+	received := s.receive(first, 1)
+	cancel()
+
+	// resuming after the first event delivers the events that the subscriber missed
+	resumed, cancel := db.Subscribe("", received[0].ID)
+	defer cancel()
+	missed := s.receive(resumed, 2)
+	s.Equal("b", missed[0].Key)
+	s.Equal("c", missed[1].Key)
+}
+
+func (s *EventsSuite) TestResumeAfterHistory() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	for i := 0; i < eventHistorySize+2; i++ {
+		s.Require().NoError(db.Set("key", "value"))
+	}
+	s.Eventually(func() bool {
+		db.events.mu.Lock()
+		defer db.events.mu.Unlock()
+		return db.events.lastID == eventHistorySize+2
+	}, time.Second, 10*time.Millisecond)
+
+	// the events after the first one are no longer all in the history, so the subscriber is told they were lost
+	resumed, cancel := db.Subscribe("other*", 1)
+	defer cancel()
+	lost := s.receive(resumed, 1)[0]
+	s.Equal(enums.KeyspaceEventLost, lost.Type)
+	s.Empty(lost.Key)
+	s.Equal(uint64(2), lost.ID, "the lost event should have the ID of the last event that is not in the history")
+}
+
+func (s *EventsSuite) TestResumeAfterLastEvent() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key", "value"))
+	s.Eventually(func() bool {
+		db.events.mu.Lock()
+		defer db.events.mu.Unlock()
+		return db.events.lastID == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// an ID after the last event was sent before a restart or by another node, so the missed events are unknown
+	resumed, cancel := db.Subscribe("", 42)
+	defer cancel()
+	lost := s.receive(resumed, 1)[0]
+	s.Equal(enums.KeyspaceEventLost, lost.Type)
+	s.Equal(uint64(1), lost.ID, "the lost event should have the ID of the last event")
+
+	current, cancel := db.Subscribe("", 1)
+	defer cancel()
+	s.Require().NoError(db.Set("key", "value"))
+	s.Equal(enums.KeyspaceEventSet, s.receive(current, 1)[0].Type, "resuming after the last event should not send a lost event")
+}
+
+func (s *EventsSuite) TestDroppedEvents() {
+	// the dispatcher is started once the queue is full
+	bus := &eventBus{
+		logger:      slog.Default(),
+		queue:       make(chan Event, 2),
+		done:        make(chan struct{}),
+		subscribers: make(map[uint64]*eventSubscriber),
+	}
+	defer bus.close()
+	ch, cancel := bus.subscribe("a*", 0)
+	defer cancel()
+
+	for _, key := range []string{"a1", "a2", "a3", "a4"} {
+		bus.publish(enums.KeyspaceEventSet, key)
+	}
+	s.Equal(uint64(2), bus.dropped.Load())
+	go bus.dispatch()
+	s.receive(ch, 2)
+
+	// the next event is preceded by a lost event, delivered to every subscriber whatever its pattern
+	bus.publish(enums.KeyspaceEventSet, "a5")
+	events := s.receive(ch, 2)
+	s.Equal(enums.KeyspaceEventLost, events[0].Type)
+	s.Equal(uint64(3), events[0].ID)
+	s.Equal("a5", events[1].Key)
+	s.Equal(uint64(4), events[1].ID)
+}
+
+func (s *EventsSuite) TestSlowSubscriberIsDisconnected() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	ch, cancel := db.Subscribe("", 0)
+	defer cancel()
+
+	for i := 0; i <= eventSubscriberBufSize; i++ {
+		s.Require().NoError(db.Set("key", "value"))
+	}
+
+	// the buffered events are still delivered before the channel is closed
+	s.Eventually(func() bool {
+		db.events.mu.Lock()
+		defer db.events.mu.Unlock()
+		return len(db.events.subscribers) == 0
+	}, time.Second, 10*time.Millisecond)
+	s.receive(ch, eventSubscriberBufSize)
+	_, ok := <-ch
+	s.False(ok, "the channel of a slow subscriber must be closed")
+}
+
+func (s *EventsSuite) TestCloseDisconnectsSubscribers() {
+	db := NewMemoryDB(slog.Default())
+	ch, _ := db.Subscribe("", 0)
+	db.Close()
+
+	_, ok := <-ch
+	s.False(ok, "the channel must be closed when the database is closed")
+}
+
+func TestEvents(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
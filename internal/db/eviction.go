@@ -0,0 +1,146 @@
+package db
+
+import (
+	"memorydb/internal/enums"
+	"time"
+)
+
+const (
+	// itemOverhead is the approximate number of bytes taken by an Item, its map entry and its bookkeeping fields.
+	itemOverhead = 128
+	// sliceElemOverhead is the approximate number of bytes taken by the header of every string stored in a slice.
+	sliceElemOverhead = 16
+	// evictionSamples is the number of keys sampled to pick an eviction candidate.
+	// Like Redis, the policies are approximated by sampling instead of keeping the whole keyspace sorted.
+	evictionSamples = 16
+)
+
+// valueSize returns the approximate number of bytes needed to store the given value.
+func valueSize(value any) int64 {
+	var size int64
+	switch v := value.(type) {
+	case string:
+		size = int64(len(v))
+	case []string:
+		for _, elem := range v {
+			size += int64(len(elem)) + sliceElemOverhead
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if str, ok := elem.(string); ok {
+				size += int64(len(str)) + sliceElemOverhead
+			}
+		}
+	}
+	return size
+}
+
+// entrySize returns the approximate number of bytes used by a key and its item.
+func entrySize(key string, item *Item) int64 {
+	size := int64(itemOverhead + len(key))
+	if item != nil && item.Value != nil {
+		size += valueSize(item.Value.Val)
+	}
+	if item != nil && item.Stream != nil {
+		size += item.Stream.size
+	}
+	return size
+}
+
+// touch records an access to the item so the LRU and LFU policies can rank it.
+func (d *Item) touch(accessedAt time.Time) {
+	d.lastAccess = accessedAt
+	d.hits++
+}
+
+// reserveMemory makes room for delta more bytes by evicting keys according to the eviction policy.
+//
+// The key being written is never evicted to make room for itself. If the memory limit is disabled
+// or delta is not positive, reserveMemory is a no-op. It must be called with the lock held.
+func (db *memoryDB) reserveMemory(delta int64, protectedKey string) error {
+	return db.reserveMemoryFor(delta, func(key string) bool { return key == protectedKey })
+}
+
+// reserveMemoryFor is reserveMemory for a write of several keys: none of the keys for which protected returns true
+// is evicted, since they are about to be written and their current size is already deducted from delta.
+func (db *memoryDB) reserveMemoryFor(delta int64, protected func(key string) bool) error {
+	if db.maxMemory <= 0 || delta <= 0 {
+		return nil
+	}
+
+	for db.usedMemory+delta > db.maxMemory {
+		if db.evictionPolicy == enums.EvictionPolicyNoEviction {
+			return ErrOutOfMemory
+		}
+
+		key, ok := db.evictionCandidate(protected)
+		if !ok {
+			return ErrOutOfMemory
+		}
+		db.evict(key)
+	}
+
+	return nil
+}
+
+// evictionCandidate samples the store and returns the key that should be evicted first, other than the protected
+// keys. It returns false if there is no key that can be evicted under the current policy.
+func (db *memoryDB) evictionCandidate(protected func(key string) bool) (string, bool) {
+	var (
+		candidate string
+		best      *Item
+		sampled   int
+	)
+
+	// map iteration order is random, so the first keys returned are a random sample of the store
+	for key, item := range db.store {
+		if protected(key) {
+			continue
+		}
+		if db.evictionPolicy == enums.EvictionPolicyVolatileTTL && !item.ExplicitTTL {
+			continue // only keys stored with a TTL are candidates for volatile-ttl, not those with the default TTL
+		}
+
+		if best == nil || db.evictsBefore(item, best) {
+			candidate, best = key, item
+		}
+
+		sampled++
+		if sampled >= evictionSamples {
+			break
+		}
+	}
+
+	return candidate, best != nil
+}
+
+// evictsBefore reports whether item a should be evicted before item b under the current policy.
+func (db *memoryDB) evictsBefore(a, b *Item) bool {
+	switch db.evictionPolicy {
+	case enums.EvictionPolicyAllKeysLFU:
+		if a.hits != b.hits {
+			return a.hits < b.hits
+		}
+		return a.lastAccess.Before(b.lastAccess)
+	case enums.EvictionPolicyVolatileTTL:
+		return a.TTL.Before(b.TTL)
+	default:
+		return a.lastAccess.Before(b.lastAccess)
+	}
+}
+
+// evict removes the key from the store to free memory and logs the removal.
+func (db *memoryDB) evict(key string) {
+	db.deleteItem(key)
+	db.evictedKeys++
+	db.metrics.CountEvicted()
+	db.events.publish(enums.KeyspaceEventEvict, key)
+	db.logger.Debug("evicted key to free memory", "key", key, "policy", db.evictionPolicy, "used_memory", db.usedMemory)
+
+	// log the eviction as a removal, so the key is not restored when the log is replayed
+	db.logOperation(&Operation{
+		Command: enums.DBCommandRemove,
+		Key:     key,
+		Time:    db.now(),
+	})
+}
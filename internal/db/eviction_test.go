@@ -0,0 +1,206 @@
+package db
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/enums"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EvictionSuite struct {
+	suite.Suite
+}
+
+// newLimitedDB returns a memoryDB that can hold exactly n entries of the given key and value sizes.
+func (s *EvictionSuite) newLimitedDB(n int, policy enums.EvictionPolicy, opts ...DBOptions) *memoryDB {
+	limit := int64(n) * entrySize("key0", &Item{Value: &StringOrSlice{"value"}})
+	opts = append(opts, WithMaxMemory(limit), WithEvictionPolicy(policy))
+	return NewMemoryDB(slog.Default(), opts...).(*memoryDB)
+}
+
+func (s *EvictionSuite) TestMemoryAccounting() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("list", []string{"a"}))
+	initial := db.Stats().UsedMemory
+	s.Require().Equal(entrySize("list", db.store["list"]), initial)
+
+	_, err := db.Push("list", "bb")
+	s.Require().NoError(err)
+	s.Equal(initial+2+sliceElemOverhead, db.Stats().UsedMemory, "push should account for the new element")
+
+	_, err = db.Pop("list")
+	s.Require().NoError(err)
+	s.Equal(initial, db.Stats().UsedMemory, "pop should release the removed element")
+
+	s.Require().NoError(db.Update("list", "a much longer value"))
+	s.Equal(entrySize("list", db.store["list"]), db.Stats().UsedMemory)
+
+	s.Require().NoError(db.Remove("list"))
+	s.Equal(int64(0), db.Stats().UsedMemory, "remove should release the whole entry")
+}
+
+func (s *EvictionSuite) TestNoEviction() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyNoEviction)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value"))
+
+	err := db.Set("key2", "value")
+	s.Require().ErrorIs(err, ErrOutOfMemory)
+	s.NotContains(db.store, "key2")
+
+	// overwriting an existing key with a value of the same size does not need more memory
+	s.Require().NoError(db.Set("key1", "other"))
+	s.Equal(uint64(0), db.Stats().EvictedKeys)
+}
+
+func (s *EvictionSuite) TestAllKeysLRU() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyAllKeysLRU)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	time.Sleep(time.Millisecond)
+	s.Require().NoError(db.Set("key1", "value"))
+	time.Sleep(time.Millisecond)
+
+	// key0 becomes the most recently used key
+	_, err := db.Get("key0")
+	s.Require().NoError(err)
+
+	s.Require().NoError(db.Set("key2", "value"))
+	s.Contains(db.store, "key0")
+	s.NotContains(db.store, "key1", "the least recently used key should be evicted")
+	s.Contains(db.store, "key2")
+	s.Equal(uint64(1), db.Stats().EvictedKeys)
+}
+
+func (s *EvictionSuite) TestSetManyDoesNotEvictItsKeys() {
+	db := s.newLimitedDB(3, enums.EvictionPolicyAllKeysLRU)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key1", "value"))
+	time.Sleep(time.Millisecond)
+	s.Require().NoError(db.Set("key2", "value"))
+	time.Sleep(time.Millisecond)
+	s.Require().NoError(db.Set("key0", "value"))
+
+	// key1 and key2 are the least recently used keys, but they are written by the batch
+	s.Require().NoError(db.SetMany(map[string]any{"key1": "value", "key2": "value", "key3": "value"}))
+	s.NotContains(db.store, "key0", "the key outside of the batch should be evicted")
+	s.Len(db.store, 3)
+	s.Equal(uint64(1), db.Stats().EvictedKeys)
+	s.LessOrEqual(db.Stats().UsedMemory, db.maxMemory)
+}
+
+func (s *EvictionSuite) TestAllKeysLFU() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyAllKeysLFU)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value"))
+	for i := 0; i < 3; i++ {
+		_, err := db.Get("key1")
+		s.Require().NoError(err)
+	}
+
+	s.Require().NoError(db.Set("key2", "value"))
+	s.NotContains(db.store, "key0", "the least frequently used key should be evicted")
+	s.Contains(db.store, "key1")
+	s.Contains(db.store, "key2")
+}
+
+func (s *EvictionSuite) TestVolatileTTL() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyVolatileTTL)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value", WithTTL(time.Hour)))
+	s.Require().NoError(db.Set("key1", "value", WithTTL(time.Minute)))
+
+	s.Require().NoError(db.Set("key2", "value", WithTTL(time.Hour)))
+	s.Contains(db.store, "key0")
+	s.NotContains(db.store, "key1", "the key with the shortest TTL should be evicted")
+	s.Contains(db.store, "key2")
+}
+
+func (s *EvictionSuite) TestVolatileTTLSkipsDefaultTTL() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyVolatileTTL)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value", WithTTL(time.Hour)))
+
+	s.Require().NoError(db.Set("key2", "value"))
+	s.Contains(db.store, "key0", "a key with the default TTL should not be evicted")
+	s.NotContains(db.store, "key1")
+
+	err := db.Set("key3", "value")
+	s.ErrorIs(err, ErrOutOfMemory, "no key is left with a TTL set by the client")
+}
+
+func (s *EvictionSuite) TestInvalidUpdateDoesNotEvict() {
+	db := s.newLimitedDB(2, enums.EvictionPolicyAllKeysLRU)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value"))
+	used := db.Stats().UsedMemory
+
+	err := db.Update("key1", []interface{}{string(make([]byte, 64)), 1})
+	s.Require().ErrorIs(err, ErrInvalidDataType)
+	s.Contains(db.store, "key0", "an invalid update should not evict other keys")
+	s.Equal(used, db.Stats().UsedMemory)
+	s.Equal(uint64(0), db.Stats().EvictedKeys)
+}
+
+func (s *EvictionSuite) TestItemLargerThanLimit() {
+	db := s.newLimitedDB(1, enums.EvictionPolicyAllKeysLRU)
+	defer db.Close()
+
+	s.Require().NoError(db.Set("key0", "value"))
+	err := db.Set("huge", string(make([]byte, 1024)))
+	s.Require().ErrorIs(err, ErrOutOfMemory)
+	s.NotContains(db.store, "key0", "keys are evicted while trying to make room")
+}
+
+func (s *EvictionSuite) TestEvictionIsPersisted() {
+	dbPath := ".db_eviction"
+	defer os.RemoveAll(dbPath)
+
+	db := s.newLimitedDB(1, enums.EvictionPolicyAllKeysLRU, WithPersistenceEnabled(dbPath))
+	s.Require().NoError(db.Set("key0", "value"))
+	s.Require().NoError(db.Set("key1", "value"))
+	db.Close()
+
+	file, err := os.Open(filepath.Join(dbPath, "test_db.log"))
+	s.Require().NoError(err)
+	defer file.Close()
+
+	var commands []enums.DBCommand
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var op Operation
+		s.Require().NoError(json.Unmarshal(scanner.Bytes(), &op))
+		commands = append(commands, op.Command)
+	}
+	s.Equal([]enums.DBCommand{enums.DBCommandSet, enums.DBCommandRemove, enums.DBCommandSet}, commands)
+
+	// the evicted key must not come back after a restart
+	reloaded := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath)).(*memoryDB)
+	defer reloaded.Close()
+	s.NotContains(reloaded.store, "key0")
+	s.Contains(reloaded.store, "key1")
+	s.Equal(entrySize("key1", reloaded.store["key1"]), reloaded.Stats().UsedMemory)
+}
+
+func TestEviction(t *testing.T) {
+	suite.Run(t, new(EvictionSuite))
+}
@@ -0,0 +1,100 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HealthCheck is the result of a check of the state of the database.
+type HealthCheck struct {
+	Name     string // name of the check
+	Liveness bool   // whether a failure means the process is stuck, otherwise that it cannot serve requests yet
+	Err      error  // reason of the failure, nil if the check passed
+}
+
+// Health runs the checks of the database, waiting up to timeout for the lock of the items.
+//
+// The readiness checks fail while the store is being loaded from the operation log, a snapshot or a backup, and
+// while the last write to an operation log failed. The liveness checks fail when the lock of the items is not
+// acquired within the timeout and when the cleanup routine has not run for two intervals. They are skipped while
+// the store is loaded, since the lock is held for the whole load.
+func (db *memoryDB) Health(timeout time.Duration) []HealthCheck {
+	checks := []HealthCheck{{Name: "load"}}
+	loading := db.loading.Load()
+	if loading {
+		checks[0].Err = errors.New("the data is being loaded")
+	}
+	if db.persistenceEnabled {
+		checks = append(checks, HealthCheck{Name: "persistence", Err: db.persistenceError()})
+	}
+
+	cleanup := HealthCheck{Name: "cleanup", Liveness: true}
+	lock := HealthCheck{Name: "lock", Liveness: true}
+	if !loading {
+		if idle := time.Since(time.Unix(0, db.lastCleanup.Load())); idle > 2*db.cleanupInterval+timeout {
+			cleanup.Err = fmt.Errorf("the cleanup routine has not run for %s", idle.Round(time.Second))
+		}
+		if !db.lockAcquired(timeout) {
+			lock.Err = fmt.Errorf("the lock of the items was not acquired within %s", timeout)
+		}
+	}
+	return append(checks, cleanup, lock)
+}
+
+// persistenceError returns the error of the last write to the operation log of the database or of a persisted
+// namespace, nil if the last writes succeeded.
+func (db *memoryDB) persistenceError() error {
+	if err := db.logError.Load(); err != nil {
+		return fmt.Errorf("failed to write to the operation log: %w", *err)
+	}
+
+	db.nsMu.RLock()
+	defer db.nsMu.RUnlock()
+	for name, ns := range db.namespaces {
+		if ns.db == nil {
+			continue
+		}
+		if err := ns.db.logError.Load(); err != nil {
+			return fmt.Errorf("failed to write to the operation log of namespace %s: %w", name, *err)
+		}
+	}
+	return nil
+}
+
+// lockAcquired reports whether the lock of the items is acquired within the timeout. A single goroutine waits for
+// the lock at a time, so the checks of a stuck lock do not pile up goroutines waiting for it.
+func (db *memoryDB) lockAcquired(timeout time.Duration) bool {
+	if db.mu.TryLock() {
+		db.mu.Unlock()
+		return true
+	}
+	if !db.lockWaiting.CompareAndSwap(false, true) {
+		return false // the goroutine of a previous check is still waiting for the lock
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		db.mu.Lock()
+		db.mu.Unlock()
+		db.lockWaiting.Store(false)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// startLoading marks the store as being loaded until the returned function is called, which records that the
+// cleanup routine can run again. It must be called with the lock held.
+func (db *memoryDB) startLoading() func() {
+	db.loading.Store(true)
+	return func() {
+		db.lastCleanup.Store(time.Now().UnixNano())
+		db.loading.Store(false)
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthSuite struct {
+	suite.Suite
+}
+
+// failingWriter fails every write, as a log file on a full or read-only disk does.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+// check returns the check with the name from the results, failing the test if it is missing.
+func (s *HealthSuite) check(checks []HealthCheck, name string) HealthCheck {
+	for _, check := range checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	s.FailNow("missing health check", name)
+	return HealthCheck{}
+}
+
+func (s *HealthSuite) TestHealthy() {
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(s.T().TempDir())).(*memoryDB)
+	defer db.Close()
+
+	checks := db.Health(10 * time.Millisecond)
+	s.Len(checks, 4)
+	for _, check := range checks {
+		s.NoError(check.Err, check.Name)
+	}
+	s.False(s.check(checks, "load").Liveness)
+	s.False(s.check(checks, "persistence").Liveness)
+	s.True(s.check(checks, "cleanup").Liveness)
+	s.True(s.check(checks, "lock").Liveness)
+}
+
+func (s *HealthSuite) TestLoading() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	db.mu.Lock()
+	done := db.startLoading()
+	checks := db.Health(10 * time.Millisecond)
+	s.ErrorContains(s.check(checks, "load").Err, "being loaded")
+	s.NoError(s.check(checks, "lock").Err, "the liveness checks should be skipped while the data is loaded")
+	done()
+	db.mu.Unlock()
+
+	s.NoError(s.check(db.Health(10*time.Millisecond), "load").Err)
+}
+
+func (s *HealthSuite) TestPersistence() {
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(s.T().TempDir())).(*memoryDB)
+	defer db.Close()
+
+	file := db.logWriter.w
+	db.logWriter.w = failingWriter{}
+	s.Require().NoError(db.Set("key", "value"))
+	s.ErrorContains(s.check(db.Health(10*time.Millisecond), "persistence").Err, "no space left on device")
+
+	// the check passes again once the log file is writable
+	db.logWriter.w = file
+	s.Require().NoError(db.Set("key", "value"))
+	s.NoError(s.check(db.Health(10*time.Millisecond), "persistence").Err)
+}
+
+func (s *HealthSuite) TestStuckLock() {
+	db := NewMemoryDB(slog.Default()).(*memoryDB)
+	defer db.Close()
+
+	db.mu.Lock()
+	s.ErrorContains(s.check(db.Health(10*time.Millisecond), "lock").Err, "was not acquired")
+	s.ErrorContains(s.check(db.Health(10*time.Millisecond), "lock").Err, "was not acquired")
+	db.mu.Unlock()
+
+	s.Eventually(func() bool {
+		return s.check(db.Health(10*time.Millisecond), "lock").Err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *HealthSuite) TestStuckCleanup() {
+	db := NewMemoryDB(slog.Default(), WithCleanupInterval(time.Minute)).(*memoryDB)
+	defer db.Close()
+
+	s.NoError(s.check(db.Health(10*time.Millisecond), "cleanup").Err)
+
+	db.lastCleanup.Store(time.Now().Add(-3 * time.Minute).UnixNano())
+	s.ErrorContains(s.check(db.Health(10*time.Millisecond), "cleanup").Err, "has not run for 3m0s")
+}
+
+func TestHealthSuite(t *testing.T) {
+	suite.Run(t, new(HealthSuite))
+}
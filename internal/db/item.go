@@ -2,21 +2,24 @@ package db
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 )
 
-// DataType represents the type of data stored in the item. There are two types:
-// StringType for a single string value and StringSliceType for a slice of strings.
+// DataType represents the type of data stored in the item. There are three types:
+// StringType for a single string value, StringSliceType for a slice of strings and StreamType for a stream of entries.
 type DataType int
 
 const (
 	StringType DataType = iota
 	StringSliceType
+	StreamType
 )
 
 var MappingDataType = map[DataType]string{
 	StringType:      "string",
 	StringSliceType: "string_slice",
+	StreamType:      "stream",
 }
 
 const (
@@ -24,7 +27,8 @@ const (
 )
 
 // ItemOptions is an interface that allows for applying options to an item.
-// In this case, the only option that is available is WithTTL, which sets the time-to-live for the item to a custom value.
+// WithTTL sets the time-to-live for the item to a custom value, WithFlags sets its flags, and WithVersion makes
+// the write conditional.
 type ItemOptions interface {
 	apply(*Item)
 }
@@ -33,7 +37,44 @@ type ItemOptions interface {
 type WithTTL time.Duration
 
 func (o WithTTL) apply(opts *Item) {
-	opts.TTL = time.Now().Add(time.Duration(o))
+	// the TTL is relative to the last update, so it is the same wherever the operation is applied
+	opts.TTL = opts.UpdatedAt.Add(time.Duration(o))
+	opts.ExplicitTTL = true
+}
+
+// defaultItemTTL sets the TTL of an item stored without one to the default TTL of its database. Unlike WithTTL,
+// it does not make the item a candidate of the volatile eviction policies.
+type defaultItemTTL time.Duration
+
+func (o defaultItemTTL) apply(opts *Item) {
+	opts.TTL = opts.UpdatedAt.Add(time.Duration(o))
+}
+
+// WithFlags sets the flags of an item, which are opaque to the database and returned with the item. They are the
+// flags of the memcached protocol, which the clients use to tell how the value was encoded.
+type WithFlags uint32
+
+func (o WithFlags) apply(opts *Item) {
+	opts.Flags = uint32(o)
+}
+
+// WithVersion makes Set and Update conditional: the write is only applied if the version of the item stored at the
+// key is the given one, or, for Set, if the key does not exist and the version is 0. Otherwise, the write fails with
+// ErrVersionMismatch.
+type WithVersion uint64
+
+func (o WithVersion) apply(*Item) {
+	// the version is checked against the stored item before it is written
+}
+
+// expectedVersion returns the version required by the WithVersion option, if it is given.
+func expectedVersion(opts []ItemOptions) (uint64, bool) {
+	for _, opt := range opts {
+		if o, ok := opt.(WithVersion); ok {
+			return uint64(o), true
+		}
+	}
+	return 0, false
 }
 
 // StringOrSlice is a custom type that can hold either a string or a slice of strings.
@@ -95,19 +136,29 @@ func (s StringOrSlice) MarshalJSON() ([]byte, error) {
 
 // item represents a single item in the memory database. It would be similar to a row in a traditional database.
 type Item struct {
-	Value     *StringOrSlice `json:"value"`         // Value can be string or []string
-	TTL       time.Time      `json:"ttl,omitempty"` // TTL is optional and will be omitted if not set
-	Kind      DataType       `json:"kind"`          // Kind is used internally to determine the data type of the value
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	Value  *StringOrSlice `json:"value"`            // Value can be string or []string
+	Stream *Stream        `json:"stream,omitempty"` // Stream holds the entries when Kind is StreamType
+	TTL    time.Time      `json:"ttl,omitempty"`    // TTL is optional and will be omitted if not set
+	// ExplicitTTL reports whether the TTL was set by the client instead of being the default TTL
+	ExplicitTTL bool      `json:"explicit_ttl,omitempty"`
+	Flags       uint32    `json:"flags,omitempty"` // Flags are set by the memcached clients and kept by the updates
+	Kind        DataType  `json:"kind"`            // Kind is used internally to determine the data type of the value
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Version     uint64    `json:"-"` // Version changes on every write to the item, it is not persisted
+
+	// access tracking used by the eviction policies, it is not persisted
+	lastAccess time.Time
+	hits       uint64
 }
 
-// newItem creates a new item with the given value and options
-func newItem(value any, opts ...ItemOptions) (*Item, error) {
+// newItem creates a new item with the given value and options, created at the given time
+func newItem(value any, createdAt time.Time, opts ...ItemOptions) (*Item, error) {
 	dataToBeStored := &Item{
-		TTL:       calculateExpirationTime(defaultTTL),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		TTL:        createdAt.Add(defaultTTL),
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+		lastAccess: createdAt,
 	}
 
 	for _, opt := range opts {
@@ -115,73 +166,68 @@ func newItem(value any, opts ...ItemOptions) (*Item, error) {
 	}
 
 	// Determine the type of value and set the Kind and Value fields accordingly, so it's easier to work with later.
+	kind, val, err := parseValue(value)
+	if err != nil {
+		return nil, err
+	}
+	dataToBeStored.Kind = kind
+	dataToBeStored.Value = val
+	return dataToBeStored, nil
+}
+
+// parseValue returns the kind and the stored form of a value, converting []interface{} to []string.
+// It returns ErrInvalidDataType if the value is neither a string nor a slice of strings.
+func parseValue(value any) (DataType, *StringOrSlice, error) {
 	switch v := value.(type) {
 	case string:
-		dataToBeStored.Kind = StringType
-		dataToBeStored.Value = &StringOrSlice{Val: v}
-		return dataToBeStored, nil
+		return StringType, &StringOrSlice{Val: v}, nil
 	case []string:
-		dataToBeStored.Kind = StringSliceType
-		dataToBeStored.Value = &StringOrSlice{Val: v}
-		return dataToBeStored, nil
+		return StringSliceType, &StringOrSlice{Val: v}, nil
 	case []interface{}:
 		// check if all elements are strings
 		stringSlice := make([]string, len(v))
 		for i, elem := range v {
-			if _, ok := elem.(string); !ok {
-				return nil, ErrInvalidDataType
+			str, ok := elem.(string)
+			if !ok {
+				return 0, nil, ErrInvalidDataType
 			}
-			stringSlice[i] = elem.(string)
+			stringSlice[i] = str
 		}
-		dataToBeStored.Kind = StringSliceType
-		dataToBeStored.Value = &StringOrSlice{Val: stringSlice}
-		return dataToBeStored, nil
+		return StringSliceType, &StringOrSlice{Val: stringSlice}, nil
 	default:
-		return nil, ErrInvalidDataType
+		return 0, nil, ErrInvalidDataType
 	}
 }
 
-// update modifies the value of an existing item in the database.
-func (d *Item) update(value any, updatedAt time.Time, opts ...ItemOptions) error {
+// update modifies the value of an existing item in the database with a value returned by parseValue.
+func (d *Item) update(kind DataType, value *StringOrSlice, updatedAt time.Time, opts ...ItemOptions) error {
 	if d.Value == nil {
 		return ErrDataNotFound
 	}
 
-	// check whether the value is []interface{}. If it is, convert it to []string.
-	// Otherwise, if the value is a string, set it directly.
-	switch v := value.(type) {
-	case string:
-		d.Kind = StringType
-		d.Value = &StringOrSlice{Val: v}
-	case []string:
-		d.Kind = StringSliceType
-		d.Value = &StringOrSlice{Val: v}
-	case []interface{}:
-		// check if all elements are strings
-		stringSlice := make([]string, len(v))
-		for i, elem := range v {
-			if str, ok := elem.(string); ok {
-				stringSlice[i] = str
-			} else {
-				return ErrInvalidDataType
-			}
-		}
-		d.Kind = StringSliceType
-		d.Value = &StringOrSlice{Val: stringSlice}
-	default:
-		return ErrInvalidDataType // Return an error if the value type is not supported
-	}
+	d.Stream = nil
+	d.Kind = kind
+	d.Value = value
+
+	// Update the timestamp, the options that set the TTL are relative to it
+	d.UpdatedAt = updatedAt
 
 	// apply options to set TTL and other properties
 	for _, opt := range opts {
 		opt.apply(d)
 	}
-
-	// Update the timestamp to the current time
-	d.UpdatedAt = updatedAt
 	return nil
 }
 
+// copyTTL sets the TTL of the item to the TTL of the logged item, if it has one.
+func (d *Item) copyTTL(logged *Item) {
+	if logged == nil || logged.TTL.IsZero() {
+		return
+	}
+	d.TTL = logged.TTL
+	d.ExplicitTTL = logged.ExplicitTTL
+}
+
 // pushToSlice adds one or more values to a slice stored in the item.
 func (d *Item) pushToSlice(updatedAt time.Time, value string) error {
 	if d.Kind != StringSliceType {
@@ -196,7 +242,8 @@ func (d *Item) pushToSlice(updatedAt time.Time, value string) error {
 	return nil
 }
 
-// popFromSlice removes the last value from a slice
+// popFromSlice removes the last value from a slice. The capacity of the slice is cut too, so the next push
+// allocates a new array instead of overwriting the removed value in the array shared with the copies of the item.
 func (d *Item) popFromSlice(updatedAt time.Time) error {
 	if d.Kind != StringSliceType {
 		return ErrInvalidDataType
@@ -205,17 +252,27 @@ func (d *Item) popFromSlice(updatedAt time.Time) error {
 	if !ok || len(slice) == 0 {
 		return ErrDataNotFound
 	}
-	d.Value = &StringOrSlice{Val: slice[:len(slice)-1]}
+	d.Value = &StringOrSlice{Val: slice[: len(slice)-1 : len(slice)-1]}
 	d.UpdatedAt = updatedAt
 	return nil
 }
 
-// isExpired checks if the item has expired based on its TTL.
-func (d *Item) isExpired() bool {
-	return d.TTL.Before(time.Now())
+// clone returns a copy of the item that does not share its slice or its stream with the stored item,
+// so it can be read after the lock of the database is released.
+func (d *Item) clone() *Item {
+	copied := *d
+	if d.Value != nil {
+		if slice, ok := d.Value.Val.([]string); ok {
+			copied.Value = &StringOrSlice{Val: slices.Clone(slice)}
+		}
+	}
+	if d.Stream != nil {
+		copied.Stream = d.Stream.snapshot()
+	}
+	return &copied
 }
 
-// calculateExpirationTime calculates the expiration time based on the given TTL.
-func calculateExpirationTime(ttl time.Duration) time.Time {
-	return time.Now().Add(ttl)
+// isExpired checks if the item has expired at the given time based on its TTL.
+func (d *Item) isExpired(now time.Time) bool {
+	return d.TTL.Before(now)
 }
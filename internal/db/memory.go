@@ -1,13 +1,20 @@
 package db
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"memorydb/internal/enums"
+	"memorydb/internal/metrics"
 	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -26,12 +33,48 @@ type memoryDB struct {
 	cleanupInterval time.Duration    // interval for cleanup routine
 	mu              sync.RWMutex     // mutex for preventing race conditions
 	stopChan        chan struct{}    // channel to stop the cleanup routine
+	clock           func() time.Time // source of the current time, used for timestamps and expiration
+	events          *eventBus        // bus where keyspace events are published
+	version         uint64           // version assigned to the last item written
+
+	streamSignals map[string]chan struct{} // channels closed when an entry is added to a stream, used by blocking reads
+
+	// Users and API keys of the authentication module, kept apart from the items with their own lock
+	authStore map[string]*AuthItem   // users by username
+	apiKeys   map[string]*APIKeyItem // API keys by ID
+	authMu    sync.RWMutex           // mutex of the users and API keys, so reading them does not wait for the writes of the items
+
+	// Namespaces, every one with a database of its own, kept apart from the items with their own lock
+	namespaces map[string]*namespace // namespaces by name
+	nsMu       sync.RWMutex          // mutex of the namespaces, so resolving them does not wait for the writes of the items
+	itemTTL    time.Duration         // TTL of the items stored without one
+	parent     *memoryDB             // database the namespace belongs to, nil if the database is not a namespace
+	nsName     string                // name of the namespace in its parent, empty if the database is not a namespace
+
+	// Memory accounting and eviction
+	maxMemory      int64                // approximate memory limit in bytes, 0 means no limit
+	usedMemory     int64                // approximate number of bytes used by the store
+	evictionPolicy enums.EvictionPolicy // policy applied when the memory limit is reached
+	evictedKeys    uint64               // number of keys evicted since the database started
 
 	// Optional features
-	persistenceEnabled bool          // flag to indicate if persistence is enabled
-	dbPath             string        // path for persistence storage, if enabled
-	logFile            *os.File      // file handle for logging operations, if persistence is enabled
-	logEncoder         *json.Encoder // encoder for writing operations to the log file
+	persistenceEnabled bool             // flag to indicate if persistence is enabled
+	dbPath             string           // path for persistence storage, if enabled
+	logFile            *os.File         // file handle for logging operations, if persistence is enabled
+	logEncoder         *json.Encoder    // encoder for writing operations to the log file
+	logWriter          *countingWriter  // writer of the encoder, which counts the bytes written to the log file
+	metrics            *metrics.Metrics // collectors of the metrics of the server, nil if they are disabled
+
+	// Replication
+	replication        *replicationLog // log of operations streamed to the replicas
+	replicationBacklog int             // number of operations kept to resume the replication streams
+	readOnly           atomic.Bool     // whether writes are rejected, which is the case of replicas
+
+	// Health
+	loading     atomic.Bool           // whether the store is being loaded from the operation log, a snapshot or a backup
+	logError    atomic.Pointer[error] // error of the last write to the operation log, nil if it succeeded
+	lastCleanup atomic.Int64          // time of the last run of the cleanup routine, in Unix nanoseconds
+	lockWaiting atomic.Bool           // whether a health check is waiting for the lock of the items
 }
 
 // NewmemoryDB creates a new instance of memoryDB with an initialized store.
@@ -41,12 +84,24 @@ func NewMemoryDB(logger *slog.Logger, opts ...DBOptions) DBClient {
 		store:           make(map[string]*Item),
 		cleanupInterval: defaultCleanupInterval,
 		stopChan:        make(chan struct{}),
+		clock:           time.Now,
+		events:          newEventBus(logger),
+		streamSignals:   make(map[string]chan struct{}),
+		authStore:       make(map[string]*AuthItem),
+		apiKeys:         make(map[string]*APIKeyItem),
+		namespaces:      make(map[string]*namespace),
+		itemTTL:         defaultTTL,
+		evictionPolicy:  enums.EvictionPolicyNoEviction,
+
+		replicationBacklog: defaultReplicationBacklog,
 	}
 
 	// Apply options to the memoryDB instance
 	for _, opt := range opts {
 		opt.apply(db)
 	}
+	db.replication = newReplicationLog(logger, db.replicationBacklog)
+	db.metrics.CountKeys(db.keysByType)
 
 	// If persistence is enabled, set up the log file and encoder
 	if db.persistenceEnabled {
@@ -55,17 +110,29 @@ func NewMemoryDB(logger *slog.Logger, opts ...DBOptions) DBClient {
 		if err := db.loadStoredData(); err != nil {
 			panic(fmt.Sprintf("failed to load stored data: %v", err))
 		}
+		if err := db.openNamespaces(); err != nil {
+			panic(fmt.Sprintf("failed to open namespaces: %v", err))
+		}
 	}
 
 	// Start a cleanup routine to remove expired items every 5 minutes
+	db.lastCleanup.Store(time.Now().UnixNano())
 	go db.startCleanupRoutine()
 	return db
 }
 
 // Get retrieves an item from the memory database by its key.
 func (db *memoryDB) Get(key string) (*Item, error) {
+	return db.get(context.Background(), key)
+}
+
+// get is Get within the trace of the context.
+func (db *memoryDB) get(ctx context.Context, key string) (_ *Item, err error) {
+	ctx, span := startSpan(ctx, "db.get", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
 	// instead of using RLock, we use Lock here to ensure that we can check for expiration and remove items atomically
-	db.mu.Lock()
+	db.lock(ctx)
 	defer db.mu.Unlock()
 	value, exists := db.store[key]
 	if !exists {
@@ -75,84 +142,219 @@ func (db *memoryDB) Get(key string) (*Item, error) {
 		return nil, e
 	}
 
-	if value.isExpired() {
-		delete(db.store, key) // Remove expired item
+	if value.isExpired(db.now()) {
+		db.expireItem(key) // Remove expired item
 		return nil, ErrKeyHasExpired
 	}
 
-	return value, nil
+	value.touch(db.now())
+
+	return value.clone(), nil
 }
 
 // Set stores an item in the memory database with the specified key and value.
 func (db *memoryDB) Set(key string, value any, opts ...ItemOptions) error {
-	db.mu.Lock()
+	return db.set(context.Background(), key, value, opts...)
+}
+
+// set is Set within the trace of the context.
+func (db *memoryDB) set(ctx context.Context, key string, value any, opts ...ItemOptions) (err error) {
+	ctx, span := startSpan(ctx, "db.set", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.lock(ctx)
 	defer db.mu.Unlock()
 
-	itemToStore, err := newItem(value, opts...)
+	itemToStore, err := newItem(value, db.now(), db.itemOptions(opts)...)
 	if err != nil {
 		return fmt.Errorf("failed to create value for key %s: %w", key, err)
 	}
+	if version, ok := expectedVersion(opts); ok && version != db.currentVersion(key) {
+		return ErrVersionMismatch
+	}
+
+	// make room for the new item, the previous value of the key is released when it is replaced
+	delta := entrySize(key, itemToStore)
+	if previous, exists := db.store[key]; exists {
+		delta -= entrySize(key, previous)
+	}
+	if err := db.reserveMemory(delta, key); err != nil {
+		return err
+	}
 
 	// log the operation
-	db.logOperation(&Operation{
+	db.logTraced(ctx, &Operation{
 		Command: enums.DBCommandSet,
 		Key:     key,
-		Time:    time.Now(),
+		Time:    db.now(),
 		Item:    itemToStore,
 	})
 
-	db.store[key] = itemToStore
+	db.storeItem(key, itemToStore)
+	db.events.publish(enums.KeyspaceEventSet, key)
+	return nil
+}
+
+// SetMany stores the items under their keys with the same options, all of them or none of them.
+// The conditional option WithVersion is ignored, since it refers to a single item.
+func (db *memoryDB) SetMany(items map[string]any, opts ...ItemOptions) error {
+	return db.setMany(context.Background(), items, opts...)
+}
+
+// setMany is SetMany within the trace of the context.
+func (db *memoryDB) setMany(ctx context.Context, items map[string]any, opts ...ItemOptions) (err error) {
+	ctx, span := startSpan(ctx, "db.set_many", attribute.Int("db.keys", len(items)))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.lock(ctx)
+	defer db.mu.Unlock()
+
+	// create every item before storing any of them, so an invalid value leaves the store untouched
+	keys := slices.Sorted(maps.Keys(items))
+	itemsToStore := make([]*Item, len(keys))
+	var delta int64
+	for i, key := range keys {
+		item, err := newItem(items[key], db.now(), db.itemOptions(opts)...)
+		if err != nil {
+			return fmt.Errorf("failed to create value for key %s: %w", key, err)
+		}
+		itemsToStore[i] = item
+		delta += entrySize(key, item)
+		if previous, exists := db.store[key]; exists {
+			delta -= entrySize(key, previous)
+		}
+	}
+	// none of the keys of the batch is evicted to make room for the others
+	if err := db.reserveMemoryFor(delta, func(key string) bool { _, ok := items[key]; return ok }); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		db.logTraced(ctx, &Operation{
+			Command: enums.DBCommandSet,
+			Key:     key,
+			Time:    db.now(),
+			Item:    itemsToStore[i],
+		})
+		db.storeItem(key, itemsToStore[i])
+		db.events.publish(enums.KeyspaceEventSet, key)
+	}
 	return nil
 }
 
 // Update updates an existing item in the memory database with the specified key and value.
 func (db *memoryDB) Update(key string, value any, opts ...ItemOptions) error {
-	db.mu.Lock()
+	return db.update(context.Background(), key, value, opts...)
+}
+
+// update is Update within the trace of the context.
+func (db *memoryDB) update(ctx context.Context, key string, value any, opts ...ItemOptions) (err error) {
+	ctx, span := startSpan(ctx, "db.update", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.lock(ctx)
 	defer db.mu.Unlock()
 
 	itemToUpdate, exists := db.store[key]
 	if !exists {
 		return fmt.Errorf("key %s not found for update", key)
 	}
+	if itemToUpdate.Kind == StreamType {
+		return fmt.Errorf("failed to update value for key '%s': %w", key, ErrInvalidDataType)
+	}
+	if version, ok := expectedVersion(opts); ok && version != db.currentVersion(key) {
+		return ErrVersionMismatch
+	}
+
+	// the value is validated before keys are evicted to make room for it
+	kind, newValue, err := parseValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to update value for key '%s': %w", key, err)
+	}
+	if err := db.reserveMemory(valueSize(newValue.Val)-valueSize(itemToUpdate.Value.Val), key); err != nil {
+		return err
+	}
 
-	if err := itemToUpdate.update(value, time.Now(), opts...); err != nil {
+	sizeBefore := entrySize(key, itemToUpdate)
+	updatedAt := db.now()
+	if err := itemToUpdate.update(kind, newValue, updatedAt, opts...); err != nil {
 		return fmt.Errorf("failed to update value for key '%s': %w", key, err)
 	}
+	itemToUpdate.touch(updatedAt)
+	db.usedMemory += entrySize(key, itemToUpdate) - sizeBefore
 
-	db.logOperation(&Operation{
+	db.logTraced(ctx, &Operation{
 		Command: enums.DBCommandUpdate,
 		Key:     key,
-		Time:    time.Now(),
+		Time:    db.now(),
 		Item:    itemToUpdate,
 	})
 
+	db.events.publish(enums.KeyspaceEventUpdate, key)
 	return nil
 }
 
 // Remove deletes an item from the memory database by its key.
 func (db *memoryDB) Remove(key string) error {
-	db.mu.Lock()
+	return db.remove(context.Background(), key)
+}
+
+// remove is Remove within the trace of the context.
+func (db *memoryDB) remove(ctx context.Context, key string) (err error) {
+	ctx, span := startSpan(ctx, "db.remove", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.lock(ctx)
 	defer db.mu.Unlock()
 
 	if _, exists := db.store[key]; !exists {
 		return fmt.Errorf("key %s not found for removal", key)
 	}
 
-	delete(db.store, key)
+	db.deleteItem(key)
 
 	// log the operation
-	db.logOperation(&Operation{
+	db.logTraced(ctx, &Operation{
 		Command: enums.DBCommandRemove,
 		Key:     key,
-		Time:    time.Now(),
+		Time:    db.now(),
 	})
 
+	db.events.publish(enums.KeyspaceEventRemove, key)
 	return nil
 }
 
 // Push adds a new item to the memory database with the specified key and value.
 func (db *memoryDB) Push(key string, value string, opts ...ItemOptions) (*Item, error) {
-	db.mu.Lock()
+	return db.push(context.Background(), key, value, opts...)
+}
+
+// push is Push within the trace of the context.
+func (db *memoryDB) push(ctx context.Context, key string, value string, opts ...ItemOptions) (_ *Item, err error) {
+	ctx, span := startSpan(ctx, "db.push", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return nil, ErrReadOnly
+	}
+
+	db.lock(ctx)
 	defer db.mu.Unlock()
 
 	item, exists := db.store[key]
@@ -160,13 +362,20 @@ func (db *memoryDB) Push(key string, value string, opts ...ItemOptions) (*Item,
 		return nil, fmt.Errorf("key %s not found for push", key)
 	}
 
-	updatedAt := time.Now()
+	if err := db.reserveMemory(int64(len(value))+sliceElemOverhead, key); err != nil {
+		return nil, err
+	}
+
+	sizeBefore := entrySize(key, item)
+	updatedAt := db.now()
 	if err := item.pushToSlice(updatedAt, value); err != nil {
 		return nil, fmt.Errorf("failed to push values to key %s: %w", key, err)
 	}
+	item.touch(updatedAt)
+	db.usedMemory += entrySize(key, item) - sizeBefore
 
 	// log the operation
-	db.logOperation(&Operation{
+	db.logTraced(ctx, &Operation{
 		Command: enums.DBCommandPush,
 		Key:     key,
 		Time:    updatedAt,
@@ -176,12 +385,25 @@ func (db *memoryDB) Push(key string, value string, opts ...ItemOptions) (*Item,
 		},
 	})
 
-	return item, nil
+	db.events.publish(enums.KeyspaceEventPush, key)
+	return item.clone(), nil
 }
 
 // Pop removes the last item from the slice stored at the specified key in the memory database.
 func (db *memoryDB) Pop(key string) (*Item, error) {
-	db.mu.Lock()
+	return db.pop(context.Background(), key)
+}
+
+// pop is Pop within the trace of the context.
+func (db *memoryDB) pop(ctx context.Context, key string) (_ *Item, err error) {
+	ctx, span := startSpan(ctx, "db.pop", attribute.String("db.key", key))
+	defer func() { endSpan(span, err) }()
+
+	if db.readOnly.Load() {
+		return nil, ErrReadOnly
+	}
+
+	db.lock(ctx)
 	defer db.mu.Unlock()
 
 	item, exists := db.store[key]
@@ -189,13 +411,16 @@ func (db *memoryDB) Pop(key string) (*Item, error) {
 		return nil, fmt.Errorf("key %s not found for pop", key)
 	}
 
-	updatedAt := time.Now()
+	sizeBefore := entrySize(key, item)
+	updatedAt := db.now()
 	if err := item.popFromSlice(updatedAt); err != nil {
 		return nil, fmt.Errorf("failed to pop item from key %s: %w", key, err)
 	}
+	item.touch(updatedAt)
+	db.usedMemory += entrySize(key, item) - sizeBefore
 
 	// log the operation
-	db.logOperation(&Operation{
+	db.logTraced(ctx, &Operation{
 		Command: enums.DBCommandPop,
 		Key:     key,
 		Time:    updatedAt,
@@ -204,15 +429,35 @@ func (db *memoryDB) Pop(key string) (*Item, error) {
 		},
 	})
 
-	return item, nil
+	db.events.publish(enums.KeyspaceEventPop, key)
+	return item.clone(), nil
+}
+
+// Subscribe returns a channel with the keyspace events of the keys that match the glob pattern.
+//
+// If lastEventID is not zero, the recent events after that ID are delivered first, so a subscriber can resume
+// where it left off. The channel is closed when the subscription is cancelled, when the subscriber does not keep up
+// with the events or when the database is closed.
+func (db *memoryDB) Subscribe(pattern string, lastEventID uint64) (<-chan Event, func()) {
+	return db.events.subscribe(pattern, lastEventID)
 }
 
 // Close stops the cleanup routine and releases resources held by the memoryDB.
 func (db *memoryDB) Close() {
 	close(db.stopChan) // Signal the cleanup routine to stop
+	db.events.close()  // Deliver the pending events and disconnect the subscribers
+	db.replication.close()
 	db.mu.Lock()
 	defer db.mu.Unlock()
 	db.store = make(map[string]*Item)
+	db.usedMemory = 0
+
+	// wake up the blocked stream readers, they will find that the stream no longer exists
+	for key := range db.streamSignals {
+		db.notifyStream(key)
+	}
+
+	db.closeNamespaces()
 
 	// close the log file if persistence is enabled
 	if db.persistenceEnabled {
@@ -231,6 +476,7 @@ func (db *memoryDB) startCleanupRoutine() {
 		select {
 		case <-ticker.C:
 			db.cleanExpired()
+			db.lastCleanup.Store(time.Now().UnixNano())
 		case <-db.stopChan:
 			return
 		}
@@ -242,9 +488,54 @@ func (db *memoryDB) cleanExpired() {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
+	start := time.Now()
 	for key, item := range db.store {
-		if item.isExpired() {
-			delete(db.store, key)
+		if item.isExpired(db.now()) {
+			db.expireItem(key)
 		}
 	}
+	db.metrics.ObserveCleanup(time.Since(start))
+}
+
+// now returns the current time according to the clock of the database.
+func (db *memoryDB) now() time.Time {
+	return db.clock()
+}
+
+// itemOptions returns the options of a new item, which expires after the default TTL of the database unless
+// the options set another TTL.
+func (db *memoryDB) itemOptions(opts []ItemOptions) []ItemOptions {
+	if db.itemTTL == defaultTTL {
+		return opts
+	}
+	return append([]ItemOptions{defaultItemTTL(db.itemTTL)}, opts...)
+}
+
+// currentVersion returns the version of the item stored at the key, or 0 if the key does not exist or has expired.
+// It must be called with the lock held.
+func (db *memoryDB) currentVersion(key string) uint64 {
+	item, exists := db.store[key]
+	if !exists || item.isExpired(db.now()) {
+		return 0
+	}
+	return item.Version
+}
+
+// storeItem stores the item under the given key and keeps the memory accounting up to date.
+// It must be called with the lock held.
+func (db *memoryDB) storeItem(key string, item *Item) {
+	if previous, exists := db.store[key]; exists {
+		db.usedMemory -= entrySize(key, previous)
+	}
+	db.store[key] = item
+	db.usedMemory += entrySize(key, item)
+}
+
+// deleteItem removes the key from the store and keeps the memory accounting up to date.
+// It must be called with the lock held.
+func (db *memoryDB) deleteItem(key string) {
+	if item, exists := db.store[key]; exists {
+		db.usedMemory -= entrySize(key, item)
+		delete(db.store, key)
+	}
 }
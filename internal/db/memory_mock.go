@@ -5,6 +5,11 @@
 package db
 
 import (
+	"context"
+	"io"
+	"memorydb/internal/enums"
+	"time"
+
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -35,6 +40,163 @@ func (_m *MockDBClient) EXPECT() *MockDBClient_Expecter {
 	return &MockDBClient_Expecter{mock: &_m.Mock}
 }
 
+// APIKeys provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) APIKeys() []*APIKeyItem {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for APIKeys")
+	}
+
+	var r0 []*APIKeyItem
+	if returnFunc, ok := ret.Get(0).(func() []*APIKeyItem); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*APIKeyItem)
+		}
+	}
+	return r0
+}
+
+// MockDBClient_APIKeys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'APIKeys'
+type MockDBClient_APIKeys_Call struct {
+	*mock.Call
+}
+
+// APIKeys is a helper method to define mock.On call
+func (_e *MockDBClient_Expecter) APIKeys() *MockDBClient_APIKeys_Call {
+	return &MockDBClient_APIKeys_Call{Call: _e.mock.On("APIKeys")}
+}
+
+func (_c *MockDBClient_APIKeys_Call) Run(run func()) *MockDBClient_APIKeys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDBClient_APIKeys_Call) Return(aPIKeyItems []*APIKeyItem) *MockDBClient_APIKeys_Call {
+	_c.Call.Return(aPIKeyItems)
+	return _c
+}
+
+func (_c *MockDBClient_APIKeys_Call) RunAndReturn(run func() []*APIKeyItem) *MockDBClient_APIKeys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ApplyReplicated provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) ApplyReplicated(op ReplicatedOperation) error {
+	ret := _mock.Called(op)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyReplicated")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(ReplicatedOperation) error); ok {
+		r0 = returnFunc(op)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_ApplyReplicated_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyReplicated'
+type MockDBClient_ApplyReplicated_Call struct {
+	*mock.Call
+}
+
+// ApplyReplicated is a helper method to define mock.On call
+//   - op ReplicatedOperation
+func (_e *MockDBClient_Expecter) ApplyReplicated(op interface{}) *MockDBClient_ApplyReplicated_Call {
+	return &MockDBClient_ApplyReplicated_Call{Call: _e.mock.On("ApplyReplicated", op)}
+}
+
+func (_c *MockDBClient_ApplyReplicated_Call) Run(run func(op ReplicatedOperation)) *MockDBClient_ApplyReplicated_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 ReplicatedOperation
+		if args[0] != nil {
+			arg0 = args[0].(ReplicatedOperation)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_ApplyReplicated_Call) Return(err error) *MockDBClient_ApplyReplicated_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_ApplyReplicated_Call) RunAndReturn(run func(op ReplicatedOperation) error) *MockDBClient_ApplyReplicated_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Backup provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Backup(w io.Writer) (BackupHeader, error) {
+	ret := _mock.Called(w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Backup")
+	}
+
+	var r0 BackupHeader
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(io.Writer) (BackupHeader, error)); ok {
+		return returnFunc(w)
+	}
+	if returnFunc, ok := ret.Get(0).(func(io.Writer) BackupHeader); ok {
+		r0 = returnFunc(w)
+	} else {
+		r0 = ret.Get(0).(BackupHeader)
+	}
+	if returnFunc, ok := ret.Get(1).(func(io.Writer) error); ok {
+		r1 = returnFunc(w)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Backup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Backup'
+type MockDBClient_Backup_Call struct {
+	*mock.Call
+}
+
+// Backup is a helper method to define mock.On call
+//   - w io.Writer
+func (_e *MockDBClient_Expecter) Backup(w interface{}) *MockDBClient_Backup_Call {
+	return &MockDBClient_Backup_Call{Call: _e.mock.On("Backup", w)}
+}
+
+func (_c *MockDBClient_Backup_Call) Run(run func(w io.Writer)) *MockDBClient_Backup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 io.Writer
+		if args[0] != nil {
+			arg0 = args[0].(io.Writer)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Backup_Call) Return(backupHeader BackupHeader, err error) *MockDBClient_Backup_Call {
+	_c.Call.Return(backupHeader, err)
+	return _c
+}
+
+func (_c *MockDBClient_Backup_Call) RunAndReturn(run func(w io.Writer) (BackupHeader, error)) *MockDBClient_Backup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Close provides a mock function for the type MockDBClient
 func (_mock *MockDBClient) Close() {
 	_mock.Called()
@@ -58,342 +220,2200 @@ func (_c *MockDBClient_Close_Call) Run(run func()) *MockDBClient_Close_Call {
 	return _c
 }
 
-func (_c *MockDBClient_Close_Call) Return() *MockDBClient_Close_Call {
-	_c.Call.Return()
+func (_c *MockDBClient_Close_Call) Return() *MockDBClient_Close_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockDBClient_Close_Call) RunAndReturn(run func()) *MockDBClient_Close_Call {
+	_c.Run(run)
+	return _c
+}
+
+// CreateNamespace provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) CreateNamespace(name string, config NamespaceConfig) error {
+	ret := _mock.Called(name, config)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateNamespace")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, NamespaceConfig) error); ok {
+		r0 = returnFunc(name, config)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_CreateNamespace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateNamespace'
+type MockDBClient_CreateNamespace_Call struct {
+	*mock.Call
+}
+
+// CreateNamespace is a helper method to define mock.On call
+//   - name string
+//   - config NamespaceConfig
+func (_e *MockDBClient_Expecter) CreateNamespace(name interface{}, config interface{}) *MockDBClient_CreateNamespace_Call {
+	return &MockDBClient_CreateNamespace_Call{Call: _e.mock.On("CreateNamespace", name, config)}
+}
+
+func (_c *MockDBClient_CreateNamespace_Call) Run(run func(name string, config NamespaceConfig)) *MockDBClient_CreateNamespace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 NamespaceConfig
+		if args[1] != nil {
+			arg1 = args[1].(NamespaceConfig)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_CreateNamespace_Call) Return(err error) *MockDBClient_CreateNamespace_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_CreateNamespace_Call) RunAndReturn(run func(name string, config NamespaceConfig) error) *MockDBClient_CreateNamespace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DropNamespace provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) DropNamespace(name string) error {
+	ret := _mock.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DropNamespace")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(name)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_DropNamespace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DropNamespace'
+type MockDBClient_DropNamespace_Call struct {
+	*mock.Call
+}
+
+// DropNamespace is a helper method to define mock.On call
+//   - name string
+func (_e *MockDBClient_Expecter) DropNamespace(name interface{}) *MockDBClient_DropNamespace_Call {
+	return &MockDBClient_DropNamespace_Call{Call: _e.mock.On("DropNamespace", name)}
+}
+
+func (_c *MockDBClient_DropNamespace_Call) Run(run func(name string)) *MockDBClient_DropNamespace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_DropNamespace_Call) Return(err error) *MockDBClient_DropNamespace_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_DropNamespace_Call) RunAndReturn(run func(name string) error) *MockDBClient_DropNamespace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Dump provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Dump(key string) ([]byte, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Dump")
+	}
+
+	var r0 []byte
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) ([]byte, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) []byte); ok {
+		r0 = returnFunc(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Dump_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Dump'
+type MockDBClient_Dump_Call struct {
+	*mock.Call
+}
+
+// Dump is a helper method to define mock.On call
+//   - key string
+func (_e *MockDBClient_Expecter) Dump(key interface{}) *MockDBClient_Dump_Call {
+	return &MockDBClient_Dump_Call{Call: _e.mock.On("Dump", key)}
+}
+
+func (_c *MockDBClient_Dump_Call) Run(run func(key string)) *MockDBClient_Dump_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Dump_Call) Return(bytes []byte, err error) *MockDBClient_Dump_Call {
+	_c.Call.Return(bytes, err)
+	return _c
+}
+
+func (_c *MockDBClient_Dump_Call) RunAndReturn(run func(key string) ([]byte, error)) *MockDBClient_Dump_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Get provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Get(key string) (*Item, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Get")
+	}
+
+	var r0 *Item
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*Item, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *Item); ok {
+		r0 = returnFunc(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Item)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
+type MockDBClient_Get_Call struct {
+	*mock.Call
+}
+
+// Get is a helper method to define mock.On call
+//   - key string
+func (_e *MockDBClient_Expecter) Get(key interface{}) *MockDBClient_Get_Call {
+	return &MockDBClient_Get_Call{Call: _e.mock.On("Get", key)}
+}
+
+func (_c *MockDBClient_Get_Call) Run(run func(key string)) *MockDBClient_Get_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Get_Call) Return(item *Item, err error) *MockDBClient_Get_Call {
+	_c.Call.Return(item, err)
+	return _c
+}
+
+func (_c *MockDBClient_Get_Call) RunAndReturn(run func(key string) (*Item, error)) *MockDBClient_Get_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetAPIKey provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) GetAPIKey(id string) (*APIKeyItem, error) {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAPIKey")
+	}
+
+	var r0 *APIKeyItem
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*APIKeyItem, error)); ok {
+		return returnFunc(id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *APIKeyItem); ok {
+		r0 = returnFunc(id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*APIKeyItem)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_GetAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetAPIKey'
+type MockDBClient_GetAPIKey_Call struct {
+	*mock.Call
+}
+
+// GetAPIKey is a helper method to define mock.On call
+//   - id string
+func (_e *MockDBClient_Expecter) GetAPIKey(id interface{}) *MockDBClient_GetAPIKey_Call {
+	return &MockDBClient_GetAPIKey_Call{Call: _e.mock.On("GetAPIKey", id)}
+}
+
+func (_c *MockDBClient_GetAPIKey_Call) Run(run func(id string)) *MockDBClient_GetAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_GetAPIKey_Call) Return(aPIKeyItem *APIKeyItem, err error) *MockDBClient_GetAPIKey_Call {
+	_c.Call.Return(aPIKeyItem, err)
+	return _c
+}
+
+func (_c *MockDBClient_GetAPIKey_Call) RunAndReturn(run func(id string) (*APIKeyItem, error)) *MockDBClient_GetAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetUser provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) GetUser(username string) (*AuthItem, error) {
+	ret := _mock.Called(username)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUser")
+	}
+
+	var r0 *AuthItem
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*AuthItem, error)); ok {
+		return returnFunc(username)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *AuthItem); ok {
+		r0 = returnFunc(username)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*AuthItem)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(username)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_GetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetUser'
+type MockDBClient_GetUser_Call struct {
+	*mock.Call
+}
+
+// GetUser is a helper method to define mock.On call
+//   - username string
+func (_e *MockDBClient_Expecter) GetUser(username interface{}) *MockDBClient_GetUser_Call {
+	return &MockDBClient_GetUser_Call{Call: _e.mock.On("GetUser", username)}
+}
+
+func (_c *MockDBClient_GetUser_Call) Run(run func(username string)) *MockDBClient_GetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_GetUser_Call) Return(authItem *AuthItem, err error) *MockDBClient_GetUser_Call {
+	_c.Call.Return(authItem, err)
+	return _c
+}
+
+func (_c *MockDBClient_GetUser_Call) RunAndReturn(run func(username string) (*AuthItem, error)) *MockDBClient_GetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Health provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Health(timeout time.Duration) []HealthCheck {
+	ret := _mock.Called(timeout)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Health")
+	}
+
+	var r0 []HealthCheck
+	if returnFunc, ok := ret.Get(0).(func(time.Duration) []HealthCheck); ok {
+		r0 = returnFunc(timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]HealthCheck)
+		}
+	}
+	return r0
+}
+
+// MockDBClient_Health_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Health'
+type MockDBClient_Health_Call struct {
+	*mock.Call
+}
+
+// Health is a helper method to define mock.On call
+//   - timeout time.Duration
+func (_e *MockDBClient_Expecter) Health(timeout interface{}) *MockDBClient_Health_Call {
+	return &MockDBClient_Health_Call{Call: _e.mock.On("Health", timeout)}
+}
+
+func (_c *MockDBClient_Health_Call) Run(run func(timeout time.Duration)) *MockDBClient_Health_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 time.Duration
+		if args[0] != nil {
+			arg0 = args[0].(time.Duration)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Health_Call) Return(healthChecks []HealthCheck) *MockDBClient_Health_Call {
+	_c.Call.Return(healthChecks)
+	return _c
+}
+
+func (_c *MockDBClient_Health_Call) RunAndReturn(run func(timeout time.Duration) []HealthCheck) *MockDBClient_Health_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Keys provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Keys(match string) []string {
+	ret := _mock.Called(match)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Keys")
+	}
+
+	var r0 []string
+	if returnFunc, ok := ret.Get(0).(func(string) []string); ok {
+		r0 = returnFunc(match)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+	return r0
+}
+
+// MockDBClient_Keys_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Keys'
+type MockDBClient_Keys_Call struct {
+	*mock.Call
+}
+
+// Keys is a helper method to define mock.On call
+//   - match string
+func (_e *MockDBClient_Expecter) Keys(match interface{}) *MockDBClient_Keys_Call {
+	return &MockDBClient_Keys_Call{Call: _e.mock.On("Keys", match)}
+}
+
+func (_c *MockDBClient_Keys_Call) Run(run func(match string)) *MockDBClient_Keys_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Keys_Call) Return(strings []string) *MockDBClient_Keys_Call {
+	_c.Call.Return(strings)
+	return _c
+}
+
+func (_c *MockDBClient_Keys_Call) RunAndReturn(run func(match string) []string) *MockDBClient_Keys_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LoadSnapshot provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) LoadSnapshot(r io.Reader) (uint64, error) {
+	ret := _mock.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LoadSnapshot")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(io.Reader) (uint64, error)); ok {
+		return returnFunc(r)
+	}
+	if returnFunc, ok := ret.Get(0).(func(io.Reader) uint64); ok {
+		r0 = returnFunc(r)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(io.Reader) error); ok {
+		r1 = returnFunc(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_LoadSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LoadSnapshot'
+type MockDBClient_LoadSnapshot_Call struct {
+	*mock.Call
+}
+
+// LoadSnapshot is a helper method to define mock.On call
+//   - r io.Reader
+func (_e *MockDBClient_Expecter) LoadSnapshot(r interface{}) *MockDBClient_LoadSnapshot_Call {
+	return &MockDBClient_LoadSnapshot_Call{Call: _e.mock.On("LoadSnapshot", r)}
+}
+
+func (_c *MockDBClient_LoadSnapshot_Call) Run(run func(r io.Reader)) *MockDBClient_LoadSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 io.Reader
+		if args[0] != nil {
+			arg0 = args[0].(io.Reader)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_LoadSnapshot_Call) Return(v uint64, err error) *MockDBClient_LoadSnapshot_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *MockDBClient_LoadSnapshot_Call) RunAndReturn(run func(r io.Reader) (uint64, error)) *MockDBClient_LoadSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Namespace provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Namespace(name string) (DBClient, error) {
+	ret := _mock.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Namespace")
+	}
+
+	var r0 DBClient
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (DBClient, error)); ok {
+		return returnFunc(name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) DBClient); ok {
+		r0 = returnFunc(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(DBClient)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Namespace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Namespace'
+type MockDBClient_Namespace_Call struct {
+	*mock.Call
+}
+
+// Namespace is a helper method to define mock.On call
+//   - name string
+func (_e *MockDBClient_Expecter) Namespace(name interface{}) *MockDBClient_Namespace_Call {
+	return &MockDBClient_Namespace_Call{Call: _e.mock.On("Namespace", name)}
+}
+
+func (_c *MockDBClient_Namespace_Call) Run(run func(name string)) *MockDBClient_Namespace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Namespace_Call) Return(dBClient DBClient, err error) *MockDBClient_Namespace_Call {
+	_c.Call.Return(dBClient, err)
+	return _c
+}
+
+func (_c *MockDBClient_Namespace_Call) RunAndReturn(run func(name string) (DBClient, error)) *MockDBClient_Namespace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Namespaces provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Namespaces() []NamespaceItem {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Namespaces")
+	}
+
+	var r0 []NamespaceItem
+	if returnFunc, ok := ret.Get(0).(func() []NamespaceItem); ok {
+		r0 = returnFunc()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]NamespaceItem)
+		}
+	}
+	return r0
+}
+
+// MockDBClient_Namespaces_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Namespaces'
+type MockDBClient_Namespaces_Call struct {
+	*mock.Call
+}
+
+// Namespaces is a helper method to define mock.On call
+func (_e *MockDBClient_Expecter) Namespaces() *MockDBClient_Namespaces_Call {
+	return &MockDBClient_Namespaces_Call{Call: _e.mock.On("Namespaces")}
+}
+
+func (_c *MockDBClient_Namespaces_Call) Run(run func()) *MockDBClient_Namespaces_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Namespaces_Call) Return(namespaceItems []NamespaceItem) *MockDBClient_Namespaces_Call {
+	_c.Call.Return(namespaceItems)
+	return _c
+}
+
+func (_c *MockDBClient_Namespaces_Call) RunAndReturn(run func() []NamespaceItem) *MockDBClient_Namespaces_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Pop provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Pop(key string) (*Item, error) {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Pop")
+	}
+
+	var r0 *Item
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string) (*Item, error)); ok {
+		return returnFunc(key)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string) *Item); ok {
+		r0 = returnFunc(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Item)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
+		r1 = returnFunc(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
+type MockDBClient_Pop_Call struct {
+	*mock.Call
+}
+
+// Pop is a helper method to define mock.On call
+//   - key string
+func (_e *MockDBClient_Expecter) Pop(key interface{}) *MockDBClient_Pop_Call {
+	return &MockDBClient_Pop_Call{Call: _e.mock.On("Pop", key)}
+}
+
+func (_c *MockDBClient_Pop_Call) Run(run func(key string)) *MockDBClient_Pop_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Pop_Call) Return(item *Item, err error) *MockDBClient_Pop_Call {
+	_c.Call.Return(item, err)
+	return _c
+}
+
+func (_c *MockDBClient_Pop_Call) RunAndReturn(run func(key string) (*Item, error)) *MockDBClient_Pop_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Push provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Push(key string, value string, opts ...ItemOptions) (*Item, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(key, value, opts)
+	} else {
+		tmpRet = _mock.Called(key, value)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for Push")
+	}
+
+	var r0 *Item
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, ...ItemOptions) (*Item, error)); ok {
+		return returnFunc(key, value, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, ...ItemOptions) *Item); ok {
+		r0 = returnFunc(key, value, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Item)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string, ...ItemOptions) error); ok {
+		r1 = returnFunc(key, value, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_Push_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Push'
+type MockDBClient_Push_Call struct {
+	*mock.Call
+}
+
+// Push is a helper method to define mock.On call
+//   - key string
+//   - value string
+//   - opts ...ItemOptions
+func (_e *MockDBClient_Expecter) Push(key interface{}, value interface{}, opts ...interface{}) *MockDBClient_Push_Call {
+	return &MockDBClient_Push_Call{Call: _e.mock.On("Push",
+		append([]interface{}{key, value}, opts...)...)}
+}
+
+func (_c *MockDBClient_Push_Call) Run(run func(key string, value string, opts ...ItemOptions)) *MockDBClient_Push_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []ItemOptions
+		var variadicArgs []ItemOptions
+		if len(args) > 2 {
+			variadicArgs = args[2].([]ItemOptions)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Push_Call) Return(item *Item, err error) *MockDBClient_Push_Call {
+	_c.Call.Return(item, err)
+	return _c
+}
+
+func (_c *MockDBClient_Push_Call) RunAndReturn(run func(key string, value string, opts ...ItemOptions) (*Item, error)) *MockDBClient_Push_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Remove provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Remove(key string) error {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Remove")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
+type MockDBClient_Remove_Call struct {
+	*mock.Call
+}
+
+// Remove is a helper method to define mock.On call
+//   - key string
+func (_e *MockDBClient_Expecter) Remove(key interface{}) *MockDBClient_Remove_Call {
+	return &MockDBClient_Remove_Call{Call: _e.mock.On("Remove", key)}
+}
+
+func (_c *MockDBClient_Remove_Call) Run(run func(key string)) *MockDBClient_Remove_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Remove_Call) Return(err error) *MockDBClient_Remove_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_Remove_Call) RunAndReturn(run func(key string) error) *MockDBClient_Remove_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveAPIKey provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) RemoveAPIKey(id string) error {
+	ret := _mock.Called(id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveAPIKey")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
+		r0 = returnFunc(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_RemoveAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveAPIKey'
+type MockDBClient_RemoveAPIKey_Call struct {
+	*mock.Call
+}
+
+// RemoveAPIKey is a helper method to define mock.On call
+//   - id string
+func (_e *MockDBClient_Expecter) RemoveAPIKey(id interface{}) *MockDBClient_RemoveAPIKey_Call {
+	return &MockDBClient_RemoveAPIKey_Call{Call: _e.mock.On("RemoveAPIKey", id)}
+}
+
+func (_c *MockDBClient_RemoveAPIKey_Call) Run(run func(id string)) *MockDBClient_RemoveAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_RemoveAPIKey_Call) Return(err error) *MockDBClient_RemoveAPIKey_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_RemoveAPIKey_Call) RunAndReturn(run func(id string) error) *MockDBClient_RemoveAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReplicationFeed provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) ReplicationFeed(offset uint64) (<-chan ReplicatedOperation, func(), error) {
+	ret := _mock.Called(offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReplicationFeed")
+	}
+
+	var r0 <-chan ReplicatedOperation
+	var r1 func()
+	var r2 error
+	if returnFunc, ok := ret.Get(0).(func(uint64) (<-chan ReplicatedOperation, func(), error)); ok {
+		return returnFunc(offset)
+	}
+	if returnFunc, ok := ret.Get(0).(func(uint64) <-chan ReplicatedOperation); ok {
+		r0 = returnFunc(offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan ReplicatedOperation)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(uint64) func()); ok {
+		r1 = returnFunc(offset)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+	if returnFunc, ok := ret.Get(2).(func(uint64) error); ok {
+		r2 = returnFunc(offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+	return r0, r1, r2
+}
+
+// MockDBClient_ReplicationFeed_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReplicationFeed'
+type MockDBClient_ReplicationFeed_Call struct {
+	*mock.Call
+}
+
+// ReplicationFeed is a helper method to define mock.On call
+//   - offset uint64
+func (_e *MockDBClient_Expecter) ReplicationFeed(offset interface{}) *MockDBClient_ReplicationFeed_Call {
+	return &MockDBClient_ReplicationFeed_Call{Call: _e.mock.On("ReplicationFeed", offset)}
+}
+
+func (_c *MockDBClient_ReplicationFeed_Call) Run(run func(offset uint64)) *MockDBClient_ReplicationFeed_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 uint64
+		if args[0] != nil {
+			arg0 = args[0].(uint64)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_ReplicationFeed_Call) Return(replicatedOperationCh <-chan ReplicatedOperation, fn func(), err error) *MockDBClient_ReplicationFeed_Call {
+	_c.Call.Return(replicatedOperationCh, fn, err)
+	return _c
+}
+
+func (_c *MockDBClient_ReplicationFeed_Call) RunAndReturn(run func(offset uint64) (<-chan ReplicatedOperation, func(), error)) *MockDBClient_ReplicationFeed_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Restore provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Restore(key string, data []byte) error {
+	ret := _mock.Called(key, data)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Restore")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, []byte) error); ok {
+		r0 = returnFunc(key, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_Restore_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Restore'
+type MockDBClient_Restore_Call struct {
+	*mock.Call
+}
+
+// Restore is a helper method to define mock.On call
+//   - key string
+//   - data []byte
+func (_e *MockDBClient_Expecter) Restore(key interface{}, data interface{}) *MockDBClient_Restore_Call {
+	return &MockDBClient_Restore_Call{Call: _e.mock.On("Restore", key, data)}
+}
+
+func (_c *MockDBClient_Restore_Call) Run(run func(key string, data []byte)) *MockDBClient_Restore_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 []byte
+		if args[1] != nil {
+			arg1 = args[1].([]byte)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Restore_Call) Return(err error) *MockDBClient_Restore_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_Restore_Call) RunAndReturn(run func(key string, data []byte) error) *MockDBClient_Restore_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RestoreBackup provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) RestoreBackup(r io.Reader, mode enums.RestoreMode) (int, error) {
+	ret := _mock.Called(r, mode)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestoreBackup")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(io.Reader, enums.RestoreMode) (int, error)); ok {
+		return returnFunc(r, mode)
+	}
+	if returnFunc, ok := ret.Get(0).(func(io.Reader, enums.RestoreMode) int); ok {
+		r0 = returnFunc(r, mode)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(io.Reader, enums.RestoreMode) error); ok {
+		r1 = returnFunc(r, mode)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_RestoreBackup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestoreBackup'
+type MockDBClient_RestoreBackup_Call struct {
+	*mock.Call
+}
+
+// RestoreBackup is a helper method to define mock.On call
+//   - r io.Reader
+//   - mode enums.RestoreMode
+func (_e *MockDBClient_Expecter) RestoreBackup(r interface{}, mode interface{}) *MockDBClient_RestoreBackup_Call {
+	return &MockDBClient_RestoreBackup_Call{Call: _e.mock.On("RestoreBackup", r, mode)}
+}
+
+func (_c *MockDBClient_RestoreBackup_Call) Run(run func(r io.Reader, mode enums.RestoreMode)) *MockDBClient_RestoreBackup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 io.Reader
+		if args[0] != nil {
+			arg0 = args[0].(io.Reader)
+		}
+		var arg1 enums.RestoreMode
+		if args[1] != nil {
+			arg1 = args[1].(enums.RestoreMode)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_RestoreBackup_Call) Return(n int, err error) *MockDBClient_RestoreBackup_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockDBClient_RestoreBackup_Call) RunAndReturn(run func(r io.Reader, mode enums.RestoreMode) (int, error)) *MockDBClient_RestoreBackup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Set provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Set(key string, value any, opts ...ItemOptions) error {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(key, value, opts)
+	} else {
+		tmpRet = _mock.Called(key, value)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for Set")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, any, ...ItemOptions) error); ok {
+		r0 = returnFunc(key, value, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
+type MockDBClient_Set_Call struct {
+	*mock.Call
+}
+
+// Set is a helper method to define mock.On call
+//   - key string
+//   - value any
+//   - opts ...ItemOptions
+func (_e *MockDBClient_Expecter) Set(key interface{}, value interface{}, opts ...interface{}) *MockDBClient_Set_Call {
+	return &MockDBClient_Set_Call{Call: _e.mock.On("Set",
+		append([]interface{}{key, value}, opts...)...)}
+}
+
+func (_c *MockDBClient_Set_Call) Run(run func(key string, value any, opts ...ItemOptions)) *MockDBClient_Set_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 any
+		if args[1] != nil {
+			arg1 = args[1].(any)
+		}
+		var arg2 []ItemOptions
+		var variadicArgs []ItemOptions
+		if len(args) > 2 {
+			variadicArgs = args[2].([]ItemOptions)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Set_Call) Return(err error) *MockDBClient_Set_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_Set_Call) RunAndReturn(run func(key string, value any, opts ...ItemOptions) error) *MockDBClient_Set_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetAPIKey provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) SetAPIKey(key *APIKeyItem) error {
+	ret := _mock.Called(key)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetAPIKey")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*APIKeyItem) error); ok {
+		r0 = returnFunc(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_SetAPIKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetAPIKey'
+type MockDBClient_SetAPIKey_Call struct {
+	*mock.Call
+}
+
+// SetAPIKey is a helper method to define mock.On call
+//   - key *APIKeyItem
+func (_e *MockDBClient_Expecter) SetAPIKey(key interface{}) *MockDBClient_SetAPIKey_Call {
+	return &MockDBClient_SetAPIKey_Call{Call: _e.mock.On("SetAPIKey", key)}
+}
+
+func (_c *MockDBClient_SetAPIKey_Call) Run(run func(key *APIKeyItem)) *MockDBClient_SetAPIKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *APIKeyItem
+		if args[0] != nil {
+			arg0 = args[0].(*APIKeyItem)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_SetAPIKey_Call) Return(err error) *MockDBClient_SetAPIKey_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_SetAPIKey_Call) RunAndReturn(run func(key *APIKeyItem) error) *MockDBClient_SetAPIKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetMany provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) SetMany(items map[string]any, opts ...ItemOptions) error {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(items, opts)
+	} else {
+		tmpRet = _mock.Called(items)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetMany")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(map[string]any, ...ItemOptions) error); ok {
+		r0 = returnFunc(items, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_SetMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetMany'
+type MockDBClient_SetMany_Call struct {
+	*mock.Call
+}
+
+// SetMany is a helper method to define mock.On call
+//   - items map[string]any
+//   - opts ...ItemOptions
+func (_e *MockDBClient_Expecter) SetMany(items interface{}, opts ...interface{}) *MockDBClient_SetMany_Call {
+	return &MockDBClient_SetMany_Call{Call: _e.mock.On("SetMany",
+		append([]interface{}{items}, opts...)...)}
+}
+
+func (_c *MockDBClient_SetMany_Call) Run(run func(items map[string]any, opts ...ItemOptions)) *MockDBClient_SetMany_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 map[string]any
+		if args[0] != nil {
+			arg0 = args[0].(map[string]any)
+		}
+		var arg1 []ItemOptions
+		var variadicArgs []ItemOptions
+		if len(args) > 1 {
+			variadicArgs = args[1].([]ItemOptions)
+		}
+		arg1 = variadicArgs
+		run(
+			arg0,
+			arg1...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_SetMany_Call) Return(err error) *MockDBClient_SetMany_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_SetMany_Call) RunAndReturn(run func(items map[string]any, opts ...ItemOptions) error) *MockDBClient_SetMany_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetReadOnly provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) SetReadOnly(readOnly bool) {
+	_mock.Called(readOnly)
+	return
+}
+
+// MockDBClient_SetReadOnly_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetReadOnly'
+type MockDBClient_SetReadOnly_Call struct {
+	*mock.Call
+}
+
+// SetReadOnly is a helper method to define mock.On call
+//   - readOnly bool
+func (_e *MockDBClient_Expecter) SetReadOnly(readOnly interface{}) *MockDBClient_SetReadOnly_Call {
+	return &MockDBClient_SetReadOnly_Call{Call: _e.mock.On("SetReadOnly", readOnly)}
+}
+
+func (_c *MockDBClient_SetReadOnly_Call) Run(run func(readOnly bool)) *MockDBClient_SetReadOnly_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 bool
+		if args[0] != nil {
+			arg0 = args[0].(bool)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_SetReadOnly_Call) Return() *MockDBClient_SetReadOnly_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockDBClient_SetReadOnly_Call) RunAndReturn(run func(readOnly bool)) *MockDBClient_SetReadOnly_Call {
+	_c.Run(run)
+	return _c
+}
+
+// SetUser provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) SetUser(user *AuthItem) error {
+	ret := _mock.Called(user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetUser")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(*AuthItem) error); ok {
+		r0 = returnFunc(user)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_SetUser_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetUser'
+type MockDBClient_SetUser_Call struct {
+	*mock.Call
+}
+
+// SetUser is a helper method to define mock.On call
+//   - user *AuthItem
+func (_e *MockDBClient_Expecter) SetUser(user interface{}) *MockDBClient_SetUser_Call {
+	return &MockDBClient_SetUser_Call{Call: _e.mock.On("SetUser", user)}
+}
+
+func (_c *MockDBClient_SetUser_Call) Run(run func(user *AuthItem)) *MockDBClient_SetUser_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 *AuthItem
+		if args[0] != nil {
+			arg0 = args[0].(*AuthItem)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_SetUser_Call) Return(err error) *MockDBClient_SetUser_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_SetUser_Call) RunAndReturn(run func(user *AuthItem) error) *MockDBClient_SetUser_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Stats provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Stats() Stats {
+	ret := _mock.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Stats")
+	}
+
+	var r0 Stats
+	if returnFunc, ok := ret.Get(0).(func() Stats); ok {
+		r0 = returnFunc()
+	} else {
+		r0 = ret.Get(0).(Stats)
+	}
+	return r0
+}
+
+// MockDBClient_Stats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Stats'
+type MockDBClient_Stats_Call struct {
+	*mock.Call
+}
+
+// Stats is a helper method to define mock.On call
+func (_e *MockDBClient_Expecter) Stats() *MockDBClient_Stats_Call {
+	return &MockDBClient_Stats_Call{Call: _e.mock.On("Stats")}
+}
+
+func (_c *MockDBClient_Stats_Call) Run(run func()) *MockDBClient_Stats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockDBClient_Stats_Call) Return(stats Stats) *MockDBClient_Stats_Call {
+	_c.Call.Return(stats)
+	return _c
+}
+
+func (_c *MockDBClient_Stats_Call) RunAndReturn(run func() Stats) *MockDBClient_Stats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamAck provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamAck(key string, group string, ids ...string) (int, error) {
+	var tmpRet mock.Arguments
+	if len(ids) > 0 {
+		tmpRet = _mock.Called(key, group, ids)
+	} else {
+		tmpRet = _mock.Called(key, group)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamAck")
+	}
+
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, ...string) (int, error)); ok {
+		return returnFunc(key, group, ids...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, ...string) int); ok {
+		r0 = returnFunc(key, group, ids...)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string, ...string) error); ok {
+		r1 = returnFunc(key, group, ids...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_StreamAck_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamAck'
+type MockDBClient_StreamAck_Call struct {
+	*mock.Call
+}
+
+// StreamAck is a helper method to define mock.On call
+//   - key string
+//   - group string
+//   - ids ...string
+func (_e *MockDBClient_Expecter) StreamAck(key interface{}, group interface{}, ids ...interface{}) *MockDBClient_StreamAck_Call {
+	return &MockDBClient_StreamAck_Call{Call: _e.mock.On("StreamAck",
+		append([]interface{}{key, group}, ids...)...)}
+}
+
+func (_c *MockDBClient_StreamAck_Call) Run(run func(key string, group string, ids ...string)) *MockDBClient_StreamAck_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []string
+		var variadicArgs []string
+		if len(args) > 2 {
+			variadicArgs = args[2].([]string)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_StreamAck_Call) Return(n int, err error) *MockDBClient_StreamAck_Call {
+	_c.Call.Return(n, err)
+	return _c
+}
+
+func (_c *MockDBClient_StreamAck_Call) RunAndReturn(run func(key string, group string, ids ...string) (int, error)) *MockDBClient_StreamAck_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamAdd provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamAdd(key string, fields map[string]string, opts ...ItemOptions) (StreamID, error) {
+	var tmpRet mock.Arguments
+	if len(opts) > 0 {
+		tmpRet = _mock.Called(key, fields, opts)
+	} else {
+		tmpRet = _mock.Called(key, fields)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamAdd")
+	}
+
+	var r0 StreamID
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, map[string]string, ...ItemOptions) (StreamID, error)); ok {
+		return returnFunc(key, fields, opts...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, map[string]string, ...ItemOptions) StreamID); ok {
+		r0 = returnFunc(key, fields, opts...)
+	} else {
+		r0 = ret.Get(0).(StreamID)
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, map[string]string, ...ItemOptions) error); ok {
+		r1 = returnFunc(key, fields, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_StreamAdd_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamAdd'
+type MockDBClient_StreamAdd_Call struct {
+	*mock.Call
+}
+
+// StreamAdd is a helper method to define mock.On call
+//   - key string
+//   - fields map[string]string
+//   - opts ...ItemOptions
+func (_e *MockDBClient_Expecter) StreamAdd(key interface{}, fields interface{}, opts ...interface{}) *MockDBClient_StreamAdd_Call {
+	return &MockDBClient_StreamAdd_Call{Call: _e.mock.On("StreamAdd",
+		append([]interface{}{key, fields}, opts...)...)}
+}
+
+func (_c *MockDBClient_StreamAdd_Call) Run(run func(key string, fields map[string]string, opts ...ItemOptions)) *MockDBClient_StreamAdd_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 map[string]string
+		if args[1] != nil {
+			arg1 = args[1].(map[string]string)
+		}
+		var arg2 []ItemOptions
+		var variadicArgs []ItemOptions
+		if len(args) > 2 {
+			variadicArgs = args[2].([]ItemOptions)
+		}
+		arg2 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_StreamAdd_Call) Return(streamID StreamID, err error) *MockDBClient_StreamAdd_Call {
+	_c.Call.Return(streamID, err)
+	return _c
+}
+
+func (_c *MockDBClient_StreamAdd_Call) RunAndReturn(run func(key string, fields map[string]string, opts ...ItemOptions) (StreamID, error)) *MockDBClient_StreamAdd_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamClaim provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error) {
+	var tmpRet mock.Arguments
+	if len(ids) > 0 {
+		tmpRet = _mock.Called(key, group, consumer, minIdle, ids)
+	} else {
+		tmpRet = _mock.Called(key, group, consumer, minIdle)
+	}
+	ret := tmpRet
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamClaim")
+	}
+
+	var r0 []StreamEntry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, string, time.Duration, ...string) ([]StreamEntry, error)); ok {
+		return returnFunc(key, group, consumer, minIdle, ids...)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, string, time.Duration, ...string) []StreamEntry); ok {
+		r0 = returnFunc(key, group, consumer, minIdle, ids...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]StreamEntry)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string, string, time.Duration, ...string) error); ok {
+		r1 = returnFunc(key, group, consumer, minIdle, ids...)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_StreamClaim_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamClaim'
+type MockDBClient_StreamClaim_Call struct {
+	*mock.Call
+}
+
+// StreamClaim is a helper method to define mock.On call
+//   - key string
+//   - group string
+//   - consumer string
+//   - minIdle time.Duration
+//   - ids ...string
+func (_e *MockDBClient_Expecter) StreamClaim(key interface{}, group interface{}, consumer interface{}, minIdle interface{}, ids ...interface{}) *MockDBClient_StreamClaim_Call {
+	return &MockDBClient_StreamClaim_Call{Call: _e.mock.On("StreamClaim",
+		append([]interface{}{key, group, consumer, minIdle}, ids...)...)}
+}
+
+func (_c *MockDBClient_StreamClaim_Call) Run(run func(key string, group string, consumer string, minIdle time.Duration, ids ...string)) *MockDBClient_StreamClaim_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 time.Duration
+		if args[3] != nil {
+			arg3 = args[3].(time.Duration)
+		}
+		var arg4 []string
+		var variadicArgs []string
+		if len(args) > 4 {
+			variadicArgs = args[4].([]string)
+		}
+		arg4 = variadicArgs
+		run(
+			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4...,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_StreamClaim_Call) Return(streamEntrys []StreamEntry, err error) *MockDBClient_StreamClaim_Call {
+	_c.Call.Return(streamEntrys, err)
+	return _c
+}
+
+func (_c *MockDBClient_StreamClaim_Call) RunAndReturn(run func(key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error)) *MockDBClient_StreamClaim_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamGroupCreate provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamGroupCreate(key string, group string, start string) error {
+	ret := _mock.Called(key, group, start)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamGroupCreate")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = returnFunc(key, group, start)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockDBClient_StreamGroupCreate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamGroupCreate'
+type MockDBClient_StreamGroupCreate_Call struct {
+	*mock.Call
+}
+
+// StreamGroupCreate is a helper method to define mock.On call
+//   - key string
+//   - group string
+//   - start string
+func (_e *MockDBClient_Expecter) StreamGroupCreate(key interface{}, group interface{}, start interface{}) *MockDBClient_StreamGroupCreate_Call {
+	return &MockDBClient_StreamGroupCreate_Call{Call: _e.mock.On("StreamGroupCreate", key, group, start)}
+}
+
+func (_c *MockDBClient_StreamGroupCreate_Call) Run(run func(key string, group string, start string)) *MockDBClient_StreamGroupCreate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_StreamGroupCreate_Call) Return(err error) *MockDBClient_StreamGroupCreate_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockDBClient_StreamGroupCreate_Call) RunAndReturn(run func(key string, group string, start string) error) *MockDBClient_StreamGroupCreate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// StreamPending provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamPending(key string, group string, consumer string) ([]PendingEntry, error) {
+	ret := _mock.Called(key, group, consumer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for StreamPending")
+	}
+
+	var r0 []PendingEntry
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, string, string) ([]PendingEntry, error)); ok {
+		return returnFunc(key, group, consumer)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, string, string) []PendingEntry); ok {
+		r0 = returnFunc(key, group, consumer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]PendingEntry)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = returnFunc(key, group, consumer)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_StreamPending_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamPending'
+type MockDBClient_StreamPending_Call struct {
+	*mock.Call
+}
+
+// StreamPending is a helper method to define mock.On call
+//   - key string
+//   - group string
+//   - consumer string
+func (_e *MockDBClient_Expecter) StreamPending(key interface{}, group interface{}, consumer interface{}) *MockDBClient_StreamPending_Call {
+	return &MockDBClient_StreamPending_Call{Call: _e.mock.On("StreamPending", key, group, consumer)}
+}
+
+func (_c *MockDBClient_StreamPending_Call) Run(run func(key string, group string, consumer string)) *MockDBClient_StreamPending_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_StreamPending_Call) Return(pendingEntrys []PendingEntry, err error) *MockDBClient_StreamPending_Call {
+	_c.Call.Return(pendingEntrys, err)
 	return _c
 }
 
-func (_c *MockDBClient_Close_Call) RunAndReturn(run func()) *MockDBClient_Close_Call {
-	_c.Run(run)
+func (_c *MockDBClient_StreamPending_Call) RunAndReturn(run func(key string, group string, consumer string) ([]PendingEntry, error)) *MockDBClient_StreamPending_Call {
+	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function for the type MockDBClient
-func (_mock *MockDBClient) Get(key string) (*Item, error) {
-	ret := _mock.Called(key)
+// StreamRange provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamRange(key string, start string, end string, count int) ([]StreamEntry, error) {
+	ret := _mock.Called(key, start, end, count)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for StreamRange")
 	}
 
-	var r0 *Item
+	var r0 []StreamEntry
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (*Item, error)); ok {
-		return returnFunc(key)
+	if returnFunc, ok := ret.Get(0).(func(string, string, string, int) ([]StreamEntry, error)); ok {
+		return returnFunc(key, start, end, count)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) *Item); ok {
-		r0 = returnFunc(key)
+	if returnFunc, ok := ret.Get(0).(func(string, string, string, int) []StreamEntry); ok {
+		r0 = returnFunc(key, start, end, count)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*Item)
+			r0 = ret.Get(0).([]StreamEntry)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(key)
+	if returnFunc, ok := ret.Get(1).(func(string, string, string, int) error); ok {
+		r1 = returnFunc(key, start, end, count)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockDBClient_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockDBClient_Get_Call struct {
+// MockDBClient_StreamRange_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamRange'
+type MockDBClient_StreamRange_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// StreamRange is a helper method to define mock.On call
 //   - key string
-func (_e *MockDBClient_Expecter) Get(key interface{}) *MockDBClient_Get_Call {
-	return &MockDBClient_Get_Call{Call: _e.mock.On("Get", key)}
+//   - start string
+//   - end string
+//   - count int
+func (_e *MockDBClient_Expecter) StreamRange(key interface{}, start interface{}, end interface{}, count interface{}) *MockDBClient_StreamRange_Call {
+	return &MockDBClient_StreamRange_Call{Call: _e.mock.On("StreamRange", key, start, end, count)}
 }
 
-func (_c *MockDBClient_Get_Call) Run(run func(key string)) *MockDBClient_Get_Call {
+func (_c *MockDBClient_StreamRange_Call) Run(run func(key string, start string, end string, count int)) *MockDBClient_StreamRange_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
 			arg0 = args[0].(string)
 		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
 		run(
 			arg0,
+			arg1,
+			arg2,
+			arg3,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBClient_Get_Call) Return(item *Item, err error) *MockDBClient_Get_Call {
-	_c.Call.Return(item, err)
+func (_c *MockDBClient_StreamRange_Call) Return(streamEntrys []StreamEntry, err error) *MockDBClient_StreamRange_Call {
+	_c.Call.Return(streamEntrys, err)
 	return _c
 }
 
-func (_c *MockDBClient_Get_Call) RunAndReturn(run func(key string) (*Item, error)) *MockDBClient_Get_Call {
+func (_c *MockDBClient_StreamRange_Call) RunAndReturn(run func(key string, start string, end string, count int) ([]StreamEntry, error)) *MockDBClient_StreamRange_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Pop provides a mock function for the type MockDBClient
-func (_mock *MockDBClient) Pop(key string) (*Item, error) {
-	ret := _mock.Called(key)
+// StreamRead provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) ([]StreamEntry, error) {
+	ret := _mock.Called(ctx, key, after, count, block)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Pop")
+		panic("no return value specified for StreamRead")
 	}
 
-	var r0 *Item
+	var r0 []StreamEntry
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string) (*Item, error)); ok {
-		return returnFunc(key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int, time.Duration) ([]StreamEntry, error)); ok {
+		return returnFunc(ctx, key, after, count, block)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string) *Item); ok {
-		r0 = returnFunc(key)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, int, time.Duration) []StreamEntry); ok {
+		r0 = returnFunc(ctx, key, after, count, block)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*Item)
+			r0 = ret.Get(0).([]StreamEntry)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string) error); ok {
-		r1 = returnFunc(key)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, int, time.Duration) error); ok {
+		r1 = returnFunc(ctx, key, after, count, block)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockDBClient_Pop_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Pop'
-type MockDBClient_Pop_Call struct {
+// MockDBClient_StreamRead_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamRead'
+type MockDBClient_StreamRead_Call struct {
 	*mock.Call
 }
 
-// Pop is a helper method to define mock.On call
+// StreamRead is a helper method to define mock.On call
+//   - ctx context.Context
 //   - key string
-func (_e *MockDBClient_Expecter) Pop(key interface{}) *MockDBClient_Pop_Call {
-	return &MockDBClient_Pop_Call{Call: _e.mock.On("Pop", key)}
+//   - after string
+//   - count int
+//   - block time.Duration
+func (_e *MockDBClient_Expecter) StreamRead(ctx interface{}, key interface{}, after interface{}, count interface{}, block interface{}) *MockDBClient_StreamRead_Call {
+	return &MockDBClient_StreamRead_Call{Call: _e.mock.On("StreamRead", ctx, key, after, count, block)}
 }
 
-func (_c *MockDBClient_Pop_Call) Run(run func(key string)) *MockDBClient_Pop_Call {
+func (_c *MockDBClient_StreamRead_Call) Run(run func(ctx context.Context, key string, after string, count int, block time.Duration)) *MockDBClient_StreamRead_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 int
+		if args[3] != nil {
+			arg3 = args[3].(int)
+		}
+		var arg4 time.Duration
+		if args[4] != nil {
+			arg4 = args[4].(time.Duration)
 		}
 		run(
 			arg0,
+			arg1,
+			arg2,
+			arg3,
+			arg4,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBClient_Pop_Call) Return(item *Item, err error) *MockDBClient_Pop_Call {
-	_c.Call.Return(item, err)
+func (_c *MockDBClient_StreamRead_Call) Return(streamEntrys []StreamEntry, err error) *MockDBClient_StreamRead_Call {
+	_c.Call.Return(streamEntrys, err)
 	return _c
 }
 
-func (_c *MockDBClient_Pop_Call) RunAndReturn(run func(key string) (*Item, error)) *MockDBClient_Pop_Call {
+func (_c *MockDBClient_StreamRead_Call) RunAndReturn(run func(ctx context.Context, key string, after string, count int, block time.Duration) ([]StreamEntry, error)) *MockDBClient_StreamRead_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Push provides a mock function for the type MockDBClient
-func (_mock *MockDBClient) Push(key string, value string, opts ...ItemOptions) (*Item, error) {
-	var tmpRet mock.Arguments
-	if len(opts) > 0 {
-		tmpRet = _mock.Called(key, value, opts)
-	} else {
-		tmpRet = _mock.Called(key, value)
-	}
-	ret := tmpRet
+// StreamReadGroup provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamEntry, error) {
+	ret := _mock.Called(ctx, key, group, consumer, count, block)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Push")
+		panic("no return value specified for StreamReadGroup")
 	}
 
-	var r0 *Item
+	var r0 []StreamEntry
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string, string, ...ItemOptions) (*Item, error)); ok {
-		return returnFunc(key, value, opts...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, time.Duration) ([]StreamEntry, error)); ok {
+		return returnFunc(ctx, key, group, consumer, count, block)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string, string, ...ItemOptions) *Item); ok {
-		r0 = returnFunc(key, value, opts...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, string, int, time.Duration) []StreamEntry); ok {
+		r0 = returnFunc(ctx, key, group, consumer, count, block)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*Item)
+			r0 = ret.Get(0).([]StreamEntry)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string, string, ...ItemOptions) error); ok {
-		r1 = returnFunc(key, value, opts...)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, string, int, time.Duration) error); ok {
+		r1 = returnFunc(ctx, key, group, consumer, count, block)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockDBClient_Push_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Push'
-type MockDBClient_Push_Call struct {
+// MockDBClient_StreamReadGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamReadGroup'
+type MockDBClient_StreamReadGroup_Call struct {
 	*mock.Call
 }
 
-// Push is a helper method to define mock.On call
+// StreamReadGroup is a helper method to define mock.On call
+//   - ctx context.Context
 //   - key string
-//   - value string
-//   - opts ...ItemOptions
-func (_e *MockDBClient_Expecter) Push(key interface{}, value interface{}, opts ...interface{}) *MockDBClient_Push_Call {
-	return &MockDBClient_Push_Call{Call: _e.mock.On("Push",
-		append([]interface{}{key, value}, opts...)...)}
+//   - group string
+//   - consumer string
+//   - count int
+//   - block time.Duration
+func (_e *MockDBClient_Expecter) StreamReadGroup(ctx interface{}, key interface{}, group interface{}, consumer interface{}, count interface{}, block interface{}) *MockDBClient_StreamReadGroup_Call {
+	return &MockDBClient_StreamReadGroup_Call{Call: _e.mock.On("StreamReadGroup", ctx, key, group, consumer, count, block)}
 }
 
-func (_c *MockDBClient_Push_Call) Run(run func(key string, value string, opts ...ItemOptions)) *MockDBClient_Push_Call {
+func (_c *MockDBClient_StreamReadGroup_Call) Run(run func(ctx context.Context, key string, group string, consumer string, count int, block time.Duration)) *MockDBClient_StreamReadGroup_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
 		var arg1 string
 		if args[1] != nil {
 			arg1 = args[1].(string)
 		}
-		var arg2 []ItemOptions
-		var variadicArgs []ItemOptions
-		if len(args) > 2 {
-			variadicArgs = args[2].([]ItemOptions)
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 string
+		if args[3] != nil {
+			arg3 = args[3].(string)
+		}
+		var arg4 int
+		if args[4] != nil {
+			arg4 = args[4].(int)
+		}
+		var arg5 time.Duration
+		if args[5] != nil {
+			arg5 = args[5].(time.Duration)
 		}
-		arg2 = variadicArgs
 		run(
 			arg0,
 			arg1,
-			arg2...,
+			arg2,
+			arg3,
+			arg4,
+			arg5,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBClient_Push_Call) Return(item *Item, err error) *MockDBClient_Push_Call {
-	_c.Call.Return(item, err)
+func (_c *MockDBClient_StreamReadGroup_Call) Return(streamEntrys []StreamEntry, err error) *MockDBClient_StreamReadGroup_Call {
+	_c.Call.Return(streamEntrys, err)
 	return _c
 }
 
-func (_c *MockDBClient_Push_Call) RunAndReturn(run func(key string, value string, opts ...ItemOptions) (*Item, error)) *MockDBClient_Push_Call {
+func (_c *MockDBClient_StreamReadGroup_Call) RunAndReturn(run func(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamEntry, error)) *MockDBClient_StreamReadGroup_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Remove provides a mock function for the type MockDBClient
-func (_mock *MockDBClient) Remove(key string) error {
-	ret := _mock.Called(key)
+// StreamTrim provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) StreamTrim(key string, maxLen int, maxAge time.Duration) (int, error) {
+	ret := _mock.Called(key, maxLen, maxAge)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Remove")
+		panic("no return value specified for StreamTrim")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(string) error); ok {
-		r0 = returnFunc(key)
+	var r0 int
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(string, int, time.Duration) (int, error)); ok {
+		return returnFunc(key, maxLen, maxAge)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, int, time.Duration) int); ok {
+		r0 = returnFunc(key, maxLen, maxAge)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(int)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string, int, time.Duration) error); ok {
+		r1 = returnFunc(key, maxLen, maxAge)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockDBClient_Remove_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Remove'
-type MockDBClient_Remove_Call struct {
+// MockDBClient_StreamTrim_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'StreamTrim'
+type MockDBClient_StreamTrim_Call struct {
 	*mock.Call
 }
 
-// Remove is a helper method to define mock.On call
+// StreamTrim is a helper method to define mock.On call
 //   - key string
-func (_e *MockDBClient_Expecter) Remove(key interface{}) *MockDBClient_Remove_Call {
-	return &MockDBClient_Remove_Call{Call: _e.mock.On("Remove", key)}
+//   - maxLen int
+//   - maxAge time.Duration
+func (_e *MockDBClient_Expecter) StreamTrim(key interface{}, maxLen interface{}, maxAge interface{}) *MockDBClient_StreamTrim_Call {
+	return &MockDBClient_StreamTrim_Call{Call: _e.mock.On("StreamTrim", key, maxLen, maxAge)}
 }
 
-func (_c *MockDBClient_Remove_Call) Run(run func(key string)) *MockDBClient_Remove_Call {
+func (_c *MockDBClient_StreamTrim_Call) Run(run func(key string, maxLen int, maxAge time.Duration)) *MockDBClient_StreamTrim_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
 			arg0 = args[0].(string)
 		}
+		var arg1 int
+		if args[1] != nil {
+			arg1 = args[1].(int)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
+		}
 		run(
 			arg0,
+			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBClient_Remove_Call) Return(err error) *MockDBClient_Remove_Call {
-	_c.Call.Return(err)
+func (_c *MockDBClient_StreamTrim_Call) Return(n int, err error) *MockDBClient_StreamTrim_Call {
+	_c.Call.Return(n, err)
 	return _c
 }
 
-func (_c *MockDBClient_Remove_Call) RunAndReturn(run func(key string) error) *MockDBClient_Remove_Call {
+func (_c *MockDBClient_StreamTrim_Call) RunAndReturn(run func(key string, maxLen int, maxAge time.Duration) (int, error)) *MockDBClient_StreamTrim_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Set provides a mock function for the type MockDBClient
-func (_mock *MockDBClient) Set(key string, value any, opts ...ItemOptions) error {
-	var tmpRet mock.Arguments
-	if len(opts) > 0 {
-		tmpRet = _mock.Called(key, value, opts)
-	} else {
-		tmpRet = _mock.Called(key, value)
-	}
-	ret := tmpRet
+// Subscribe provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) Subscribe(pattern string, lastEventID uint64) (<-chan Event, func()) {
+	ret := _mock.Called(pattern, lastEventID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Set")
+		panic("no return value specified for Subscribe")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(string, any, ...ItemOptions) error); ok {
-		r0 = returnFunc(key, value, opts...)
+	var r0 <-chan Event
+	var r1 func()
+	if returnFunc, ok := ret.Get(0).(func(string, uint64) (<-chan Event, func())); ok {
+		return returnFunc(pattern, lastEventID)
+	}
+	if returnFunc, ok := ret.Get(0).(func(string, uint64) <-chan Event); ok {
+		r0 = returnFunc(pattern, lastEventID)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan Event)
+		}
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(string, uint64) func()); ok {
+		r1 = returnFunc(pattern, lastEventID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+	return r0, r1
 }
 
-// MockDBClient_Set_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Set'
-type MockDBClient_Set_Call struct {
+// MockDBClient_Subscribe_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Subscribe'
+type MockDBClient_Subscribe_Call struct {
 	*mock.Call
 }
 
-// Set is a helper method to define mock.On call
-//   - key string
-//   - value any
-//   - opts ...ItemOptions
-func (_e *MockDBClient_Expecter) Set(key interface{}, value interface{}, opts ...interface{}) *MockDBClient_Set_Call {
-	return &MockDBClient_Set_Call{Call: _e.mock.On("Set",
-		append([]interface{}{key, value}, opts...)...)}
+// Subscribe is a helper method to define mock.On call
+//   - pattern string
+//   - lastEventID uint64
+func (_e *MockDBClient_Expecter) Subscribe(pattern interface{}, lastEventID interface{}) *MockDBClient_Subscribe_Call {
+	return &MockDBClient_Subscribe_Call{Call: _e.mock.On("Subscribe", pattern, lastEventID)}
 }
 
-func (_c *MockDBClient_Set_Call) Run(run func(key string, value any, opts ...ItemOptions)) *MockDBClient_Set_Call {
+func (_c *MockDBClient_Subscribe_Call) Run(run func(pattern string, lastEventID uint64)) *MockDBClient_Subscribe_Call {
 	_c.Call.Run(func(args mock.Arguments) {
 		var arg0 string
 		if args[0] != nil {
 			arg0 = args[0].(string)
 		}
-		var arg1 any
+		var arg1 uint64
 		if args[1] != nil {
-			arg1 = args[1].(any)
-		}
-		var arg2 []ItemOptions
-		var variadicArgs []ItemOptions
-		if len(args) > 2 {
-			variadicArgs = args[2].([]ItemOptions)
+			arg1 = args[1].(uint64)
 		}
-		arg2 = variadicArgs
 		run(
 			arg0,
 			arg1,
-			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBClient_Set_Call) Return(err error) *MockDBClient_Set_Call {
-	_c.Call.Return(err)
+func (_c *MockDBClient_Subscribe_Call) Return(eventCh <-chan Event, fn func()) *MockDBClient_Subscribe_Call {
+	_c.Call.Return(eventCh, fn)
 	return _c
 }
 
-func (_c *MockDBClient_Set_Call) RunAndReturn(run func(key string, value any, opts ...ItemOptions) error) *MockDBClient_Set_Call {
+func (_c *MockDBClient_Subscribe_Call) RunAndReturn(run func(pattern string, lastEventID uint64) (<-chan Event, func())) *MockDBClient_Subscribe_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -469,3 +2489,116 @@ func (_c *MockDBClient_Update_Call) RunAndReturn(run func(key string, value any,
 	_c.Call.Return(run)
 	return _c
 }
+
+// WithContext provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) WithContext(ctx context.Context) DBClient {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithContext")
+	}
+
+	var r0 DBClient
+	if returnFunc, ok := ret.Get(0).(func(context.Context) DBClient); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(DBClient)
+		}
+	}
+	return r0
+}
+
+// MockDBClient_WithContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WithContext'
+type MockDBClient_WithContext_Call struct {
+	*mock.Call
+}
+
+// WithContext is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockDBClient_Expecter) WithContext(ctx interface{}) *MockDBClient_WithContext_Call {
+	return &MockDBClient_WithContext_Call{Call: _e.mock.On("WithContext", ctx)}
+}
+
+func (_c *MockDBClient_WithContext_Call) Run(run func(ctx context.Context)) *MockDBClient_WithContext_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_WithContext_Call) Return(dBClient DBClient) *MockDBClient_WithContext_Call {
+	_c.Call.Return(dBClient)
+	return _c
+}
+
+func (_c *MockDBClient_WithContext_Call) RunAndReturn(run func(ctx context.Context) DBClient) *MockDBClient_WithContext_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// WriteSnapshot provides a mock function for the type MockDBClient
+func (_mock *MockDBClient) WriteSnapshot(w io.Writer) (uint64, error) {
+	ret := _mock.Called(w)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WriteSnapshot")
+	}
+
+	var r0 uint64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(io.Writer) (uint64, error)); ok {
+		return returnFunc(w)
+	}
+	if returnFunc, ok := ret.Get(0).(func(io.Writer) uint64); ok {
+		r0 = returnFunc(w)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(io.Writer) error); ok {
+		r1 = returnFunc(w)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockDBClient_WriteSnapshot_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'WriteSnapshot'
+type MockDBClient_WriteSnapshot_Call struct {
+	*mock.Call
+}
+
+// WriteSnapshot is a helper method to define mock.On call
+//   - w io.Writer
+func (_e *MockDBClient_Expecter) WriteSnapshot(w interface{}) *MockDBClient_WriteSnapshot_Call {
+	return &MockDBClient_WriteSnapshot_Call{Call: _e.mock.On("WriteSnapshot", w)}
+}
+
+func (_c *MockDBClient_WriteSnapshot_Call) Run(run func(w io.Writer)) *MockDBClient_WriteSnapshot_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 io.Writer
+		if args[0] != nil {
+			arg0 = args[0].(io.Writer)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockDBClient_WriteSnapshot_Call) Return(v uint64, err error) *MockDBClient_WriteSnapshot_Call {
+	_c.Call.Return(v, err)
+	return _c
+}
+
+func (_c *MockDBClient_WriteSnapshot_Call) RunAndReturn(run func(w io.Writer) (uint64, error)) *MockDBClient_WriteSnapshot_Call {
+	_c.Call.Return(run)
+	return _c
+}
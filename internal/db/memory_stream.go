@@ -0,0 +1,481 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"memorydb/internal/enums"
+	"time"
+)
+
+// StreamAdd appends an entry with the given fields to the stream stored at the key and returns its ID.
+// The stream is created if the key does not exist, in which case the options are applied to the new item.
+func (db *memoryDB) StreamAdd(key string, fields map[string]string, opts ...ItemOptions) (StreamID, error) {
+	if db.readOnly.Load() {
+		return StreamID{}, ErrReadOnly
+	}
+
+	if len(fields) == 0 {
+		return StreamID{}, fmt.Errorf("failed to add entry to stream %s: %w", key, ErrInvalidDataType)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	now := db.now()
+	item, exists := db.store[key]
+	if exists && item.isExpired(now) {
+		db.expireItem(key)
+		exists = false
+	}
+	if exists && item.Kind != StreamType {
+		return StreamID{}, ErrNotAStream
+	}
+
+	// copy the fields so the caller cannot modify the entry once it is stored
+	entryFields := make(map[string]string, len(fields))
+	for field, value := range fields {
+		entryFields[field] = value
+	}
+
+	delta := StreamEntry{Fields: entryFields}.size()
+	if !exists {
+		delta += entrySize(key, nil)
+	}
+	if err := db.reserveMemory(delta, key); err != nil {
+		return StreamID{}, err
+	}
+
+	op := &Operation{Command: enums.DBCommandStreamAdd, Key: key, Time: now}
+	if !exists {
+		item = newStreamItem(now, db.itemOptions(opts)...)
+		db.storeItem(key, item)
+
+		// the log only needs the properties of the item, the entries are logged one by one
+		op.Item = &Item{Kind: StreamType, TTL: item.TTL, ExplicitTTL: item.ExplicitTTL, CreatedAt: item.CreatedAt, UpdatedAt: item.UpdatedAt}
+	} else {
+		for _, opt := range opts {
+			opt.apply(item)
+		}
+	}
+
+	entry, err := item.Stream.add(item.Stream.nextID(now), entryFields)
+	if err != nil {
+		return StreamID{}, fmt.Errorf("failed to add entry to stream %s: %w", key, err)
+	}
+	item.UpdatedAt = now
+	item.touch(now)
+	db.usedMemory += entry.size()
+
+	op.StreamArgs = &StreamOperation{ID: entry.ID, Fields: entryFields}
+	if op.Item == nil && len(opts) > 0 {
+		op.Item = &Item{TTL: item.TTL, ExplicitTTL: item.ExplicitTTL}
+	}
+	db.logOperation(op)
+
+	db.notifyStream(key)
+	db.events.publish(enums.KeyspaceEventStreamAdd, key)
+	return entry.ID, nil
+}
+
+// StreamRange returns up to count entries of the stream with IDs between start and end, both included.
+// The special IDs "-" and "+" are the first and the last entry of the stream. A count of 0 means no limit.
+func (db *memoryDB) StreamRange(key string, start, end string, count int) ([]StreamEntry, error) {
+	startID, err := ParseStreamID(start, 0)
+	if err != nil {
+		return nil, err
+	}
+	endID, err := ParseStreamID(end, maxStreamID.Seq)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	item.touch(db.now())
+	return item.Stream.rangeEntries(startID, endID, count), nil
+}
+
+// StreamRead returns up to count entries of the stream with IDs greater than after. The special ID "$" means
+// the last entry of the stream when the call is made, so only new entries are returned.
+//
+// If there are no entries and block is positive, it waits until an entry is added, the block time passes or the
+// context is cancelled. A stream that does not exist yet is waited for as if it was empty.
+func (db *memoryDB) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) ([]StreamEntry, error) {
+	var afterID StreamID
+	resolveLast := after == "$"
+	if !resolveLast && after != "" {
+		parsed, err := ParseStreamID(after, 0)
+		if err != nil {
+			return nil, err
+		}
+		afterID = parsed
+	}
+
+	deadline := blockDeadline(block)
+	for {
+		db.mu.Lock()
+		item, err := db.getStream(key)
+		switch {
+		case err == nil:
+			if resolveLast {
+				afterID, resolveLast = item.Stream.LastID, false
+			}
+			item.touch(db.now())
+			if entries := item.Stream.entriesAfter(afterID, count); len(entries) > 0 {
+				db.mu.Unlock()
+				return entries, nil
+			}
+		case err == ErrDataNotFound || err == ErrKeyHasExpired:
+			if block <= 0 {
+				db.mu.Unlock()
+				return nil, err
+			}
+			// the stream is waited for as if it was empty, so every entry added to it is new
+			resolveLast = false
+		default:
+			db.mu.Unlock()
+			return nil, err
+		}
+
+		if block <= 0 {
+			db.mu.Unlock()
+			return []StreamEntry{}, nil
+		}
+		signal := db.streamSignal(key)
+		db.mu.Unlock()
+
+		if err := waitStream(ctx, signal, deadline); err != nil {
+			if err == errBlockTimeout {
+				return []StreamEntry{}, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// StreamTrim removes the oldest entries of the stream so it has at most maxLen entries and no entry older than maxAge.
+// A maxLen or a maxAge of 0 disables the corresponding criterion. It returns the number of removed entries.
+func (db *memoryDB) StreamTrim(key string, maxLen int, maxAge time.Duration) (int, error) {
+	if db.readOnly.Load() {
+		return 0, ErrReadOnly
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return 0, err
+	}
+
+	// the age is resolved to a minimum ID, so replaying the log removes the same entries
+	now := db.now()
+	var minID StreamID
+	if maxAge > 0 {
+		minID = StreamID{Ms: uint64(now.Add(-maxAge).UnixMilli())}
+	}
+
+	removed := item.Stream.trim(maxLen, minID)
+	if len(removed) == 0 {
+		return 0, nil
+	}
+	for _, entry := range removed {
+		db.usedMemory -= entry.size()
+	}
+	item.UpdatedAt = now
+
+	db.logOperation(&Operation{
+		Command:    enums.DBCommandStreamTrim,
+		Key:        key,
+		Time:       now,
+		StreamArgs: &StreamOperation{MaxLen: maxLen, MinID: minID},
+	})
+
+	db.events.publish(enums.KeyspaceEventStreamTrim, key)
+	return len(removed), nil
+}
+
+// StreamGroupCreate creates a consumer group in the stream. The group delivers the entries after start,
+// where "$" means the last entry of the stream, so only new entries are delivered, and "0" means every entry.
+func (db *memoryDB) StreamGroupCreate(key string, group string, start string) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return err
+	}
+
+	lastDeliveredID := item.Stream.LastID
+	if start != "$" && start != "" {
+		if lastDeliveredID, err = ParseStreamID(start, 0); err != nil {
+			return err
+		}
+	}
+
+	if err := item.Stream.createGroup(group, lastDeliveredID); err != nil {
+		return err
+	}
+
+	db.logOperation(&Operation{
+		Command:    enums.DBCommandStreamGroupCreate,
+		Key:        key,
+		Time:       db.now(),
+		StreamArgs: &StreamOperation{ID: lastDeliveredID, Group: group},
+	})
+	return nil
+}
+
+// StreamReadGroup delivers up to count entries that have not been delivered to any consumer of the group yet.
+// The entries are added to the pending list of the consumer until they are acknowledged with StreamAck.
+//
+// If there are no new entries and block is positive, it waits until an entry is added, the block time passes or
+// the context is cancelled.
+func (db *memoryDB) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) ([]StreamEntry, error) {
+	if db.readOnly.Load() {
+		return nil, ErrReadOnly
+	}
+
+	deadline := blockDeadline(block)
+	for {
+		db.mu.Lock()
+		item, err := db.getStream(key)
+		if err != nil {
+			db.mu.Unlock()
+			return nil, err
+		}
+		consumerGroup, err := item.Stream.group(group)
+		if err != nil {
+			db.mu.Unlock()
+			return nil, err
+		}
+
+		now := db.now()
+		item.touch(now)
+		if entries := item.Stream.entriesAfter(consumerGroup.LastDeliveredID, count); len(entries) > 0 {
+			ids := make([]StreamID, len(entries))
+			for i, entry := range entries {
+				ids[i] = entry.ID
+			}
+			item.Stream.deliver(consumerGroup, consumer, ids, now)
+
+			db.logOperation(&Operation{
+				Command:    enums.DBCommandStreamDeliver,
+				Key:        key,
+				Time:       now,
+				StreamArgs: &StreamOperation{IDs: ids, Group: group, Consumer: consumer},
+			})
+			db.mu.Unlock()
+			return entries, nil
+		}
+
+		if block <= 0 {
+			db.mu.Unlock()
+			return []StreamEntry{}, nil
+		}
+		signal := db.streamSignal(key)
+		db.mu.Unlock()
+
+		if err := waitStream(ctx, signal, deadline); err != nil {
+			if err == errBlockTimeout {
+				return []StreamEntry{}, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// StreamAck removes the entries from the pending list of the group and returns how many of them were pending.
+func (db *memoryDB) StreamAck(key string, group string, ids ...string) (int, error) {
+	if db.readOnly.Load() {
+		return 0, ErrReadOnly
+	}
+
+	streamIDs, err := parseStreamIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return 0, err
+	}
+	consumerGroup, err := item.Stream.group(group)
+	if err != nil {
+		return 0, err
+	}
+
+	acked := item.Stream.ack(consumerGroup, streamIDs)
+	if acked > 0 {
+		db.logOperation(&Operation{
+			Command:    enums.DBCommandStreamAck,
+			Key:        key,
+			Time:       db.now(),
+			StreamArgs: &StreamOperation{IDs: streamIDs, Group: group},
+		})
+	}
+	return acked, nil
+}
+
+// StreamPending returns the entries delivered to the consumer of the group that have not been acknowledged yet.
+// If consumer is empty, the pending entries of every consumer are returned.
+func (db *memoryDB) StreamPending(key string, group string, consumer string) ([]PendingEntry, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	consumerGroup, err := item.Stream.group(group)
+	if err != nil {
+		return nil, err
+	}
+	return consumerGroup.pendingEntries(consumer), nil
+}
+
+// StreamClaim transfers to the consumer the pending entries of the group that have been idle for at least minIdle,
+// so the entries of a consumer that failed can be processed by another one. It returns the claimed entries.
+func (db *memoryDB) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) ([]StreamEntry, error) {
+	if db.readOnly.Load() {
+		return nil, ErrReadOnly
+	}
+
+	streamIDs, err := parseStreamIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, err := db.getStream(key)
+	if err != nil {
+		return nil, err
+	}
+	consumerGroup, err := item.Stream.group(group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := db.now()
+	claimable := item.Stream.claimable(consumerGroup, streamIDs, minIdle, now)
+	if len(claimable) == 0 {
+		return []StreamEntry{}, nil
+	}
+	entries := item.Stream.claim(consumerGroup, consumer, claimable, now)
+
+	db.logOperation(&Operation{
+		Command:    enums.DBCommandStreamClaim,
+		Key:        key,
+		Time:       now,
+		StreamArgs: &StreamOperation{IDs: claimable, Group: group, Consumer: consumer},
+	})
+	return entries, nil
+}
+
+// newStreamItem creates an item holding an empty stream.
+func newStreamItem(createdAt time.Time, opts ...ItemOptions) *Item {
+	item := &Item{
+		Kind:       StreamType,
+		Stream:     newStream(),
+		TTL:        createdAt.Add(defaultTTL),
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+		lastAccess: createdAt,
+	}
+	for _, opt := range opts {
+		opt.apply(item)
+	}
+	return item
+}
+
+// getStream returns the item with the stream stored at the key. It must be called with the lock held.
+func (db *memoryDB) getStream(key string) (*Item, error) {
+	item, exists := db.store[key]
+	if !exists {
+		return nil, ErrDataNotFound
+	}
+	if item.isExpired(db.now()) {
+		db.expireItem(key)
+		return nil, ErrKeyHasExpired
+	}
+	if item.Kind != StreamType {
+		return nil, ErrNotAStream
+	}
+	return item, nil
+}
+
+// expireItem removes an expired key and publishes its expiration. It must be called with the lock held.
+func (db *memoryDB) expireItem(key string) {
+	db.deleteItem(key)
+	db.events.publish(enums.KeyspaceEventExpire, key)
+	db.metrics.CountExpired()
+}
+
+// streamSignal returns a channel that is closed when an entry is added to the stream stored at the key.
+// It must be called with the lock held.
+func (db *memoryDB) streamSignal(key string) <-chan struct{} {
+	signal, exists := db.streamSignals[key]
+	if !exists {
+		signal = make(chan struct{})
+		db.streamSignals[key] = signal
+	}
+	return signal
+}
+
+// notifyStream wakes up the readers blocked on the stream stored at the key. It must be called with the lock held.
+func (db *memoryDB) notifyStream(key string) {
+	if signal, exists := db.streamSignals[key]; exists {
+		close(signal)
+		delete(db.streamSignals, key)
+	}
+}
+
+// errBlockTimeout is returned by waitStream when the block time of a read passes.
+var errBlockTimeout = fmt.Errorf("block timeout")
+
+// blockDeadline returns a channel that fires when the block time passes, or nil if the read does not block.
+func blockDeadline(block time.Duration) <-chan time.Time {
+	if block <= 0 {
+		return nil
+	}
+	return time.After(block)
+}
+
+// waitStream waits until the signal is closed, the deadline passes or the context is cancelled.
+func waitStream(ctx context.Context, signal <-chan struct{}, deadline <-chan time.Time) error {
+	select {
+	case <-signal:
+		return nil
+	case <-deadline:
+		return errBlockTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseStreamIDs parses a list of entry IDs.
+func parseStreamIDs(ids []string) ([]StreamID, error) {
+	streamIDs := make([]StreamID, len(ids))
+	for i, id := range ids {
+		parsed, err := ParseStreamID(id, 0)
+		if err != nil {
+			return nil, err
+		}
+		streamIDs[i] = parsed
+	}
+	return streamIDs, nil
+}
@@ -1,12 +1,22 @@
 package db_test
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"memorydb/internal/db"
+	"memorydb/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type MemoryDBSuite struct {
@@ -150,6 +160,27 @@ func (suite *MemoryDBSuite) TestPop() {
 		}
 	}
 }
+func (suite *MemoryDBSuite) TestReturnedItemsAreCopies() {
+	suite.Require().NoError(suite.db.Set("list", []string{"a", "b"}))
+
+	// the items returned are not changed by the writes after them, and changing them does not change the store
+	pushed, err := suite.db.Push("list", "c")
+	suite.Require().NoError(err)
+	got, err := suite.db.Get("list")
+	suite.Require().NoError(err)
+	_, err = suite.db.Pop("list")
+	suite.Require().NoError(err)
+	_, err = suite.db.Push("list", "CHANGED")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "b", "c"}, pushed.Value.Val)
+	suite.Equal([]string{"a", "b", "c"}, got.Value.Val)
+
+	got.Value.Val.([]string)[0] = "modified"
+	item, err := suite.db.Get("list")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"a", "b", "CHANGED"}, item.Value.Val)
+}
+
 func (suite *MemoryDBSuite) TestExpiration() {
 	values := []struct {
 		key           string
@@ -179,6 +210,115 @@ func (suite *MemoryDBSuite) TestExpiration() {
 	}
 }
 
+func (suite *MemoryDBSuite) TestVersions() {
+	suite.Require().NoError(suite.db.Set("key", "v1"))
+	item, err := suite.db.Get("key")
+	suite.Require().NoError(err)
+	version := item.Version
+	suite.NotZero(version)
+
+	suite.Require().NoError(suite.db.Set("list", []string{"a"}))
+	_, err = suite.db.Push("list", "b")
+	suite.Require().NoError(err)
+	item, err = suite.db.Get("key")
+	suite.Require().NoError(err)
+	suite.Equal(version, item.Version, "the writes to other keys should not change the version")
+
+	suite.ErrorIs(suite.db.Set("key", "v2", db.WithVersion(version+1)), db.ErrVersionMismatch)
+	suite.ErrorIs(suite.db.Set("key", "v2", db.WithVersion(0)), db.ErrVersionMismatch, "version 0 requires a missing key")
+	suite.Require().NoError(suite.db.Set("key", "v2", db.WithVersion(version)))
+
+	item, err = suite.db.Get("key")
+	suite.Require().NoError(err)
+	suite.Equal("v2", item.Value.Val)
+	suite.Greater(item.Version, version)
+
+	suite.ErrorIs(suite.db.Update("key", "v3", db.WithVersion(version)), db.ErrVersionMismatch, "the version read before the last write should be stale")
+	suite.Require().NoError(suite.db.Update("key", "v3", db.WithVersion(item.Version)))
+
+	suite.Require().NoError(suite.db.Set("new", "value", db.WithVersion(0)))
+	suite.Require().NoError(suite.db.Set("expiring", "value", db.WithTTL(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+	suite.NoError(suite.db.Set("expiring", "value", db.WithVersion(0)), "expired keys should be missing for the conditional writes")
+}
+
+func (suite *MemoryDBSuite) TestSetMany() {
+	suite.Require().NoError(suite.db.SetMany(map[string]any{"a": "1", "b": []string{"2"}}, db.WithTTL(time.Minute)))
+	item, err := suite.db.Get("a")
+	suite.Require().NoError(err)
+	suite.Equal("1", item.Value.Val)
+	suite.WithinDuration(time.Now().Add(time.Minute), item.TTL, time.Second)
+	item, err = suite.db.Get("b")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"2"}, item.Value.Val)
+
+	suite.Error(suite.db.SetMany(map[string]any{"a": "changed", "c": 3}))
+	item, err = suite.db.Get("a")
+	suite.Require().NoError(err)
+	suite.Equal("1", item.Value.Val, "no item should be stored if one of them is invalid")
+	_, err = suite.db.Get("c")
+	suite.Error(err)
+
+	suite.db.SetReadOnly(true)
+	suite.ErrorIs(suite.db.SetMany(map[string]any{"a": "changed"}), db.ErrReadOnly)
+}
+
+func (suite *MemoryDBSuite) TestMetrics() {
+	collectors := metrics.New()
+	database := db.NewMemoryDB(slog.Default(), db.WithCleanupInterval(time.Millisecond), db.WithMetrics{Metrics: collectors})
+	defer database.Close()
+
+	suite.Require().NoError(database.Set("expiring", "value", db.WithTTL(time.Millisecond)))
+	suite.Require().NoError(database.Set("kept", "value"))
+	suite.Require().NoError(database.Remove("kept"))
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		collectors.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		body, err := io.ReadAll(w.Body)
+		suite.Require().NoError(err)
+		return string(body)
+	}
+	suite.Eventually(func() bool {
+		return strings.Contains(scrape(), "memorydb_expired_keys_total 1")
+	}, time.Second, 5*time.Millisecond, "the cleanup routine should count the expired keys")
+
+	body := scrape()
+	suite.Contains(body, `memorydb_operations_total{command="set"} 2`)
+	suite.Contains(body, `memorydb_operations_total{command="remove"} 1`)
+	suite.Contains(body, `memorydb_keys{type="string"} 0`)
+	suite.Contains(body, "memorydb_cleanup_duration_seconds_count")
+}
+
+func (suite *MemoryDBSuite) TestTracing() {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	ctx, request := provider.Tracer("test").Start(context.Background(), "request")
+
+	traced := suite.db.WithContext(ctx)
+	suite.Require().NoError(traced.Set("key", "value"))
+	_, err := traced.Get("missing")
+	suite.Require().Error(err)
+	suite.Require().NoError(suite.db.Set("untraced", "value"))
+	request.End()
+
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	suite.Len(spans["request"], 1)
+	suite.Require().Len(spans["db.set"], 1, "the operations without a traced context should not be traced")
+	suite.Require().Len(spans["db.get"], 1)
+	suite.Len(spans["db.lock_wait"], 2)
+	suite.Require().Len(spans["db.log_write"], 1, "only the writes should be logged")
+
+	set := spans["db.set"][0]
+	suite.Equal(request.SpanContext().SpanID(), set.Parent().SpanID())
+	suite.Equal(set.SpanContext().SpanID(), spans["db.log_write"][0].Parent().SpanID())
+	suite.Contains(set.Attributes(), attribute.String("db.key", "key"))
+	suite.Equal(codes.Error, spans["db.get"][0].Status().Code, "the span of a failed operation should record the error")
+}
+
 func TestMemoryDB(t *testing.T) {
 	t.Parallel()
 	suite.Run(t, new(MemoryDBSuite))
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"memorydb/internal/enums"
+	"memorydb/internal/glob"
+	"sort"
+)
+
+// Keys returns the keys that match the glob pattern, sorted. Expired keys are skipped.
+func (db *memoryDB) Keys(match string) []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	now := db.now()
+	keys := make([]string, 0)
+	for key, item := range db.store {
+		if !item.isExpired(now) && glob.Match(match, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Dump returns the JSON encoding of the item stored at the key, in the same format used by the snapshots,
+// so it can be moved to another database with Restore.
+func (db *memoryDB) Dump(key string) ([]byte, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item, exists := db.store[key]
+	if !exists {
+		return nil, ErrDataNotFound
+	}
+	if item.isExpired(db.now()) {
+		db.expireItem(key)
+		return nil, ErrKeyHasExpired
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key %s: %w", key, err)
+	}
+	return data, nil
+}
+
+// Restore stores at the key the item encoded by Dump, replacing any previous value. The item keeps its
+// timestamps and TTL, and it is logged and published as a set.
+func (db *memoryDB) Restore(key string, data []byte) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	var item Item
+	if err := json.Unmarshal(data, &item); err != nil {
+		return fmt.Errorf("failed to decode key %s: %w", key, err)
+	}
+	if item.Kind == StreamType && item.Stream == nil {
+		return fmt.Errorf("failed to decode key %s: the stream has no entries", key)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	delta := entrySize(key, &item)
+	if previous, exists := db.store[key]; exists {
+		delta -= entrySize(key, previous)
+	}
+	if err := db.reserveMemory(delta, key); err != nil {
+		return err
+	}
+
+	item.lastAccess = db.now()
+	db.logOperation(&Operation{Command: enums.DBCommandSet, Key: key, Time: db.now(), Item: &item})
+	db.storeItem(key, &item)
+	db.events.publish(enums.KeyspaceEventSet, key)
+	return nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationSuite struct {
+	suite.Suite
+	source *memoryDB
+	target *memoryDB
+}
+
+func (s *MigrationSuite) SetupTest() {
+	s.source = NewMemoryDB(slog.Default()).(*memoryDB)
+	s.target = NewMemoryDB(slog.Default()).(*memoryDB)
+}
+
+func (s *MigrationSuite) TearDownTest() {
+	s.source.Close()
+	s.target.Close()
+}
+
+func (s *MigrationSuite) TestKeys() {
+	s.Require().NoError(s.source.Set("user:2", "b"))
+	s.Require().NoError(s.source.Set("user:1", "a"))
+	s.Require().NoError(s.source.Set("session:1", "c"))
+	s.Require().NoError(s.source.Set("user:3", "d", WithTTL(time.Millisecond)))
+	time.Sleep(5 * time.Millisecond)
+
+	s.Equal([]string{"user:1", "user:2"}, s.source.Keys("user:*"), "expired keys should be skipped")
+	s.Len(s.source.Keys("*"), 3)
+	s.Empty(s.source.Keys("missing:*"))
+}
+
+func (s *MigrationSuite) TestDumpRestore() {
+	s.Require().NoError(s.source.Set("list", []string{"a", "b"}, WithTTL(time.Hour)))
+	_, err := s.source.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+
+	for _, key := range []string{"list", "stream"} {
+		data, err := s.source.Dump(key)
+		s.Require().NoError(err)
+		s.Require().NoError(s.target.Restore(key, data))
+	}
+
+	source, err := s.source.Get("list")
+	s.Require().NoError(err)
+	restored, err := s.target.Get("list")
+	s.Require().NoError(err)
+	s.Equal(source.Value.Val, restored.Value.Val)
+	s.True(source.TTL.Equal(restored.TTL), "the TTL should be kept")
+
+	entries, err := s.target.StreamRange("stream", "-", "+", 0)
+	s.Require().NoError(err)
+	s.Len(entries, 1)
+	s.Positive(s.target.Stats().UsedMemory)
+}
+
+func (s *MigrationSuite) TestDumpMissing() {
+	_, err := s.source.Dump("missing")
+	s.ErrorIs(err, ErrDataNotFound)
+
+	s.ErrorContains(s.target.Restore("key", []byte("invalid")), "failed to decode key key")
+}
+
+func TestMigrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationSuite))
+}
@@ -0,0 +1,249 @@
+package db
+
+import (
+	"fmt"
+	"maps"
+	"memorydb/internal/enums"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// namespaceName is the format of the names of the namespaces, which are part of the URLs and of the paths of
+// their data directories.
+var namespaceName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// IsValidNamespaceName reports whether the name has the format of the names of the namespaces.
+func IsValidNamespaceName(name string) bool {
+	return namespaceName.MatchString(name)
+}
+
+// NamespaceConfig holds the settings of a namespace, which apply to its keys instead of the ones of the database.
+type NamespaceConfig struct {
+	DefaultTTL     time.Duration        `json:"default_ttl,omitempty"`     // TTL of the items stored without one, 5 minutes if not set
+	MaxMemory      int64                `json:"max_memory,omitempty"`      // approximate memory limit in bytes, 0 means no limit
+	EvictionPolicy enums.EvictionPolicy `json:"eviction_policy,omitempty"` // policy applied when the memory limit is reached
+	Persistence    bool                 `json:"persistence,omitempty"`     // whether the keys are written to a log of their own
+}
+
+// NamespaceItem is a namespace with its settings.
+type NamespaceItem struct {
+	Name   string          `json:"name"`
+	Config NamespaceConfig `json:"config"`
+}
+
+// namespace is a keyspace isolated from the store and from the other namespaces. It is a database of its own,
+// with its own memory limit, cleanup routine, keyspace events and, if it is persisted, operation log.
+type namespace struct {
+	config NamespaceConfig
+	db     *memoryDB // database of the keys, nil while the operation log of the parent is replayed
+}
+
+// CreateNamespace creates an empty namespace with the settings. A persisted namespace keeps its keys in a
+// subdirectory of the data directory, and any data left there by a dropped namespace with the same name is removed.
+func (db *memoryDB) CreateNamespace(name string, config NamespaceConfig) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+	if config.DefaultTTL == 0 {
+		config.DefaultTTL = defaultTTL
+	}
+	if config.EvictionPolicy == "" {
+		config.EvictionPolicy = enums.EvictionPolicyNoEviction
+	}
+	if !namespaceName.MatchString(name) || !config.EvictionPolicy.IsValid() || config.DefaultTTL < 0 || config.MaxMemory < 0 {
+		return ErrInvalidNamespace
+	}
+	if config.Persistence && !db.persistenceEnabled {
+		return ErrNamespaceNotPersistent
+	}
+
+	// the log file is written with the lock of the items held
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.createNamespace(name, config)
+}
+
+// DropNamespace removes the namespace with all its keys. The subscribers of its keyspace events are disconnected.
+func (db *memoryDB) DropNamespace(name string) error {
+	if db.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.dropNamespace(name)
+}
+
+// createNamespace opens the database of a new namespace and logs its creation, so it is persisted and replicated.
+// It must be called with the lock of the items held.
+func (db *memoryDB) createNamespace(name string, config NamespaceConfig) error {
+	db.nsMu.Lock()
+	defer db.nsMu.Unlock()
+	if _, exists := db.namespaces[name]; exists {
+		return ErrNamespaceExists
+	}
+
+	if config.Persistence {
+		if err := os.RemoveAll(db.namespacePath(name)); err != nil {
+			return fmt.Errorf("failed to remove previous data of namespace %s: %w", name, err)
+		}
+	}
+	child, err := db.openNamespace(name, config)
+	if err != nil {
+		return err
+	}
+	db.namespaces[name] = &namespace{config: config, db: child}
+
+	db.logOperation(&Operation{
+		Command:   enums.DBCommandNamespaceCreate,
+		Time:      db.now(),
+		Namespace: &NamespaceItem{Name: name, Config: config},
+	})
+	return nil
+}
+
+// dropNamespace closes the database of the namespace, removes its data and logs the drop. It must be called with
+// the lock of the items held.
+func (db *memoryDB) dropNamespace(name string) error {
+	db.nsMu.Lock()
+	ns, exists := db.namespaces[name]
+	delete(db.namespaces, name)
+	db.nsMu.Unlock()
+	if !exists {
+		return ErrNamespaceNotFound
+	}
+
+	db.logOperation(&Operation{
+		Command:   enums.DBCommandNamespaceDrop,
+		Time:      db.now(),
+		Namespace: &NamespaceItem{Name: name},
+	})
+
+	ns.db.Close()
+	if ns.config.Persistence {
+		if err := os.RemoveAll(db.namespacePath(name)); err != nil {
+			db.logger.Warn("failed to remove data of dropped namespace", "namespace", name, "error", err)
+		}
+	}
+	return nil
+}
+
+// Namespace returns the database of the keys of the namespace.
+func (db *memoryDB) Namespace(name string) (DBClient, error) {
+	db.nsMu.RLock()
+	defer db.nsMu.RUnlock()
+
+	ns, exists := db.namespaces[name]
+	if !exists {
+		return nil, ErrNamespaceNotFound
+	}
+	return ns.db, nil
+}
+
+// Namespaces returns the namespaces with their settings, sorted by name.
+func (db *memoryDB) Namespaces() []NamespaceItem {
+	db.nsMu.RLock()
+	defer db.nsMu.RUnlock()
+
+	items := make([]NamespaceItem, 0, len(db.namespaces))
+	for _, name := range slices.Sorted(maps.Keys(db.namespaces)) {
+		items = append(items, NamespaceItem{Name: name, Config: db.namespaces[name].config})
+	}
+	return items
+}
+
+// openNamespace creates the database of a namespace, which shares the clock and the cleanup interval of the
+// parent. Its operations are replicated through the log of the parent, so it keeps no replication backlog.
+func (db *memoryDB) openNamespace(name string, config NamespaceConfig) (*memoryDB, error) {
+	opts := []DBOptions{
+		WithCleanupInterval(db.cleanupInterval),
+		WithClock(db.clock),
+		WithDefaultTTL(config.DefaultTTL),
+		WithMaxMemory(config.MaxMemory),
+		WithEvictionPolicy(config.EvictionPolicy),
+		WithReplicationBacklog(0),
+	}
+	if config.Persistence {
+		// the option panics if the directory cannot be created, so it is created first to return the error
+		if err := os.MkdirAll(db.namespacePath(name), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory of namespace %s: %w", name, err)
+		}
+		opts = append(opts, WithPersistenceEnabled(db.namespacePath(name)))
+	}
+
+	child := NewMemoryDB(db.logger.With("namespace", name), opts...).(*memoryDB)
+	child.SetReadOnly(db.readOnly.Load())
+
+	// the metrics are set once the database is created, since the keys of the namespace are counted by the parent
+	child.mu.Lock()
+	child.metrics = db.metrics
+	child.parent = db
+	child.nsName = name
+	child.mu.Unlock()
+	return child, nil
+}
+
+// openNamespaces opens the databases of the namespaces restored from the operation log. They are opened once the
+// whole log is replayed, so the data of a namespace that was dropped and created again is only loaded once.
+func (db *memoryDB) openNamespaces() error {
+	db.nsMu.Lock()
+	defer db.nsMu.Unlock()
+
+	for name, ns := range db.namespaces {
+		child, err := db.openNamespace(name, ns.config)
+		if err != nil {
+			return err
+		}
+		ns.db = child
+	}
+	return nil
+}
+
+// closeNamespaces closes the databases of the namespaces, keeping their persisted data.
+func (db *memoryDB) closeNamespaces() {
+	db.nsMu.Lock()
+	defer db.nsMu.Unlock()
+
+	for name, ns := range db.namespaces {
+		if ns.db != nil {
+			ns.db.Close()
+		}
+		delete(db.namespaces, name)
+	}
+}
+
+// setNamespacesReadOnly sets whether the databases of the namespaces reject writes, as the parent.
+func (db *memoryDB) setNamespacesReadOnly(readOnly bool) {
+	db.nsMu.RLock()
+	defer db.nsMu.RUnlock()
+
+	for _, ns := range db.namespaces {
+		ns.db.SetReadOnly(readOnly)
+	}
+}
+
+// namespacePath returns the data directory of the persisted namespace.
+func (db *memoryDB) namespacePath(name string) string {
+	return filepath.Join(db.dbPath, "namespaces", name)
+}
+
+// replayNamespace records the namespace created or dropped by an operation of the log. The databases of the
+// namespaces are opened by openNamespaces after the replay.
+func (db *memoryDB) replayNamespace(op *Operation) error {
+	if op.Namespace == nil {
+		return fmt.Errorf("missing namespace for command %s", op.Command)
+	}
+
+	db.nsMu.Lock()
+	defer db.nsMu.Unlock()
+
+	if op.Command == enums.DBCommandNamespaceDrop {
+		delete(db.namespaces, op.Namespace.Name)
+		return nil
+	}
+	db.namespaces[op.Namespace.Name] = &namespace{config: op.Namespace.Config}
+	return nil
+}
@@ -0,0 +1,155 @@
+package db
+
+import (
+	"log/slog"
+	"memorydb/internal/enums"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NamespaceSuite struct {
+	suite.Suite
+}
+
+func (s *NamespaceSuite) TestIsolation() {
+	db := NewMemoryDB(slog.Default())
+	defer db.Close()
+
+	s.Require().NoError(db.CreateNamespace("billing", NamespaceConfig{}))
+	s.Require().NoError(db.CreateNamespace("config", NamespaceConfig{}))
+	s.ErrorIs(db.CreateNamespace("billing", NamespaceConfig{}), ErrNamespaceExists)
+
+	billing, err := db.Namespace("billing")
+	s.Require().NoError(err)
+	config, err := db.Namespace("config")
+	s.Require().NoError(err)
+
+	s.Require().NoError(db.Set("key", "default"))
+	s.Require().NoError(billing.Set("key", "billing"))
+	s.Require().NoError(config.Set("key", "config"))
+
+	for client, expected := range map[DBClient]string{db: "default", billing: "billing", config: "config"} {
+		item, err := client.Get("key")
+		s.Require().NoError(err)
+		s.Equal(expected, item.Value.Val)
+	}
+	s.Equal([]string{"key"}, db.Keys("*"), "the keys of the namespaces should not be listed with the store")
+	s.Equal(1, db.Stats().Keys)
+	s.Equal(2, db.Stats().Namespaces)
+	s.Equal(1, billing.Stats().Keys)
+
+	s.Require().NoError(db.DropNamespace("billing"))
+	s.ErrorIs(db.DropNamespace("billing"), ErrNamespaceNotFound)
+	_, err = db.Namespace("billing")
+	s.ErrorIs(err, ErrNamespaceNotFound)
+	s.Equal([]NamespaceItem{{Name: "config", Config: NamespaceConfig{DefaultTTL: defaultTTL, EvictionPolicy: enums.EvictionPolicyNoEviction}}}, db.Namespaces())
+
+	_, err = config.Get("key")
+	s.NoError(err, "dropping a namespace should not affect the others")
+}
+
+func (s *NamespaceSuite) TestSettings() {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	db := NewMemoryDB(slog.Default(), WithClock(func() time.Time { return now }))
+	defer db.Close()
+
+	s.Require().NoError(db.CreateNamespace("sessions", NamespaceConfig{
+		DefaultTTL:     time.Hour,
+		MaxMemory:      1024,
+		EvictionPolicy: enums.EvictionPolicyAllKeysLRU,
+	}))
+	sessions, err := db.Namespace("sessions")
+	s.Require().NoError(err)
+
+	s.Require().NoError(sessions.Set("default", "value"))
+	s.Require().NoError(sessions.Set("custom", "value", WithTTL(time.Minute)))
+	s.Require().NoError(db.Set("key", "value"))
+
+	item, err := sessions.Get("default")
+	s.Require().NoError(err)
+	s.Equal(now.Add(time.Hour), item.TTL, "the items should expire after the default TTL of the namespace")
+	item, err = sessions.Get("custom")
+	s.Require().NoError(err)
+	s.Equal(now.Add(time.Minute), item.TTL)
+	item, err = db.Get("key")
+	s.Require().NoError(err)
+	s.Equal(now.Add(defaultTTL), item.TTL, "the default TTL of the database should not change")
+
+	value := string(make([]byte, 300))
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		s.Require().NoError(sessions.Set(key, value))
+	}
+	stats := sessions.Stats()
+	s.LessOrEqual(stats.UsedMemory, int64(1024))
+	s.NotZero(stats.EvictedKeys, "the namespace should evict with its own policy")
+	s.Zero(db.Stats().EvictedKeys)
+}
+
+func (s *NamespaceSuite) TestInvalid() {
+	db := NewMemoryDB(slog.Default())
+	defer db.Close()
+
+	s.ErrorIs(db.CreateNamespace("", NamespaceConfig{}), ErrInvalidNamespace)
+	s.ErrorIs(db.CreateNamespace("a/b", NamespaceConfig{}), ErrInvalidNamespace)
+	s.ErrorIs(db.CreateNamespace("a", NamespaceConfig{EvictionPolicy: "random"}), ErrInvalidNamespace)
+	s.ErrorIs(db.CreateNamespace("a", NamespaceConfig{MaxMemory: -1}), ErrInvalidNamespace)
+	s.ErrorIs(db.CreateNamespace("a", NamespaceConfig{Persistence: true}), ErrNamespaceNotPersistent)
+	s.Empty(db.Namespaces())
+
+	s.Require().NoError(db.CreateNamespace("a", NamespaceConfig{}))
+	db.SetReadOnly(true)
+	s.ErrorIs(db.CreateNamespace("b", NamespaceConfig{}), ErrReadOnly)
+	s.ErrorIs(db.DropNamespace("a"), ErrReadOnly)
+	ns, err := db.Namespace("a")
+	s.Require().NoError(err)
+	s.ErrorIs(ns.Set("key", "value"), ErrReadOnly, "the namespaces should be read-only as the database")
+}
+
+func (s *NamespaceSuite) TestPersistence() {
+	dbPath := s.T().TempDir()
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	s.Require().NoError(db.CreateNamespace("persisted", NamespaceConfig{Persistence: true, DefaultTTL: time.Hour}))
+	s.Require().NoError(db.CreateNamespace("volatile", NamespaceConfig{}))
+	s.Require().NoError(db.CreateNamespace("dropped", NamespaceConfig{Persistence: true}))
+	for _, name := range []string{"persisted", "volatile", "dropped"} {
+		ns, err := db.Namespace(name)
+		s.Require().NoError(err)
+		s.Require().NoError(ns.Set("key", name))
+	}
+	s.Require().NoError(db.DropNamespace("dropped"))
+	s.NoDirExists(filepath.Join(dbPath, "namespaces", "dropped"), "the data of a dropped namespace should be removed")
+	s.Require().NoError(db.CreateNamespace("dropped", NamespaceConfig{Persistence: true}))
+	db.Close()
+
+	reloaded := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath))
+	defer reloaded.Close()
+	s.Len(reloaded.Namespaces(), 3)
+
+	persisted, err := reloaded.Namespace("persisted")
+	s.Require().NoError(err)
+	item, err := persisted.Get("key")
+	s.Require().NoError(err)
+	s.Equal("persisted", item.Value.Val)
+	s.Equal(time.Hour, reloaded.Namespaces()[1].Config.DefaultTTL)
+
+	volatile, err := reloaded.Namespace("volatile")
+	s.Require().NoError(err)
+	_, err = volatile.Get("key")
+	s.ErrorIs(err, ErrDataNotFound, "the keys of a namespace without persistence should not be restored")
+
+	dropped, err := reloaded.Namespace("dropped")
+	s.Require().NoError(err)
+	_, err = dropped.Get("key")
+	s.ErrorIs(err, ErrDataNotFound, "a namespace created again should not restore the keys of the dropped one")
+
+	_, err = os.Stat(LogFilePath(filepath.Join(dbPath, "namespaces", "persisted")))
+	s.NoError(err, "a persisted namespace should have its own log")
+}
+
+func TestNamespaceSuite(t *testing.T) {
+	suite.Run(t, new(NamespaceSuite))
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	// LogFileName is the name of the operation log in the data directory.
+	LogFileName = "test_db.log"
+)
+
+// LogFilePath returns the path of the operation log in the data directory.
+func LogFilePath(dbPath string) string {
+	return filepath.Join(dbPath, LogFileName)
+}
+
+// LogError is an error found at a record of the operation log.
+type LogError struct {
+	Record int   // number of the record, starting at 1
+	Offset int64 // byte offset where the previous record ends
+	Err    error
+}
+
+func (e *LogError) Error() string {
+	return fmt.Sprintf("record %d at offset %d: %v", e.Record, e.Offset, e.Err)
+}
+
+func (e *LogError) Unwrap() error {
+	return e.Err
+}
+
+// LogReader decodes the operations of an operation log one by one.
+type LogReader struct {
+	decoder *json.Decoder
+	record  int
+	offset  int64
+}
+
+// NewLogReader returns a reader of the operations of the log read from r.
+func NewLogReader(r io.Reader) *LogReader {
+	return &LogReader{decoder: json.NewDecoder(r)}
+}
+
+// Next returns the next operation of the log, or io.EOF once the whole log has been read.
+// Decoding errors are returned as a *LogError.
+func (r *LogReader) Next() (*Operation, error) {
+	r.offset = r.decoder.InputOffset()
+	var op Operation
+	if err := r.decoder.Decode(&op); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, &LogError{Record: r.record + 1, Offset: r.offset, Err: err}
+	}
+	r.record++
+	return &op, nil
+}
+
+// Record returns the number of the last operation returned by Next.
+func (r *LogReader) Record() int {
+	return r.record
+}
+
+// Offset returns the byte offset at which Next started to read the last operation, where the previous one ends.
+func (r *LogReader) Offset() int64 {
+	return r.offset
+}
+
+// LogReplayer rebuilds the store from the operations of a log without running a database, in the same
+// way the log is loaded when the database starts.
+type LogReplayer struct {
+	db *memoryDB
+}
+
+// NewLogReplayer returns a replayer with an empty store.
+func NewLogReplayer() *LogReplayer {
+	return &LogReplayer{db: &memoryDB{store: make(map[string]*Item), authStore: make(map[string]*AuthItem), apiKeys: make(map[string]*APIKeyItem)}}
+}
+
+// Apply applies an operation of the log to the store.
+func (r *LogReplayer) Apply(op *Operation) error {
+	return r.db.replayOperation(op)
+}
+
+// Records returns the keys of the store sorted by key. The keys expired at now are skipped.
+func (r *LogReplayer) Records(now time.Time) []BackupRecord {
+	records := make([]BackupRecord, 0, len(r.db.store))
+	for key, item := range r.db.store {
+		if item.isExpired(now) {
+			continue
+		}
+		records = append(records, BackupRecord{Key: key, Item: item})
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].Key < records[j].Key })
+	return records
+}
+
+// Users returns the users of the authentication module sorted by username.
+func (r *LogReplayer) Users() []*AuthItem {
+	users := slices.Collect(maps.Values(r.db.authStore))
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// APIKeys returns the API keys of the authentication module sorted by creation time.
+func (r *LogReplayer) APIKeys() []*APIKeyItem {
+	keys := slices.Collect(maps.Values(r.db.apiKeys))
+	sortAPIKeys(keys)
+	return keys
+}
+
+// Len returns the number of keys in the store, including the expired ones.
+func (r *LogReplayer) Len() int {
+	return len(r.db.store)
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type OpLogSuite struct {
+	suite.Suite
+	dbPath string
+}
+
+func (s *OpLogSuite) SetupTest() {
+	s.dbPath = s.T().TempDir()
+}
+
+func (s *OpLogSuite) TestReader() {
+	log := `{"command":"set","key":"key1","value":"v","kind":0}
+{"command":"remove","key":"key1"}
+{"command":"set","key":`
+
+	reader := NewLogReader(strings.NewReader(log))
+	op, err := reader.Next()
+	s.Require().NoError(err)
+	s.Equal("key1", op.Key)
+	s.Equal(1, reader.Record())
+
+	_, err = reader.Next()
+	s.Require().NoError(err)
+	s.Equal(2, reader.Record())
+
+	// the last record is truncated
+	_, err = reader.Next()
+	var logError *LogError
+	s.Require().ErrorAs(err, &logError)
+	s.Equal(3, logError.Record)
+	s.Equal(int64(strings.LastIndex(log, "\n")), logError.Offset)
+	s.ErrorIs(err, io.ErrUnexpectedEOF)
+
+	_, err = NewLogReader(strings.NewReader("")).Next()
+	s.ErrorIs(err, io.EOF)
+}
+
+func (s *OpLogSuite) TestReplayer() {
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(s.dbPath))
+	s.Require().NoError(db.Set("string", "value"))
+	s.Require().NoError(db.Update("string", "updated"))
+	s.Require().NoError(db.Set("list", []string{"a"}))
+	_, err := db.Push("list", "b")
+	s.Require().NoError(err)
+	s.Require().NoError(db.Set("removed", "value"))
+	s.Require().NoError(db.Remove("removed"))
+	s.Require().NoError(db.Set("expired", "value", WithTTL(time.Millisecond)))
+	_, err = db.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+	db.Close()
+
+	f, err := os.Open(LogFilePath(s.dbPath))
+	s.Require().NoError(err)
+	defer f.Close()
+
+	replayer := NewLogReplayer()
+	reader := NewLogReader(f)
+	for {
+		op, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		s.Require().NoError(err)
+		s.Require().NoError(replayer.Apply(op))
+	}
+	s.Equal(4, replayer.Len())
+
+	// the replayed store is the one loaded by the database
+	records := replayer.Records(time.Now().Add(time.Second))
+	s.Require().Len(records, 3, "the expired keys should be skipped")
+	s.Equal("list", records[0].Key)
+	s.Equal([]string{"a", "b"}, records[0].Item.Value.Val)
+	s.Equal("stream", records[1].Key)
+	s.Len(records[1].Item.Stream.Entries, 1)
+	s.Equal("string", records[2].Key)
+	s.Equal("updated", records[2].Item.Value.Val)
+
+	loaded := NewMemoryDB(slog.Default(), WithPersistenceEnabled(s.dbPath))
+	defer loaded.Close()
+	for key, item := range replayer.db.store {
+		s.Equal(item.Value, loaded.(*memoryDB).store[key].Value, key)
+	}
+	s.Len(loaded.(*memoryDB).store, replayer.Len())
+}
+
+func (s *OpLogSuite) TestReplayerError() {
+	replayer := NewLogReplayer()
+	err := replayer.Apply(&Operation{Command: "remove", Key: "missing"})
+	s.Error(err, "the operations on missing keys should fail as when the database is loaded")
+}
+
+func TestOpLogSuite(t *testing.T) {
+	suite.Run(t, new(OpLogSuite))
+}
@@ -2,6 +2,8 @@ package db
 
 import (
 	"encoding/json"
+	"memorydb/internal/enums"
+	"memorydb/internal/metrics"
 	"time"
 )
 
@@ -20,6 +22,16 @@ func (o WithCleanupInterval) apply(db *memoryDB) {
 	db.cleanupInterval = time.Duration(o)
 }
 
+// WithClock sets the source of the current time used for the timestamps, the TTLs and the stream IDs.
+//
+// Operations applied with the same clock readings produce the same result, which is what lets a replicated log
+// apply the same operation on several nodes.
+type WithClock func() time.Time
+
+func (o WithClock) apply(db *memoryDB) {
+	db.clock = o
+}
+
 // WithPersistenceEnabled sets whether persistence is enabled for the database.
 type WithPersistenceEnabled string
 
@@ -32,5 +44,45 @@ func (o WithPersistenceEnabled) apply(db *memoryDB) {
 		panic("failed to set up directory for persistence: " + err.Error())
 	}
 	db.logFile = logFile
-	db.logEncoder = json.NewEncoder(db.logFile)
+	db.logWriter = &countingWriter{w: logFile}
+	db.logEncoder = json.NewEncoder(db.logWriter)
+}
+
+// WithMaxMemory sets the approximate maximum number of bytes the store can use. A value of 0 disables the limit.
+type WithMaxMemory int64
+
+func (o WithMaxMemory) apply(db *memoryDB) {
+	db.maxMemory = int64(o)
+}
+
+// WithEvictionPolicy sets the policy used to free memory once the memory limit is reached.
+type WithEvictionPolicy enums.EvictionPolicy
+
+func (o WithEvictionPolicy) apply(db *memoryDB) {
+	db.evictionPolicy = enums.EvictionPolicy(o)
+}
+
+// WithReplicationBacklog sets the number of logged operations kept so replicas can resume replication after a
+// disconnection without a full synchronization. A value of 0 disables the backlog.
+type WithReplicationBacklog int
+
+func (o WithReplicationBacklog) apply(db *memoryDB) {
+	db.replicationBacklog = int(o)
+}
+
+// WithDefaultTTL sets the TTL of the items stored without one, instead of 5 minutes. A value of 0 keeps the default.
+type WithDefaultTTL time.Duration
+
+func (o WithDefaultTTL) apply(db *memoryDB) {
+	if o > 0 {
+		db.itemTTL = time.Duration(o)
+	}
+}
+
+// WithMetrics records the metrics of the database, such as the operations, the keys, the expirations and the
+// writes to the log file, in the collectors. The namespaces are recorded in the same collectors.
+type WithMetrics struct{ *metrics.Metrics }
+
+func (o WithMetrics) apply(db *memoryDB) {
+	db.metrics = o.Metrics
 }
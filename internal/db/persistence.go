@@ -7,7 +7,6 @@ import (
 	"io"
 	"memorydb/internal/enums"
 	"os"
-	"path/filepath"
 	"time"
 )
 
@@ -17,19 +16,39 @@ type Operation struct {
 	Key     string          `json:"key"`
 	Time    time.Time       `json:"time"`
 	*Item
+
+	StreamArgs *StreamOperation `json:"stream_args,omitempty"` // arguments of the stream commands
+	User       *AuthItem        `json:"user,omitempty"`        // user stored by user_set, which has no key
+	APIKey     *APIKeyItem      `json:"api_key,omitempty"`     // API key stored by apikey_set, or only its ID for apikey_remove
+	Namespace  *NamespaceItem   `json:"namespace,omitempty"`   // namespace created by ns_create, or only its name for ns_drop
+
+	// KeyNamespace is the namespace of the key of a replicated operation, empty for the keys of the store
+	KeyNamespace string `json:"key_namespace,omitempty"`
+}
+
+// StreamOperation holds the arguments of a stream command in the operation log.
+//
+// Commands that depend on the time they are executed, like trimming by age, are logged with their resolved
+// arguments, so replaying the log always produces the same stream.
+type StreamOperation struct {
+	ID       StreamID          `json:"id"`                 // ID of the added entry, or last delivered ID of a new group
+	Fields   map[string]string `json:"fields,omitempty"`   // fields of the added entry
+	IDs      []StreamID        `json:"ids,omitempty"`      // IDs of the delivered, acknowledged or claimed entries
+	Group    string            `json:"group,omitempty"`    // name of the consumer group
+	Consumer string            `json:"consumer,omitempty"` // name of the consumer
+	MaxLen   int               `json:"max_len,omitempty"`  // maximum number of entries kept by a trim
+	MinID    StreamID          `json:"min_id"`             // minimum ID kept by a trim
 }
 
 // setupDirectory creates a directory for the database file if it does not exist and returns a file handle to the database log file.
 func setupDirectory(dbPath string) (*os.File, error) {
-	filename := "test_db.log"
-
 	// create the directory if it does not exist
 	if err := os.MkdirAll(dbPath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory for database file: %w", err)
 	}
 
 	// create or open the database file
-	dbFilePath := filepath.Join(dbPath, filename)
+	dbFilePath := LogFilePath(dbPath)
 	logFile, err := os.OpenFile(dbFilePath, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database file %s: %w", dbFilePath, err)
@@ -38,22 +57,79 @@ func setupDirectory(dbPath string) (*os.File, error) {
 	return logFile, nil
 }
 
-// logOperation logs a database operation to the log file.
+// logOperation logs a database operation to the log file and streams it to the replicas.
+// Every write is logged, so it also assigns a new version to the item written by the operation.
 func (db *memoryDB) logOperation(op *Operation) {
+	db.metrics.CountOperation(op.Command)
+	db.versionItem(op)
+	db.replicate(op)
+	db.persistOperation(op)
+}
+
+// replicate appends the operation to the replication log. The operations of a namespace are appended to the log of
+// its parent with the name of the namespace, so the replicas of the parent apply them to the same namespace.
+func (db *memoryDB) replicate(op *Operation) {
+	if db.parent == nil {
+		db.replication.append(op)
+		return
+	}
+	replicated := *op
+	replicated.KeyNamespace = db.nsName
+	db.parent.replication.append(&replicated)
+}
+
+// persistOperation writes a database operation to the log file, if persistence is enabled.
+func (db *memoryDB) persistOperation(op *Operation) {
 	if !db.persistenceEnabled {
 		return
 	}
-	if err := db.logEncoder.Encode(op); err != nil {
+	written := db.logWriter.written
+	err := db.logEncoder.Encode(op)
+	db.metrics.CountPersistedBytes(int(db.logWriter.written - written))
+	if err != nil {
+		db.metrics.CountEncodeFailure()
+		db.logError.Store(&err)
+		db.logEncoder = json.NewEncoder(db.logWriter) // the encoder keeps its first error, so the next writes can succeed
 		db.logger.Warn("failed to log operation to file", "key", op.Key, "command", op.Command, "error", err)
 		return
 	}
+	db.logError.Store(nil)
+}
+
+// countingWriter counts the bytes written to the log file.
+type countingWriter struct {
+	w       io.Writer
+	written int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.written += int64(n)
+	return n, err
+}
+
+// versionItem assigns the next version to the item written by the operation. The item of a set may not be stored
+// yet when the operation is logged, so it is the item of the operation, and the stored item for the other commands.
+func (db *memoryDB) versionItem(op *Operation) {
+	if isAuthCommand(op.Command) || isNamespaceCommand(op.Command) {
+		return // the users, the API keys and the namespaces are not items
+	}
+	db.version++
+	if op.Command == enums.DBCommandSet && op.Item != nil {
+		op.Item.Version = db.version
+		return
+	}
+	if item, exists := db.store[op.Key]; exists {
+		item.Version = db.version
+	}
 }
 
 func (db *memoryDB) loadStoredData() error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
+	defer db.startLoading()()
 
-	dbLog := filepath.Join(db.dbPath, "test_db.log")
+	dbLog := LogFilePath(db.dbPath)
 	fileInfo, err := os.Stat(dbLog)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -74,10 +150,11 @@ func (db *memoryDB) loadStoredData() error {
 	}
 	defer db.logFile.Close()
 
-	decoder := json.NewDecoder(db.logFile)
+	start := time.Now()
+	reader := NewLogReader(db.logFile)
 	for {
-		var op Operation
-		if err := decoder.Decode(&op); err != nil {
+		op, err := reader.Next()
+		if err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
@@ -86,53 +163,154 @@ func (db *memoryDB) loadStoredData() error {
 
 		// Reconstruct the item and store it in the memoryDB
 		db.logger.Debug("reconstructing item from operation log", "key", op.Key, "command", op.Command)
-		switch op.Command {
-		case enums.DBCommandSet:
-			db.store[op.Key] = op.Item
-		case enums.DBCommandUpdate:
-			if item, exists := db.store[op.Key]; exists {
-				if err := item.update(op.Item.Value.Val, op.Item.UpdatedAt); err != nil {
-					return fmt.Errorf("failed to update item with key %s: %w", op.Key, err)
-				}
-				// update the ttl if it exists
-				if !op.Item.TTL.IsZero() {
-					item.TTL = op.Item.TTL
-				}
-			} else {
-				return fmt.Errorf("item with key %s not found for update", op.Key)
+		if err := db.replayOperation(op); err != nil {
+			return &LogError{Record: reader.Record(), Offset: reader.Offset(), Err: err}
+		}
+	}
+
+	db.metrics.ObserveReplay(time.Since(start))
+
+	// rebuild the memory accounting, the access tracking and the versions from the replayed items
+	db.usedMemory = 0
+	for key, item := range db.store {
+		item.lastAccess = item.UpdatedAt
+		db.usedMemory += entrySize(key, item)
+		db.version++
+		item.Version = db.version
+	}
+
+	return nil
+}
+
+// replayOperation applies an operation of the log to the store. It is used to restore the persisted data
+// and to apply the operations received from the primary on a replica. It must be called with the lock held.
+func (db *memoryDB) replayOperation(op *Operation) error {
+	switch op.Command {
+	case enums.DBCommandSet:
+		db.store[op.Key] = op.Item
+	case enums.DBCommandUpdate:
+		if item, exists := db.store[op.Key]; exists {
+			kind, value, err := parseValue(op.Item.Value.Val)
+			if err == nil {
+				err = item.update(kind, value, op.Item.UpdatedAt)
 			}
-		case enums.DBCommandRemove:
-			if _, exists := db.store[op.Key]; exists {
-				delete(db.store, op.Key)
-			} else {
-				return fmt.Errorf("item with key %s not found for removal", op.Key)
+			if err != nil {
+				return fmt.Errorf("failed to update item with key %s: %w", op.Key, err)
 			}
-		case enums.DBCommandPush:
-			if item, exists := db.store[op.Key]; exists {
-				if err := item.pushToSlice(op.UpdatedAt, op.Item.Value.Val.(string)); err != nil {
-					return fmt.Errorf("failed to push value to item with key %s: %v", op.Key, err)
-				}
-				// update the ttl if it exists
-				if !op.Item.TTL.IsZero() {
-					item.TTL = op.Item.TTL
-				}
-			} else {
-				return fmt.Errorf("item with key %s not found for push", op.Key)
+			// update the ttl if it exists
+			item.copyTTL(op.Item)
+		} else {
+			return fmt.Errorf("item with key %s not found for update", op.Key)
+		}
+	case enums.DBCommandRemove:
+		if _, exists := db.store[op.Key]; exists {
+			delete(db.store, op.Key)
+		} else {
+			return fmt.Errorf("item with key %s not found for removal", op.Key)
+		}
+	case enums.DBCommandPush:
+		if item, exists := db.store[op.Key]; exists {
+			if err := item.pushToSlice(op.UpdatedAt, op.Item.Value.Val.(string)); err != nil {
+				return fmt.Errorf("failed to push value to item with key %s: %v", op.Key, err)
 			}
-		case enums.DBCommandPop:
-			if item, exists := db.store[op.Key]; exists {
-				if err := item.popFromSlice(op.UpdatedAt); err != nil {
-					return fmt.Errorf("failed to pop value from item with key %s: %w", op.Key, err)
-				}
-				// update the ttl if it exists
-				if !op.Item.TTL.IsZero() {
-					item.TTL = op.Item.TTL
-				}
-			} else {
-				return fmt.Errorf("item with key %s not found for pop", op.Key)
+			// update the ttl if it exists
+			item.copyTTL(op.Item)
+		} else {
+			return fmt.Errorf("item with key %s not found for push", op.Key)
+		}
+	case enums.DBCommandPop:
+		if item, exists := db.store[op.Key]; exists {
+			if err := item.popFromSlice(op.UpdatedAt); err != nil {
+				return fmt.Errorf("failed to pop value from item with key %s: %w", op.Key, err)
 			}
-		default:
-			return fmt.Errorf("unknown command %s in operation log", op.Command)
+			// update the ttl if it exists
+			item.copyTTL(op.Item)
+		} else {
+			return fmt.Errorf("item with key %s not found for pop", op.Key)
+		}
+	case enums.DBCommandStreamAdd, enums.DBCommandStreamTrim, enums.DBCommandStreamGroupCreate,
+		enums.DBCommandStreamDeliver, enums.DBCommandStreamAck, enums.DBCommandStreamClaim:
+		if err := db.replayStreamOperation(op); err != nil {
+			return err
+		}
+	case enums.DBCommandUserSet:
+		if err := db.replayUser(op); err != nil {
+			return err
+		}
+	case enums.DBCommandAPIKeySet, enums.DBCommandAPIKeyRemove:
+		if err := db.replayAPIKey(op); err != nil {
+			return err
+		}
+	case enums.DBCommandNamespaceCreate, enums.DBCommandNamespaceDrop:
+		if err := db.replayNamespace(op); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown command %s in operation log", op.Command)
+	}
+
+	return nil
+}
+
+// isAuthCommand reports whether the command writes to the store of the authentication module instead of the items.
+func isAuthCommand(command enums.DBCommand) bool {
+	return command == enums.DBCommandUserSet || command == enums.DBCommandAPIKeySet || command == enums.DBCommandAPIKeyRemove
+}
+
+// isNamespaceCommand reports whether the command creates or drops a namespace.
+func isNamespaceCommand(command enums.DBCommand) bool {
+	return command == enums.DBCommandNamespaceCreate || command == enums.DBCommandNamespaceDrop
+}
+
+// replayStreamOperation applies a stream command of the operation log to the store.
+func (db *memoryDB) replayStreamOperation(op *Operation) error {
+	if op.StreamArgs == nil {
+		return fmt.Errorf("missing stream arguments for command %s on key %s", op.Command, op.Key)
+	}
+	args := op.StreamArgs
+
+	// the first entry of a stream is logged with the stream item, which replaces any expired value of the key
+	item, exists := db.store[op.Key]
+	if op.Command == enums.DBCommandStreamAdd && op.Item != nil && op.Item.Kind == StreamType {
+		item = newStreamItem(op.Item.CreatedAt)
+		item.copyTTL(op.Item)
+		db.store[op.Key] = item
+		exists = true
+	}
+	if !exists {
+		return fmt.Errorf("stream with key %s not found for %s", op.Key, op.Command)
+	}
+	if item.Kind != StreamType {
+		return fmt.Errorf("item with key %s is not a stream for %s", op.Key, op.Command)
+	}
+
+	switch op.Command {
+	case enums.DBCommandStreamAdd:
+		if _, err := item.Stream.add(args.ID, args.Fields); err != nil {
+			return fmt.Errorf("failed to add entry %s to stream with key %s: %w", args.ID, op.Key, err)
+		}
+		// update the ttl if it exists
+		item.copyTTL(op.Item)
+		item.UpdatedAt = op.Time
+	case enums.DBCommandStreamTrim:
+		item.Stream.trim(args.MaxLen, args.MinID)
+		item.UpdatedAt = op.Time
+	case enums.DBCommandStreamGroupCreate:
+		if err := item.Stream.createGroup(args.Group, args.ID); err != nil {
+			return fmt.Errorf("failed to create group %s in stream with key %s: %w", args.Group, op.Key, err)
+		}
+	default:
+		group, err := item.Stream.group(args.Group)
+		if err != nil {
+			return fmt.Errorf("failed to find group %s in stream with key %s: %w", args.Group, op.Key, err)
+		}
+		switch op.Command {
+		case enums.DBCommandStreamDeliver:
+			item.Stream.deliver(group, args.Consumer, args.IDs, op.Time)
+		case enums.DBCommandStreamAck:
+			item.Stream.ack(group, args.IDs)
+		case enums.DBCommandStreamClaim:
+			item.Stream.claim(group, args.Consumer, args.IDs, op.Time)
 		}
 	}
 
@@ -0,0 +1,473 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"memorydb/internal/enums"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReplicationBacklog    = 10000 // default number of operations kept to resume replication streams
+	replicationSubscriberBufSize = 1024  // number of operations buffered per replica before it is disconnected
+)
+
+// ReplicatedOperation is an operation of the log with its replication offset.
+//
+// Offsets are assigned in increasing order as the operations are logged, so a replica that reconnects can resume
+// from the last offset it applied. The operation is kept encoded, exactly as it was when it was logged.
+type ReplicatedOperation struct {
+	Offset uint64          `json:"offset"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Snapshot is a point-in-time copy of the store, taken at the given replication offset.
+type Snapshot struct {
+	Offset     uint64                        `json:"offset"`
+	Items      map[string]*Item              `json:"items"`
+	Users      map[string]*AuthItem          `json:"users,omitempty"`      // users of the authentication module, by username
+	APIKeys    map[string]*APIKeyItem        `json:"api_keys,omitempty"`   // API keys of the authentication module, by ID
+	Namespaces map[string]*NamespaceSnapshot `json:"namespaces,omitempty"` // namespaces with their keys, by name
+}
+
+// NamespaceSnapshot is the copy of a namespace in a snapshot.
+type NamespaceSnapshot struct {
+	Config NamespaceConfig  `json:"config"`
+	Items  map[string]*Item `json:"items"`
+}
+
+// replicationLog keeps the last logged operations and fans them out to the connected replicas.
+//
+// Like the event bus, it never blocks the write path: a replica that does not keep up is disconnected and
+// resumes from the last offset it applied, or does a full synchronization if that offset is no longer in the backlog.
+type replicationLog struct {
+	logger *slog.Logger
+	size   int // number of operations kept in the backlog, 0 disables the backlog
+
+	mu          sync.Mutex
+	offset      uint64                              // offset of the last logged operation
+	backlog     []ReplicatedOperation               // ring buffer with the last logged operations
+	subscribers map[uint64]chan ReplicatedOperation // connected replicas by subscription ID
+	nextSubID   uint64                              // ID of the next subscription
+	closed      bool                                // whether the log has been closed
+}
+
+// newReplicationLog creates a replication log that keeps the given number of operations.
+func newReplicationLog(logger *slog.Logger, size int) *replicationLog {
+	return &replicationLog{
+		logger:      logger,
+		size:        size,
+		subscribers: make(map[uint64]chan ReplicatedOperation),
+	}
+}
+
+// append encodes the operation, assigns it the next offset and delivers it to the replicas.
+func (l *replicationLog) append(op *Operation) {
+	data, err := json.Marshal(op)
+	if err != nil {
+		l.logger.Warn("failed to encode operation for replication", "key", op.Key, "command", op.Command, "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.offset++
+	replicated := ReplicatedOperation{Offset: l.offset, Data: data}
+	switch {
+	case l.size <= 0:
+		// without backlog the replicas must do a full synchronization every time they reconnect
+	case len(l.backlog) < l.size:
+		l.backlog = append(l.backlog, replicated)
+	default:
+		l.backlog[(l.offset-1)%uint64(l.size)] = replicated
+	}
+
+	for id, ch := range l.subscribers {
+		select {
+		case ch <- replicated:
+		default:
+			// the replica is too slow, disconnect it so it can resume from its last offset
+			l.logger.Warn("replica is too slow, disconnecting it", "subscription", id, "offset", l.offset)
+			delete(l.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns a channel with the operations after the given offset, starting with the ones in the backlog,
+// and a function that cancels the subscription. It returns ErrOffsetOutOfRange if some of the operations after
+// the offset are no longer in the backlog.
+func (l *replicationLog) subscribe(offset uint64) (<-chan ReplicatedOperation, func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	oldest := l.offset - uint64(len(l.backlog)) + 1
+	if offset > l.offset || offset+1 < oldest {
+		return nil, nil, ErrOffsetOutOfRange
+	}
+
+	missed := l.offset - offset
+	ch := make(chan ReplicatedOperation, replicationSubscriberBufSize+int(missed))
+	for o := offset + 1; o <= l.offset; o++ {
+		ch <- l.backlog[(o-1)%uint64(l.size)]
+	}
+
+	if l.closed {
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	l.nextSubID++
+	id := l.nextSubID
+	l.subscribers[id] = ch
+
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if sub, exists := l.subscribers[id]; exists {
+			delete(l.subscribers, id)
+			close(sub)
+		}
+	}
+	return ch, cancel, nil
+}
+
+// currentOffset returns the offset of the last logged operation.
+func (l *replicationLog) currentOffset() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.offset
+}
+
+// replicas returns the number of connected replicas.
+func (l *replicationLog) replicas() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.subscribers)
+}
+
+// close disconnects all the replicas.
+func (l *replicationLog) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	for id, ch := range l.subscribers {
+		delete(l.subscribers, id)
+		close(ch)
+	}
+}
+
+// WriteSnapshot writes a JSON encoded snapshot of the store to w and returns its replication offset.
+// The snapshot is encoded with the locks of the store and of its namespaces held, so it is consistent with the offset,
+// and written once the locks are released.
+func (db *memoryDB) WriteSnapshot(w io.Writer) (uint64, error) {
+	var buf bytes.Buffer
+
+	db.mu.Lock()
+	db.nsMu.RLock()
+	for _, ns := range db.namespaces {
+		ns.db.mu.Lock()
+	}
+	snapshot := Snapshot{
+		Offset:     db.replication.currentOffset(),
+		Items:      db.liveItems(),
+		Users:      db.users(),
+		APIKeys:    db.apiKeysByID(),
+		Namespaces: make(map[string]*NamespaceSnapshot, len(db.namespaces)),
+	}
+	for name, ns := range db.namespaces {
+		snapshot.Namespaces[name] = &NamespaceSnapshot{Config: ns.config, Items: ns.db.liveItems()}
+	}
+	err := json.NewEncoder(&buf).Encode(snapshot)
+	for _, ns := range db.namespaces {
+		ns.db.mu.Unlock()
+	}
+	db.nsMu.RUnlock()
+	db.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if _, err := buf.WriteTo(w); err != nil {
+		return 0, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return snapshot.Offset, nil
+}
+
+// liveItems returns the items of the store that have not expired. It must be called with the lock held.
+func (db *memoryDB) liveItems() map[string]*Item {
+	items := make(map[string]*Item, len(db.store))
+	for key, item := range db.store {
+		if !item.isExpired(db.now()) {
+			items[key] = item
+		}
+	}
+	return items
+}
+
+// LoadSnapshot replaces the content of the store with the JSON encoded snapshot read from r and returns
+// the replication offset of the snapshot.
+//
+// If persistence is enabled, the change is written to the operation log as the removal of the keys that are not
+// in the snapshot and the set of the ones that are, so the log keeps reflecting the content of the store.
+// The users of the snapshot are stored too, replacing the users with the same username. The API keys are replaced
+// as the keys, so the keys revoked in the primary are revoked in the replica. The namespaces are replaced too, and
+// each of them gets the keys of the snapshot as the store.
+func (db *memoryDB) LoadSnapshot(r io.Reader) (uint64, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return 0, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if err := validateSnapshotItems(snapshot.Items); err != nil {
+		return 0, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	for name, ns := range snapshot.Namespaces {
+		if !namespaceName.MatchString(name) || ns == nil {
+			return 0, fmt.Errorf("invalid snapshot: invalid namespace %q", name)
+		}
+		if err := validateSnapshotItems(ns.Items); err != nil {
+			return 0, fmt.Errorf("invalid snapshot: namespace %s: %w", name, err)
+		}
+	}
+	for username, user := range snapshot.Users {
+		if user == nil {
+			return 0, fmt.Errorf("invalid snapshot: user %s has no value", username)
+		}
+	}
+	for id, key := range snapshot.APIKeys {
+		if key == nil {
+			return 0, fmt.Errorf("invalid snapshot: API key %s has no value", id)
+		}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	defer db.startLoading()()
+
+	now := db.now()
+	db.replaceItems(snapshot.Items, now)
+
+	for _, user := range snapshot.Users {
+		op := &Operation{Command: enums.DBCommandUserSet, Time: now, User: user}
+		if err := db.replayUser(op); err != nil {
+			return 0, err
+		}
+		db.logOperation(op)
+	}
+
+	for id := range db.apiKeysByID() {
+		if _, exists := snapshot.APIKeys[id]; !exists {
+			op := &Operation{Command: enums.DBCommandAPIKeyRemove, Time: now, APIKey: &APIKeyItem{ID: id}}
+			if err := db.replayAPIKey(op); err != nil {
+				return 0, err
+			}
+			db.logOperation(op)
+		}
+	}
+	for _, key := range snapshot.APIKeys {
+		op := &Operation{Command: enums.DBCommandAPIKeySet, Time: now, APIKey: key}
+		if err := db.replayAPIKey(op); err != nil {
+			return 0, err
+		}
+		db.logOperation(op)
+	}
+
+	if err := db.loadNamespaces(snapshot.Namespaces, now); err != nil {
+		return 0, err
+	}
+
+	db.logger.Info("loaded snapshot", "offset", snapshot.Offset, "keys", len(db.store), "used_memory", db.usedMemory)
+	return snapshot.Offset, nil
+}
+
+// validateSnapshotItems checks that every item of a snapshot has a value.
+func validateSnapshotItems(items map[string]*Item) error {
+	for key, item := range items {
+		if item == nil || (item.Kind == StreamType && item.Stream == nil) {
+			return fmt.Errorf("key %s has no value", key)
+		}
+	}
+	return nil
+}
+
+// replaceItems replaces the items of the store with the ones of a snapshot. The change is logged as the removal of
+// the keys that are not in the snapshot and the set of the ones that are. It must be called with the lock held.
+func (db *memoryDB) replaceItems(items map[string]*Item, now time.Time) {
+	for key := range db.store {
+		if _, exists := items[key]; !exists {
+			db.logOperation(&Operation{Command: enums.DBCommandRemove, Key: key, Time: now})
+		}
+	}
+
+	db.store = make(map[string]*Item, len(items))
+	db.usedMemory = 0
+	for key, item := range items {
+		item.lastAccess = now
+		db.storeItem(key, item)
+		db.logOperation(&Operation{Command: enums.DBCommandSet, Key: key, Time: now, Item: item})
+	}
+}
+
+// loadNamespaces replaces the namespaces with the ones of a snapshot. The namespaces that are not in the snapshot,
+// or that have other settings, are dropped, and the others get the keys of the snapshot. It must be called with
+// the lock of the items held.
+func (db *memoryDB) loadNamespaces(namespaces map[string]*NamespaceSnapshot, now time.Time) error {
+	for _, ns := range db.Namespaces() {
+		loaded, exists := namespaces[ns.Name]
+		if !exists || db.replicatedNamespaceConfig(loaded.Config) != ns.Config {
+			if err := db.dropNamespace(ns.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, ns := range namespaces {
+		if _, err := db.Namespace(name); errors.Is(err, ErrNamespaceNotFound) {
+			if err := db.createNamespace(name, db.replicatedNamespaceConfig(ns.Config)); err != nil {
+				return err
+			}
+		}
+		client, err := db.Namespace(name)
+		if err != nil {
+			return err
+		}
+		child := client.(*memoryDB)
+		child.mu.Lock()
+		child.replaceItems(ns.Items, now)
+		child.mu.Unlock()
+	}
+	return nil
+}
+
+// replicatedNamespaceConfig returns the settings of a namespace replicated from the primary. The namespace is only
+// persisted if the replica has persistence enabled.
+func (db *memoryDB) replicatedNamespaceConfig(config NamespaceConfig) NamespaceConfig {
+	config.Persistence = config.Persistence && db.persistenceEnabled
+	return config
+}
+
+// ReplicationFeed returns a channel with the operations logged after the given offset and a function that cancels
+// the feed. The channel is closed when the feed is cancelled, when the replica does not keep up or when the database
+// is closed. It returns ErrOffsetOutOfRange if the operations after the offset are no longer available, in which
+// case the replica must load a new snapshot.
+func (db *memoryDB) ReplicationFeed(offset uint64) (<-chan ReplicatedOperation, func(), error) {
+	return db.replication.subscribe(offset)
+}
+
+// ApplyReplicated applies an operation received from the primary to the store.
+//
+// The operation is logged as if it was executed locally, so it is persisted and forwarded to the replicas of this
+// database, and the corresponding keyspace event is published. It is applied even if the database is read-only.
+func (db *memoryDB) ApplyReplicated(op ReplicatedOperation) error {
+	var operation Operation
+	if err := json.Unmarshal(op.Data, &operation); err != nil {
+		return fmt.Errorf("failed to decode replicated operation %d: %w", op.Offset, err)
+	}
+	if err := db.applyReplicated(&operation); err != nil {
+		return fmt.Errorf("failed to apply replicated operation %d: %w", op.Offset, err)
+	}
+	return nil
+}
+
+// applyReplicated applies a decoded replicated operation. The operations of the keys of a namespace are applied
+// to the database of the namespace.
+func (db *memoryDB) applyReplicated(operation *Operation) error {
+	if operation.KeyNamespace != "" {
+		client, err := db.Namespace(operation.KeyNamespace)
+		if err != nil {
+			// the namespace was dropped on the primary while the operation was being written
+			db.logger.Debug("skipping replicated operation of missing namespace", "namespace", operation.KeyNamespace, "command", operation.Command)
+			return nil
+		}
+		applied := *operation
+		applied.KeyNamespace = ""
+		return client.(*memoryDB).applyReplicated(&applied)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if isNamespaceCommand(operation.Command) {
+		return db.applyNamespace(operation)
+	}
+
+	// the users and the API keys are not items, so they are not accounted in the memory of the items
+	if isAuthCommand(operation.Command) {
+		if err := db.replayOperation(operation); err != nil {
+			return err
+		}
+		db.logOperation(operation)
+		return nil
+	}
+
+	var sizeBefore int64
+	if item, exists := db.store[operation.Key]; exists {
+		sizeBefore = entrySize(operation.Key, item)
+	}
+
+	// the key may have expired and been cleaned up on the replica before the primary removed it
+	if _, exists := db.store[operation.Key]; !exists && operation.Command == enums.DBCommandRemove {
+		return nil
+	}
+
+	if err := db.replayOperation(operation); err != nil {
+		return err
+	}
+
+	// the operation may have replaced or removed the item, so the memory is accounted from scratch for the key
+	db.usedMemory -= sizeBefore
+	if item, exists := db.store[operation.Key]; exists {
+		item.lastAccess = db.now()
+		db.usedMemory += entrySize(operation.Key, item)
+	}
+
+	db.logOperation(operation)
+	if event, ok := replicatedEvents[operation.Command]; ok {
+		db.events.publish(event, operation.Key)
+	}
+	return nil
+}
+
+// applyNamespace creates or drops a namespace replicated from the primary. A namespace created on the primary
+// replaces the one with the same name on the replica, if any. It must be called with the lock of the items held.
+func (db *memoryDB) applyNamespace(operation *Operation) error {
+	if operation.Namespace == nil {
+		return fmt.Errorf("missing namespace for command %s", operation.Command)
+	}
+
+	name := operation.Namespace.Name
+	if !namespaceName.MatchString(name) {
+		return ErrInvalidNamespace
+	}
+	if err := db.dropNamespace(name); err != nil && !errors.Is(err, ErrNamespaceNotFound) {
+		return err
+	}
+	if operation.Command == enums.DBCommandNamespaceDrop {
+		return nil
+	}
+	return db.createNamespace(name, db.replicatedNamespaceConfig(operation.Namespace.Config))
+}
+
+// SetReadOnly sets whether the database rejects writes, which is the case of replicas.
+func (db *memoryDB) SetReadOnly(readOnly bool) {
+	db.readOnly.Store(readOnly)
+	db.setNamespacesReadOnly(readOnly)
+}
+
+// replicatedEvents maps the commands applied on a replica to the keyspace events they publish.
+var replicatedEvents = map[enums.DBCommand]enums.KeyspaceEvent{
+	enums.DBCommandSet:        enums.KeyspaceEventSet,
+	enums.DBCommandUpdate:     enums.KeyspaceEventUpdate,
+	enums.DBCommandRemove:     enums.KeyspaceEventRemove,
+	enums.DBCommandPush:       enums.KeyspaceEventPush,
+	enums.DBCommandPop:        enums.KeyspaceEventPop,
+	enums.DBCommandStreamAdd:  enums.KeyspaceEventStreamAdd,
+	enums.DBCommandStreamTrim: enums.KeyspaceEventStreamTrim,
+}
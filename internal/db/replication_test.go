@@ -0,0 +1,227 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplicationSuite struct {
+	suite.Suite
+	primary *memoryDB
+	replica *memoryDB
+}
+
+func (s *ReplicationSuite) SetupTest() {
+	s.primary = NewMemoryDB(slog.Default(), WithReplicationBacklog(3)).(*memoryDB)
+	s.replica = NewMemoryDB(slog.Default()).(*memoryDB)
+}
+
+func (s *ReplicationSuite) TearDownTest() {
+	s.primary.Close()
+	s.replica.Close()
+}
+
+// receive reads an operation from the feed or fails the test after a timeout.
+func (s *ReplicationSuite) receive(feed <-chan ReplicatedOperation) ReplicatedOperation {
+	select {
+	case op, ok := <-feed:
+		s.Require().True(ok, "feed closed unexpectedly")
+		return op
+	case <-time.After(time.Second):
+		s.FailNow("timeout waiting for operation")
+	}
+	return ReplicatedOperation{}
+}
+
+func (s *ReplicationSuite) TestFeed() {
+	s.Require().NoError(s.primary.Set("key1", "value1"))
+	s.Equal(uint64(1), s.primary.Stats().ReplicationOffset)
+
+	feed, cancel, err := s.primary.ReplicationFeed(0)
+	s.Require().NoError(err)
+	defer cancel()
+	s.Equal(1, s.primary.Stats().Replicas)
+
+	// the operations in the backlog are delivered first, then the new ones
+	s.Equal(uint64(1), s.receive(feed).Offset)
+	s.Require().NoError(s.primary.Remove("key1"))
+	s.Equal(uint64(2), s.receive(feed).Offset)
+
+	cancel()
+	_, ok := <-feed
+	s.False(ok, "feed should be closed after cancelling it")
+	s.Equal(0, s.primary.Stats().Replicas)
+}
+
+func (s *ReplicationSuite) TestFeedOffsetOutOfRange() {
+	for range 5 {
+		s.Require().NoError(s.primary.Set("key", "value"))
+	}
+
+	// only the last 3 operations are kept
+	_, _, err := s.primary.ReplicationFeed(1)
+	s.ErrorIs(err, ErrOffsetOutOfRange)
+	_, _, err = s.primary.ReplicationFeed(6)
+	s.ErrorIs(err, ErrOffsetOutOfRange, "offsets ahead of the primary should be rejected")
+
+	feed, cancel, err := s.primary.ReplicationFeed(2)
+	s.Require().NoError(err)
+	defer cancel()
+	s.Equal(uint64(3), s.receive(feed).Offset)
+}
+
+func (s *ReplicationSuite) TestSnapshot() {
+	s.Require().NoError(s.primary.Set("string", "value"))
+	s.Require().NoError(s.primary.Set("list", []string{"a", "b"}))
+	_, err := s.primary.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.replica.Set("stale", "value"))
+
+	var buf bytes.Buffer
+	offset, err := s.primary.WriteSnapshot(&buf)
+	s.Require().NoError(err)
+	s.Equal(uint64(3), offset)
+
+	loaded, err := s.replica.LoadSnapshot(&buf)
+	s.Require().NoError(err)
+	s.Equal(offset, loaded)
+
+	_, err = s.replica.Get("stale")
+	s.Error(err, "keys missing from the snapshot should be removed")
+	item, err := s.replica.Get("list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+	entries, err := s.replica.StreamRange("stream", "-", "+", 0)
+	s.Require().NoError(err)
+	s.Len(entries, 1)
+	s.Equal(s.primary.Stats().UsedMemory, s.replica.Stats().UsedMemory)
+}
+
+func (s *ReplicationSuite) TestApplyReplicated() {
+	feed, cancel, err := s.primary.ReplicationFeed(0)
+	s.Require().NoError(err)
+	defer cancel()
+
+	s.Require().NoError(s.primary.Set("list", []string{"a"}))
+	_, err = s.primary.Push("list", "b")
+	s.Require().NoError(err)
+	_, err = s.primary.StreamAdd("stream", map[string]string{"field": "value"})
+	s.Require().NoError(err)
+	s.Require().NoError(s.primary.StreamGroupCreate("stream", "workers", "0"))
+	_, err = s.primary.StreamReadGroup(context.Background(), "stream", "workers", "alice", 0, 0)
+	s.Require().NoError(err)
+	s.Require().NoError(s.primary.Set("removed", "value"))
+	s.Require().NoError(s.primary.Remove("removed"))
+
+	s.replica.SetReadOnly(true)
+	for i := 0; i < 7; i++ {
+		s.Require().NoError(s.replica.ApplyReplicated(s.receive(feed)), "replicated operations should be applied on read-only databases")
+	}
+
+	item, err := s.replica.Get("list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value.Val)
+	_, err = s.replica.Get("removed")
+	s.Error(err)
+	pending, err := s.replica.StreamPending("stream", "workers", "alice")
+	s.Require().NoError(err)
+	s.Len(pending, 1)
+	s.Equal(s.primary.Stats().UsedMemory, s.replica.Stats().UsedMemory)
+
+	// the applied operations are logged, so the replica can feed its own replicas
+	s.Equal(uint64(7), s.replica.Stats().ReplicationOffset)
+}
+
+func (s *ReplicationSuite) TestApplyReplicatedNamespaces() {
+	feed, cancel, err := s.primary.ReplicationFeed(0)
+	s.Require().NoError(err)
+	defer cancel()
+
+	s.Require().NoError(s.primary.CreateNamespace("billing", NamespaceConfig{DefaultTTL: time.Hour}))
+	billing, err := s.primary.Namespace("billing")
+	s.Require().NoError(err)
+	s.Require().NoError(billing.Set("key", "billing"))
+	s.Require().NoError(s.primary.Set("key", "default"))
+
+	s.replica.SetReadOnly(true)
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(s.replica.ApplyReplicated(s.receive(feed)))
+	}
+
+	s.Equal(s.primary.Namespaces(), s.replica.Namespaces())
+	replicated, err := s.replica.Namespace("billing")
+	s.Require().NoError(err)
+	item, err := replicated.Get("key")
+	s.Require().NoError(err)
+	s.Equal("billing", item.Value.Val, "the keys of the namespace should be applied to the namespace")
+	item, err = s.replica.Get("key")
+	s.Require().NoError(err)
+	s.Equal("default", item.Value.Val)
+	s.ErrorIs(replicated.Set("other", "value"), ErrReadOnly, "the namespaces of a replica should be read-only")
+	s.Equal(uint64(3), s.replica.Stats().ReplicationOffset, "the keys of the namespaces should be logged by the parent")
+
+	s.Require().NoError(s.primary.DropNamespace("billing"))
+	s.Require().NoError(s.replica.ApplyReplicated(s.receive(feed)))
+	s.Empty(s.replica.Namespaces())
+}
+
+func (s *ReplicationSuite) TestSnapshotNamespaces() {
+	s.Require().NoError(s.primary.CreateNamespace("billing", NamespaceConfig{}))
+	billing, err := s.primary.Namespace("billing")
+	s.Require().NoError(err)
+	s.Require().NoError(billing.Set("key", "billing"))
+
+	// the namespaces of the replica that are not in the primary, or have other settings, are replaced
+	s.Require().NoError(s.replica.CreateNamespace("stale", NamespaceConfig{}))
+	s.Require().NoError(s.replica.CreateNamespace("billing", NamespaceConfig{MaxMemory: 1024}))
+	stale, err := s.replica.Namespace("billing")
+	s.Require().NoError(err)
+	s.Require().NoError(stale.Set("stale", "value"))
+
+	var buf bytes.Buffer
+	_, err = s.primary.WriteSnapshot(&buf)
+	s.Require().NoError(err)
+	_, err = s.replica.LoadSnapshot(&buf)
+	s.Require().NoError(err)
+
+	s.Equal(s.primary.Namespaces(), s.replica.Namespaces())
+	loaded, err := s.replica.Namespace("billing")
+	s.Require().NoError(err)
+	item, err := loaded.Get("key")
+	s.Require().NoError(err)
+	s.Equal("billing", item.Value.Val)
+	_, err = loaded.Get("stale")
+	s.Error(err, "keys missing from the snapshot should be removed from the namespace")
+}
+
+func (s *ReplicationSuite) TestReadOnly() {
+	s.Require().NoError(s.primary.Set("key", "value"))
+	s.primary.SetReadOnly(true)
+	s.True(s.primary.Stats().ReadOnly)
+
+	s.ErrorIs(s.primary.Set("key", "other"), ErrReadOnly)
+	s.ErrorIs(s.primary.Update("key", "other"), ErrReadOnly)
+	s.ErrorIs(s.primary.Remove("key"), ErrReadOnly)
+	_, err := s.primary.Push("key", "other")
+	s.ErrorIs(err, ErrReadOnly)
+	_, err = s.primary.StreamAdd("stream", map[string]string{"field": "value"})
+	s.ErrorIs(err, ErrReadOnly)
+
+	// reads are still served
+	item, err := s.primary.Get("key")
+	s.Require().NoError(err)
+	s.Equal("value", item.Value.Val)
+
+	s.primary.SetReadOnly(false)
+	s.NoError(s.primary.Set("key", "other"))
+}
+
+func TestReplicationSuite(t *testing.T) {
+	suite.Run(t, new(ReplicationSuite))
+}
@@ -0,0 +1,66 @@
+package db
+
+import "memorydb/internal/enums"
+
+// Stats is a snapshot of the counters of the database.
+type Stats struct {
+	Keys           int                  `json:"keys"`            // number of keys in the store, including expired keys not cleaned up yet
+	UsedMemory     int64                `json:"used_memory"`     // approximate number of bytes used by the store
+	MaxMemory      int64                `json:"max_memory"`      // memory limit in bytes, 0 if there is no limit
+	EvictionPolicy enums.EvictionPolicy `json:"eviction_policy"` // policy applied when the memory limit is reached
+	EvictedKeys    uint64               `json:"evicted_keys"`    // number of keys evicted since the database started
+	Namespaces     int                  `json:"namespaces"`      // number of namespaces, whose keys are not counted
+
+	ReplicationOffset uint64 `json:"replication_offset"` // offset of the last operation logged for replication
+	Replicas          int    `json:"replicas"`           // number of replicas streaming the operations
+	ReadOnly          bool   `json:"read_only"`          // whether writes are rejected, which is the case of replicas
+}
+
+// Stats returns a snapshot of the counters of the memory database.
+func (db *memoryDB) Stats() Stats {
+	db.nsMu.RLock()
+	namespaces := len(db.namespaces)
+	db.nsMu.RUnlock()
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return Stats{
+		Keys:           len(db.store),
+		UsedMemory:     db.usedMemory,
+		MaxMemory:      db.maxMemory,
+		EvictionPolicy: db.evictionPolicy,
+		EvictedKeys:    db.evictedKeys,
+		Namespaces:     namespaces,
+
+		ReplicationOffset: db.replication.currentOffset(),
+		Replicas:          db.replication.replicas(),
+		ReadOnly:          db.readOnly.Load(),
+	}
+}
+
+// keysByType returns the number of keys of the store and of the namespaces by the name of their data type,
+// including the expired keys not cleaned up yet.
+func (db *memoryDB) keysByType() map[string]int {
+	db.nsMu.RLock()
+	databases := make([]*memoryDB, 0, len(db.namespaces)+1)
+	for _, ns := range db.namespaces {
+		if ns.db != nil {
+			databases = append(databases, ns.db)
+		}
+	}
+	db.nsMu.RUnlock()
+
+	counts := make(map[string]int, len(MappingDataType))
+	for _, name := range MappingDataType {
+		counts[name] = 0
+	}
+	for _, database := range append(databases, db) {
+		database.mu.RLock()
+		for _, item := range database.store {
+			counts[MappingDataType[item.Kind]]++
+		}
+		database.mu.RUnlock()
+	}
+	return counts
+}
@@ -0,0 +1,369 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// streamEntryOverhead is the approximate number of bytes taken by a stream entry besides its fields.
+	streamEntryOverhead = 64
+)
+
+// StreamID identifies an entry of a stream. It is made of the time the entry was added, in milliseconds,
+// and a sequence number that orders the entries added in the same millisecond. It is represented as "<ms>-<seq>".
+type StreamID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+var (
+	minStreamID = StreamID{Ms: 0, Seq: 0}
+	maxStreamID = StreamID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+)
+
+// ParseStreamID parses an ID in the form "<ms>-<seq>" or "<ms>". The special IDs "-" and "+" are the smallest
+// and the greatest possible IDs. If the sequence number is omitted, defaultSeq is used, so "<ms>" can be used
+// as the start of a range with 0 or as its end with math.MaxUint64.
+func ParseStreamID(raw string, defaultSeq uint64) (StreamID, error) {
+	switch raw {
+	case "-":
+		return minStreamID, nil
+	case "+":
+		return maxStreamID, nil
+	}
+
+	msPart, seqPart, hasSeq := strings.Cut(raw, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	if !hasSeq {
+		return StreamID{Ms: ms, Seq: defaultSeq}, nil
+	}
+
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return StreamID{}, ErrInvalidStreamID
+	}
+	return StreamID{Ms: ms, Seq: seq}, nil
+}
+
+// String returns the representation of the ID in the form "<ms>-<seq>".
+func (id StreamID) String() string {
+	return strconv.FormatUint(id.Ms, 10) + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+// IsZero reports whether the ID is the zero ID "0-0".
+func (id StreamID) IsZero() bool {
+	return id == minStreamID
+}
+
+// Less reports whether the ID is smaller than other.
+func (id StreamID) Less(other StreamID) bool {
+	if id.Ms != other.Ms {
+		return id.Ms < other.Ms
+	}
+	return id.Seq < other.Seq
+}
+
+// MarshalJSON implements the json.Marshaler interface for StreamID, writing it as a string.
+func (id StreamID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for StreamID.
+func (id *StreamID) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	parsed, err := ParseStreamID(raw, 0)
+	if err != nil {
+		return fmt.Errorf("invalid stream ID '%s': %w", raw, err)
+	}
+	*id = parsed
+	return nil
+}
+
+// StreamEntry is a single entry of a stream: an ID and a map of fields.
+type StreamEntry struct {
+	ID     StreamID          `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// size returns the approximate number of bytes used by the entry.
+func (e StreamEntry) size() int64 {
+	size := int64(streamEntryOverhead)
+	for field, value := range e.Fields {
+		size += int64(len(field)+len(value)) + sliceElemOverhead
+	}
+	return size
+}
+
+// PendingEntry is an entry that has been delivered to a consumer of a group but not acknowledged yet.
+type PendingEntry struct {
+	ID            StreamID  `json:"id"`
+	Consumer      string    `json:"consumer"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+	DeliveryCount int       `json:"delivery_count"`
+}
+
+// ConsumerGroup tracks which entries of a stream have been delivered to its consumers.
+type ConsumerGroup struct {
+	LastDeliveredID StreamID                   `json:"last_delivered_id"`
+	Pending         map[StreamID]*PendingEntry `json:"-"`
+}
+
+// consumerGroupJSON is the JSON representation of a ConsumerGroup, with the pending entries as a list
+// because StreamID cannot be used as a JSON object key.
+type consumerGroupJSON struct {
+	LastDeliveredID StreamID       `json:"last_delivered_id"`
+	Pending         []PendingEntry `json:"pending"`
+}
+
+// MarshalJSON implements the json.Marshaler interface for ConsumerGroup.
+func (g *ConsumerGroup) MarshalJSON() ([]byte, error) {
+	return json.Marshal(consumerGroupJSON{LastDeliveredID: g.LastDeliveredID, Pending: g.pendingEntries("")})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for ConsumerGroup.
+func (g *ConsumerGroup) UnmarshalJSON(data []byte) error {
+	var aux consumerGroupJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	g.LastDeliveredID = aux.LastDeliveredID
+	g.Pending = make(map[StreamID]*PendingEntry, len(aux.Pending))
+	for i := range aux.Pending {
+		g.Pending[aux.Pending[i].ID] = &aux.Pending[i]
+	}
+	return nil
+}
+
+// pendingEntries returns the pending entries of the consumer sorted by ID, or of every consumer if it is empty.
+func (g *ConsumerGroup) pendingEntries(consumer string) []PendingEntry {
+	entries := make([]PendingEntry, 0, len(g.Pending))
+	for _, pending := range g.Pending {
+		if consumer == "" || pending.Consumer == consumer {
+			entries = append(entries, *pending)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID.Less(entries[j].ID) })
+	return entries
+}
+
+// Stream is an append-only log of entries ordered by ID, with optional consumer groups.
+type Stream struct {
+	Entries []StreamEntry             `json:"entries"`
+	LastID  StreamID                  `json:"last_id"`
+	Groups  map[string]*ConsumerGroup `json:"groups,omitempty"`
+
+	size int64 // approximate number of bytes used by the entries
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Stream, rebuilding the fields that are not serialized.
+func (s *Stream) UnmarshalJSON(data []byte) error {
+	type alias Stream
+	aux := (*alias)(s)
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	if s.Groups == nil {
+		s.Groups = make(map[string]*ConsumerGroup)
+	}
+	s.size = 0
+	for _, entry := range s.Entries {
+		s.size += entry.size()
+	}
+	return nil
+}
+
+// snapshot returns a copy of the stream that is not changed by later commands. The entries are never modified
+// once added, and trimming replaces the slice, so they are shared with the stream. The groups are copied.
+func (s *Stream) snapshot() *Stream {
+	groups := make(map[string]*ConsumerGroup, len(s.Groups))
+	for name, group := range s.Groups {
+		pending := make(map[StreamID]*PendingEntry, len(group.Pending))
+		for id, entry := range group.Pending {
+			copied := *entry
+			pending[id] = &copied
+		}
+		groups[name] = &ConsumerGroup{LastDeliveredID: group.LastDeliveredID, Pending: pending}
+	}
+	return &Stream{Entries: s.Entries[:len(s.Entries):len(s.Entries)], LastID: s.LastID, Groups: groups, size: s.size}
+}
+
+// newStream creates an empty stream.
+func newStream() *Stream {
+	return &Stream{Groups: make(map[string]*ConsumerGroup)}
+}
+
+// nextID returns the ID for an entry added at the given time. IDs are always increasing, even if the clock goes back.
+func (s *Stream) nextID(now time.Time) StreamID {
+	ms := uint64(now.UnixMilli())
+	if ms <= s.LastID.Ms {
+		return StreamID{Ms: s.LastID.Ms, Seq: s.LastID.Seq + 1}
+	}
+	return StreamID{Ms: ms, Seq: 0}
+}
+
+// add appends an entry to the stream. The ID must be greater than the last ID of the stream.
+func (s *Stream) add(id StreamID, fields map[string]string) (StreamEntry, error) {
+	if !s.LastID.Less(id) {
+		return StreamEntry{}, ErrInvalidStreamID
+	}
+
+	entry := StreamEntry{ID: id, Fields: fields}
+	s.Entries = append(s.Entries, entry)
+	s.LastID = id
+	s.size += entry.size()
+	return entry, nil
+}
+
+// indexFrom returns the index of the first entry with an ID greater than or equal to id.
+func (s *Stream) indexFrom(id StreamID) int {
+	return sort.Search(len(s.Entries), func(i int) bool { return !s.Entries[i].ID.Less(id) })
+}
+
+// rangeEntries returns up to count entries with IDs between start and end, both included. A count of 0 means no limit.
+func (s *Stream) rangeEntries(start, end StreamID, count int) []StreamEntry {
+	entries := []StreamEntry{}
+	for i := s.indexFrom(start); i < len(s.Entries) && !end.Less(s.Entries[i].ID); i++ {
+		if count > 0 && len(entries) >= count {
+			break
+		}
+		entries = append(entries, s.Entries[i])
+	}
+	return entries
+}
+
+// entriesAfter returns up to count entries with IDs strictly greater than id. A count of 0 means no limit.
+func (s *Stream) entriesAfter(id StreamID, count int) []StreamEntry {
+	if id == maxStreamID {
+		return []StreamEntry{}
+	}
+	next := StreamID{Ms: id.Ms, Seq: id.Seq + 1}
+	if id.Seq == math.MaxUint64 {
+		next = StreamID{Ms: id.Ms + 1, Seq: 0}
+	}
+	return s.rangeEntries(next, maxStreamID, count)
+}
+
+// entry returns the entry with the given ID, if it is still in the stream.
+func (s *Stream) entry(id StreamID) (StreamEntry, bool) {
+	i := s.indexFrom(id)
+	if i < len(s.Entries) && s.Entries[i].ID == id {
+		return s.Entries[i], true
+	}
+	return StreamEntry{}, false
+}
+
+// trim removes the oldest entries so the stream has at most maxLen entries and no entry older than minID.
+// A maxLen of 0 or a zero minID disables the corresponding criterion. It returns the removed entries.
+func (s *Stream) trim(maxLen int, minID StreamID) []StreamEntry {
+	cut := 0
+	if maxLen > 0 && len(s.Entries) > maxLen {
+		cut = len(s.Entries) - maxLen
+	}
+	if !minID.IsZero() {
+		if i := s.indexFrom(minID); i > cut {
+			cut = i
+		}
+	}
+	if cut == 0 {
+		return nil
+	}
+
+	removed := make([]StreamEntry, cut)
+	copy(removed, s.Entries[:cut])
+	for _, entry := range removed {
+		s.size -= entry.size()
+	}
+
+	// copy the remaining entries so the memory of the removed ones can be released
+	s.Entries = append([]StreamEntry(nil), s.Entries[cut:]...)
+	return removed
+}
+
+// createGroup creates a consumer group that delivers the entries after lastDeliveredID.
+func (s *Stream) createGroup(name string, lastDeliveredID StreamID) error {
+	if _, exists := s.Groups[name]; exists {
+		return ErrStreamGroupExists
+	}
+	s.Groups[name] = &ConsumerGroup{LastDeliveredID: lastDeliveredID, Pending: make(map[StreamID]*PendingEntry)}
+	return nil
+}
+
+// group returns the consumer group with the given name.
+func (s *Stream) group(name string) (*ConsumerGroup, error) {
+	group, exists := s.Groups[name]
+	if !exists {
+		return nil, ErrStreamGroupNotFound
+	}
+	return group, nil
+}
+
+// deliver records that the entries have been delivered to the consumer of the group, adding them to its pending list.
+func (s *Stream) deliver(group *ConsumerGroup, consumer string, ids []StreamID, deliveredAt time.Time) {
+	for _, id := range ids {
+		group.Pending[id] = &PendingEntry{ID: id, Consumer: consumer, DeliveredAt: deliveredAt, DeliveryCount: 1}
+		if group.LastDeliveredID.Less(id) {
+			group.LastDeliveredID = id
+		}
+	}
+}
+
+// ack removes the entries from the pending list of the group and returns how many were pending.
+func (s *Stream) ack(group *ConsumerGroup, ids []StreamID) int {
+	acked := 0
+	for _, id := range ids {
+		if _, exists := group.Pending[id]; exists {
+			delete(group.Pending, id)
+			acked++
+		}
+	}
+	return acked
+}
+
+// claimable returns the IDs of the given pending entries that have been idle for at least minIdle.
+func (s *Stream) claimable(group *ConsumerGroup, ids []StreamID, minIdle time.Duration, now time.Time) []StreamID {
+	claimable := make([]StreamID, 0, len(ids))
+	for _, id := range ids {
+		pending, exists := group.Pending[id]
+		if exists && now.Sub(pending.DeliveredAt) >= minIdle {
+			claimable = append(claimable, id)
+		}
+	}
+	return claimable
+}
+
+// claim transfers the pending entries to the consumer and returns the entries that are still in the stream.
+// Pending entries whose entry has been trimmed are removed from the pending list.
+func (s *Stream) claim(group *ConsumerGroup, consumer string, ids []StreamID, claimedAt time.Time) []StreamEntry {
+	entries := make([]StreamEntry, 0, len(ids))
+	for _, id := range ids {
+		pending, exists := group.Pending[id]
+		if !exists {
+			continue
+		}
+
+		entry, ok := s.entry(id)
+		if !ok {
+			delete(group.Pending, id)
+			continue
+		}
+
+		pending.Consumer = consumer
+		pending.DeliveredAt = claimedAt
+		pending.DeliveryCount++
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,341 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StreamSuite struct {
+	suite.Suite
+	db *memoryDB
+}
+
+func (s *StreamSuite) SetupTest() {
+	s.db = NewMemoryDB(slog.Default()).(*memoryDB)
+}
+
+func (s *StreamSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// add appends n entries to the stream and returns their IDs.
+func (s *StreamSuite) add(key string, n int) []StreamID {
+	ids := make([]StreamID, n)
+	for i := range ids {
+		id, err := s.db.StreamAdd(key, map[string]string{"n": string(rune('a' + i))})
+		s.Require().NoError(err)
+		ids[i] = id
+	}
+	return ids
+}
+
+func (s *StreamSuite) TestParseStreamID() {
+	values := []struct {
+		in            string
+		defaultSeq    uint64
+		out           StreamID
+		expectedError bool
+	}{
+		{"1-2", 0, StreamID{Ms: 1, Seq: 2}, false},
+		{"5", 0, StreamID{Ms: 5, Seq: 0}, false},
+		{"5", 9, StreamID{Ms: 5, Seq: 9}, false},
+		{"-", 0, minStreamID, false},
+		{"+", 0, maxStreamID, false},
+		{"abc", 0, StreamID{}, true},
+		{"1-x", 0, StreamID{}, true},
+	}
+
+	for _, v := range values {
+		id, err := ParseStreamID(v.in, v.defaultSeq)
+		if v.expectedError {
+			s.Error(err, "expected error for ID: %s", v.in)
+		} else {
+			s.NoError(err)
+			s.Equal(v.out, id)
+		}
+	}
+}
+
+func (s *StreamSuite) TestAddAndRange() {
+	ids := s.add("orders", 3)
+	s.True(ids[0].Less(ids[1]) && ids[1].Less(ids[2]), "IDs must be increasing")
+
+	item, err := s.db.Get("orders")
+	s.Require().NoError(err)
+	s.Equal(StreamType, item.Kind)
+
+	s.Run("ok - full range", func() {
+		entries, err := s.db.StreamRange("orders", "-", "+", 0)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 3)
+		s.Equal(ids[0], entries[0].ID)
+		s.Equal(map[string]string{"n": "a"}, entries[0].Fields)
+	})
+
+	s.Run("ok - bounded range with count", func() {
+		entries, err := s.db.StreamRange("orders", ids[1].String(), "+", 1)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal(ids[1], entries[0].ID)
+	})
+
+	s.Run("error - invalid ID", func() {
+		_, err := s.db.StreamRange("orders", "bad", "+", 0)
+		s.ErrorIs(err, ErrInvalidStreamID)
+	})
+
+	s.Run("error - not found", func() {
+		_, err := s.db.StreamRange("missing", "-", "+", 0)
+		s.ErrorIs(err, ErrDataNotFound)
+	})
+
+	s.Run("error - not a stream", func() {
+		s.Require().NoError(s.db.Set("str", "value"))
+		_, err := s.db.StreamAdd("str", map[string]string{"a": "b"})
+		s.ErrorIs(err, ErrNotAStream)
+		_, err = s.db.StreamRange("str", "-", "+", 0)
+		s.ErrorIs(err, ErrNotAStream)
+	})
+
+	s.Run("error - empty fields", func() {
+		_, err := s.db.StreamAdd("orders", nil)
+		s.ErrorIs(err, ErrInvalidDataType)
+	})
+}
+
+func (s *StreamSuite) TestRead() {
+	ids := s.add("orders", 2)
+
+	s.Run("ok - entries after ID", func() {
+		entries, err := s.db.StreamRead(context.Background(), "orders", ids[0].String(), 0, 0)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal(ids[1], entries[0].ID)
+	})
+
+	s.Run("ok - block until an entry is added", func() {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = s.db.StreamAdd("orders", map[string]string{"n": "new"})
+		}()
+
+		entries, err := s.db.StreamRead(context.Background(), "orders", "$", 0, time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal("new", entries[0].Fields["n"])
+	})
+
+	s.Run("ok - block until the stream is created", func() {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = s.db.StreamAdd("created", map[string]string{"n": "first"})
+		}()
+
+		entries, err := s.db.StreamRead(context.Background(), "created", "$", 0, time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal("first", entries[0].Fields["n"])
+	})
+
+	s.Run("ok - block timeout", func() {
+		entries, err := s.db.StreamRead(context.Background(), "orders", "$", 0, 50*time.Millisecond)
+		s.Require().NoError(err)
+		s.Empty(entries)
+	})
+
+	s.Run("error - context cancelled", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := s.db.StreamRead(ctx, "orders", "$", 0, time.Minute)
+		s.ErrorIs(err, context.DeadlineExceeded)
+	})
+}
+
+func (s *StreamSuite) TestTrim() {
+	s.Run("ok - max length", func() {
+		ids := s.add("bylen", 5)
+		removed, err := s.db.StreamTrim("bylen", 2, 0)
+		s.Require().NoError(err)
+		s.Equal(3, removed)
+
+		entries, err := s.db.StreamRange("bylen", "-", "+", 0)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 2)
+		s.Equal(ids[3], entries[0].ID)
+	})
+
+	s.Run("ok - max age", func() {
+		s.add("byage", 2)
+		time.Sleep(20 * time.Millisecond)
+		ids := s.add("byage", 1)
+
+		removed, err := s.db.StreamTrim("byage", 0, 10*time.Millisecond)
+		s.Require().NoError(err)
+		s.Equal(2, removed)
+
+		entries, err := s.db.StreamRange("byage", "-", "+", 0)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal(ids[0], entries[0].ID)
+	})
+
+	s.Run("ok - memory is released", func() {
+		before := s.db.Stats().UsedMemory
+		s.add("released", 10)
+		_, err := s.db.StreamTrim("released", 1, 0)
+		s.Require().NoError(err)
+		s.Require().NoError(s.db.Remove("released"))
+		s.Equal(before, s.db.Stats().UsedMemory)
+	})
+}
+
+func (s *StreamSuite) TestConsumerGroups() {
+	ids := s.add("jobs", 3)
+	ctx := context.Background()
+
+	s.Require().NoError(s.db.StreamGroupCreate("jobs", "workers", "0"))
+	s.ErrorIs(s.db.StreamGroupCreate("jobs", "workers", "0"), ErrStreamGroupExists)
+
+	s.Run("ok - entries are delivered once", func() {
+		first, err := s.db.StreamReadGroup(ctx, "jobs", "workers", "alice", 2, 0)
+		s.Require().NoError(err)
+		s.Require().Len(first, 2)
+		s.Equal(ids[0], first[0].ID)
+
+		second, err := s.db.StreamReadGroup(ctx, "jobs", "workers", "bob", 0, 0)
+		s.Require().NoError(err)
+		s.Require().Len(second, 1)
+		s.Equal(ids[2], second[0].ID)
+
+		none, err := s.db.StreamReadGroup(ctx, "jobs", "workers", "bob", 0, 0)
+		s.Require().NoError(err)
+		s.Empty(none)
+	})
+
+	s.Run("ok - pending entries by consumer", func() {
+		pending, err := s.db.StreamPending("jobs", "workers", "alice")
+		s.Require().NoError(err)
+		s.Require().Len(pending, 2)
+		s.Equal("alice", pending[0].Consumer)
+		s.Equal(1, pending[0].DeliveryCount)
+
+		all, err := s.db.StreamPending("jobs", "workers", "")
+		s.Require().NoError(err)
+		s.Len(all, 3)
+	})
+
+	s.Run("ok - ack", func() {
+		acked, err := s.db.StreamAck("jobs", "workers", ids[0].String(), ids[0].String())
+		s.Require().NoError(err)
+		s.Equal(1, acked)
+
+		pending, err := s.db.StreamPending("jobs", "workers", "alice")
+		s.Require().NoError(err)
+		s.Len(pending, 1)
+	})
+
+	s.Run("ok - claim idle entries", func() {
+		claimed, err := s.db.StreamClaim("jobs", "workers", "bob", time.Hour, ids[1].String())
+		s.Require().NoError(err)
+		s.Empty(claimed, "entries that are not idle long enough must not be claimed")
+
+		claimed, err = s.db.StreamClaim("jobs", "workers", "bob", 0, ids[1].String())
+		s.Require().NoError(err)
+		s.Require().Len(claimed, 1)
+
+		pending, err := s.db.StreamPending("jobs", "workers", "bob")
+		s.Require().NoError(err)
+		s.Require().Len(pending, 2)
+		s.Equal(2, pending[0].DeliveryCount)
+	})
+
+	s.Run("ok - block until a new entry is added", func() {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			_, _ = s.db.StreamAdd("jobs", map[string]string{"n": "late"})
+		}()
+
+		entries, err := s.db.StreamReadGroup(ctx, "jobs", "workers", "alice", 0, time.Second)
+		s.Require().NoError(err)
+		s.Require().Len(entries, 1)
+		s.Equal("late", entries[0].Fields["n"])
+	})
+
+	s.Run("ok - group created at the end only delivers new entries", func() {
+		s.Require().NoError(s.db.StreamGroupCreate("jobs", "late", "$"))
+		entries, err := s.db.StreamReadGroup(ctx, "jobs", "late", "carol", 0, 0)
+		s.Require().NoError(err)
+		s.Empty(entries)
+	})
+
+	s.Run("error - group not found", func() {
+		_, err := s.db.StreamReadGroup(ctx, "jobs", "missing", "alice", 0, 0)
+		s.ErrorIs(err, ErrStreamGroupNotFound)
+		_, err = s.db.StreamAck("jobs", "missing", ids[0].String())
+		s.ErrorIs(err, ErrStreamGroupNotFound)
+	})
+}
+
+func (s *StreamSuite) TestPersistence() {
+	dbPath := ".db_stream"
+	defer os.RemoveAll(dbPath)
+
+	db := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath)).(*memoryDB)
+	ctx := context.Background()
+
+	var ids []StreamID
+	for _, n := range []string{"a", "b", "c", "d"} {
+		id, err := db.StreamAdd("jobs", map[string]string{"n": n})
+		s.Require().NoError(err)
+		ids = append(ids, id)
+	}
+	_, err := db.StreamTrim("jobs", 3, 0)
+	s.Require().NoError(err)
+	s.Require().NoError(db.StreamGroupCreate("jobs", "workers", "0"))
+	_, err = db.StreamReadGroup(ctx, "jobs", "workers", "alice", 2, 0)
+	s.Require().NoError(err)
+	_, err = db.StreamAck("jobs", "workers", ids[1].String())
+	s.Require().NoError(err)
+	_, err = db.StreamClaim("jobs", "workers", "bob", 0, ids[2].String())
+	s.Require().NoError(err)
+	usedMemory := db.Stats().UsedMemory
+	db.Close()
+
+	restored := NewMemoryDB(slog.Default(), WithPersistenceEnabled(dbPath)).(*memoryDB)
+	defer restored.Close()
+
+	s.Equal(usedMemory, restored.Stats().UsedMemory)
+
+	entries, err := restored.StreamRange("jobs", "-", "+", 0)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 3)
+	s.Equal(ids[1], entries[0].ID)
+
+	pending, err := restored.StreamPending("jobs", "workers", "")
+	s.Require().NoError(err)
+	s.Require().Len(pending, 1)
+	s.Equal(ids[2], pending[0].ID)
+	s.Equal("bob", pending[0].Consumer)
+	s.Equal(2, pending[0].DeliveryCount)
+
+	// the group resumes after the last delivered entry
+	next, err := restored.StreamReadGroup(ctx, "jobs", "workers", "alice", 0, 0)
+	s.Require().NoError(err)
+	s.Require().Len(next, 1)
+	s.Equal(ids[3], next[0].ID)
+
+	// new entries keep increasing from the restored last ID
+	id, err := restored.StreamAdd("jobs", map[string]string{"n": "e"})
+	s.Require().NoError(err)
+	s.True(ids[3].Less(id))
+
+}
+
+func TestStream(t *testing.T) {
+	suite.Run(t, new(StreamSuite))
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// Ensure tracedDB implements DBClient interface
+	_ DBClient = (*tracedDB)(nil)
+)
+
+const (
+	tracerName = "memorydb/internal/db" // name of the tracer of the spans of the operations
+)
+
+// tracedDB is a database whose operations on the keys are traced as children of the span of its context.
+// The other methods are the ones of the database.
+type tracedDB struct {
+	*memoryDB
+	ctx context.Context
+}
+
+// WithContext returns the database with the operations on the keys traced as children of the span of the context.
+func (db *memoryDB) WithContext(ctx context.Context) DBClient {
+	return &tracedDB{memoryDB: db, ctx: ctx}
+}
+
+func (t *tracedDB) Get(key string) (*Item, error) {
+	return t.get(t.ctx, key)
+}
+
+func (t *tracedDB) Set(key string, value any, opts ...ItemOptions) error {
+	return t.set(t.ctx, key, value, opts...)
+}
+
+func (t *tracedDB) SetMany(items map[string]any, opts ...ItemOptions) error {
+	return t.setMany(t.ctx, items, opts...)
+}
+
+func (t *tracedDB) Update(key string, value any, opts ...ItemOptions) error {
+	return t.update(t.ctx, key, value, opts...)
+}
+
+func (t *tracedDB) Remove(key string) error {
+	return t.remove(t.ctx, key)
+}
+
+func (t *tracedDB) Push(key string, value string, opts ...ItemOptions) (*Item, error) {
+	return t.push(t.ctx, key, value, opts...)
+}
+
+func (t *tracedDB) Pop(key string) (*Item, error) {
+	return t.pop(t.ctx, key)
+}
+
+// startSpan starts a span as a child of the span of the context, with the tracer provider of that span. No span
+// is recorded if the context has none, such as for the operations of the cleanup routine or of the replay of the log.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.SpanContext().IsValid() {
+		return ctx, parent
+	}
+	return parent.TracerProvider().Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan ends the span, with the error of the operation if it failed.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// lock locks the items, with the time spent waiting for the lock traced as a child of the span of the context.
+func (db *memoryDB) lock(ctx context.Context) {
+	_, span := startSpan(ctx, "db.lock_wait")
+	db.mu.Lock()
+	span.End()
+}
+
+// logTraced logs the operation, with the write to the operation log traced as a child of the span of the context.
+func (db *memoryDB) logTraced(ctx context.Context, op *Operation) {
+	_, span := startSpan(ctx, "db.log_write", attribute.String("db.operation.name", op.Command.String()))
+	db.logOperation(op)
+	span.End()
+}
@@ -0,0 +1,29 @@
+package enums
+
+type ClientAuth string
+
+const (
+	// ClientAuthNone does not request the certificates of the clients.
+	ClientAuthNone ClientAuth = "none"
+	// ClientAuthOptional verifies the certificates of the clients that send one.
+	ClientAuthOptional ClientAuth = "optional"
+	// ClientAuthRequire rejects the clients without a valid certificate.
+	ClientAuthRequire ClientAuth = "require"
+)
+
+var MappedClientAuths = map[string]ClientAuth{
+	"none":     ClientAuthNone,
+	"optional": ClientAuthOptional,
+	"require":  ClientAuthRequire,
+}
+
+// IsValid checks if the policy is a valid ClientAuth.
+func (c ClientAuth) IsValid() bool {
+	_, exists := MappedClientAuths[string(c)]
+	return exists
+}
+
+// String returns the string representation of the ClientAuth.
+func (c ClientAuth) String() string {
+	return string(c)
+}
@@ -13,6 +13,33 @@ const (
 	DBCommandPush DBCommand = "push"
 	// DBCommandPop removes and returns the last item from a slice stored at the specified key.
 	DBCommandPop DBCommand = "pop"
+	// DBCommandStreamAdd appends an entry to the stream stored at the specified key, creating the stream if needed.
+	DBCommandStreamAdd DBCommand = "xadd"
+	// DBCommandStreamTrim removes the oldest entries of a stream.
+	DBCommandStreamTrim DBCommand = "xtrim"
+	// DBCommandStreamGroupCreate creates a consumer group in a stream.
+	DBCommandStreamGroupCreate DBCommand = "xgroup_create"
+	// DBCommandStreamDeliver records the entries delivered to a consumer of a group.
+	DBCommandStreamDeliver DBCommand = "xdeliver"
+	// DBCommandStreamAck acknowledges entries pending in a consumer group.
+	DBCommandStreamAck DBCommand = "xack"
+	// DBCommandStreamClaim transfers pending entries of a consumer group to another consumer.
+	DBCommandStreamClaim DBCommand = "xclaim"
+	// DBCommandUserSet stores a user of the authentication module, which lives in its own store.
+	DBCommandUserSet DBCommand = "user_set"
+	// DBCommandAPIKeySet stores an API key, which lives in the store of the authentication module.
+	DBCommandAPIKeySet DBCommand = "apikey_set"
+	// DBCommandAPIKeyRemove revokes an API key.
+	DBCommandAPIKeyRemove DBCommand = "apikey_remove"
+	// DBCommandNamespaceCreate creates a namespace, which has a keyspace of its own. The operations of its keys
+	// are replicated with the name of the namespace.
+	DBCommandNamespaceCreate DBCommand = "ns_create"
+	// DBCommandNamespaceDrop drops a namespace with all its keys.
+	DBCommandNamespaceDrop DBCommand = "ns_drop"
+
+	// DBCommandSetMany stores several items at once. It is only replicated through the Raft log of the cluster,
+	// the database logs a set for every item.
+	DBCommandSetMany DBCommand = "mset"
 )
 
 var MappedCommands = map[string]DBCommand{
@@ -21,6 +48,20 @@ var MappedCommands = map[string]DBCommand{
 	"remove": DBCommandRemove,
 	"push":   DBCommandPush,
 	"pop":    DBCommandPop,
+
+	"xadd":          DBCommandStreamAdd,
+	"xtrim":         DBCommandStreamTrim,
+	"xgroup_create": DBCommandStreamGroupCreate,
+	"xdeliver":      DBCommandStreamDeliver,
+	"xack":          DBCommandStreamAck,
+	"xclaim":        DBCommandStreamClaim,
+
+	"user_set":      DBCommandUserSet,
+	"apikey_set":    DBCommandAPIKeySet,
+	"apikey_remove": DBCommandAPIKeyRemove,
+
+	"ns_create": DBCommandNamespaceCreate,
+	"ns_drop":   DBCommandNamespaceDrop,
 }
 
 // IsValid checks if the command is a valid DBCommand.
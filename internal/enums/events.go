@@ -0,0 +1,52 @@
+package enums
+
+type KeyspaceEvent string
+
+const (
+	// KeyspaceEventSet is published when a key is stored.
+	KeyspaceEventSet KeyspaceEvent = "set"
+	// KeyspaceEventUpdate is published when the value of an existing key is modified.
+	KeyspaceEventUpdate KeyspaceEvent = "update"
+	// KeyspaceEventRemove is published when a key is deleted.
+	KeyspaceEventRemove KeyspaceEvent = "remove"
+	// KeyspaceEventPush is published when a value is appended to a slice.
+	KeyspaceEventPush KeyspaceEvent = "push"
+	// KeyspaceEventPop is published when the last value of a slice is removed.
+	KeyspaceEventPop KeyspaceEvent = "pop"
+	// KeyspaceEventExpire is published when a key is removed because its TTL has passed.
+	KeyspaceEventExpire KeyspaceEvent = "expire"
+	// KeyspaceEventEvict is published when a key is removed to free memory.
+	KeyspaceEventEvict KeyspaceEvent = "evict"
+	// KeyspaceEventStreamAdd is published when an entry is appended to a stream.
+	KeyspaceEventStreamAdd KeyspaceEvent = "xadd"
+	// KeyspaceEventStreamTrim is published when the oldest entries of a stream are removed.
+	KeyspaceEventStreamTrim KeyspaceEvent = "xtrim"
+	// KeyspaceEventLost is sent to every subscriber, whatever its pattern, when events it should have received were
+	// dropped or are no longer kept to resume its subscription. It has no key, and the subscriber must read the keys
+	// it follows again.
+	KeyspaceEventLost KeyspaceEvent = "lost"
+)
+
+var MappedKeyspaceEvents = map[string]KeyspaceEvent{
+	"set":    KeyspaceEventSet,
+	"update": KeyspaceEventUpdate,
+	"remove": KeyspaceEventRemove,
+	"push":   KeyspaceEventPush,
+	"pop":    KeyspaceEventPop,
+	"expire": KeyspaceEventExpire,
+	"evict":  KeyspaceEventEvict,
+	"xadd":   KeyspaceEventStreamAdd,
+	"xtrim":  KeyspaceEventStreamTrim,
+	"lost":   KeyspaceEventLost,
+}
+
+// IsValid checks if the event is a valid KeyspaceEvent.
+func (e KeyspaceEvent) IsValid() bool {
+	_, exists := MappedKeyspaceEvents[string(e)]
+	return exists
+}
+
+// String returns the string representation of the KeyspaceEvent.
+func (e KeyspaceEvent) String() string {
+	return string(e)
+}
@@ -0,0 +1,32 @@
+package enums
+
+type EvictionPolicy string
+
+const (
+	// EvictionPolicyNoEviction rejects writes that would exceed the memory limit.
+	EvictionPolicyNoEviction EvictionPolicy = "noeviction"
+	// EvictionPolicyAllKeysLRU evicts the least recently used keys first.
+	EvictionPolicyAllKeysLRU EvictionPolicy = "allkeys-lru"
+	// EvictionPolicyAllKeysLFU evicts the least frequently used keys first.
+	EvictionPolicyAllKeysLFU EvictionPolicy = "allkeys-lfu"
+	// EvictionPolicyVolatileTTL evicts the keys with the shortest remaining TTL first.
+	EvictionPolicyVolatileTTL EvictionPolicy = "volatile-ttl"
+)
+
+var MappedEvictionPolicies = map[string]EvictionPolicy{
+	"noeviction":   EvictionPolicyNoEviction,
+	"allkeys-lru":  EvictionPolicyAllKeysLRU,
+	"allkeys-lfu":  EvictionPolicyAllKeysLFU,
+	"volatile-ttl": EvictionPolicyVolatileTTL,
+}
+
+// IsValid checks if the policy is a valid EvictionPolicy.
+func (p EvictionPolicy) IsValid() bool {
+	_, exists := MappedEvictionPolicies[string(p)]
+	return exists
+}
+
+// String returns the string representation of the EvictionPolicy.
+func (p EvictionPolicy) String() string {
+	return string(p)
+}
@@ -0,0 +1,26 @@
+package enums
+
+type Permission string
+
+const (
+	// PermissionRead allows reading the keys, and subscribing to their events and channels.
+	PermissionRead Permission = "read"
+	// PermissionWrite allows writing and removing the keys, and publishing to their channels.
+	PermissionWrite Permission = "write"
+)
+
+var MappedPermissions = map[string]Permission{
+	"read":  PermissionRead,
+	"write": PermissionWrite,
+}
+
+// IsValid checks if the permission is a valid Permission.
+func (p Permission) IsValid() bool {
+	_, exists := MappedPermissions[string(p)]
+	return exists
+}
+
+// String returns the string representation of the Permission.
+func (p Permission) String() string {
+	return string(p)
+}
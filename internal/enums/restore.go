@@ -0,0 +1,26 @@
+package enums
+
+type RestoreMode string
+
+const (
+	// RestoreModeReplace replaces the whole content of the database with the backup.
+	RestoreModeReplace RestoreMode = "replace"
+	// RestoreModeMerge stores the keys of the backup, replacing the existing ones, and keeps the rest.
+	RestoreModeMerge RestoreMode = "merge"
+)
+
+var MappedRestoreModes = map[string]RestoreMode{
+	"replace": RestoreModeReplace,
+	"merge":   RestoreModeMerge,
+}
+
+// IsValid checks if the mode is a valid RestoreMode.
+func (m RestoreMode) IsValid() bool {
+	_, exists := MappedRestoreModes[string(m)]
+	return exists
+}
+
+// String returns the string representation of the RestoreMode.
+func (m RestoreMode) String() string {
+	return string(m)
+}
@@ -0,0 +1,29 @@
+package enums
+
+type TracingExporter string
+
+const (
+	// TracingExporterNone records no spans, the trace context of the requests is still propagated.
+	TracingExporterNone TracingExporter = "none"
+	// TracingExporterOTLP sends the spans to an OTLP collector over HTTP.
+	TracingExporterOTLP TracingExporter = "otlp"
+	// TracingExporterFile writes the spans as OTLP JSON lines to a file or to the standard output.
+	TracingExporterFile TracingExporter = "file"
+)
+
+var MappedTracingExporters = map[string]TracingExporter{
+	"none": TracingExporterNone,
+	"otlp": TracingExporterOTLP,
+	"file": TracingExporterFile,
+}
+
+// IsValid checks if the exporter is a valid TracingExporter.
+func (t TracingExporter) IsValid() bool {
+	_, exists := MappedTracingExporters[string(t)]
+	return exists
+}
+
+// String returns the string representation of the TracingExporter.
+func (t TracingExporter) String() string {
+	return string(t)
+}
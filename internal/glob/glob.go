@@ -0,0 +1,116 @@
+/*
+The package glob implements the Redis-style glob patterns used to match keys and channels.
+
+The supported syntax is:
+  - `*` matches any sequence of characters, including the empty one.
+  - `?` matches exactly one character.
+  - `[abc]`, `[a-z]` and `[^abc]` match one character of, or not of, the given set.
+  - `\` escapes the next character so it is matched literally.
+
+Unlike path.Match, the separators `/` and `:` have no special meaning, so `user:*` matches `user:1:profile`.
+*/
+package glob
+
+// Match reports whether the string s matches the glob pattern.
+//
+// An empty pattern matches every string, so callers can use it as "no filter".
+func Match(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	return match([]rune(pattern), []rune(s))
+}
+
+// match is the recursive implementation of Match that works on runes so multi-byte characters count as one.
+func match(pattern, s []rune) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			// collapse consecutive stars, they are equivalent to a single one
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if match(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			matched, rest, ok := matchClass(pattern[1:], s[0])
+			if !ok {
+				// an unterminated class is matched literally
+				if s[0] != '[' {
+					return false
+				}
+				pattern, s = pattern[1:], s[1:]
+				continue
+			}
+			if !matched {
+				return false
+			}
+			pattern, s = rest, s[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass matches the character c against the class that starts right after the opening `[`.
+// It returns whether c belongs to the class, the rest of the pattern after the closing `]`,
+// and false as last value if the class is not terminated.
+func matchClass(pattern []rune, c rune) (bool, []rune, bool) {
+	negate := false
+	if len(pattern) > 0 && pattern[0] == '^' {
+		negate = true
+		pattern = pattern[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case pattern[i] == ']' && i > 0:
+			return matched != negate, pattern[i+1:], true
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			i++
+			if pattern[i] == c {
+				matched = true
+			}
+		case i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']':
+			low, high := pattern[i], pattern[i+2]
+			if low > high {
+				low, high = high, low
+			}
+			if c >= low && c <= high {
+				matched = true
+			}
+			i += 2
+		default:
+			if pattern[i] == c {
+				matched = true
+			}
+		}
+	}
+
+	return false, nil, false
+}
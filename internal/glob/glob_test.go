@@ -0,0 +1,48 @@
+package glob_test
+
+import (
+	"memorydb/internal/glob"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GlobSuite struct {
+	suite.Suite
+}
+
+func (s *GlobSuite) TestMatch() {
+	values := []struct {
+		pattern  string
+		in       string
+		expected bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"user:*", "user:1:profile", true},
+		{"user:*", "users", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"*:profile", "user:1:profile", true},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"key[0-9]", "key7", true},
+		{"key[0-9]", "keyx", false},
+		{`literal\*`, "literal*", true},
+		{`literal\*`, "literalx", false},
+		{"[unterminated", "[unterminated", true},
+		{"ñ?", "ñé", true},
+	}
+
+	for _, v := range values {
+		s.Equal(v.expected, glob.Match(v.pattern, v.in), "pattern %q against %q", v.pattern, v.in)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	suite.Run(t, new(GlobSuite))
+}
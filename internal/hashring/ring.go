@@ -0,0 +1,118 @@
+/*
+The package hashring implements the consistent hashing ring used to spread keys across independent servers,
+shared by the sharded client and the proxy so they place every key in the same server.
+*/
+package hashring
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+const (
+	// DefaultVirtualNodes is the number of points of every node in the ring if none is given.
+	DefaultVirtualNodes = 160
+)
+
+// Ring is a consistent hashing ring. Every node is placed at several points of the ring, its virtual nodes,
+// and a key belongs to the node of the first point after the hash of the key.
+//
+// Adding or removing a node only moves the keys between that node and its neighbours, about 1/n of the keys,
+// and the virtual nodes spread the keys evenly across the nodes. The ring is not safe for concurrent use.
+type Ring struct {
+	virtualNodes int
+	points       []uint64          // sorted hashes of the virtual nodes
+	owners       map[uint64]string // node of every point
+	nodes        []string          // nodes in the ring, sorted
+}
+
+// New creates an empty ring that places every node at the given number of points,
+// or at DefaultVirtualNodes points if it is not positive.
+func New(virtualNodes int) *Ring {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	return &Ring{virtualNodes: virtualNodes, owners: make(map[uint64]string)}
+}
+
+// hashKey returns the position of the value in the ring.
+func hashKey(value string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+
+	// FNV spreads similar inputs poorly in the high bits, so mix them before using the hash as a position
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Add places the node in the ring. It returns false if the node is already in the ring.
+func (r *Ring) Add(node string) bool {
+	if r.Contains(node) {
+		return false
+	}
+
+	for i := range r.virtualNodes {
+		point := hashKey(node + "#" + strconv.Itoa(i))
+		if _, taken := r.owners[point]; taken {
+			continue // collisions are rare enough to just skip the point
+		}
+		r.owners[point] = node
+		r.points = append(r.points, point)
+	}
+	slices.Sort(r.points)
+
+	r.nodes = append(r.nodes, node)
+	slices.Sort(r.nodes)
+	return true
+}
+
+// Remove takes the node out of the ring. It returns false if the node is not in the ring.
+func (r *Ring) Remove(node string) bool {
+	if !r.Contains(node) {
+		return false
+	}
+
+	points := r.points[:0]
+	for _, point := range r.points {
+		if r.owners[point] == node {
+			delete(r.owners, point)
+			continue
+		}
+		points = append(points, point)
+	}
+	r.points = points
+	r.nodes = slices.DeleteFunc(r.nodes, func(n string) bool { return n == node })
+	return true
+}
+
+// Contains returns whether the node is in the ring.
+func (r *Ring) Contains(node string) bool {
+	_, found := slices.BinarySearch(r.nodes, node)
+	return found
+}
+
+// Nodes returns the nodes in the ring, sorted.
+func (r *Ring) Nodes() []string {
+	return append([]string(nil), r.nodes...)
+}
+
+// Get returns the node that owns the key, or false if the ring is empty.
+func (r *Ring) Get(key string) (string, bool) {
+	if len(r.points) == 0 {
+		return "", false
+	}
+
+	hash := hashKey(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= hash })
+	if i == len(r.points) {
+		i = 0 // wrap around the ring
+	}
+	return r.owners[r.points[i]], true
+}
@@ -0,0 +1,91 @@
+package hashring
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RingSuite struct {
+	ring *Ring
+	keys []string
+	suite.Suite
+}
+
+func (s *RingSuite) SetupTest() {
+	s.ring = New(DefaultVirtualNodes)
+	for i := range 3 {
+		s.True(s.ring.Add(fmt.Sprintf("http://node%d:8080", i)))
+	}
+
+	s.keys = make([]string, 10000)
+	for i := range s.keys {
+		s.keys[i] = fmt.Sprintf("key:%d", i)
+	}
+}
+
+// owners returns the node that owns every key.
+func (s *RingSuite) owners() map[string]string {
+	owners := make(map[string]string, len(s.keys))
+	for _, key := range s.keys {
+		node, ok := s.ring.Get(key)
+		s.Require().True(ok)
+		owners[key] = node
+	}
+	return owners
+}
+
+func (s *RingSuite) TestDistribution() {
+	counts := make(map[string]int)
+	for _, node := range s.owners() {
+		counts[node]++
+	}
+
+	// every node should get roughly a third of the keys
+	s.Len(counts, 3)
+	for node, count := range counts {
+		s.InDelta(len(s.keys)/3, count, float64(len(s.keys))/10, "node %s owns %d keys", node, count)
+	}
+}
+
+func (s *RingSuite) TestAddNode() {
+	before := s.owners()
+	s.True(s.ring.Add("http://node3:8080"))
+	s.False(s.ring.Add("http://node3:8080"), "nodes cannot be added twice")
+
+	// only the keys taken over by the new node move
+	moved := 0
+	for key, node := range s.owners() {
+		if node != before[key] {
+			s.Equal("http://node3:8080", node, "key %s moved between old nodes", key)
+			moved++
+		}
+	}
+	s.InDelta(len(s.keys)/4, moved, float64(len(s.keys))/10)
+}
+
+func (s *RingSuite) TestRemoveNode() {
+	before := s.owners()
+	s.True(s.ring.Remove("http://node1:8080"))
+	s.False(s.ring.Remove("http://node1:8080"), "unknown nodes cannot be removed")
+
+	// only the keys of the removed node move
+	for key, node := range s.owners() {
+		if before[key] != "http://node1:8080" {
+			s.Equal(before[key], node, "key %s moved between remaining nodes", key)
+		}
+		s.NotEqual("http://node1:8080", node)
+	}
+	s.Equal([]string{"http://node0:8080", "http://node2:8080"}, s.ring.Nodes())
+}
+
+func (s *RingSuite) TestEmpty() {
+	r := New(0)
+	_, ok := r.Get("key")
+	s.False(ok)
+}
+
+func TestRingSuite(t *testing.T) {
+	suite.Run(t, new(RingSuite))
+}
@@ -0,0 +1,618 @@
+package memcache
+
+import (
+	"errors"
+	"fmt"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// serverVersion is the version reported by VERSION and STATS.
+	serverVersion = "1.0.0"
+	// maxKeyLength is the maximum length of a key, the same as memcached.
+	maxKeyLength = 250
+	// maxItemSize is the maximum size of a value, the default of memcached.
+	maxItemSize = 1024 * 1024
+	// maxRelativeExptime is the largest expiration time taken as seconds from now, larger ones are Unix timestamps.
+	maxRelativeExptime = 60 * 60 * 24 * 30
+)
+
+var (
+	// errBadDataChunk is returned when the data block of a storage command does not have the announced size.
+	errBadDataChunk = errors.New("bad data chunk")
+)
+
+// command is a command of the protocol.
+type command struct {
+	// arity is the number of arguments including the name of the command and excluding noreply, or the minimum
+	// number if it is negative
+	arity int
+	// noreply tells whether the command accepts the noreply argument
+	noreply bool
+	// keys returns the keys of the command, whose permission is checked if the clients are authenticated
+	keys func(args []string) []string
+	// permission is the permission the principal of the client needs on the keys
+	permission enums.Permission
+	handler    func(c *conn, args []string)
+}
+
+// firstKey returns the first argument, which is the key of most commands.
+func firstKey(args []string) []string {
+	return args[1:2]
+}
+
+// allKeys returns every argument as a key.
+func allKeys(args []string) []string {
+	return args[1:]
+}
+
+// commands are the commands served, by name.
+var commands map[string]command
+
+func init() {
+	// initialized here because the handlers of the storage commands refer to the table
+	commands = map[string]command{
+		"get":       {arity: -2, keys: allKeys, permission: enums.PermissionRead, handler: (*conn).get},
+		"gets":      {arity: -2, keys: allKeys, permission: enums.PermissionRead, handler: (*conn).get},
+		"set":       {arity: 5, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).set},
+		"add":       {arity: 5, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).add},
+		"replace":   {arity: 5, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).replace},
+		"append":    {arity: 5, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).appendCommand},
+		"prepend":   {arity: 5, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).prepend},
+		"cas":       {arity: 6, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).cas},
+		"delete":    {arity: -2, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).delete},
+		"incr":      {arity: 3, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).incr},
+		"decr":      {arity: 3, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).decr},
+		"touch":     {arity: 3, noreply: true, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).touch},
+		"flush_all": {arity: -1, noreply: true, handler: (*conn).flushAll},
+		"version":   {arity: 1, handler: (*conn).version},
+		"verbosity": {arity: 2, noreply: true, handler: (*conn).verbosity},
+		"stats":     {arity: 1, handler: (*conn).stats},
+		"quit":      {arity: 1, handler: (*conn).quitCommand},
+	}
+}
+
+// execute runs a command and writes its reply.
+func (c *conn) execute(args []string) {
+	c.noreply = false
+	if len(args) == 0 {
+		c.writeError("ERROR")
+		return
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		c.writeError("ERROR")
+		return
+	}
+	if cmd.noreply && len(args) > 1 && args[len(args)-1] == "noreply" {
+		args = args[:len(args)-1]
+		c.noreply = true
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.discardData(args)
+		c.writeError("ERROR")
+		return
+	}
+
+	if c.server.auth != nil {
+		// until the client is authenticated, it can only send its credentials, as the data of a set
+		if c.principal == nil && args[0] != "quit" {
+			if args[0] == "set" {
+				c.authenticate(args)
+				return
+			}
+			c.discardData(args)
+			c.writeError("CLIENT_ERROR unauthenticated")
+			return
+		}
+		if cmd.keys != nil {
+			for _, key := range cmd.keys(args) {
+				if !c.principal.Allows(cmd.permission, key) {
+					c.discardData(args)
+					c.writeError("CLIENT_ERROR permission denied")
+					return
+				}
+			}
+		}
+	}
+
+	cmd.handler(c, args)
+}
+
+// authenticate authenticates the client with the data of a set, as the ASCII authentication of memcached:
+// "<username> <password>" authenticates a user, and an API key alone or as the password of the username default
+// authenticates the API key. The key of the set is ignored and nothing is stored.
+func (c *conn) authenticate(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	username, password, found := strings.Cut(strings.TrimSpace(req.data), " ")
+	if !found {
+		username, password = "default", username
+	}
+	principal, err := auth.AuthenticatePassword(c.server.auth, username, password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidAPIKey), errors.Is(err, auth.ErrInvalidCredentials):
+		c.writeError("CLIENT_ERROR authentication failure")
+	case err != nil:
+		c.writeDBError(err)
+	default:
+		c.principal = principal
+		c.writeLine("STORED")
+	}
+}
+
+// writeError writes an error reply. Unlike the other replies, errors are sent even if the command was sent with noreply.
+func (c *conn) writeError(line string) {
+	c.writer.WriteString(line)
+	c.writer.WriteString("\r\n")
+}
+
+// writeDBError writes the error returned by the database as a server error.
+func (c *conn) writeDBError(err error) {
+	if errors.Is(err, db.ErrOutOfMemory) {
+		c.writeError("SERVER_ERROR out of memory storing object")
+		return
+	}
+	c.writeError("SERVER_ERROR " + err.Error())
+}
+
+// isNotFound reports whether the error of Get means that the key does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, db.ErrDataNotFound) || errors.Is(err, db.ErrKeyHasExpired)
+}
+
+// lookup returns the item of the key, or nil if it does not exist. Only strings are visible to the protocol,
+// so the keys holding other kinds of values are reported as missing.
+func (c *conn) lookup(key string) (*db.Item, error) {
+	item, err := c.server.db.Get(key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if item.Kind != db.StringType {
+		return nil, nil
+	}
+	return item, nil
+}
+
+// modify reads the item of the key and writes it back with write, which must make the write conditional on the
+// version of the item. If the item is written by another client meanwhile, it is read and written again.
+// It returns false if the key does not exist.
+func (c *conn) modify(key string, write func(item *db.Item, value string) error) (bool, error) {
+	for {
+		item, err := c.lookup(key)
+		if err != nil || item == nil {
+			return false, err
+		}
+		value, _ := item.Value.Val.(string)
+		err = write(item, value)
+		if err == nil {
+			return true, nil
+		}
+		if errors.Is(err, db.ErrVersionMismatch) {
+			continue
+		}
+		// the key may have been removed meanwhile, which is reported as missing by the next lookup
+		if current, lookupErr := c.lookup(key); lookupErr == nil && current == nil {
+			return false, nil
+		}
+		return false, err
+	}
+}
+
+// validKey reports whether the key can be used by the protocol: at most 250 bytes without control characters.
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// ttlOptions returns the options of the expiration time of a command.
+//
+// An expiration time of 0 uses the default TTL of the database, since the items always expire. Times up to 30 days
+// are seconds from now, and larger ones are Unix timestamps. Negative times and timestamps in the past store the item
+// already expired, so it is no longer returned.
+func ttlOptions(exptime int64, now time.Time) []db.ItemOptions {
+	var ttl time.Duration
+	switch {
+	case exptime == 0:
+		return nil
+	case exptime < 0:
+		ttl = -time.Second
+	case exptime > maxRelativeExptime:
+		ttl = time.Unix(exptime, 0).Sub(now)
+		if ttl <= 0 {
+			ttl = -time.Second
+		}
+	default:
+		ttl = time.Duration(exptime) * time.Second
+	}
+	return []db.ItemOptions{db.WithTTL(ttl)}
+}
+
+// storageRequest is a parsed storage command.
+type storageRequest struct {
+	key  string
+	opts []db.ItemOptions // expiration time and flags of the item
+	data string
+	cas  uint64
+}
+
+// readStorage parses a storage command and reads its data block. If the command is invalid, the error is written
+// and false is returned.
+func (c *conn) readStorage(args []string) (storageRequest, bool) {
+	size, err := strconv.Atoi(args[4])
+	if err != nil || size < 0 {
+		c.writeError("CLIENT_ERROR bad command line format")
+		return storageRequest{}, false
+	}
+	if size > maxItemSize {
+		// the data is discarded so the next command can be read
+		if _, err := c.reader.Discard(size + 2); err != nil {
+			c.quit = true
+		}
+		c.writeError("SERVER_ERROR object too large for cache")
+		return storageRequest{}, false
+	}
+	data, err := c.readData(size)
+	if err != nil {
+		if !errors.Is(err, errBadDataChunk) {
+			c.quit = true
+		}
+		c.writeError("CLIENT_ERROR bad data chunk")
+		return storageRequest{}, false
+	}
+
+	req := storageRequest{key: args[1], data: string(data)}
+	flags, err1 := strconv.ParseUint(args[2], 10, 32)
+	exptime, err2 := strconv.ParseInt(args[3], 10, 64)
+	if err1 != nil || err2 != nil || !validKey(req.key) {
+		c.writeError("CLIENT_ERROR bad command line format")
+		return storageRequest{}, false
+	}
+	if len(args) > 5 {
+		if req.cas, err = strconv.ParseUint(args[5], 10, 64); err != nil {
+			c.writeError("CLIENT_ERROR bad command line format")
+			return storageRequest{}, false
+		}
+	}
+	req.opts = append(ttlOptions(exptime, time.Now()), db.WithFlags(flags))
+	return req, true
+}
+
+// discardData reads the data block of a storage command sent with the wrong number of arguments, so it is not
+// taken as the next command.
+func (c *conn) discardData(args []string) {
+	cmd := commands[args[0]]
+	if cmd.arity < 5 || len(args) < 5 {
+		return
+	}
+	if size, err := strconv.Atoi(args[4]); err == nil && size >= 0 {
+		if _, err := c.reader.Discard(size + 2); err != nil {
+			c.quit = true
+		}
+	}
+}
+
+// get returns the values of the keys that exist, with their CAS token for gets.
+func (c *conn) get(args []string) {
+	for _, key := range args[1:] {
+		if !validKey(key) {
+			c.writeError("CLIENT_ERROR bad command line format")
+			return
+		}
+	}
+
+	for _, key := range args[1:] {
+		item, err := c.lookup(key)
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		if item == nil {
+			continue
+		}
+		value, _ := item.Value.Val.(string)
+		if args[0] == "gets" {
+			c.writeLine(fmt.Sprintf("VALUE %s %d %d %d", key, item.Flags, len(value), item.Version))
+		} else {
+			c.writeLine(fmt.Sprintf("VALUE %s %d %d", key, item.Flags, len(value)))
+		}
+		c.writeLine(value)
+	}
+	c.writeLine("END")
+}
+
+// set stores the value at the key.
+func (c *conn) set(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	if err := c.server.db.Set(req.key, req.data, req.opts...); err != nil {
+		c.writeDBError(err)
+		return
+	}
+	c.writeLine("STORED")
+}
+
+// add stores the value only if the key does not exist.
+func (c *conn) add(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	err := c.server.db.Set(req.key, req.data, append(req.opts, db.WithVersion(0))...)
+	switch {
+	case errors.Is(err, db.ErrVersionMismatch):
+		c.writeLine("NOT_STORED")
+	case err != nil:
+		c.writeDBError(err)
+	default:
+		c.writeLine("STORED")
+	}
+}
+
+// replace stores the value only if the key exists.
+func (c *conn) replace(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	c.writeStored(c.modify(req.key, func(item *db.Item, _ string) error {
+		return c.server.db.Set(req.key, req.data, append(req.opts, db.WithVersion(item.Version))...)
+	}))
+}
+
+// appendCommand adds the data after the value of an existing key. The flags and the expiration time are ignored.
+func (c *conn) appendCommand(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	c.writeStored(c.modify(req.key, func(item *db.Item, value string) error {
+		return c.server.db.Update(req.key, value+req.data, db.WithVersion(item.Version))
+	}))
+}
+
+// prepend adds the data before the value of an existing key. The flags and the expiration time are ignored.
+func (c *conn) prepend(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+	c.writeStored(c.modify(req.key, func(item *db.Item, value string) error {
+		return c.server.db.Update(req.key, req.data+value, db.WithVersion(item.Version))
+	}))
+}
+
+// writeStored writes the reply of the storage commands that require an existing key.
+func (c *conn) writeStored(found bool, err error) {
+	switch {
+	case err != nil:
+		c.writeDBError(err)
+	case !found:
+		c.writeLine("NOT_STORED")
+	default:
+		c.writeLine("STORED")
+	}
+}
+
+// cas stores the value only if the key has not been written since the client read its CAS token with gets.
+func (c *conn) cas(args []string) {
+	req, ok := c.readStorage(args)
+	if !ok {
+		return
+	}
+
+	// a token of 0 is never returned by gets, and it would make the write conditional on a missing key
+	var err error = db.ErrVersionMismatch
+	if req.cas != 0 {
+		err = c.server.db.Set(req.key, req.data, append(req.opts, db.WithVersion(req.cas))...)
+	}
+	if !errors.Is(err, db.ErrVersionMismatch) {
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		c.writeLine("STORED")
+		return
+	}
+
+	item, err := c.lookup(req.key)
+	switch {
+	case err != nil:
+		c.writeDBError(err)
+	case item == nil:
+		c.writeLine("NOT_FOUND")
+	default:
+		c.writeLine("EXISTS")
+	}
+}
+
+// delete removes the key. A time argument of 0 is accepted for the clients that still send it.
+func (c *conn) delete(args []string) {
+	if len(args) > 3 || (len(args) == 3 && args[2] != "0") {
+		c.writeError("CLIENT_ERROR bad command line format.  Usage: delete <key> [noreply]")
+		return
+	}
+	if !validKey(args[1]) {
+		c.writeError("CLIENT_ERROR bad command line format")
+		return
+	}
+
+	found, err := c.modify(args[1], func(*db.Item, string) error {
+		return c.server.db.Remove(args[1])
+	})
+	switch {
+	case err != nil:
+		c.writeDBError(err)
+	case !found:
+		c.writeLine("NOT_FOUND")
+	default:
+		c.writeLine("DELETED")
+	}
+}
+
+// incr adds the delta to the number stored at the key. The number wraps around at 64 bits.
+func (c *conn) incr(args []string) {
+	c.incrBy(args, func(n, delta uint64) uint64 {
+		return n + delta
+	})
+}
+
+// decr subtracts the delta from the number stored at the key. The number does not go below 0.
+func (c *conn) decr(args []string) {
+	c.incrBy(args, func(n, delta uint64) uint64 {
+		if delta > n {
+			return 0
+		}
+		return n - delta
+	})
+}
+
+// incrBy applies the operation to the number stored at the key and the delta, and replies the new number.
+func (c *conn) incrBy(args []string, operation func(n, delta uint64) uint64) {
+	if !validKey(args[1]) {
+		c.writeError("CLIENT_ERROR bad command line format")
+		return
+	}
+	delta, err := strconv.ParseUint(args[2], 10, 64)
+	if err != nil {
+		c.writeError("CLIENT_ERROR invalid numeric delta argument")
+		return
+	}
+
+	var result string
+	nonNumeric := false
+	found, err := c.modify(args[1], func(item *db.Item, value string) error {
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			nonNumeric = true
+			return nil
+		}
+		result = strconv.FormatUint(operation(n, delta), 10)
+		return c.server.db.Update(args[1], result, db.WithVersion(item.Version))
+	})
+	switch {
+	case err != nil:
+		c.writeDBError(err)
+	case !found:
+		c.writeLine("NOT_FOUND")
+	case nonNumeric:
+		c.writeError("CLIENT_ERROR cannot increment or decrement non-numeric value")
+	default:
+		c.writeLine(result)
+	}
+}
+
+// touch sets the expiration time of an existing key.
+func (c *conn) touch(args []string) {
+	exptime, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil || !validKey(args[1]) {
+		c.writeError("CLIENT_ERROR bad command line format")
+		return
+	}
+
+	// the value is written again with its flags, since an expiration time of 0 goes back to the default TTL as with set
+	ttl := ttlOptions(exptime, time.Now())
+	found, err := c.modify(args[1], func(item *db.Item, value string) error {
+		return c.server.db.Set(args[1], value, append(ttl, db.WithFlags(item.Flags), db.WithVersion(item.Version))...)
+	})
+	switch {
+	case err != nil:
+		c.writeDBError(err)
+	case !found:
+		c.writeLine("NOT_FOUND")
+	default:
+		c.writeLine("TOUCHED")
+	}
+}
+
+// flushAll removes every key of the database, or makes them expire after the delay if it is given.
+// Unlike memcached, the keys written after the command are not affected by the delay.
+func (c *conn) flushAll(args []string) {
+	if len(args) > 2 {
+		c.writeError("ERROR")
+		return
+	}
+	var delay time.Duration
+	if len(args) == 2 {
+		seconds, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || seconds < 0 {
+			c.writeError("CLIENT_ERROR bad command line format")
+			return
+		}
+		delay = time.Duration(seconds) * time.Second
+	}
+	if c.server.auth != nil && !c.principal.AllowsPattern(enums.PermissionWrite, "*") {
+		c.writeError("CLIENT_ERROR permission denied")
+		return
+	}
+
+	for _, key := range c.server.db.Keys("*") {
+		if delay == 0 {
+			if err := c.server.db.Remove(key); err != nil && !isNotFound(err) {
+				c.server.logger.Debug("failed to flush key", "key", key, "error", err)
+			}
+			continue
+		}
+
+		item, err := c.server.db.Get(key)
+		if err != nil || item.Kind == db.StreamType || item.TTL.Before(time.Now().Add(delay)) {
+			continue
+		}
+		if err := c.server.db.Update(key, item.Value.Val, db.WithTTL(delay), db.WithVersion(item.Version)); err != nil {
+			c.server.logger.Debug("failed to flush key", "key", key, "error", err)
+		}
+	}
+	c.writeLine("OK")
+}
+
+func (c *conn) version(_ []string) {
+	c.writeLine("VERSION " + serverVersion)
+}
+
+// verbosity is accepted for compatibility, the logging level is set by the configuration.
+func (c *conn) verbosity(_ []string) {
+	c.writeLine("OK")
+}
+
+// stats returns the general statistics of the server.
+func (c *conn) stats(_ []string) {
+	now := time.Now()
+	stats := [][2]string{
+		{"pid", strconv.Itoa(os.Getpid())},
+		{"uptime", strconv.FormatInt(int64(now.Sub(c.server.started).Seconds()), 10)},
+		{"time", strconv.FormatInt(now.Unix(), 10)},
+		{"version", serverVersion},
+		{"curr_connections", strconv.Itoa(c.server.clients())},
+		{"curr_items", strconv.Itoa(len(c.server.db.Keys("*")))},
+		{"item_size_max", strconv.Itoa(maxItemSize)},
+	}
+	for _, stat := range stats {
+		c.writeLine(fmt.Sprintf("STAT %s %s", stat[0], stat[1]))
+	}
+	c.writeLine("END")
+}
+
+func (c *conn) quitCommand(_ []string) {
+	c.quit = true
+}
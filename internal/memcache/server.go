@@ -0,0 +1,257 @@
+package memcache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLineLength is the maximum length of a command line, enough for a get of a few hundred keys.
+	maxLineLength = 64 * 1024
+)
+
+var (
+	// ErrServerClosed is returned by ListenAndServe and Serve once the server has been closed.
+	ErrServerClosed = errors.New("memcache: server closed")
+)
+
+// ServerOptions defines an interface for applying options to the Server.
+type ServerOptions interface {
+	apply(*Server)
+}
+
+// WithAuthManager authenticates the clients with the authentication manager, so they must send their credentials
+// as the data of a set, or connect with a client certificate whose identity is the subject of an API key, before
+// sending commands, and they can only access the keys their principal is allowed to.
+type WithAuthManager struct{ auth.AuthManager }
+
+func (o WithAuthManager) apply(s *Server) {
+	s.auth = o.AuthManager
+}
+
+// Server serves the database over the text protocol of memcached.
+type Server struct {
+	logger  *slog.Logger
+	addr    string
+	db      db.DBClient
+	auth    auth.AuthManager // authentication manager of the clients, nil if they are not authenticated
+	started time.Time
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[*conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a server that listens at the TCP address and runs the commands against the database.
+func NewServer(logger *slog.Logger, addr string, database db.DBClient, opts ...ServerOptions) *Server {
+	s := &Server{
+		logger:  logger,
+		addr:    addr,
+		db:      database,
+		started: time.Now(),
+		conns:   make(map[*conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+	return s
+}
+
+// ListenAndServe listens at the address of the server and serves the connections until the server is closed.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve serves the connections accepted by the listener until the server is closed. It always returns an error,
+// ErrServerClosed after Close.
+func (s *Server) Serve(listener net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.mu.Unlock()
+
+	s.logger.Info("Starting memcached server", "address", listener.Addr().String())
+	for {
+		netConn, err := listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		c := newConn(s, netConn)
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			netConn.Close()
+			return ErrServerClosed
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(c)
+	}
+}
+
+// Close stops listening, closes the connections and waits for their commands to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	var err error
+	if s.listener != nil {
+		err = s.listener.Close()
+	}
+	for c := range s.conns {
+		c.netConn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// clients returns the number of connected clients.
+func (s *Server) clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// serveConn runs the commands of a connection until it is closed.
+//
+// The replies are buffered while more pipelined commands are waiting to be read, and sent together.
+func (s *Server) serveConn(c *conn) {
+	defer func() {
+		c.netConn.Close()
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	if s.auth != nil {
+		c.authenticateCertificate()
+	}
+	for {
+		line, err := c.reader.ReadSlice('\n')
+		if err != nil {
+			if errors.Is(err, bufio.ErrBufferFull) {
+				c.writeLine("CLIENT_ERROR line too long")
+				_ = c.writer.Flush()
+			} else if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.Debug("memcached connection closed", "client", c.netConn.RemoteAddr().String(), "error", err)
+			}
+			return
+		}
+
+		c.execute(strings.Fields(string(bytes.TrimRight(line, "\r\n"))))
+
+		if c.reader.Buffered() == 0 || c.quit {
+			if err := c.writer.Flush(); err != nil {
+				s.logger.Debug("failed to send memcached replies", "client", c.netConn.RemoteAddr().String(), "error", err)
+				return
+			}
+		}
+		if c.quit {
+			return
+		}
+	}
+}
+
+// conn is a client connection.
+type conn struct {
+	server  *Server
+	netConn net.Conn
+	reader  *bufio.Reader
+	writer  *bufio.Writer
+	noreply bool // whether the replies of the current command are suppressed
+	quit    bool // whether the connection must be closed once the replies are sent
+
+	principal *auth.Principal // principal the client is authenticated as, nil until it is authenticated
+}
+
+// newConn returns a connection of the server.
+func newConn(s *Server, netConn net.Conn) *conn {
+	return &conn{
+		server:  s,
+		netConn: netConn,
+		reader:  bufio.NewReaderSize(netConn, maxLineLength),
+		writer:  bufio.NewWriter(netConn),
+	}
+}
+
+// authenticateCertificate authenticates the client as the API key of its verified client certificate, if it
+// connected over TLS with one. Otherwise, the client must send its credentials.
+func (c *conn) authenticateCertificate() {
+	tlsConn, ok := c.netConn.(*tls.Conn)
+	if !ok {
+		return
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		c.server.logger.Debug("memcached TLS handshake failed", "client", c.netConn.RemoteAddr().String(), "error", err)
+		return
+	}
+	state := tlsConn.ConnectionState()
+	principal, err := auth.AuthenticateTLS(c.server.auth, &state)
+	if err != nil {
+		c.server.logger.Warn("failed to authenticate the memcached client certificate", "client", c.netConn.RemoteAddr().String(), "error", err)
+		return
+	}
+	c.principal = principal
+}
+
+// writeLine writes a line of the reply, unless the command was sent with noreply.
+func (c *conn) writeLine(line string) {
+	if c.noreply {
+		return
+	}
+	c.writer.WriteString(line)
+	c.writer.WriteString("\r\n")
+}
+
+// readData reads the data block of a storage command, which must be followed by "\r\n".
+func (c *conn) readData(size int) ([]byte, error) {
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(data, []byte("\r\n")) {
+		// the rest of the line is discarded, as memcached does, so it is not taken as a command
+		if data[len(data)-1] != '\n' {
+			if _, err := c.reader.ReadSlice('\n'); err != nil {
+				return nil, err
+			}
+		}
+		return nil, errBadDataChunk
+	}
+	return data[:size], nil
+}
@@ -0,0 +1,334 @@
+package memcache_test
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/memcache"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerSuite struct {
+	suite.Suite
+	database db.DBClient
+	server   *memcache.Server
+	addr     string
+	conn     net.Conn
+	reader   *bufio.Reader
+}
+
+func (s *ServerSuite) SetupTest() {
+	s.database = db.NewMemoryDB(slog.Default())
+	s.start()
+}
+
+// start serves the database on a random port and connects to it.
+func (s *ServerSuite) start(opts ...memcache.ServerOptions) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.addr = listener.Addr().String()
+	s.server = memcache.NewServer(slog.Default(), s.addr, s.database, opts...)
+	go s.server.Serve(listener)
+	s.dial()
+}
+
+// dial opens a new connection to the server.
+func (s *ServerSuite) dial() {
+	var err error
+	s.conn, err = net.Dial("tcp", s.addr)
+	s.Require().NoError(err)
+	s.reader = bufio.NewReader(s.conn)
+}
+
+func (s *ServerSuite) TearDownTest() {
+	s.conn.Close()
+	s.server.Close()
+	s.database.Close()
+}
+
+// send writes the lines of a command, each one terminated by "\r\n".
+func (s *ServerSuite) send(lines ...string) {
+	_, err := s.conn.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+	s.Require().NoError(err)
+}
+
+// read returns the next line of the replies without the "\r\n".
+func (s *ServerSuite) read() string {
+	s.Require().NoError(s.conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+	line, err := s.reader.ReadString('\n')
+	s.Require().NoError(err)
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+// do sends a command and returns the first line of its reply.
+func (s *ServerSuite) do(lines ...string) string {
+	s.send(lines...)
+	return s.read()
+}
+
+// gets returns the value and the CAS token of the key, or false if it does not exist.
+func (s *ServerSuite) gets(key string) (string, uint64, bool) {
+	line := s.do("gets " + key)
+	if line == "END" {
+		return "", 0, false
+	}
+	var (
+		name          string
+		flags, length int
+		cas           uint64
+	)
+	_, err := fmt.Sscanf(line, "VALUE %s %d %d %d", &name, &flags, &length, &cas)
+	s.Require().NoError(err, line)
+	value := s.read()
+	s.Require().Equal("END", s.read())
+	s.Require().Len(value, length)
+	return value, cas, true
+}
+
+func (s *ServerSuite) TestStorage() {
+	s.Equal("STORED", s.do("set foo 0 0 3", "bar"))
+	s.Equal("VALUE foo 0 3", s.do("get foo"))
+	s.Equal("bar", s.read())
+	s.Equal("END", s.read())
+
+	s.Equal("NOT_STORED", s.do("add foo 0 0 1", "x"))
+	s.Equal("STORED", s.do("add new 0 0 1", "x"))
+	s.Equal("NOT_STORED", s.do("replace missing 0 0 1", "x"))
+	s.Equal("STORED", s.do("replace new 0 0 1", "y"))
+	s.Equal("STORED", s.do("append foo 0 0 2", "!!"))
+	s.Equal("STORED", s.do("prepend foo 0 0 2", "<<"))
+	s.Equal("NOT_STORED", s.do("append missing 0 0 1", "x"))
+
+	value, _, ok := s.gets("foo")
+	s.Require().True(ok)
+	s.Equal("<<bar!!", value)
+
+	s.Equal("STORED", s.do("set spaces 0 0 5", "a b c"))
+	value, _, ok = s.gets("spaces")
+	s.True(ok)
+	s.Equal("a b c", value, "the values should be read by their length")
+
+	s.Equal("CLIENT_ERROR bad data chunk", s.do("set short 0 0 2", "abc"))
+	s.Equal("CLIENT_ERROR bad command line format", s.do("set flagged 4294967296 0 1", "x"), "the flags have 32 bits")
+	s.Equal("CLIENT_ERROR bad command line format", s.do("set "+strings.Repeat("k", 251)+" 0 0 1", "x"))
+	s.Equal("ERROR", s.do("set foo 0 0", "bar"))
+	s.Equal("ERROR", s.read(), "the data of an incomplete command cannot be discarded")
+	s.Equal("ERROR", s.do("unknown"))
+}
+
+func (s *ServerSuite) TestFlags() {
+	s.Equal("STORED", s.do("set foo 4294967295 0 3", "bar"))
+	s.Equal("VALUE foo 4294967295 3", s.do("get foo"))
+	s.Equal("bar", s.read())
+	s.Equal("END", s.read())
+
+	// the commands that modify the value keep the flags, while the storage commands replace them
+	s.Equal("STORED", s.do("append foo 7 0 1", "!"))
+	s.Equal("STORED", s.do("set n 12 0 1", "3"))
+	s.Equal("4", s.do("incr n 1"))
+	s.Equal("TOUCHED", s.do("touch n 60"))
+	s.Equal("VALUE n 12 1", s.do("get n"))
+	s.Equal("4", s.read())
+	s.Equal("END", s.read())
+
+	s.Equal("VALUE foo 4294967295 4", s.do("get foo"))
+	s.Equal("bar!", s.read())
+	s.Equal("END", s.read())
+	s.Equal("STORED", s.do("set foo 0 0 3", "baz"))
+	s.Equal("VALUE foo 0 3", s.do("get foo"))
+	s.Equal("baz", s.read())
+	s.Equal("END", s.read())
+}
+
+func (s *ServerSuite) TestMultiGet() {
+	s.send("set a 0 0 1", "1", "set b 0 0 1", "2")
+	s.Equal("STORED", s.read())
+	s.Equal("STORED", s.read())
+	s.Require().NoError(s.database.Set("list", []string{"x"}))
+
+	s.Equal("VALUE a 0 1", s.do("get a missing list b"))
+	s.Equal("1", s.read())
+	s.Equal("VALUE b 0 1", s.read(), "the missing keys and the values that are not strings should be skipped")
+	s.Equal("2", s.read())
+	s.Equal("END", s.read())
+}
+
+func (s *ServerSuite) TestCAS() {
+	s.Equal("NOT_FOUND", s.do("cas foo 0 0 1 1", "x"))
+	s.Equal("STORED", s.do("set foo 0 0 3", "bar"))
+
+	_, cas, ok := s.gets("foo")
+	s.Require().True(ok)
+	s.NotZero(cas)
+
+	s.Equal("STORED", s.do(fmt.Sprintf("cas foo 0 0 3 %d", cas), "baz"))
+	s.Equal("EXISTS", s.do(fmt.Sprintf("cas foo 0 0 3 %d", cas), "qux"), "the token should be stale after the write")
+	s.Equal("EXISTS", s.do("cas foo 0 0 3 0", "qux"))
+
+	value, next, ok := s.gets("foo")
+	s.Require().True(ok)
+	s.Equal("baz", value)
+	s.Greater(next, cas)
+
+	// the tokens are the versions of the items, so the writes through the other APIs invalidate them
+	s.Require().NoError(s.database.Update("foo", "http"))
+	s.Equal("EXISTS", s.do(fmt.Sprintf("cas foo 0 0 3 %d", next), "qux"))
+}
+
+func (s *ServerSuite) TestDelete() {
+	s.Equal("STORED", s.do("set foo 0 0 3", "bar"))
+	s.Equal("DELETED", s.do("delete foo"))
+	s.Equal("NOT_FOUND", s.do("delete foo"))
+	s.Equal("STORED", s.do("set foo 0 0 3", "bar"))
+	s.Equal("DELETED", s.do("delete foo 0"), "the legacy time argument should be accepted")
+	s.Contains(s.do("delete foo 10"), "CLIENT_ERROR bad command line format")
+}
+
+func (s *ServerSuite) TestIncrDecr() {
+	s.Equal("NOT_FOUND", s.do("incr counter 1"))
+	s.Equal("STORED", s.do("set counter 0 0 2", "10"))
+	s.Equal("15", s.do("incr counter 5"))
+	s.Equal("3", s.do("decr counter 12"))
+	s.Equal("0", s.do("decr counter 100"), "decr should not go below 0")
+
+	s.Equal("STORED", s.do("set max 0 0 20", strconv.FormatUint(^uint64(0), 10)))
+	s.Equal("1", s.do("incr max 2"), "incr should wrap around at 64 bits")
+
+	s.Equal("STORED", s.do("set text 0 0 3", "abc"))
+	s.Equal("CLIENT_ERROR cannot increment or decrement non-numeric value", s.do("incr text 1"))
+	s.Equal("CLIENT_ERROR invalid numeric delta argument", s.do("incr counter -1"))
+}
+
+func (s *ServerSuite) TestExpiration() {
+	s.Equal("STORED", s.do("set foo 0 100 3", "bar"))
+	item, err := s.database.Get("foo")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(100*time.Second), item.TTL, 2*time.Second)
+
+	s.Equal("STORED", s.do(fmt.Sprintf("set unix 0 %d 3", time.Now().Add(time.Hour).Unix()), "bar"))
+	item, err = s.database.Get("unix")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(time.Hour), item.TTL, 2*time.Second, "large times should be Unix timestamps")
+
+	s.Equal("STORED", s.do("set gone 0 -1 3", "bar"))
+	_, _, ok := s.gets("gone")
+	s.False(ok, "a negative time should expire the item")
+
+	s.Equal("TOUCHED", s.do("touch foo 1000"))
+	item, err = s.database.Get("foo")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(1000*time.Second), item.TTL, 2*time.Second)
+	s.Equal("bar", item.Value.Val, "touch should keep the value")
+	s.Equal("NOT_FOUND", s.do("touch missing 10"))
+
+	s.Equal("STORED", s.do("append foo 0 5 1", "!"))
+	item, err = s.database.Get("foo")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(1000*time.Second), item.TTL, 2*time.Second, "append should ignore the time")
+}
+
+func (s *ServerSuite) TestFlushAll() {
+	s.send("set a 0 5 1", "1", "set b 0 0 1", "2")
+	s.Equal("STORED", s.read())
+	s.Equal("STORED", s.read())
+
+	s.Equal("OK", s.do("flush_all 10"))
+	item, err := s.database.Get("b")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(10*time.Second), item.TTL, 2*time.Second)
+	item, err = s.database.Get("a")
+	s.Require().NoError(err)
+	s.WithinDuration(time.Now().Add(5*time.Second), item.TTL, 2*time.Second, "the keys expiring earlier should keep their TTL")
+
+	s.Equal("OK", s.do("flush_all"))
+	s.Empty(s.database.Keys("*"))
+}
+
+func (s *ServerSuite) TestNoreply() {
+	s.send("set foo 0 0 3 noreply", "100", "incr foo 1 noreply", "delete missing noreply", "get foo")
+	s.Equal("VALUE foo 0 3", s.read(), "the commands sent with noreply should not be answered")
+	s.Equal("101", s.read())
+	s.Equal("END", s.read())
+
+	s.Equal("STORED", s.do("set text 0 0 3", "abc"))
+	s.Equal("CLIENT_ERROR cannot increment or decrement non-numeric value", s.do("incr text 1 noreply"),
+		"the errors should be sent even with noreply")
+}
+
+func (s *ServerSuite) TestLimits() {
+	large := strings.Repeat("x", 1024*1024+1)
+	s.Equal("SERVER_ERROR object too large for cache", s.do("set large 0 0 "+strconv.Itoa(len(large)), large))
+	s.Equal("VERSION 1.0.0", s.do("version"), "the data of the large item should be discarded")
+
+	s.database.SetReadOnly(true)
+	s.Contains(s.do("set foo 0 0 3", "bar"), "SERVER_ERROR")
+}
+
+func (s *ServerSuite) TestStats() {
+	s.send("stats")
+	stats := map[string]string{}
+	for line := s.read(); line != "END"; line = s.read() {
+		fields := strings.Fields(line)
+		s.Require().Len(fields, 3, line)
+		s.Equal("STAT", fields[0])
+		stats[fields[1]] = fields[2]
+	}
+	s.Equal("1.0.0", stats["version"])
+	s.Equal("1", stats["curr_connections"])
+
+	s.send("quit")
+	_, err := s.reader.ReadString('\n')
+	s.Error(err, "the connection should be closed after quit")
+}
+
+func (s *ServerSuite) TestAuth() {
+	s.conn.Close()
+	s.server.Close()
+
+	manager, err := auth.NewManager(slog.Default(), s.database, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	s.Require().NoError(manager.Register("admin", "admin-password", "alice", "secret"))
+	rules := []db.ACLRule{
+		{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}},
+		{Pattern: "config:*", Permissions: []enums.Permission{enums.PermissionRead}},
+	}
+	_, key, err := manager.CreateAPIKey("billing", "", rules)
+	s.Require().NoError(err)
+	s.start(memcache.WithAuthManager{AuthManager: manager})
+
+	s.Equal("CLIENT_ERROR unauthenticated", s.do("get foo"))
+	s.Equal("CLIENT_ERROR unauthenticated", s.do("add foo 0 0 3", "bar"), "the data should be discarded")
+	s.Equal("CLIENT_ERROR authentication failure", s.do("set auth 0 0 11", "alice wrong"))
+	s.Equal("CLIENT_ERROR unauthenticated", s.do("get foo"))
+
+	// the users can access every key
+	s.Equal("STORED", s.do("set auth 0 0 12", "alice secret"))
+	s.Equal("END", s.do("get auth"), "the credentials should not be stored")
+	s.Equal("STORED", s.do("set config:1 0 0 2", "on"))
+
+	// the API keys can only access the keys of their rules
+	s.conn.Close()
+	s.dial()
+	s.Equal("STORED", s.do(fmt.Sprintf("set auth 0 0 %d", len(key)), key))
+	s.Equal("STORED", s.do("set billing:1 0 0 4", "paid"))
+	s.Equal("VALUE config:1 0 2", s.do("get config:1"))
+	s.Equal("on", s.read())
+	s.Equal("END", s.read())
+	s.Equal("CLIENT_ERROR permission denied", s.do("set config:1 0 0 3", "off"))
+	s.Equal("CLIENT_ERROR permission denied", s.do("get billing:1 foo"))
+	s.Equal("CLIENT_ERROR permission denied", s.do("flush_all"))
+	s.Equal("DELETED", s.do("delete billing:1"))
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,274 @@
+/*
+The package metrics exposes the counters of the server in the Prometheus text format.
+
+The collectors are registered in a registry of their own, served by the health server at /metrics. The database and
+the HTTP API record their metrics through the methods of Metrics, which do nothing on a nil Metrics, so the
+components work the same when the metrics are disabled.
+*/
+package metrics
+
+import (
+	"memorydb/internal/enums"
+	"memorydb/internal/ratelimit"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "memorydb" // prefix of the names of the metrics
+)
+
+// Metrics holds the collectors of the server.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTP API
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	// Database
+	operations      *prometheus.CounterVec
+	keys            *keysCollector
+	expiredKeys     prometheus.Counter
+	evictedKeys     prometheus.Counter
+	cleanupDuration prometheus.Histogram
+
+	// Persistence
+	persistedBytes prometheus.Counter
+	encodeFailures prometheus.Counter
+	replayDuration prometheus.Gauge
+}
+
+// New creates the collectors of the server, along with the collectors of the Go runtime and of the process.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests, by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Number of write operations applied to the database, by command.",
+		}, []string{"command"}),
+		keys: &keysCollector{desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "keys"),
+			"Number of keys in the database and its namespaces, by data type.",
+			[]string{"type"}, nil,
+		)},
+		expiredKeys: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "expired_keys_total",
+			Help:      "Number of keys removed because their TTL expired.",
+		}),
+		evictedKeys: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "evicted_keys_total",
+			Help:      "Number of keys evicted to free memory.",
+		}),
+		cleanupDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "cleanup_duration_seconds",
+			Help:      "Duration of the runs of the routine that removes the expired keys.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 10),
+		}),
+		persistedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "persistence_written_bytes_total",
+			Help:      "Number of bytes written to the operation logs.",
+		}),
+		encodeFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "persistence_encode_failures_total",
+			Help:      "Number of operations that could not be written to the operation logs.",
+		}),
+		replayDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "persistence_replay_duration_seconds",
+			Help:      "Duration of the replay of the operation log when the database started.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.operations,
+		m.keys,
+		m.expiredKeys,
+		m.evictedKeys,
+		m.cleanupDuration,
+		m.persistedBytes,
+		m.encodeFailures,
+		m.replayDuration,
+	)
+	return m
+}
+
+// Handler returns the handler that serves the metrics in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records an HTTP request served with the status code. The route is the pattern of the route,
+// such as /api/v1/{key}, so the keys do not end up in the labels.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// CountOperation records a write operation applied to the database.
+func (m *Metrics) CountOperation(command enums.DBCommand) {
+	if m == nil {
+		return
+	}
+	m.operations.WithLabelValues(command.String()).Inc()
+}
+
+// CountKeys sets the function that counts the keys by data type when the metrics are collected.
+func (m *Metrics) CountKeys(count func() map[string]int) {
+	if m == nil {
+		return
+	}
+	m.keys.mu.Lock()
+	defer m.keys.mu.Unlock()
+	m.keys.count = count
+}
+
+// CountExpired records a key removed because its TTL expired.
+func (m *Metrics) CountExpired() {
+	if m == nil {
+		return
+	}
+	m.expiredKeys.Inc()
+}
+
+// CountEvicted records a key evicted to free memory.
+func (m *Metrics) CountEvicted() {
+	if m == nil {
+		return
+	}
+	m.evictedKeys.Inc()
+}
+
+// ObserveCleanup records a run of the routine that removes the expired keys.
+func (m *Metrics) ObserveCleanup(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.cleanupDuration.Observe(duration.Seconds())
+}
+
+// CountPersistedBytes records bytes written to an operation log.
+func (m *Metrics) CountPersistedBytes(n int) {
+	if m == nil {
+		return
+	}
+	m.persistedBytes.Add(float64(n))
+}
+
+// CountEncodeFailure records an operation that could not be written to an operation log.
+func (m *Metrics) CountEncodeFailure() {
+	if m == nil {
+		return
+	}
+	m.encodeFailures.Inc()
+}
+
+// ObserveReplay records the duration of the replay of the operation log.
+func (m *Metrics) ObserveReplay(duration time.Duration) {
+	if m == nil {
+		return
+	}
+	m.replayDuration.Set(duration.Seconds())
+}
+
+// WatchRateLimits exposes the counters of the rate limits and of the requests in flight. Either of them can be nil
+// if it is disabled.
+func (m *Metrics) WatchRateLimits(limiter *ratelimit.Limiter, inFlight *ratelimit.InFlight) {
+	if m == nil {
+		return
+	}
+	if limiter != nil {
+		m.registry.MustRegister(
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "ratelimit_allowed_total",
+				Help:      "Number of requests allowed by the rate limits.",
+			}, func() float64 { return float64(limiter.Stats().Allowed) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace:   namespace,
+				Name:        "ratelimit_limited_total",
+				Help:        "Number of requests rejected by the rate limits, by kind.",
+				ConstLabels: prometheus.Labels{"kind": "read"},
+			}, func() float64 { return float64(limiter.Stats().LimitedReads) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace:   namespace,
+				Name:        "ratelimit_limited_total",
+				Help:        "Number of requests rejected by the rate limits, by kind.",
+				ConstLabels: prometheus.Labels{"kind": "write"},
+			}, func() float64 { return float64(limiter.Stats().LimitedWrites) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "ratelimit_clients",
+				Help:      "Number of clients with a token bucket.",
+			}, func() float64 { return float64(limiter.Stats().Clients) }),
+		)
+	}
+	if inFlight != nil {
+		m.registry.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "http_requests_in_flight",
+				Help:      "Number of requests of the data routes being served.",
+			}, func() float64 { return float64(inFlight.Stats().InFlight) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_shed_total",
+				Help:      "Number of requests rejected because the server was serving as many requests as its limit.",
+			}, func() float64 { return float64(inFlight.Stats().Shed) }),
+		)
+	}
+}
+
+// keysCollector collects the number of keys by data type from the function set by the database.
+type keysCollector struct {
+	desc  *prometheus.Desc
+	mu    sync.Mutex
+	count func() map[string]int // number of keys by data type, nil until the database is created
+}
+
+func (c *keysCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *keysCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	count := c.count
+	c.mu.Unlock()
+	if count == nil {
+		return
+	}
+	for kind, n := range count() {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), kind)
+	}
+}
@@ -0,0 +1,92 @@
+package metrics_test
+
+import (
+	"io"
+	"memorydb/internal/enums"
+	"memorydb/internal/metrics"
+	"memorydb/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	suite.Suite
+}
+
+// scrape returns the metrics served by the handler in the Prometheus text format.
+func (s *MetricsSuite) scrape(m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Require().Equal(http.StatusOK, w.Code)
+	body, err := io.ReadAll(w.Body)
+	s.Require().NoError(err)
+	return string(body)
+}
+
+func (s *MetricsSuite) TestRecord() {
+	m := metrics.New()
+	m.ObserveRequest(http.MethodGet, "/api/v1/{key}", http.StatusNotFound, 10*time.Millisecond)
+	m.CountOperation(enums.DBCommandSet)
+	m.CountOperation(enums.DBCommandSet)
+	m.CountKeys(func() map[string]int { return map[string]int{"string": 3, "stream": 1} })
+	m.CountExpired()
+	m.CountEvicted()
+	m.ObserveCleanup(time.Millisecond)
+	m.CountPersistedBytes(128)
+	m.CountEncodeFailure()
+	m.ObserveReplay(2 * time.Second)
+
+	body := s.scrape(m)
+	s.Contains(body, `memorydb_http_requests_total{method="GET",route="/api/v1/{key}",status="404"} 1`)
+	s.Contains(body, `memorydb_http_request_duration_seconds_count{method="GET",route="/api/v1/{key}",status="404"} 1`)
+	s.Contains(body, `memorydb_operations_total{command="set"} 2`)
+	s.Contains(body, `memorydb_keys{type="string"} 3`)
+	s.Contains(body, `memorydb_keys{type="stream"} 1`)
+	s.Contains(body, `memorydb_expired_keys_total 1`)
+	s.Contains(body, `memorydb_evicted_keys_total 1`)
+	s.Contains(body, `memorydb_cleanup_duration_seconds_count 1`)
+	s.Contains(body, `memorydb_persistence_written_bytes_total 128`)
+	s.Contains(body, `memorydb_persistence_encode_failures_total 1`)
+	s.Contains(body, `memorydb_persistence_replay_duration_seconds 2`)
+	s.Contains(body, `go_goroutines`, "the metrics of the Go runtime should be served too")
+}
+
+func (s *MetricsSuite) TestRateLimits() {
+	m := metrics.New()
+	limiter := ratelimit.NewLimiter(ratelimit.Limits{Rate: 1}, ratelimit.Limits{Rate: 1})
+	inFlight := ratelimit.NewInFlight(10)
+	m.WatchRateLimits(limiter, inFlight)
+
+	limiter.Allow("a", enums.PermissionWrite)
+	limiter.Allow("a", enums.PermissionWrite)
+
+	body := s.scrape(m)
+	s.Contains(body, `memorydb_ratelimit_allowed_total 1`)
+	s.Contains(body, `memorydb_ratelimit_limited_total{kind="write"} 1`)
+	s.Contains(body, `memorydb_ratelimit_limited_total{kind="read"} 0`)
+	s.Contains(body, `memorydb_http_requests_in_flight 0`)
+}
+
+func (s *MetricsSuite) TestNil() {
+	var m *metrics.Metrics
+	s.NotPanics(func() {
+		m.ObserveRequest(http.MethodGet, "/", http.StatusOK, time.Second)
+		m.CountOperation(enums.DBCommandSet)
+		m.CountKeys(nil)
+		m.CountExpired()
+		m.CountEvicted()
+		m.ObserveCleanup(time.Second)
+		m.CountPersistedBytes(1)
+		m.CountEncodeFailure()
+		m.ObserveReplay(time.Second)
+		m.WatchRateLimits(nil, nil)
+	}, "a nil Metrics should record nothing")
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
@@ -0,0 +1,299 @@
+/*
+The package proxy implements the pool of backends of the sharding proxy.
+
+Every key belongs to one of the backends, chosen with the same consistent hashing ring as the sharded client of the
+godb package, so the proxy and the Go clients place the keys in the same servers. The backends are health-checked
+in the background: a backend that fails several checks in a row is taken out of the ring, and its keys are served
+by the remaining backends until it passes a check again. The data of the failed backend is not moved.
+*/
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/hashring"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthInterval   = 2 * time.Second // default interval between the health checks of a backend
+	defaultHealthTimeout    = time.Second     // default timeout of a health check
+	defaultFailureThreshold = 3               // default number of failed checks in a row that take a backend out of rotation
+)
+
+// ErrNoBackends is returned when every backend is out of rotation.
+var ErrNoBackends = errors.New("no backend available")
+
+// Backend is a memorydb server behind the proxy.
+type Backend struct {
+	URL       string `json:"url"`        // base URL of the API of the server
+	HealthURL string `json:"health_url"` // URL of the health check of the server, served by its health server
+}
+
+// ParseBackends parses a comma separated list of backends in the form <API URL>=<health check URL>,
+// such as http://db1:8080=http://db1:8081/health.
+func ParseBackends(raw string) ([]Backend, error) {
+	var backends []Backend
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		apiURL, healthURL, ok := strings.Cut(entry, "=")
+		if !ok || !isURL(apiURL) || !isURL(healthURL) {
+			return nil, fmt.Errorf("invalid backend '%s', expected <API URL>=<health check URL>", entry)
+		}
+		apiURL = strings.TrimSuffix(apiURL, "/")
+		if seen[apiURL] {
+			return nil, fmt.Errorf("duplicated backend %s", apiURL)
+		}
+		seen[apiURL] = true
+		backends = append(backends, Backend{URL: apiURL, HealthURL: healthURL})
+	}
+	return backends, nil
+}
+
+// isURL returns whether the value is an absolute HTTP URL.
+func isURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// BackendStatus is the health of a backend.
+type BackendStatus struct {
+	Backend
+	Healthy   bool      `json:"healthy"`              // whether the backend is in rotation
+	Failures  int       `json:"failures"`             // number of failed checks in a row
+	LastCheck time.Time `json:"last_check"`           // time of the last check, zero if it has not been checked yet
+	LastError string    `json:"last_error,omitempty"` // error of the last failed check
+}
+
+// PoolOptions defines an interface for applying options to the Pool.
+type PoolOptions interface {
+	apply(*Pool)
+}
+
+// WithHealthInterval sets the interval between the health checks of every backend.
+type WithHealthInterval time.Duration
+
+func (o WithHealthInterval) apply(p *Pool) {
+	if o > 0 {
+		p.interval = time.Duration(o)
+	}
+}
+
+// WithHealthTimeout sets the timeout of a health check.
+type WithHealthTimeout time.Duration
+
+func (o WithHealthTimeout) apply(p *Pool) {
+	if o > 0 {
+		p.client.Timeout = time.Duration(o)
+	}
+}
+
+// WithFailureThreshold sets the number of failed checks in a row that take a backend out of rotation.
+type WithFailureThreshold int
+
+func (o WithFailureThreshold) apply(p *Pool) {
+	if o > 0 {
+		p.threshold = int(o)
+	}
+}
+
+// WithVirtualNodes sets the number of points of every backend in the hash ring. It must match the
+// number used by the Go clients that share the backends.
+type WithVirtualNodes int
+
+func (o WithVirtualNodes) apply(p *Pool) {
+	p.virtualNodes = int(o)
+}
+
+// Pool routes the keys to the healthy backends.
+type Pool struct {
+	logger       *slog.Logger
+	client       *http.Client // client of the health checks
+	interval     time.Duration
+	threshold    int
+	virtualNodes int
+
+	mu       sync.RWMutex
+	backends []*BackendStatus
+	ring     *hashring.Ring // ring of the backends in rotation
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewPool creates a pool of the backends. Every backend is in rotation until it fails its health checks.
+func NewPool(logger *slog.Logger, backends []Backend, opts ...PoolOptions) *Pool {
+	p := &Pool{
+		logger:    logger,
+		client:    &http.Client{Timeout: defaultHealthTimeout},
+		interval:  defaultHealthInterval,
+		threshold: defaultFailureThreshold,
+	}
+	for _, opt := range opts {
+		opt.apply(p)
+	}
+
+	p.ring = hashring.New(p.virtualNodes)
+	for _, backend := range backends {
+		p.backends = append(p.backends, &BackendStatus{Backend: backend, Healthy: true})
+		p.ring.Add(backend.URL)
+	}
+	return p
+}
+
+// Start checks the health of the backends in the background until the context is cancelled or the pool is stopped.
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.done = make(chan struct{})
+	go p.run(ctx, p.done)
+}
+
+// Stop stops the health checks and waits until they return.
+func (p *Pool) Stop() {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel = nil
+	p.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Pick returns the URL of the backend of the key, or ErrNoBackends if every backend is out of rotation.
+func (p *Pool) Pick(key string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	backend, ok := p.ring.Get(key)
+	if !ok {
+		return "", ErrNoBackends
+	}
+	return backend, nil
+}
+
+// Healthy returns the URLs of the backends in rotation, sorted, or ErrNoBackends if there is none.
+func (p *Pool) Healthy() ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	backends := p.ring.Nodes()
+	if len(backends) == 0 {
+		return nil, ErrNoBackends
+	}
+	return backends, nil
+}
+
+// Status returns the health of every backend.
+func (p *Pool) Status() []BackendStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := make([]BackendStatus, len(p.backends))
+	for i, backend := range p.backends {
+		status[i] = *backend
+	}
+	return status
+}
+
+// run checks the health of the backends at every interval until the context is cancelled.
+func (p *Pool) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll checks the health of every backend concurrently.
+func (p *Pool) checkAll(ctx context.Context) {
+	p.mu.RLock()
+	backends := make([]Backend, len(p.backends))
+	for i, backend := range p.backends {
+		backends[i] = backend.Backend
+	}
+	p.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.check(ctx, backend)
+			if ctx.Err() != nil {
+				return // the pool is stopping, the backend did not fail
+			}
+			p.record(i, err)
+		}()
+	}
+	wg.Wait()
+}
+
+// check requests the health check of the backend.
+func (p *Pool) check(ctx context.Context, backend Backend) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.HealthURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to check health: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// record updates the health of the backend with the result of a check, moving it in or out of rotation.
+func (p *Pool) record(i int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	backend := p.backends[i]
+	backend.LastCheck = time.Now()
+	if err == nil {
+		backend.Failures = 0
+		backend.LastError = ""
+		if !backend.Healthy {
+			backend.Healthy = true
+			p.ring.Add(backend.URL)
+			p.logger.Info("backend back in rotation", "backend", backend.URL)
+		}
+		return
+	}
+
+	backend.Failures++
+	backend.LastError = err.Error()
+	if backend.Healthy && backend.Failures >= p.threshold {
+		backend.Healthy = false
+		p.ring.Remove(backend.URL)
+		p.logger.Warn("backend taken out of rotation", "backend", backend.URL, "failures", backend.Failures, "error", err)
+	}
+}
@@ -0,0 +1,129 @@
+package proxy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/proxy"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PoolSuite struct {
+	healthy  []*atomic.Bool // health of every backend
+	backends []proxy.Backend
+	servers  []*httptest.Server
+	pool     *proxy.Pool
+	suite.Suite
+}
+
+func (s *PoolSuite) SetupTest() {
+	s.healthy, s.backends, s.servers = nil, nil, nil
+	for i := range 3 {
+		healthy := new(atomic.Bool)
+		healthy.Store(true)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		s.healthy = append(s.healthy, healthy)
+		s.servers = append(s.servers, server)
+		s.backends = append(s.backends, proxy.Backend{URL: fmt.Sprintf("http://db%d:8080", i), HealthURL: server.URL})
+	}
+
+	s.pool = proxy.NewPool(slog.Default(), s.backends, proxy.WithHealthInterval(10*time.Millisecond), proxy.WithFailureThreshold(2))
+}
+
+func (s *PoolSuite) TearDownTest() {
+	s.pool.Stop()
+	for _, server := range s.servers {
+		server.Close()
+	}
+}
+
+// inRotation returns whether the backend is in rotation.
+func (s *PoolSuite) inRotation(url string) bool {
+	backends, err := s.pool.Healthy()
+	if err != nil {
+		return false
+	}
+	for _, backend := range backends {
+		if backend == url {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PoolSuite) TestParseBackends() {
+	backends, err := proxy.ParseBackends("http://db1:8080/=http://db1:8081/health, http://db2:8080=http://db2:8081/health")
+	s.Require().NoError(err)
+	s.Equal([]proxy.Backend{
+		{URL: "http://db1:8080", HealthURL: "http://db1:8081/health"},
+		{URL: "http://db2:8080", HealthURL: "http://db2:8081/health"},
+	}, backends)
+
+	for _, raw := range []string{"http://db1:8080", "db1:8080=http://db1:8081/health", "http://db1:8080=http://db1:8081,http://db1:8080=http://db1:8081"} {
+		_, err := proxy.ParseBackends(raw)
+		s.Error(err, "backends '%s' should be rejected", raw)
+	}
+}
+
+func (s *PoolSuite) TestPick() {
+	// every key is always routed to the same backend
+	for i := range 100 {
+		key := fmt.Sprintf("key:%d", i)
+		backend, err := s.pool.Pick(key)
+		s.Require().NoError(err)
+		again, err := s.pool.Pick(key)
+		s.Require().NoError(err)
+		s.Equal(backend, again)
+	}
+}
+
+func (s *PoolSuite) TestHealthCheck() {
+	s.pool.Start(context.Background())
+
+	// a failing backend is taken out of rotation after the failure threshold
+	s.healthy[1].Store(false)
+	s.Eventually(func() bool { return !s.inRotation(s.backends[1].URL) }, 2*time.Second, 10*time.Millisecond)
+	for i := range 100 {
+		backend, err := s.pool.Pick(fmt.Sprintf("key:%d", i))
+		s.Require().NoError(err)
+		s.NotEqual(s.backends[1].URL, backend, "keys should not be routed to failed backends")
+	}
+
+	status := s.pool.Status()
+	s.False(status[1].Healthy)
+	s.GreaterOrEqual(status[1].Failures, 2)
+	s.Contains(status[1].LastError, "503")
+	s.True(status[0].Healthy)
+
+	// and put back once it recovers
+	s.healthy[1].Store(true)
+	s.Eventually(func() bool { return s.inRotation(s.backends[1].URL) }, 2*time.Second, 10*time.Millisecond)
+	s.Equal(0, s.pool.Status()[1].Failures)
+
+	// without backends, no key can be routed
+	for _, healthy := range s.healthy {
+		healthy.Store(false)
+	}
+	s.Eventually(func() bool {
+		_, err := s.pool.Pick("key")
+		return errors.Is(err, proxy.ErrNoBackends)
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestPoolSuite(t *testing.T) {
+	suite.Run(t, new(PoolSuite))
+}
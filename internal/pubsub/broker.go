@@ -0,0 +1,275 @@
+/*
+The package pubsub implements Redis-style publish/subscribe messaging.
+
+Messages are published to named channels and delivered to every subscriber of the channel, and to every subscriber
+of a glob pattern that matches the channel. Delivery is at-most-once: messages are not stored, so subscribers
+only receive the messages published while they are connected. Every subscriber has a bounded buffer, and
+a subscriber that does not keep up with the messages is dropped instead of slowing down the publishers.
+*/
+package pubsub
+
+import (
+	"log/slog"
+	"memorydb/internal/glob"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBufferSize = 128 // default number of messages buffered per subscriber
+)
+
+// Message is a message delivered to a subscriber.
+type Message struct {
+	Channel string    `json:"channel"`           // channel the message was published to
+	Pattern string    `json:"pattern,omitempty"` // pattern that matched the channel, empty for channel subscriptions
+	Payload string    `json:"payload"`           // content of the message
+	Time    time.Time `json:"time"`              // time the message was published
+}
+
+// Stats is a snapshot of the counters of the broker.
+type Stats struct {
+	Channels    int    `json:"channels"`    // number of channels with at least one subscriber
+	Patterns    int    `json:"patterns"`    // number of subscribed patterns
+	Subscribers int    `json:"subscribers"` // number of connected subscribers
+	Published   uint64 `json:"published"`   // number of messages published
+	Delivered   uint64 `json:"delivered"`   // number of messages delivered to subscribers
+	Dropped     uint64 `json:"dropped"`     // number of subscribers dropped because they were too slow
+}
+
+// BrokerOptions defines an interface for applying options to the Broker.
+type BrokerOptions interface {
+	apply(*Broker)
+}
+
+// WithBufferSize sets the number of messages buffered per subscriber before it is dropped.
+type WithBufferSize int
+
+func (o WithBufferSize) apply(b *Broker) {
+	if o > 0 {
+		b.bufferSize = int(o)
+	}
+}
+
+// Broker routes published messages to the subscribers of channels and patterns.
+type Broker struct {
+	logger     *slog.Logger
+	bufferSize int // number of messages buffered per subscriber
+
+	mu          sync.RWMutex
+	closed      bool                              // whether the broker has been closed
+	channels    map[string]map[uint64]*Subscriber // subscribers by channel
+	patterns    map[string]map[uint64]*Subscriber // subscribers by pattern
+	subscribers map[uint64]*Subscriber            // connected subscribers by ID
+	nextID      uint64                            // ID of the next subscriber
+
+	published atomic.Uint64
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// NewBroker creates a new Broker with the given options.
+func NewBroker(logger *slog.Logger, opts ...BrokerOptions) *Broker {
+	b := &Broker{
+		logger:      logger,
+		bufferSize:  defaultBufferSize,
+		channels:    make(map[string]map[uint64]*Subscriber),
+		patterns:    make(map[string]map[uint64]*Subscriber),
+		subscribers: make(map[uint64]*Subscriber),
+	}
+
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+
+	return b
+}
+
+// Publish sends the payload to the subscribers of the channel and of the patterns that match it.
+// It returns the number of subscribers that received the message.
+func (b *Broker) Publish(channel, payload string) int {
+	b.published.Add(1)
+	now := time.Now()
+
+	var (
+		receivers int
+		slow      []*Subscriber
+	)
+
+	b.mu.RLock()
+	deliver := func(sub *Subscriber, msg Message) {
+		select {
+		case sub.ch <- msg:
+			receivers++
+		default:
+			slow = append(slow, sub)
+		}
+	}
+
+	for _, sub := range b.channels[channel] {
+		deliver(sub, Message{Channel: channel, Payload: payload, Time: now})
+	}
+	for pattern, subs := range b.patterns {
+		if !glob.Match(pattern, channel) {
+			continue
+		}
+		for _, sub := range subs {
+			deliver(sub, Message{Channel: channel, Pattern: pattern, Payload: payload, Time: now})
+		}
+	}
+	b.mu.RUnlock()
+
+	// slow subscribers are dropped once the read lock is released, since it is needed to close their channel
+	for _, sub := range slow {
+		b.logger.Warn("pubsub subscriber is too slow, dropping it", "subscriber", sub.id, "channel", channel)
+		b.dropped.Add(1)
+		b.remove(sub)
+	}
+
+	b.delivered.Add(uint64(receivers))
+	return receivers
+}
+
+// Subscribe creates a subscriber for the given channels and glob patterns.
+// If the broker has been closed, the channel of the returned subscriber is already closed.
+func (b *Broker) Subscribe(channels []string, patterns []string) *Subscriber {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	sub := &Subscriber{
+		id:       b.nextID,
+		broker:   b,
+		channels: channels,
+		patterns: patterns,
+		ch:       make(chan Message, b.bufferSize),
+	}
+
+	if b.closed {
+		close(sub.ch)
+		return sub
+	}
+
+	b.subscribers[sub.id] = sub
+	for _, channel := range channels {
+		if b.channels[channel] == nil {
+			b.channels[channel] = make(map[uint64]*Subscriber)
+		}
+		b.channels[channel][sub.id] = sub
+	}
+	for _, pattern := range patterns {
+		if b.patterns[pattern] == nil {
+			b.patterns[pattern] = make(map[uint64]*Subscriber)
+		}
+		b.patterns[pattern][sub.id] = sub
+	}
+
+	return sub
+}
+
+// NumSubscribers returns the number of subscribers of every channel that matches the glob pattern.
+// Pattern subscriptions are not counted, like the Redis command PUBSUB NUMSUB.
+func (b *Broker) NumSubscribers(pattern string) map[string]int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for channel, subs := range b.channels {
+		if glob.Match(pattern, channel) {
+			counts[channel] = len(subs)
+		}
+	}
+	return counts
+}
+
+// Patterns returns the subscribed patterns, sorted alphabetically.
+func (b *Broker) Patterns() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	patterns := make([]string, 0, len(b.patterns))
+	for pattern := range b.patterns {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// Stats returns a snapshot of the counters of the broker.
+func (b *Broker) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return Stats{
+		Channels:    len(b.channels),
+		Patterns:    len(b.patterns),
+		Subscribers: len(b.subscribers),
+		Published:   b.published.Load(),
+		Delivered:   b.delivered.Load(),
+		Dropped:     b.dropped.Load(),
+	}
+}
+
+// Close disconnects all the subscribers. Subscribers created afterwards are closed immediately.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, sub := range b.subscribers {
+		b.unsubscribe(sub)
+	}
+}
+
+// remove disconnects the subscriber from the broker.
+func (b *Broker) remove(sub *Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.unsubscribe(sub)
+}
+
+// unsubscribe removes the subscriber from every channel and pattern and closes its channel.
+// It must be called with the lock held.
+func (b *Broker) unsubscribe(sub *Subscriber) {
+	if _, exists := b.subscribers[sub.id]; !exists {
+		return
+	}
+	delete(b.subscribers, sub.id)
+
+	for _, channel := range sub.channels {
+		delete(b.channels[channel], sub.id)
+		if len(b.channels[channel]) == 0 {
+			delete(b.channels, channel)
+		}
+	}
+	for _, pattern := range sub.patterns {
+		delete(b.patterns[pattern], sub.id)
+		if len(b.patterns[pattern]) == 0 {
+			delete(b.patterns, pattern)
+		}
+	}
+
+	close(sub.ch)
+}
+
+// Subscriber receives the messages of the channels and patterns it is subscribed to.
+type Subscriber struct {
+	id       uint64
+	broker   *Broker
+	channels []string
+	patterns []string
+	ch       chan Message
+}
+
+// Messages returns the channel where the messages are delivered. It is closed when the subscriber is closed,
+// when it is dropped for being too slow or when the broker is closed.
+func (s *Subscriber) Messages() <-chan Message {
+	return s.ch
+}
+
+// Close unsubscribes the subscriber from all its channels and patterns.
+func (s *Subscriber) Close() {
+	s.broker.remove(s)
+}
@@ -0,0 +1,128 @@
+package pubsub_test
+
+import (
+	"log/slog"
+	"memorydb/internal/pubsub"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BrokerSuite struct {
+	broker *pubsub.Broker
+	suite.Suite
+}
+
+func (s *BrokerSuite) SetupTest() {
+	s.broker = pubsub.NewBroker(slog.Default(), pubsub.WithBufferSize(2))
+}
+
+func (s *BrokerSuite) TearDownTest() {
+	s.broker.Close()
+}
+
+// receive reads a message from the subscriber or fails the test after a timeout.
+func (s *BrokerSuite) receive(sub *pubsub.Subscriber) pubsub.Message {
+	select {
+	case msg, ok := <-sub.Messages():
+		s.Require().True(ok, "subscriber channel closed unexpectedly")
+		return msg
+	case <-time.After(time.Second):
+		s.FailNow("timeout waiting for message")
+	}
+	return pubsub.Message{}
+}
+
+func (s *BrokerSuite) TestPublishToChannel() {
+	sub := s.broker.Subscribe([]string{"news"}, nil)
+	defer sub.Close()
+
+	s.Equal(1, s.broker.Publish("news", "hello"))
+	s.Equal(0, s.broker.Publish("other", "ignored"))
+
+	msg := s.receive(sub)
+	s.Equal("news", msg.Channel)
+	s.Equal("hello", msg.Payload)
+	s.Empty(msg.Pattern)
+}
+
+func (s *BrokerSuite) TestPublishToPattern() {
+	sub := s.broker.Subscribe(nil, []string{"news.*"})
+	defer sub.Close()
+
+	s.Equal(1, s.broker.Publish("news.sports", "goal"))
+	s.Equal(0, s.broker.Publish("weather", "rain"))
+
+	msg := s.receive(sub)
+	s.Equal("news.sports", msg.Channel)
+	s.Equal("news.*", msg.Pattern)
+	s.Equal("goal", msg.Payload)
+}
+
+func (s *BrokerSuite) TestChannelAndPatternReceiveTwice() {
+	sub := s.broker.Subscribe([]string{"news.sports"}, []string{"news.*"})
+	defer sub.Close()
+
+	s.Equal(2, s.broker.Publish("news.sports", "goal"), "like Redis, a message matching both subscriptions is delivered twice")
+	s.receive(sub)
+	s.receive(sub)
+}
+
+func (s *BrokerSuite) TestSlowSubscriberIsDropped() {
+	slow := s.broker.Subscribe([]string{"news"}, nil)
+	fast := s.broker.Subscribe([]string{"news"}, nil)
+	defer fast.Close()
+
+	// the buffer size is 2, so the third message drops both subscribers unless they read
+	s.broker.Publish("news", "1")
+	s.broker.Publish("news", "2")
+	s.receive(fast)
+	s.receive(fast)
+	s.Equal(1, s.broker.Publish("news", "3"))
+
+	// the buffered messages are delivered before the channel of the dropped subscriber is closed
+	s.receive(slow)
+	s.receive(slow)
+	_, ok := <-slow.Messages()
+	s.False(ok, "slow subscriber must be dropped")
+	s.Equal(uint64(1), s.broker.Stats().Dropped)
+	s.Equal(map[string]int{"news": 1}, s.broker.NumSubscribers(""))
+}
+
+func (s *BrokerSuite) TestCounters() {
+	a := s.broker.Subscribe([]string{"a", "b"}, []string{"c*"})
+	b := s.broker.Subscribe([]string{"a"}, nil)
+
+	s.Equal(map[string]int{"a": 2, "b": 1}, s.broker.NumSubscribers(""))
+	s.Equal(map[string]int{"b": 1}, s.broker.NumSubscribers("b*"))
+	s.Equal([]string{"c*"}, s.broker.Patterns())
+
+	stats := s.broker.Stats()
+	s.Equal(2, stats.Channels)
+	s.Equal(1, stats.Patterns)
+	s.Equal(2, stats.Subscribers)
+
+	a.Close()
+	b.Close()
+	s.Empty(s.broker.NumSubscribers(""))
+	s.Empty(s.broker.Patterns())
+	s.Equal(0, s.broker.Stats().Subscribers)
+}
+
+func (s *BrokerSuite) TestClose() {
+	sub := s.broker.Subscribe([]string{"news"}, nil)
+	s.broker.Close()
+
+	_, ok := <-sub.Messages()
+	s.False(ok, "closing the broker must disconnect the subscribers")
+
+	late := s.broker.Subscribe([]string{"news"}, nil)
+	_, ok = <-late.Messages()
+	s.False(ok, "subscribers created after closing the broker must be closed")
+	sub.Close() // closing twice is safe
+}
+
+func TestBroker(t *testing.T) {
+	suite.Run(t, new(BrokerSuite))
+}
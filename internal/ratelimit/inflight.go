@@ -0,0 +1,47 @@
+package ratelimit
+
+import "sync/atomic"
+
+// InFlightStats is a snapshot of the counters of the limit of requests in flight.
+type InFlightStats struct {
+	InFlight int64  `json:"in_flight"` // number of requests being served
+	Max      int64  `json:"max"`       // maximum number of requests served at once
+	Shed     uint64 `json:"shed"`      // number of requests rejected because the server was at the limit
+}
+
+// InFlight limits the number of requests served at once by the server, so the requests beyond the limit are
+// rejected at once instead of slowing down the requests in progress.
+type InFlight struct {
+	max      int64
+	inFlight atomic.Int64
+	shed     atomic.Uint64
+}
+
+// NewInFlight creates a limit of max requests in flight.
+func NewInFlight(max int) *InFlight {
+	return &InFlight{max: int64(max)}
+}
+
+// Acquire reserves a place for a request and reports whether there was one. Every acquired place must be released.
+func (f *InFlight) Acquire() bool {
+	for {
+		n := f.inFlight.Load()
+		if n >= f.max {
+			f.shed.Add(1)
+			return false
+		}
+		if f.inFlight.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// Release frees the place of a request that has been served.
+func (f *InFlight) Release() {
+	f.inFlight.Add(-1)
+}
+
+// Stats returns a snapshot of the counters of the limit.
+func (f *InFlight) Stats() InFlightStats {
+	return InFlightStats{InFlight: f.inFlight.Load(), Max: f.max, Shed: f.shed.Load()}
+}
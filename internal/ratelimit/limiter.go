@@ -0,0 +1,180 @@
+/*
+The package ratelimit implements the per-client rate limits and the global limit of requests in flight of the server.
+
+Every client has a token bucket for its reads and another one for its writes. A bucket holds up to Burst tokens and
+is refilled at Rate tokens per second, and every request takes a token from the bucket of its kind, so a client can
+send bursts of up to Burst requests and Rate requests per second in the long run. A request that finds the bucket
+empty is rejected with the time until the next token, instead of waiting for it.
+
+The buckets of the clients that have not sent requests for a while are full, so they are removed and created again
+on their next request, which keeps the memory bounded by the number of recent clients.
+*/
+package ratelimit
+
+import (
+	"math"
+	"memorydb/internal/enums"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// sweepInterval is the interval at which the buckets of the idle clients are removed.
+	sweepInterval = time.Minute
+)
+
+// Limits are the rate and the burst of a token bucket.
+type Limits struct {
+	Rate  float64 // tokens added per second, 0 disables the limit
+	Burst int     // maximum number of tokens, the rate rounded up if it is not greater than 0
+}
+
+// burst returns the capacity of the bucket.
+func (l Limits) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Ceil(l.Rate)
+}
+
+// Stats is a snapshot of the counters of the limiter.
+type Stats struct {
+	Clients       int    `json:"clients"`        // number of clients with a bucket
+	Allowed       uint64 `json:"allowed"`        // number of requests allowed
+	LimitedReads  uint64 `json:"limited_reads"`  // number of reads rejected by the rate limit
+	LimitedWrites uint64 `json:"limited_writes"` // number of writes rejected by the rate limit
+}
+
+// LimiterOptions defines an interface for applying options to the Limiter.
+type LimiterOptions interface {
+	apply(*Limiter)
+}
+
+// WithClock sets the source of the current time the buckets are refilled with.
+type WithClock func() time.Time
+
+func (o WithClock) apply(l *Limiter) {
+	l.clock = o
+}
+
+// bucketKey identifies the bucket of a kind of requests of a client.
+type bucketKey struct {
+	client     string
+	permission enums.Permission
+}
+
+// bucket is a token bucket, refilled when it is used.
+type bucket struct {
+	tokens float64   // tokens left at the last update
+	last   time.Time // time of the last update
+}
+
+// Limiter limits the rate of the reads and the writes of every client.
+type Limiter struct {
+	reads  Limits
+	writes Limits
+	clock  func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time // last time the buckets of the idle clients were removed
+
+	allowed       atomic.Uint64
+	limitedReads  atomic.Uint64
+	limitedWrites atomic.Uint64
+}
+
+// NewLimiter creates a limiter with the limits of the reads and the writes of every client.
+func NewLimiter(reads, writes Limits, opts ...LimiterOptions) *Limiter {
+	l := &Limiter{
+		reads:   reads,
+		writes:  writes,
+		clock:   time.Now,
+		buckets: make(map[bucketKey]*bucket),
+	}
+	for _, opt := range opts {
+		opt.apply(l)
+	}
+	l.lastSweep = l.clock()
+	return l
+}
+
+// Allow takes a token from the bucket of the kind of request of the client. If the bucket is empty, the request
+// is not allowed and Allow returns the time until the bucket has a token again.
+func (l *Limiter) Allow(client string, permission enums.Permission) (bool, time.Duration) {
+	limits := l.limits(permission)
+	if limits.Rate <= 0 {
+		l.allowed.Add(1)
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock()
+	l.sweep(now)
+
+	key := bucketKey{client: client, permission: permission}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limits.burst(), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(limits.burst(), b.tokens+now.Sub(b.last).Seconds()*limits.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		if permission == enums.PermissionRead {
+			l.limitedReads.Add(1)
+		} else {
+			l.limitedWrites.Add(1)
+		}
+		wait := (1 - b.tokens) / limits.Rate
+		return false, time.Duration(wait * float64(time.Second))
+	}
+	b.tokens--
+	l.allowed.Add(1)
+	return true, 0
+}
+
+// Stats returns a snapshot of the counters of the limiter.
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	clients := make(map[string]struct{}, len(l.buckets))
+	for key := range l.buckets {
+		clients[key.client] = struct{}{}
+	}
+	l.mu.Unlock()
+
+	return Stats{
+		Clients:       len(clients),
+		Allowed:       l.allowed.Load(),
+		LimitedReads:  l.limitedReads.Load(),
+		LimitedWrites: l.limitedWrites.Load(),
+	}
+}
+
+// limits returns the limits of the kind of request.
+func (l *Limiter) limits(permission enums.Permission) Limits {
+	if permission == enums.PermissionRead {
+		return l.reads
+	}
+	return l.writes
+}
+
+// sweep removes the buckets that are full again, at most once every sweepInterval. It must be called with the
+// lock held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		limits := l.limits(key.permission)
+		if b.tokens+now.Sub(b.last).Seconds()*limits.Rate >= limits.burst() {
+			delete(l.buckets, key)
+		}
+	}
+}
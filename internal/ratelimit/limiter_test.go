@@ -0,0 +1,106 @@
+package ratelimit_test
+
+import (
+	"memorydb/internal/enums"
+	"memorydb/internal/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LimiterSuite struct {
+	now     time.Time
+	limiter *ratelimit.Limiter
+	suite.Suite
+}
+
+func (s *LimiterSuite) SetupTest() {
+	s.now = time.Now()
+	s.limiter = ratelimit.NewLimiter(
+		ratelimit.Limits{Rate: 10},
+		ratelimit.Limits{Rate: 1, Burst: 2},
+		ratelimit.WithClock(func() time.Time { return s.now }),
+	)
+}
+
+func (s *LimiterSuite) TestAllow() {
+	for i := 0; i < 2; i++ {
+		ok, _ := s.limiter.Allow("a", enums.PermissionWrite)
+		s.True(ok, "the burst should be allowed")
+	}
+	ok, wait := s.limiter.Allow("a", enums.PermissionWrite)
+	s.False(ok)
+	s.Equal(time.Second, wait)
+
+	// the reads and the other clients have their own buckets
+	ok, _ = s.limiter.Allow("a", enums.PermissionRead)
+	s.True(ok)
+	ok, _ = s.limiter.Allow("b", enums.PermissionWrite)
+	s.True(ok)
+
+	s.now = s.now.Add(500 * time.Millisecond)
+	ok, wait = s.limiter.Allow("a", enums.PermissionWrite)
+	s.False(ok)
+	s.Equal(500*time.Millisecond, wait)
+	s.now = s.now.Add(500 * time.Millisecond)
+	ok, _ = s.limiter.Allow("a", enums.PermissionWrite)
+	s.True(ok, "a token should be added every second")
+
+	// the bucket of the reads is full again, and its burst is the rate
+	for i := 0; i < 10; i++ {
+		ok, _ := s.limiter.Allow("a", enums.PermissionRead)
+		s.True(ok)
+	}
+	ok, wait = s.limiter.Allow("a", enums.PermissionRead)
+	s.False(ok)
+	s.Equal(100*time.Millisecond, wait)
+
+	stats := s.limiter.Stats()
+	s.Equal(2, stats.Clients)
+	s.Equal(uint64(15), stats.Allowed)
+	s.Equal(uint64(1), stats.LimitedReads)
+	s.Equal(uint64(2), stats.LimitedWrites)
+}
+
+func (s *LimiterSuite) TestUnlimited() {
+	limiter := ratelimit.NewLimiter(ratelimit.Limits{}, ratelimit.Limits{Rate: 1})
+	for i := 0; i < 100; i++ {
+		ok, _ := limiter.Allow("a", enums.PermissionRead)
+		s.True(ok, "a rate of 0 should not limit the reads")
+	}
+	s.Equal(0, limiter.Stats().Clients, "the unlimited requests should not create buckets")
+}
+
+func (s *LimiterSuite) TestSweep() {
+	s.limiter.Allow("a", enums.PermissionWrite)
+	s.limiter.Allow("a", enums.PermissionWrite)
+	s.limiter.Allow("b", enums.PermissionRead)
+	s.Equal(2, s.limiter.Stats().Clients)
+
+	// the bucket of b is full again after a second, but a has sent requests since then
+	s.now = s.now.Add(time.Minute - time.Second)
+	s.limiter.Allow("a", enums.PermissionWrite)
+	s.limiter.Allow("a", enums.PermissionWrite)
+	s.now = s.now.Add(time.Second)
+	s.limiter.Allow("a", enums.PermissionRead)
+	s.Equal(1, s.limiter.Stats().Clients, "the full buckets should be removed")
+}
+
+func (s *LimiterSuite) TestInFlight() {
+	inFlight := ratelimit.NewInFlight(2)
+	s.True(inFlight.Acquire())
+	s.True(inFlight.Acquire())
+	s.False(inFlight.Acquire(), "the requests beyond the limit should be shed")
+	inFlight.Release()
+	s.True(inFlight.Acquire())
+
+	stats := inFlight.Stats()
+	s.Equal(int64(2), stats.InFlight)
+	s.Equal(int64(2), stats.Max)
+	s.Equal(uint64(1), stats.Shed)
+}
+
+func TestLimiterSuite(t *testing.T) {
+	suite.Run(t, new(LimiterSuite))
+}
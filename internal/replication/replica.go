@@ -0,0 +1,399 @@
+/*
+The package replication implements the replica side of the primary-to-replica asynchronous replication.
+
+A replica downloads a snapshot of the primary and then tails the stream of operations the primary logs, applying
+them in order to its local database, which is read-only while it is a replica. Every operation has a replication
+offset, so a replica that loses the connection resumes from the last offset it applied, and does a new full
+synchronization only if the primary no longer keeps the operations after that offset.
+
+Replication is asynchronous: the primary does not wait for the replicas, so a replica can lag behind and
+the writes acknowledged by the primary and not yet streamed are lost if the primary fails.
+*/
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/db"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRetryInterval = time.Second // default time to wait before reconnecting to the primary
+
+	// HeartbeatInterval is the interval at which the primary sends its offset to the replicas on idle streams.
+	HeartbeatInterval = time.Second
+
+	// heartbeatTimeout is the time without messages after which the replica considers the primary unreachable.
+	heartbeatTimeout = 5 * HeartbeatInterval
+)
+
+// Stream and event names shared by the primary and the replicas.
+const (
+	SnapshotPath = "/api/v1/replication/snapshot" // path where the primary serves its snapshot
+	StreamPath   = "/api/v1/replication/stream"   // path where the primary streams the operations
+	EventOp      = "op"                           // event with a replicated operation
+	EventPing    = "ping"                         // event with the current offset of the primary
+)
+
+// Roles of a server.
+const (
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+var (
+	// ErrNotReplica is returned when a server that is not a replica is promoted.
+	ErrNotReplica = errors.New("the server is not a replica")
+
+	// errResync is returned when the replica must do a full synchronization before streaming again.
+	errResync = errors.New("full synchronization required")
+)
+
+// Heartbeat is the data of the ping events sent by the primary.
+type Heartbeat struct {
+	Offset uint64 `json:"offset"` // offset of the last operation logged by the primary
+}
+
+// Status is a snapshot of the replication state of a replica.
+type Status struct {
+	Role          string    `json:"role"`           // role of the server, replica until it is promoted
+	Primary       string    `json:"primary"`        // URL of the primary
+	Connected     bool      `json:"connected"`      // whether the replica is streaming from the primary
+	Offset        uint64    `json:"offset"`         // offset of the last operation applied
+	PrimaryOffset uint64    `json:"primary_offset"` // last offset known of the primary
+	Lag           uint64    `json:"lag"`            // number of operations the replica is behind the primary
+	LastContact   time.Time `json:"last_contact"`   // last time a message was received from the primary
+	FullSyncs     int       `json:"full_syncs"`     // number of full synchronizations done
+}
+
+// ReplicaOptions defines an interface for applying options to the Replica.
+type ReplicaOptions interface {
+	apply(*Replica)
+}
+
+// WithRetryInterval sets the time to wait before reconnecting to the primary after an error.
+type WithRetryInterval time.Duration
+
+func (o WithRetryInterval) apply(r *Replica) {
+	if o > 0 {
+		r.retryInterval = time.Duration(o)
+	}
+}
+
+// WithBasicAuth sets the credentials of the administrator sent to the primary, which requires them on the
+// replication endpoints when the authentication is enabled.
+type WithBasicAuth struct{ Username, Password string }
+
+func (o WithBasicAuth) apply(r *Replica) {
+	r.username, r.password = o.Username, o.Password
+}
+
+// Replica keeps the local database in sync with a primary.
+type Replica struct {
+	logger        *slog.Logger
+	db            db.DBClient
+	primary       string        // base URL of the primary
+	retryInterval time.Duration // time to wait before reconnecting to the primary
+	client        *http.Client  // client used to download the snapshots and stream the operations
+	username      string        // username of the administrator of the primary, not sent if empty
+	password      string        // password of the administrator of the primary
+
+	mu       sync.Mutex
+	status   Status
+	needSync bool               // whether the next connection must start with a full synchronization
+	cancel   context.CancelFunc // stops the replication loop, nil if it is not running
+	done     chan struct{}      // closed when the replication loop returns
+}
+
+// NewReplica creates a replica of the primary listening at primaryURL that applies the operations to the database.
+func NewReplica(logger *slog.Logger, database db.DBClient, primaryURL string, opts ...ReplicaOptions) *Replica {
+	r := &Replica{
+		logger:        logger,
+		db:            database,
+		primary:       primaryURL,
+		retryInterval: defaultRetryInterval,
+		client:        &http.Client{}, // no timeout, the operation stream is long-lived
+		status:        Status{Role: RoleReplica, Primary: primaryURL},
+		needSync:      true,
+	}
+
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r
+}
+
+// Start makes the database read-only and starts replicating from the primary in the background
+// until the context is cancelled, the replica is stopped or it is promoted.
+func (r *Replica) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil || r.status.Role != RoleReplica {
+		return
+	}
+
+	r.db.SetReadOnly(true)
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.run(ctx, r.done)
+}
+
+// Stop stops replicating and waits until the replication loop returns. The database stays read-only.
+func (r *Replica) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel = nil
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Promote stops replicating and makes the database writable, turning the server into a primary.
+// It returns ErrNotReplica if the server has already been promoted.
+func (r *Replica) Promote() (Status, error) {
+	r.mu.Lock()
+	if r.status.Role != RoleReplica {
+		r.mu.Unlock()
+		return Status{}, ErrNotReplica
+	}
+	r.status.Role = RolePrimary
+	r.mu.Unlock()
+
+	r.Stop()
+	r.db.SetReadOnly(false)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Connected = false
+	r.logger.Info("replica promoted to primary", "primary", r.primary, "offset", r.status.Offset)
+	return r.status, nil
+}
+
+// Status returns the replication state of the replica.
+func (r *Replica) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	if status.PrimaryOffset > status.Offset {
+		status.Lag = status.PrimaryOffset - status.Offset
+	}
+	return status
+}
+
+// Ready returns an error while the replica is not in sync with the primary: until its full synchronization
+// completes, and while it is not streaming the operations of the primary. A promoted replica is always ready.
+func (r *Replica) Ready() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case r.status.Role != RoleReplica:
+		return nil
+	case r.needSync:
+		return errors.New("the full synchronization with the primary is not complete")
+	case !r.status.Connected:
+		return errors.New("the replica is not connected to the primary")
+	}
+	return nil
+}
+
+// run synchronizes with the primary and streams its operations, reconnecting after errors, until the context is cancelled.
+func (r *Replica) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		err := r.sync(ctx)
+		r.setConnected(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, errResync) {
+			r.logger.Warn("replication stream lost, a full synchronization is needed", "primary", r.primary, "error", err)
+			r.mu.Lock()
+			r.needSync = true
+			r.mu.Unlock()
+		} else {
+			r.logger.Warn("replication from primary interrupted", "primary", r.primary, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.retryInterval):
+		}
+	}
+}
+
+// sync does a full synchronization if needed and streams the operations of the primary until an error occurs.
+func (r *Replica) sync(ctx context.Context) error {
+	r.mu.Lock()
+	needSync := r.needSync
+	r.mu.Unlock()
+
+	if needSync {
+		if err := r.fullSync(ctx); err != nil {
+			return err
+		}
+	}
+	return r.stream(ctx)
+}
+
+// authenticate adds the credentials of the administrator to a request to the primary, if they are set.
+func (r *Replica) authenticate(req *http.Request) {
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+}
+
+// fullSync replaces the content of the database with a snapshot of the primary.
+func (r *Replica) fullSync(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.primary+SnapshotPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot request: %w", err)
+	}
+	r.authenticate(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request snapshot: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d when requesting snapshot", resp.StatusCode)
+	}
+
+	offset, err := r.db.LoadSnapshot(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.needSync = false
+	r.status.Offset = offset
+	r.status.PrimaryOffset = max(r.status.PrimaryOffset, offset)
+	r.status.LastContact = time.Now()
+	r.status.FullSyncs++
+	r.logger.Info("full synchronization with primary completed", "primary", r.primary, "offset", offset)
+	return nil
+}
+
+// stream applies the operations streamed by the primary after the current offset until an error occurs.
+func (r *Replica) stream(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	r.mu.Lock()
+	offset := r.status.Offset
+	r.mu.Unlock()
+
+	query := url.Values{"offset": {strconv.FormatUint(offset, 10)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.primary+StreamPath+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create stream request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	r.authenticate(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to primary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusGone:
+		return fmt.Errorf("offset %d is no longer available in the primary: %w", offset, errResync)
+	default:
+		return fmt.Errorf("received status code %d when connecting to primary", resp.StatusCode)
+	}
+
+	r.setConnected(true)
+	r.logger.Info("streaming operations from primary", "primary", r.primary, "offset", offset)
+
+	// the primary sends heartbeats on idle streams, so a silent connection means the primary is unreachable
+	var timedOut atomic.Bool
+	watchdog := time.AfterFunc(heartbeatTimeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer watchdog.Stop()
+
+	events := newEventReader(resp.Body)
+	for {
+		event, err := events.next()
+		if err != nil {
+			if timedOut.Load() {
+				return fmt.Errorf("no messages received from primary in %s", heartbeatTimeout)
+			}
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+		watchdog.Reset(heartbeatTimeout)
+
+		if err := r.handleEvent(event); err != nil {
+			return err
+		}
+	}
+}
+
+// handleEvent applies an operation or records the offset of a heartbeat sent by the primary.
+func (r *Replica) handleEvent(event sseEvent) error {
+	switch event.name {
+	case EventOp:
+		var op db.ReplicatedOperation
+		if err := json.Unmarshal(event.data, &op); err != nil {
+			return fmt.Errorf("failed to decode operation: %w", err)
+		}
+
+		r.mu.Lock()
+		expected := r.status.Offset + 1
+		r.mu.Unlock()
+		if op.Offset != expected {
+			return fmt.Errorf("received offset %d while expecting %d: %w", op.Offset, expected, errResync)
+		}
+		if err := r.db.ApplyReplicated(op); err != nil {
+			return fmt.Errorf("%w: %w", err, errResync)
+		}
+
+		r.mu.Lock()
+		r.status.Offset = op.Offset
+		r.status.PrimaryOffset = max(r.status.PrimaryOffset, op.Offset)
+		r.status.LastContact = time.Now()
+		r.mu.Unlock()
+	case EventPing:
+		var heartbeat Heartbeat
+		if err := json.Unmarshal(event.data, &heartbeat); err != nil {
+			return fmt.Errorf("failed to decode heartbeat: %w", err)
+		}
+
+		r.mu.Lock()
+		r.status.PrimaryOffset = heartbeat.Offset
+		r.status.LastContact = time.Now()
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// setConnected records whether the replica is streaming from the primary.
+func (r *Replica) setConnected(connected bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Connected = connected
+}
@@ -0,0 +1,160 @@
+package replication_test
+
+import (
+	"context"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/replication"
+	"memorydb/internal/transport"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplicaSuite struct {
+	primaryDB db.DBClient
+	primary   *httptest.Server
+	replicaDB db.DBClient
+	replica   *replication.Replica
+	suite.Suite
+}
+
+func (s *ReplicaSuite) SetupTest() {
+	s.primaryDB = db.NewMemoryDB(slog.Default())
+	h := transport.NewReplicationHandler(slog.Default(), s.primaryDB, nil)
+
+	r := chi.NewRouter()
+	r.Get(replication.SnapshotPath, h.HandleSnapshot)
+	r.Get(replication.StreamPath, h.HandleStream)
+	s.primary = httptest.NewServer(r)
+
+	s.replicaDB = db.NewMemoryDB(slog.Default())
+	s.replica = replication.NewReplica(slog.Default(), s.replicaDB, s.primary.URL, replication.WithRetryInterval(10*time.Millisecond))
+}
+
+func (s *ReplicaSuite) TearDownTest() {
+	s.replica.Stop()
+	s.primary.Close()
+	s.primaryDB.Close()
+	s.replicaDB.Close()
+}
+
+// waitForValue waits until the key holds the value in the replica or fails the test after a timeout.
+func (s *ReplicaSuite) waitForValue(key string, value any) {
+	s.Eventually(func() bool {
+		item, err := s.replicaDB.Get(key)
+		return err == nil && item.Value.Val == value
+	}, 2*time.Second, 10*time.Millisecond, "key %s was not replicated", key)
+}
+
+func (s *ReplicaSuite) TestReplicate() {
+	// the data written before the replica connects is received in the snapshot
+	s.Require().NoError(s.primaryDB.Set("before", "value"))
+
+	s.replica.Start(context.Background())
+	s.waitForValue("before", "value")
+	s.ErrorIs(s.replicaDB.Set("key", "value"), db.ErrReadOnly, "replicas should be read-only")
+
+	// the data written afterwards is streamed
+	s.Require().NoError(s.primaryDB.Set("after", "value"))
+	s.Require().NoError(s.primaryDB.Update("before", "updated"))
+	s.waitForValue("after", "value")
+	s.waitForValue("before", "updated")
+
+	s.Eventually(func() bool {
+		status := s.replica.Status()
+		return status.Connected && status.Offset == 3 && status.Lag == 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	status := s.replica.Status()
+	s.Equal(replication.RoleReplica, status.Role)
+	s.Equal(s.primary.URL, status.Primary)
+	s.Equal(1, status.FullSyncs)
+	s.Equal(1, s.primaryDB.Stats().Replicas)
+}
+
+func (s *ReplicaSuite) TestResume() {
+	s.replica.Start(context.Background())
+	s.Require().NoError(s.primaryDB.Set("key1", "value"))
+	s.waitForValue("key1", "value")
+
+	// close the connections to the primary, the replica resumes from its offset without a full synchronization
+	s.primary.CloseClientConnections()
+	s.Require().NoError(s.primaryDB.Set("key2", "value"))
+	s.waitForValue("key2", "value")
+	s.Equal(1, s.replica.Status().FullSyncs)
+}
+
+func (s *ReplicaSuite) TestPromote() {
+	s.replica.Start(context.Background())
+	s.Require().NoError(s.primaryDB.Set("key", "value"))
+	s.waitForValue("key", "value")
+
+	status, err := s.replica.Promote()
+	s.Require().NoError(err)
+	s.Equal(replication.RolePrimary, status.Role)
+	s.False(status.Connected)
+
+	// the promoted replica accepts writes and no longer follows the old primary
+	s.NoError(s.replicaDB.Set("key", "local"))
+	s.Require().NoError(s.primaryDB.Set("other", "value"))
+	s.Never(func() bool {
+		_, err := s.replicaDB.Get("other")
+		return err == nil
+	}, 100*time.Millisecond, 10*time.Millisecond)
+
+	_, err = s.replica.Promote()
+	s.ErrorIs(err, replication.ErrNotReplica)
+}
+
+func (s *ReplicaSuite) TestReady() {
+	s.ErrorContains(s.replica.Ready(), "full synchronization", "a replica should not be ready before it is synchronized")
+
+	s.replica.Start(context.Background())
+	s.Eventually(func() bool {
+		return s.replica.Ready() == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	s.replica.Stop()
+	s.ErrorContains(s.replica.Ready(), "not connected", "a replica should not be ready while it does not stream from the primary")
+
+	_, err := s.replica.Promote()
+	s.Require().NoError(err)
+	s.NoError(s.replica.Ready(), "a promoted replica should be ready")
+}
+
+func (s *ReplicaSuite) TestBasicAuth() {
+	// the replication endpoints of a primary with the authentication enabled require the administrator
+	manager, err := auth.NewManager(slog.Default(), s.primaryDB, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	primary := httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.primaryDB, transport.WithAuthManager{AuthManager: manager}).Handler())
+	defer primary.Close()
+	s.Require().NoError(s.primaryDB.Set("key", "value"))
+
+	anonymousDB := db.NewMemoryDB(slog.Default())
+	defer anonymousDB.Close()
+	anonymous := replication.NewReplica(slog.Default(), anonymousDB, primary.URL, replication.WithRetryInterval(10*time.Millisecond))
+	anonymous.Start(context.Background())
+	defer anonymous.Stop()
+
+	s.replica = replication.NewReplica(slog.Default(), s.replicaDB, primary.URL,
+		replication.WithRetryInterval(10*time.Millisecond),
+		replication.WithBasicAuth{Username: "admin", Password: "admin-password"},
+	)
+	s.replica.Start(context.Background())
+	defer s.replica.Stop() // the stream is closed before the server
+	s.waitForValue("key", "value")
+	s.Eventually(func() bool { return s.replica.Status().Connected }, 2*time.Second, 10*time.Millisecond)
+
+	status := anonymous.Status()
+	s.False(status.Connected, "a replica without the credentials should be rejected")
+	s.Zero(status.FullSyncs)
+}
+
+func TestReplicaSuite(t *testing.T) {
+	suite.Run(t, new(ReplicaSuite))
+}
@@ -0,0 +1,61 @@
+package replication
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// sseEvent is an event of a Server-Sent Events stream.
+type sseEvent struct {
+	id   string
+	name string
+	data []byte
+}
+
+// eventReader reads the events of a Server-Sent Events stream.
+type eventReader struct {
+	r *bufio.Reader
+}
+
+// newEventReader creates a reader of the events in r.
+func newEventReader(r io.Reader) *eventReader {
+	return &eventReader{r: bufio.NewReader(r)}
+}
+
+// next returns the next event of the stream. Comments, like the keep-alive messages, are skipped.
+func (e *eventReader) next() (sseEvent, error) {
+	var event sseEvent
+	for {
+		line, err := e.r.ReadBytes('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		// an empty line dispatches the event
+		if len(line) == 0 {
+			if event.name == "" && event.data == nil {
+				continue
+			}
+			return event, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "id":
+			event.id = string(value)
+		case "event":
+			event.name = string(value)
+		case "data":
+			if event.data != nil {
+				event.data = append(event.data, '\n')
+			}
+			event.data = append(event.data, value...)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package resp
+
+import (
+	"fmt"
+	"net"
+)
+
+// Client is a minimal client of the protocol, used to test the server and to script it without the Redis tools.
+// It is not safe for concurrent use.
+type Client struct {
+	conn   net.Conn
+	reader *Reader
+	writer *Writer
+}
+
+// Dial connects to the server listening at the TCP address.
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a client that sends the commands through the connection.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn, reader: NewReader(conn), writer: NewWriter(conn)}
+}
+
+// Do sends a command and returns its reply. Error replies are returned as an Error.
+func (c *Client) Do(args ...string) (any, error) {
+	c.Send(args...)
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return c.Receive()
+}
+
+// Send buffers a command, so several commands can be pipelined and sent at once with Flush.
+func (c *Client) Send(args ...string) {
+	c.writer.WriteCommand(args...)
+}
+
+// Flush sends the buffered commands.
+func (c *Client) Flush() error {
+	if err := c.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to send commands: %w", err)
+	}
+	return nil
+}
+
+// Receive reads the reply of the next command sent. Error replies are returned as an Error.
+func (c *Client) Receive() (any, error) {
+	reply, err := c.reader.ReadValue()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,762 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// serverVersion is the version reported by HELLO and INFO.
+	serverVersion = "1.0.0"
+
+	// errAuthDisabled is the reply of Redis to AUTH when no password is configured.
+	errAuthDisabled = "ERR AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?"
+)
+
+// command is a command of the protocol.
+type command struct {
+	// arity is the number of arguments including the name of the command, or the minimum number if it is negative
+	arity int
+	// keys returns the keys of the command, which are routed by the hash slots
+	keys func(args []string) []string
+	// permission is the permission the principal of the client needs on the keys, if the clients are authenticated
+	permission enums.Permission
+	// public reports whether the command is served before the client is authenticated
+	public  bool
+	handler func(c *conn, args []string)
+}
+
+// firstKey returns the first argument, which is the key of most commands.
+func firstKey(args []string) []string {
+	return args[1:2]
+}
+
+// allKeys returns every argument as a key.
+func allKeys(args []string) []string {
+	return args[1:]
+}
+
+// commands are the commands served, by lowercase name.
+var commands map[string]command
+
+func init() {
+	// initialized here because the handlers of COMMAND refer to the table
+	commands = map[string]command{
+		"ping":    {arity: -1, handler: (*conn).ping},
+		"echo":    {arity: 2, handler: (*conn).echo},
+		"auth":    {arity: -2, public: true, handler: (*conn).authCommand},
+		"hello":   {arity: -1, public: true, handler: (*conn).hello},
+		"quit":    {arity: -1, public: true, handler: (*conn).quitCommand},
+		"select":  {arity: 2, handler: (*conn).selectDB},
+		"client":  {arity: -2, handler: (*conn).client},
+		"command": {arity: -1, handler: (*conn).command},
+		"asking":  {arity: 1, handler: (*conn).askingCommand},
+		"info":    {arity: -1, handler: (*conn).info},
+		"get":     {arity: 2, keys: firstKey, permission: enums.PermissionRead, handler: (*conn).get},
+		"set":     {arity: -3, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).set},
+		"del":     {arity: -2, keys: allKeys, permission: enums.PermissionWrite, handler: (*conn).del},
+		"expire":  {arity: 3, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).expire},
+		"ttl":     {arity: 2, keys: firstKey, permission: enums.PermissionRead, handler: (*conn).ttl},
+		"rpush":   {arity: -3, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).rpush},
+		"rpop":    {arity: -2, keys: firstKey, permission: enums.PermissionWrite, handler: (*conn).rpop},
+		"lrange":  {arity: 4, keys: firstKey, permission: enums.PermissionRead, handler: (*conn).lrange},
+	}
+}
+
+// execute runs a command and writes its reply.
+func (c *conn) execute(args []string) {
+	name := strings.ToLower(args[0])
+	asking := c.asking
+	c.asking = false
+
+	cmd, ok := commands[name]
+	if !ok {
+		var quoted []string
+		for _, arg := range args[1:] {
+			quoted = append(quoted, "'"+arg+"'")
+		}
+		c.writer.WriteError(fmt.Sprintf("ERR unknown command '%s', with args beginning with: %s", args[0], strings.Join(quoted, " ")))
+		return
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		c.writer.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		return
+	}
+
+	// once authenticated, the client can only access the keys its principal is allowed to
+	if c.server.auth != nil {
+		if c.principal == nil && !cmd.public {
+			c.writer.WriteError("NOAUTH Authentication required.")
+			return
+		}
+		if cmd.keys != nil {
+			for _, key := range cmd.keys(args) {
+				if !c.principal.Allows(cmd.permission, key) {
+					c.writer.WriteError(fmt.Sprintf("NOPERM User %s has no permissions to access the '%s' key", c.principal.Name, key))
+					return
+				}
+			}
+		}
+	}
+
+	// serve only the keys of the slots of the node, holding the slot so it is not moved meanwhile
+	if c.server.router != nil && cmd.keys != nil {
+		keys := cmd.keys(args)
+		slot := slots.KeySlot(keys[0])
+		for _, key := range keys[1:] {
+			if slots.KeySlot(key) != slot {
+				c.writer.WriteError("CROSSSLOT Keys in request don't hash to the same slot")
+				return
+			}
+		}
+		redirect, done := c.server.router.Route(keys[0], asking)
+		if redirect != nil {
+			c.writer.WriteError(redirect.String())
+			return
+		}
+		defer done()
+	}
+
+	cmd.handler(c, args)
+}
+
+// writeDBError writes the error returned by the database with the error code Redis uses for the same condition.
+func (c *conn) writeDBError(err error) {
+	switch {
+	case errors.Is(err, db.ErrInvalidDataType), errors.Is(err, db.ErrNotAStream):
+		c.writer.WriteError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	case errors.Is(err, db.ErrReadOnly):
+		c.writer.WriteError("READONLY You can't write against a read only replica.")
+	case errors.Is(err, db.ErrOutOfMemory):
+		c.writer.WriteError("OOM command not allowed when used memory > 'maxmemory'.")
+	case errors.Is(err, db.ErrNoLeader):
+		c.writer.WriteError("CLUSTERDOWN " + err.Error())
+	default:
+		c.writer.WriteError("ERR " + err.Error())
+	}
+}
+
+// isNotFound reports whether the error of Get means that the key does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, db.ErrDataNotFound) || errors.Is(err, db.ErrKeyHasExpired)
+}
+
+// lookup returns the item of the key, or nil if it does not exist. Other errors are written as the reply.
+func (c *conn) lookup(key string) (*db.Item, bool) {
+	item, err := c.server.db.Get(key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, true
+		}
+		c.writeDBError(err)
+		return nil, false
+	}
+	return item, true
+}
+
+// conflicted reports whether a write conditional on the version of the key failed because another client wrote
+// or removed the key after it was read, in which case the command reads the key again and retries.
+func (c *conn) conflicted(key string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, db.ErrVersionMismatch) {
+		return true
+	}
+	_, getErr := c.server.db.Get(key)
+	return isNotFound(getErr)
+}
+
+// list returns the list stored in the item, writing a WRONGTYPE error if it is not a list.
+func (c *conn) list(item *db.Item) ([]string, bool) {
+	list, ok := item.Value.Val.([]string)
+	if item.Kind != db.StringSliceType || !ok {
+		c.writeDBError(db.ErrInvalidDataType)
+		return nil, false
+	}
+	return list, true
+}
+
+func (c *conn) ping(args []string) {
+	switch len(args) {
+	case 1:
+		c.writer.WriteSimple("PONG")
+	case 2:
+		c.writer.WriteBulk(args[1])
+	default:
+		c.writer.WriteError("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (c *conn) echo(args []string) {
+	c.writer.WriteBulk(args[1])
+}
+
+// authCommand authenticates the client as a user with AUTH <username> <password>, or as an API key with
+// AUTH <key>. As the clients of Redis send AUTH default <password> when they only have a password, the username
+// default also authenticates an API key.
+func (c *conn) authCommand(args []string) {
+	if c.server.auth == nil {
+		c.writer.WriteError(errAuthDisabled)
+		return
+	}
+	if len(args) > 3 {
+		c.writer.WriteError("ERR syntax error")
+		return
+	}
+	username, password := "default", args[1]
+	if len(args) == 3 {
+		username, password = args[1], args[2]
+	}
+	if c.authenticate(username, password) {
+		c.writer.WriteSimple("OK")
+	}
+}
+
+// authenticate authenticates the client as a user, or as an API key if the username is default. It writes the
+// error reply and keeps the previous principal of the client if the credentials are not valid.
+func (c *conn) authenticate(username, password string) bool {
+	principal, err := auth.AuthenticatePassword(c.server.auth, username, password)
+	switch {
+	case errors.Is(err, auth.ErrInvalidAPIKey), errors.Is(err, auth.ErrInvalidCredentials):
+		c.writer.WriteError("WRONGPASS invalid username-password pair or user is disabled.")
+		return false
+	case err != nil:
+		c.writeDBError(err)
+		return false
+	}
+	c.principal = principal
+	return true
+}
+
+// hello switches the protocol of the connection and describes the server, authenticating the client first if
+// it is sent with AUTH.
+func (c *conn) hello(args []string) {
+	proto := c.writer.Protocol()
+	name := c.name
+	if len(args) > 1 {
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			c.writer.WriteError("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if version != 2 && version != 3 {
+			c.writer.WriteError("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = version
+
+		for i := 2; i < len(args); i++ {
+			switch strings.ToLower(args[i]) {
+			case "setname":
+				if i+1 >= len(args) {
+					c.writer.WriteError("ERR syntax error")
+					return
+				}
+				name = args[i+1]
+				i++
+			case "auth":
+				if i+2 >= len(args) {
+					c.writer.WriteError("ERR syntax error")
+					return
+				}
+				if c.server.auth == nil {
+					c.writer.WriteError(errAuthDisabled)
+					return
+				}
+				if !c.authenticate(args[i+1], args[i+2]) {
+					return
+				}
+				i += 2
+			default:
+				c.writer.WriteError("ERR syntax error")
+				return
+			}
+		}
+	}
+	if c.server.auth != nil && c.principal == nil {
+		c.writer.WriteError("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+		return
+	}
+	c.name = name
+	c.writer.SetProtocol(proto)
+
+	role, mode := "master", "standalone"
+	if c.server.db.Stats().ReadOnly {
+		role = "replica"
+	}
+	if c.server.router != nil {
+		mode = "cluster"
+	}
+
+	c.writer.WriteMap(7)
+	c.writer.WriteBulk("server")
+	c.writer.WriteBulk("memorydb")
+	c.writer.WriteBulk("version")
+	c.writer.WriteBulk(serverVersion)
+	c.writer.WriteBulk("proto")
+	c.writer.WriteInteger(int64(proto))
+	c.writer.WriteBulk("id")
+	c.writer.WriteInteger(c.id)
+	c.writer.WriteBulk("mode")
+	c.writer.WriteBulk(mode)
+	c.writer.WriteBulk("role")
+	c.writer.WriteBulk(role)
+	c.writer.WriteBulk("modules")
+	c.writer.WriteArray(0)
+}
+
+func (c *conn) quitCommand(args []string) {
+	c.writer.WriteSimple("OK")
+	c.quit = true
+}
+
+// selectDB accepts the database 0, which is the only one.
+func (c *conn) selectDB(args []string) {
+	if args[1] != "0" {
+		c.writer.WriteError("ERR DB index is out of range")
+		return
+	}
+	c.writer.WriteSimple("OK")
+}
+
+// client implements the subcommands of CLIENT sent by the client libraries when they connect.
+func (c *conn) client(args []string) {
+	switch strings.ToLower(args[1]) {
+	case "id":
+		c.writer.WriteInteger(c.id)
+	case "getname":
+		if c.name == "" {
+			c.writer.WriteNull()
+			return
+		}
+		c.writer.WriteBulk(c.name)
+	case "setname":
+		if len(args) != 3 {
+			c.writer.WriteError("ERR wrong number of arguments for 'client|setname' command")
+			return
+		}
+		c.name = args[2]
+		c.writer.WriteSimple("OK")
+	case "setinfo":
+		c.writer.WriteSimple("OK")
+	default:
+		c.writer.WriteError(fmt.Sprintf("ERR unknown subcommand '%s'", args[1]))
+	}
+}
+
+// command replies to COMMAND COUNT with the number of commands and to the other subcommands, which the
+// clients use to learn about the commands, with an empty reply.
+func (c *conn) command(args []string) {
+	if len(args) > 1 && strings.ToLower(args[1]) == "count" {
+		c.writer.WriteInteger(int64(len(commands)))
+		return
+	}
+	c.writer.WriteArray(0)
+}
+
+func (c *conn) askingCommand(args []string) {
+	c.asking = true
+	c.writer.WriteSimple("OK")
+}
+
+// info describes the server in the format of Redis, by sections.
+func (c *conn) info(args []string) {
+	stats := c.server.db.Stats()
+	role := "master"
+	if stats.ReadOnly {
+		role = "slave"
+	}
+	mode := "standalone"
+	if c.server.router != nil {
+		mode = "cluster"
+	}
+
+	sections := map[string][]string{
+		"server": {
+			"memorydb_version:" + serverVersion,
+			"redis_mode:" + mode,
+			"process_id:" + strconv.Itoa(os.Getpid()),
+			"tcp_port:" + portOf(c.netConn.LocalAddr().String()),
+			"uptime_in_seconds:" + strconv.FormatInt(int64(time.Since(c.server.started).Seconds()), 10),
+		},
+		"clients": {
+			"connected_clients:" + strconv.Itoa(c.server.clients()),
+		},
+		"memory": {
+			"used_memory:" + strconv.FormatInt(stats.UsedMemory, 10),
+			"maxmemory:" + strconv.FormatInt(stats.MaxMemory, 10),
+			"maxmemory_policy:" + stats.EvictionPolicy.String(),
+		},
+		"stats": {
+			"evicted_keys:" + strconv.FormatUint(stats.EvictedKeys, 10),
+		},
+		"replication": {
+			"role:" + role,
+			"connected_slaves:" + strconv.Itoa(stats.Replicas),
+			"master_repl_offset:" + strconv.FormatUint(stats.ReplicationOffset, 10),
+		},
+		"keyspace": {
+			fmt.Sprintf("db0:keys=%d", stats.Keys),
+		},
+	}
+	order := []string{"server", "clients", "memory", "stats", "replication", "keyspace"}
+
+	requested := order
+	if len(args) > 1 {
+		requested = nil
+		for _, arg := range args[1:] {
+			section := strings.ToLower(arg)
+			if section == "all" || section == "default" || section == "everything" {
+				requested = order
+				break
+			}
+			if _, ok := sections[section]; ok {
+				requested = append(requested, section)
+			}
+		}
+		sort.SliceStable(requested, func(i, j int) bool { return indexOf(order, requested[i]) < indexOf(order, requested[j]) })
+	}
+
+	var b strings.Builder
+	for i, section := range requested {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString("# " + strings.ToUpper(section[:1]) + section[1:] + "\r\n")
+		for _, line := range sections[section] {
+			b.WriteString(line + "\r\n")
+		}
+	}
+	c.writer.WriteBulk(b.String())
+}
+
+func (c *conn) get(args []string) {
+	item, ok := c.lookup(args[1])
+	if !ok {
+		return
+	}
+	if item == nil {
+		c.writer.WriteNull()
+		return
+	}
+	value, isString := item.Value.Val.(string)
+	if item.Kind != db.StringType || !isString {
+		c.writeDBError(db.ErrInvalidDataType)
+		return
+	}
+	c.writer.WriteBulk(value)
+}
+
+// set implements SET key value [EX seconds|PX milliseconds] [NX|XX]. With NX or XX, the write is conditional on the
+// version of the key that was checked, so it is atomic with the writes of other clients to the same key.
+func (c *conn) set(args []string) {
+	var (
+		ttl         time.Duration
+		nx, xx      bool
+		ttlSet      bool
+		key, value  = args[1], args[2]
+		expireError = "ERR invalid expire time in 'set' command"
+	)
+	for i := 3; i < len(args); i++ {
+		switch option := strings.ToLower(args[i]); option {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "ex", "px":
+			if ttlSet || i+1 >= len(args) {
+				c.writer.WriteError("ERR syntax error")
+				return
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil {
+				c.writer.WriteError("ERR value is not an integer or out of range")
+				return
+			}
+			if n <= 0 {
+				c.writer.WriteError(expireError)
+				return
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if option == "ex" {
+				ttl = time.Duration(n) * time.Second
+			}
+			ttlSet = true
+			i++
+		default:
+			c.writer.WriteError("ERR syntax error")
+			return
+		}
+	}
+	if nx && xx {
+		c.writer.WriteError("ERR syntax error")
+		return
+	}
+
+	var opts []db.ItemOptions
+	if ttlSet {
+		opts = append(opts, db.WithTTL(ttl))
+	}
+	for {
+		writeOpts := opts
+		if nx || xx {
+			item, ok := c.lookup(key)
+			if !ok {
+				return
+			}
+			if (nx && item != nil) || (xx && item == nil) {
+				c.writer.WriteNull()
+				return
+			}
+			var version uint64 // a key that does not exist has version 0
+			if item != nil {
+				version = item.Version
+			}
+			writeOpts = append(slices.Clip(opts), db.WithVersion(version))
+		}
+
+		err := c.server.db.Set(key, value, writeOpts...)
+		if errors.Is(err, db.ErrVersionMismatch) {
+			continue // the key was written meanwhile, it is checked again
+		}
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		c.writer.WriteSimple("OK")
+		return
+	}
+}
+
+// del removes the keys and returns the number of keys removed.
+func (c *conn) del(args []string) {
+	var removed int64
+	for _, key := range args[1:] {
+		if err := c.server.db.Remove(key); err != nil {
+			// the keys that do not exist are reported as plain errors, the database errors stop the command
+			var dbError *db.DBerror
+			if errors.As(err, &dbError) {
+				c.writeDBError(err)
+				return
+			}
+			continue
+		}
+		removed++
+	}
+	c.writer.WriteInteger(removed)
+}
+
+// expire sets the TTL of the key in seconds, removing it if the TTL is not positive.
+// It returns 1 if the key exists and 0 otherwise.
+func (c *conn) expire(args []string) {
+	seconds, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		c.writer.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	for {
+		item, ok := c.lookup(args[1])
+		if !ok {
+			return
+		}
+		if item == nil {
+			c.writer.WriteInteger(0)
+			return
+		}
+
+		if seconds <= 0 {
+			err = c.server.db.Remove(args[1])
+		} else {
+			// the value is written again with the new TTL, since the database has no command to change only the TTL.
+			// The write is conditional on the version that was read, so it does not undo the writes of other clients
+			err = c.server.db.Update(args[1], item.Value.Val, db.WithTTL(time.Duration(seconds)*time.Second), db.WithVersion(item.Version))
+		}
+		if c.conflicted(args[1], err) {
+			continue
+		}
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		c.writer.WriteInteger(1)
+		return
+	}
+}
+
+// ttl returns the seconds left until the key expires, -1 if it does not expire and -2 if it does not exist.
+func (c *conn) ttl(args []string) {
+	item, ok := c.lookup(args[1])
+	if !ok {
+		return
+	}
+	switch {
+	case item == nil:
+		c.writer.WriteInteger(-2)
+	case item.TTL.IsZero():
+		c.writer.WriteInteger(-1)
+	default:
+		c.writer.WriteInteger(int64((time.Until(item.TTL) + 500*time.Millisecond) / time.Second))
+	}
+}
+
+// rpush appends the values to the list, creating it if it does not exist, and returns its length. The list is
+// written as a whole, conditional on the version that was read, so the values are appended at once and a list
+// created by another client at the same time is not overwritten.
+func (c *conn) rpush(args []string) {
+	key, values := args[1], args[2:]
+	for {
+		item, ok := c.lookup(key)
+		if !ok {
+			return
+		}
+
+		var list []string
+		var err error
+		if item == nil {
+			list = slices.Clone(values)
+			err = c.server.db.Set(key, list, db.WithVersion(0))
+		} else {
+			if list, ok = c.list(item); !ok {
+				return
+			}
+			list = append(slices.Clip(list), values...)
+			err = c.server.db.Update(key, list, db.WithVersion(item.Version))
+		}
+		if c.conflicted(key, err) {
+			continue
+		}
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		c.writer.WriteInteger(int64(len(list)))
+		return
+	}
+}
+
+// rpop removes and returns the last element of the list, or the last count elements. The remaining elements are
+// written conditional on the version that was read, so two clients never pop the same element.
+func (c *conn) rpop(args []string) {
+	if len(args) > 3 {
+		c.writer.WriteError("ERR syntax error")
+		return
+	}
+	count, withCount := 1, len(args) == 3
+	if withCount {
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 0 {
+			c.writer.WriteError("ERR value is out of range, must be positive")
+			return
+		}
+		count = n
+	}
+
+	for {
+		item, ok := c.lookup(args[1])
+		if !ok {
+			return
+		}
+		var list []string
+		if item != nil {
+			if list, ok = c.list(item); !ok {
+				return
+			}
+		}
+		if len(list) == 0 {
+			if withCount {
+				c.writer.WriteNullArray()
+			} else {
+				c.writer.WriteNull()
+			}
+			return
+		}
+
+		remaining := max(len(list)-count, 0)
+		popped := slices.Clone(list[remaining:])
+		slices.Reverse(popped)
+		if len(popped) == 0 {
+			c.writer.WriteStrings(popped)
+			return
+		}
+		err := c.server.db.Update(args[1], list[:remaining:remaining], db.WithVersion(item.Version))
+		if c.conflicted(args[1], err) {
+			continue
+		}
+		if err != nil {
+			c.writeDBError(err)
+			return
+		}
+		if withCount {
+			c.writer.WriteStrings(popped)
+			return
+		}
+		c.writer.WriteBulk(popped[0])
+		return
+	}
+}
+
+// lrange returns the elements of the list between the start and stop indexes, both included. Negative indexes
+// count from the end of the list.
+func (c *conn) lrange(args []string) {
+	start, err1 := strconv.Atoi(args[2])
+	stop, err2 := strconv.Atoi(args[3])
+	if err1 != nil || err2 != nil {
+		c.writer.WriteError("ERR value is not an integer or out of range")
+		return
+	}
+	item, ok := c.lookup(args[1])
+	if !ok {
+		return
+	}
+	if item == nil {
+		c.writer.WriteArray(0)
+		return
+	}
+	list, ok := c.list(item)
+	if !ok {
+		return
+	}
+
+	n := len(list)
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+	if start > stop {
+		c.writer.WriteArray(0)
+		return
+	}
+	c.writer.WriteStrings(list[start : stop+1])
+}
+
+// portOf returns the port of a TCP address.
+func portOf(addr string) string {
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		return addr[i+1:]
+	}
+	return addr
+}
+
+// indexOf returns the index of the value in the values, or -1.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,356 @@
+// Package resp implements the RESP2 and RESP3 wire protocol, so the database can be used with the Redis tools
+// and client libraries.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxBulkLength is the maximum length of a bulk string sent by a client, the same as the default of Redis.
+	MaxBulkLength = 512 << 20
+	// maxArrayLength is the maximum number of arguments of a command.
+	maxArrayLength = 1 << 20
+	// maxInlineLength is the maximum length of a line, which bounds the inline commands.
+	maxInlineLength = 64 << 10
+)
+
+// Error is an error reply of the server.
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// ProtocolError is returned by the Reader when the data received does not follow the protocol.
+type ProtocolError struct {
+	Message string
+}
+
+func (e *ProtocolError) Error() string {
+	return "Protocol error: " + e.Message
+}
+
+// Reader reads the commands and the replies of the protocol.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a reader of the protocol that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReaderSize(r, maxInlineLength)}
+}
+
+// Buffered returns the number of bytes received and not read yet, which is not zero when commands are pipelined.
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// ReadCommand reads a command, as an array of bulk strings or as an inline command of words separated by spaces.
+// Empty commands are skipped.
+func (r *Reader) ReadCommand() ([]string, error) {
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] != '*' {
+			line, err := r.readLine()
+			if err != nil {
+				return nil, err
+			}
+			if args := strings.Fields(string(line)); len(args) > 0 {
+				return args, nil
+			}
+			continue
+		}
+
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n > maxArrayLength {
+			return nil, &ProtocolError{Message: "invalid multibulk length"}
+		}
+		if n <= 0 {
+			continue
+		}
+
+		args := make([]string, 0, min(n, 1024))
+		for range n {
+			line, err := r.readLine()
+			if err != nil {
+				return nil, err
+			}
+			if len(line) == 0 || line[0] != '$' {
+				return nil, &ProtocolError{Message: fmt.Sprintf("expected '$', got '%s'", truncate(line))}
+			}
+			arg, err := r.readBulk(line[1:])
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		return args, nil
+	}
+}
+
+// ReadValue reads a reply. The replies are returned as the following types:
+//
+//   - simple strings, bulk strings and verbatim strings as string
+//   - integers as int64, doubles as float64, booleans as bool and big numbers as string
+//   - nulls as nil
+//   - arrays, sets and pushes as []any, and maps as map[string]any
+//   - errors as Error
+//
+// Attributes are skipped.
+func (r *Reader) ReadValue() (any, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, &ProtocolError{Message: "empty reply"}
+	}
+
+	payload := string(line[1:])
+	switch line[0] {
+	case '+', '(':
+		return payload, nil
+	case '-':
+		return Error(payload), nil
+	case ':':
+		n, err := strconv.ParseInt(payload, 10, 64)
+		if err != nil {
+			return nil, &ProtocolError{Message: "invalid integer " + payload}
+		}
+		return n, nil
+	case ',':
+		f, err := strconv.ParseFloat(strings.Replace(payload, "inf", "Inf", 1), 64)
+		if err != nil {
+			return nil, &ProtocolError{Message: "invalid double " + payload}
+		}
+		return f, nil
+	case '#':
+		return payload == "t", nil
+	case '_':
+		return nil, nil
+	case '$', '=', '!':
+		if payload == "-1" {
+			return nil, nil
+		}
+		s, err := r.readBulk(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		switch line[0] {
+		case '=':
+			// verbatim strings start with their format, such as txt:
+			if len(s) >= 4 && s[3] == ':' {
+				s = s[4:]
+			}
+		case '!':
+			return Error(s), nil
+		}
+		return s, nil
+	case '*', '~', '>':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, &ProtocolError{Message: "invalid array length " + payload}
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]any, n)
+		for i := range values {
+			if values[i], err = r.ReadValue(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case '%', '|':
+		n, err := strconv.Atoi(payload)
+		if err != nil || n < 0 {
+			return nil, &ProtocolError{Message: "invalid map length " + payload}
+		}
+		values := make(map[string]any, n)
+		for range n {
+			key, err := r.ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			if values[fmt.Sprint(key)], err = r.ReadValue(); err != nil {
+				return nil, err
+			}
+		}
+		if line[0] == '|' {
+			return r.ReadValue() // the attributes precede the reply they describe
+		}
+		return values, nil
+	default:
+		return nil, &ProtocolError{Message: fmt.Sprintf("unknown reply type '%c'", line[0])}
+	}
+}
+
+// readLine reads a line without its terminator. Lines ending in \n alone are accepted for the inline commands.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if err != nil {
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, &ProtocolError{Message: "too big inline request"}
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	line = line[:len(line)-1]
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line, nil
+}
+
+// readBulk reads the content of a bulk string whose length has been read.
+func (r *Reader) readBulk(rawLength []byte) (string, error) {
+	length, err := strconv.Atoi(string(rawLength))
+	if err != nil || length < 0 || length > MaxBulkLength {
+		return "", &ProtocolError{Message: "invalid bulk length"}
+	}
+	buf := make([]byte, length+2)
+	if _, err := io.ReadFull(r.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	if buf[length] != '\r' || buf[length+1] != '\n' {
+		return "", &ProtocolError{Message: "bulk string not terminated by CRLF"}
+	}
+	return string(buf[:length]), nil
+}
+
+// truncate shortens a line received for an error message.
+func truncate(line []byte) string {
+	if len(line) > 32 {
+		return string(line[:32]) + "..."
+	}
+	return string(line)
+}
+
+// Writer writes the replies and the commands of the protocol. The replies that differ between RESP2 and RESP3,
+// such as the nulls and the maps, are written in the protocol set with SetProtocol, RESP2 by default.
+//
+// The writes are buffered until Flush is called, and the first write error is returned by Flush.
+type Writer struct {
+	w     *bufio.Writer
+	proto int
+	err   error
+}
+
+// NewWriter returns a writer of the protocol that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), proto: 2}
+}
+
+// SetProtocol sets the version of the protocol of the replies, 2 or 3.
+func (w *Writer) SetProtocol(proto int) {
+	w.proto = proto
+}
+
+// Protocol returns the version of the protocol of the replies.
+func (w *Writer) Protocol() int {
+	return w.proto
+}
+
+// WriteSimple writes a simple string, which must not contain line breaks.
+func (w *Writer) WriteSimple(s string) {
+	w.write("+", s, "\r\n")
+}
+
+// WriteError writes an error reply. The message starts with the error code, such as ERR or WRONGTYPE.
+func (w *Writer) WriteError(message string) {
+	w.write("-", strings.NewReplacer("\r", " ", "\n", " ").Replace(message), "\r\n")
+}
+
+// WriteInteger writes an integer.
+func (w *Writer) WriteInteger(n int64) {
+	w.write(":", strconv.FormatInt(n, 10), "\r\n")
+}
+
+// WriteBulk writes a bulk string.
+func (w *Writer) WriteBulk(s string) {
+	w.write("$", strconv.Itoa(len(s)), "\r\n", s, "\r\n")
+}
+
+// WriteNull writes a null, which is a null bulk string in RESP2.
+func (w *Writer) WriteNull() {
+	if w.proto >= 3 {
+		w.write("_\r\n")
+		return
+	}
+	w.write("$-1\r\n")
+}
+
+// WriteNullArray writes a null, which is a null array in RESP2.
+func (w *Writer) WriteNullArray() {
+	if w.proto >= 3 {
+		w.write("_\r\n")
+		return
+	}
+	w.write("*-1\r\n")
+}
+
+// WriteArray writes the header of an array of n elements, which must be written next.
+func (w *Writer) WriteArray(n int) {
+	w.write("*", strconv.Itoa(n), "\r\n")
+}
+
+// WriteMap writes the header of a map of n pairs of key and value, which must be written next.
+// In RESP2, the map is written as an array of 2n elements.
+func (w *Writer) WriteMap(n int) {
+	if w.proto >= 3 {
+		w.write("%", strconv.Itoa(n), "\r\n")
+		return
+	}
+	w.WriteArray(2 * n)
+}
+
+// WriteStrings writes an array of bulk strings.
+func (w *Writer) WriteStrings(values []string) {
+	w.WriteArray(len(values))
+	for _, value := range values {
+		w.WriteBulk(value)
+	}
+}
+
+// WriteCommand writes a command as an array of bulk strings.
+func (w *Writer) WriteCommand(args ...string) {
+	w.WriteStrings(args)
+}
+
+// Flush sends the buffered data and returns the first error of the writes.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.w.Flush()
+	return w.err
+}
+
+// write writes the parts of a reply, keeping the first error.
+func (w *Writer) write(parts ...string) {
+	for _, part := range parts {
+		if w.err != nil {
+			return
+		}
+		_, w.err = w.w.WriteString(part)
+	}
+}
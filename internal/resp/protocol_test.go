@@ -0,0 +1,97 @@
+package resp_test
+
+import (
+	"bytes"
+	"io"
+	"memorydb/internal/resp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProtocolSuite struct {
+	suite.Suite
+}
+
+func (s *ProtocolSuite) TestReadCommand() {
+	s.Run("Multibulk", func() {
+		reader := resp.NewReader(strings.NewReader("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$12\r\nhello\r\nworld\r\n"))
+		args, err := reader.ReadCommand()
+		s.Require().NoError(err)
+		s.Equal([]string{"SET", "key", "hello\r\nworld"}, args, "bulk strings are binary safe")
+
+		_, err = reader.ReadCommand()
+		s.ErrorIs(err, io.EOF)
+	})
+
+	s.Run("Inline", func() {
+		reader := resp.NewReader(strings.NewReader("\r\nPING\r\nGET  key\n"))
+		args, err := reader.ReadCommand()
+		s.Require().NoError(err)
+		s.Equal([]string{"PING"}, args, "empty lines should be skipped")
+
+		args, err = reader.ReadCommand()
+		s.Require().NoError(err)
+		s.Equal([]string{"GET", "key"}, args)
+	})
+
+	s.Run("Protocol errors", func() {
+		for _, input := range []string{"*x\r\n", "*1\r\n+GET\r\n", "*1\r\n$3\r\nGETX\r\n", "*1\r\n$-5\r\n"} {
+			_, err := resp.NewReader(strings.NewReader(input)).ReadCommand()
+			var protocolErr *resp.ProtocolError
+			s.ErrorAs(err, &protocolErr, "input %q", input)
+		}
+
+		_, err := resp.NewReader(strings.NewReader("*2\r\n$3\r\nGET\r\n")).ReadCommand()
+		s.ErrorIs(err, io.EOF, "truncated commands are not protocol errors")
+	})
+}
+
+func (s *ProtocolSuite) TestWriter() {
+	s.Run("RESP2", func() {
+		var buf bytes.Buffer
+		writer := resp.NewWriter(&buf)
+		writer.WriteSimple("OK")
+		writer.WriteError("ERR bad\r\nthing")
+		writer.WriteInteger(-3)
+		writer.WriteBulk("hi")
+		writer.WriteNull()
+		writer.WriteNullArray()
+		writer.WriteMap(1)
+		writer.WriteBulk("k")
+		writer.WriteStrings([]string{"a"})
+		s.Require().NoError(writer.Flush())
+		s.Equal("+OK\r\n-ERR bad  thing\r\n:-3\r\n$2\r\nhi\r\n$-1\r\n*-1\r\n*2\r\n$1\r\nk\r\n*1\r\n$1\r\na\r\n", buf.String())
+	})
+
+	s.Run("RESP3", func() {
+		var buf bytes.Buffer
+		writer := resp.NewWriter(&buf)
+		writer.SetProtocol(3)
+		writer.WriteNull()
+		writer.WriteNullArray()
+		writer.WriteMap(0)
+		s.Require().NoError(writer.Flush())
+		s.Equal("_\r\n_\r\n%0\r\n", buf.String())
+	})
+}
+
+func (s *ProtocolSuite) TestReadValue() {
+	input := "+OK\r\n-ERR fail\r\n:42\r\n,1.5\r\n#t\r\n_\r\n$-1\r\n=7\r\ntxt:abc\r\n*2\r\n$1\r\na\r\n:1\r\n%1\r\n+k\r\n+v\r\n|1\r\n+ttl\r\n:3\r\n+attributed\r\n"
+	reader := resp.NewReader(strings.NewReader(input))
+
+	expected := []any{
+		"OK", resp.Error("ERR fail"), int64(42), 1.5, true, nil, nil, "abc",
+		[]any{"a", int64(1)}, map[string]any{"k": "v"}, "attributed",
+	}
+	for _, want := range expected {
+		value, err := reader.ReadValue()
+		s.Require().NoError(err)
+		s.Equal(want, value)
+	}
+}
+
+func TestProtocolSuite(t *testing.T) {
+	suite.Run(t, new(ProtocolSuite))
+}
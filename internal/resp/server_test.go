@@ -0,0 +1,333 @@
+package resp_test
+
+import (
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/resp"
+	"memorydb/internal/slots"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ServerSuite struct {
+	suite.Suite
+	database db.DBClient
+	server   *resp.Server
+	addr     string
+	client   *resp.Client
+}
+
+func (s *ServerSuite) SetupTest() {
+	s.database = db.NewMemoryDB(slog.Default())
+	s.start()
+}
+
+func (s *ServerSuite) TearDownTest() {
+	s.client.Close()
+	s.server.Close()
+	s.database.Close()
+}
+
+// start serves the database on a random port and connects the client.
+func (s *ServerSuite) start(opts ...resp.ServerOptions) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().NoError(err)
+	s.addr = listener.Addr().String()
+	s.server = resp.NewServer(slog.Default(), s.addr, s.database, opts...)
+	go s.server.Serve(listener)
+
+	s.client, err = resp.Dial(s.addr)
+	s.Require().NoError(err)
+}
+
+// do sends a command and fails the test if it returns an error.
+func (s *ServerSuite) do(args ...string) any {
+	reply, err := s.client.Do(args...)
+	s.Require().NoError(err, "command %v", args)
+	return reply
+}
+
+// doError sends a command and returns its error reply.
+func (s *ServerSuite) doError(args ...string) string {
+	_, err := s.client.Do(args...)
+	s.Require().Error(err, "command %v", args)
+	s.Require().IsType(resp.Error(""), err)
+	return err.Error()
+}
+
+func (s *ServerSuite) TestConnection() {
+	s.Equal("PONG", s.do("PING"))
+	s.Equal("hi", s.do("ping", "hi"), "the names of the commands are case insensitive")
+	s.Equal("hello", s.do("ECHO", "hello"))
+	s.Equal("OK", s.do("SELECT", "0"))
+	s.Contains(s.doError("SELECT", "1"), "ERR DB index")
+	s.Equal("OK", s.do("CLIENT", "SETNAME", "tests"))
+	s.Equal("tests", s.do("CLIENT", "GETNAME"))
+
+	s.Equal("ERR unknown command 'NOPE', with args beginning with: 'a'", s.doError("NOPE", "a"))
+	s.Equal("ERR wrong number of arguments for 'get' command", s.doError("GET"))
+
+	s.Equal("OK", s.do("QUIT"))
+	_, err := s.client.Do("PING")
+	s.Error(err, "the connection should be closed after QUIT")
+}
+
+func (s *ServerSuite) TestHello() {
+	reply := s.do("HELLO")
+	s.Equal([]any{"server", "memorydb", "version", "1.0.0", "proto", int64(2), "id", int64(1), "mode", "standalone", "role", "master", "modules", []any{}}, reply, "RESP2 maps are arrays")
+
+	s.Contains(s.doError("HELLO", "4"), "NOPROTO")
+
+	hello, ok := s.do("HELLO", "3", "SETNAME", "resp3").(map[string]any)
+	s.Require().True(ok, "RESP3 should reply with a map")
+	s.Equal(int64(3), hello["proto"])
+	s.Equal("resp3", s.do("CLIENT", "GETNAME"))
+
+	s.Nil(s.do("GET", "missing"))
+	s.Nil(s.do("RPOP", "missing", "2"))
+}
+
+func (s *ServerSuite) TestStrings() {
+	s.Nil(s.do("GET", "key"))
+	s.Equal("OK", s.do("SET", "key", "value"))
+	s.Equal("OK", s.do("SET", "config:1", "on"))
+	s.Equal("value", s.do("GET", "key"))
+
+	s.Nil(s.do("SET", "key", "other", "NX"), "NX should not overwrite existing keys")
+	s.Equal("OK", s.do("SET", "key", "other", "XX"))
+	s.Equal("other", s.do("GET", "key"))
+	s.Nil(s.do("SET", "missing", "value", "XX"), "XX should not create keys")
+	s.Nil(s.do("GET", "missing"))
+
+	s.Equal("OK", s.do("SET", "expiring", "value", "EX", "100"))
+	s.Equal(int64(100), s.do("TTL", "expiring"))
+	s.Equal("OK", s.do("SET", "expiring", "value", "PX", "50"))
+	time.Sleep(100 * time.Millisecond)
+	s.Nil(s.do("GET", "expiring"), "the key should expire after the milliseconds of PX")
+
+	s.Contains(s.doError("SET", "key", "value", "EX", "0"), "invalid expire time")
+	s.Contains(s.doError("SET", "key", "value", "EX", "ten"), "not an integer")
+	s.Contains(s.doError("SET", "key", "value", "NX", "XX"), "syntax error")
+	s.Contains(s.doError("SET", "key", "value", "KEEPTTL"), "syntax error")
+}
+
+func (s *ServerSuite) TestKeys() {
+	s.do("SET", "a", "1")
+	s.do("SET", "b", "2")
+	s.Equal(int64(2), s.do("DEL", "a", "b", "c"))
+	s.Equal(int64(0), s.do("DEL", "a"))
+
+	s.Equal(int64(-2), s.do("TTL", "a"))
+	s.Equal(int64(0), s.do("EXPIRE", "a", "10"))
+
+	s.do("SET", "a", "1")
+	s.Equal(int64(1), s.do("EXPIRE", "a", "1000"))
+	s.Equal(int64(1000), s.do("TTL", "a"))
+	s.Equal("1", s.do("GET", "a"), "EXPIRE should keep the value")
+	s.Equal(int64(1), s.do("EXPIRE", "a", "0"))
+	s.Equal(int64(-2), s.do("TTL", "a"), "a TTL of 0 should remove the key")
+}
+
+func (s *ServerSuite) TestLists() {
+	s.Equal(int64(2), s.do("RPUSH", "list", "a", "b"))
+	s.Equal(int64(4), s.do("RPUSH", "list", "c", "d"))
+	s.Equal([]any{"a", "b", "c", "d"}, s.do("LRANGE", "list", "0", "-1"))
+	s.Equal([]any{"b", "c"}, s.do("LRANGE", "list", "1", "-2"))
+	s.Equal([]any{}, s.do("LRANGE", "list", "3", "1"))
+	s.Equal([]any{}, s.do("LRANGE", "missing", "0", "-1"))
+
+	s.Equal("d", s.do("RPOP", "list"))
+	s.Equal([]any{"c", "b"}, s.do("RPOP", "list", "2"))
+	s.Equal([]any{"a"}, s.do("LRANGE", "list", "0", "100"))
+	s.Nil(s.do("RPOP", "missing"))
+
+	wrongType := "WRONGTYPE Operation against a key holding the wrong kind of value"
+	s.do("SET", "string", "value")
+	s.Equal(wrongType, s.doError("RPUSH", "string", "a"))
+	s.Equal(wrongType, s.doError("LRANGE", "string", "0", "-1"))
+	s.Equal(wrongType, s.doError("GET", "list"))
+}
+
+func (s *ServerSuite) TestConcurrentWrites() {
+	const clients = 8
+	s.Require().NoError(s.database.Set("list", []string{"0", "1", "2", "3", "4", "5", "6", "7"}))
+
+	var wg sync.WaitGroup
+	replies := make([][]any, clients)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := resp.Dial(s.addr)
+			if !s.NoError(err) {
+				return
+			}
+			defer client.Close()
+			for _, args := range [][]string{{"SET", "nx", "value", "NX"}, {"RPUSH", "created", "x", "y"}, {"RPOP", "list"}} {
+				reply, err := client.Do(args...)
+				s.NoError(err)
+				replies[i] = append(replies[i], reply)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var created int
+	popped := make(map[any]bool)
+	for _, reply := range replies {
+		if reply[0] == "OK" {
+			created++
+		}
+		popped[reply[2]] = true
+	}
+	s.Equal(1, created, "only one client should set the key with NX")
+	s.Equal(int64(2*clients+1), s.do("RPUSH", "created", "z"), "no RPUSH should overwrite the list created by another one")
+	s.Len(popped, clients, "every client should pop another element")
+}
+
+func (s *ServerSuite) TestPipelining() {
+	for i := range 100 {
+		s.client.Send("RPUSH", "list", strings.Repeat("x", i))
+	}
+	s.client.Send("GET", "list")
+	s.client.Send("LRANGE", "list", "0", "0")
+	s.Require().NoError(s.client.Flush())
+
+	for i := range 100 {
+		reply, err := s.client.Receive()
+		s.Require().NoError(err)
+		s.Equal(int64(i+1), reply, "the replies should follow the order of the commands")
+	}
+	_, err := s.client.Receive()
+	s.Error(err, "the errors should not stop the pipeline")
+	reply, err := s.client.Receive()
+	s.Require().NoError(err)
+	s.Equal([]any{""}, reply)
+}
+
+func (s *ServerSuite) TestInlineCommands() {
+	conn, err := net.Dial("tcp", s.addr)
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("SET key value\r\nGET key\r\n*1\r\n$4\r\nPING\r\n"))
+	s.Require().NoError(err)
+
+	reader := resp.NewReader(conn)
+	for _, want := range []any{"OK", "value", "PONG"} {
+		reply, err := reader.ReadValue()
+		s.Require().NoError(err)
+		s.Equal(want, reply)
+	}
+
+	_, err = conn.Write([]byte("*1\r\n+PING\r\n"))
+	s.Require().NoError(err)
+	reply, err := reader.ReadValue()
+	s.Require().NoError(err)
+	s.Contains(reply, "ERR Protocol error", "protocol errors should be reported before closing the connection")
+	_, err = reader.ReadValue()
+	s.Error(err)
+}
+
+func (s *ServerSuite) TestInfo() {
+	s.do("SET", "key", "value")
+	info, ok := s.do("INFO").(string)
+	s.Require().True(ok)
+	for _, section := range []string{"# Server", "# Clients", "# Memory", "# Stats", "# Replication", "# Keyspace"} {
+		s.Contains(info, section)
+	}
+	s.Contains(info, "db0:keys=1")
+	s.Contains(info, "connected_clients:1")
+
+	info, ok = s.do("INFO", "keyspace", "memory").(string)
+	s.Require().True(ok)
+	s.True(strings.HasPrefix(info, "# Memory"), "the sections should keep their order")
+	s.NotContains(info, "# Server")
+}
+
+func (s *ServerSuite) TestReadOnly() {
+	s.do("SET", "key", "value")
+	s.database.SetReadOnly(true)
+	s.Equal("READONLY You can't write against a read only replica.", s.doError("SET", "key", "other"))
+	s.Equal("value", s.do("GET", "key"), "reads should be served by read-only replicas")
+}
+
+func (s *ServerSuite) TestSlots() {
+	s.client.Close()
+	s.server.Close()
+
+	// node a serves the slots 0-8191 and node b the slots 8192-16383
+	nodes := []slots.Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := slots.NewRouter(slog.Default(), s.database, "a", slots.NewTopology(nodes))
+	s.Require().NoError(err)
+	s.start(resp.WithSlotRouter{Router: router})
+
+	s.Require().Less(slots.KeySlot("bar"), 8192)
+	s.Equal("OK", s.do("SET", "bar", "value"))
+	s.Equal("MOVED 12182 http://b:8080", s.doError("GET", "foo"))
+	s.Equal("CROSSSLOT Keys in request don't hash to the same slot", s.doError("DEL", "bar", "foo"))
+	s.Equal(int64(1), s.do("DEL", "{bar}1", "bar"), "the keys with the same hash tag should share the slot")
+	s.Equal("PONG", s.do("PING"), "the commands without keys should not be routed")
+
+	info, ok := s.do("INFO", "server").(string)
+	s.Require().True(ok)
+	s.Contains(info, "redis_mode:cluster")
+}
+
+func (s *ServerSuite) TestAuth() {
+	s.Contains(s.doError("AUTH", "secret"), "without any password configured")
+	s.client.Close()
+	s.server.Close()
+
+	manager, err := auth.NewManager(slog.Default(), s.database, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	s.Require().NoError(manager.Register("admin", "admin-password", "alice", "secret"))
+	rules := []db.ACLRule{
+		{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}},
+		{Pattern: "config:*", Permissions: []enums.Permission{enums.PermissionRead}},
+	}
+	_, key, err := manager.CreateAPIKey("billing", "", rules)
+	s.Require().NoError(err)
+	s.start(resp.WithAuthManager{AuthManager: manager})
+
+	s.Equal("NOAUTH Authentication required.", s.doError("GET", "key"))
+	s.Contains(s.doError("HELLO", "3"), "NOAUTH HELLO must be called with the client already authenticated")
+	s.Contains(s.doError("AUTH", "alice", "wrong"), "WRONGPASS")
+	s.Contains(s.doError("AUTH", "not-a-key"), "WRONGPASS")
+	s.Equal("NOAUTH Authentication required.", s.doError("GET", "key"), "a failed AUTH should not authenticate the client")
+
+	// the users can access every key
+	s.Equal("OK", s.do("AUTH", "alice", "secret"))
+	s.Equal("OK", s.do("SET", "billing:1", "paid"))
+	s.Equal("OK", s.do("SET", "key", "value"))
+	s.Equal("OK", s.do("SET", "config:1", "on"))
+
+	// the API keys, sent as the password alone or of the user default, can only access the keys of their rules
+	s.Equal("OK", s.do("AUTH", key))
+	s.Equal("paid", s.do("GET", "billing:1"))
+	s.Equal("on", s.do("GET", "config:1"))
+	s.Equal("NOPERM User billing has no permissions to access the 'config:1' key", s.doError("SET", "config:1", "off"))
+	s.Equal("NOPERM User billing has no permissions to access the 'key' key", s.doError("GET", "key"))
+	s.Equal("NOPERM User billing has no permissions to access the 'key' key", s.doError("DEL", "billing:1", "key"))
+
+	client, err := resp.Dial(s.addr)
+	s.Require().NoError(err)
+	defer client.Close()
+	reply, err := client.Do("HELLO", "3", "AUTH", "default", key)
+	s.Require().NoError(err)
+	s.IsType(map[string]any{}, reply)
+	reply, err = client.Do("GET", "billing:1")
+	s.Require().NoError(err)
+	s.Equal("paid", reply)
+}
+
+func TestServerSuite(t *testing.T) {
+	suite.Run(t, new(ServerSuite))
+}
@@ -0,0 +1,408 @@
+package slots
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"memorydb/internal/db"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	requestTimeout = 10 * time.Second // timeout of the requests sent to the other nodes
+
+	// TopologyPath is the path where a node returns and receives the topology.
+	TopologyPath = "/api/v1/admin/slots"
+
+	// MigratePath is the path where the source of a migration receives the slots to move.
+	MigratePath = "/api/v1/admin/slots/migrate"
+
+	// ImportPath is the path where the target of a migration is told to accept the slots.
+	ImportPath = "/api/v1/admin/slots/import"
+
+	// RestorePath is the path where the target of a migration receives the moved keys.
+	RestorePath = "/api/v1/admin/slots/restore"
+)
+
+var (
+	// ErrStaleTopology is returned when a topology is not newer than the one of the node.
+	ErrStaleTopology = errors.New("the topology is not newer than the current one")
+
+	// ErrInvalidTopology is returned when a topology does not assign every slot to exactly one of its nodes.
+	ErrInvalidTopology = errors.New("invalid topology")
+
+	// ErrInvalidMigration is returned when the slots cannot be moved between the nodes.
+	ErrInvalidMigration = errors.New("invalid slot migration")
+)
+
+// ImportRequest tells the target of a migration to accept the requests of the slots sent after an ASK redirect.
+type ImportRequest struct {
+	Range
+	Source string `json:"source"` // ID of the node the slots are moved from
+}
+
+// RestoreRequest is a key moved to the target of a migration.
+type RestoreRequest struct {
+	Key  string          `json:"key"`
+	Item json.RawMessage `json:"item"` // item encoded by db.DBClient.Dump
+}
+
+// Router decides which node serves every key and moves slots to other nodes.
+type Router struct {
+	logger *slog.Logger
+	db     db.DBClient
+	self   string       // ID of the node
+	client *http.Client // client used to talk to the other nodes
+
+	// credentials of the administrator of the other nodes, not sent if the username is empty
+	username string
+	password string
+
+	mu        sync.RWMutex
+	topology  *Topology
+	owners    []string       // ID of the owner of every slot
+	migrating map[int]string // slots moving to another node, with the ID of the target
+	importing map[int]string // slots moving to this node, with the ID of the source
+
+	// locks of the slots owned by the node. Requests hold the read lock while they are served,
+	// and the migration takes the write lock to move the keys of the slot.
+	locks [NumSlots]sync.RWMutex
+}
+
+// RouterOptions defines an interface for applying options to the Router.
+type RouterOptions interface {
+	apply(*Router)
+}
+
+// WithBasicAuth sets the credentials of the administrator sent to the other nodes, which require them on the
+// slot endpoints when the authentication is enabled.
+type WithBasicAuth struct{ Username, Password string }
+
+func (o WithBasicAuth) apply(r *Router) {
+	r.username, r.password = o.Username, o.Password
+}
+
+// NewRouter creates the router of the node with the given ID, which must be part of the topology.
+func NewRouter(logger *slog.Logger, database db.DBClient, self string, topology *Topology, opts ...RouterOptions) (*Router, error) {
+	owners, err := topology.owners()
+	if err != nil {
+		return nil, fmt.Errorf("invalid topology: %w", err)
+	}
+	if _, ok := topology.node(self); !ok {
+		return nil, fmt.Errorf("node %s is not part of the topology", self)
+	}
+
+	r := &Router{
+		logger:    logger,
+		db:        database,
+		self:      self,
+		client:    &http.Client{Timeout: requestTimeout},
+		topology:  topology,
+		owners:    owners,
+		migrating: make(map[int]string),
+		importing: make(map[int]string),
+	}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return r, nil
+}
+
+// Route decides whether the node serves the key. If it does, it returns a function that must be called once
+// the request is served. Otherwise, it returns the redirect to the node that serves the key.
+//
+// asking tells whether the request was sent after an ASK redirect, in which case it is served if the slot
+// is being imported.
+func (r *Router) Route(key string, asking bool) (*Redirect, func()) {
+	slot := KeySlot(key)
+
+	r.mu.RLock()
+	owner := r.owners[slot]
+	_, importing := r.importing[slot]
+	r.mu.RUnlock()
+
+	if owner != r.self {
+		if importing && asking {
+			return nil, func() {}
+		}
+		return r.redirect(RedirectMoved, slot, owner), nil
+	}
+
+	// wait until the keys of the slot are not being moved, the slot may have changed owner in the meantime
+	lock := &r.locks[slot]
+	lock.RLock()
+
+	r.mu.RLock()
+	owner = r.owners[slot]
+	target, migrating := r.migrating[slot]
+	r.mu.RUnlock()
+
+	if owner != r.self {
+		lock.RUnlock()
+		return r.redirect(RedirectMoved, slot, owner), nil
+	}
+	if migrating {
+		// the keys that are no longer in the node have been moved, or are created in the target
+		if _, err := r.db.Get(key); err != nil {
+			lock.RUnlock()
+			return r.redirect(RedirectAsk, slot, target), nil
+		}
+	}
+	return nil, lock.RUnlock
+}
+
+// redirect returns a redirect of the kind to the node with the ID.
+func (r *Router) redirect(kind RedirectKind, slot int, id string) *Redirect {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	node, _ := r.topology.node(id)
+	return &Redirect{Kind: kind, Slot: slot, URL: node.URL}
+}
+
+// Topology returns the current topology.
+func (r *Router) Topology() Topology {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return *r.topology
+}
+
+// SetTopology replaces the topology with a newer one. The slots the node now owns are no longer imported,
+// and the slots it no longer owns are no longer migrated.
+func (r *Router) SetTopology(topology *Topology) error {
+	owners, err := topology.owners()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTopology, err)
+	}
+	if _, ok := topology.node(r.self); !ok {
+		return fmt.Errorf("%w: node %s is not part of the topology", ErrInvalidTopology, r.self)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if topology.Epoch <= r.topology.Epoch {
+		return fmt.Errorf("%w: epoch %d, current epoch %d", ErrStaleTopology, topology.Epoch, r.topology.Epoch)
+	}
+	r.setTopology(topology, owners)
+	return nil
+}
+
+// setTopology replaces the topology. It must be called with the lock held.
+func (r *Router) setTopology(topology *Topology, owners []string) {
+	r.topology = topology
+	r.owners = owners
+	for slot := range r.importing {
+		if owners[slot] == r.self {
+			delete(r.importing, slot)
+		}
+	}
+	for slot := range r.migrating {
+		if owners[slot] != r.self {
+			delete(r.migrating, slot)
+		}
+	}
+	r.logger.Info("slot topology updated", "epoch", topology.Epoch)
+}
+
+// Import makes the node accept the requests of the slots in the range sent after an ASK redirect,
+// while they are moved from the source.
+func (r *Router) Import(rng Range, source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkRange(rng, source); err != nil {
+		return err
+	}
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		r.importing[slot] = source
+	}
+	r.logger.Info("importing slots", "start", rng.Start, "end", rng.End, "source", source)
+	return nil
+}
+
+// checkRange checks that the slots in the range are owned by the node with the ID. It must be called with the lock held.
+func (r *Router) checkRange(rng Range, owner string) error {
+	if rng.Start < 0 || rng.End >= NumSlots || rng.Start > rng.End {
+		return fmt.Errorf("%w: invalid slot range %d-%d", ErrInvalidMigration, rng.Start, rng.End)
+	}
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		if r.owners[slot] != owner {
+			return fmt.Errorf("%w: slot %d is owned by %s, not by %s", ErrInvalidMigration, slot, r.owners[slot], owner)
+		}
+	}
+	return nil
+}
+
+// Migrate moves the slots in the range to the target node and returns the number of keys moved.
+//
+// The keys are moved one by one while the node keeps serving requests: the requests for the keys still in the node
+// are served, and the others are redirected with ASK to the target. Once every key is moved, the target owns
+// the slots and the new topology is sent to every node. If the migration fails, the slots stay in migration,
+// and it can be resumed by migrating them to the same target again.
+func (r *Router) Migrate(ctx context.Context, rng Range, target string) (int, error) {
+	r.mu.Lock()
+	if err := r.checkRange(rng, r.self); err != nil {
+		r.mu.Unlock()
+		return 0, err
+	}
+	targetNode, ok := r.topology.node(target)
+	if !ok || target == r.self {
+		r.mu.Unlock()
+		return 0, fmt.Errorf("%w: unknown target node %s", ErrInvalidMigration, target)
+	}
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		if current, migrating := r.migrating[slot]; migrating && current != target {
+			r.mu.Unlock()
+			return 0, fmt.Errorf("%w: slot %d is already migrating to %s", ErrInvalidMigration, slot, current)
+		}
+	}
+	r.mu.Unlock()
+
+	if err := r.post(ctx, targetNode.URL+ImportPath, ImportRequest{Range: rng, Source: r.self}); err != nil {
+		return 0, fmt.Errorf("failed to start import in %s: %w", target, err)
+	}
+
+	r.mu.Lock()
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		r.migrating[slot] = target
+	}
+	r.mu.Unlock()
+	r.logger.Info("migrating slots", "start", rng.Start, "end", rng.End, "target", target)
+
+	// wait for the requests routed before the migration started, which may still create keys
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		r.locks[slot].Lock()
+		r.locks[slot].Unlock()
+	}
+
+	moved := 0
+	for _, key := range r.keys(rng) {
+		slot := KeySlot(key)
+		r.locks[slot].Lock()
+		ok, err := r.moveKey(ctx, targetNode.URL, key)
+		r.locks[slot].Unlock()
+		if err != nil {
+			return moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+
+	next, remaining, err := r.handOver(ctx, rng, targetNode)
+	moved += remaining
+	if err != nil {
+		return moved, err
+	}
+
+	r.logger.Info("slots migrated", "start", rng.Start, "end", rng.End, "target", target, "keys", moved, "epoch", next.Epoch)
+	r.broadcast(ctx, next)
+	return moved, nil
+}
+
+// handOver moves the keys left in the slots of the range and makes the target the owner of the slots.
+// Every slot of the range is locked meanwhile, so no request is served while the owner changes.
+// It returns the new topology and the number of keys moved.
+func (r *Router) handOver(ctx context.Context, rng Range, target Node) (*Topology, int, error) {
+	for slot := rng.Start; slot <= rng.End; slot++ {
+		r.locks[slot].Lock()
+		defer r.locks[slot].Unlock()
+	}
+
+	moved := 0
+	for _, key := range r.keys(rng) {
+		ok, err := r.moveKey(ctx, target.URL, key)
+		if err != nil {
+			return nil, moved, err
+		}
+		if ok {
+			moved++
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next := r.topology.reassign(rng, target.ID)
+	owners, _ := next.owners()
+	r.setTopology(next, owners)
+	return next, moved, nil
+}
+
+// keys returns the keys of the node in the slots of the range.
+func (r *Router) keys(rng Range) []string {
+	var keys []string
+	for _, key := range r.db.Keys("*") {
+		if slot := KeySlot(key); slot >= rng.Start && slot <= rng.End {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// moveKey sends the key to the target and removes it from the node. It returns false if the key no longer exists.
+// It must be called with the lock of the slot held.
+func (r *Router) moveKey(ctx context.Context, targetURL string, key string) (bool, error) {
+	data, err := r.db.Dump(key)
+	if err != nil {
+		// the key expired or was removed since the keys were listed
+		return false, nil
+	}
+	if err := r.post(ctx, targetURL+RestorePath, RestoreRequest{Key: key, Item: data}); err != nil {
+		return false, fmt.Errorf("failed to move key %s: %w", key, err)
+	}
+	if err := r.db.Remove(key); err != nil {
+		return false, fmt.Errorf("failed to remove moved key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// broadcast sends the topology to the other nodes. Nodes that cannot be reached learn it later,
+// since the nodes that own the slots redirect the requests they receive.
+func (r *Router) broadcast(ctx context.Context, topology *Topology) {
+	for _, node := range topology.Nodes {
+		if node.ID == r.self {
+			continue
+		}
+		if err := r.send(ctx, http.MethodPut, node.URL+TopologyPath, topology); err != nil {
+			r.logger.Warn("failed to send topology", "node", node.ID, "epoch", topology.Epoch, "error", err)
+		}
+	}
+}
+
+// post sends the body as JSON to the URL and checks that the request succeeded.
+func (r *Router) post(ctx context.Context, url string, body any) error {
+	return r.send(ctx, http.MethodPost, url, body)
+}
+
+// send sends the body as JSON to the URL with the method and checks that the request succeeded.
+func (r *Router) send(ctx context.Context, method, url string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("received status code %d from %s: %s", resp.StatusCode, url, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+The package slots implements server-side sharding with hash slots.
+
+Every key maps to one of NumSlots hash slots, and every slot is owned by one node of the topology. A node that
+receives a request for a key of a slot it does not own answers with a MOVED redirect that names the owner, so
+clients learn the topology from the redirects. Slots are moved between nodes live: while a slot migrates, the
+source serves the keys it still holds and answers with an ASK redirect for the others, which the client follows
+once without updating its view of the topology.
+*/
+package slots
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// NumSlots is the number of hash slots the keys are spread across.
+	NumSlots = 16384
+
+	// RedirectHeader is the header of the redirects, with the form "MOVED <slot> <URL>" or "ASK <slot> <URL>".
+	RedirectHeader = "X-Memorydb-Redirect"
+
+	// AskingHeader marks a request sent after an ASK redirect, which the node importing the slot accepts.
+	AskingHeader = "X-Memorydb-Asking"
+)
+
+// KeySlot returns the hash slot of the key.
+//
+// If the key contains a hash tag, a non-empty substring between the first '{' and the next '}', only the tag is
+// hashed, so keys such as user:{42}:name and user:{42}:email are always in the same slot.
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % NumSlots
+}
+
+// crc16 returns the CRC16-CCITT (XMODEM) checksum of the string.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// RedirectKind is the kind of a redirect.
+type RedirectKind string
+
+const (
+	// RedirectMoved is returned when the slot is owned by another node. Clients should send the requests of the slot there.
+	RedirectMoved RedirectKind = "MOVED"
+
+	// RedirectAsk is returned when the slot is migrating and the key is no longer in the source. Clients should send
+	// only this request to the target, with the AskingHeader.
+	RedirectAsk RedirectKind = "ASK"
+)
+
+// Redirect tells a client which node serves the slot of the key it requested.
+type Redirect struct {
+	Kind RedirectKind
+	Slot int
+	URL  string // base URL of the node that serves the slot
+}
+
+// String returns the redirect as it is sent in the RedirectHeader.
+func (r Redirect) String() string {
+	return fmt.Sprintf("%s %d %s", r.Kind, r.Slot, r.URL)
+}
+
+// ParseRedirect parses the value of the RedirectHeader.
+func ParseRedirect(value string) (Redirect, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 {
+		return Redirect{}, fmt.Errorf("invalid redirect '%s'", value)
+	}
+
+	kind := RedirectKind(fields[0])
+	if kind != RedirectMoved && kind != RedirectAsk {
+		return Redirect{}, fmt.Errorf("invalid redirect kind '%s'", fields[0])
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil || slot < 0 || slot >= NumSlots {
+		return Redirect{}, fmt.Errorf("invalid redirect slot '%s'", fields[1])
+	}
+	return Redirect{Kind: kind, Slot: slot, URL: fields[2]}, nil
+}
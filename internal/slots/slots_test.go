@@ -0,0 +1,111 @@
+package slots
+
+import (
+	"log/slog"
+	"memorydb/internal/db"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SlotsSuite struct {
+	suite.Suite
+}
+
+func (s *SlotsSuite) TestKeySlot() {
+	// reference values of the CRC16 XMODEM checksum
+	s.Equal(12739, KeySlot("123456789"))
+	s.Equal(12182, KeySlot("foo"))
+
+	s.Equal(KeySlot("42"), KeySlot("user:{42}:name"), "only the hash tag should be hashed")
+	s.Equal(KeySlot("user:{42}:name"), KeySlot("user:{42}:email"))
+	s.Equal(crc16("{}key")%NumSlots, uint16(KeySlot("{}key")), "empty hash tags should be ignored")
+	s.Equal(crc16("key{")%NumSlots, uint16(KeySlot("key{")), "unclosed hash tags should be ignored")
+}
+
+func (s *SlotsSuite) TestParseRedirect() {
+	redirect := Redirect{Kind: RedirectAsk, Slot: 42, URL: "http://node2:8080"}
+	parsed, err := ParseRedirect(redirect.String())
+	s.Require().NoError(err)
+	s.Equal(redirect, parsed)
+
+	for _, value := range []string{"", "MOVED 42", "WRONG 42 http://node2:8080", "MOVED 16384 http://node2:8080", "MOVED x http://node2:8080"} {
+		_, err := ParseRedirect(value)
+		s.Error(err, "redirect '%s' should be rejected", value)
+	}
+}
+
+func (s *SlotsSuite) TestTopology() {
+	nodes, err := ParseNodes("a=http://a:8080, b=http://b:8080,c=http://c:8080")
+	s.Require().NoError(err)
+	s.Len(nodes, 3)
+
+	topology := NewTopology(nodes)
+	s.Equal(uint64(1), topology.Epoch)
+	owners, err := topology.owners()
+	s.Require().NoError(err)
+	s.Equal("a", owners[0])
+	s.Equal("c", owners[NumSlots-1])
+
+	next := topology.reassign(Range{Start: 0, End: 99}, "b")
+	s.Equal(uint64(2), next.Epoch)
+	owners, err = next.owners()
+	s.Require().NoError(err)
+	s.Equal("b", owners[0])
+	s.Equal("b", owners[99])
+	s.Equal("a", owners[100])
+	s.Equal(uint64(1), topology.Epoch, "the previous topology should not change")
+
+	_, err = ParseNodes("a=http://a:8080,a=http://b:8080")
+	s.Error(err, "duplicated nodes should be rejected")
+	_, err = ParseNodes("a")
+	s.Error(err)
+}
+
+func (s *SlotsSuite) TestRoute() {
+	database := db.NewMemoryDB(slog.Default())
+	defer database.Close()
+
+	nodes := []Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := NewRouter(slog.Default(), database, "a", NewTopology(nodes))
+	s.Require().NoError(err)
+
+	s.Run("Owned slot", func() {
+		redirect, release := router.Route("foo", false) // slot 12182, owned by b
+		s.Require().NotNil(redirect)
+		s.Equal(Redirect{Kind: RedirectMoved, Slot: 12182, URL: "http://b:8080"}, *redirect)
+		s.Nil(release)
+
+		redirect, release = router.Route("123", false) // slot 5970, owned by a
+		s.Nil(redirect)
+		s.Require().NotNil(release)
+		release()
+	})
+
+	s.Run("Importing slot", func() {
+		s.Require().NoError(router.Import(Range{Start: 12182, End: 12182}, "b"))
+		redirect, release := router.Route("foo", true)
+		s.Nil(redirect, "requests sent after an ASK redirect should be served")
+		release()
+
+		redirect, _ = router.Route("foo", false)
+		s.NotNil(redirect, "other requests should be redirected")
+
+		s.Error(router.Import(Range{Start: 0, End: 0}, "b"), "the slot is not owned by b")
+	})
+
+	s.Run("Topology", func() {
+		topology := router.Topology()
+		s.ErrorIs(router.SetTopology(&topology), ErrStaleTopology)
+
+		next := topology.reassign(Range{Start: 12182, End: 12182}, "a")
+		s.Require().NoError(router.SetTopology(next))
+		redirect, release := router.Route("foo", false)
+		s.Nil(redirect, "the slot should be owned by the node")
+		release()
+	})
+}
+
+func TestSlotsSuite(t *testing.T) {
+	suite.Run(t, new(SlotsSuite))
+}
@@ -0,0 +1,125 @@
+package slots
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Range is a range of slots, both ends included.
+type Range struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Node is a node of the topology with the slots it owns.
+type Node struct {
+	ID    string  `json:"id"`
+	URL   string  `json:"url"` // base URL of the HTTP API of the node
+	Slots []Range `json:"slots"`
+}
+
+// Topology is the assignment of the slots to the nodes. Every change increases the epoch,
+// so the nodes only accept topologies newer than the one they have.
+type Topology struct {
+	Epoch uint64 `json:"epoch"`
+	Nodes []Node `json:"nodes"`
+}
+
+// ParseNodes parses a comma separated list of nodes in the form <id>=<URL>, such as node1=http://10.0.0.1:8080.
+func ParseNodes(raw string) ([]Node, error) {
+	var nodes []Node
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		id, url, ok := strings.Cut(part, "=")
+		if !ok || id == "" || url == "" {
+			return nil, fmt.Errorf("invalid node '%s', it must have the form <id>=<URL>", part)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicated node ID '%s'", id)
+		}
+		seen[id] = true
+		nodes = append(nodes, Node{ID: id, URL: strings.TrimSuffix(url, "/")})
+	}
+	return nodes, nil
+}
+
+// NewTopology creates the first topology of the nodes, which splits the slots in contiguous ranges of the same size
+// in the order of the nodes.
+func NewTopology(nodes []Node) *Topology {
+	t := &Topology{Epoch: 1, Nodes: make([]Node, len(nodes))}
+	for i, node := range nodes {
+		start := i * NumSlots / len(nodes)
+		end := (i+1)*NumSlots/len(nodes) - 1
+		t.Nodes[i] = Node{ID: node.ID, URL: node.URL, Slots: []Range{{Start: start, End: end}}}
+	}
+	return t
+}
+
+// owners returns the ID of the owner of every slot, and an error if a slot is not owned by exactly one node.
+func (t *Topology) owners() ([]string, error) {
+	owners := make([]string, NumSlots)
+	for _, node := range t.Nodes {
+		for _, r := range node.Slots {
+			if r.Start < 0 || r.End >= NumSlots || r.Start > r.End {
+				return nil, fmt.Errorf("invalid slot range %d-%d of node %s", r.Start, r.End, node.ID)
+			}
+			for slot := r.Start; slot <= r.End; slot++ {
+				if owners[slot] != "" {
+					return nil, fmt.Errorf("slot %d is owned by nodes %s and %s", slot, owners[slot], node.ID)
+				}
+				owners[slot] = node.ID
+			}
+		}
+	}
+	for slot, owner := range owners {
+		if owner == "" {
+			return nil, fmt.Errorf("slot %d is not owned by any node", slot)
+		}
+	}
+	return owners, nil
+}
+
+// node returns the node with the ID.
+func (t *Topology) node(id string) (Node, bool) {
+	for _, node := range t.Nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+	return Node{}, false
+}
+
+// reassign returns a copy of the topology with the next epoch where the slots in the range are owned by the node.
+func (t *Topology) reassign(r Range, owner string) *Topology {
+	owners, _ := t.owners()
+	for slot := r.Start; slot <= r.End; slot++ {
+		owners[slot] = owner
+	}
+
+	next := &Topology{Epoch: t.Epoch + 1, Nodes: make([]Node, len(t.Nodes))}
+	for i, node := range t.Nodes {
+		next.Nodes[i] = Node{ID: node.ID, URL: node.URL, Slots: ranges(owners, node.ID)}
+	}
+	return next
+}
+
+// ranges returns the slots owned by the node as contiguous ranges.
+func ranges(owners []string, id string) []Range {
+	result := make([]Range, 0)
+	for slot := 0; slot < NumSlots; slot++ {
+		if owners[slot] != id {
+			continue
+		}
+		if n := len(result); n > 0 && result[n-1].End == slot-1 {
+			result[n-1].End = slot
+			continue
+		}
+		result = append(result, Range{Start: slot, End: slot})
+	}
+	return result
+}
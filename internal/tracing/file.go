@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient writes the spans exported by an OTLP exporter to a file, as the JSON lines of the OTLP file exporter:
+// every batch of spans is written as the JSON encoding of an export request on a line of its own.
+type fileClient struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer // file closed when the exporter stops, nil for the standard output
+}
+
+// newFileClient opens the file the spans are appended to, or the standard output if the path is "stdout" or "-".
+func newFileClient(path string) (*fileClient, error) {
+	if path == "stdout" || path == "-" {
+		return &fileClient{w: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tracing file %s: %w", path, err)
+	}
+	return &fileClient{w: file, closer: file}, nil
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closer == nil {
+		return nil
+	}
+	err := c.closer.Close()
+	c.closer, c.w = nil, io.Discard
+	return err
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := protojson.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write spans: %w", err)
+	}
+	return nil
+}
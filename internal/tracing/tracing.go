@@ -0,0 +1,129 @@
+/*
+The package tracing sets up the OpenTelemetry tracer provider of the server.
+
+The HTTP API creates a span for every request, as a child of the span of the W3C trace context in its headers, and
+the database creates the spans of its operations as children of the span of the request. The spans are exported by
+the exporter set in the configuration: to an OTLP collector over HTTP, or as OTLP JSON lines to a file or to the
+standard output, which needs no collector. Without an exporter no span is recorded, but the trace context of the
+requests is still propagated.
+*/
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"memorydb/internal/enums"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	serviceName = "memorydb" // name of the service in the resource of the spans
+)
+
+// SetupOptions defines an interface for applying options to the setup of the tracing.
+type SetupOptions interface {
+	apply(*settings)
+}
+
+// WithEndpoint sets the URL of the OTLP/HTTP collector, such as http://collector:4318. The collector is set by the
+// OTEL_EXPORTER_OTLP_* environment variables if it is not set.
+type WithEndpoint string
+
+func (o WithEndpoint) apply(s *settings) {
+	s.endpoint = string(o)
+}
+
+// WithFile sets the path of the file the spans are written to by the file exporter. The spans are written to
+// the standard output if it is "stdout" or "-", which is the default.
+type WithFile string
+
+func (o WithFile) apply(s *settings) {
+	s.file = string(o)
+}
+
+// WithSampleRatio sets the fraction of the traces started by the server that are sampled. The traces started by
+// the clients are sampled if the clients sampled them. All the traces are sampled by default.
+type WithSampleRatio float64
+
+func (o WithSampleRatio) apply(s *settings) {
+	s.sampleRatio = float64(o)
+}
+
+// settings holds the settings of the tracing.
+type settings struct {
+	endpoint    string  // URL of the OTLP/HTTP collector
+	file        string  // path of the file of the file exporter
+	sampleRatio float64 // fraction of the traces started by the server that are sampled
+}
+
+// Setup sets the W3C trace context propagator and the tracer provider of the exporter as the global ones. The
+// returned function flushes the spans not exported yet and stops the exporter, and must be called before exiting.
+func Setup(ctx context.Context, exporter enums.TracingExporter, opts ...SetupOptions) (func(context.Context) error, error) {
+	s := &settings{file: "stdout", sampleRatio: 1}
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == enums.TracingExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	spanExporter, err := newExporter(ctx, exporter, s)
+	if err != nil {
+		return nil, err
+	}
+
+	// the attributes of the environment, such as OTEL_SERVICE_NAME, take precedence over the name of the service
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource of the spans: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.sampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// newExporter creates the exporter of the spans.
+func newExporter(ctx context.Context, exporter enums.TracingExporter, s *settings) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case enums.TracingExporterOTLP:
+		var opts []otlptracehttp.Option
+		if s.endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(s.endpoint))
+		}
+		spanExporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		return spanExporter, nil
+	case enums.TracingExporterFile:
+		client, err := newFileClient(s.file)
+		if err != nil {
+			return nil, err
+		}
+		spanExporter, err := otlptrace.New(ctx, client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		return spanExporter, nil
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter: %s", exporter)
+	}
+}
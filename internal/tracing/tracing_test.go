@@ -0,0 +1,79 @@
+package tracing_test
+
+import (
+	"bufio"
+	"context"
+	"memorydb/internal/enums"
+	"memorydb/internal/tracing"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type TracingSuite struct {
+	suite.Suite
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+func (s *TracingSuite) SetupTest() {
+	s.provider = otel.GetTracerProvider()
+	s.propagator = otel.GetTextMapPropagator()
+}
+
+func (s *TracingSuite) TearDownTest() {
+	otel.SetTracerProvider(s.provider)
+	otel.SetTextMapPropagator(s.propagator)
+}
+
+func (s *TracingSuite) TestFileExporter() {
+	path := filepath.Join(s.T().TempDir(), "spans.jsonl")
+	shutdown, err := tracing.Setup(context.Background(), enums.TracingExporterFile, tracing.WithFile(path))
+	s.Require().NoError(err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "operation")
+	span.End()
+	s.Require().NoError(shutdown(context.Background()), "the spans should be flushed when the exporter stops")
+
+	file, err := os.Open(path)
+	s.Require().NoError(err)
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	s.Require().True(scanner.Scan(), "the spans should be written on a line")
+
+	var request coltracepb.ExportTraceServiceRequest
+	s.Require().NoError(protojson.Unmarshal(scanner.Bytes(), &request), "the line should be an OTLP JSON export request")
+	s.Require().Len(request.ResourceSpans, 1)
+	resourceSpans := request.ResourceSpans[0]
+	s.Contains(resourceSpans.Resource.String(), "memorydb")
+	s.Require().Len(resourceSpans.ScopeSpans, 1)
+	s.Require().Len(resourceSpans.ScopeSpans[0].Spans, 1)
+	s.Equal("operation", resourceSpans.ScopeSpans[0].Spans[0].Name)
+	s.Equal(span.SpanContext().TraceID().String(), trace.TraceID(resourceSpans.ScopeSpans[0].Spans[0].TraceId).String())
+}
+
+func (s *TracingSuite) TestNone() {
+	shutdown, err := tracing.Setup(context.Background(), enums.TracingExporterNone)
+	s.Require().NoError(err)
+	s.NoError(shutdown(context.Background()))
+
+	s.Contains(otel.GetTextMapPropagator().Fields(), "traceparent", "the trace context should be propagated without an exporter")
+	_, span := otel.Tracer("test").Start(context.Background(), "operation")
+	s.False(span.IsRecording(), "no span should be recorded without an exporter")
+}
+
+func (s *TracingSuite) TestInvalidFile() {
+	_, err := tracing.Setup(context.Background(), enums.TracingExporterFile, tracing.WithFile(filepath.Join(s.T().TempDir(), "missing", "spans.jsonl")))
+	s.ErrorContains(err, "failed to open tracing file")
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingSuite))
+}
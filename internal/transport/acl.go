@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/auth"
+	"memorydb/internal/enums"
+	"net/http"
+)
+
+// principalKey is the key of the principal of the authenticated requests in their context.
+type principalKey struct{}
+
+// PrincipalFromContext returns the principal of an authenticated request, which is a user or an API key.
+func PrincipalFromContext(ctx context.Context) (*auth.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*auth.Principal)
+	return principal, ok
+}
+
+// authorizeKey serves the request only if its principal has the permission on the key returned by keyFunc.
+// Every request is served if it has no principal, which is the case when the authentication is disabled.
+func authorizeKey(permission enums.Permission, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the key is not read without a principal, since reading it from the body copies the body
+			if _, ok := PrincipalFromContext(r.Context()); ok {
+				if e := authorize(r, permission, keyFunc(r)); e != nil {
+					wrapError(w, e)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorize returns a forbidden error if the principal of the request does not have the permission on every key.
+// Empty keys are rejected, since no rule can be checked for them.
+func authorize(r *http.Request, permission enums.Permission, keys ...string) *apierrors.ApiError {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	for _, key := range keys {
+		if key == "" {
+			return errKeyRequired()
+		}
+		if !principal.Allows(permission, key) {
+			return forbidden(principal, permission, key)
+		}
+	}
+	return nil
+}
+
+// authorizePattern returns a forbidden error if the principal of the request does not have the permission on every
+// key that matches each glob pattern. An empty pattern matches every key.
+func authorizePattern(r *http.Request, permission enums.Permission, patterns ...string) *apierrors.ApiError {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return nil
+	}
+	for _, pattern := range patterns {
+		if !principal.AllowsPattern(permission, pattern) {
+			if pattern == "" {
+				pattern = "*"
+			}
+			return forbidden(principal, permission, pattern)
+		}
+	}
+	return nil
+}
+
+// errKeyRequired returns the error of a request without a key.
+func errKeyRequired() *apierrors.ApiError {
+	e := *apierrors.ErrInvalidRequest
+	e.Message = "the key is required"
+	e.SysMessage = e.Message
+	return &e
+}
+
+// forbidden returns the error of a principal that does not have the permission on the key or pattern.
+func forbidden(principal *auth.Principal, permission enums.Permission, key string) *apierrors.ApiError {
+	e := *apierrors.ErrForbidden
+	e.Message = fmt.Sprintf("'%s' has no %s permission on '%s'", principal.Name, permission, key)
+	e.SysMessage = e.Message
+	return &e
+}
@@ -0,0 +1,57 @@
+package transport
+
+import (
+	"memorydb/internal/db"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleCreateAPIKey creates an API key with the rules of the request. The key is only returned in this response.
+func (h *AuthHandler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var body schemas.CreateAPIKeyRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	item, key, err := h.manager.CreateAPIKey(body.Name, body.Subject, body.Rules)
+	if err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	response := apiKeyResponse(item)
+	response.Key = key
+	writeJSON(w, http.StatusCreated, response)
+}
+
+// HandleListAPIKeys returns the API keys, without their keys.
+func (h *AuthHandler) HandleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	items := h.manager.APIKeys()
+	response := schemas.APIKeysResponse{Keys: make([]schemas.APIKeyResponse, len(items))}
+	for i, item := range items {
+		response.Keys[i] = apiKeyResponse(item)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleRevokeAPIKey revokes the API key with the ID in the URL.
+func (h *AuthHandler) HandleRevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := h.manager.RevokeAPIKey(chi.URLParam(r, "id")); err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// apiKeyResponse returns the response of an API key, without its key.
+func apiKeyResponse(item *db.APIKeyItem) schemas.APIKeyResponse {
+	return schemas.APIKeyResponse{
+		ID:        item.ID,
+		Name:      item.Name,
+		Subject:   item.Subject,
+		Rules:     item.Rules,
+		CreatedAt: item.CreatedAt,
+	}
+}
@@ -0,0 +1,118 @@
+package transport_test
+
+import (
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+)
+
+// admin returns the headers of the requests authenticated with the credentials of the administrator.
+func admin(password string) http.Header {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("admin", password)
+	return req.Header
+}
+
+// withAPIKey returns the headers of the requests authenticated with the API key.
+func withAPIKey(key string) http.Header {
+	return http.Header{"X-Api-Key": []string{key}}
+}
+
+// createAPIKey creates an API key that can read and write billing:* and read config:*.
+func (s *AuthSuite) createAPIKey() schemas.APIKeyResponse {
+	request := schemas.CreateAPIKeyRequest{Name: "billing", Rules: []db.ACLRule{
+		{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}},
+		{Pattern: "config:*", Permissions: []enums.Permission{enums.PermissionRead}},
+	}}
+	var key schemas.APIKeyResponse
+	s.Require().Equal(http.StatusCreated, s.send(http.MethodPost, "/api/v1/admin/apikeys", admin("admin-password"), request, &key))
+	s.Require().NotEmpty(key.Key)
+	return key
+}
+
+func (s *AuthSuite) TestAPIKeysAdmin() {
+	var errResponse apierrors.ApiError
+	request := schemas.CreateAPIKeyRequest{Name: "billing", Rules: []db.ACLRule{{Pattern: "billing:*:x", Permissions: []enums.Permission{enums.PermissionRead}}}}
+	s.Equal(http.StatusUnauthorized, s.send(http.MethodPost, "/api/v1/admin/apikeys", http.Header{}, request, &errResponse))
+	s.Equal(http.StatusUnauthorized, s.send(http.MethodGet, "/api/v1/admin/apikeys", admin("wrong"), nil, &errResponse))
+	s.Equal(apierrors.ErrInvalidCredentials.Code, errResponse.Code)
+	s.Equal(http.StatusBadRequest, s.send(http.MethodPost, "/api/v1/admin/apikeys", admin("admin-password"), request, &errResponse), "the rule should be rejected")
+
+	key := s.createAPIKey()
+	var keys schemas.APIKeysResponse
+	s.Require().Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/admin/apikeys", admin("admin-password"), nil, &keys))
+	s.Require().Len(keys.Keys, 1)
+	s.Equal(key.ID, keys.Keys[0].ID)
+	s.Empty(keys.Keys[0].Key, "the secret should not be listed")
+
+	s.Require().NoError(s.db.Set("config:timeout", "30s"))
+	s.Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/config:timeout", withAPIKey(key.Key), nil, nil))
+	s.Equal(http.StatusOK, s.send(http.MethodDelete, "/api/v1/admin/apikeys/"+key.ID, admin("admin-password"), nil, nil))
+	s.Equal(http.StatusNotFound, s.send(http.MethodDelete, "/api/v1/admin/apikeys/"+key.ID, admin("admin-password"), nil, &errResponse))
+	s.Equal(apierrors.ErrAPIKeyNotFound.Code, errResponse.Code)
+	s.Equal(http.StatusUnauthorized, s.send(http.MethodGet, "/api/v1/config:timeout", withAPIKey(key.Key), nil, &errResponse), "a revoked key should be rejected")
+	s.Equal(apierrors.ErrInvalidAPIKey.Code, errResponse.Code)
+}
+
+func (s *AuthSuite) TestAPIKeyRules() {
+	key := withAPIKey(s.createAPIKey().Key)
+	var errResponse apierrors.ApiError
+
+	s.Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/set", key, schemas.SetRowRequest{Key: "billing:1", Value: db.StringOrSlice{Val: "1"}}, nil))
+	s.Equal(http.StatusForbidden, s.send(http.MethodPost, "/api/v1/set", key, schemas.SetRowRequest{Key: "config:timeout", Value: db.StringOrSlice{Val: "1"}}, &errResponse))
+	s.Equal(apierrors.ErrForbidden.Code, errResponse.Code)
+	s.Equal(http.StatusForbidden, s.send(http.MethodGet, "/api/v1/users:1", key, nil, &errResponse))
+	s.Equal(http.StatusForbidden, s.send(http.MethodDelete, "/api/v1/config:timeout", key, nil, &errResponse))
+	var row schemas.RowResponse
+	s.Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/billing:1", key, nil, &row))
+	s.Equal("1", row.Value)
+	s.Equal(http.StatusUnauthorized, s.send(http.MethodGet, "/api/v1/billing:1", withAPIKey("unknown.secret"), nil, &errResponse))
+
+	// the batches are checked key by key, unless they are atomic
+	var batch schemas.BatchResponse
+	s.Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/mget", key, schemas.MGetRequest{Keys: []string{"billing:1", "users:1"}}, &batch))
+	s.NotNil(batch.Results["billing:1"].Item)
+	s.Require().NotNil(batch.Results["users:1"].Error)
+	s.Equal(apierrors.ErrForbidden.Code, batch.Results["users:1"].Error.Code)
+	mset := schemas.MSetRequest{Items: map[string]db.StringOrSlice{"billing:2": {Val: "2"}, "config:timeout": {Val: "2"}}, Atomic: true}
+	s.Equal(http.StatusForbidden, s.send(http.MethodPost, "/api/v1/mset", key, mset, &errResponse))
+	s.Equal(http.StatusNotFound, s.send(http.MethodGet, "/api/v1/billing:2", key, nil, &errResponse), "an atomic batch should not be partially applied")
+
+	var pipeline schemas.PipelineResponse
+	commands := schemas.PipelineRequest{Commands: []schemas.PipelineCommand{{Op: "get", Key: "config:timeout"}, {Op: "remove", Key: "config:timeout"}}}
+	s.Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/pipeline", key, commands, &pipeline))
+	s.Require().Len(pipeline.Results, 2)
+	s.Equal(apierrors.ErrItemNotFound.Code, pipeline.Results[0].Error.Code)
+	s.Equal(apierrors.ErrForbidden.Code, pipeline.Results[1].Error.Code)
+
+	// the channels and the patterns of the events are checked as keys
+	s.Equal(http.StatusForbidden, s.send(http.MethodPost, "/api/v1/publish", key, schemas.PublishRequest{Channel: "config:changes"}, &errResponse))
+	s.Equal(http.StatusForbidden, s.send(http.MethodGet, "/api/v1/subscribe?pattern=billing*", key, nil, &errResponse))
+	s.Equal(http.StatusForbidden, s.send(http.MethodGet, "/api/v1/events", key, nil, &errResponse), "the events of every key should be forbidden")
+	s.Equal(http.StatusForbidden, s.send(http.MethodGet, "/api/v1/streams/users/", key, nil, &errResponse))
+
+	// the users can access every key
+	tokens := s.login()
+	s.Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/set", tokens.AccessToken, schemas.SetRowRequest{Key: "config:timeout", Value: db.StringOrSlice{Val: "1"}}, nil))
+}
+
+func (s *AuthSuite) TestAPIKeyRulesFieldCase() {
+	key := withAPIKey(s.createAPIKey().Key)
+	var errResponse apierrors.ApiError
+	s.Require().NoError(s.db.Set("config:timeout", "30s"))
+
+	// the fields are matched without case, as the handler does, so the key checked is the key written
+	body := map[string]any{"Key": "config:timeout", "value": "1s"}
+	s.Equal(http.StatusForbidden, s.send(http.MethodPost, "/api/v1/set", key, body, &errResponse))
+	s.Equal(apierrors.ErrForbidden.Code, errResponse.Code)
+	item, err := s.db.Get("config:timeout")
+	s.Require().NoError(err)
+	s.Equal("30s", item.Value.Val, "the value should not be overwritten")
+
+	body = map[string]any{"KEY": "billing:1", "value": "1"}
+	s.Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/set", key, body, nil))
+	s.Equal(http.StatusBadRequest, s.send(http.MethodPost, "/api/v1/set", key, map[string]any{"value": "1"}, &errResponse))
+	s.Equal(apierrors.ErrInvalidRequest.Code, errResponse.Code)
+}
@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strings"
+)
+
+// apiKeyHeader is the header of the API key of the requests authenticated with an API key instead of an access token.
+const apiKeyHeader = "X-API-Key"
+
+// claimsKey is the key of the claims of the access token in the context of the authenticated requests.
+type claimsKey struct{}
+
+type AuthHandler struct {
+	logger  *slog.Logger
+	manager auth.AuthManager
+	handler *Handler // handler of the keys, used to convert the errors of the database
+}
+
+// NewAuthHandler creates a new handler for the authentication endpoints.
+func NewAuthHandler(logger *slog.Logger, db db.DBClient, manager auth.AuthManager) *AuthHandler {
+	return &AuthHandler{logger: logger, manager: manager, handler: NewHandler(logger, db)}
+}
+
+// HandleRegister registers a new user with the credentials of the administrator.
+func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
+	var body schemas.RegisterRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	if err := h.manager.Register(body.AdminUsername, body.AdminPassword, body.Username, body.Password); err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, schemas.OKResponse{Message: "ok"})
+}
+
+// HandleLogin checks the credentials of a user and returns the tokens of a new session.
+func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	var body schemas.LoginRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	tokens, err := h.manager.Login(body.Username, body.Password)
+	if err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, tokenResponse(tokens))
+}
+
+// HandleRefresh exchanges a refresh token for a new pair of tokens.
+func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	var body schemas.RefreshTokenRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	tokens, err := h.manager.RefreshToken(body.RefreshToken)
+	if err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, tokenResponse(tokens))
+}
+
+// HandleLogout ends the session of the access token of the Authorization header.
+func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		wrapError(w, apierrors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.manager.Logout(token); err != nil {
+		wrapError(w, h.wrapAuthError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// wrapAuthError converts an error of the authentication manager into an API error.
+func (h *AuthHandler) wrapAuthError(err error) *apierrors.ApiError {
+	var e apierrors.ApiError
+	switch {
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		e = *apierrors.ErrInvalidCredentials
+	case errors.Is(err, auth.ErrUserAlreadyExists):
+		e = *apierrors.ErrUserAlreadyExists
+	case errors.Is(err, auth.ErrInvalidToken):
+		e = *apierrors.ErrInvalidToken
+		e.Message = err.Error()
+	case errors.Is(err, auth.ErrPasswordTooLong), errors.Is(err, auth.ErrInvalidRule):
+		e = *apierrors.ErrInvalidRequest
+		e.Message = err.Error()
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		e = *apierrors.ErrAPIKeyNotFound
+	case errors.Is(err, auth.ErrSubjectAlreadyUsed):
+		e = *apierrors.ErrAPIKeySubjectConflict
+	default:
+		// the users are written to the database, which can be a replica or a cluster without leader
+		var dbError *db.DBerror
+		if errors.As(err, &dbError) {
+			return h.handler.wrapDBError(dbError)
+		}
+		e = *apierrors.ErrInternalServer
+		e.Message = err.Error()
+	}
+	e.SysMessage = err.Error()
+	return &e
+}
+
+// requireAuth serves the request only if it has a valid access token in its Authorization header, a valid API key
+// in its X-API-Key header or a verified client certificate whose identity is the subject of an API key, in that
+// order, and stores the principal of the request in its context, with the claims of the token if it has one.
+// Every request is served if manager is nil.
+func requireAuth(manager auth.AuthManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if manager == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get(apiKeyHeader); key != "" {
+				principal, err := manager.AuthenticateAPIKey(key)
+				if err != nil {
+					e := *apierrors.ErrInvalidAPIKey
+					if !errors.Is(err, auth.ErrInvalidAPIKey) {
+						e = *apierrors.ErrInternalServer
+					}
+					e.Message = err.Error()
+					e.SysMessage = err.Error()
+					wrapError(w, &e)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+				return
+			}
+
+			token, ok := bearerToken(r)
+			if !ok {
+				if principal, err := authenticateCertificate(manager, r); err != nil {
+					e := *apierrors.ErrInternalServer
+					e.Message = err.Error()
+					e.SysMessage = err.Error()
+					wrapError(w, &e)
+					return
+				} else if principal != nil {
+					next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+					return
+				}
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				wrapError(w, apierrors.ErrUnauthorized)
+				return
+			}
+
+			claims, err := manager.Authenticate(token)
+			if err != nil {
+				e := *apierrors.ErrInvalidToken
+				e.Message = err.Error()
+				e.SysMessage = err.Error()
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				wrapError(w, &e)
+				return
+			}
+			// the users can access every key
+			ctx := context.WithValue(r.Context(), claimsKey{}, claims)
+			ctx = context.WithValue(ctx, principalKey{}, &auth.Principal{Name: claims.Subject, Rules: auth.FullAccess})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// requireAdmin serves the request only if it has the credentials of the administrator in its Authorization header,
+// with the Basic scheme.
+func requireAdmin(manager auth.AuthManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok || manager.AuthenticateAdmin(username, password) != nil {
+				w.Header().Set("WWW-Authenticate", `Basic realm="memorydb admin"`)
+				wrapError(w, apierrors.ErrInvalidCredentials)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClaimsFromContext returns the claims of the access token of an authenticated request.
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*auth.Claims)
+	return claims, ok
+}
+
+// authenticateCertificate returns the principal of the verified client certificate of the request, or nil if the
+// request has no verified certificate or no API key has its identity.
+func authenticateCertificate(manager auth.AuthManager, r *http.Request) (*auth.Principal, error) {
+	return auth.AuthenticateTLS(manager, r.TLS)
+}
+
+// bearerToken returns the token of the Authorization header of the request.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// tokenResponse returns the response with the tokens of a session.
+func tokenResponse(tokens *auth.Tokens) schemas.TokenResponse {
+	return schemas.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(tokens.ExpiresIn.Seconds()),
+	}
+}
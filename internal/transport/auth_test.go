@@ -0,0 +1,228 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthSuite struct {
+	db     db.DBClient
+	server *httptest.Server
+	suite.Suite
+}
+
+func (s *AuthSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	manager, err := auth.NewManager(slog.Default(), s.db, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithAuthManager{AuthManager: manager}).Handler())
+}
+
+func (s *AuthSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+}
+
+// do sends the request with the body and the access token, if any, and decodes the response into out.
+func (s *AuthSuite) do(method, path, token string, body any, out any) int {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return s.send(method, path, header, body, out)
+}
+
+// send sends the request with the body and the headers, and decodes the response into out.
+func (s *AuthSuite) send(method, path string, header http.Header, body any, out any) int {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		s.Require().NoError(err)
+	}
+	req, err := http.NewRequest(method, s.server.URL+path, bytes.NewReader(data))
+	s.Require().NoError(err)
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	if out != nil {
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp.StatusCode
+}
+
+// login registers a user and returns the tokens of a session.
+func (s *AuthSuite) login() schemas.TokenResponse {
+	register := schemas.RegisterRequest{AdminUsername: "admin", AdminPassword: "admin-password", Username: "alice", Password: "secret"}
+	s.Require().Equal(http.StatusCreated, s.do(http.MethodPost, "/api/v1/auth/register", "", register, nil))
+
+	var tokens schemas.TokenResponse
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/auth/login", "", schemas.LoginRequest{Username: "alice", Password: "secret"}, &tokens))
+	s.Equal("Bearer", tokens.TokenType)
+	s.Equal(900, tokens.ExpiresIn)
+	return tokens
+}
+
+func (s *AuthSuite) TestRegister() {
+	var errResponse apierrors.ApiError
+	register := schemas.RegisterRequest{AdminUsername: "admin", AdminPassword: "wrong", Username: "alice", Password: "secret"}
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodPost, "/api/v1/auth/register", "", register, &errResponse))
+	s.Equal(apierrors.ErrInvalidCredentials.Code, errResponse.Code)
+
+	register.AdminPassword = "admin-password"
+	register.Password = strings.Repeat("x", 73)
+	s.Equal(http.StatusBadRequest, s.do(http.MethodPost, "/api/v1/auth/register", "", register, &errResponse))
+
+	register.Password = "secret"
+	s.Equal(http.StatusCreated, s.do(http.MethodPost, "/api/v1/auth/register", "", register, nil))
+	s.Equal(http.StatusConflict, s.do(http.MethodPost, "/api/v1/auth/register", "", register, &errResponse))
+	s.Equal(apierrors.ErrUserAlreadyExists.Code, errResponse.Code)
+}
+
+func (s *AuthSuite) TestProtectedRoutes() {
+	var errResponse apierrors.ApiError
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodPost, "/api/v1/set", "", schemas.SetRowRequest{Key: "a", Value: db.StringOrSlice{Val: "1"}}, &errResponse))
+	s.Equal(apierrors.ErrUnauthorized.Code, errResponse.Code)
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodGet, "/api/v1/a", "invalid", nil, &errResponse))
+	s.Equal(apierrors.ErrInvalidToken.Code, errResponse.Code)
+
+	tokens := s.login()
+	s.Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/set", tokens.AccessToken, schemas.SetRowRequest{Key: "a", Value: db.StringOrSlice{Val: "1"}}, nil))
+	var row schemas.RowResponse
+	s.Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/a", tokens.AccessToken, nil, &row))
+	s.Equal("1", row.Value)
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodGet, "/api/v1/a", tokens.RefreshToken, nil, &errResponse), "a refresh token should not authenticate requests")
+
+	// the documentation is not protected
+	resp, err := http.Get(s.server.URL + "/api/v1/docs/swagger.yaml")
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (s *AuthSuite) TestRefreshAndLogout() {
+	tokens := s.login()
+
+	var refreshed schemas.TokenResponse
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/auth/refresh", "", schemas.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, &refreshed))
+	var errResponse apierrors.ApiError
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodGet, "/api/v1/a", tokens.AccessToken, nil, &errResponse), "the refresh should revoke the previous tokens")
+	s.Equal(http.StatusNotFound, s.do(http.MethodGet, "/api/v1/a", refreshed.AccessToken, nil, &errResponse))
+
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodPost, "/api/v1/auth/logout", "", nil, &errResponse))
+	s.Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/auth/logout", refreshed.AccessToken, nil, nil))
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodGet, "/api/v1/a", refreshed.AccessToken, nil, &errResponse))
+	s.Equal(http.StatusUnauthorized, s.do(http.MethodPost, "/api/v1/auth/refresh", "", schemas.RefreshTokenRequest{RefreshToken: refreshed.RefreshToken}, &errResponse))
+	s.Equal(apierrors.ErrInvalidToken.Code, errResponse.Code)
+}
+
+func (s *AuthSuite) TestAdminEndpoints() {
+	var errResponse apierrors.ApiError
+	tokens := s.login()
+	paths := []struct{ method, path string }{
+		{http.MethodGet, "/api/v1/replication/snapshot"},
+		{http.MethodGet, "/api/v1/replication/stream"},
+		{http.MethodPost, "/api/v1/admin/promote"},
+		{http.MethodGet, "/api/v1/admin/backup"},
+		{http.MethodPost, "/api/v1/admin/restore"},
+	}
+	// the snapshots and the backups include the users and the API keys, so only the administrator can access them
+	for _, p := range paths {
+		s.Equal(http.StatusUnauthorized, s.send(p.method, p.path, http.Header{}, nil, &errResponse), p.path)
+		s.Equal(apierrors.ErrInvalidCredentials.Code, errResponse.Code)
+		s.Equal(http.StatusUnauthorized, s.do(p.method, p.path, tokens.AccessToken, nil, &errResponse), "a user should not access %s", p.path)
+		s.Equal(http.StatusUnauthorized, s.send(p.method, p.path, admin("wrong"), nil, &errResponse), p.path)
+	}
+
+	s.Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/replication/snapshot", admin("admin-password"), nil, nil))
+	s.Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/admin/backup", admin("admin-password"), nil, nil))
+	s.Equal(http.StatusConflict, s.send(http.MethodPost, "/api/v1/admin/promote", admin("admin-password"), nil, &errResponse))
+	s.Equal(apierrors.ErrNotReplica.Code, errResponse.Code)
+}
+
+func (s *AuthSuite) TestSlotMigration() {
+	// the URLs must be known to create the topology, so the servers are started before their handlers
+	handlers := make([]http.Handler, 2)
+	var nodes []slots.Node
+	for i := range handlers {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		defer server.Close()
+		nodes = append(nodes, slots.Node{ID: fmt.Sprintf("node%d", i), URL: server.URL})
+	}
+	var databases []db.DBClient
+	for i, node := range nodes {
+		database := db.NewMemoryDB(slog.Default())
+		defer database.Close()
+		databases = append(databases, database)
+		manager, err := auth.NewManager(slog.Default(), database, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+		s.Require().NoError(err)
+		router, err := slots.NewRouter(slog.Default(), database, node.ID, slots.NewTopology(nodes), slots.WithBasicAuth{Username: "admin", Password: "admin-password"})
+		s.Require().NoError(err)
+		handlers[i] = transport.NewServer(slog.Default(), 0, 0, database, transport.WithSlotRouter{Router: router}, transport.WithAuthManager{AuthManager: manager}).Handler()
+	}
+	s.Require().NoError(databases[0].Set("bar", "1")) // "bar" is in the slot 5061
+
+	migrate := func(header http.Header) int {
+		req, err := http.NewRequest(http.MethodPost, nodes[0].URL+slots.MigratePath, strings.NewReader(`{"start": 0, "end": 8191, "target": "node1"}`))
+		s.Require().NoError(err)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for _, p := range []struct{ method, path string }{{http.MethodGet, slots.TopologyPath}, {http.MethodPut, slots.TopologyPath}, {http.MethodPost, slots.ImportPath}, {http.MethodPost, slots.RestorePath}} {
+		req, err := http.NewRequest(p.method, nodes[1].URL+p.path, strings.NewReader(`{}`))
+		s.Require().NoError(err)
+		resp, err := http.DefaultClient.Do(req)
+		s.Require().NoError(err)
+		resp.Body.Close()
+		s.Equal(http.StatusUnauthorized, resp.StatusCode, p.path)
+	}
+	s.Equal(http.StatusUnauthorized, migrate(http.Header{}))
+
+	// the node sends the credentials of the administrator to the target of the migration
+	s.Equal(http.StatusOK, migrate(admin("admin-password")))
+	item, err := databases[1].Get("bar")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value.Val)
+}
+
+func (s *AuthSuite) TestDisabled() {
+	// without a manager, the data routes are open and the authentication endpoints are not mounted
+	server := httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db).Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/set", "application/json", strings.NewReader(`{"key": "a", "value": "1"}`))
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/api/v1/auth/login", "application/json", strings.NewReader(`{"username": "alice", "password": "secret"}`))
+	s.Require().NoError(err)
+	resp.Body.Close()
+	s.NotEqual(http.StatusOK, resp.StatusCode)
+}
+
+func TestAuthSuite(t *testing.T) {
+	suite.Run(t, new(AuthSuite))
+}
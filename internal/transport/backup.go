@@ -0,0 +1,58 @@
+package transport
+
+import (
+	"fmt"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"time"
+)
+
+// HandleBackup streams a compressed point-in-time backup of the database. Writers are only blocked while
+// the items are copied, not while the backup is sent.
+func (h *Handler) HandleBackup(w http.ResponseWriter, r *http.Request) {
+	filename := fmt.Sprintf("memorydb-%s.ndjson.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// the status has been sent once the backup starts, so errors can only be logged
+	header, err := h.db.Backup(w)
+	if err != nil {
+		h.logger.Error("failed to write backup", "error", err)
+		return
+	}
+	h.logger.Info("backup sent", "keys", header.Keys, "offset", header.Offset)
+}
+
+// HandleRestore restores a backup sent in the body of the request. The `mode` query parameter tells whether
+// the backup replaces the whole database (`replace`) or only the keys it contains (`merge`, the default).
+func (h *Handler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	mode := enums.RestoreModeMerge
+	if raw := r.URL.Query().Get("mode"); raw != "" {
+		mode = enums.RestoreMode(raw)
+	}
+	if !mode.IsValid() {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = fmt.Sprintf("invalid restore mode '%s', expected 'replace' or 'merge'", mode)
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+
+	keys, err := h.db.RestoreBackup(r.Body, mode)
+	if err != nil {
+		h.logger.Error("failed to restore backup", "mode", mode, "error", err)
+		if _, ok := err.(*db.DBerror); ok {
+			wrapError(w, h.wrapDBError(err))
+			return
+		}
+		e := *apierrors.ErrInvalidRequest
+		e.Message = err.Error()
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.RestoreResponse{Keys: keys, Mode: mode.String()})
+}
@@ -0,0 +1,119 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BackupHandlerSuite struct {
+	source db.DBClient
+	target db.DBClient
+	server *httptest.Server
+	suite.Suite
+}
+
+func (s *BackupHandlerSuite) SetupTest() {
+	s.source = db.NewMemoryDB(slog.Default())
+	s.target = db.NewMemoryDB(slog.Default())
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.target).Handler())
+}
+
+func (s *BackupHandlerSuite) TearDownTest() {
+	s.server.Close()
+	s.source.Close()
+	s.target.Close()
+}
+
+// restore sends the backup to the restore endpoint with the mode.
+func (s *BackupHandlerSuite) restore(data []byte, mode string) *http.Response {
+	resp, err := http.Post(s.server.URL+"/api/v1/admin/restore?mode="+mode, "application/gzip", bytes.NewReader(data))
+	s.Require().NoError(err)
+	return resp
+}
+
+func (s *BackupHandlerSuite) TestBackup() {
+	s.Require().NoError(s.target.Set("key1", "value"))
+	s.Require().NoError(s.target.Set("key2", []string{"a", "b"}))
+
+	resp, err := http.Get(s.server.URL + "/api/v1/admin/backup")
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("application/gzip", resp.Header.Get("Content-Type"))
+	s.Contains(resp.Header.Get("Content-Disposition"), "attachment; filename=\"memorydb-")
+
+	// the body is a valid backup of the keys
+	header, records, err := db.ReadBackup(resp.Body)
+	s.Require().NoError(err)
+	s.Equal(2, header.Keys)
+	s.Equal("key1", records[0].Key)
+	s.Equal("key2", records[1].Key)
+}
+
+func (s *BackupHandlerSuite) TestRestore() {
+	s.Require().NoError(s.source.Set("key1", "backup"))
+	var buf bytes.Buffer
+	_, err := s.source.Backup(&buf)
+	s.Require().NoError(err)
+	s.Require().NoError(s.target.Set("key2", "live"))
+
+	s.Run("Merge", func() {
+		resp := s.restore(buf.Bytes(), "")
+		defer resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var restored schemas.RestoreResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&restored))
+		s.Equal(schemas.RestoreResponse{Keys: 1, Mode: "merge"}, restored)
+		s.ElementsMatch([]string{"key1", "key2"}, s.target.Keys("*"))
+	})
+
+	s.Run("Replace", func() {
+		resp := s.restore(buf.Bytes(), "replace")
+		defer resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal([]string{"key1"}, s.target.Keys("*"))
+	})
+
+	s.Run("Invalid mode", func() {
+		resp := s.restore(buf.Bytes(), "append")
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+	})
+
+	s.Run("Invalid backup", func() {
+		resp := s.restore([]byte("not a backup"), "replace")
+		defer resp.Body.Close()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+
+		var errResponse apierrors.ApiError
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+		s.Equal(apierrors.ErrInvalidRequest.Code, errResponse.Code)
+		s.Equal([]string{"key1"}, s.target.Keys("*"), "an invalid backup should leave the database untouched")
+	})
+
+	s.Run("Read only", func() {
+		s.target.SetReadOnly(true)
+		defer s.target.SetReadOnly(false)
+
+		resp := s.restore(buf.Bytes(), "merge")
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		s.Equal(http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestBackupHandlerSuite(t *testing.T) {
+	suite.Run(t, new(BackupHandlerSuite))
+}
@@ -0,0 +1,269 @@
+package transport
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"slices"
+)
+
+const (
+	// maxBatchKeys is the maximum number of keys of a batch request.
+	maxBatchKeys = 1000
+)
+
+type BatchHandler struct {
+	logger  *slog.Logger
+	db      db.DBClient
+	router  *slots.Router // router of the hash slots, nil if the server is not part of a sharded topology
+	handler *Handler      // handler of the keys, used to convert the errors of the database
+}
+
+// NewBatchHandler creates a new handler for the batch endpoints. The router can be nil if the server is not part
+// of a sharded topology.
+func NewBatchHandler(logger *slog.Logger, db db.DBClient, router *slots.Router) *BatchHandler {
+	return &BatchHandler{logger: logger, db: db, router: router, handler: NewHandler(logger, db)}
+}
+
+// HandleMGet retrieves the items of several keys. Every key has its own result, so a missing key does not fail
+// the request. In a sharded topology, the keys of other nodes fail with their redirect, and the keys the API key
+// of the request cannot read fail as forbidden.
+func (h *BatchHandler) HandleMGet(w http.ResponseWriter, r *http.Request) {
+	var body schemas.MGetRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if e := checkBatchSize(len(body.Keys)); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	asking := r.Header.Get(slots.AskingHeader) != ""
+	database := keyspace(r, h.db)
+	response := schemas.BatchResponse{Results: make(map[string]schemas.KeyResult, len(body.Keys))}
+	for _, key := range body.Keys {
+		if e := authorize(r, enums.PermissionRead, key); e != nil {
+			response.Results[key] = schemas.KeyResult{Error: e}
+			continue
+		}
+		response.Results[key] = h.get(database, key, asking)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleMSet stores several items with the same TTL.
+//
+// By default, every key has its own result, as in HandleMGet. If the request is atomic, every item is stored or none
+// of them, and the request fails as a whole. In a sharded topology, the keys of an atomic request must share their slot.
+func (h *BatchHandler) HandleMSet(w http.ResponseWriter, r *http.Request) {
+	var body schemas.MSetRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if e := checkBatchSize(len(body.Items)); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	var opts []db.ItemOptions
+	if body.TTL != nil {
+		opts = append(opts, db.WithTTL(body.TTL.Duration))
+	}
+	items := make(map[string]any, len(body.Items))
+	for key, value := range body.Items {
+		items[key] = value.Val
+	}
+
+	asking := r.Header.Get(slots.AskingHeader) != ""
+	if body.Atomic {
+		h.setAtomic(w, r, items, opts, asking)
+		return
+	}
+
+	database := keyspace(r, h.db)
+	response := schemas.BatchResponse{Results: make(map[string]schemas.KeyResult, len(items))}
+	for key, value := range items {
+		if e := authorize(r, enums.PermissionWrite, key); e != nil {
+			response.Results[key] = schemas.KeyResult{Error: e}
+			continue
+		}
+		response.Results[key] = h.set(database, key, value, opts, asking)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleMDel removes several keys. Every key has its own result, as in HandleMGet.
+func (h *BatchHandler) HandleMDel(w http.ResponseWriter, r *http.Request) {
+	var body schemas.MDelRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if e := checkBatchSize(len(body.Keys)); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	asking := r.Header.Get(slots.AskingHeader) != ""
+	database := keyspace(r, h.db)
+	response := schemas.BatchResponse{Results: make(map[string]schemas.KeyResult, len(body.Keys))}
+	for _, key := range body.Keys {
+		if e := authorize(r, enums.PermissionWrite, key); e != nil {
+			response.Results[key] = schemas.KeyResult{Error: e}
+			continue
+		}
+		response.Results[key] = h.remove(database, key, asking)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// get returns the result of the item of the key in the database.
+func (h *BatchHandler) get(database db.DBClient, key string, asking bool) schemas.KeyResult {
+	release, e := h.route(key, asking)
+	if e != nil {
+		return schemas.KeyResult{Error: e}
+	}
+	defer release()
+
+	item, err := database.Get(key)
+	if err != nil {
+		return schemas.KeyResult{Error: h.keyError(err)}
+	}
+	return itemResult(key, item)
+}
+
+// set stores the value at the key of the database and returns its result.
+func (h *BatchHandler) set(database db.DBClient, key string, value any, opts []db.ItemOptions, asking bool) schemas.KeyResult {
+	release, e := h.route(key, asking)
+	if e != nil {
+		return schemas.KeyResult{Error: e}
+	}
+	defer release()
+
+	if err := database.Set(key, value, opts...); err != nil {
+		return schemas.KeyResult{Error: h.keyError(err)}
+	}
+	return schemas.KeyResult{}
+}
+
+// setAtomic stores every item or none of them, and answers the request as a whole.
+func (h *BatchHandler) setAtomic(w http.ResponseWriter, r *http.Request, items map[string]any, opts []db.ItemOptions, asking bool) {
+	keys := slices.Sorted(maps.Keys(items))
+	if keys[0] == "" {
+		wrapError(w, invalidRequest("key is required"))
+		return
+	}
+	if e := authorize(r, enums.PermissionWrite, keys...); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	if h.router != nil {
+		slot := slots.KeySlot(keys[0])
+		for _, key := range keys[1:] {
+			if slots.KeySlot(key) != slot {
+				e := *apierrors.ErrCrossShard
+				e.Message = "the keys of an atomic request must share their hash slot, which can be forced with a hash tag such as {user}"
+				wrapError(w, &e)
+				return
+			}
+		}
+		redirect, release := h.router.Route(keys[0], asking)
+		if redirect != nil {
+			writeRedirect(w, r, redirect)
+			return
+		}
+		defer release()
+	}
+
+	if err := keyspace(r, h.db).SetMany(items, opts...); err != nil {
+		wrapError(w, h.keyError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// remove removes the key from the database and returns its result.
+func (h *BatchHandler) remove(database db.DBClient, key string, asking bool) schemas.KeyResult {
+	release, e := h.route(key, asking)
+	if e != nil {
+		return schemas.KeyResult{Error: e}
+	}
+	defer release()
+
+	if err := database.Remove(key); err != nil {
+		return schemas.KeyResult{Error: h.writeError(database, key, err)}
+	}
+	return schemas.KeyResult{}
+}
+
+// route checks that the node serves the slot of the key, and returns a function that must be called once the key
+// is served. The keys of other nodes fail with the redirect to the node that serves them.
+func (h *BatchHandler) route(key string, asking bool) (func(), *apierrors.ApiError) {
+	if key == "" {
+		return nil, invalidRequest("key is required")
+	}
+	if h.router == nil {
+		return func() {}, nil
+	}
+
+	redirect, release := h.router.Route(key, asking)
+	if redirect == nil {
+		return release, nil
+	}
+	e := *apierrors.ErrMoved
+	if redirect.Kind == slots.RedirectAsk {
+		e = *apierrors.ErrAsk
+	}
+	e.Message = redirect.String()
+	return nil, &e
+}
+
+// keyError converts an error of the database into the API error of a key. The error is copied, since the errors
+// of the keys of a batch are sent together.
+func (h *BatchHandler) keyError(err error) *apierrors.ApiError {
+	e := *h.handler.wrapDBError(err)
+	return &e
+}
+
+// itemResult returns the result of an operation that returned the item of the key.
+func itemResult(key string, item *db.Item) schemas.KeyResult {
+	return schemas.KeyResult{Item: &schemas.RowResponse{
+		Key:       key,
+		Value:     item.Value,
+		Kind:      db.MappingDataType[item.Kind],
+		TTL:       item.TTL,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+	}}
+}
+
+// writeError converts the error of a write to an existing key of the database into the API error of the key. Writing
+// to a missing key is not a database error, so the key is read to report why the write failed.
+func (h *BatchHandler) writeError(database db.DBClient, key string, err error) *apierrors.ApiError {
+	if _, ok := err.(*db.DBerror); !ok {
+		if _, getErr := database.Get(key); getErr != nil {
+			return h.keyError(getErr)
+		}
+	}
+	return h.keyError(err)
+}
+
+// checkBatchSize returns an error if a batch request has no keys or more than maxBatchKeys.
+func checkBatchSize(keys int) *apierrors.ApiError {
+	if keys == 0 {
+		return invalidRequest("the request must have at least one key")
+	}
+	if keys > maxBatchKeys {
+		return invalidRequest(fmt.Sprintf("the request cannot have more than %d keys", maxBatchKeys))
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BatchSuite struct {
+	db      db.DBClient
+	handler *transport.BatchHandler
+	suite.Suite
+}
+
+func (s *BatchSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.handler = transport.NewBatchHandler(slog.Default(), s.db, nil)
+}
+
+func (s *BatchSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// do sends the body to the handler and decodes the response into out.
+func (s *BatchSuite) do(handle http.HandlerFunc, body string, out any) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handle(w, req)
+
+	resp := w.Result()
+	defer resp.Body.Close()
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(out))
+	return resp.StatusCode
+}
+
+func (s *BatchSuite) TestMGet() {
+	s.Require().NoError(s.db.Set("a", "1"))
+	s.Require().NoError(s.db.Set("b", []string{"x", "y"}))
+
+	var response schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMGet, `{"keys": ["a", "b", "missing"]}`, &response))
+	s.Len(response.Results, 3)
+	s.Require().NotNil(response.Results["a"].Item)
+	s.Equal("1", response.Results["a"].Item.Value)
+	s.Require().NotNil(response.Results["b"].Item)
+	s.Equal("string_slice", response.Results["b"].Item.Kind)
+	s.Nil(response.Results["missing"].Item)
+	s.Require().NotNil(response.Results["missing"].Error, "a missing key should not fail the batch")
+	s.Equal(apierrors.ErrItemNotFound.Code, response.Results["missing"].Error.Code)
+
+	var errResponse apierrors.ApiError
+	s.Equal(http.StatusBadRequest, s.do(s.handler.HandleMGet, `{"keys": []}`, &errResponse))
+	keys := make([]string, 1001)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key:%d", i)
+	}
+	body, err := json.Marshal(schemas.MGetRequest{Keys: keys})
+	s.Require().NoError(err)
+	s.Equal(http.StatusBadRequest, s.do(s.handler.HandleMGet, string(body), &errResponse))
+	s.Equal(apierrors.ErrInvalidRequest.Code, errResponse.Code)
+}
+
+func (s *BatchSuite) TestMSet() {
+	var response schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMSet, `{"items": {"a": "1", "b": ["2"]}, "ttl": "1m"}`, &response))
+	s.Equal(map[string]schemas.KeyResult{"a": {}, "b": {}}, response.Results)
+
+	item, err := s.db.Get("b")
+	s.Require().NoError(err)
+	s.Equal([]string{"2"}, item.Value.Val)
+	s.WithinDuration(time.Now().Add(time.Minute), item.TTL, time.Second)
+
+	s.db.SetReadOnly(true)
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMSet, `{"items": {"a": "2"}}`, &response))
+	s.Require().NotNil(response.Results["a"].Error)
+	s.Equal(apierrors.ErrReadOnly.Code, response.Results["a"].Error.Code)
+}
+
+func (s *BatchSuite) TestMSetAtomic() {
+	var ok schemas.OKResponse
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMSet, `{"items": {"a": "1", "b": "2"}, "atomic": true}`, &ok))
+	s.Equal("ok", ok.Message)
+
+	var errResponse apierrors.ApiError
+	s.Equal(http.StatusBadRequest, s.do(s.handler.HandleMSet, `{"items": {"a": "changed", "c": 3}, "atomic": true}`, &errResponse))
+	item, err := s.db.Get("a")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value.Val, "no item should be stored if one of them is invalid")
+	_, err = s.db.Get("c")
+	s.Error(err)
+
+	// in a sharded topology, the keys must share their slot
+	nodes := []slots.Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := slots.NewRouter(slog.Default(), s.db, "a", slots.NewTopology(nodes))
+	s.Require().NoError(err)
+	s.handler = transport.NewBatchHandler(slog.Default(), s.db, router)
+
+	s.Equal(http.StatusBadRequest, s.do(s.handler.HandleMSet, `{"items": {"a": "1", "b": "2"}, "atomic": true}`, &errResponse))
+	s.Equal(apierrors.ErrCrossShard.Code, errResponse.Code)
+	s.Equal(http.StatusOK, s.do(s.handler.HandleMSet, `{"items": {"{user}:a": "1", "{user}:b": "2"}, "atomic": true}`, &ok))
+}
+
+func (s *BatchSuite) TestMDel() {
+	s.Require().NoError(s.db.Set("a", "1"))
+
+	var response schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMDel, `{"keys": ["a", "missing"]}`, &response))
+	s.Nil(response.Results["a"].Error)
+	s.Require().NotNil(response.Results["missing"].Error)
+	s.Equal(apierrors.ErrItemNotFound.Code, response.Results["missing"].Error.Code)
+
+	_, err := s.db.Get("a")
+	s.Error(err)
+}
+
+func (s *BatchSuite) TestSlots() {
+	// this node serves the slots 0-8191, and "foo" is in the slot 12182
+	nodes := []slots.Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := slots.NewRouter(slog.Default(), s.db, "a", slots.NewTopology(nodes))
+	s.Require().NoError(err)
+	s.handler = transport.NewBatchHandler(slog.Default(), s.db, router)
+	s.Require().NoError(s.db.Set("bar", "1"))
+
+	var response schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.do(s.handler.HandleMGet, `{"keys": ["foo", "bar"]}`, &response))
+	s.Require().NotNil(response.Results["foo"].Error, "the keys of other nodes should fail with their redirect")
+	s.Equal(apierrors.ErrMoved.Code, response.Results["foo"].Error.Code)
+	s.Equal("MOVED 12182 http://b:8080", response.Results["foo"].Error.Message)
+	s.Require().NotNil(response.Results["bar"].Item)
+	s.Equal("1", response.Results["bar"].Item.Value)
+}
+
+func (s *BatchSuite) TestSetSlotRedirect() {
+	// this node serves the slots 0-8191, and "foo" is in the slot 12182
+	nodes := []slots.Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := slots.NewRouter(slog.Default(), s.db, "a", slots.NewTopology(nodes))
+	s.Require().NoError(err)
+	server := httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithSlotRouter{Router: router}).Handler())
+	defer server.Close()
+
+	set := func(body string) (int, apierrors.ApiError) {
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Post(server.URL+"/api/v1/set", "application/json", strings.NewReader(body))
+		s.Require().NoError(err)
+		defer resp.Body.Close()
+		var errResponse apierrors.ApiError
+		_ = json.NewDecoder(resp.Body).Decode(&errResponse)
+		return resp.StatusCode, errResponse
+	}
+
+	// the fields are matched without case, as the handler does, so the key routed is the key written
+	status, errResponse := set(`{"Key": "foo", "value": "1"}`)
+	s.Equal(http.StatusTemporaryRedirect, status)
+	s.Equal(apierrors.ErrMoved.Code, errResponse.Code)
+	_, err = s.db.Get("foo")
+	s.Error(err, "the key should not be written in a node that does not serve its slot")
+
+	status, _ = set(`{"KEY": "bar", "value": "1"}`)
+	s.Equal(http.StatusOK, status)
+	status, errResponse = set(`{"value": "1"}`)
+	s.Equal(http.StatusBadRequest, status)
+	s.Equal(apierrors.ErrInvalidRequest.Code, errResponse.Code)
+}
+
+func TestBatchSuite(t *testing.T) {
+	suite.Run(t, new(BatchSuite))
+}
@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/cluster"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"sort"
+)
+
+type ClusterHandler struct {
+	logger *slog.Logger
+	node   *cluster.Node
+}
+
+// NewClusterHandler creates a new handler for the cluster endpoints.
+func NewClusterHandler(logger *slog.Logger, node *cluster.Node) *ClusterHandler {
+	return &ClusterHandler{logger: logger, node: node}
+}
+
+// HandleApply commits a command forwarded by a follower and returns its result.
+//
+// The errors of the database are part of the result, since the command is committed even if it fails.
+// If the node is no longer the leader, the follower gets a 503 and the write fails, since it cannot know
+// whether the command was committed.
+func (h *ClusterHandler) HandleApply(w http.ResponseWriter, r *http.Request) {
+	var cmd cluster.Command
+	if err := decodeJSON(r.Body, &cmd); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	result, err := h.node.Apply(cmd)
+	if err != nil {
+		if errors.Is(err, cluster.ErrNotLeader) {
+			e := *apierrors.ErrClusterUnavailable
+			e.Message = err.Error()
+			e.SysMessage = err.Error()
+			wrapError(w, &e)
+			return
+		}
+		h.logger.Error("failed to apply forwarded command", "op", cmd.Op, "key", cmd.Key, "error", err)
+		wrapError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// HandleStatus returns the Raft state of the node.
+func (h *ClusterHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	status := h.node.Status()
+	response := schemas.ClusterStatusResponse{
+		ID:           status.ID,
+		State:        status.State,
+		LeaderID:     status.LeaderID,
+		Term:         status.Term,
+		CommitIndex:  status.CommitIndex,
+		AppliedIndex: status.AppliedIndex,
+		LastIndex:    status.LastIndex,
+		Peers:        make([]schemas.ClusterPeer, 0, len(status.Peers)),
+	}
+	for _, peer := range status.Peers {
+		response.Peers = append(response.Peers, schemas.ClusterPeer{ID: peer.ID, RaftAddr: peer.RaftAddr, HTTPURL: peer.HTTPURL})
+	}
+	sort.Slice(response.Peers, func(i, j int) bool { return response.Peers[i].ID < response.Peers[j].ID })
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,112 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/cluster"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	"github.com/stretchr/testify/suite"
+)
+
+type ClusterHandlerSuite struct {
+	node    *cluster.Node
+	handler *transport.ClusterHandler
+	suite.Suite
+}
+
+// SetupTest starts a single node cluster, which elects itself as leader.
+func (s *ClusterHandlerSuite) SetupTest() {
+	addr, trans := raft.NewInmemTransport("")
+	peers := []cluster.Peer{{ID: "node1", RaftAddr: string(addr), HTTPURL: "http://localhost:8080"}}
+
+	node, err := cluster.NewNode(slog.Default(), "node1", peers, cluster.WithTransport{Transport: trans}, cluster.WithHeartbeatTimeout(50*time.Millisecond))
+	s.Require().NoError(err)
+	s.node = node
+	s.handler = transport.NewClusterHandler(slog.Default(), node)
+	s.Require().Eventually(node.IsLeader, 5*time.Second, 10*time.Millisecond)
+}
+
+func (s *ClusterHandlerSuite) TearDownTest() {
+	if s.node != nil {
+		s.node.Close()
+	}
+}
+
+func (s *ClusterHandlerSuite) TestApply() {
+	s.Run("Set", func() {
+		req := httptest.NewRequest(http.MethodPost, cluster.ApplyPath, bytes.NewBufferString(`{"op": "set", "key": "key", "value": "value"}`))
+		w := httptest.NewRecorder()
+		s.handler.HandleApply(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var result cluster.Result
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+		s.Nil(result.Error)
+
+		item, err := s.node.Get("key")
+		s.Require().NoError(err)
+		s.Equal("value", item.Value.Val)
+	})
+
+	s.Run("Database error", func() {
+		req := httptest.NewRequest(http.MethodPost, cluster.ApplyPath, bytes.NewBufferString(`{"op": "xadd", "key": "key", "fields": {"a": "b"}}`))
+		w := httptest.NewRecorder()
+		s.handler.HandleApply(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode, "the command is committed even if the database rejects it")
+
+		var result cluster.Result
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&result))
+		s.Require().NotNil(result.Error)
+		s.Equal("not_a_stream", result.Error.Sentinel)
+	})
+
+	s.Run("Not leader", func() {
+		// a node that has shut down is no longer the leader
+		s.node.Close()
+		s.node = nil
+
+		req := httptest.NewRequest(http.MethodPost, cluster.ApplyPath, bytes.NewBufferString(`{"op": "remove", "key": "key"}`))
+		w := httptest.NewRecorder()
+		s.handler.HandleApply(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+
+		var errResponse apierrors.ApiError
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+		s.Equal(apierrors.ErrClusterUnavailable.Code, errResponse.Code)
+	})
+}
+
+func (s *ClusterHandlerSuite) TestStatus() {
+	req := httptest.NewRequest(http.MethodGet, "/cluster", nil)
+	w := httptest.NewRecorder()
+	s.handler.HandleStatus(w, req)
+
+	resp := w.Result()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	var status schemas.ClusterStatusResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&status))
+	s.Equal("node1", status.ID)
+	s.Equal(raft.Leader.String(), status.State)
+	s.Equal("node1", status.LeaderID)
+	s.Len(status.Peers, 1)
+}
+
+func TestClusterHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ClusterHandlerSuite))
+}
@@ -0,0 +1,157 @@
+package transport
+
+import (
+	"fmt"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// upgrader upgrades HTTP connections to WebSocket connections.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// HandleEvents streams the keyspace events of the keys that match the glob pattern in the `match` query parameter.
+//
+// Events are sent as Server-Sent Events unless the client requests a WebSocket upgrade. A client can resume
+// a stream by sending the last event ID it received in the `Last-Event-ID` header or the `last_event_id` query parameter.
+func (h *Handler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	match := r.URL.Query().Get("match")
+	if e := authorizePattern(r, enums.PermissionRead, match); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	events, cancel := keyspace(r, h.db).Subscribe(match, lastEventID)
+	defer cancel()
+
+	if websocket.IsWebSocketUpgrade(r) {
+		h.streamEventsWebSocket(w, r, events)
+		return
+	}
+	h.streamEventsSSE(w, r, events)
+}
+
+// streamEventsSSE writes the events to the response as Server-Sent Events until the client disconnects
+// or the subscription is closed.
+func (h *Handler) streamEventsSSE(w http.ResponseWriter, r *http.Request, events <-chan db.Event) {
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			id := strconv.FormatUint(event.ID, 10)
+			if err := writeSSE(w, flusher, id, event.Type.String(), toEventResponse(event)); err != nil {
+				h.logger.Debug("failed to write keyspace event", "error", err)
+				return
+			}
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamEventsWebSocket upgrades the connection and writes every event as a JSON text message until
+// the client disconnects or the subscription is closed.
+func (h *Handler) streamEventsWebSocket(w http.ResponseWriter, r *http.Request, events <-chan db.Event) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already written an error response to the client
+		h.logger.Error("failed to upgrade connection to websocket", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// the client is not expected to send messages, but the connection must be read to process control frames
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-disconnected:
+			return
+		case event, ok := <-events:
+			if !ok {
+				message := websocket.FormatCloseMessage(websocket.CloseGoingAway, "subscription closed")
+				_ = conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(streamWriteTimeout))
+				return
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := conn.WriteJSON(toEventResponse(event)); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseLastEventID returns the ID of the last event received by the client, or 0 if the client is not resuming a stream.
+func parseLastEventID(r *http.Request) (uint64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = fmt.Sprintf("invalid last event ID '%s'", raw)
+		e.SysMessage = fmt.Sprintf("failed to parse last event ID: %v", err)
+		return 0, &e
+	}
+	return id, nil
+}
+
+// toEventResponse converts a keyspace event into its API representation.
+func toEventResponse(event db.Event) schemas.EventResponse {
+	return schemas.EventResponse{
+		ID:   event.ID,
+		Type: event.Type.String(),
+		Key:  event.Key,
+		Time: event.Time,
+	}
+}
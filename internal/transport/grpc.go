@@ -0,0 +1,408 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"memorydb/pkg/godb/memdbpb"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// grpcRedirectKey is the metadata key of the redirects of the gRPC API, the same as the header of the HTTP API.
+	grpcRedirectKey = strings.ToLower(slots.RedirectHeader)
+	// grpcAskingKey is the metadata key that marks a call sent after an ASK redirect.
+	grpcAskingKey = strings.ToLower(slots.AskingHeader)
+	// grpcAPIKeyKey is the metadata key of the API key of the calls, the same as the header of the HTTP API.
+	grpcAPIKeyKey = strings.ToLower(apiKeyHeader)
+)
+
+// GRPCHandler serves the gRPC API of the database. The errors of the database are converted with the same
+// mapping as the HTTP API.
+type GRPCHandler struct {
+	memdbpb.UnimplementedMemoryDBServer
+
+	logger  *slog.Logger
+	db      db.DBClient
+	router  *slots.Router    // router of the hash slots, nil if the server is not part of a sharded topology
+	manager auth.AuthManager // authentication manager of the calls, nil if they are not authenticated
+	handler *Handler         // handler of the HTTP API, used to convert the errors of the database
+}
+
+// NewGRPCHandler creates a new handler of the gRPC API. The router can be nil if the server is not part of a sharded
+// topology, and the manager if the calls are not authenticated.
+func NewGRPCHandler(logger *slog.Logger, db db.DBClient, router *slots.Router, manager auth.AuthManager) *GRPCHandler {
+	return &GRPCHandler{logger: logger, db: db, router: router, manager: manager, handler: NewHandler(logger, db)}
+}
+
+// NewGRPCServer creates a gRPC server with the API of the database registered. If the manager is not nil, the
+// calls are authenticated by interceptors as the requests of the HTTP API.
+func NewGRPCServer(logger *slog.Logger, db db.DBClient, router *slots.Router, manager auth.AuthManager, opts ...grpc.ServerOption) *grpc.Server {
+	h := NewGRPCHandler(logger, db, router, manager)
+	if manager != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(h.authenticateUnary), grpc.ChainStreamInterceptor(h.authenticateStream))
+	}
+	srv := grpc.NewServer(opts...)
+	memdbpb.RegisterMemoryDBServer(srv, h)
+	return srv
+}
+
+// Get returns the item stored at the key.
+func (h *GRPCHandler) Get(ctx context.Context, req *memdbpb.GetRequest) (*memdbpb.Item, error) {
+	release, err := h.route(ctx, enums.PermissionRead, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	item, err := h.db.Get(req.GetKey())
+	if err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return toItemProto(req.GetKey(), item), nil
+}
+
+// Set stores the value at the key.
+func (h *GRPCHandler) Set(ctx context.Context, req *memdbpb.SetRequest) (*emptypb.Empty, error) {
+	value, ok := fromValueProto(req.GetValue())
+	if !ok {
+		return nil, h.grpcError(invalidRequest("value is required"))
+	}
+	release, err := h.route(ctx, enums.PermissionWrite, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var opts []db.ItemOptions
+	if req.Ttl != nil {
+		opts = append(opts, db.WithTTL(req.Ttl.AsDuration()))
+	}
+	if err := h.db.Set(req.GetKey(), value, opts...); err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Update replaces the value of an existing key.
+func (h *GRPCHandler) Update(ctx context.Context, req *memdbpb.UpdateRequest) (*emptypb.Empty, error) {
+	value, ok := fromValueProto(req.GetValue())
+	if !ok {
+		return nil, h.grpcError(invalidRequest("value is required"))
+	}
+	release, err := h.route(ctx, enums.PermissionWrite, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var opts []db.ItemOptions
+	if req.Ttl != nil {
+		opts = append(opts, db.WithTTL(req.Ttl.AsDuration()))
+	}
+	if err := h.db.Update(req.GetKey(), value, opts...); err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Remove deletes the key.
+func (h *GRPCHandler) Remove(ctx context.Context, req *memdbpb.RemoveRequest) (*emptypb.Empty, error) {
+	release, err := h.route(ctx, enums.PermissionWrite, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := h.db.Remove(req.GetKey()); err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// Push appends a value to the list stored at the key.
+func (h *GRPCHandler) Push(ctx context.Context, req *memdbpb.PushRequest) (*memdbpb.Item, error) {
+	if req.GetValue() == "" {
+		return nil, h.grpcError(invalidRequest("value is required"))
+	}
+	release, err := h.route(ctx, enums.PermissionWrite, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var opts []db.ItemOptions
+	if req.Ttl != nil {
+		opts = append(opts, db.WithTTL(req.Ttl.AsDuration()))
+	}
+	item, err := h.db.Push(req.GetKey(), req.GetValue(), opts...)
+	if err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return toItemProto(req.GetKey(), item), nil
+}
+
+// Pop removes the last value of the list stored at the key.
+func (h *GRPCHandler) Pop(ctx context.Context, req *memdbpb.PopRequest) (*memdbpb.Item, error) {
+	release, err := h.route(ctx, enums.PermissionWrite, req.GetKey())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	item, err := h.db.Pop(req.GetKey())
+	if err != nil {
+		return nil, h.grpcError(h.handler.wrapDBError(err))
+	}
+	return toItemProto(req.GetKey(), item), nil
+}
+
+// Watch streams the keyspace events of the keys that match the glob pattern until the client cancels the call
+// or the subscription is closed.
+func (h *GRPCHandler) Watch(req *memdbpb.WatchRequest, stream grpc.ServerStreamingServer[memdbpb.Event]) error {
+	if principal, ok := PrincipalFromContext(stream.Context()); ok && !principal.AllowsPattern(enums.PermissionRead, req.GetMatch()) {
+		pattern := req.GetMatch()
+		if pattern == "" {
+			pattern = "*"
+		}
+		return h.grpcError(forbidden(principal, enums.PermissionRead, pattern))
+	}
+
+	events, cancel := h.db.Subscribe(req.GetMatch(), req.GetLastEventId())
+	defer cancel()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "subscription closed")
+			}
+			if err := stream.Send(toEventProto(event)); err != nil {
+				h.logger.Debug("failed to send keyspace event", "error", err)
+				return err
+			}
+		}
+	}
+}
+
+// route checks that the principal of the call has the permission on the key and that the node serves the slot of
+// the key, and returns a function that must be called once the call is served. The calls for keys of other nodes
+// are answered with the redirect to the node that serves them.
+func (h *GRPCHandler) route(ctx context.Context, permission enums.Permission, key string) (func(), error) {
+	if key == "" {
+		return nil, h.grpcError(invalidRequest("key is required"))
+	}
+	if principal, ok := PrincipalFromContext(ctx); ok && !principal.Allows(permission, key) {
+		return nil, h.grpcError(forbidden(principal, permission, key))
+	}
+	if h.router == nil {
+		return func() {}, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	redirect, release := h.router.Route(key, len(md.Get(grpcAskingKey)) > 0)
+	if redirect == nil {
+		return release, nil
+	}
+
+	e := *apierrors.ErrMoved
+	if redirect.Kind == slots.RedirectAsk {
+		e = *apierrors.ErrAsk
+	}
+	e.Message = redirect.String()
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(grpcRedirectKey, redirect.String()))
+	return nil, h.grpcError(&e)
+}
+
+// authenticateUnary serves the unary calls authenticated by authenticate.
+func (h *GRPCHandler) authenticateUnary(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := h.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// authenticateStream serves the streaming calls authenticated by authenticate.
+func (h *GRPCHandler) authenticateStream(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := h.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticatedStream is a server stream whose context has the principal of the call.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate returns the context of the call with its principal, authenticated as requireAuth does with the
+// requests of the HTTP API: by the API key of the x-api-key metadata, the access token of the authorization
+// metadata with the Bearer scheme or the verified client certificate of the connection, in that order.
+func (h *GRPCHandler) authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(grpcAPIKeyKey); len(keys) > 0 {
+		principal, err := h.manager.AuthenticateAPIKey(keys[0])
+		if err != nil {
+			e := *apierrors.ErrInvalidAPIKey
+			if !errors.Is(err, auth.ErrInvalidAPIKey) {
+				e = *apierrors.ErrInternalServer
+			}
+			e.Message = err.Error()
+			e.SysMessage = err.Error()
+			return nil, h.grpcError(&e)
+		}
+		return context.WithValue(ctx, principalKey{}, principal), nil
+	}
+
+	if values := md.Get("authorization"); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if found && strings.EqualFold(scheme, "Bearer") && token != "" {
+			claims, err := h.manager.Authenticate(strings.TrimSpace(token))
+			if err != nil {
+				e := *apierrors.ErrInvalidToken
+				e.Message = err.Error()
+				e.SysMessage = err.Error()
+				return nil, h.grpcError(&e)
+			}
+			// the users can access every key
+			ctx = context.WithValue(ctx, claimsKey{}, claims)
+			return context.WithValue(ctx, principalKey{}, &auth.Principal{Name: claims.Subject, Rules: auth.FullAccess}), nil
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			principal, err := auth.AuthenticateTLS(h.manager, &info.State)
+			if err != nil {
+				e := *apierrors.ErrInternalServer
+				e.Message = err.Error()
+				e.SysMessage = err.Error()
+				return nil, h.grpcError(&e)
+			}
+			if principal != nil {
+				return context.WithValue(ctx, principalKey{}, principal), nil
+			}
+		}
+	}
+	return nil, h.grpcError(apierrors.ErrUnauthorized)
+}
+
+// grpcError converts an API error into a gRPC status with the code closest to its HTTP status.
+// The code of the API error is sent as the reason of an ErrorInfo detail.
+func (h *GRPCHandler) grpcError(e *apierrors.ApiError) error {
+	h.logger.Error("gRPC error", "code", e.Code, "message", e.Message)
+
+	code := grpcCode(e.HTTPStatus)
+	if e.Code == apierrors.ErrForbidden.Code {
+		// the read-only replicas are forbidden too, but they are a precondition of the node rather than of the caller
+		code = codes.PermissionDenied
+	}
+	st := status.New(code, e.Message)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Code, Domain: memdbpb.ErrorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+// grpcCode returns the gRPC code of an HTTP status.
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusNotFound, http.StatusGone:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		// a conditional write, which the client retries after reading the item again
+		return codes.Aborted
+	case http.StatusForbidden, http.StatusTemporaryRedirect:
+		return codes.FailedPrecondition
+	case http.StatusInsufficientStorage:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// invalidRequest returns an invalid request error with the message.
+func invalidRequest(message string) *apierrors.ApiError {
+	e := *apierrors.ErrInvalidRequest
+	e.Message = message
+	e.SysMessage = message
+	return &e
+}
+
+// fromValueProto returns the value of the database of a value of the gRPC API, or false if it is not set.
+func fromValueProto(value *memdbpb.Value) (any, bool) {
+	switch v := value.GetKind().(type) {
+	case *memdbpb.Value_StringValue:
+		return v.StringValue, true
+	case *memdbpb.Value_ListValue:
+		return append([]string{}, v.ListValue.GetValues()...), true
+	default:
+		return nil, false
+	}
+}
+
+// toItemProto converts an item of the database into its gRPC representation.
+func toItemProto(key string, item *db.Item) *memdbpb.Item {
+	response := &memdbpb.Item{
+		Key:       key,
+		Kind:      db.MappingDataType[item.Kind],
+		CreatedAt: timestamppb.New(item.CreatedAt),
+		UpdatedAt: timestamppb.New(item.UpdatedAt),
+	}
+	if !item.TTL.IsZero() {
+		response.Ttl = timestamppb.New(item.TTL)
+	}
+	if item.Value != nil {
+		switch v := item.Value.Val.(type) {
+		case string:
+			response.Value = &memdbpb.Value{Kind: &memdbpb.Value_StringValue{StringValue: v}}
+		case []string:
+			response.Value = &memdbpb.Value{Kind: &memdbpb.Value_ListValue{ListValue: &memdbpb.StringList{Values: v}}}
+		}
+	}
+	return response
+}
+
+// toEventProto converts a keyspace event into its gRPC representation.
+func toEventProto(event db.Event) *memdbpb.Event {
+	return &memdbpb.Event{
+		Id:   event.ID,
+		Type: event.Type.String(),
+		Key:  event.Key,
+		Time: timestamppb.New(event.Time),
+	}
+}
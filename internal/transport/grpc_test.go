@@ -0,0 +1,248 @@
+package transport_test
+
+import (
+	"context"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb/memdbpb"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type GRPCSuite struct {
+	db     db.DBClient
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	client memdbpb.MemoryDBClient
+	suite.Suite
+}
+
+func (s *GRPCSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.start(nil, nil)
+}
+
+func (s *GRPCSuite) TearDownTest() {
+	s.conn.Close()
+	s.server.Stop()
+	s.db.Close()
+}
+
+// start serves the database through an in-memory listener and connects the client.
+func (s *GRPCSuite) start(router *slots.Router, manager auth.AuthManager) {
+	listener := bufconn.Listen(1 << 20)
+	s.server = transport.NewGRPCServer(slog.Default(), s.db, router, manager)
+	go s.server.Serve(listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.conn = conn
+	s.client = memdbpb.NewMemoryDBClient(conn)
+}
+
+// requireError checks the code of the gRPC error and the code of the API error in its details.
+func (s *GRPCSuite) requireError(err error, code codes.Code, reason string) {
+	s.Require().Error(err)
+	st, ok := status.FromError(err)
+	s.Require().True(ok, "expected a gRPC status error")
+	s.Equal(code, st.Code(), st.Message())
+
+	s.Require().Len(st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	s.Require().True(ok)
+	s.Equal(reason, info.GetReason())
+	s.Equal(memdbpb.ErrorDomain, info.GetDomain())
+}
+
+func stringValue(v string) *memdbpb.Value {
+	return &memdbpb.Value{Kind: &memdbpb.Value_StringValue{StringValue: v}}
+}
+
+func listValue(v ...string) *memdbpb.Value {
+	return &memdbpb.Value{Kind: &memdbpb.Value_ListValue{ListValue: &memdbpb.StringList{Values: v}}}
+}
+
+func (s *GRPCSuite) TestKeys() {
+	ctx := context.Background()
+
+	_, err := s.client.Set(ctx, &memdbpb.SetRequest{Key: "key", Value: stringValue("value"), Ttl: durationpb.New(time.Hour)})
+	s.Require().NoError(err)
+
+	item, err := s.client.Get(ctx, &memdbpb.GetRequest{Key: "key"})
+	s.Require().NoError(err)
+	s.Equal("key", item.GetKey())
+	s.Equal("value", item.GetValue().GetStringValue())
+	s.Equal("string", item.GetKind())
+	s.WithinDuration(time.Now().Add(time.Hour), item.GetTtl().AsTime(), time.Second)
+
+	_, err = s.client.Update(ctx, &memdbpb.UpdateRequest{Key: "key", Value: stringValue("updated")})
+	s.Require().NoError(err)
+	item, err = s.client.Get(ctx, &memdbpb.GetRequest{Key: "key"})
+	s.Require().NoError(err)
+	s.Equal("updated", item.GetValue().GetStringValue())
+	s.WithinDuration(time.Now().Add(time.Hour), item.GetTtl().AsTime(), time.Second, "Update should keep the TTL")
+
+	_, err = s.client.Remove(ctx, &memdbpb.RemoveRequest{Key: "key"})
+	s.Require().NoError(err)
+	_, err = s.client.Get(ctx, &memdbpb.GetRequest{Key: "key"})
+	s.requireError(err, codes.NotFound, "item_not_found")
+}
+
+func (s *GRPCSuite) TestLists() {
+	ctx := context.Background()
+
+	_, err := s.client.Set(ctx, &memdbpb.SetRequest{Key: "list", Value: listValue("a", "b")})
+	s.Require().NoError(err)
+
+	item, err := s.client.Push(ctx, &memdbpb.PushRequest{Key: "list", Value: "c"})
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b", "c"}, item.GetValue().GetListValue().GetValues())
+	s.Equal("string_slice", item.GetKind())
+
+	item, err = s.client.Pop(ctx, &memdbpb.PopRequest{Key: "list"})
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.GetValue().GetListValue().GetValues())
+}
+
+func (s *GRPCSuite) TestErrors() {
+	ctx := context.Background()
+
+	_, err := s.client.Set(ctx, &memdbpb.SetRequest{Key: "key"})
+	s.requireError(err, codes.InvalidArgument, "invalid_request")
+	_, err = s.client.Get(ctx, &memdbpb.GetRequest{})
+	s.requireError(err, codes.InvalidArgument, "invalid_request")
+
+	_, err = s.client.Set(ctx, &memdbpb.SetRequest{Key: "expiring", Value: stringValue("value"), Ttl: durationpb.New(time.Millisecond)})
+	s.Require().NoError(err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = s.client.Get(ctx, &memdbpb.GetRequest{Key: "expiring"})
+	s.requireError(err, codes.NotFound, "key_has_expired")
+
+	s.db.SetReadOnly(true)
+	_, err = s.client.Set(ctx, &memdbpb.SetRequest{Key: "key", Value: stringValue("value")})
+	s.requireError(err, codes.FailedPrecondition, "read_only")
+
+	expired, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Second))
+	defer cancel()
+	_, err = s.client.Get(expired, &memdbpb.GetRequest{Key: "key"})
+	s.Equal(codes.DeadlineExceeded, status.Code(err), "the deadline of the caller should be enforced")
+}
+
+func (s *GRPCSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := s.client.Watch(ctx, &memdbpb.WatchRequest{Match: "user:*"})
+	s.Require().NoError(err)
+
+	// the subscription starts once the call is received, so the writes are retried until the first event arrives
+	received := make(chan *memdbpb.Event, 1)
+	go func() {
+		event, err := stream.Recv()
+		if err == nil {
+			received <- event
+		}
+	}()
+
+	var event *memdbpb.Event
+	deadline := time.After(5 * time.Second)
+	for event == nil {
+		s.Require().NoError(s.db.Set("other", "value"))
+		s.Require().NoError(s.db.Set("user:1", "value"))
+		select {
+		case event = <-received:
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			s.FailNow("no event received")
+		}
+	}
+	s.Equal("user:1", event.GetKey(), "the events of the keys that do not match should be filtered")
+	s.Equal("set", event.GetType())
+	s.NotZero(event.GetId())
+}
+
+func (s *GRPCSuite) TestSlots() {
+	s.conn.Close()
+	s.server.Stop()
+
+	// this node serves the slots 0-8191, and "foo" is in the slot 12182
+	nodes := []slots.Node{{ID: "a", URL: "http://a:8080"}, {ID: "b", URL: "http://b:8080"}}
+	router, err := slots.NewRouter(slog.Default(), s.db, "a", slots.NewTopology(nodes))
+	s.Require().NoError(err)
+	s.start(router, nil)
+
+	var trailer metadata.MD
+	_, err = s.client.Get(context.Background(), &memdbpb.GetRequest{Key: "foo"}, grpc.Trailer(&trailer))
+	s.requireError(err, codes.FailedPrecondition, "moved")
+	s.Equal([]string{"MOVED 12182 http://b:8080"}, trailer.Get("x-memorydb-redirect"))
+
+	_, err = s.client.Set(context.Background(), &memdbpb.SetRequest{Key: "bar", Value: stringValue("value")})
+	s.NoError(err)
+}
+
+func (s *GRPCSuite) TestAuth() {
+	s.conn.Close()
+	s.server.Stop()
+
+	manager, err := auth.NewManager(slog.Default(), s.db, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	s.Require().NoError(manager.Register("admin", "admin-password", "alice", "secret"))
+	tokens, err := manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	rules := []db.ACLRule{{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead}}}
+	_, key, err := manager.CreateAPIKey("billing", "", rules)
+	s.Require().NoError(err)
+	s.start(nil, manager)
+
+	_, err = s.client.Get(context.Background(), &memdbpb.GetRequest{Key: "billing:1"})
+	s.requireError(err, codes.Unauthenticated, "unauthorized")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer not-a-token")
+	_, err = s.client.Get(ctx, &memdbpb.GetRequest{Key: "billing:1"})
+	s.requireError(err, codes.Unauthenticated, "invalid_token")
+
+	// the users can access every key
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+tokens.AccessToken)
+	_, err = s.client.Set(ctx, &memdbpb.SetRequest{Key: "billing:1", Value: stringValue("paid")})
+	s.Require().NoError(err)
+
+	// the API keys can only access the keys of their rules
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+	item, err := s.client.Get(ctx, &memdbpb.GetRequest{Key: "billing:1"})
+	s.Require().NoError(err)
+	s.Equal("paid", item.GetValue().GetStringValue())
+	_, err = s.client.Set(ctx, &memdbpb.SetRequest{Key: "billing:1", Value: stringValue("due")})
+	s.requireError(err, codes.PermissionDenied, "forbidden")
+	_, err = s.client.Get(ctx, &memdbpb.GetRequest{Key: "other"})
+	s.requireError(err, codes.PermissionDenied, "forbidden")
+
+	stream, err := s.client.Watch(ctx, &memdbpb.WatchRequest{Match: "*"})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.requireError(err, codes.PermissionDenied, "forbidden")
+	stream, err = s.client.Watch(context.Background(), &memdbpb.WatchRequest{Match: "billing:*"})
+	s.Require().NoError(err)
+	_, err = stream.Recv()
+	s.requireError(err, codes.Unauthenticated, "unauthorized")
+}
+
+func TestGRPCSuite(t *testing.T) {
+	suite.Run(t, new(GRPCSuite))
+}
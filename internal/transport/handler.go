@@ -23,9 +23,9 @@ func NewHandler(logger *slog.Logger, db db.DBClient) *Handler {
 
 // HandleSet sets a value in the database.
 func (h *Handler) HandleSet(w http.ResponseWriter, r *http.Request) {
-	// decode the request body into a SetRequest object
-	var body schemas.SetRowRequest
-	if err := decodeJSON(r.Body, &body); err != nil {
+	// decode the request body into a SetRequest object, unless the route has already decoded it
+	body, err := requestBody[schemas.SetRowRequest](r)
+	if err != nil {
 		wrapError(w, err)
 		return
 	}
@@ -35,9 +35,9 @@ func (h *Handler) HandleSet(w http.ResponseWriter, r *http.Request) {
 	if body.TTL != nil {
 		opts = append(opts, db.WithTTL(body.TTL.Duration))
 	}
-	err := h.db.Set(body.Key, body.Value.Val, opts...)
+	err = keyspace(r, h.db).Set(body.Key, body.Value.Val, opts...)
 	if err != nil {
-		wrapError(w, fmt.Errorf("failed to set item in db: %w", err))
+		wrapError(w, h.wrapDBError(err))
 		return
 	}
 
@@ -54,7 +54,7 @@ func (h *Handler) HandleGet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// get item from db
-	item, err := h.db.Get(keyParam)
+	item, err := keyspace(r, h.db).Get(keyParam)
 	if err != nil {
 		wrapError(w, h.wrapDBError(err))
 		return
@@ -82,7 +82,7 @@ func (h *Handler) HandleRemove(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// remove item from db
-	if err := h.db.Remove(keyParam); err != nil {
+	if err := keyspace(r, h.db).Remove(keyParam); err != nil {
 		wrapError(w, h.wrapDBError(err))
 		return
 	}
@@ -111,7 +111,7 @@ func (h *Handler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	if body.TTL != nil {
 		opts = append(opts, db.WithTTL(body.TTL.Duration))
 	}
-	err := h.db.Update(keyParam, body.Value.Val, opts...)
+	err := keyspace(r, h.db).Update(keyParam, body.Value.Val, opts...)
 	if err != nil {
 		wrapError(w, h.wrapDBError(err))
 		return
@@ -141,7 +141,7 @@ func (h *Handler) HandlePush(w http.ResponseWriter, r *http.Request) {
 	if body.TTL != nil {
 		opts = append(opts, db.WithTTL(body.TTL.Duration))
 	}
-	row, err := h.db.Push(keyParam, body.Value, opts...)
+	row, err := keyspace(r, h.db).Push(keyParam, body.Value, opts...)
 	if err != nil {
 		wrapError(w, h.wrapDBError(err))
 		return
@@ -167,7 +167,7 @@ func (h *Handler) HandlePop(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// pop value from db
-	row, err := h.db.Pop(keyParam)
+	row, err := keyspace(r, h.db).Pop(keyParam)
 	if err != nil {
 		wrapError(w, h.wrapDBError(err))
 		return
@@ -206,6 +206,61 @@ func (h *Handler) wrapDBError(err error) *apierrors.ApiError {
 		e.Message = dbError.Message
 		e.SysMessage = dbError.SysMessage
 		return e
+	case db.ErrOutOfMemory:
+		e := *apierrors.ErrInsufficientStorage
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrNotAStream:
+		e := *apierrors.ErrWrongType
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrInvalidStreamID:
+		e := *apierrors.ErrInvalidRequest
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrStreamGroupNotFound:
+		e := *apierrors.ErrGroupNotFound
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrStreamGroupExists:
+		e := *apierrors.ErrGroupAlreadyExists
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrNamespaceNotFound:
+		e := *apierrors.ErrNamespaceNotFound
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrNamespaceExists:
+		e := *apierrors.ErrNamespaceAlreadyExists
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrInvalidNamespace, db.ErrNamespaceNotPersistent:
+		e := *apierrors.ErrInvalidRequest
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrReadOnly:
+		e := *apierrors.ErrReadOnly
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrNoLeader:
+		e := *apierrors.ErrClusterUnavailable
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
+	case db.ErrVersionMismatch:
+		e := *apierrors.ErrVersionMismatch
+		e.Message = dbError.Message
+		e.SysMessage = dbError.SysMessage
+		return &e
 	default:
 		e := apierrors.ErrInternalServer
 		e.Message = dbError.Message
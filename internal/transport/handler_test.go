@@ -8,13 +8,17 @@ import (
 	"log/slog"
 	"memorydb/internal/apierrors"
 	"memorydb/internal/db"
+	"memorydb/internal/enums"
 	"memorydb/internal/transport"
 	"memorydb/internal/transport/schemas"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -77,6 +81,28 @@ func (s *HandlerSuite) TestSet() {
 		s.Require().NoError(err, "failed to decode error response")
 		s.Equal(apierrors.ErrInvalidJSON.Code, errResponse.Code, "expected error code to be 'invalid_data_type'")
 	})
+
+	s.Run("Set error out of memory", func() {
+		body := `{
+			"key": "fullKey",
+			"value": "testValue"
+		}`
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/set", bytes.NewBuffer([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		s.db.On("Set", "fullKey", "testValue", mock.Anything).Return(db.ErrOutOfMemory)
+		s.handler.HandleSet(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusInsufficientStorage, resp.StatusCode, "expected status code 507 Insufficient Storage")
+
+		var errResponse apierrors.ApiError
+		err := json.NewDecoder(resp.Body).Decode(&errResponse)
+		s.Require().NoError(err, "failed to decode error response")
+		s.Equal(apierrors.ErrInsufficientStorage.Code, errResponse.Code, "expected error code to be 'insufficient_storage'")
+	})
 }
 
 func (s *HandlerSuite) TestGet() {
@@ -220,6 +246,58 @@ func (s *HandlerSuite) TestPop() {
 	})
 }
 
+func (s *HandlerSuite) TestEvents() {
+	s.Run("Events SSE ok", func() {
+		ch := make(chan db.Event, 1)
+		ch <- db.Event{ID: 7, Type: enums.KeyspaceEventSet, Key: "user:1", Time: time.Now()}
+		close(ch)
+		s.db.On("Subscribe", "user:*", uint64(6)).Return((<-chan db.Event)(ch), func() {}).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?match=user:*", nil)
+		req.Header.Set("Last-Event-ID", "6")
+		w := httptest.NewRecorder()
+
+		s.handler.HandleEvents(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode, "expected status code 200 OK")
+		s.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+		s.Contains(w.Body.String(), "id: 7\nevent: set\ndata: {")
+		s.Contains(w.Body.String(), `"key":"user:1"`)
+	})
+
+	s.Run("Events invalid last event ID", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/events?last_event_id=abc", nil)
+		w := httptest.NewRecorder()
+
+		s.handler.HandleEvents(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusBadRequest, resp.StatusCode, "expected status code 400 Bad Request")
+	})
+
+	s.Run("Events WebSocket ok", func() {
+		ch := make(chan db.Event, 1)
+		ch <- db.Event{ID: 1, Type: enums.KeyspaceEventRemove, Key: "session", Time: time.Now()}
+		close(ch)
+		s.db.On("Subscribe", "session", uint64(0)).Return((<-chan db.Event)(ch), func() {}).Once()
+
+		srv := httptest.NewServer(http.HandlerFunc(s.handler.HandleEvents))
+		defer srv.Close()
+
+		wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/events?match=session"
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		s.Require().NoError(err, "failed to dial websocket")
+		defer conn.Close()
+
+		var event schemas.EventResponse
+		s.Require().NoError(conn.ReadJSON(&event), "failed to read event")
+		s.Equal(uint64(1), event.ID)
+		s.Equal("remove", event.Type)
+		s.Equal("session", event.Key)
+	})
+}
+
 func TestHandlerSuite(t *testing.T) {
 	suite.Run(t, new(HandlerSuite))
 }
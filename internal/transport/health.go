@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"errors"
+	"log/slog"
+	"memorydb/internal/certs"
+	"memorydb/internal/db"
+	"memorydb/internal/replication"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// healthCheckTimeout is the time the checks of a probe wait for the lock of the items before reporting it as stuck.
+const healthCheckTimeout = time.Second
+
+type HealthHandler struct {
+	logger       *slog.Logger
+	db           db.DBClient          // nil while the database is loaded at startup
+	replica      *replication.Replica // replica of the server, nil if the server is a primary
+	shuttingDown *atomic.Bool         // set once the server begins to shut down, nil while the database is loaded
+}
+
+// NewHealthHandler creates a new handler for the liveness and readiness probes. The database is nil while it is loaded
+// at startup, in which case the server is reported alive but not ready.
+func NewHealthHandler(logger *slog.Logger, db db.DBClient, replica *replication.Replica, shuttingDown *atomic.Bool) *HealthHandler {
+	return &HealthHandler{logger: logger, db: db, replica: replica, shuttingDown: shuttingDown}
+}
+
+// HandleLive reports whether the server is alive: 200 if its liveness checks pass, 503 if the process is stuck and
+// must be restarted. The checks are listed with the `verbose` query parameter.
+func (h *HealthHandler) HandleLive(w http.ResponseWriter, r *http.Request) {
+	var checks []db.HealthCheck
+	for _, check := range h.checks() {
+		if check.Liveness {
+			checks = append(checks, check)
+		}
+	}
+	h.writeChecks(w, r, "liveness", checks)
+}
+
+// HandleReady reports whether the server can serve requests: 200 if all its checks pass, 503 while the data is
+// loaded, the operation log is not writable, the replica is not in sync with the primary, the server is shutting
+// down, or a liveness check fails. The checks are listed with the `verbose` query parameter.
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	h.writeChecks(w, r, "readiness", h.checks())
+}
+
+// checks runs the checks of the database, of the replica and of the shutdown.
+func (h *HealthHandler) checks() []db.HealthCheck {
+	if h.db == nil {
+		return []db.HealthCheck{{Name: "load", Err: errors.New("the data is being loaded")}}
+	}
+
+	checks := h.db.Health(healthCheckTimeout)
+	if h.replica != nil {
+		checks = append(checks, db.HealthCheck{Name: "replication", Err: h.replica.Ready()})
+	}
+	if h.shuttingDown != nil {
+		shutdown := db.HealthCheck{Name: "shutdown"}
+		if h.shuttingDown.Load() {
+			shutdown.Err = errors.New("the server is shutting down")
+		}
+		checks = append(checks, shutdown)
+	}
+	return checks
+}
+
+// writeChecks writes the status of the checks of a probe, with the list of the checks if the view is verbose.
+func (h *HealthHandler) writeChecks(w http.ResponseWriter, r *http.Request, probe string, checks []db.HealthCheck) {
+	verbose := r.URL.Query().Has("verbose")
+	status := http.StatusOK
+	response := schemas.HealthResponse{Status: "ok"}
+
+	for _, check := range checks {
+		result := schemas.HealthCheckResponse{Name: check.Name, Kind: "readiness", Status: "ok"}
+		if check.Liveness {
+			result.Kind = "liveness"
+		}
+		if check.Err != nil {
+			h.logger.Warn("health check failed", "probe", probe, "check", check.Name, "error", check.Err)
+			status = http.StatusServiceUnavailable
+			response.Status = "failing"
+			result.Status = "failing"
+			result.Error = check.Err.Error()
+		}
+		if verbose {
+			response.Checks = append(response.Checks, result)
+		}
+	}
+
+	writeJSON(w, status, response)
+}
+
+// StartupHealth serves the probes on the health port while the database is loaded, before the server is created.
+// It reports the server alive but not ready, until the server takes over its listener with WithStartupHealth.
+type StartupHealth struct {
+	logger  *slog.Logger
+	srv     *http.Server
+	handler atomic.Pointer[http.Handler]
+}
+
+// NewStartupHealth creates the health server used while the database is loaded. It serves over TLS with the
+// certificates of the reloader, which is nil if the health server serves plain HTTP.
+func NewStartupHealth(logger *slog.Logger, healthPort int, reloader *certs.Reloader) *StartupHealth {
+	h := &StartupHealth{logger: logger}
+	h.srv = &http.Server{
+		Addr: ":" + strconv.Itoa(healthPort),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			(*h.handler.Load()).ServeHTTP(w, r)
+		}),
+	}
+	if reloader != nil {
+		h.srv.TLSConfig = reloader.ServerConfig(false)
+	}
+	h.setHandler(mountStartupHealthRouter(logger))
+	return h
+}
+
+// Start listens for the probes on the health port until the server is shut down.
+func (h *StartupHealth) Start() error {
+	h.logger.Info("Starting health HTTP server", "port", h.srv.Addr, "tls", h.srv.TLSConfig != nil)
+	return listenAndServe(h.srv)
+}
+
+// Handler returns the handler currently serving the probes.
+func (h *StartupHealth) Handler() http.Handler {
+	return *h.handler.Load()
+}
+
+// setHandler replaces the handler serving the requests of the health port.
+func (h *StartupHealth) setHandler(handler http.Handler) {
+	h.handler.Store(&handler)
+}
+
+// mountStartupHealthRouter mounts the router of the probes served while the database is loaded.
+func mountStartupHealthRouter(logger *slog.Logger) http.Handler {
+	r := chi.NewRouter()
+	hh := NewHealthHandler(logger, nil, nil, nil)
+	r.Get("/livez", hh.HandleLive)
+	r.Get("/readyz", hh.HandleReady)
+	r.Get("/health", hh.HandleReady)
+	return r
+}
@@ -0,0 +1,118 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/replication"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthSuite struct {
+	db     db.DBClient
+	server *transport.Server
+	suite.Suite
+}
+
+func (s *HealthSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default(), db.WithPersistenceEnabled(s.T().TempDir()))
+	s.server = transport.NewServer(slog.Default(), 0, 0, s.db)
+}
+
+func (s *HealthSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// probe sends a request for the path to the handler and returns its status code and response.
+func (s *HealthSuite) probe(handler http.Handler, path string) (int, schemas.HealthResponse) {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var response schemas.HealthResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+func (s *HealthSuite) TestProbes() {
+	for _, path := range []string{"/livez", "/readyz", "/health"} {
+		status, response := s.probe(s.server.HealthHandler(), path)
+		s.Equal(http.StatusOK, status, path)
+		s.Equal(schemas.HealthResponse{Status: "ok"}, response, "the checks should only be listed in the verbose view")
+	}
+}
+
+func (s *HealthSuite) TestVerbose() {
+	status, response := s.probe(s.server.HealthHandler(), "/health?verbose")
+	s.Require().Equal(http.StatusOK, status)
+	s.Equal([]schemas.HealthCheckResponse{
+		{Name: "load", Kind: "readiness", Status: "ok"},
+		{Name: "persistence", Kind: "readiness", Status: "ok"},
+		{Name: "cleanup", Kind: "liveness", Status: "ok"},
+		{Name: "lock", Kind: "liveness", Status: "ok"},
+		{Name: "shutdown", Kind: "readiness", Status: "ok"},
+	}, response.Checks)
+
+	_, response = s.probe(s.server.HealthHandler(), "/livez?verbose")
+	s.Equal([]schemas.HealthCheckResponse{
+		{Name: "cleanup", Kind: "liveness", Status: "ok"},
+		{Name: "lock", Kind: "liveness", Status: "ok"},
+	}, response.Checks, "the liveness probe should only run the liveness checks")
+}
+
+func (s *HealthSuite) TestShutdown() {
+	s.server.BeginShutdown()
+
+	status, response := s.probe(s.server.HealthHandler(), "/readyz?verbose")
+	s.Equal(http.StatusServiceUnavailable, status)
+	s.Equal("failing", response.Status)
+	s.Contains(response.Checks, schemas.HealthCheckResponse{
+		Name: "shutdown", Kind: "readiness", Status: "failing", Error: "the server is shutting down",
+	})
+
+	status, _ = s.probe(s.server.HealthHandler(), "/livez")
+	s.Equal(http.StatusOK, status, "a server shutting down should stay alive")
+}
+
+func (s *HealthSuite) TestReplica() {
+	replica := replication.NewReplica(slog.Default(), s.db, "http://primary:8080")
+	server := transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithReplica{Replica: replica})
+
+	status, response := s.probe(server.HealthHandler(), "/readyz?verbose")
+	s.Equal(http.StatusServiceUnavailable, status)
+	s.Contains(response.Checks, schemas.HealthCheckResponse{
+		Name: "replication", Kind: "readiness", Status: "failing",
+		Error: "the full synchronization with the primary is not complete",
+	})
+
+	status, _ = s.probe(server.HealthHandler(), "/livez")
+	s.Equal(http.StatusOK, status)
+}
+
+func (s *HealthSuite) TestStartup() {
+	startup := transport.NewStartupHealth(slog.Default(), 0, nil)
+
+	status, _ := s.probe(startup.Handler(), "/livez")
+	s.Equal(http.StatusOK, status, "the server should be alive while the data is loaded")
+	status, response := s.probe(startup.Handler(), "/readyz?verbose")
+	s.Equal(http.StatusServiceUnavailable, status)
+	s.Equal([]schemas.HealthCheckResponse{
+		{Name: "load", Kind: "readiness", Status: "failing", Error: "the data is being loaded"},
+	}, response.Checks)
+
+	// the server takes over the health server once the data is loaded
+	server := transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithStartupHealth{StartupHealth: startup})
+	s.Nil(server.StartHealth(), "the health server should not be started twice")
+	status, _ = s.probe(startup.Handler(), "/readyz")
+	s.Equal(http.StatusOK, status)
+	status, _ = s.probe(server.HealthHandler(), "/readyz")
+	s.Equal(http.StatusOK, status)
+}
+
+func TestHealthSuite(t *testing.T) {
+	suite.Run(t, new(HealthSuite))
+}
@@ -0,0 +1,36 @@
+package transport
+
+import (
+	"memorydb/internal/metrics"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// instrument records the method, the route, the status code and the duration of every request in the metrics.
+// The route is the pattern matched by the router, so the requests of every key are recorded in the same route.
+// Every request is served without being recorded if m is nil.
+func instrument(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if m == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			route := chi.RouteContext(r.Context()).RoutePattern()
+			if route == "" {
+				route = "unmatched"
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK // the handler wrote nothing, so the server answers 200
+			}
+			m.ObserveRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
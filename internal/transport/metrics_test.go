@@ -0,0 +1,79 @@
+package transport_test
+
+import (
+	"io"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/metrics"
+	"memorydb/internal/transport"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsSuite struct {
+	db     db.DBClient
+	server *transport.Server
+	suite.Suite
+}
+
+func (s *MetricsSuite) SetupTest() {
+	collectors := metrics.New()
+	s.db = db.NewMemoryDB(slog.Default(), db.WithPersistenceEnabled(s.T().TempDir()), db.WithMetrics{Metrics: collectors})
+	s.server = transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithMetrics{Metrics: collectors})
+}
+
+func (s *MetricsSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// do sends the request to the handler and returns its status code.
+func (s *MetricsSuite) do(handler http.Handler, method, path, body string) (int, string) {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	response, err := io.ReadAll(w.Body)
+	s.Require().NoError(err)
+	return w.Code, string(response)
+}
+
+func (s *MetricsSuite) TestMetrics() {
+	status, _ := s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", `{"key": "a", "value": "1"}`)
+	s.Require().Equal(http.StatusOK, status)
+	status, _ = s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", `{"key": "b", "value": ["1", "2"]}`)
+	s.Require().Equal(http.StatusOK, status)
+	status, _ = s.do(s.server.Handler(), http.MethodGet, "/api/v1/a", "")
+	s.Require().Equal(http.StatusOK, status)
+	status, _ = s.do(s.server.Handler(), http.MethodGet, "/api/v1/missing", "")
+	s.Require().Equal(http.StatusNotFound, status)
+
+	status, body := s.do(s.server.HealthHandler(), http.MethodGet, "/metrics", "")
+	s.Require().Equal(http.StatusOK, status)
+	s.Contains(body, `memorydb_http_requests_total{method="POST",route="/api/v1/set",status="200"} 2`)
+	s.Contains(body, `memorydb_http_requests_total{method="GET",route="/api/v1/{key}",status="200"} 1`,
+		"the requests should be recorded by route instead of by key")
+	s.Contains(body, `memorydb_http_requests_total{method="GET",route="/api/v1/{key}",status="404"} 1`)
+	s.Contains(body, `memorydb_operations_total{command="set"} 2`)
+	s.Contains(body, `memorydb_keys{type="string"} 1`)
+	s.Contains(body, `memorydb_keys{type="string_slice"} 1`)
+	s.Contains(body, `memorydb_persistence_written_bytes_total`)
+	s.NotContains(body, `memorydb_persistence_written_bytes_total 0`)
+}
+
+func (s *MetricsSuite) TestNamespaces() {
+	s.Require().NoError(s.db.CreateNamespace("sessions", db.NamespaceConfig{}))
+	status, _ := s.do(s.server.Handler(), http.MethodPost, "/api/v1/ns/sessions/set", `{"key": "a", "value": "1"}`)
+	s.Require().Equal(http.StatusOK, status)
+
+	_, body := s.do(s.server.HealthHandler(), http.MethodGet, "/metrics", "")
+	s.Contains(body, `memorydb_http_requests_total{method="POST",route="/api/v1/ns/{namespace}/set",status="200"} 1`)
+	s.Contains(body, `memorydb_operations_total{command="ns_create"} 1`)
+	s.Contains(body, `memorydb_operations_total{command="set"} 1`)
+	s.Contains(body, `memorydb_keys{type="string"} 1`, "the keys of the namespaces should be counted")
+}
+
+func TestMetricsSuite(t *testing.T) {
+	suite.Run(t, new(MetricsSuite))
+}
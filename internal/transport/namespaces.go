@@ -0,0 +1,117 @@
+package transport
+
+import (
+	"context"
+	"memorydb/internal/db"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// namespaceKey is the key of the database of the namespace of a request in its context.
+type namespaceKey struct{}
+
+// keyspace returns the database of the keys of the request: the one of its namespace, or else the database.
+// The operations are traced as children of the span of the request, if it is traced.
+func keyspace(r *http.Request, database db.DBClient) db.DBClient {
+	if namespace, ok := r.Context().Value(namespaceKey{}).(db.DBClient); ok {
+		database = namespace
+	}
+	if trace.SpanContextFromContext(r.Context()).IsValid() {
+		return database.WithContext(r.Context())
+	}
+	return database
+}
+
+// useNamespace serves the request with the keys of the namespace in the URL. The principal of the request is
+// moved to the namespace too, so the rules of its API key are checked for the keys of the namespace.
+func (h *Handler) useNamespace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := chi.URLParam(r, "namespace")
+		namespace, err := h.db.Namespace(name)
+		if err != nil {
+			wrapError(w, h.wrapDBError(err))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), namespaceKey{}, namespace)
+		if principal, ok := PrincipalFromContext(ctx); ok {
+			ctx = context.WithValue(ctx, principalKey{}, principal.InNamespace(name))
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// HandleCreateNamespace creates an empty namespace with the settings of the request.
+func (h *Handler) HandleCreateNamespace(w http.ResponseWriter, r *http.Request) {
+	var body schemas.CreateNamespaceRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	config := db.NamespaceConfig{
+		DefaultTTL:     durationOrZero(body.DefaultTTL),
+		MaxMemory:      body.MaxMemory,
+		EvictionPolicy: body.EvictionPolicy,
+		Persistence:    body.Persistence,
+	}
+	if err := h.db.CreateNamespace(body.Name, config); err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+	h.writeNamespace(w, http.StatusCreated, body.Name)
+}
+
+// HandleListNamespaces returns the namespaces with their settings and counters.
+func (h *Handler) HandleListNamespaces(w http.ResponseWriter, r *http.Request) {
+	items := h.db.Namespaces()
+	response := schemas.NamespacesResponse{Namespaces: make([]schemas.NamespaceResponse, 0, len(items))}
+	for _, item := range items {
+		response.Namespaces = append(response.Namespaces, h.namespaceResponse(item))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleGetNamespace returns the namespace in the URL with its settings and counters.
+func (h *Handler) HandleGetNamespace(w http.ResponseWriter, r *http.Request) {
+	h.writeNamespace(w, http.StatusOK, chi.URLParam(r, "namespace"))
+}
+
+// HandleDropNamespace removes the namespace in the URL with all its keys.
+func (h *Handler) HandleDropNamespace(w http.ResponseWriter, r *http.Request) {
+	if err := h.db.DropNamespace(chi.URLParam(r, "namespace")); err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// writeNamespace writes the response of the namespace with the status, or a not found error if it does not exist.
+func (h *Handler) writeNamespace(w http.ResponseWriter, status int, name string) {
+	for _, item := range h.db.Namespaces() {
+		if item.Name == name {
+			writeJSON(w, status, h.namespaceResponse(item))
+			return
+		}
+	}
+	wrapError(w, h.wrapDBError(db.ErrNamespaceNotFound))
+}
+
+// namespaceResponse returns the response of a namespace, with the counters of its keyspace. The counters are
+// empty if the namespace is dropped in the meantime.
+func (h *Handler) namespaceResponse(item db.NamespaceItem) schemas.NamespaceResponse {
+	response := schemas.NamespaceResponse{
+		Name:           item.Name,
+		DefaultTTL:     schemas.Duration{Duration: item.Config.DefaultTTL},
+		MaxMemory:      item.Config.MaxMemory,
+		EvictionPolicy: item.Config.EvictionPolicy,
+		Persistence:    item.Config.Persistence,
+	}
+	if namespace, err := h.db.Namespace(item.Name); err == nil {
+		response.Stats = namespace.Stats()
+	}
+	return response
+}
@@ -0,0 +1,76 @@
+package transport_test
+
+import (
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"time"
+)
+
+func (s *AuthSuite) TestNamespaces() {
+	var errResponse apierrors.ApiError
+	request := schemas.CreateNamespaceRequest{Name: "sessions", DefaultTTL: &schemas.Duration{Duration: time.Hour}, MaxMemory: 1 << 20}
+	s.Equal(http.StatusUnauthorized, s.send(http.MethodPost, "/api/v1/admin/namespaces", http.Header{}, request, &errResponse))
+
+	var created schemas.NamespaceResponse
+	s.Require().Equal(http.StatusCreated, s.send(http.MethodPost, "/api/v1/admin/namespaces", admin("admin-password"), request, &created))
+	s.Equal(time.Hour, created.DefaultTTL.Duration)
+	s.Equal(enums.EvictionPolicyNoEviction, created.EvictionPolicy)
+	s.Equal(http.StatusConflict, s.send(http.MethodPost, "/api/v1/admin/namespaces", admin("admin-password"), request, &errResponse))
+	s.Equal(apierrors.ErrNamespaceAlreadyExists.Code, errResponse.Code)
+	invalid := schemas.CreateNamespaceRequest{Name: "a b"}
+	s.Equal(http.StatusBadRequest, s.send(http.MethodPost, "/api/v1/admin/namespaces", admin("admin-password"), invalid, &errResponse))
+
+	tokens := s.login()
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/ns/sessions/set", tokens.AccessToken, schemas.SetRowRequest{Key: "user:1", Value: db.StringOrSlice{Val: "namespaced"}}, nil))
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/set", tokens.AccessToken, schemas.SetRowRequest{Key: "user:1", Value: db.StringOrSlice{Val: "default"}}, nil))
+
+	var row schemas.RowResponse
+	s.Require().Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/ns/sessions/user:1", tokens.AccessToken, nil, &row))
+	s.Equal("namespaced", row.Value)
+	s.WithinDuration(time.Now().Add(time.Hour), row.TTL, time.Minute, "the item should expire after the default TTL of the namespace")
+	s.Require().Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/user:1", tokens.AccessToken, nil, &row))
+	s.Equal("default", row.Value)
+
+	var batch schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/ns/sessions/mget", tokens.AccessToken, schemas.MGetRequest{Keys: []string{"user:1"}}, &batch))
+	s.Equal("namespaced", batch.Results["user:1"].Item.Value)
+
+	s.Equal(http.StatusNotFound, s.do(http.MethodGet, "/api/v1/ns/missing/user:1", tokens.AccessToken, nil, &errResponse))
+	s.Equal(apierrors.ErrNamespaceNotFound.Code, errResponse.Code)
+	s.Require().Equal(http.StatusOK, s.do(http.MethodPost, "/api/v1/set", tokens.AccessToken, schemas.SetRowRequest{Key: "ns", Value: db.StringOrSlice{Val: "key"}}, nil))
+	s.Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/ns", tokens.AccessToken, nil, nil), "a key named ns should still be served")
+
+	var namespace schemas.NamespaceResponse
+	s.Require().Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/admin/namespaces/sessions", admin("admin-password"), nil, &namespace))
+	s.Equal(1, namespace.Stats.Keys)
+	var namespaces schemas.NamespacesResponse
+	s.Require().Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/admin/namespaces", admin("admin-password"), nil, &namespaces))
+	s.Len(namespaces.Namespaces, 1)
+
+	s.Equal(http.StatusOK, s.send(http.MethodDelete, "/api/v1/admin/namespaces/sessions", admin("admin-password"), nil, nil))
+	s.Equal(http.StatusNotFound, s.send(http.MethodDelete, "/api/v1/admin/namespaces/sessions", admin("admin-password"), nil, &errResponse))
+	s.Equal(http.StatusNotFound, s.do(http.MethodGet, "/api/v1/ns/sessions/user:1", tokens.AccessToken, nil, &errResponse))
+	s.Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/user:1", tokens.AccessToken, nil, nil), "dropping a namespace should keep the keys of the database")
+}
+
+func (s *AuthSuite) TestNamespaceAPIKeyRules() {
+	s.Require().NoError(s.db.CreateNamespace("billing", db.NamespaceConfig{}))
+	request := schemas.CreateAPIKeyRequest{Name: "billing", Rules: []db.ACLRule{
+		{Pattern: "*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}, Namespace: "billing"},
+	}}
+	var key schemas.APIKeyResponse
+	s.Require().Equal(http.StatusCreated, s.send(http.MethodPost, "/api/v1/admin/apikeys", admin("admin-password"), request, &key))
+	header := withAPIKey(key.Key)
+
+	s.Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/ns/billing/set", header, schemas.SetRowRequest{Key: "invoice:1", Value: db.StringOrSlice{Val: "paid"}}, nil))
+	s.Equal(http.StatusOK, s.send(http.MethodGet, "/api/v1/ns/billing/invoice:1", header, nil, nil))
+	s.Equal(http.StatusForbidden, s.send(http.MethodPost, "/api/v1/set", header, schemas.SetRowRequest{Key: "invoice:1", Value: db.StringOrSlice{Val: "paid"}}, nil),
+		"the rules of a namespace should not allow the keys of the database")
+
+	var batch schemas.BatchResponse
+	s.Require().Equal(http.StatusOK, s.send(http.MethodPost, "/api/v1/ns/billing/mget", header, schemas.MGetRequest{Keys: []string{"invoice:1"}}, &batch))
+	s.Nil(batch.Results["invoice:1"].Error)
+}
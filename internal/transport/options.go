@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"memorydb/internal/auth"
+	"memorydb/internal/certs"
+	"memorydb/internal/cluster"
+	"memorydb/internal/metrics"
+	"memorydb/internal/ratelimit"
+	"memorydb/internal/replication"
+	"memorydb/internal/slots"
+)
+
+// ServerOptions defines an interface for applying options to the Server.
+//
+// It follows the same functional options pattern used to configure the database.
+type ServerOptions interface {
+	apply(*Server)
+}
+
+// WithPubSubBufferSize sets the number of pub/sub messages buffered per subscriber before it is dropped.
+type WithPubSubBufferSize int
+
+func (o WithPubSubBufferSize) apply(s *Server) {
+	s.pubsubBufferSize = int(o)
+}
+
+// WithReplica sets the replica that keeps the database in sync with a primary, making the server a replica.
+type WithReplica struct{ *replication.Replica }
+
+func (o WithReplica) apply(s *Server) {
+	s.replica = o.Replica
+}
+
+// WithClusterNode sets the cluster node of the server, which receives the writes forwarded by the other nodes.
+type WithClusterNode struct{ *cluster.Node }
+
+func (o WithClusterNode) apply(s *Server) {
+	s.node = o.Node
+}
+
+// WithSlotRouter sets the router of the hash slots, which redirects the requests for keys served by other nodes.
+type WithSlotRouter struct{ *slots.Router }
+
+func (o WithSlotRouter) apply(s *Server) {
+	s.slotRouter = o.Router
+}
+
+// WithRESPPort sets the TCP port of the listener of the RESP protocol, so the server can be used with the Redis
+// tools and client libraries. A port of 0 disables the listener.
+type WithRESPPort int
+
+func (o WithRESPPort) apply(s *Server) {
+	s.respPort = int(o)
+}
+
+// WithGRPCPort sets the TCP port of the gRPC API, which serves the same database as the HTTP API.
+// A port of 0 disables the gRPC API.
+type WithGRPCPort int
+
+func (o WithGRPCPort) apply(s *Server) {
+	s.grpcPort = int(o)
+}
+
+// WithMemcachedPort sets the TCP port of the listener of the memcached text protocol, so the clients of memcached
+// can use the database. A port of 0 disables the listener.
+type WithMemcachedPort int
+
+func (o WithMemcachedPort) apply(s *Server) {
+	s.memcachedPort = int(o)
+}
+
+// WithAuthManager sets the manager of the authentication, which enables the authentication endpoints and requires
+// an access token in the data routes of the HTTP API.
+type WithAuthManager struct{ auth.AuthManager }
+
+func (o WithAuthManager) apply(s *Server) {
+	s.authManager = o.AuthManager
+}
+
+// WithTLS serves the HTTP API and the health server over TLS with the certificates of the reloader. The main server
+// verifies the certificates of the clients with the policy of the reloader, while the health server does not, so the
+// probes do not need a client certificate.
+type WithTLS struct{ *certs.Reloader }
+
+func (o WithTLS) apply(s *Server) {
+	s.certs = o.Reloader
+}
+
+// WithRateLimiter limits the rate of the reads and the writes of every client of the data routes of the HTTP API.
+type WithRateLimiter struct{ *ratelimit.Limiter }
+
+func (o WithRateLimiter) apply(s *Server) {
+	s.limiter = o.Limiter
+}
+
+// WithInFlightLimit limits the number of requests of the data routes of the HTTP API served at once. The event and
+// message streams are not limited, since they stay open as long as their clients are connected.
+type WithInFlightLimit struct{ *ratelimit.InFlight }
+
+func (o WithInFlightLimit) apply(s *Server) {
+	s.inFlight = o.InFlight
+}
+
+// WithMetrics records the requests of the HTTP API in the collectors, and serves the metrics in the Prometheus text
+// format at /metrics of the health server.
+type WithMetrics struct{ *metrics.Metrics }
+
+func (o WithMetrics) apply(s *Server) {
+	s.metrics = o.Metrics
+}
+
+// WithStartupHealth hands the listener of the health server started while the database was loaded over to the
+// server, which serves its probes from then on. The health server is then not started again by StartHealth.
+type WithStartupHealth struct{ *StartupHealth }
+
+func (o WithStartupHealth) apply(s *Server) {
+	s.startup = o.StartupHealth
+}
@@ -0,0 +1,214 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport/schemas"
+	"memorydb/internal/validator"
+	"mime"
+	"net/http"
+)
+
+const (
+	// ndjsonContentType is the content type of the pipelines streamed as newline-delimited JSON.
+	ndjsonContentType = "application/x-ndjson"
+
+	// maxPipelineCommands is the maximum number of commands of a pipeline sent as a single JSON document.
+	// Longer pipelines must be streamed as NDJSON.
+	maxPipelineCommands = 10000
+
+	// maxPipelineLineSize is the maximum length of a command of a pipeline streamed as NDJSON.
+	maxPipelineLineSize = 1024 * 1024
+)
+
+// errLineTooLong is returned by readLine when a line is longer than its limit.
+var errLineTooLong = errors.New("line too long")
+
+// HandlePipeline runs an ordered list of commands and returns their results in the same order. The pipeline is
+// not atomic: every command has its own result, and a failed command does not stop the next ones.
+//
+// With the content type application/x-ndjson, the commands are read one per line and their results are written
+// one per line while the commands run, so the pipeline is never held in memory, whatever its length.
+func (h *BatchHandler) HandlePipeline(w http.ResponseWriter, r *http.Request) {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == ndjsonContentType {
+		h.streamPipeline(w, r)
+		return
+	}
+
+	var body schemas.PipelineRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if len(body.Commands) > maxPipelineCommands {
+		wrapError(w, invalidRequest(fmt.Sprintf("the pipeline cannot have more than %d commands, longer pipelines must be sent as NDJSON", maxPipelineCommands)))
+		return
+	}
+
+	asking := r.Header.Get(slots.AskingHeader) != ""
+	response := schemas.PipelineResponse{Results: make([]schemas.KeyResult, len(body.Commands))}
+	for i, cmd := range body.Commands {
+		response.Results[i] = h.run(r, cmd, asking)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// streamPipeline runs the commands of a pipeline streamed as NDJSON and streams their results in the same format.
+//
+// The results are buffered while more commands are waiting to be read, and sent together. A line that is not a
+// valid command gets an error as its result, and a line longer than maxPipelineLineSize ends the pipeline with a
+// 413 error, as the status of the response if no result was sent yet, or as the last result otherwise.
+func (h *BatchHandler) streamPipeline(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	// the results are written while the commands are still being read, which HTTP/1 servers do not allow by default
+	if err := rc.EnableFullDuplex(); err != nil {
+		h.logger.Debug("failed to enable full duplex for the pipeline", "error", err)
+	}
+
+	asking := r.Header.Get(slots.AskingHeader) != ""
+	reader := bufio.NewReader(r.Body)
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	started := false // whether the status of the response was sent
+	for {
+		line, err := readLine(reader, maxPipelineLineSize)
+		if errors.Is(err, errLineTooLong) {
+			e := errPipelineLineTooLong()
+			if !started {
+				wrapError(w, e)
+				return
+			}
+			if writeErr := encoder.Encode(schemas.KeyResult{Error: e}); writeErr != nil {
+				h.logger.Debug("failed to write pipeline result", "error", writeErr)
+				return
+			}
+			if flushErr := writer.Flush(); flushErr != nil {
+				h.logger.Debug("failed to send pipeline results", "error", flushErr)
+			}
+			return
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			h.logger.Debug("failed to read pipeline", "error", err)
+			return
+		}
+		if !started {
+			w.Header().Set("Content-Type", ndjsonContentType)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var result schemas.KeyResult
+			var cmd schemas.PipelineCommand
+			if decodeErr := json.Unmarshal(line, &cmd); decodeErr != nil {
+				e := *apierrors.ErrInvalidJSON
+				e.Message = fmt.Sprintf("failed to decode JSON: %v", decodeErr)
+				result.Error = &e
+			} else {
+				result = h.run(r, cmd, asking)
+			}
+			if writeErr := encoder.Encode(result); writeErr != nil {
+				h.logger.Debug("failed to write pipeline result", "error", writeErr)
+				return
+			}
+		}
+
+		if errors.Is(err, io.EOF) || reader.Buffered() == 0 {
+			if flushErr := writer.Flush(); flushErr != nil {
+				h.logger.Debug("failed to send pipeline results", "error", flushErr)
+				return
+			}
+			_ = rc.Flush()
+		}
+		if errors.Is(err, io.EOF) {
+			return
+		}
+	}
+}
+
+// readLine reads the next line of the reader, including its "\n", or the rest of the reader at its end along with
+// io.EOF. It returns errLineTooLong once the line is longer than limit bytes, without reading the rest of it.
+func readLine(reader *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(line)+len(chunk) > limit {
+			return nil, errLineTooLong
+		}
+		line = append(line, chunk...)
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return line, err
+		}
+	}
+}
+
+// errPipelineLineTooLong returns the error of a command of a pipeline streamed as NDJSON longer than
+// maxPipelineLineSize.
+func errPipelineLineTooLong() *apierrors.ApiError {
+	e := *apierrors.ErrRequestTooLarge
+	e.Message = fmt.Sprintf("the commands of a pipeline cannot be longer than %d bytes", maxPipelineLineSize)
+	e.SysMessage = e.Message
+	return &e
+}
+
+// run runs a command of a pipeline sent with the request and returns its result.
+func (h *BatchHandler) run(r *http.Request, cmd schemas.PipelineCommand, asking bool) schemas.KeyResult {
+	if err := validator.ValidateJSON(&cmd); err != nil {
+		return schemas.KeyResult{Error: invalidRequest(err.Error())}
+	}
+
+	permission := enums.PermissionWrite
+	if cmd.Op == "get" {
+		permission = enums.PermissionRead
+	}
+	if e := authorize(r, permission, cmd.Key); e != nil {
+		return schemas.KeyResult{Error: e}
+	}
+
+	var opts []db.ItemOptions
+	if cmd.TTL != nil {
+		opts = append(opts, db.WithTTL(cmd.TTL.Duration))
+	}
+
+	database := keyspace(r, h.db)
+	switch cmd.Op {
+	case "get":
+		return h.get(database, cmd.Key, asking)
+	case "set":
+		return h.set(database, cmd.Key, cmd.Value.Val, opts, asking)
+	case "remove":
+		return h.remove(database, cmd.Key, asking)
+	}
+
+	release, e := h.route(cmd.Key, asking)
+	if e != nil {
+		return schemas.KeyResult{Error: e}
+	}
+	defer release()
+
+	var item *db.Item
+	var err error
+	switch cmd.Op {
+	case "update":
+		err = database.Update(cmd.Key, cmd.Value.Val, opts...)
+	case "push":
+		item, err = database.Push(cmd.Key, cmd.Value.Val.(string), opts...)
+	case "pop":
+		item, err = database.Pop(cmd.Key)
+	}
+	if err != nil {
+		return schemas.KeyResult{Error: h.writeError(database, cmd.Key, err)}
+	}
+	if item == nil {
+		return schemas.KeyResult{}
+	}
+	return itemResult(cmd.Key, item)
+}
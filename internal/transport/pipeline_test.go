@@ -0,0 +1,183 @@
+package transport_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PipelineSuite struct {
+	db     db.DBClient
+	server *httptest.Server
+	suite.Suite
+}
+
+func (s *PipelineSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db).Handler())
+}
+
+func (s *PipelineSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+}
+
+func (s *PipelineSuite) TestJSON() {
+	body := `{"commands": [
+		{"op": "set", "key": "a", "value": "1", "ttl": "1m"},
+		{"op": "get", "key": "a"},
+		{"op": "set", "key": "list", "value": ["x"]},
+		{"op": "push", "key": "list", "value": "y"},
+		{"op": "pop", "key": "list"},
+		{"op": "update", "key": "missing", "value": "2"},
+		{"op": "remove", "key": "a"},
+		{"op": "get", "key": "a"},
+		{"op": "set", "key": "b"},
+		{"op": "unknown", "key": "b"}
+	]}`
+	resp, err := http.Post(s.server.URL+"/api/v1/pipeline", "application/json", strings.NewReader(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var response schemas.PipelineResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	s.Require().Len(response.Results, 10)
+
+	s.Equal(schemas.KeyResult{}, response.Results[0])
+	s.Require().NotNil(response.Results[1].Item, "the commands should run in order")
+	s.Equal("1", response.Results[1].Item.Value)
+	s.Require().NotNil(response.Results[3].Item)
+	s.Equal([]any{"x", "y"}, response.Results[3].Item.Value)
+	s.Require().NotNil(response.Results[4].Item)
+	s.Require().NotNil(response.Results[5].Error, "a failed command should not stop the next ones")
+	s.Equal(apierrors.ErrItemNotFound.Code, response.Results[5].Error.Code)
+	s.Nil(response.Results[6].Error)
+	s.Require().NotNil(response.Results[7].Error)
+	s.Equal(apierrors.ErrItemNotFound.Code, response.Results[7].Error.Code)
+	s.Require().NotNil(response.Results[8].Error)
+	s.Equal(apierrors.ErrInvalidRequest.Code, response.Results[8].Error.Code, "set should require a value")
+	s.Require().NotNil(response.Results[9].Error)
+	s.Equal(apierrors.ErrInvalidRequest.Code, response.Results[9].Error.Code)
+}
+
+func (s *PipelineSuite) TestNDJSON() {
+	body := `{"op": "set", "key": "a", "value": "1"}
+not json
+
+{"op": "get", "key": "a"}`
+	resp, err := http.Post(s.server.URL+"/api/v1/pipeline", "application/x-ndjson", strings.NewReader(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	var results []schemas.KeyResult
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var result schemas.KeyResult
+		s.Require().NoError(decoder.Decode(&result))
+		results = append(results, result)
+	}
+	s.Require().Len(results, 3, "the empty lines should be skipped")
+	s.Nil(results[0].Error)
+	s.Require().NotNil(results[1].Error)
+	s.Equal(apierrors.ErrInvalidJSON.Code, results[1].Error.Code, "an invalid line should not stop the pipeline")
+	s.Require().NotNil(results[2].Item)
+	s.Equal("1", results[2].Item.Value)
+}
+
+func (s *PipelineSuite) TestNDJSONLineTooLong() {
+	long := `{"op": "set", "key": "a", "value": "` + strings.Repeat("x", 1024*1024) + `"}`
+
+	// before the first result, the status of the response is the error
+	resp, err := http.Post(s.server.URL+"/api/v1/pipeline", "application/x-ndjson", strings.NewReader(long+"\n"))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal(http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// after it, the error is the last result
+	body := `{"op": "set", "key": "b", "value": "1"}` + "\n" + long + "\n" + `{"op": "get", "key": "b"}`
+	resp, err = http.Post(s.server.URL+"/api/v1/pipeline", "application/x-ndjson", strings.NewReader(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var results []schemas.KeyResult
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var result schemas.KeyResult
+		s.Require().NoError(decoder.Decode(&result))
+		results = append(results, result)
+	}
+	s.Require().Len(results, 2, "the pipeline should stop at the line too long")
+	s.Nil(results[0].Error)
+	s.Require().NotNil(results[1].Error)
+	s.Equal(apierrors.ErrRequestTooLarge.Code, results[1].Error.Code)
+}
+
+func (s *PipelineSuite) TestStreaming() {
+	// the results are sent while the request is still being written
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	req, err := http.NewRequest(http.MethodPost, s.server.URL+"/api/v1/pipeline", reader)
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	type response struct {
+		resp *http.Response
+		err  error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		responses <- response{resp, err}
+	}()
+
+	_, err = fmt.Fprintln(writer, `{"op": "set", "key": "a", "value": "1"}`)
+	s.Require().NoError(err)
+	var resp *http.Response
+	select {
+	case r := <-responses:
+		s.Require().NoError(r.err)
+		resp = r.resp
+	case <-time.After(5 * time.Second):
+		s.FailNow("the response should start before the end of the request")
+	}
+	defer resp.Body.Close()
+	lines := bufio.NewReader(resp.Body)
+
+	line, err := lines.ReadString('\n')
+	s.Require().NoError(err)
+	s.JSONEq(`{}`, line)
+
+	_, err = fmt.Fprintln(writer, `{"op": "get", "key": "a"}`)
+	s.Require().NoError(err)
+	line, err = lines.ReadString('\n')
+	s.Require().NoError(err)
+	var result schemas.KeyResult
+	s.Require().NoError(json.Unmarshal([]byte(line), &result))
+	s.Require().NotNil(result.Item)
+	s.Equal("1", result.Item.Value)
+
+	s.Require().NoError(writer.Close())
+	_, err = lines.ReadString('\n')
+	s.ErrorIs(err, io.EOF)
+}
+
+func TestPipelineSuite(t *testing.T) {
+	suite.Run(t, new(PipelineSuite))
+}
@@ -0,0 +1,619 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/proxy"
+	"memorydb/internal/transport/schemas"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// backendKey is the context key of the URL of the backend a request is forwarded to.
+type backendKey struct{}
+
+type ProxyHandler struct {
+	logger  *slog.Logger
+	pool    *proxy.Pool
+	client  *http.Client           // client of the requests fanned out to every backend
+	forward *httputil.ReverseProxy // forwards the requests of a single key to its backend
+}
+
+// NewProxyHandler creates a new handler that serves the API by forwarding the requests to the backends of the pool.
+func NewProxyHandler(logger *slog.Logger, pool *proxy.Pool) *ProxyHandler {
+	h := &ProxyHandler{
+		logger: logger,
+		pool:   pool,
+		client: &http.Client{}, // no timeout, the event and message streams are long-lived
+	}
+	h.forward = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(pr.In.Context().Value(backendKey{}).(*url.URL))
+			pr.SetXForwarded()
+		},
+		FlushInterval: -1, // stream reads block and must be flushed immediately
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			h.logger.Error("failed to forward request to backend", "backend", r.Context().Value(backendKey{}), "error", err)
+			wrapError(w, backendError(err))
+		},
+	}
+	return h
+}
+
+// backendError converts the error of a request to a backend into an API error.
+func backendError(err error) error {
+	if errors.Is(err, proxy.ErrNoBackends) {
+		e := *apierrors.ErrNoBackends
+		e.SysMessage = err.Error()
+		return &e
+	}
+	e := *apierrors.ErrBackendUnavailable
+	e.Message = "the backend of the request is not available"
+	e.SysMessage = err.Error()
+	return &e
+}
+
+// HandleKey forwards the request to the backend of the key returned by keyFunc. Requests without a key are
+// forwarded to any backend, which rejects them with the same error as a single server.
+func (h *ProxyHandler) HandleKey(keyFunc func(*http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var backend string
+		var err error
+		if key := keyFunc(r); key != "" {
+			backend, err = h.pool.Pick(key)
+		} else {
+			var backends []string
+			backends, err = h.pool.Healthy()
+			if err == nil {
+				backend = backends[0]
+			}
+		}
+		if err != nil {
+			wrapError(w, backendError(err))
+			return
+		}
+
+		target, err := url.Parse(backend)
+		if err != nil {
+			wrapError(w, fmt.Errorf("invalid backend URL %s: %w", backend, err))
+			return
+		}
+		h.forward.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), backendKey{}, target)))
+	}
+}
+
+// HandleEvents streams the keyspace events of every backend as Server-Sent Events.
+//
+// Every backend numbers its own events, so the stream cannot be resumed from an event ID, and the events
+// are sent without one. The events of different backends are not ordered.
+func (h *ProxyHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "the proxy only streams events as Server-Sent Events"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+	if lastEventID != 0 {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "the proxy cannot resume the events from an event ID"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+
+	backends, err := h.pool.Healthy()
+	if err != nil {
+		wrapError(w, backendError(err))
+		return
+	}
+
+	query := url.Values{"match": r.URL.Query()["match"]}
+	urls := make([]string, len(backends))
+	for i, backend := range backends {
+		urls[i] = backend + r.URL.Path + "?" + query.Encode()
+	}
+	h.streamMerged(w, r, urls)
+}
+
+// HandleSubscribe streams the messages of the channels and patterns as Server-Sent Events. Every channel is
+// subscribed in its backend, where it is published, and the patterns are subscribed in every backend.
+func (h *ProxyHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	channels := r.URL.Query()["channel"]
+	patterns := r.URL.Query()["pattern"]
+	if len(channels) == 0 && len(patterns) == 0 {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "at least one 'channel' or 'pattern' query parameter is required"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+
+	queries := make(map[string]url.Values)
+	for _, channel := range channels {
+		backend, err := h.pool.Pick(channel)
+		if err != nil {
+			wrapError(w, backendError(err))
+			return
+		}
+		if queries[backend] == nil {
+			queries[backend] = url.Values{}
+		}
+		queries[backend].Add("channel", channel)
+	}
+	if len(patterns) > 0 {
+		backends, err := h.pool.Healthy()
+		if err != nil {
+			wrapError(w, backendError(err))
+			return
+		}
+		for _, backend := range backends {
+			if queries[backend] == nil {
+				queries[backend] = url.Values{}
+			}
+			queries[backend]["pattern"] = patterns
+		}
+	}
+
+	var urls []string
+	for backend, query := range queries {
+		urls = append(urls, backend+r.URL.Path+"?"+query.Encode())
+	}
+	h.streamMerged(w, r, urls)
+}
+
+// HandleChannels returns the active pub/sub channels of every backend. The subscribers of every channel
+// and the connected subscribers are added up.
+func (h *ProxyHandler) HandleChannels(w http.ResponseWriter, r *http.Request) {
+	backends, err := h.pool.Healthy()
+	if err != nil {
+		wrapError(w, backendError(err))
+		return
+	}
+
+	responses := make([]schemas.PubSubChannelsResponse, len(backends))
+	errs := make([]error, len(backends))
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = h.getJSON(r.Context(), backend+r.URL.RequestURI(), &responses[i])
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		h.logger.Error("failed to get pub/sub channels of the backends", "error", err)
+		wrapError(w, backendError(err))
+		return
+	}
+
+	merged := schemas.PubSubChannelsResponse{Channels: make(map[string]int), Patterns: []string{}}
+	for _, response := range responses {
+		for channel, subscribers := range response.Channels {
+			merged.Channels[channel] += subscribers
+		}
+		merged.Patterns = append(merged.Patterns, response.Patterns...)
+		merged.Subscribers += response.Subscribers
+	}
+	slices.Sort(merged.Patterns)
+	merged.Patterns = slices.Compact(merged.Patterns)
+	writeJSON(w, http.StatusOK, merged)
+}
+
+// proxyBatch is the body of a batch request, whose values are forwarded to the backends as they were sent.
+type proxyBatch struct {
+	Keys   []string                   `json:"keys,omitempty"`
+	Items  map[string]json.RawMessage `json:"items,omitempty"`
+	TTL    json.RawMessage            `json:"ttl,omitempty"`
+	Atomic bool                       `json:"atomic,omitempty"`
+}
+
+// proxyBatchResponse is the response of a batch request, whose results are forwarded as the backends sent them.
+type proxyBatchResponse struct {
+	Results map[string]json.RawMessage `json:"results"`
+}
+
+// HandleBatch splits a batch request by the backends of its keys, sends the parts concurrently and merges their
+// results. The keys of a backend that fails get its error.
+//
+// Atomic requests are forwarded as they are, so their keys must belong to the same backend.
+func (h *ProxyHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		wrapError(w, fmt.Errorf("failed to read request body: %w", err))
+		return
+	}
+	var batch proxyBatch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "invalid request body"
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+	if e := checkBatchSize(len(batch.Keys) + len(batch.Items)); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	parts := make(map[string]*proxyBatch)
+	results := make(map[string]json.RawMessage, len(batch.Keys)+len(batch.Items))
+	var pickErr error
+	add := func(key string, value json.RawMessage) {
+		backend, err := h.pool.Pick(key)
+		if err != nil {
+			results[key] = batchError(backendError(err))
+			pickErr = err
+			return
+		}
+		part, ok := parts[backend]
+		if !ok {
+			part = &proxyBatch{TTL: batch.TTL, Atomic: batch.Atomic}
+			parts[backend] = part
+		}
+		if value == nil {
+			part.Keys = append(part.Keys, key)
+			return
+		}
+		if part.Items == nil {
+			part.Items = make(map[string]json.RawMessage)
+		}
+		part.Items[key] = value
+	}
+	for _, key := range batch.Keys {
+		add(key, nil)
+	}
+	for key, value := range batch.Items {
+		add(key, value)
+	}
+
+	if batch.Atomic {
+		if pickErr != nil {
+			wrapError(w, backendError(pickErr))
+			return
+		}
+		h.forwardAtomic(w, r, body, parts)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for backend, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var response proxyBatchResponse
+			err := h.postJSON(r.Context(), backend+r.URL.Path, part, &response)
+			if err != nil {
+				h.logger.Error("failed to send batch request to backend", "backend", backend, "error", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, key := range part.Keys {
+				results[key] = batchResult(response, key, err)
+			}
+			for key := range part.Items {
+				results[key] = batchResult(response, key, err)
+			}
+		}()
+	}
+	wg.Wait()
+	writeJSON(w, http.StatusOK, proxyBatchResponse{Results: results})
+}
+
+// forwardAtomic forwards an atomic batch request to the single backend of its keys.
+func (h *ProxyHandler) forwardAtomic(w http.ResponseWriter, r *http.Request, body []byte, parts map[string]*proxyBatch) {
+	backends := slices.Collect(maps.Keys(parts))
+	if len(backends) > 1 {
+		e := *apierrors.ErrCrossShard
+		e.Message = "the keys of an atomic request must belong to the same backend"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+
+	target, err := url.Parse(backends[0])
+	if err != nil {
+		wrapError(w, fmt.Errorf("invalid backend URL %s: %w", backends[0], err))
+		return
+	}
+	// the body was read to find the keys, so it is restored before forwarding the request
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	h.forward.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), backendKey{}, target)))
+}
+
+// proxyPipeline is the body of a pipeline, whose commands are forwarded to the backends as they were sent.
+type proxyPipeline struct {
+	Commands []json.RawMessage `json:"commands"`
+}
+
+// proxyPipelineResponse is the response of a pipeline, whose results are forwarded as the backends sent them.
+type proxyPipelineResponse struct {
+	Results []json.RawMessage `json:"results"`
+}
+
+// HandlePipeline splits a pipeline by the backends of the keys of its commands, keeping their order, sends the parts
+// concurrently and returns the results in the order of the commands. The commands of a backend that fails get its error.
+//
+// Unlike a single server, the proxy reads the whole pipeline before it runs, including the ones streamed as NDJSON,
+// so the pipelines are limited to maxPipelineCommands commands.
+func (h *ProxyHandler) HandlePipeline(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	ndjson := mediaType == ndjsonContentType
+
+	var pipeline proxyPipeline
+	if ndjson {
+		reader := bufio.NewReader(r.Body)
+		for len(pipeline.Commands) <= maxPipelineCommands {
+			line, err := readLine(reader, maxPipelineLineSize)
+			if errors.Is(err, errLineTooLong) {
+				wrapError(w, errPipelineLineTooLong())
+				return
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				wrapError(w, fmt.Errorf("failed to read pipeline: %w", err))
+				return
+			}
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				pipeline.Commands = append(pipeline.Commands, line)
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+		}
+	} else if err := decodeJSON(r.Body, &pipeline); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if len(pipeline.Commands) > maxPipelineCommands {
+		wrapError(w, invalidRequest(fmt.Sprintf("the proxy cannot run pipelines of more than %d commands", maxPipelineCommands)))
+		return
+	}
+
+	results := make([]json.RawMessage, len(pipeline.Commands))
+	parts := make(map[string][]int) // indexes of the commands of every backend
+	for i, command := range pipeline.Commands {
+		var keyed struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(command, &keyed); err != nil {
+			e := *apierrors.ErrInvalidJSON
+			e.Message = fmt.Sprintf("failed to decode JSON: %v", err)
+			results[i] = batchError(&e)
+			continue
+		}
+		backend, err := h.pool.Pick(keyed.Key)
+		if err != nil {
+			results[i] = batchError(backendError(err))
+			continue
+		}
+		parts[backend] = append(parts[backend], i)
+	}
+
+	var wg sync.WaitGroup
+	for backend, indexes := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			part := proxyPipeline{Commands: make([]json.RawMessage, len(indexes))}
+			for j, i := range indexes {
+				part.Commands[j] = pipeline.Commands[i]
+			}
+
+			var response proxyPipelineResponse
+			err := h.postJSON(r.Context(), backend+r.URL.Path, part, &response)
+			if err == nil && len(response.Results) != len(indexes) {
+				err = fmt.Errorf("the backend returned %d results for %d commands", len(response.Results), len(indexes))
+			}
+			if err != nil {
+				h.logger.Error("failed to send pipeline to backend", "backend", backend, "error", err)
+			}
+			// every command has its own slot of the results, so they are written without a lock
+			for j, i := range indexes {
+				if err != nil {
+					results[i] = batchError(backendError(err))
+				} else {
+					results[i] = response.Results[j]
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !ndjson {
+		writeJSON(w, http.StatusOK, proxyPipelineResponse{Results: results})
+		return
+	}
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+	writer := bufio.NewWriter(w)
+	for _, result := range results {
+		writer.Write(result)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		h.logger.Debug("failed to send pipeline results", "error", err)
+	}
+}
+
+// batchResult returns the result of the key in the response of its backend, or the error of the backend.
+func batchResult(response proxyBatchResponse, key string, err error) json.RawMessage {
+	if err != nil {
+		return batchError(backendError(err))
+	}
+	result, ok := response.Results[key]
+	if !ok {
+		return batchError(backendError(fmt.Errorf("the backend did not return the result of key %s", key)))
+	}
+	return result
+}
+
+// batchError encodes an API error as the result of a key.
+func batchError(err error) json.RawMessage {
+	e := *err.(*apierrors.ApiError)
+	result, _ := json.Marshal(schemas.KeyResult{Error: &e})
+	return result
+}
+
+// postJSON sends the JSON body in a POST request to a backend and decodes its JSON response.
+func (h *ProxyHandler) postJSON(ctx context.Context, target string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request for %s: %w", target, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", target, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d from %s", resp.StatusCode, target)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", target, err)
+	}
+	return nil
+}
+
+// getJSON decodes the JSON response of a GET request to a backend.
+func (h *ProxyHandler) getJSON(ctx context.Context, target string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", target, err)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d from %s", resp.StatusCode, target)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", target, err)
+	}
+	return nil
+}
+
+// streamMerged opens a Server-Sent Events stream in every URL and sends their events to the client
+// as a single stream. The stream ends when any of the backend streams ends, so the client reconnects
+// to all of them.
+func (h *ProxyHandler) streamMerged(w http.ResponseWriter, r *http.Request, urls []string) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// open every stream before answering, so the errors of the backends are returned to the client
+	var bodies []io.ReadCloser
+	defer func() {
+		for _, body := range bodies {
+			body.Close()
+		}
+	}()
+	for _, target := range urls {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			wrapError(w, fmt.Errorf("failed to create request for %s: %w", target, err))
+			return
+		}
+		resp, err := h.client.Do(req)
+		if err != nil {
+			h.logger.Error("failed to open stream in backend", "url", target, "error", err)
+			wrapError(w, backendError(err))
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			defer resp.Body.Close()
+			w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+			w.WriteHeader(resp.StatusCode)
+			_, _ = io.Copy(w, resp.Body)
+			return
+		}
+		bodies = append(bodies, resp.Body)
+	}
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	events := make(chan sseEvent)
+	for _, body := range bodies {
+		go func() {
+			defer cancel()
+			reader := newEventReader(body)
+			for {
+				event, err := reader.next()
+				if err != nil {
+					return
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-events:
+			if err := writeSSE(w, flusher, "", event.name, json.RawMessage(event.data)); err != nil {
+				h.logger.Debug("failed to write proxied event", "error", err)
+				return
+			}
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HandleBackends returns the health of every backend.
+func (h *ProxyHandler) HandleBackends(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.pool.Status())
+}
+
+// HandleHealth answers with 200 while at least one backend is in rotation.
+func (h *ProxyHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.pool.Healthy(); err != nil {
+		wrapError(w, backendError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
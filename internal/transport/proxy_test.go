@@ -0,0 +1,354 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/proxy"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProxySuite struct {
+	dbs      map[string]db.DBClient // database of every backend, by URL
+	backends []*httptest.Server
+	servers  []*transport.Server
+	pool     *proxy.Pool
+	proxy    *httptest.Server
+	suite.Suite
+}
+
+func (s *ProxySuite) SetupTest() {
+	s.dbs = make(map[string]db.DBClient)
+	s.backends, s.servers = nil, nil
+
+	var backends []proxy.Backend
+	for range 3 {
+		database := db.NewMemoryDB(slog.Default())
+		server := transport.NewServer(slog.Default(), 0, 0, database)
+
+		mux := http.NewServeMux()
+		mux.Handle("/", server.Handler())
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+		backend := httptest.NewServer(mux)
+
+		s.dbs[backend.URL] = database
+		s.servers = append(s.servers, server)
+		s.backends = append(s.backends, backend)
+		backends = append(backends, proxy.Backend{URL: backend.URL, HealthURL: backend.URL + "/health"})
+	}
+
+	s.pool = proxy.NewPool(slog.Default(), backends, proxy.WithHealthInterval(10*time.Millisecond), proxy.WithFailureThreshold(1))
+	s.proxy = httptest.NewServer(transport.NewProxyServer(slog.Default(), 0, 0, s.pool).Handler())
+}
+
+func (s *ProxySuite) TearDownTest() {
+	s.pool.Stop()
+	s.proxy.Close()
+	for _, server := range s.servers {
+		_ = server.Shutdown() // closes the pub/sub subscribers, so the backends can be closed
+	}
+	for _, backend := range s.backends {
+		backend.Close()
+	}
+	for _, database := range s.dbs {
+		database.Close()
+	}
+}
+
+// do sends a request with a JSON body to the proxy and returns the response.
+func (s *ProxySuite) do(method, path, body string) *http.Response {
+	req, err := http.NewRequest(method, s.proxy.URL+path, strings.NewReader(body))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	return resp
+}
+
+// nextEvent reads the next Server-Sent Event of the stream and returns its name and data.
+func (s *ProxySuite) nextEvent(reader *bufio.Reader) (string, string) {
+	var name, data string
+	for {
+		line, err := reader.ReadString('\n')
+		s.Require().NoError(err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "id:"):
+			s.Fail("the proxy should not send event IDs")
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func (s *ProxySuite) TestKeys() {
+	for i := range 50 {
+		key := fmt.Sprintf("key:%d", i)
+		resp := s.do(http.MethodPost, "/api/v1/set", fmt.Sprintf(`{"key": %q, "value": "value"}`, key))
+		resp.Body.Close()
+		s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+		// the key is only stored in its backend
+		owner, err := s.pool.Pick(key)
+		s.Require().NoError(err)
+		for url, database := range s.dbs {
+			_, err := database.Get(key)
+			if url == owner {
+				s.NoError(err, "key %s should be stored in %s", key, url)
+			} else {
+				s.Error(err, "key %s should not be stored in %s", key, url)
+			}
+		}
+
+		resp = s.do(http.MethodGet, "/api/v1/"+key, "")
+		var row schemas.RowResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&row))
+		resp.Body.Close()
+		s.Equal("value", row.Value)
+	}
+
+	// the errors of the backends are returned as they are
+	resp := s.do(http.MethodGet, "/api/v1/missing", "")
+	defer resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func (s *ProxySuite) TestBatch() {
+	items := make(map[string]string)
+	var keys []string
+	for i := range 30 {
+		key := fmt.Sprintf("key:%d", i)
+		items[key] = "value"
+		keys = append(keys, key)
+	}
+	body, err := json.Marshal(map[string]any{"items": items})
+	s.Require().NoError(err)
+	resp := s.do(http.MethodPost, "/api/v1/mset", string(body))
+	var response schemas.BatchResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	resp.Body.Close()
+	s.Require().Len(response.Results, 30)
+
+	// every key is stored in its backend
+	for _, key := range keys {
+		s.Nil(response.Results[key].Error)
+		owner, err := s.pool.Pick(key)
+		s.Require().NoError(err)
+		_, err = s.dbs[owner].Get(key)
+		s.NoError(err, "key %s should be stored in %s", key, owner)
+	}
+
+	body, err = json.Marshal(map[string]any{"keys": append(keys, "missing")})
+	s.Require().NoError(err)
+	resp = s.do(http.MethodPost, "/api/v1/mget", string(body))
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	resp.Body.Close()
+	s.Require().Len(response.Results, 31)
+	s.Require().NotNil(response.Results["key:0"].Item)
+	s.Equal("value", response.Results["key:0"].Item.Value)
+	s.Require().NotNil(response.Results["missing"].Error)
+	s.Equal(apierrors.ErrItemNotFound.Code, response.Results["missing"].Error.Code)
+
+	resp = s.do(http.MethodPost, "/api/v1/mdel", string(body))
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	resp.Body.Close()
+	s.Nil(response.Results["key:0"].Error)
+	s.NotNil(response.Results["missing"].Error)
+
+	// the keys of an atomic batch must belong to the same backend
+	resp = s.do(http.MethodPost, "/api/v1/mset", `{"items": {"key:0": "value"}, "atomic": true}`)
+	resp.Body.Close()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	body, err = json.Marshal(map[string]any{"items": items, "atomic": true})
+	s.Require().NoError(err)
+	resp = s.do(http.MethodPost, "/api/v1/mset", string(body))
+	defer resp.Body.Close()
+	s.Equal(http.StatusBadRequest, resp.StatusCode)
+	var errResponse apierrors.ApiError
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+	s.Equal(apierrors.ErrCrossShard.Code, errResponse.Code)
+}
+
+func (s *ProxySuite) TestPipeline() {
+	var commands []string
+	for i := range 20 {
+		commands = append(commands, fmt.Sprintf(`{"op": "set", "key": "key:%d", "value": "%d"}`, i, i))
+		commands = append(commands, fmt.Sprintf(`{"op": "get", "key": "key:%d"}`, i))
+	}
+	resp := s.do(http.MethodPost, "/api/v1/pipeline", `{"commands": [`+strings.Join(commands, ",")+`]}`)
+	var response schemas.PipelineResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+	resp.Body.Close()
+
+	// the results are in the order of the commands, whatever their backend
+	s.Require().Len(response.Results, 40)
+	for i := range 20 {
+		s.Nil(response.Results[2*i].Error)
+		s.Require().NotNil(response.Results[2*i+1].Item)
+		s.Equal(fmt.Sprintf("key:%d", i), response.Results[2*i+1].Item.Key)
+		s.Equal(fmt.Sprint(i), response.Results[2*i+1].Item.Value)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.proxy.URL+"/api/v1/pipeline", strings.NewReader(strings.Join(commands[:4], "\n")))
+	s.Require().NoError(err)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err = http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+	decoder := json.NewDecoder(resp.Body)
+	var results []schemas.KeyResult
+	for decoder.More() {
+		var result schemas.KeyResult
+		s.Require().NoError(decoder.Decode(&result))
+		results = append(results, result)
+	}
+	s.Require().Len(results, 4)
+	s.Require().NotNil(results[3].Item)
+	s.Equal("1", results[3].Item.Value)
+}
+
+func (s *ProxySuite) TestStreams() {
+	resp := s.do(http.MethodPost, "/api/v1/streams/orders", `{"fields": {"item": "book"}}`)
+	resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	owner, err := s.pool.Pick("orders")
+	s.Require().NoError(err)
+	entries, err := s.dbs[owner].StreamRange("orders", "-", "+", 0)
+	s.Require().NoError(err)
+	s.Len(entries, 1)
+}
+
+func (s *ProxySuite) TestEvents() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.proxy.URL+"/api/v1/events?match=key:*", nil)
+	s.Require().NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	// the events of every backend are merged in the stream
+	keys := make(map[string]bool)
+	for i := range 10 {
+		key := fmt.Sprintf("key:%d", i)
+		keys[key] = true
+		r := s.do(http.MethodPost, "/api/v1/set", fmt.Sprintf(`{"key": %q, "value": "value"}`, key))
+		r.Body.Close()
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for range len(keys) {
+		name, data := s.nextEvent(reader)
+		s.Equal("set", name)
+		var event schemas.EventResponse
+		s.Require().NoError(json.Unmarshal([]byte(data), &event))
+		s.True(keys[event.Key], "unexpected event for key %s", event.Key)
+		delete(keys, event.Key)
+	}
+	s.Empty(keys)
+
+	// the stream cannot be resumed, since every backend numbers its own events
+	r := s.do(http.MethodGet, "/api/v1/events?last_event_id=3", "")
+	defer r.Body.Close()
+	s.Equal(http.StatusBadRequest, r.StatusCode)
+}
+
+func (s *ProxySuite) TestPubSub() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.proxy.URL+"/api/v1/subscribe?channel=news&pattern=sports.*", nil)
+	s.Require().NoError(err)
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	// the channel is subscribed in its backend, and the pattern in every backend
+	r := s.do(http.MethodGet, "/api/v1/pubsub/channels", "")
+	var channels schemas.PubSubChannelsResponse
+	s.Require().NoError(json.NewDecoder(r.Body).Decode(&channels))
+	r.Body.Close()
+	s.Equal(map[string]int{"news": 1}, channels.Channels)
+	s.Equal([]string{"sports.*"}, channels.Patterns)
+	s.Equal(3, channels.Subscribers)
+
+	reader := bufio.NewReader(resp.Body)
+	for _, channel := range []string{"news", "sports.tennis", "sports.golf"} {
+		r := s.do(http.MethodPost, "/api/v1/publish", fmt.Sprintf(`{"channel": %q, "message": "hello"}`, channel))
+		var published schemas.PublishResponse
+		s.Require().NoError(json.NewDecoder(r.Body).Decode(&published))
+		r.Body.Close()
+		s.Equal(1, published.Receivers, "message to %s should be received", channel)
+
+		_, data := s.nextEvent(reader)
+		var message schemas.MessageResponse
+		s.Require().NoError(json.Unmarshal([]byte(data), &message))
+		s.Equal(channel, message.Channel)
+		s.Equal("hello", message.Payload)
+	}
+}
+
+func (s *ProxySuite) TestFailedBackend() {
+	s.pool.Start(context.Background())
+
+	// the keys of a failed backend are served by the others
+	failed := s.backends[0]
+	failed.Close()
+	s.Eventually(func() bool {
+		backends, err := s.pool.Healthy()
+		return err == nil && len(backends) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	for i := range 20 {
+		key := fmt.Sprintf("key:%d", i)
+		resp := s.do(http.MethodPost, "/api/v1/set", fmt.Sprintf(`{"key": %q, "value": "value"}`, key))
+		resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode)
+	}
+
+	// without backends, the proxy is unavailable
+	for _, backend := range s.backends[1:] {
+		backend.Close()
+	}
+	s.Eventually(func() bool {
+		_, err := s.pool.Healthy()
+		return err != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	resp := s.do(http.MethodPost, "/api/v1/set", `{"key": "key", "value": "value"}`)
+	defer resp.Body.Close()
+	s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	var errResponse apierrors.ApiError
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+	s.Equal(apierrors.ErrNoBackends.Code, errResponse.Code)
+}
+
+func (s *ProxySuite) TestUnknownRoutes() {
+	// the administration endpoints belong to every backend and are not proxied
+	resp := s.do(http.MethodPost, "/api/v1/admin/promote", "")
+	defer resp.Body.Close()
+	s.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func TestProxySuite(t *testing.T) {
+	suite.Run(t, new(ProxySuite))
+}
@@ -0,0 +1,121 @@
+package transport
+
+import (
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/enums"
+	"memorydb/internal/pubsub"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type PubSubHandler struct {
+	logger *slog.Logger
+	broker *pubsub.Broker
+}
+
+// NewPubSubHandler creates a new handler for the publish/subscribe endpoints.
+func NewPubSubHandler(logger *slog.Logger, broker *pubsub.Broker) *PubSubHandler {
+	return &PubSubHandler{logger: logger, broker: broker}
+}
+
+// HandlePublish publishes a message to a channel and returns the number of subscribers that received it.
+func (h *PubSubHandler) HandlePublish(w http.ResponseWriter, r *http.Request) {
+	var body schemas.PublishRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+	if e := authorize(r, enums.PermissionWrite, body.Channel); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	receivers := h.broker.Publish(body.Channel, body.Message)
+	writeJSON(w, http.StatusOK, schemas.PublishResponse{Receivers: receivers})
+}
+
+// HandleSubscribe streams the messages of the channels and patterns given in the `channel` and `pattern`
+// query parameters as Server-Sent Events. Both parameters can be repeated.
+//
+// Delivery is at-most-once: the stream ends if the subscriber does not keep up with the messages.
+func (h *PubSubHandler) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	channels := r.URL.Query()["channel"]
+	patterns := r.URL.Query()["pattern"]
+	if len(channels) == 0 && len(patterns) == 0 {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "at least one 'channel' or 'pattern' query parameter is required"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+	if e := authorize(r, enums.PermissionRead, channels...); e != nil {
+		wrapError(w, e)
+		return
+	}
+	if e := authorizePattern(r, enums.PermissionRead, patterns...); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	sub := h.broker.Subscribe(channels, patterns)
+	defer sub.Close()
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.Messages():
+			if !ok {
+				return
+			}
+			response := schemas.MessageResponse{
+				Channel: msg.Channel,
+				Pattern: msg.Pattern,
+				Payload: msg.Payload,
+				Time:    msg.Time,
+			}
+			if err := writeSSE(w, flusher, "", "message", response); err != nil {
+				h.logger.Debug("failed to write pubsub message", "error", err)
+				return
+			}
+		case <-keepAlive.C:
+			if err := writeSSEKeepAlive(w, flusher); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HandleChannels returns the active channels that match the glob pattern in the `match` query parameter,
+// with their number of subscribers.
+//
+// The patterns subscribed to are only listed if the principal of the request can read every channel they match.
+func (h *PubSubHandler) HandleChannels(w http.ResponseWriter, r *http.Request) {
+	match := r.URL.Query().Get("match")
+	if e := authorizePattern(r, enums.PermissionRead, match); e != nil {
+		wrapError(w, e)
+		return
+	}
+
+	patterns := slices.DeleteFunc(h.broker.Patterns(), func(pattern string) bool {
+		return authorizePattern(r, enums.PermissionRead, pattern) != nil
+	})
+
+	response := schemas.PubSubChannelsResponse{
+		Channels:    h.broker.NumSubscribers(match),
+		Patterns:    patterns,
+		Subscribers: h.broker.Stats().Subscribers,
+	}
+	writeJSON(w, http.StatusOK, response)
+}
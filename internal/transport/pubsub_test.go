@@ -0,0 +1,130 @@
+package transport_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/pubsub"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PubSubHandlerSuite struct {
+	broker  *pubsub.Broker
+	handler *transport.PubSubHandler
+	suite.Suite
+}
+
+func (s *PubSubHandlerSuite) SetupTest() {
+	s.broker = pubsub.NewBroker(slog.Default())
+	s.handler = transport.NewPubSubHandler(slog.Default(), s.broker)
+}
+
+func (s *PubSubHandlerSuite) TearDownTest() {
+	s.broker.Close()
+}
+
+func (s *PubSubHandlerSuite) TestPublish() {
+	s.Run("Publish ok", func() {
+		sub := s.broker.Subscribe([]string{"news"}, nil)
+		defer sub.Close()
+
+		body := `{"channel": "news", "message": "hello"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/publish", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		s.handler.HandlePublish(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode, "expected status code 200 OK")
+
+		var response schemas.PublishResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response), "failed to decode response")
+		s.Equal(1, response.Receivers)
+		s.Equal("hello", (<-sub.Messages()).Payload)
+	})
+
+	s.Run("Publish missing channel", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/publish", bytes.NewBufferString(`{"message": "hello"}`))
+		w := httptest.NewRecorder()
+
+		s.handler.HandlePublish(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusBadRequest, resp.StatusCode, "expected status code 400 Bad Request")
+
+		var errResponse apierrors.ApiError
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse), "failed to decode error response")
+		s.Equal(apierrors.ErrInvalidRequest.Code, errResponse.Code)
+	})
+}
+
+func (s *PubSubHandlerSuite) TestSubscribe() {
+	s.Run("Subscribe ok", func() {
+		srv := httptest.NewServer(http.HandlerFunc(s.handler.HandleSubscribe))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL + "/api/v1/subscribe?channel=news&pattern=sports.*")
+		s.Require().NoError(err, "failed to subscribe")
+		defer resp.Body.Close()
+		s.Equal(http.StatusOK, resp.StatusCode, "expected status code 200 OK")
+
+		// wait until the subscriber is registered before publishing
+		s.Eventually(func() bool { return s.broker.Stats().Subscribers == 1 }, time.Second, 10*time.Millisecond)
+		s.broker.Publish("sports.tennis", "match point")
+
+		reader := bufio.NewReader(resp.Body)
+		var data string
+		for data == "" {
+			line, err := reader.ReadString('\n')
+			s.Require().NoError(err, "failed to read stream")
+			if strings.HasPrefix(line, "data: ") {
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+
+		var msg schemas.MessageResponse
+		s.Require().NoError(json.Unmarshal([]byte(data), &msg), "failed to decode message")
+		s.Equal("sports.tennis", msg.Channel)
+		s.Equal("sports.*", msg.Pattern)
+		s.Equal("match point", msg.Payload)
+	})
+
+	s.Run("Subscribe without channels", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/subscribe", nil)
+		w := httptest.NewRecorder()
+
+		s.handler.HandleSubscribe(w, req)
+
+		s.Equal(http.StatusBadRequest, w.Result().StatusCode, "expected status code 400 Bad Request")
+	})
+}
+
+func (s *PubSubHandlerSuite) TestChannels() {
+	sub := s.broker.Subscribe([]string{"news", "sports"}, []string{"weather.*"})
+	defer sub.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pubsub/channels?match=n*", nil)
+	w := httptest.NewRecorder()
+
+	s.handler.HandleChannels(w, req)
+
+	var response schemas.PubSubChannelsResponse
+	s.Require().NoError(json.NewDecoder(w.Result().Body).Decode(&response), "failed to decode response")
+	s.Equal(map[string]int{"news": 1}, response.Channels)
+	s.Equal([]string{"weather.*"}, response.Patterns)
+	s.Equal(1, response.Subscribers)
+}
+
+func TestPubSubHandlerSuite(t *testing.T) {
+	suite.Run(t, new(PubSubHandlerSuite))
+}
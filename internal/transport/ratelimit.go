@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"math"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/certs"
+	"memorydb/internal/enums"
+	"memorydb/internal/ratelimit"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// limitRate serves the request only if its client has not exceeded the rate of the kind of request, and answers
+// 429 with the seconds until the client can send it again otherwise. Every request is served if limiter is nil.
+//
+// It must run after the authentication, so the clients that authenticate are limited by their credentials instead
+// of by their address.
+func limitRate(limiter *ratelimit.Limiter, permission enums.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientID(r)
+			if ok, wait := limiter.Allow(client, permission); !ok {
+				w.Header().Set("Retry-After", retryAfter(wait))
+				e := *apierrors.ErrRateLimited
+				e.SysMessage = "client " + client + " exceeded the " + permission.String() + " rate limit"
+				wrapError(w, &e)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitInFlight serves the request only if the server is serving fewer requests than its limit, and answers 503
+// otherwise. Every request is served if inFlight is nil.
+func limitInFlight(inFlight *ratelimit.InFlight) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if inFlight == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !inFlight.Acquire() {
+				w.Header().Set("Retry-After", "1")
+				wrapError(w, apierrors.ErrOverloaded)
+				return
+			}
+			defer inFlight.Release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientID returns the identity the request is limited by: the ID of its API key, the username of its access token,
+// the identity of its client certificate or its IP address, in the order the authentication checks them.
+func clientID(r *http.Request) string {
+	if _, ok := PrincipalFromContext(r.Context()); ok {
+		if key := r.Header.Get(apiKeyHeader); key != "" {
+			id, _, _ := strings.Cut(key, ".")
+			return "apikey:" + id
+		}
+		if claims, ok := ClaimsFromContext(r.Context()); ok {
+			return "user:" + claims.Subject
+		}
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return "cert:" + certs.Identity(r.TLS.VerifiedChains[0][0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// retryAfter returns the value of the Retry-After header of the wait, in seconds rounded up.
+func retryAfter(wait time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,128 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/ratelimit"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitSuite struct {
+	db     db.DBClient
+	now    time.Time
+	server *transport.Server
+	suite.Suite
+}
+
+func (s *RateLimitSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.now = time.Now()
+	limiter := ratelimit.NewLimiter(
+		ratelimit.Limits{Rate: 2},
+		ratelimit.Limits{Rate: 1},
+		ratelimit.WithClock(func() time.Time { return s.now }),
+	)
+	s.server = transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithRateLimiter{Limiter: limiter})
+}
+
+func (s *RateLimitSuite) TearDownTest() {
+	s.db.Close()
+}
+
+// do sends the request from the address to the handler and returns its response.
+func (s *RateLimitSuite) do(handler http.Handler, method, path, remoteAddr string, header http.Header, body string) *http.Response {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func (s *RateLimitSuite) TestRateLimit() {
+	const set = `{"key": "a", "value": "1"}`
+	resp := s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", "10.0.0.1:1234", nil, set)
+	s.Equal(http.StatusOK, resp.StatusCode)
+	resp = s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", "10.0.0.1:4321", nil, set)
+	s.Equal(http.StatusTooManyRequests, resp.StatusCode, "the clients should be limited by their IP address")
+	s.Equal("1", resp.Header.Get("Retry-After"))
+	var errResponse apierrors.ApiError
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+	s.Equal(apierrors.ErrRateLimited.Code, errResponse.Code)
+
+	// the reads and the other clients have their own limits
+	s.Equal(http.StatusOK, s.do(s.server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", nil, "").StatusCode)
+	s.Equal(http.StatusOK, s.do(s.server.Handler(), http.MethodPost, "/api/v1/mget", "10.0.0.1:1234", nil, `{"keys": ["a"]}`).StatusCode)
+	s.Equal(http.StatusTooManyRequests, s.do(s.server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", nil, "").StatusCode)
+	s.Equal(http.StatusOK, s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", "10.0.0.2:1234", nil, set).StatusCode)
+
+	s.now = s.now.Add(time.Second)
+	s.Equal(http.StatusOK, s.do(s.server.Handler(), http.MethodPost, "/api/v1/set", "10.0.0.1:1234", nil, set).StatusCode)
+
+	resp = s.do(s.server.HealthHandler(), http.MethodGet, "/ratelimit", "", nil, "")
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	var stats schemas.RateLimitStatsResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&stats))
+	s.Require().NotNil(stats.Limiter)
+	s.Nil(stats.InFlight)
+	s.Equal(uint64(1), stats.Limiter.LimitedReads)
+	s.Equal(uint64(1), stats.Limiter.LimitedWrites)
+}
+
+func (s *RateLimitSuite) TestAPIKeys() {
+	manager, err := auth.NewManager(slog.Default(), s.db, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+	limiter := ratelimit.NewLimiter(ratelimit.Limits{Rate: 1}, ratelimit.Limits{Rate: 1})
+	server := transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithAuthManager{AuthManager: manager}, transport.WithRateLimiter{Limiter: limiter})
+
+	rules := []db.ACLRule{{Pattern: "*", Permissions: []enums.Permission{enums.PermissionRead}}}
+	_, first, err := manager.CreateAPIKey("first", "", rules)
+	s.Require().NoError(err)
+	_, second, err := manager.CreateAPIKey("second", "", rules)
+	s.Require().NoError(err)
+
+	// the clients with an API key are limited by their key, even behind the same address
+	s.Equal(http.StatusNotFound, s.do(server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", withAPIKey(first), "").StatusCode)
+	s.Equal(http.StatusTooManyRequests, s.do(server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", withAPIKey(first), "").StatusCode)
+	s.Equal(http.StatusNotFound, s.do(server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", withAPIKey(second), "").StatusCode)
+}
+
+func (s *RateLimitSuite) TestInFlight() {
+	server := transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithInFlightLimit{InFlight: ratelimit.NewInFlight(0)})
+
+	resp := s.do(server.Handler(), http.MethodGet, "/api/v1/a", "10.0.0.1:1234", nil, "")
+	s.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	s.Equal("1", resp.Header.Get("Retry-After"))
+
+	// the event streams are not counted, so they are not shed
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+	events, err := http.Get(httpServer.URL + "/api/v1/events?match=a")
+	s.Require().NoError(err)
+	events.Body.Close()
+	s.Equal(http.StatusOK, events.StatusCode)
+
+	resp = s.do(server.HealthHandler(), http.MethodGet, "/ratelimit", "", nil, "")
+	var stats schemas.RateLimitStatsResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&stats))
+	s.Require().NotNil(stats.InFlight)
+	s.Equal(uint64(1), stats.InFlight.Shed)
+}
+
+func TestRateLimitSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitSuite))
+}
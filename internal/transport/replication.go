@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/replication"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ReplicationHandler struct {
+	logger  *slog.Logger
+	db      db.DBClient
+	replica *replication.Replica // replica of the server, nil if the server is a primary
+}
+
+// NewReplicationHandler creates a new handler for the replication endpoints. The replica is nil if the server is a primary.
+func NewReplicationHandler(logger *slog.Logger, db db.DBClient, replica *replication.Replica) *ReplicationHandler {
+	return &ReplicationHandler{logger: logger, db: db, replica: replica}
+}
+
+// HandleSnapshot writes a snapshot of the database, used by the replicas to do a full synchronization.
+func (h *ReplicationHandler) HandleSnapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	offset, err := h.db.WriteSnapshot(w)
+	if err != nil {
+		// the snapshot is encoded before anything is written, so the error can still be returned to the replica
+		h.logger.Error("failed to write snapshot", "error", err)
+		wrapError(w, apierrors.ErrInternalServer)
+		return
+	}
+	h.logger.Info("snapshot sent to replica", "offset", offset, "remote_addr", r.RemoteAddr)
+}
+
+// HandleStream streams as Server-Sent Events the operations logged after the offset in the `offset` query parameter.
+//
+// Every operation is sent as an `op` event with its offset as ID. On idle streams, the current offset is sent
+// as a `ping` event so the replicas can compute their lag and detect a lost primary. If the operations after
+// the offset are no longer available, the replica must do a full synchronization.
+func (h *ReplicationHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	offset, err := strconv.ParseUint(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "the offset query parameter must be a non-negative integer"
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+
+	ops, cancel, err := h.db.ReplicationFeed(offset)
+	if err != nil {
+		e := *apierrors.ErrResyncRequired
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+	defer cancel()
+
+	flusher, ok := startSSE(w)
+	if !ok {
+		return
+	}
+	h.logger.Info("replica connected", "offset", offset, "remote_addr", r.RemoteAddr)
+
+	heartbeat := time.NewTicker(replication.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			h.logger.Info("replica disconnected", "remote_addr", r.RemoteAddr)
+			return
+		case op, ok := <-ops:
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, flusher, strconv.FormatUint(op.Offset, 10), replication.EventOp, op); err != nil {
+				h.logger.Debug("failed to write replicated operation", "error", err)
+				return
+			}
+		case <-heartbeat.C:
+			ping := replication.Heartbeat{Offset: h.db.Stats().ReplicationOffset}
+			if err := writeSSE(w, flusher, "", replication.EventPing, ping); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// HandlePromote promotes the replica to primary, so it stops replicating and accepts writes.
+func (h *ReplicationHandler) HandlePromote(w http.ResponseWriter, r *http.Request) {
+	if h.replica == nil {
+		wrapError(w, apierrors.ErrNotReplica)
+		return
+	}
+
+	if _, err := h.replica.Promote(); err != nil {
+		e := *apierrors.ErrNotReplica
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.status())
+}
+
+// HandleStatus returns the replication state of the server.
+func (h *ReplicationHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.status())
+}
+
+// status returns the replication state of the server. The fields about the primary are only set on replicas.
+func (h *ReplicationHandler) status() schemas.ReplicationStatusResponse {
+	stats := h.db.Stats()
+	response := schemas.ReplicationStatusResponse{
+		Role:     replication.RolePrimary,
+		Offset:   stats.ReplicationOffset,
+		Replicas: stats.Replicas,
+		ReadOnly: stats.ReadOnly,
+	}
+	if h.replica == nil {
+		return response
+	}
+
+	status := h.replica.Status()
+	response.Primary = status.Primary
+	if status.Role == replication.RoleReplica {
+		response.Role = replication.RoleReplica
+		response.Offset = status.Offset
+		response.Connected = &status.Connected
+		response.PrimaryOffset = &status.PrimaryOffset
+		response.Lag = &status.Lag
+		if !status.LastContact.IsZero() {
+			response.LastContact = &status.LastContact
+		}
+	}
+	response.FullSyncs = &status.FullSyncs
+	return response
+}
@@ -0,0 +1,166 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/replication"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReplicationHandlerSuite struct {
+	db      *db.MockDBClient
+	handler *transport.ReplicationHandler
+	suite.Suite
+}
+
+func (s *ReplicationHandlerSuite) SetupTest() {
+	s.db = db.NewMockDBClient(s.T())
+	s.handler = transport.NewReplicationHandler(slog.Default(), s.db, nil)
+}
+
+// decodeError decodes the API error of the response.
+func (s *ReplicationHandlerSuite) decodeError(resp *http.Response) apierrors.ApiError {
+	var errResponse apierrors.ApiError
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse), "failed to decode error response")
+	return errResponse
+}
+
+func (s *ReplicationHandlerSuite) TestSnapshot() {
+	s.db.On("WriteSnapshot", mock.Anything).Run(func(args mock.Arguments) {
+		_, _ = io.WriteString(args.Get(0).(io.Writer), `{"offset":7,"items":{}}`)
+	}).Return(uint64(7), nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/replication/snapshot", nil)
+	w := httptest.NewRecorder()
+	s.handler.HandleSnapshot(w, req)
+
+	resp := w.Result()
+	s.Equal(http.StatusOK, resp.StatusCode)
+	s.Equal("application/json", resp.Header.Get("Content-Type"))
+
+	var snapshot db.Snapshot
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&snapshot))
+	s.Equal(uint64(7), snapshot.Offset)
+}
+
+func (s *ReplicationHandlerSuite) TestStream() {
+	s.Run("Invalid offset", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/replication/stream?offset=abc", nil)
+		w := httptest.NewRecorder()
+		s.handler.HandleStream(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+		s.Equal(apierrors.ErrInvalidRequest.Code, s.decodeError(resp).Code)
+	})
+
+	s.Run("Offset out of range", func() {
+		s.db.On("ReplicationFeed", uint64(3)).Return(nil, nil, db.ErrOffsetOutOfRange).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/replication/stream?offset=3", nil)
+		w := httptest.NewRecorder()
+		s.handler.HandleStream(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusGone, resp.StatusCode, "replicas should do a full synchronization")
+		s.Equal(apierrors.ErrResyncRequired.Code, s.decodeError(resp).Code)
+	})
+
+	s.Run("Operations", func() {
+		feed := make(chan db.ReplicatedOperation, 1)
+		feed <- db.ReplicatedOperation{Offset: 4, Data: json.RawMessage(`{"command":"remove","key":"key1"}`)}
+		close(feed)
+		s.db.On("ReplicationFeed", uint64(3)).Return((<-chan db.ReplicatedOperation)(feed), func() {}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/replication/stream?offset=3", nil)
+		w := httptest.NewRecorder()
+		s.handler.HandleStream(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+		s.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		s.Require().NoError(err)
+		s.Contains(string(body), "id: 4\nevent: op\n")
+		s.Contains(string(body), `"offset":4`)
+	})
+}
+
+func (s *ReplicationHandlerSuite) TestPromote() {
+	s.Run("Primary", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/promote", nil)
+		w := httptest.NewRecorder()
+		s.handler.HandlePromote(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusConflict, resp.StatusCode)
+		s.Equal(apierrors.ErrNotReplica.Code, s.decodeError(resp).Code)
+	})
+
+	s.Run("Replica", func() {
+		replica := replication.NewReplica(slog.Default(), s.db, "http://primary:8080")
+		handler := transport.NewReplicationHandler(slog.Default(), s.db, replica)
+		s.db.On("SetReadOnly", false).Once()
+		s.db.On("Stats").Return(db.Stats{ReplicationOffset: 0}).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/promote", nil)
+		w := httptest.NewRecorder()
+		handler.HandlePromote(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var status schemas.ReplicationStatusResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&status))
+		s.Equal(replication.RolePrimary, status.Role)
+		s.Equal("http://primary:8080", status.Primary)
+	})
+}
+
+func (s *ReplicationHandlerSuite) TestStatus() {
+	s.db.On("Stats").Return(db.Stats{ReplicationOffset: 12, Replicas: 2}).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/replication", nil)
+	w := httptest.NewRecorder()
+	s.handler.HandleStatus(w, req)
+
+	resp := w.Result()
+	s.Equal(http.StatusOK, resp.StatusCode)
+
+	var status schemas.ReplicationStatusResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&status))
+	s.Equal(replication.RolePrimary, status.Role)
+	s.Equal(uint64(12), status.Offset)
+	s.Equal(2, status.Replicas)
+	s.Nil(status.Lag, "the lag is only reported by replicas")
+}
+
+func (s *ReplicationHandlerSuite) TestReadOnlyWrite() {
+	handler := transport.NewHandler(slog.Default(), s.db)
+	s.db.On("Set", "key", "value", mock.Anything).Return(db.ErrReadOnly)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/set", bytes.NewBufferString(`{"key": "key", "value": "value"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.HandleSet(w, req)
+
+	resp := w.Result()
+	s.Equal(http.StatusForbidden, resp.StatusCode)
+	s.Equal(apierrors.ErrReadOnly.Code, s.decodeError(resp).Code)
+}
+
+func TestReplicationHandlerSuite(t *testing.T) {
+	suite.Run(t, new(ReplicationHandlerSuite))
+}
@@ -3,9 +3,19 @@ package transport
 import (
 	"log/slog"
 	"memorydb/api"
+	"memorydb/internal/auth"
+	"memorydb/internal/cluster"
 	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/metrics"
+	"memorydb/internal/proxy"
+	"memorydb/internal/pubsub"
+	"memorydb/internal/ratelimit"
+	"memorydb/internal/replication"
+	"memorydb/internal/slots"
 	"memorydb/internal/transport/schemas"
 	"net/http"
+	"sync/atomic"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -14,33 +24,118 @@ import (
 )
 
 // mountRouter mounts the main router with all sub-routers and middlewares.
-func mountRouter(logger *slog.Logger, db db.DBClient) http.Handler {
+func mountRouter(logger *slog.Logger, db db.DBClient, broker *pubsub.Broker, replica *replication.Replica, node *cluster.Node, slotRouter *slots.Router, authManager auth.AuthManager, limiter *ratelimit.Limiter, inFlight *ratelimit.InFlight, m *metrics.Metrics) http.Handler {
 	r := chi.NewRouter()
 
 	// add middleware
 	r.Use(middleware.Logger)
+	r.Use(traceRequests)
+	r.Use(instrument(m))
 	r.Use(middleware.Recoverer)
 
 	// mount v1 router
-	r.Mount("/api/v1", mountRouterV1(logger, db))
+	r.Mount("/api/v1", mountRouterV1(logger, db, broker, replica, node, slotRouter, authManager, limiter, inFlight))
 
 	return r
 }
 
 // mountRouterV1 mounts the v1 router with its specific routes. In this project, there are not going to be more versions,
 // but this approach shows how we could handle versioning in other projects.
-func mountRouterV1(logger *slog.Logger, db db.DBClient) http.Handler {
+func mountRouterV1(logger *slog.Logger, db db.DBClient, broker *pubsub.Broker, replica *replication.Replica, node *cluster.Node, slotRouter *slots.Router, authManager auth.AuthManager, limiter *ratelimit.Limiter, inFlight *ratelimit.InFlight) http.Handler {
 	r := chi.NewRouter()
 
-	// start handler
+	// start handlers
 	h := NewHandler(logger, db)
+	ps := NewPubSubHandler(logger, broker)
+	rh := NewReplicationHandler(logger, db, replica)
+	bh := NewBatchHandler(logger, db, slotRouter)
 
-	r.Post("/set", h.HandleSet)
-	r.Get("/{key}", h.HandleGet)
-	r.Delete("/{key}", h.HandleRemove)
-	r.Patch("/{key}", h.HandleUpdate)
-	r.Patch("/{key}/push", h.HandlePush)
-	r.Patch("/{key}/pop", h.HandlePop)
+	// endpoints of the administrator and of the other nodes, which send the credentials of the administrator
+	// when the authentication is enabled, since the snapshots and the backups include the users and the API keys
+	r.Group(func(r chi.Router) {
+		if authManager != nil {
+			r.Use(requireAdmin(authManager))
+		}
+
+		// replication
+		r.Get("/replication/snapshot", rh.HandleSnapshot)
+		r.Get("/replication/stream", rh.HandleStream)
+		r.Post("/admin/promote", rh.HandlePromote)
+
+		// backup and restore of the whole database
+		r.Get("/admin/backup", h.HandleBackup)
+		r.Post("/admin/restore", h.HandleRestore)
+
+		// cluster, only mounted in cluster mode
+		if node != nil {
+			ch := NewClusterHandler(logger, node)
+			r.Post("/cluster/apply", ch.HandleApply)
+		}
+
+		// hash slots, only mounted when the server is part of a sharded topology
+		if slotRouter != nil {
+			sh := NewSlotsHandler(logger, db, slotRouter)
+			r.Get("/admin/slots", sh.HandleTopology)
+			r.Put("/admin/slots", sh.HandleSetTopology)
+			r.Post("/admin/slots/migrate", sh.HandleMigrate)
+			r.Post("/admin/slots/import", sh.HandleImport)
+			r.Post("/admin/slots/restore", sh.HandleRestore)
+		}
+	})
+
+	// authentication, only mounted when it is enabled
+	if authManager != nil {
+		ah := NewAuthHandler(logger, db, authManager)
+		r.Post("/auth/register", ah.HandleRegister)
+		r.Post("/auth/login", ah.HandleLogin)
+		r.Post("/auth/refresh", ah.HandleRefresh)
+		r.Post("/auth/logout", ah.HandleLogout)
+
+		// API keys, managed with the credentials of the administrator
+		r.Route("/admin/apikeys", func(r chi.Router) {
+			r.Use(requireAdmin(authManager))
+			r.Post("/", ah.HandleCreateAPIKey)
+			r.Get("/", ah.HandleListAPIKeys)
+			r.Delete("/{id}", ah.HandleRevokeAPIKey)
+		})
+	}
+
+	// namespaces, managed with the credentials of the administrator when the authentication is enabled
+	r.Route("/admin/namespaces", func(r chi.Router) {
+		if authManager != nil {
+			r.Use(requireAdmin(authManager))
+		}
+		r.Post("/", h.HandleCreateNamespace)
+		r.Get("/", h.HandleListNamespaces)
+		r.Get("/{namespace}", h.HandleGetNamespace)
+		r.Delete("/{namespace}", h.HandleDropNamespace)
+	})
+
+	// data routes, which require an access token or an API key when the authentication is enabled. The rules of
+	// the API keys are checked for the key of the route, and by the handlers of the routes with several keys.
+	// Every client has its own rate of reads and writes.
+	r.Group(func(r chi.Router) {
+		r.Use(requireAuth(authManager))
+		reads := r.With(limitRate(limiter, enums.PermissionRead))
+		writes := r.With(limitRate(limiter, enums.PermissionWrite))
+
+		// message streams, which stay open as long as their clients are connected, so they are not counted as
+		// requests in flight. The channels are checked as keys
+		reads.Get("/subscribe", ps.HandleSubscribe)
+
+		// publish/subscribe
+		writes.With(limitInFlight(inFlight)).Post("/publish", ps.HandlePublish)
+		reads.With(limitInFlight(inFlight)).Get("/pubsub/channels", ps.HandleChannels)
+
+		// keys of the namespaces, with the same routes as the keys of the database. The rules of the API keys are
+		// checked with the namespace of the request
+		r.Route("/ns/{namespace}", func(r chi.Router) {
+			r.Use(h.useNamespace)
+			mountKeyspace(r, h, bh, slotRouter, limiter, inFlight)
+		})
+
+		mountKeyspace(r, h, bh, slotRouter, limiter, inFlight)
+	})
 
 	// serve swagger UI
 
@@ -55,15 +150,184 @@ func mountRouterV1(logger *slog.Logger, db db.DBClient) http.Handler {
 	return r
 }
 
+// mountKeyspace mounts the routes of the keys, the streams and the keyspace events, whose handlers serve the keys
+// of the namespace of the request, or else the keys of the database.
+func mountKeyspace(r chi.Router, h *Handler, bh *BatchHandler, slotRouter *slots.Router, limiter *ratelimit.Limiter, inFlight *ratelimit.InFlight) {
+	reads := r.With(limitRate(limiter, enums.PermissionRead))
+	writes := r.With(limitRate(limiter, enums.PermissionWrite))
+
+	// event streams, which stay open as long as their clients are connected, so they are not counted as requests
+	// in flight. The patterns are checked as keys
+	reads.Get("/events", h.HandleEvents)
+
+	reads = reads.With(limitInFlight(inFlight))
+	writes = writes.With(limitInFlight(inFlight))
+
+	// streams
+	r.With(limitInFlight(inFlight)).Route("/streams/{key}", func(r chi.Router) {
+		r.Use(guardSlot(slotRouter, keyFromURL))
+		read := r.With(limitRate(limiter, enums.PermissionRead), authorizeKey(enums.PermissionRead, keyFromURL))
+		write := r.With(limitRate(limiter, enums.PermissionWrite), authorizeKey(enums.PermissionWrite, keyFromURL))
+
+		write.Post("/", h.HandleStreamAdd)
+		read.Get("/", h.HandleStreamRange)
+		read.Get("/read", h.HandleStreamRead)
+		write.Post("/trim", h.HandleStreamTrim)
+		write.Post("/groups", h.HandleStreamGroupCreate)
+		write.Get("/groups/{group}/read", h.HandleStreamReadGroup) // delivering the entries makes them pending
+		write.Post("/groups/{group}/ack", h.HandleStreamAck)
+		read.Get("/groups/{group}/pending", h.HandleStreamPending)
+		write.Post("/groups/{group}/claim", h.HandleStreamClaim)
+	})
+
+	// keys, redirected to the node that serves their slot
+	writeByBody := writes.With(decodeBody[schemas.SetRowRequest], authorizeKey(enums.PermissionWrite, keyFromBody), guardSlot(slotRouter, keyFromBody))
+	readByURL := reads.With(authorizeKey(enums.PermissionRead, keyFromURL), guardSlot(slotRouter, keyFromURL))
+	writeByURL := writes.With(authorizeKey(enums.PermissionWrite, keyFromURL), guardSlot(slotRouter, keyFromURL))
+
+	writeByBody.Post("/set", h.HandleSet)
+	reads.Post("/mget", bh.HandleMGet)
+	writes.Post("/mset", bh.HandleMSet)
+	writes.Post("/mdel", bh.HandleMDel)
+	writes.Post("/pipeline", bh.HandlePipeline) // a pipeline can mix reads and writes
+	readByURL.Get("/{key}", h.HandleGet)
+	writeByURL.Delete("/{key}", h.HandleRemove)
+	writeByURL.Patch("/{key}", h.HandleUpdate)
+	writeByURL.Patch("/{key}/push", h.HandlePush)
+	writeByURL.Patch("/{key}/pop", h.HandlePop)
+}
+
 // mountHealthRouter mounts the health check router.
-func mountHealthRouter(logger *slog.Logger) http.Handler {
+func mountHealthRouter(logger *slog.Logger, db db.DBClient, replica *replication.Replica, node *cluster.Node, limiter *ratelimit.Limiter, inFlight *ratelimit.InFlight, m *metrics.Metrics, shuttingDown *atomic.Bool) http.Handler {
+	r := chi.NewRouter()
+	rh := NewReplicationHandler(logger, db, replica)
+	hh := NewHealthHandler(logger, db, replica, shuttingDown)
+
+	// liveness and readiness probes, the health check endpoint reports the readiness
+	r.Get("/livez", hh.HandleLive)
+	r.Get("/readyz", hh.HandleReady)
+	r.Get("/health", hh.HandleReady)
+
+	// replication offset and lag
+	r.Get("/replication", rh.HandleStatus)
+
+	// Raft state of the node
+	if node != nil {
+		ch := NewClusterHandler(logger, node)
+		r.Get("/cluster", ch.HandleStatus)
+	}
+
+	// counters of the rate limits and of the requests in flight
+	if limiter != nil || inFlight != nil {
+		r.Get("/ratelimit", func(w http.ResponseWriter, r *http.Request) {
+			var response schemas.RateLimitStatsResponse
+			if limiter != nil {
+				stats := limiter.Stats()
+				response.Limiter = &stats
+			}
+			if inFlight != nil {
+				stats := inFlight.Stats()
+				response.InFlight = &stats
+			}
+			writeJSON(w, http.StatusOK, response)
+		})
+	}
+
+	// metrics in the Prometheus text format
+	if m != nil {
+		r.Method(http.MethodGet, "/metrics", m.Handler())
+	}
+
+	return r
+}
+
+// mountProxyRouter mounts the router of the proxy, which serves the same API as a server by forwarding
+// the requests to the backends.
+func mountProxyRouter(logger *slog.Logger, pool *proxy.Pool) http.Handler {
 	r := chi.NewRouter()
 
-	// health check endpoint
-	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
-		logger.Info("Health check endpoint hit")
-		writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+	// add middleware
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+
+	// mount v1 router
+	r.Mount("/api/v1", mountProxyRouterV1(logger, pool))
+
+	return r
+}
+
+// mountProxyRouterV1 mounts the v1 router of the proxy. The replication, cluster and admin endpoints are not
+// served, since they belong to every backend.
+func mountProxyRouterV1(logger *slog.Logger, pool *proxy.Pool) http.Handler {
+	r := chi.NewRouter()
+	ph := NewProxyHandler(logger, pool)
+
+	// publish/subscribe, every channel is published in the backend that owns it
+	r.Post("/publish", ph.HandleKey(channelFromBody))
+	r.Get("/subscribe", ph.HandleSubscribe)
+	r.Get("/pubsub/channels", ph.HandleChannels)
+
+	// keys of the namespaces, which are forwarded with their path, so the namespaces must exist in every backend
+	r.Route("/ns/{namespace}", func(r chi.Router) {
+		mountProxyKeyspace(r, ph)
+	})
+
+	mountProxyKeyspace(r, ph)
+
+	// serve swagger UI
+	r.Get("/docs/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, api.SwaggerSpec, "swagger_v1.yaml")
 	})
 
+	r.Get("/docs/swagger/*", httpSwagger.Handler(
+		httpSwagger.URL("/api/v1/docs/swagger.yaml"), // The url pointing to API definition
+	))
+
+	return r
+}
+
+// mountProxyKeyspace mounts the routes of the keys, the streams and the keyspace events of the proxy.
+func mountProxyKeyspace(r chi.Router, ph *ProxyHandler) {
+	byBody := ph.HandleKey(keyFromBody)
+	byURL := ph.HandleKey(keyFromURL)
+
+	// streams
+	r.Route("/streams/{key}", func(r chi.Router) {
+		r.Post("/", byURL)
+		r.Get("/", byURL)
+		r.Get("/read", byURL)
+		r.Post("/trim", byURL)
+		r.Post("/groups", byURL)
+		r.Get("/groups/{group}/read", byURL)
+		r.Post("/groups/{group}/ack", byURL)
+		r.Get("/groups/{group}/pending", byURL)
+		r.Post("/groups/{group}/claim", byURL)
+	})
+
+	// keys
+	r.Post("/set", byBody)
+	r.Post("/mget", ph.HandleBatch)
+	r.Post("/mset", ph.HandleBatch)
+	r.Post("/mdel", ph.HandleBatch)
+	r.Post("/pipeline", ph.HandlePipeline)
+	r.Get("/events", ph.HandleEvents)
+	r.Get("/{key}", byURL)
+	r.Delete("/{key}", byURL)
+	r.Patch("/{key}", byURL)
+	r.Patch("/{key}/push", byURL)
+	r.Patch("/{key}/pop", byURL)
+}
+
+// mountProxyHealthRouter mounts the health check router of the proxy.
+func mountProxyHealthRouter(logger *slog.Logger, pool *proxy.Pool) http.Handler {
+	r := chi.NewRouter()
+	ph := NewProxyHandler(logger, pool)
+
+	// health check endpoint, failing when every backend is out of rotation
+	r.Get("/health", ph.HandleHealth)
+
+	// health of every backend
+	r.Get("/backends", ph.HandleBackends)
+
 	return r
 }
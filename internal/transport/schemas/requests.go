@@ -2,7 +2,9 @@ package schemas
 
 import (
 	"encoding/json"
+	"fmt"
 	"memorydb/internal/db"
+	"memorydb/internal/enums"
 	"time"
 )
 
@@ -46,8 +48,133 @@ type UpdateRowRequest struct {
 	TTL   *Duration        `json:"ttl,omitempty"`             // Optional TTL for the item
 }
 
+// MGetRequest represents a request to get several rows of the database at once.
+type MGetRequest struct {
+	Keys []string `json:"keys" validate:"required"`
+}
+
+// MSetRequest represents a request to set several rows of the database at once, with the same TTL.
+type MSetRequest struct {
+	Items  map[string]db.StringOrSlice `json:"items" validate:"required"` // Values by key, each one a string or []string
+	TTL    *Duration                   `json:"ttl,omitempty"`
+	Atomic bool                        `json:"atomic,omitempty"` // Whether every item must be set or none of them
+}
+
+// MDelRequest represents a request to remove several rows of the database at once.
+type MDelRequest struct {
+	Keys []string `json:"keys" validate:"required"`
+}
+
+// PipelineCommand represents a command of a pipeline. Value is required by set, update and push, and TTL is
+// only used by them.
+type PipelineCommand struct {
+	Op    string            `json:"op" validate:"required,oneof=get set update remove push pop"`
+	Key   string            `json:"key" validate:"required"`
+	Value *db.StringOrSlice `json:"value,omitempty"` // string or []string, only a string for push
+	TTL   *Duration         `json:"ttl,omitempty"`
+}
+
+// Validate checks the value required by the operation of the command.
+func (c *PipelineCommand) Validate() error {
+	switch c.Op {
+	case "set", "update", "push":
+		if c.Value == nil {
+			return fmt.Errorf("field 'value' is required by %s", c.Op)
+		}
+		if _, ok := c.Value.Val.(string); c.Op == "push" && !ok {
+			return fmt.Errorf("the value of push must be a string")
+		}
+	}
+	return nil
+}
+
+// PipelineRequest represents a request to run several commands in order, sent as a single JSON document.
+type PipelineRequest struct {
+	Commands []PipelineCommand `json:"commands" validate:"required"`
+}
+
 // PushItemToSliceRequest represents a request to push an item into a slice stored in the database.
 type PushItemToSliceRequest struct {
 	Value string    `json:"value" validate:"required"` // Value to push into the slice
 	TTL   *Duration `json:"ttl,omitempty"`             // Optional TTL for the item
 }
+
+// PublishRequest represents a request to publish a message to a pub/sub channel.
+type PublishRequest struct {
+	Channel string `json:"channel" validate:"required"` // Channel the message is published to
+	Message string `json:"message"`                     // Content of the message
+}
+
+// StreamAddRequest represents a request to append an entry to a stream.
+type StreamAddRequest struct {
+	Fields map[string]string `json:"fields" validate:"required,min=1"`   // Fields of the entry
+	TTL    *Duration         `json:"ttl,omitempty"`                      // Optional TTL for the stream
+	MaxLen int               `json:"max_len,omitempty" validate:"min=0"` // Optional maximum number of entries kept after adding
+	MaxAge *Duration         `json:"max_age,omitempty"`                  // Optional maximum age of the entries kept after adding
+}
+
+// StreamTrimRequest represents a request to remove the oldest entries of a stream.
+type StreamTrimRequest struct {
+	MaxLen int       `json:"max_len,omitempty" validate:"min=0"` // Maximum number of entries kept
+	MaxAge *Duration `json:"max_age,omitempty"`                  // Maximum age of the entries kept
+}
+
+// StreamGroupCreateRequest represents a request to create a consumer group in a stream.
+type StreamGroupCreateRequest struct {
+	Group string `json:"group" validate:"required"` // Name of the group
+	Start string `json:"start,omitempty"`           // ID after which entries are delivered: "$" (default) for new entries only, "0" for all
+}
+
+// StreamAckRequest represents a request to acknowledge entries pending in a consumer group.
+type StreamAckRequest struct {
+	IDs []string `json:"ids" validate:"required,min=1"` // IDs of the acknowledged entries
+}
+
+// StreamClaimRequest represents a request to transfer pending entries of a consumer group to another consumer.
+type StreamClaimRequest struct {
+	Consumer string    `json:"consumer" validate:"required"`  // Consumer that claims the entries
+	MinIdle  *Duration `json:"min_idle,omitempty"`            // Minimum time the entries must have been pending
+	IDs      []string  `json:"ids" validate:"required,min=1"` // IDs of the claimed entries
+}
+
+// SlotsMigrateRequest represents a request to move a range of hash slots to another node.
+type SlotsMigrateRequest struct {
+	Start  *int   `json:"start" validate:"required,min=0,max=16383"`
+	End    *int   `json:"end" validate:"required,min=0,max=16383"`
+	Target string `json:"target" validate:"required"` // ID of the node that receives the slots
+}
+
+// RegisterRequest represents a request to register a user, authorized with the credentials of the administrator.
+type RegisterRequest struct {
+	AdminUsername string `json:"admin_username" validate:"required"`
+	AdminPassword string `json:"admin_password" validate:"required"`
+	Username      string `json:"username" validate:"required"`
+	Password      string `json:"password" validate:"required"`
+}
+
+// LoginRequest represents a request to log in with the credentials of a user.
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// CreateAPIKeyRequest represents a request to create an API key with the rules of the keys it can access.
+type CreateAPIKeyRequest struct {
+	Name    string       `json:"name" validate:"required"`        // Name of the key, such as the team that uses it
+	Subject string       `json:"subject,omitempty"`               // Identity of the client certificates authenticated as the key
+	Rules   []db.ACLRule `json:"rules" validate:"required,min=1"` // Rules such as {"pattern": "billing:*", "permissions": ["read", "write"]}
+}
+
+// CreateNamespaceRequest represents a request to create a namespace with its own keyspace and settings.
+type CreateNamespaceRequest struct {
+	Name           string               `json:"name" validate:"required"`  // Name of the namespace, with letters, digits, '-' or '_'
+	DefaultTTL     *Duration            `json:"default_ttl,omitempty"`     // TTL of the items stored without one, 5m if not set
+	MaxMemory      int64                `json:"max_memory,omitempty"`      // Approximate memory limit in bytes, 0 means no limit
+	EvictionPolicy enums.EvictionPolicy `json:"eviction_policy,omitempty"` // Policy applied when the memory limit is reached, noeviction if not set
+	Persistence    bool                 `json:"persistence,omitempty"`     // Whether the keys are persisted, which requires the persistence of the server
+}
+
+// RefreshTokenRequest represents a request to exchange a refresh token for a new pair of tokens.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
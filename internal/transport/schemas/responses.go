@@ -1,6 +1,10 @@
 package schemas
 
 import (
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/ratelimit"
 	"time"
 )
 
@@ -18,3 +22,194 @@ type RowResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
+
+// KeyResult represents the result of a key of a batch request, or of a command of a pipeline. Error is set if the
+// operation failed, and Item is set by the operations that return an item and succeeded.
+type KeyResult struct {
+	Item  *RowResponse        `json:"item,omitempty"`
+	Error *apierrors.ApiError `json:"error,omitempty"`
+}
+
+// BatchResponse represents the results of a batch request, by key.
+type BatchResponse struct {
+	Results map[string]KeyResult `json:"results"`
+}
+
+// PipelineResponse represents the results of the commands of a pipeline, in the order of the commands.
+type PipelineResponse struct {
+	Results []KeyResult `json:"results"`
+}
+
+// EventResponse represents a keyspace event streamed to the subscribers.
+type EventResponse struct {
+	ID   uint64    `json:"id"`
+	Type string    `json:"type"`
+	Key  string    `json:"key"`
+	Time time.Time `json:"time"`
+}
+
+// PublishResponse represents the response to a published message.
+type PublishResponse struct {
+	Receivers int `json:"receivers"` // Number of subscribers that received the message
+}
+
+// MessageResponse represents a pub/sub message streamed to the subscribers.
+type MessageResponse struct {
+	Channel string    `json:"channel"`
+	Pattern string    `json:"pattern,omitempty"`
+	Payload string    `json:"payload"`
+	Time    time.Time `json:"time"`
+}
+
+// PubSubChannelsResponse represents the active pub/sub channels and their number of subscribers.
+type PubSubChannelsResponse struct {
+	Channels    map[string]int `json:"channels"`    // Number of subscribers by channel
+	Patterns    []string       `json:"patterns"`    // Subscribed patterns
+	Subscribers int            `json:"subscribers"` // Number of connected subscribers
+}
+
+// StreamAddResponse represents the response to an entry appended to a stream.
+type StreamAddResponse struct {
+	ID string `json:"id"` // ID assigned to the entry
+}
+
+// StreamEntryResponse represents a single entry of a stream.
+type StreamEntryResponse struct {
+	ID     string            `json:"id"`
+	Fields map[string]string `json:"fields"`
+}
+
+// StreamEntriesResponse represents a list of entries read from a stream.
+type StreamEntriesResponse struct {
+	Key     string                `json:"key"`
+	Entries []StreamEntryResponse `json:"entries"`
+}
+
+// StreamTrimResponse represents the result of trimming a stream.
+type StreamTrimResponse struct {
+	Removed int `json:"removed"` // Number of removed entries
+}
+
+// StreamAckResponse represents the result of acknowledging entries of a consumer group.
+type StreamAckResponse struct {
+	Acknowledged int `json:"acknowledged"` // Number of entries that were pending
+}
+
+// PendingEntryResponse represents an entry delivered to a consumer that has not been acknowledged yet.
+type PendingEntryResponse struct {
+	ID            string    `json:"id"`
+	Consumer      string    `json:"consumer"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+	DeliveryCount int       `json:"delivery_count"`
+}
+
+// StreamPendingResponse represents the pending entries of a consumer group.
+type StreamPendingResponse struct {
+	Key     string                 `json:"key"`
+	Group   string                 `json:"group"`
+	Pending []PendingEntryResponse `json:"pending"`
+}
+
+// ReplicationStatusResponse represents the replication state of the server.
+type ReplicationStatusResponse struct {
+	Role          string     `json:"role"`                     // primary or replica
+	Offset        uint64     `json:"offset"`                   // offset of the last operation logged by a primary or applied by a replica
+	Replicas      int        `json:"replicas"`                 // number of replicas streaming from the server
+	ReadOnly      bool       `json:"read_only"`                // whether writes are rejected
+	Primary       string     `json:"primary,omitempty"`        // URL of the primary, only for replicas
+	Connected     *bool      `json:"connected,omitempty"`      // whether the replica is streaming from the primary
+	PrimaryOffset *uint64    `json:"primary_offset,omitempty"` // last offset known of the primary
+	Lag           *uint64    `json:"lag,omitempty"`            // number of operations the replica is behind the primary
+	LastContact   *time.Time `json:"last_contact,omitempty"`   // last time the replica received a message from the primary
+	FullSyncs     *int       `json:"full_syncs,omitempty"`     // number of full synchronizations done by the replica
+}
+
+// ClusterPeer represents a node of the cluster.
+type ClusterPeer struct {
+	ID       string `json:"id"`        // unique ID of the node
+	RaftAddr string `json:"raft_addr"` // address where the node listens for Raft traffic
+	HTTPURL  string `json:"http_url"`  // base URL of the HTTP API of the node
+}
+
+// ClusterStatusResponse represents the Raft state of a cluster node.
+type ClusterStatusResponse struct {
+	ID           string        `json:"id"`            // ID of the node
+	State        string        `json:"state"`         // Leader, Follower, Candidate or Shutdown
+	LeaderID     string        `json:"leader_id"`     // ID of the current leader, empty if there is none
+	Term         uint64        `json:"term"`          // current term
+	CommitIndex  uint64        `json:"commit_index"`  // index of the last committed command
+	AppliedIndex uint64        `json:"applied_index"` // index of the last command applied to the local database
+	LastIndex    uint64        `json:"last_index"`    // index of the last command in the local log
+	Peers        []ClusterPeer `json:"peers"`         // nodes of the cluster
+}
+
+// SlotsMigrateResponse represents the result of a slot migration.
+type SlotsMigrateResponse struct {
+	Moved int    `json:"moved"` // number of keys moved to the target
+	Epoch uint64 `json:"epoch"` // epoch of the topology after the migration
+}
+
+// RestoreResponse represents the result of a backup restored in the database.
+type RestoreResponse struct {
+	Keys int    `json:"keys"` // Number of keys restored
+	Mode string `json:"mode"` // Whether the backup replaced the database or was merged into it
+}
+
+// TokenResponse represents the tokens of the session of a user.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`  // token sent in the Authorization header of the protected endpoints
+	RefreshToken string `json:"refresh_token"` // token exchanged for a new pair of tokens
+	TokenType    string `json:"token_type"`    // always Bearer
+	ExpiresIn    int    `json:"expires_in"`    // lifetime of the access token, in seconds
+}
+
+// APIKeyResponse represents an API key. The key itself is only returned when the API key is created.
+type APIKeyResponse struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Key       string       `json:"key,omitempty"`     // key sent in the X-API-Key header, only returned once
+	Subject   string       `json:"subject,omitempty"` // identity of the client certificates authenticated as the key
+	Rules     []db.ACLRule `json:"rules"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// APIKeysResponse represents the API keys of the server.
+type APIKeysResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+// NamespaceResponse represents a namespace with its settings and the counters of its keyspace.
+type NamespaceResponse struct {
+	Name           string               `json:"name"`
+	DefaultTTL     Duration             `json:"default_ttl"`
+	MaxMemory      int64                `json:"max_memory"`
+	EvictionPolicy enums.EvictionPolicy `json:"eviction_policy"`
+	Persistence    bool                 `json:"persistence"`
+	Stats          db.Stats             `json:"stats"`
+}
+
+// NamespacesResponse represents the namespaces of the server.
+type NamespacesResponse struct {
+	Namespaces []NamespaceResponse `json:"namespaces"`
+}
+
+// RateLimitStatsResponse represents the counters of the rate limits and of the requests in flight of the server.
+type RateLimitStatsResponse struct {
+	Limiter  *ratelimit.Stats         `json:"limiter,omitempty"`   // nil if the rate limits are disabled
+	InFlight *ratelimit.InFlightStats `json:"in_flight,omitempty"` // nil if the limit of requests in flight is disabled
+}
+
+// HealthResponse represents the state of the server reported by the health probes. The checks are only listed
+// when the verbose view is requested.
+type HealthResponse struct {
+	Status string                `json:"status"` // ok or failing
+	Checks []HealthCheckResponse `json:"checks,omitempty"`
+}
+
+// HealthCheckResponse represents the result of a single check of a health probe.
+type HealthCheckResponse struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`            // liveness or readiness
+	Status string `json:"status"`          // ok or failing
+	Error  string `json:"error,omitempty"` // reason of the failure
+}
@@ -1,57 +1,235 @@
 package transport
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/certs"
+	"memorydb/internal/cluster"
 	"memorydb/internal/db"
+	"memorydb/internal/memcache"
+	"memorydb/internal/metrics"
+	"memorydb/internal/proxy"
+	"memorydb/internal/pubsub"
+	"memorydb/internal/ratelimit"
+	"memorydb/internal/replication"
+	"memorydb/internal/resp"
+	"memorydb/internal/slots"
+	"net"
 	"net/http"
 	"strconv"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type Server struct {
 	logger    *slog.Logger
 	srv       *http.Server
 	healthSrv *http.Server
+	broker    *pubsub.Broker   // broker for the publish/subscribe endpoints
+	respSrv   *resp.Server     // server of the RESP protocol, nil if it is disabled
+	grpcSrv   *grpc.Server     // server of the gRPC API, nil if it is disabled
+	mcSrv     *memcache.Server // server of the memcached protocol, nil if it is disabled
+
+	shuttingDown atomic.Bool // set once the server begins to shut down, which fails the readiness probe
+
+	// Optional settings
+	pubsubBufferSize int                  // number of pub/sub messages buffered per subscriber
+	replica          *replication.Replica // replica of the server, nil if the server is a primary
+	node             *cluster.Node        // cluster node of the server, nil if the server does not run in cluster mode
+	slotRouter       *slots.Router        // router of the hash slots, nil if the server is not part of a sharded topology
+	authManager      auth.AuthManager     // manager of the authentication of the data routes and listeners, nil if it is disabled
+	certs            *certs.Reloader      // certificates of the servers, nil if they serve plain text
+	limiter          *ratelimit.Limiter   // rate limits of the clients of the data routes, nil if they are disabled
+	inFlight         *ratelimit.InFlight  // limit of the requests of the data routes served at once, nil if it is disabled
+	metrics          *metrics.Metrics     // collectors of the metrics served by the health server, nil if they are disabled
+	respPort         int                  // TCP port of the RESP protocol, 0 if it is disabled
+	grpcPort         int                  // TCP port of the gRPC API, 0 if it is disabled
+	memcachedPort    int                  // TCP port of the memcached protocol, 0 if it is disabled
+	startup          *StartupHealth       // health server started while the database was loaded, nil if there is none
 }
 
 // NewServer creates a new HTTP server with the provided logger, port, health port, and in-memory database.
 //
 // The server and the health server are listening to requests on different ports to allow for health checks
 // without affecting the main application functionality.
-func NewServer(logger *slog.Logger, port, healthPort int, db db.DBClient) *Server {
-	srv := &http.Server{
+func NewServer(logger *slog.Logger, port, healthPort int, db db.DBClient, opts ...ServerOptions) *Server {
+	s := &Server{logger: logger}
+
+	// Apply options to the server before mounting the routers
+	for _, opt := range opts {
+		opt.apply(s)
+	}
+
+	s.broker = pubsub.NewBroker(logger, pubsub.WithBufferSize(s.pubsubBufferSize))
+	s.metrics.WatchRateLimits(s.limiter, s.inFlight)
+
+	s.srv = &http.Server{
 		Addr:    ":" + strconv.Itoa(port),
-		Handler: mountRouter(logger, db),
+		Handler: mountRouter(logger, db, s.broker, s.replica, s.node, s.slotRouter, s.authManager, s.limiter, s.inFlight, s.metrics),
+	}
+
+	healthHandler := mountHealthRouter(logger, db, s.replica, s.node, s.limiter, s.inFlight, s.metrics, &s.shuttingDown)
+	if s.startup != nil {
+		// the health server is already listening, it serves the probes of the server from now on
+		s.healthSrv = s.startup.srv
+		s.startup.setHandler(healthHandler)
+	} else {
+		s.healthSrv = &http.Server{
+			Addr:    ":" + strconv.Itoa(healthPort),
+			Handler: healthHandler,
+		}
 	}
 
-	healthSrv := &http.Server{
-		Addr:    ":" + strconv.Itoa(healthPort),
-		Handler: mountHealthRouter(logger),
+	if s.certs != nil {
+		s.srv.TLSConfig = s.certs.ServerConfig(true)
+		if s.startup == nil {
+			s.healthSrv.TLSConfig = s.certs.ServerConfig(false)
+		}
+	}
+
+	if s.respPort > 0 {
+		s.respSrv = resp.NewServer(logger, ":"+strconv.Itoa(s.respPort), db, resp.WithSlotRouter{Router: s.slotRouter}, resp.WithAuthManager{AuthManager: s.authManager})
+	}
+	if s.grpcPort > 0 {
+		var grpcOpts []grpc.ServerOption
+		if s.certs != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(s.certs.ServerConfig(true))))
+		}
+		s.grpcSrv = NewGRPCServer(logger, db, s.slotRouter, s.authManager, grpcOpts...)
 	}
+	if s.memcachedPort > 0 {
+		s.mcSrv = memcache.NewServer(logger, ":"+strconv.Itoa(s.memcachedPort), db, memcache.WithAuthManager{AuthManager: s.authManager})
+	}
+
+	return s
+}
 
+// NewProxyServer creates a new HTTP server that serves the API of the servers by forwarding the requests
+// to the backends of the pool, and a health server that reports the health of the backends.
+func NewProxyServer(logger *slog.Logger, port, healthPort int, pool *proxy.Pool) *Server {
 	return &Server{
-		logger:    logger,
-		srv:       srv,
-		healthSrv: healthSrv,
+		logger: logger,
+		srv: &http.Server{
+			Addr:    ":" + strconv.Itoa(port),
+			Handler: mountProxyRouter(logger, pool),
+		},
+		healthSrv: &http.Server{
+			Addr:    ":" + strconv.Itoa(healthPort),
+			Handler: mountProxyHealthRouter(logger, pool),
+		},
 	}
 }
 
-// Start starts the HTTP server and listens for incoming requests on the specified port.
+// Start starts the HTTP server and listens for incoming requests on the specified port, over TLS if it is enabled.
 func (s *Server) Start() error {
-	s.logger.Info("Starting HTTP server", "port", 8080)
-	return s.srv.ListenAndServe()
+	s.logger.Info("Starting HTTP server", "port", 8080, "tls", s.srv.TLSConfig != nil)
+	return listenAndServe(s.srv)
+}
+
+// Handler returns the handler of the API, so it can also be served from other listeners.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler
+}
+
+// HealthHandler returns the handler of the health server, so it can also be served from other listeners.
+func (s *Server) HealthHandler() http.Handler {
+	if s.startup != nil {
+		return s.startup.Handler()
+	}
+	return s.healthSrv.Handler
 }
 
-// StartHealth starts the health HTTP server and listens for health check requests on the specified health port.
+// StartHealth starts the health HTTP server and listens for health check requests on the specified health port,
+// over TLS if it is enabled. It returns nil at once if the health server was started while the database was loaded.
 func (s *Server) StartHealth() error {
-	s.logger.Info("Starting health HTTP server", "port", 8081)
-	return s.healthSrv.ListenAndServe()
+	if s.startup != nil {
+		return nil
+	}
+	s.logger.Info("Starting health HTTP server", "port", 8081, "tls", s.healthSrv.TLSConfig != nil)
+	return listenAndServe(s.healthSrv)
+}
+
+// listenAndServe serves the server over TLS if it has a TLS configuration, which provides its certificates.
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+// listenTLS listens at the TCP port and, if TLS is enabled, accepts TLS connections with the certificates of the
+// HTTP servers and their client authentication policy.
+func (s *Server) listenTLS(port int) (net.Listener, error) {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return nil, err
+	}
+	if s.certs != nil {
+		listener = tls.NewListener(listener, s.certs.ServerConfig(true))
+	}
+	return listener, nil
+}
+
+// StartRESP starts the server of the RESP protocol and listens for connections on the RESP port, over TLS if it is
+// enabled. It returns nil at once if the RESP protocol is disabled.
+func (s *Server) StartRESP() error {
+	if s.respSrv == nil {
+		return nil
+	}
+	listener, err := s.listenTLS(s.respPort)
+	if err != nil {
+		return err
+	}
+	return s.respSrv.Serve(listener)
+}
+
+// StartMemcached starts the server of the memcached protocol and listens for connections on the memcached port,
+// over TLS if it is enabled. It returns nil at once if the memcached protocol is disabled.
+func (s *Server) StartMemcached() error {
+	if s.mcSrv == nil {
+		return nil
+	}
+	listener, err := s.listenTLS(s.memcachedPort)
+	if err != nil {
+		return err
+	}
+	return s.mcSrv.Serve(listener)
+}
+
+// StartGRPC starts the gRPC server and listens for calls on the gRPC port, over TLS if it is enabled.
+// It returns nil at once if the gRPC API is disabled.
+func (s *Server) StartGRPC() error {
+	if s.grpcSrv == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(s.grpcPort))
+	if err != nil {
+		return err
+	}
+	s.logger.Info("Starting gRPC server", "port", s.grpcPort, "tls", s.certs != nil)
+	return s.grpcSrv.Serve(listener)
+}
+
+// BeginShutdown fails the readiness probe, so the load balancers stop sending requests to the server before it
+// is shut down. The server keeps serving the requests in the meantime.
+func (s *Server) BeginShutdown() {
+	s.logger.Info("Beginning shutdown, the server is no longer ready")
+	s.shuttingDown.Store(true)
 }
 
 // Shutdown gracefully shuts down the HTTP server and the health HTTP server.
 func (s *Server) Shutdown() error {
 	var errs []error
 
+	// disconnect the pub/sub subscribers so their streams end, the proxy has no broker
+	if s.broker != nil {
+		s.broker.Close()
+	}
+
 	s.logger.Info("Closing HTTP server")
 	if err := s.srv.Close(); err != nil {
 		s.logger.Error("Error closing main server", "error", err)
@@ -62,6 +240,25 @@ func (s *Server) Shutdown() error {
 		s.logger.Error("Error closing health server", "error", err)
 		errs = append(errs, err)
 	}
+	if s.grpcSrv != nil {
+		// the watch streams are cancelled, so the calls in progress end at once
+		s.logger.Info("Closing gRPC server")
+		s.grpcSrv.Stop()
+	}
+	if s.respSrv != nil {
+		s.logger.Info("Closing RESP server")
+		if err := s.respSrv.Close(); err != nil {
+			s.logger.Error("Error closing RESP server", "error", err)
+			errs = append(errs, err)
+		}
+	}
+	if s.mcSrv != nil {
+		s.logger.Info("Closing memcached server")
+		if err := s.mcSrv.Close(); err != nil {
+			s.logger.Error("Error closing memcached server", "error", err)
+			errs = append(errs, err)
+		}
+	}
 
 	if len(errs) > 0 {
 		return fmt.Errorf("shutdown had errors: %v", errs)
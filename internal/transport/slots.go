@@ -0,0 +1,230 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SlotsHandler struct {
+	logger  *slog.Logger
+	db      db.DBClient
+	router  *slots.Router
+	handler *Handler // handler of the keys, used to convert the errors of the database
+}
+
+// NewSlotsHandler creates a new handler for the hash slot endpoints.
+func NewSlotsHandler(logger *slog.Logger, db db.DBClient, router *slots.Router) *SlotsHandler {
+	return &SlotsHandler{logger: logger, db: db, router: router, handler: NewHandler(logger, db)}
+}
+
+// keyFromURL returns the key in the URL of the request.
+func keyFromURL(r *http.Request) string {
+	return chi.URLParam(r, "key")
+}
+
+// keyFromBody returns the key of the set request in the JSON body of the request.
+func keyFromBody(r *http.Request) string {
+	body, err := peekBody[schemas.SetRowRequest](r)
+	if err != nil {
+		return ""
+	}
+	return body.Key
+}
+
+// channelFromBody returns the channel of the publish request in the JSON body of the request.
+func channelFromBody(r *http.Request) string {
+	body, err := peekBody[schemas.PublishRequest](r)
+	if err != nil {
+		return ""
+	}
+	return body.Channel
+}
+
+// bodyKey is the key of the decoded body of a request in its context.
+type bodyKey struct{}
+
+// decodeBody decodes the JSON body of the request into a T once, so the access rules, the slot of the key and the
+// handler read the same fields with the same case-insensitive matching. The request is rejected if it is not valid.
+func decodeBody[T any](next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(T)
+		if err := decodeJSON(r.Body, body); err != nil {
+			wrapError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), bodyKey{}, body)))
+	})
+}
+
+// requestBody returns the body decoded by decodeBody, or decodes the body if the route does not decode it.
+func requestBody[T any](r *http.Request) (*T, error) {
+	if body, ok := r.Context().Value(bodyKey{}).(*T); ok {
+		return body, nil
+	}
+	body := new(T)
+	if err := decodeJSON(r.Body, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// peekBody returns the body decoded by decodeBody or, if the route does not decode it, decodes a copy of the body
+// into a T, leaving the body untouched for the handler.
+func peekBody[T any](r *http.Request) (*T, error) {
+	if body, ok := r.Context().Value(bodyKey{}).(*T); ok {
+		return body, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	body := new(T)
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// guardSlot serves the request only if the node serves the slot of the key returned by keyFunc,
+// and answers with a redirect to the node that serves it otherwise. Every request is served if router is nil.
+func guardSlot(router *slots.Router, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if router == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+			if key == "" {
+				// the slot of a request without a key is unknown, so it cannot be served by this node
+				wrapError(w, errKeyRequired())
+				return
+			}
+
+			redirect, release := router.Route(key, r.Header.Get(slots.AskingHeader) != "")
+			if redirect != nil {
+				writeRedirect(w, r, redirect)
+				return
+			}
+			defer release()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeRedirect answers with a redirect to the node of the slot. The redirect is in the RedirectHeader,
+// and the Location header has the same request in the other node.
+func writeRedirect(w http.ResponseWriter, r *http.Request, redirect *slots.Redirect) {
+	e := *apierrors.ErrMoved
+	if redirect.Kind == slots.RedirectAsk {
+		e = *apierrors.ErrAsk
+	}
+	e.Message = redirect.String()
+
+	w.Header().Set(slots.RedirectHeader, redirect.String())
+	w.Header().Set("Location", redirect.URL+r.URL.RequestURI())
+	writeJSON(w, e.HTTPStatus, e)
+}
+
+// HandleTopology returns the topology known by the node.
+func (h *SlotsHandler) HandleTopology(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.router.Topology())
+}
+
+// HandleSetTopology replaces the topology of the node with a newer one.
+func (h *SlotsHandler) HandleSetTopology(w http.ResponseWriter, r *http.Request) {
+	var topology slots.Topology
+	if err := decodeJSON(r.Body, &topology); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	if err := h.router.SetTopology(&topology); err != nil {
+		wrapError(w, h.wrapSlotsError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// HandleMigrate moves a range of slots of the node to another node. It returns once every key has been moved.
+func (h *SlotsHandler) HandleMigrate(w http.ResponseWriter, r *http.Request) {
+	var body schemas.SlotsMigrateRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	moved, err := h.router.Migrate(r.Context(), slots.Range{Start: *body.Start, End: *body.End}, body.Target)
+	if err != nil {
+		h.logger.Error("failed to migrate slots", "start", *body.Start, "end", *body.End, "target", body.Target, "moved", moved, "error", err)
+		wrapError(w, h.wrapSlotsError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.SlotsMigrateResponse{Moved: moved, Epoch: h.router.Topology().Epoch})
+}
+
+// HandleImport makes the node accept the requests of the slots being moved from another node.
+func (h *SlotsHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	var body slots.ImportRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	if err := h.router.Import(body.Range, body.Source); err != nil {
+		wrapError(w, h.wrapSlotsError(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// HandleRestore stores a key moved from another node.
+func (h *SlotsHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	var body slots.RestoreRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	if err := h.db.Restore(body.Key, body.Item); err != nil {
+		h.logger.Error("failed to restore moved key", "key", body.Key, "error", err)
+		if _, ok := err.(*db.DBerror); ok {
+			wrapError(w, h.handler.wrapDBError(err))
+			return
+		}
+		e := *apierrors.ErrInvalidRequest
+		e.Message = err.Error()
+		e.SysMessage = err.Error()
+		wrapError(w, &e)
+		return
+	}
+	writeJSON(w, http.StatusOK, schemas.OKResponse{Message: "ok"})
+}
+
+// wrapSlotsError converts the errors of the router into API errors.
+func (h *SlotsHandler) wrapSlotsError(err error) error {
+	switch {
+	case errors.Is(err, slots.ErrStaleTopology), errors.Is(err, slots.ErrInvalidTopology), errors.Is(err, slots.ErrInvalidMigration):
+		e := *apierrors.ErrTopologyConflict
+		e.Message = err.Error()
+		e.SysMessage = err.Error()
+		return &e
+	default:
+		e := *apierrors.ErrMigrationFailed
+		e.Message = err.Error()
+		e.SysMessage = err.Error()
+		return &e
+	}
+}
@@ -0,0 +1,119 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"memorydb/internal/apierrors"
+	"net/http"
+	"time"
+)
+
+const (
+	streamKeepAliveInterval = 15 * time.Second // interval between keep-alive messages on idle streams
+	streamWriteTimeout      = 10 * time.Second // maximum time to write a single message to a stream
+)
+
+// startSSE writes the headers of a Server-Sent Events stream and returns the flusher used to send the events.
+// If the connection does not support streaming, an error is written to the client and false is returned.
+func startSSE(w http.ResponseWriter) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		e := *apierrors.ErrInternalServer
+		e.Message = "streaming is not supported by the connection"
+		e.SysMessage = "response writer does not implement http.Flusher"
+		wrapError(w, &e)
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return flusher, true
+}
+
+// writeSSE writes an event in the Server-Sent Events format and flushes it. The ID is omitted if it is empty.
+func writeSSE[T any](w http.ResponseWriter, flusher http.Flusher, id string, event string, v T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// writeSSEKeepAlive writes a comment to keep an idle Server-Sent Events stream open through proxies.
+func writeSSEKeepAlive(w http.ResponseWriter, flusher http.Flusher) error {
+	if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
+
+// sseEvent is an event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id   string
+	name string
+	data []byte
+}
+
+// eventReader reads the events of a Server-Sent Events stream.
+type eventReader struct {
+	r *bufio.Reader
+}
+
+// newEventReader creates a reader of the events in r.
+func newEventReader(r io.Reader) *eventReader {
+	return &eventReader{r: bufio.NewReader(r)}
+}
+
+// next returns the next event of the stream. Comments, like the keep-alive messages, are skipped.
+func (e *eventReader) next() (sseEvent, error) {
+	var event sseEvent
+	for {
+		line, err := e.r.ReadBytes('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		// an empty line dispatches the event
+		if len(line) == 0 {
+			if event.name == "" && event.data == nil {
+				continue
+			}
+			return event, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "id":
+			event.id = string(value)
+		case "event":
+			event.name = string(value)
+		case "data":
+			if event.data != nil {
+				event.data = append(event.data, '\n')
+			}
+			event.data = append(event.data, value...)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package transport
+
+import (
+	"fmt"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// HandleStreamAdd appends an entry to the stream stored at the key, creating the stream if it does not exist.
+// If max_len or max_age are given, the stream is trimmed after the entry is added.
+func (h *Handler) HandleStreamAdd(w http.ResponseWriter, r *http.Request) {
+	keyParam := chi.URLParam(r, "key")
+	if keyParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	var body schemas.StreamAddRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	var opts []db.ItemOptions
+	if body.TTL != nil {
+		opts = append(opts, db.WithTTL(body.TTL.Duration))
+	}
+	id, err := keyspace(r, h.db).StreamAdd(keyParam, body.Fields, opts...)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	if body.MaxLen > 0 || body.MaxAge != nil {
+		if _, err := keyspace(r, h.db).StreamTrim(keyParam, body.MaxLen, durationOrZero(body.MaxAge)); err != nil {
+			wrapError(w, h.wrapDBError(err))
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, schemas.StreamAddResponse{ID: id.String()})
+}
+
+// HandleStreamRange returns the entries of the stream with IDs between the `start` and `end` query parameters,
+// which default to the first and the last entry. The `count` query parameter limits the number of entries.
+func (h *Handler) HandleStreamRange(w http.ResponseWriter, r *http.Request) {
+	keyParam := chi.URLParam(r, "key")
+	if keyParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := parseCount(query.Get("count"))
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	entries, err := keyspace(r, h.db).StreamRange(keyParam, queryOrDefault(query.Get("start"), "-"), queryOrDefault(query.Get("end"), "+"), count)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toStreamEntriesResponse(keyParam, entries))
+}
+
+// HandleStreamRead returns the entries of the stream after the ID in the `after` query parameter, where "$" means
+// only new entries. If the `block` query parameter is given, the request waits up to that duration for new entries.
+func (h *Handler) HandleStreamRead(w http.ResponseWriter, r *http.Request) {
+	keyParam := chi.URLParam(r, "key")
+	if keyParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := parseCount(query.Get("count"))
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+	block, err := parseBlock(query.Get("block"))
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	entries, err := keyspace(r, h.db).StreamRead(r.Context(), keyParam, queryOrDefault(query.Get("after"), "0"), count, block)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toStreamEntriesResponse(keyParam, entries))
+}
+
+// HandleStreamTrim removes the oldest entries of the stream beyond max_len entries or older than max_age.
+func (h *Handler) HandleStreamTrim(w http.ResponseWriter, r *http.Request) {
+	keyParam := chi.URLParam(r, "key")
+	if keyParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	var body schemas.StreamTrimRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	removed, err := keyspace(r, h.db).StreamTrim(keyParam, body.MaxLen, durationOrZero(body.MaxAge))
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schemas.StreamTrimResponse{Removed: removed})
+}
+
+// HandleStreamGroupCreate creates a consumer group in the stream.
+func (h *Handler) HandleStreamGroupCreate(w http.ResponseWriter, r *http.Request) {
+	keyParam := chi.URLParam(r, "key")
+	if keyParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	var body schemas.StreamGroupCreateRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	if err := keyspace(r, h.db).StreamGroupCreate(keyParam, body.Group, queryOrDefault(body.Start, "$")); err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schemas.OKResponse{Message: "ok"})
+}
+
+// HandleStreamReadGroup delivers the new entries of the stream to the consumer in the `consumer` query parameter.
+// The entries stay pending until they are acknowledged. If the `block` query parameter is given, the request
+// waits up to that duration for new entries.
+func (h *Handler) HandleStreamReadGroup(w http.ResponseWriter, r *http.Request) {
+	keyParam, groupParam := chi.URLParam(r, "key"), chi.URLParam(r, "group")
+	if keyParam == "" || groupParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	consumer := query.Get("consumer")
+	if consumer == "" {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = "the consumer query parameter is required"
+		e.SysMessage = e.Message
+		wrapError(w, &e)
+		return
+	}
+	count, err := parseCount(query.Get("count"))
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+	block, err := parseBlock(query.Get("block"))
+	if err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	entries, err := keyspace(r, h.db).StreamReadGroup(r.Context(), keyParam, groupParam, consumer, count, block)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toStreamEntriesResponse(keyParam, entries))
+}
+
+// HandleStreamAck acknowledges entries pending in the consumer group.
+func (h *Handler) HandleStreamAck(w http.ResponseWriter, r *http.Request) {
+	keyParam, groupParam := chi.URLParam(r, "key"), chi.URLParam(r, "group")
+	if keyParam == "" || groupParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	var body schemas.StreamAckRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	acked, err := keyspace(r, h.db).StreamAck(keyParam, groupParam, body.IDs...)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schemas.StreamAckResponse{Acknowledged: acked})
+}
+
+// HandleStreamPending returns the entries of the consumer group that have not been acknowledged yet.
+// The `consumer` query parameter limits the result to the entries delivered to that consumer.
+func (h *Handler) HandleStreamPending(w http.ResponseWriter, r *http.Request) {
+	keyParam, groupParam := chi.URLParam(r, "key"), chi.URLParam(r, "group")
+	if keyParam == "" || groupParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	pending, err := keyspace(r, h.db).StreamPending(keyParam, groupParam, r.URL.Query().Get("consumer"))
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	response := schemas.StreamPendingResponse{
+		Key:     keyParam,
+		Group:   groupParam,
+		Pending: make([]schemas.PendingEntryResponse, len(pending)),
+	}
+	for i, entry := range pending {
+		response.Pending[i] = schemas.PendingEntryResponse{
+			ID:            entry.ID.String(),
+			Consumer:      entry.Consumer,
+			DeliveredAt:   entry.DeliveredAt,
+			DeliveryCount: entry.DeliveryCount,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// HandleStreamClaim transfers to a consumer the pending entries of the group that have been idle for at least min_idle.
+func (h *Handler) HandleStreamClaim(w http.ResponseWriter, r *http.Request) {
+	keyParam, groupParam := chi.URLParam(r, "key"), chi.URLParam(r, "group")
+	if keyParam == "" || groupParam == "" {
+		wrapError(w, apierrors.ErrURLParamNotFound)
+		return
+	}
+
+	var body schemas.StreamClaimRequest
+	if err := decodeJSON(r.Body, &body); err != nil {
+		wrapError(w, err)
+		return
+	}
+
+	entries, err := keyspace(r, h.db).StreamClaim(keyParam, groupParam, body.Consumer, durationOrZero(body.MinIdle), body.IDs...)
+	if err != nil {
+		wrapError(w, h.wrapDBError(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toStreamEntriesResponse(keyParam, entries))
+}
+
+// toStreamEntriesResponse converts the entries of a stream into their API representation.
+func toStreamEntriesResponse(key string, entries []db.StreamEntry) schemas.StreamEntriesResponse {
+	response := schemas.StreamEntriesResponse{Key: key, Entries: make([]schemas.StreamEntryResponse, len(entries))}
+	for i, entry := range entries {
+		response.Entries[i] = schemas.StreamEntryResponse{ID: entry.ID.String(), Fields: entry.Fields}
+	}
+	return response
+}
+
+// parseCount parses the `count` query parameter, where an empty value or 0 means no limit.
+func parseCount(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(raw)
+	if err != nil || count < 0 {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = fmt.Sprintf("invalid count '%s', it must be a non-negative integer", raw)
+		e.SysMessage = e.Message
+		return 0, &e
+	}
+	return count, nil
+}
+
+// parseBlock parses the `block` query parameter as a duration, where an empty value means the read does not block.
+func parseBlock(raw string) (time.Duration, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	block, err := time.ParseDuration(raw)
+	if err != nil || block < 0 {
+		e := *apierrors.ErrInvalidRequest
+		e.Message = fmt.Sprintf("invalid block duration '%s'", raw)
+		e.SysMessage = e.Message
+		return 0, &e
+	}
+	return block, nil
+}
+
+// queryOrDefault returns the value, or the fallback if it is empty.
+func queryOrDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// durationOrZero returns the wrapped duration, or 0 if it is nil.
+func durationOrZero(d *schemas.Duration) time.Duration {
+	if d == nil {
+		return 0
+	}
+	return d.Duration
+}
@@ -0,0 +1,263 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"memorydb/internal/apierrors"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type StreamHandlerSuite struct {
+	db      *db.MockDBClient
+	handler *transport.Handler
+	suite.Suite
+}
+
+func (s *StreamHandlerSuite) SetupTest() {
+	s.db = db.NewMockDBClient(s.T())
+	s.handler = transport.NewHandler(slog.Default(), s.db)
+}
+
+func (s *StreamHandlerSuite) TestAdd() {
+	s.Run("Add ok", func() {
+		body := `{"fields": {"amount": "10"}, "ttl": "1h", "max_len": 100}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/orders", bytes.NewBufferString(body))
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamAdd", "orders", map[string]string{"amount": "10"}, mock.Anything).Return(db.StreamID{Ms: 1, Seq: 2}, nil).Once()
+		s.db.On("StreamTrim", "orders", 100, time.Duration(0)).Return(0, nil).Once()
+		s.handler.HandleStreamAdd(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var response schemas.StreamAddResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		s.Equal("1-2", response.ID)
+	})
+
+	s.Run("Add without fields", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/orders", bytes.NewBufferString(`{"fields": {}}`))
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		s.handler.HandleStreamAdd(w, req)
+		s.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("Add to a key that is not a stream", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/str", bytes.NewBufferString(`{"fields": {"a": "b"}}`))
+		req = withUrlParams(req, map[string]string{"key": "str"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamAdd", "str", map[string]string{"a": "b"}, mock.Anything).Return(db.StreamID{}, db.ErrNotAStream).Once()
+		s.handler.HandleStreamAdd(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusBadRequest, resp.StatusCode)
+
+		var errResponse apierrors.ApiError
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+		s.Equal(apierrors.ErrWrongType.Code, errResponse.Code)
+	})
+}
+
+func (s *StreamHandlerSuite) TestRange() {
+	s.Run("Range ok", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/orders?start=1&count=5", nil)
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		entries := []db.StreamEntry{{ID: db.StreamID{Ms: 1, Seq: 0}, Fields: map[string]string{"a": "b"}}}
+		s.db.On("StreamRange", "orders", "1", "+", 5).Return(entries, nil).Once()
+		s.handler.HandleStreamRange(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var response schemas.StreamEntriesResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		s.Require().Len(response.Entries, 1)
+		s.Equal("1-0", response.Entries[0].ID)
+		s.Equal("b", response.Entries[0].Fields["a"])
+	})
+
+	s.Run("Range invalid count", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/orders?count=-1", nil)
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		s.handler.HandleStreamRange(w, req)
+		s.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("Range not found", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/missing", nil)
+		req = withUrlParams(req, map[string]string{"key": "missing"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamRange", "missing", "-", "+", 0).Return(nil, db.ErrDataNotFound).Once()
+		s.handler.HandleStreamRange(w, req)
+		s.Equal(http.StatusNotFound, w.Result().StatusCode)
+	})
+}
+
+func (s *StreamHandlerSuite) TestRead() {
+	s.Run("Read ok", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/orders/read?after=$&block=2s", nil)
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamRead", mock.Anything, "orders", "$", 0, 2*time.Second).Return([]db.StreamEntry{}, nil).Once()
+		s.handler.HandleStreamRead(w, req)
+		s.Equal(http.StatusOK, w.Result().StatusCode)
+	})
+
+	s.Run("Read invalid block", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/orders/read?block=soon", nil)
+		req = withUrlParams(req, map[string]string{"key": "orders"})
+		w := httptest.NewRecorder()
+
+		s.handler.HandleStreamRead(w, req)
+		s.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	})
+}
+
+func (s *StreamHandlerSuite) TestGroups() {
+	s.Run("Create group ok", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/jobs/groups", bytes.NewBufferString(`{"group": "workers"}`))
+		req = withUrlParams(req, map[string]string{"key": "jobs"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamGroupCreate", "jobs", "workers", "$").Return(nil).Once()
+		s.handler.HandleStreamGroupCreate(w, req)
+		s.Equal(http.StatusCreated, w.Result().StatusCode)
+	})
+
+	s.Run("Create group conflict", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/jobs/groups", bytes.NewBufferString(`{"group": "workers", "start": "0"}`))
+		req = withUrlParams(req, map[string]string{"key": "jobs"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamGroupCreate", "jobs", "workers", "0").Return(db.ErrStreamGroupExists).Once()
+		s.handler.HandleStreamGroupCreate(w, req)
+		s.Equal(http.StatusConflict, w.Result().StatusCode)
+	})
+
+	s.Run("Read group ok", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/jobs/groups/workers/read?consumer=alice&count=10", nil)
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "workers"})
+		w := httptest.NewRecorder()
+
+		entries := []db.StreamEntry{{ID: db.StreamID{Ms: 3}, Fields: map[string]string{"job": "1"}}}
+		s.db.On("StreamReadGroup", mock.Anything, "jobs", "workers", "alice", 10, time.Duration(0)).Return(entries, nil).Once()
+		s.handler.HandleStreamReadGroup(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var response schemas.StreamEntriesResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		s.Require().Len(response.Entries, 1)
+		s.Equal("3-0", response.Entries[0].ID)
+	})
+
+	s.Run("Read group without consumer", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/jobs/groups/workers/read", nil)
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "workers"})
+		w := httptest.NewRecorder()
+
+		s.handler.HandleStreamReadGroup(w, req)
+		s.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	s.Run("Read group not found", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/jobs/groups/missing/read?consumer=alice", nil)
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "missing"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamReadGroup", mock.Anything, "jobs", "missing", "alice", 0, time.Duration(0)).Return(nil, db.ErrStreamGroupNotFound).Once()
+		s.handler.HandleStreamReadGroup(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusNotFound, resp.StatusCode)
+
+		var errResponse apierrors.ApiError
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&errResponse))
+		s.Equal(apierrors.ErrGroupNotFound.Code, errResponse.Code)
+	})
+
+	s.Run("Ack ok", func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/jobs/groups/workers/ack", bytes.NewBufferString(`{"ids": ["3-0", "4-0"]}`))
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "workers"})
+		w := httptest.NewRecorder()
+
+		s.db.On("StreamAck", "jobs", "workers", []string{"3-0", "4-0"}).Return(1, nil).Once()
+		s.handler.HandleStreamAck(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var response schemas.StreamAckResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		s.Equal(1, response.Acknowledged)
+	})
+
+	s.Run("Pending ok", func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/streams/jobs/groups/workers/pending?consumer=alice", nil)
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "workers"})
+		w := httptest.NewRecorder()
+
+		pending := []db.PendingEntry{{ID: db.StreamID{Ms: 3}, Consumer: "alice", DeliveredAt: time.Now(), DeliveryCount: 1}}
+		s.db.On("StreamPending", "jobs", "workers", "alice").Return(pending, nil).Once()
+		s.handler.HandleStreamPending(w, req)
+
+		resp := w.Result()
+		s.Equal(http.StatusOK, resp.StatusCode)
+
+		var response schemas.StreamPendingResponse
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&response))
+		s.Require().Len(response.Pending, 1)
+		s.Equal("3-0", response.Pending[0].ID)
+		s.Equal("alice", response.Pending[0].Consumer)
+	})
+
+	s.Run("Claim ok", func() {
+		body := `{"consumer": "bob", "min_idle": "30s", "ids": ["3-0"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/streams/jobs/groups/workers/claim", bytes.NewBufferString(body))
+		req = withUrlParams(req, map[string]string{"key": "jobs", "group": "workers"})
+		w := httptest.NewRecorder()
+
+		entries := []db.StreamEntry{{ID: db.StreamID{Ms: 3}, Fields: map[string]string{"job": "1"}}}
+		s.db.On("StreamClaim", "jobs", "workers", "bob", 30*time.Second, []string{"3-0"}).Return(entries, nil).Once()
+		s.handler.HandleStreamClaim(w, req)
+		s.Equal(http.StatusOK, w.Result().StatusCode)
+	})
+}
+
+func TestStreamHandlerSuite(t *testing.T) {
+	suite.Run(t, new(StreamHandlerSuite))
+}
+
+// withUrlParams returns a pointer to a request object with all the given URL params
+// added to a new chi.Context object.
+func withUrlParams(r *http.Request, params map[string]string) *http.Request {
+	chiCtx := chi.NewRouteContext()
+	for key, value := range params {
+		chiCtx.URLParams.Add(key, value)
+	}
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, chiCtx))
+}
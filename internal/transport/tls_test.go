@@ -0,0 +1,130 @@
+package transport_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"memorydb/internal/certs"
+	"memorydb/internal/certs/certstest"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb/memdbpb"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+type TLSSuite struct {
+	db     db.DBClient
+	ca     *certstest.CA
+	server *transport.Server
+	ports  map[string]int // ports of the RESP, gRPC and memcached listeners
+	suite.Suite
+}
+
+func (s *TLSSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.ca = certstest.NewCA(s.T(), "ca")
+	dir := s.T().TempDir()
+	s.ca.Issue("localhost", filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	reloader, err := certs.NewReloader(slog.Default(), filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	s.Require().NoError(err)
+
+	s.ports = map[string]int{"resp": freePort(s.T()), "grpc": freePort(s.T()), "memcached": freePort(s.T())}
+	s.server = transport.NewServer(slog.Default(), 0, 0, s.db,
+		transport.WithTLS{Reloader: reloader},
+		transport.WithRESPPort(s.ports["resp"]),
+		transport.WithGRPCPort(s.ports["grpc"]),
+		transport.WithMemcachedPort(s.ports["memcached"]),
+	)
+	go s.server.StartRESP()
+	go s.server.StartGRPC()
+	go s.server.StartMemcached()
+}
+
+func (s *TLSSuite) TearDownTest() {
+	s.server.Shutdown()
+	s.db.Close()
+}
+
+// freePort returns a TCP port that is not in use.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// address returns the local address of the listener of the protocol.
+func (s *TLSSuite) address(protocol string) string {
+	return "127.0.0.1:" + strconv.Itoa(s.ports[protocol])
+}
+
+// exchange connects to the listener over TLS, sends the request and returns the first line of the answer.
+// It retries the connection until the listener is up.
+func (s *TLSSuite) exchange(protocol, request string) string {
+	config := &tls.Config{RootCAs: s.ca.Pool(), ServerName: "localhost"}
+	var conn *tls.Conn
+	s.Require().Eventually(func() bool {
+		var err error
+		conn, err = tls.Dial("tcp", s.address(protocol), config)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	_, err := conn.Write([]byte(request))
+	s.Require().NoError(err)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	s.Require().NoError(err)
+	return line
+}
+
+func (s *TLSSuite) TestRESP() {
+	s.Equal("+PONG\r\n", s.exchange("resp", "*1\r\n$4\r\nPING\r\n"))
+}
+
+func (s *TLSSuite) TestMemcached() {
+	s.Contains(s.exchange("memcached", "version\r\n"), "VERSION")
+}
+
+func (s *TLSSuite) TestGRPC() {
+	creds := credentials.NewTLS(&tls.Config{RootCAs: s.ca.Pool(), ServerName: "localhost"})
+	conn, err := grpc.NewClient(s.address("grpc"), grpc.WithTransportCredentials(creds))
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = memdbpb.NewMemoryDBClient(conn).Get(ctx, &memdbpb.GetRequest{Key: "missing"}, grpc.WaitForReady(true))
+	s.Equal(codes.NotFound, status.Code(err), "the call is served over TLS")
+}
+
+func (s *TLSSuite) TestPlaintextRejected() {
+	// a plaintext request is not a TLS handshake, so the server closes the connection without answering
+	s.exchange("resp", "*1\r\n$4\r\nPING\r\n")
+	conn, err := net.Dial("tcp", s.address("resp"))
+	s.Require().NoError(err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("*1\r\n$4\r\nPING\r\n"))
+	s.Require().NoError(err)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	s.NotEqual("+PONG\r\n", line)
+}
+
+func TestTLSSuite(t *testing.T) {
+	suite.Run(t, new(TLSSuite))
+}
@@ -0,0 +1,85 @@
+package transport_test
+
+import (
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingSuite struct {
+	db       db.DBClient
+	server   *transport.Server
+	recorder *tracetest.SpanRecorder
+	provider trace.TracerProvider // global provider before the test
+	suite.Suite
+}
+
+func (s *TracingSuite) SetupTest() {
+	s.recorder = tracetest.NewSpanRecorder()
+	s.provider = otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.recorder)))
+
+	s.db = db.NewMemoryDB(slog.Default())
+	s.server = transport.NewServer(slog.Default(), 0, 0, s.db)
+}
+
+func (s *TracingSuite) TearDownTest() {
+	otel.SetTracerProvider(s.provider)
+	s.db.Close()
+}
+
+// spans returns the ended spans by name.
+func (s *TracingSuite) spans() map[string][]sdktrace.ReadOnlySpan {
+	spans := make(map[string][]sdktrace.ReadOnlySpan)
+	for _, span := range s.recorder.Ended() {
+		spans[span.Name()] = append(spans[span.Name()], span)
+	}
+	return spans
+}
+
+func (s *TracingSuite) TestPropagation() {
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/set", strings.NewReader(`{"key": "a", "value": "1"}`))
+	r.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+	s.server.Handler().ServeHTTP(w, r)
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	spans := s.spans()
+	s.Require().Len(spans["POST /api/v1/set"], 1, "the span should be named after the route")
+	request := spans["POST /api/v1/set"][0]
+	s.Equal(traceID, request.SpanContext().TraceID().String(), "the span should continue the trace of the headers")
+	s.Equal(parentID, request.Parent().SpanID().String())
+	s.Contains(request.Attributes(), attribute.String("http.route", "/api/v1/set"))
+
+	s.Require().Len(spans["db.set"], 1)
+	s.Equal(request.SpanContext().SpanID(), spans["db.set"][0].Parent().SpanID(), "the operation should be a child of the request")
+	s.Len(spans["db.lock_wait"], 1)
+	s.Len(spans["db.log_write"], 1)
+}
+
+func (s *TracingSuite) TestRouteName() {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/missing", nil)
+	w := httptest.NewRecorder()
+	s.server.Handler().ServeHTTP(w, r)
+	s.Require().Equal(http.StatusNotFound, w.Code)
+
+	spans := s.spans()
+	s.Require().Len(spans["GET /api/v1/{key}"], 1, "the requests of every key should have the same name")
+	s.False(spans["GET /api/v1/{key}"][0].Parent().IsValid(), "a request without trace context should start a trace")
+}
+
+func TestTracingSuite(t *testing.T) {
+	suite.Run(t, new(TracingSuite))
+}
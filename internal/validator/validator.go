@@ -12,10 +12,13 @@ type Validator interface {
 	Validate() error // Validate validates an object.
 }
 
+// validate validates the structs. It is shared by every call, since it caches the rules of the structs it validates
+// and is safe for concurrent use.
+var validate = validator.New(validator.WithRequiredStructEnabled())
+
 // ValidateJSON validates a given struct with json tags, and returns an error if the validation fails.
 func ValidateJSON(obj any) error {
-	v := validator.New(validator.WithRequiredStructEnabled())
-	if err := handleValidationErrors(v.Struct(obj)); err != nil {
+	if err := handleValidationErrors(validate.Struct(obj)); err != nil {
 		return err
 	}
 
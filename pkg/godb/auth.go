@@ -0,0 +1,252 @@
+package godb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ErrInvalidCredentials is returned by Login when the server rejects the username or the password.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// session holds the credentials of the user and the tokens issued by every server. It is shared by the clients
+// of every server of the sharded clients, which log in to a server the first time they send it a request.
+//
+// The lock is held while the tokens are requested, so the requests that find an expired token wait for a single
+// refresh instead of refreshing it once each.
+type session struct {
+	prefix string          // prefix of the API endpoints
+	base   *http.Transport // transport of the connections to the servers, with the TLS configuration of the client
+	client *http.Client    // client of the authentication endpoints
+
+	mu       sync.Mutex
+	apiKey   string                           // API key sent instead of the tokens, empty if the client has none
+	username string                           // username of the user, empty if the client is not logged in
+	password string                           // password of the user, to log in again once the refresh token expires
+	tokens   map[string]schemas.TokenResponse // tokens of every server, by URL
+}
+
+// newSession returns the session of a client that is not logged in.
+func newSession(version string) *session {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	return &session{
+		prefix: "/api/" + version,
+		base:   base,
+		client: &http.Client{Timeout: 10 * time.Second, Transport: base},
+		tokens: make(map[string]schemas.TokenResponse),
+	}
+}
+
+// configure applies the options to the connections of the session, before any request is sent.
+func (s *session) configure(opts ...ClientOptions) {
+	for _, opt := range opts {
+		opt.apply(s.base.TLSClientConfig)
+	}
+}
+
+// transport returns a transport that sends the requests to the server with the URL through next, with the access
+// token of the session once it is logged in. The trace context of the requests is sent in their headers.
+func (s *session) transport(node string, next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(&authTransport{session: s, node: node, next: next}, otelhttp.WithPropagators(propagation.TraceContext{}))
+}
+
+// start logs in to the servers with the credentials, which are kept to log in to other servers later.
+func (s *session) start(username, password string, nodes ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.username, s.password = username, password
+	clear(s.tokens)
+	for _, node := range nodes {
+		if _, err := s.login(node); err != nil {
+			s.username, s.password = "", ""
+			clear(s.tokens)
+			return err
+		}
+	}
+	return nil
+}
+
+// end logs out from every server the session logged in to and forgets the credentials.
+func (s *session) end() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for node, tokens := range s.tokens {
+		if err := s.post(node, "logout", tokens.AccessToken, nil, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	s.username, s.password = "", ""
+	clear(s.tokens)
+	return errors.Join(errs...)
+}
+
+// useAPIKey sets the API key sent with the requests instead of the tokens of the user. An empty key stops sending it.
+func (s *session) useAPIKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey = key
+}
+
+// key returns the API key of the session, empty if it has none.
+func (s *session) key() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.apiKey
+}
+
+// accessToken returns the access token of the server, logging in if the session has none. It returns an empty
+// token if the client is not logged in.
+func (s *session) accessToken(node string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.username == "" {
+		return "", nil
+	}
+	if tokens, ok := s.tokens[node]; ok {
+		return tokens.AccessToken, nil
+	}
+	return s.login(node)
+}
+
+// renew replaces the access token of the server rejected by it. The tokens are refreshed, unless another request
+// already did it, and the session logs in again if the refresh token is no longer valid.
+func (s *session) renew(node, rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, ok := s.tokens[node]
+	if ok && tokens.AccessToken != rejected {
+		return tokens.AccessToken, nil
+	}
+	if s.username == "" {
+		return "", ErrInvalidCredentials
+	}
+	if ok {
+		var refreshed schemas.TokenResponse
+		if err := s.post(node, "refresh", "", schemas.RefreshTokenRequest{RefreshToken: tokens.RefreshToken}, &refreshed); err == nil {
+			s.tokens[node] = refreshed
+			return refreshed.AccessToken, nil
+		}
+	}
+	return s.login(node)
+}
+
+// login logs in to the server and returns its access token. It must be called with the lock held.
+func (s *session) login(node string) (string, error) {
+	var tokens schemas.TokenResponse
+	if err := s.post(node, "login", "", schemas.LoginRequest{Username: s.username, Password: s.password}, &tokens); err != nil {
+		return "", err
+	}
+	s.tokens[node] = tokens
+	return tokens.AccessToken, nil
+}
+
+// post sends the body to the authentication endpoint of the server and decodes the response into out.
+func (s *session) post(node, name, token string, body any, out any) error {
+	endpoint, err := url.JoinPath(node, s.prefix, "auth", name)
+	if err != nil {
+		return fmt.Errorf("failed to join path for %s: %w", name, err)
+	}
+
+	var data []byte
+	if body != nil {
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", endpoint, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if name == "login" && resp.StatusCode == http.StatusUnauthorized {
+		return ErrInvalidCredentials
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send request to %s: received status code %d", endpoint, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// authTransport sends the requests with the API key or the access token of the session, and renews the token and
+// sends the request again when the server rejects it.
+type authTransport struct {
+	session *session
+	node    string            // URL of the server
+	next    http.RoundTripper // transport the requests are sent with
+}
+
+// RoundTrip sends the request with the API key of the session, or else with the access token of the server.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if key := t.session.key(); key != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-API-Key", key)
+		return t.next.RoundTrip(req)
+	}
+
+	token, err := t.session.accessToken(t.node)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return t.next.RoundTrip(req)
+	}
+
+	resp, err := t.send(req, token)
+	// the request is only sent again if its body can be read again
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	if token, err = t.session.renew(t.node, token); err != nil {
+		return nil, err
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	return t.send(req, token)
+}
+
+// send sends a copy of the request with the access token.
+func (t *authTransport) send(req *http.Request, token string) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.next.RoundTrip(req)
+}
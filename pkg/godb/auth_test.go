@@ -0,0 +1,151 @@
+package godb_test
+
+import (
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthClientSuite struct {
+	db      db.DBClient
+	manager *auth.Manager
+	server  *httptest.Server
+	client  godb.ApiClient
+
+	mu  sync.Mutex
+	now time.Time // clock of the manager
+	suite.Suite
+}
+
+func (s *AuthClientSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	s.now = time.Now()
+
+	var err error
+	s.manager, err = auth.NewManager(slog.Default(), s.db, "0123456789abcdef0123456789abcdef", "admin", "admin-password", auth.WithClock(s.clock))
+	s.Require().NoError(err)
+	s.Require().NoError(s.manager.Register("admin", "admin-password", "alice", "secret"))
+
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithAuthManager{AuthManager: s.manager}).Handler())
+	s.client = godb.NewClient(s.server.URL, "v1")
+}
+
+func (s *AuthClientSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+}
+
+func (s *AuthClientSuite) clock() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// advance moves the clock of the manager forward.
+func (s *AuthClientSuite) advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+func (s *AuthClientSuite) TestLogin() {
+	_, err := s.client.Set("a", "1", nil)
+	s.Error(err, "the requests should fail before logging in")
+
+	s.ErrorIs(s.client.Login("alice", "wrong"), godb.ErrInvalidCredentials)
+	s.Require().NoError(s.client.Login("alice", "secret"))
+
+	_, err = s.client.Set("a", "1", nil)
+	s.Require().NoError(err)
+	item, err := s.client.Get("a")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value)
+
+	s.Require().NoError(s.client.Logout())
+	_, err = s.client.Get("a")
+	s.Error(err, "the requests should fail after logging out")
+}
+
+func (s *AuthClientSuite) TestRefresh() {
+	s.Require().NoError(s.client.Login("alice", "secret"))
+
+	// the access token expires, and the client refreshes it and sends the request again
+	s.advance(time.Hour)
+	_, err := s.client.Set("a", "1", nil)
+	s.Require().NoError(err)
+
+	// another login revokes the tokens of the client, which logs in again
+	_, err = s.manager.Login("alice", "secret")
+	s.Require().NoError(err)
+	_, err = s.client.MSet(map[string]any{"b": "2"}, nil)
+	s.Require().NoError(err, "the body should be sent again")
+	item, err := s.client.Get("b")
+	s.Require().NoError(err)
+	s.Equal("2", item.Value)
+}
+
+func (s *AuthClientSuite) TestAPIKey() {
+	_, key, err := s.manager.CreateAPIKey("billing", "", []db.ACLRule{
+		{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}},
+	})
+	s.Require().NoError(err)
+	s.client.UseAPIKey(key)
+
+	_, err = s.client.Set("billing:1", "1", nil)
+	s.Require().NoError(err)
+	item, err := s.client.Get("billing:1")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value)
+	_, err = s.client.Set("config:timeout", "1", nil)
+	s.Error(err, "the key should not be allowed to write outside billing:*")
+
+	s.client.UseAPIKey("")
+	_, err = s.client.Get("billing:1")
+	s.Error(err, "the requests should fail without the API key")
+}
+
+func (s *AuthClientSuite) TestNamespace() {
+	s.Require().NoError(s.db.CreateNamespace("sessions", db.NamespaceConfig{}))
+	s.Require().NoError(s.client.Login("alice", "secret"))
+	sessions := s.client.Namespace("sessions")
+
+	_, err := sessions.Set("a", "namespaced", nil)
+	s.Require().NoError(err, "the namespaced client should share the session of the client")
+	_, err = s.client.Set("a", "default", nil)
+	s.Require().NoError(err)
+
+	item, err := sessions.Get("a")
+	s.Require().NoError(err)
+	s.Equal("namespaced", item.Value)
+	item, err = s.client.Get("a")
+	s.Require().NoError(err)
+	s.Equal("default", item.Value)
+	item, err = sessions.Namespace("").Get("a")
+	s.Require().NoError(err)
+	s.Equal("default", item.Value)
+
+	results, err := sessions.MGet("a")
+	s.Require().NoError(err)
+	s.Require().NoError(results["a"].Err)
+	s.Equal("namespaced", results["a"].Item.Value)
+
+	_, err = s.client.Namespace("missing").Get("a")
+	s.Error(err)
+
+	s.Require().NoError(s.client.Logout())
+	_, err = sessions.Get("a")
+	s.Error(err, "logging out should end the session of the namespaced client")
+}
+
+func TestAuthClientSuite(t *testing.T) {
+	suite.Run(t, new(AuthClientSuite))
+}
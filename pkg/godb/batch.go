@@ -0,0 +1,162 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"memorydb/internal/db"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// GetResult is the result of a key of MGet. Err is a *KeyError if the server could not read the key.
+type GetResult struct {
+	Item *ApiResponse
+	Err  error
+}
+
+// KeyError is the error returned by the server for a key of a batch request.
+type KeyError struct {
+	Code    string `json:"code"`    // code of the error, such as item_not_found
+	Message string `json:"message"` // human readable message of the error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// batchResponse is the response of a batch request.
+type batchResponse struct {
+	Results map[string]keyResult `json:"results"`
+}
+
+// keyResult is the result of a key of a batch request.
+type keyResult struct {
+	Item  *ApiResponse `json:"item,omitempty"`
+	Error *KeyError    `json:"error,omitempty"`
+}
+
+// err returns the error of the key, nil if it succeeded. The redirects of the hash slots are returned
+// as a *RedirectError, as for the requests of a single key.
+func (r keyResult) err() error {
+	if r.Error == nil {
+		return nil
+	}
+	if r.Error.Code == "moved" || r.Error.Code == "ask" {
+		if redirect, err := slots.ParseRedirect(r.Error.Message); err == nil {
+			return &RedirectError{Ask: redirect.Kind == slots.RedirectAsk, Slot: redirect.Slot, URL: redirect.URL}
+		}
+	}
+	return r.Error
+}
+
+// MGet retrieves the items of several keys in a single request. Every key has its own result, so a missing
+// key does not fail the others.
+func (c *client) MGet(keys ...string) (map[string]GetResult, error) {
+	var response batchResponse
+	if err := c.doBatchRequest("mget", schemas.MGetRequest{Keys: keys}, &response); err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]GetResult, len(response.Results))
+	for key, result := range response.Results {
+		results[key] = GetResult{Item: result.Item, Err: result.err()}
+	}
+	return results, nil
+}
+
+// MSet stores several items with the same TTL in a single request. It returns the error of every key,
+// nil for the keys that were stored.
+func (c *client) MSet(items map[string]any, ttl *time.Duration) (map[string]error, error) {
+	var response batchResponse
+	if err := c.doBatchRequest("mset", msetRequest(items, ttl, false), &response); err != nil {
+		return nil, err
+	}
+	return keyErrors(response), nil
+}
+
+// MSetAtomic stores several items with the same TTL, all of them or none of them.
+func (c *client) MSetAtomic(items map[string]any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	var response schemas.OKResponse
+	if err := c.doBatchRequest("mset", msetRequest(items, ttl, true), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// MDel removes several keys in a single request. It returns the error of every key, nil for the keys that
+// were removed.
+func (c *client) MDel(keys ...string) (map[string]error, error) {
+	var response batchResponse
+	if err := c.doBatchRequest("mdel", schemas.MDelRequest{Keys: keys}, &response); err != nil {
+		return nil, err
+	}
+	return keyErrors(response), nil
+}
+
+// doBatchRequest sends the body to the batch endpoint and decodes the response into out.
+func (c *client) doBatchRequest(name string, body any, out any) error {
+	endpoint, err := url.JoinPath(c.url, c.keyspace, name)
+	if err != nil {
+		return fmt.Errorf("failed to join path for %s: %w", name, err)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
+	}
+
+	resp, err := c.post(endpoint, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("failed to send batch request to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send batch request to %s: received status code %d", endpoint, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return nil
+}
+
+// msetRequest returns the body of a MSET request.
+func msetRequest(items map[string]any, ttl *time.Duration, atomic bool) schemas.MSetRequest {
+	data := schemas.MSetRequest{Items: make(map[string]db.StringOrSlice, len(items)), Atomic: atomic}
+	for key, value := range items {
+		data.Items[key] = db.StringOrSlice{Val: value}
+	}
+	if ttl != nil {
+		data.TTL = &schemas.Duration{Duration: *ttl}
+	}
+	return data
+}
+
+// keyErrors returns the error of every key of the response.
+func keyErrors(response batchResponse) map[string]error {
+	errs := make(map[string]error, len(response.Results))
+	for key, result := range response.Results {
+		errs[key] = result.err()
+	}
+	return errs
+}
+
+// itemsOf returns the items of the keys.
+func itemsOf(items map[string]any, keys []string) map[string]any {
+	subset := make(map[string]any, len(keys))
+	for _, key := range keys {
+		subset[key] = items[key]
+	}
+	return subset
+}
+
+// sortedKeys returns the keys of the items, sorted.
+func sortedKeys(items map[string]any) []string {
+	return slices.Sorted(maps.Keys(items))
+}
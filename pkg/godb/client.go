@@ -2,8 +2,10 @@ package godb
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"memorydb/internal/db"
 	"memorydb/internal/transport/schemas"
 	"net/http"
@@ -17,6 +19,17 @@ var (
 
 // ApiClient defines the interface for interacting with the in-memory database API.
 type ApiClient interface {
+	// Login logs in with the credentials of a user. The access token is sent with every request from then on,
+	// and renewed when it expires.
+	Login(username, password string) error
+
+	// Logout ends the session of the user, which revokes its tokens.
+	Logout() error
+
+	// UseAPIKey sends the API key with every request from then on, instead of the access token of a user.
+	// An empty key stops sending it.
+	UseAPIKey(key string)
+
 	// Get retrieves the value associated with a key from the memory database.
 	Get(key string) (*ApiResponse, error)
 
@@ -34,35 +47,177 @@ type ApiClient interface {
 
 	// Pop removes the last item from a slice stored at the specified key in the memory database.
 	Pop(key string) (*ApiResponse, error)
+
+	// MGet retrieves the items of several keys. Every key has its own result, so a missing key does not fail the others.
+	MGet(keys ...string) (map[string]GetResult, error)
+
+	// MSet stores several items with the same TTL and returns the error of every key, nil for the keys that were stored.
+	MSet(items map[string]any, ttl *time.Duration) (map[string]error, error)
+
+	// MSetAtomic stores several items with the same TTL, all of them or none of them.
+	MSetAtomic(items map[string]any, ttl *time.Duration) (*schemas.OKResponse, error)
+
+	// MDel removes several keys and returns the error of every key, nil for the keys that were removed.
+	MDel(keys ...string) (map[string]error, error)
+
+	// Pipeline returns an empty pipeline, which queues commands to send them in a single request.
+	Pipeline() *Pipeline
+
+	// StreamAdd appends an entry with the given fields to the stream stored at the key, creating the stream if needed.
+	StreamAdd(key string, fields map[string]string, ttl *time.Duration) (*schemas.StreamAddResponse, error)
+
+	// StreamRange returns up to count entries of the stream with IDs between start and end, both included.
+	StreamRange(key string, start, end string, count int) (*schemas.StreamEntriesResponse, error)
+
+	// StreamRead returns up to count entries of the stream after the given ID, waiting up to block for new entries.
+	StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error)
+
+	// StreamTrim removes the oldest entries of the stream beyond maxLen entries or older than maxAge.
+	StreamTrim(key string, maxLen int, maxAge time.Duration) (*schemas.StreamTrimResponse, error)
+
+	// StreamGroupCreate creates a consumer group in the stream that delivers the entries after start.
+	StreamGroupCreate(key string, group string, start string) (*schemas.OKResponse, error)
+
+	// StreamReadGroup delivers up to count new entries to the consumer of the group, waiting up to block for them.
+	StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error)
+
+	// StreamAck acknowledges entries pending in the consumer group.
+	StreamAck(key string, group string, ids ...string) (*schemas.StreamAckResponse, error)
+
+	// StreamPending returns the entries of the consumer group that have not been acknowledged yet.
+	StreamPending(key string, group string, consumer string) (*schemas.StreamPendingResponse, error)
+
+	// StreamClaim transfers to the consumer the pending entries of the group idle for at least minIdle.
+	StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) (*schemas.StreamEntriesResponse, error)
+
+	// Watch streams the keyspace events of the keys that match the glob pattern until the context is cancelled.
+	Watch(ctx context.Context, match string, lastEventID uint64) (<-chan Event, error)
+
+	// Publish sends a message to a pub/sub channel and returns the number of subscribers that received it.
+	Publish(channel string, message string) (*schemas.PublishResponse, error)
+
+	// Subscribe streams the messages published to the channels and to the channels that match the patterns.
+	Subscribe(ctx context.Context, channels []string, patterns []string) (<-chan Message, error)
+
+	// Namespace returns a client of the keys of the namespace, which shares the credentials of the client.
+	// An empty name returns a client of the keys of the database. The pub/sub channels are not namespaced.
+	Namespace(name string) ApiClient
+
+	// WithContext returns a client whose requests are sent with the context, so they are cancelled with it and
+	// carry its trace. It shares the credentials of the client.
+	WithContext(ctx context.Context) ApiClient
 }
 
 // client is a simple HTTP client for interacting with the memory database.
 type client struct {
-	url    string       // URL of the memory database server
-	prefix string       // Prefix for API endpoints
-	client *http.Client // HTTP client for making requests
+	url          string          // URL of the memory database server
+	prefix       string          // Prefix for API endpoints
+	keyspace     string          // Prefix of the endpoints of the keys, the one of the namespace if the client has one
+	client       *http.Client    // HTTP client for making requests
+	streamClient *http.Client    // HTTP client without timeout for long-lived streams
+	session      *session        // credentials and tokens of the user, shared by the clients of a sharded client
+	ctx          context.Context // context of the requests, which cancels them and carries their trace
 }
 
 // NewClient creates a new Client instance with the specified URL and a default HTTP client with a timeout.
-func NewClient(url string, version string) ApiClient {
+// The options configure the TLS connections to the server, for the URLs with the https scheme.
+func NewClient(url string, version string, opts ...ClientOptions) ApiClient {
+	session := newSession(version)
+	session.configure(opts...)
+	return newClient(url, version, session)
+}
+
+// newClient creates the client of a single server, which sends its requests with the tokens of the session.
+func newClient(url string, version string, session *session) *client {
 	return &client{
-		url:    url,
-		prefix: "/api/" + version,
+		url:      url,
+		prefix:   "/api/" + version,
+		keyspace: "/api/" + version,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   10 * time.Second,
+			Transport: session.transport(url, &redirectTransport{next: session.base}),
 		},
+		streamClient: &http.Client{Transport: session.transport(url, &redirectTransport{next: session.base})},
+		session:      session,
+		ctx:          context.Background(),
 	}
 }
 
+// Namespace returns a client of the keys of the namespace in the server.
+func (c *client) Namespace(name string) ApiClient {
+	return c.inNamespace(name)
+}
+
+// inNamespace returns a copy of the client that sends the requests of the keys to the namespace, or to the
+// database if the name is empty.
+func (c *client) inNamespace(name string) *client {
+	namespaced := *c
+	namespaced.keyspace = c.prefix
+	if name != "" {
+		namespaced.keyspace = c.prefix + "/ns/" + url.PathEscape(name)
+	}
+	return &namespaced
+}
+
+// WithContext returns a copy of the client whose requests are sent with the context, so they are cancelled with
+// it and carry its trace.
+func (c *client) WithContext(ctx context.Context) ApiClient {
+	return c.withContext(ctx)
+}
+
+// withContext returns a copy of the client whose requests are sent with the context.
+func (c *client) withContext(ctx context.Context) *client {
+	scoped := *c
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// get sends a GET request to the endpoint with the context of the client.
+func (c *client) get(endpoint string) (*http.Response, error) {
+	return c.send(http.MethodGet, endpoint, "", nil)
+}
+
+// post sends a POST request with the body to the endpoint with the context of the client.
+func (c *client) post(endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	return c.send(http.MethodPost, endpoint, contentType, body)
+}
+
+// send sends a request to the endpoint with the context of the client.
+func (c *client) send(method, endpoint, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return c.client.Do(req)
+}
+
+// Login logs in to the server with the credentials of a user.
+func (c *client) Login(username, password string) error {
+	return c.session.start(username, password, c.url)
+}
+
+// Logout ends the session of the user in the server.
+func (c *client) Logout() error {
+	return c.session.end()
+}
+
+// UseAPIKey sends the API key with every request to the server.
+func (c *client) UseAPIKey(key string) {
+	c.session.useAPIKey(key)
+}
+
 // Get retrieves the value associated with a key from the memory database.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Get(key string) (*ApiResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, key)
+	endpoint, err := url.JoinPath(c.url, c.keyspace, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
 
-	resp, err := c.client.Get(endpoint)
+	resp, err := c.get(endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get item from %s: %w", endpoint, err)
 	}
@@ -82,7 +237,7 @@ func (c *client) Get(key string) (*ApiResponse, error) {
 // Set stores a key-value pair in the memory database.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Set(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, "set")
+	endpoint, err := url.JoinPath(c.url, c.keyspace, "set")
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
@@ -96,7 +251,7 @@ func (c *client) Set(key string, value any, ttl *time.Duration) (*schemas.OKResp
 		return nil, fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
 	}
 
-	resp, err := c.client.Post(endpoint, "application/json", bytes.NewBuffer(body))
+	resp, err := c.post(endpoint, "application/json", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to set item in %s: %w", endpoint, err)
 	}
@@ -116,11 +271,11 @@ func (c *client) Set(key string, value any, ttl *time.Duration) (*schemas.OKResp
 // Remove removes a key-value pair from the memory database.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Remove(key string) (*schemas.OKResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, key)
+	endpoint, err := url.JoinPath(c.url, c.keyspace, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
-	req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodDelete, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create delete request for %s: %w", endpoint, err)
 	}
@@ -145,7 +300,7 @@ func (c *client) Remove(key string) (*schemas.OKResponse, error) {
 // Update updates an existing item in the memory database with the specified key and value.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Update(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, key)
+	endpoint, err := url.JoinPath(c.url, c.keyspace, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
@@ -159,7 +314,7 @@ func (c *client) Update(key string, value any, ttl *time.Duration) (*schemas.OKR
 		return nil, fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, endpoint, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPatch, endpoint, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create update request for %s: %w", endpoint, err)
 	}
@@ -185,7 +340,7 @@ func (c *client) Update(key string, value any, ttl *time.Duration) (*schemas.OKR
 // Push adds a new item to the memory database with the specified key and value.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Push(key string, value string, ttl *time.Duration) (*ApiResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, key, "push")
+	endpoint, err := url.JoinPath(c.url, c.keyspace, key, "push")
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
@@ -198,7 +353,7 @@ func (c *client) Push(key string, value string, ttl *time.Duration) (*ApiRespons
 		return nil, fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
 	}
 
-	req, err := http.NewRequest(http.MethodPatch, endpoint, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPatch, endpoint, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create push request for %s: %w", endpoint, err)
 	}
@@ -223,11 +378,11 @@ func (c *client) Push(key string, value string, ttl *time.Duration) (*ApiRespons
 // Pop removes the last item from a slice stored at the specified key in the memory database.
 // It returns a schemas.OKResponse if the operation is successful, or an error if it fails
 func (c *client) Pop(key string) (*ApiResponse, error) {
-	endpoint, err := url.JoinPath(c.url, c.prefix, key, "pop")
+	endpoint, err := url.JoinPath(c.url, c.keyspace, key, "pop")
 	if err != nil {
 		return nil, fmt.Errorf("failed to join path for key %s: %w", key, err)
 	}
-	req, err := http.NewRequest(http.MethodPatch, endpoint, nil)
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodPatch, endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create pop request for %s: %w", endpoint, err)
 	}
@@ -0,0 +1,73 @@
+package godb_test
+
+import (
+	"context"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ClientSuite struct {
+	db       db.DBClient
+	server   *httptest.Server
+	recorder *tracetest.SpanRecorder
+	provider trace.TracerProvider // global provider before the test
+	suite.Suite
+}
+
+func (s *ClientSuite) SetupTest() {
+	s.recorder = tracetest.NewSpanRecorder()
+	s.provider = otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.recorder)))
+
+	s.db = db.NewMemoryDB(slog.Default())
+	s.server = httptest.NewServer(transport.NewServer(slog.Default(), 0, 0, s.db).Handler())
+}
+
+func (s *ClientSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+	otel.SetTracerProvider(s.provider)
+}
+
+func (s *ClientSuite) TestTraceContext() {
+	ctx, operation := otel.Tracer("test").Start(context.Background(), "operation")
+	client := godb.NewClient(s.server.URL, "v1").WithContext(ctx)
+	_, err := client.Set("key", "value", nil)
+	s.Require().NoError(err)
+	_, err = client.Namespace("").Get("key")
+	s.Require().NoError(err, "the clients of a namespace should keep the context")
+	operation.End()
+
+	var served []sdktrace.ReadOnlySpan
+	for _, span := range s.recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer {
+			served = append(served, span)
+		}
+	}
+	s.Require().Len(served, 2)
+	for _, span := range served {
+		s.Equal(operation.SpanContext().TraceID(), span.SpanContext().TraceID(), "the server should continue the trace of the client")
+		s.NotEqual(operation.SpanContext().SpanID(), span.Parent().SpanID(), "the parent should be the span of the request sent")
+	}
+}
+
+func (s *ClientSuite) TestCancelledContext() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := godb.NewClient(s.server.URL, "v1").WithContext(ctx).Get("key")
+	s.ErrorIs(err, context.Canceled)
+}
+
+func TestClientSuite(t *testing.T) {
+	suite.Run(t, new(ClientSuite))
+}
@@ -0,0 +1,90 @@
+package godb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	eventBufferSize  = 256             // number of events buffered in the channel returned by Watch
+	resubscribeDelay = 1 * time.Second // time to wait before resuming a broken stream
+)
+
+// Watch streams the keyspace events of the keys that match the glob pattern.
+//
+// The events are read from the Server-Sent Events endpoint of the server. If the stream breaks, the client
+// reconnects and resumes from the last event it received. The returned channel is closed when the context is cancelled.
+// If lastEventID is not zero, the stream resumes after that event. An event of type lost, without key, means that
+// some events were not delivered, so the keys must be read again.
+func (c *client) Watch(ctx context.Context, match string, lastEventID uint64) (<-chan Event, error) {
+	body, err := c.openEventStream(ctx, match, lastEventID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event, eventBufferSize)
+	go func() {
+		defer close(events)
+
+		for {
+			lastEventID = readEventStream(ctx, body, events, lastEventID)
+			body.Close()
+
+			// reconnect until the subscriber cancels the context
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(resubscribeDelay):
+				}
+
+				body, err = c.openEventStream(ctx, match, lastEventID)
+				if err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// openEventStream opens a request to the events endpoint and returns the body of the stream.
+func (c *client) openEventStream(ctx context.Context, match string, lastEventID uint64) (io.ReadCloser, error) {
+	endpoint, err := url.JoinPath(c.url, c.keyspace, "events")
+	if err != nil {
+		return nil, fmt.Errorf("failed to join path for events: %w", err)
+	}
+	endpoint += "?" + url.Values{"match": {match}}.Encode()
+
+	header := http.Header{}
+	if lastEventID > 0 {
+		header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+	}
+	return c.openStream(ctx, endpoint, header)
+}
+
+// readEventStream sends the events of the stream to the channel until the stream ends.
+// It returns the ID of the last event received.
+func readEventStream(ctx context.Context, body io.Reader, events chan<- Event, lastEventID uint64) uint64 {
+	readSSE(body, func(e sseEvent) bool {
+		var event Event
+		if err := json.Unmarshal([]byte(e.data), &event); err != nil {
+			return true // skip malformed events
+		}
+		select {
+		case events <- event:
+			lastEventID = event.ID
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	})
+	return lastEventID
+}
@@ -0,0 +1,216 @@
+package godb
+
+import (
+	"context"
+	"fmt"
+	"memorydb/pkg/godb/memdbpb"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// GRPCClient is a client of the gRPC API of the database. Every call takes a context, so the deadline and the
+// cancellation of the caller are sent to the server.
+//
+// The errors returned are gRPC status errors, wrapped with the operation that failed. ErrorCode returns the code of
+// the error of the API, such as "item_not_found".
+type GRPCClient struct {
+	conn   *grpc.ClientConn
+	client memdbpb.MemoryDBClient
+}
+
+// NewGRPCClient creates a client of the server listening at the target, such as "localhost:9090". Without options,
+// the connection is not encrypted.
+func NewGRPCClient(target string, opts ...grpc.DialOption) (*GRPCClient, error) {
+	if len(opts) == 0 {
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	conn, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", target, err)
+	}
+	return &GRPCClient{conn: conn, client: memdbpb.NewMemoryDBClient(conn)}, nil
+}
+
+// Close closes the connection to the server.
+func (c *GRPCClient) Close() error {
+	return c.conn.Close()
+}
+
+// Get retrieves the item stored at the key.
+func (c *GRPCClient) Get(ctx context.Context, key string) (*ApiResponse, error) {
+	item, err := c.client.Get(ctx, &memdbpb.GetRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get item %s: %w", key, err)
+	}
+	return fromItemProto(item), nil
+}
+
+// Set stores the value, a string or a slice of strings, at the key. If ttl is nil, the default TTL of the server is used.
+func (c *GRPCClient) Set(ctx context.Context, key string, value any, ttl *time.Duration) error {
+	v, err := toValueProto(value)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Set(ctx, &memdbpb.SetRequest{Key: key, Value: v, Ttl: toDurationProto(ttl)}); err != nil {
+		return fmt.Errorf("failed to set item %s: %w", key, err)
+	}
+	return nil
+}
+
+// Update replaces the value of an existing key. If ttl is nil, the TTL of the item is kept.
+func (c *GRPCClient) Update(ctx context.Context, key string, value any, ttl *time.Duration) error {
+	v, err := toValueProto(value)
+	if err != nil {
+		return err
+	}
+	if _, err := c.client.Update(ctx, &memdbpb.UpdateRequest{Key: key, Value: v, Ttl: toDurationProto(ttl)}); err != nil {
+		return fmt.Errorf("failed to update item %s: %w", key, err)
+	}
+	return nil
+}
+
+// Remove deletes the key.
+func (c *GRPCClient) Remove(ctx context.Context, key string) error {
+	if _, err := c.client.Remove(ctx, &memdbpb.RemoveRequest{Key: key}); err != nil {
+		return fmt.Errorf("failed to remove item %s: %w", key, err)
+	}
+	return nil
+}
+
+// Push appends a value to the slice stored at the key and returns the item.
+func (c *GRPCClient) Push(ctx context.Context, key string, value string, ttl *time.Duration) (*ApiResponse, error) {
+	item, err := c.client.Push(ctx, &memdbpb.PushRequest{Key: key, Value: value, Ttl: toDurationProto(ttl)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to push item to %s: %w", key, err)
+	}
+	return fromItemProto(item), nil
+}
+
+// Pop removes the last value of the slice stored at the key and returns the item.
+func (c *GRPCClient) Pop(ctx context.Context, key string) (*ApiResponse, error) {
+	item, err := c.client.Pop(ctx, &memdbpb.PopRequest{Key: key})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pop item from %s: %w", key, err)
+	}
+	return fromItemProto(item), nil
+}
+
+// Watch streams the keyspace events of the keys that match the glob pattern.
+//
+// As with the HTTP client, if the stream breaks, the client reconnects and resumes from the last event it received.
+// The returned channel is closed when the context is cancelled. If lastEventID is not zero, the stream resumes after that event.
+func (c *GRPCClient) Watch(ctx context.Context, match string, lastEventID uint64) (<-chan Event, error) {
+	stream, err := c.client.Watch(ctx, &memdbpb.WatchRequest{Match: match, LastEventId: lastEventID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", match, err)
+	}
+
+	events := make(chan Event, eventBufferSize)
+	go func() {
+		defer close(events)
+
+		for {
+			lastEventID = readEventProtos(ctx, stream, events, lastEventID)
+
+			// reconnect until the subscriber cancels the context
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(resubscribeDelay):
+				}
+
+				stream, err = c.client.Watch(ctx, &memdbpb.WatchRequest{Match: match, LastEventId: lastEventID})
+				if err == nil {
+					break
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// readEventProtos sends the events of the stream to the channel until the stream ends.
+// It returns the ID of the last event received.
+func readEventProtos(ctx context.Context, stream grpc.ServerStreamingClient[memdbpb.Event], events chan<- Event, lastEventID uint64) uint64 {
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			return lastEventID
+		}
+		event := Event{ID: e.GetId(), Type: e.GetType(), Key: e.GetKey(), Time: e.GetTime().AsTime()}
+		select {
+		case events <- event:
+			lastEventID = event.ID
+		case <-ctx.Done():
+			return lastEventID
+		}
+	}
+}
+
+// ErrorCode returns the code of the error of the API returned by the gRPC API, such as "item_not_found",
+// or an empty string if the error was not returned by the server.
+func ErrorCode(err error) string {
+	st, ok := status.FromError(err)
+	if !ok || err == nil {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetDomain() == memdbpb.ErrorDomain {
+			return info.GetReason()
+		}
+	}
+	return ""
+}
+
+// IsNotFound reports whether the error returned by the gRPC API means that the key does not exist or has expired.
+func IsNotFound(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
+
+// toValueProto converts a string or a slice of strings into a value of the gRPC API.
+func toValueProto(value any) (*memdbpb.Value, error) {
+	switch v := value.(type) {
+	case string:
+		return &memdbpb.Value{Kind: &memdbpb.Value_StringValue{StringValue: v}}, nil
+	case []string:
+		return &memdbpb.Value{Kind: &memdbpb.Value_ListValue{ListValue: &memdbpb.StringList{Values: v}}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T for value, it must be a string or a slice of strings", value)
+	}
+}
+
+// toDurationProto converts an optional TTL into a duration of the gRPC API.
+func toDurationProto(ttl *time.Duration) *durationpb.Duration {
+	if ttl == nil {
+		return nil
+	}
+	return durationpb.New(*ttl)
+}
+
+// fromItemProto converts an item of the gRPC API into the response of the HTTP client.
+func fromItemProto(item *memdbpb.Item) *ApiResponse {
+	response := &ApiResponse{
+		Key:       item.GetKey(),
+		Kind:      item.GetKind(),
+		CreatedAt: item.GetCreatedAt().AsTime(),
+		UpdatedAt: item.GetUpdatedAt().AsTime(),
+	}
+	if item.Ttl != nil {
+		response.TTL = item.GetTtl().AsTime()
+	}
+	switch v := item.GetValue().GetKind().(type) {
+	case *memdbpb.Value_StringValue:
+		response.Value = v.StringValue
+	case *memdbpb.Value_ListValue:
+		response.Value = v.ListValue.GetValues()
+	}
+	return response
+}
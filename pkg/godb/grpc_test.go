@@ -0,0 +1,106 @@
+package godb_test
+
+import (
+	"context"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type GRPCClientSuite struct {
+	db     db.DBClient
+	server *grpc.Server
+	client *godb.GRPCClient
+	suite.Suite
+}
+
+func (s *GRPCClientSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	listener := bufconn.Listen(1 << 20)
+	s.server = transport.NewGRPCServer(slog.Default(), s.db, nil, nil)
+	go s.server.Serve(listener)
+
+	client, err := godb.NewGRPCClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	s.Require().NoError(err)
+	s.client = client
+}
+
+func (s *GRPCClientSuite) TearDownTest() {
+	s.client.Close()
+	s.server.Stop()
+	s.db.Close()
+}
+
+func (s *GRPCClientSuite) TestItems() {
+	ctx := context.Background()
+	ttl := time.Minute
+
+	s.Require().NoError(s.client.Set(ctx, "key", "value", &ttl))
+	item, err := s.client.Get(ctx, "key")
+	s.Require().NoError(err)
+	s.Equal("value", item.Value)
+	s.Equal("string", item.Kind)
+	s.WithinDuration(time.Now().Add(ttl), item.TTL, time.Second)
+
+	s.Require().NoError(s.client.Set(ctx, "list", []string{"a"}, nil))
+	item, err = s.client.Push(ctx, "list", "b", nil)
+	s.Require().NoError(err)
+	s.Equal([]string{"a", "b"}, item.Value)
+	item, err = s.client.Pop(ctx, "list")
+	s.Require().NoError(err)
+	s.Equal([]string{"a"}, item.Value)
+
+	s.Require().NoError(s.client.Update(ctx, "key", "updated", nil))
+	item, err = s.client.Get(ctx, "key")
+	s.Require().NoError(err)
+	s.Equal("updated", item.Value)
+
+	s.Require().NoError(s.client.Remove(ctx, "key"))
+	_, err = s.client.Get(ctx, "key")
+	s.Error(err)
+	s.True(godb.IsNotFound(err))
+	s.Equal("item_not_found", godb.ErrorCode(err))
+
+	s.Error(s.client.Set(ctx, "key", 42, nil), "only strings and slices of strings should be accepted")
+}
+
+func (s *GRPCClientSuite) TestWatch() {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := s.client.Watch(ctx, "user:*", 0)
+	s.Require().NoError(err)
+
+	// the subscription starts once the call is received, so the write is retried until the first event arrives
+	var event godb.Event
+	deadline := time.After(5 * time.Second)
+	for event.Key == "" {
+		s.Require().NoError(s.db.Set("user:1", "value"))
+		select {
+		case event = <-events:
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			s.FailNow("no event received")
+		}
+	}
+	s.Equal("user:1", event.Key)
+	s.Equal("set", event.Type)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestGRPCClientSuite(t *testing.T) {
+	suite.Run(t, new(GRPCClientSuite))
+}
@@ -0,0 +1,11 @@
+// Package memdbpb contains the protobuf messages and the gRPC service of the API of the database, generated from
+// proto/memdb/v1/memdb.proto. Only this file is not generated.
+package memdbpb
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=memorydb --go-grpc_out=../../.. --go-grpc_opt=module=memorydb memdb/v1/memdb.proto
+
+const (
+	// ErrorDomain is the domain of the ErrorInfo details of the errors of the service, whose reason is the code of
+	// the error of the API, such as "item_not_found".
+	ErrorDomain = "memorydb"
+)
@@ -0,0 +1,197 @@
+package godb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"memorydb/internal/db"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// PipelineResult is the result of a command of a pipeline. Item is set by the get, push and pop commands that
+// succeeded, and Err is a *KeyError if the command failed.
+type PipelineResult struct {
+	Item *ApiResponse
+	Err  error
+}
+
+// Pipeline queues commands to send them in a single request. The commands run in order, but not atomically:
+// every command has its own result, and a failed command does not stop the next ones.
+//
+// The methods that queue a command return the pipeline, so they can be chained:
+//
+//	results, err := client.Pipeline().Set("a", "1", nil).Get("a").Remove("b").Exec()
+type Pipeline struct {
+	exec     func([]schemas.PipelineCommand) ([]PipelineResult, error)
+	commands []schemas.PipelineCommand
+}
+
+// Get queues the read of the item of the key.
+func (p *Pipeline) Get(key string) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "get", Key: key})
+}
+
+// Set queues the write of the value at the key.
+func (p *Pipeline) Set(key string, value any, ttl *time.Duration) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "set", Key: key, Value: &db.StringOrSlice{Val: value}, TTL: durationOf(ttl)})
+}
+
+// Update queues the update of the existing item of the key.
+func (p *Pipeline) Update(key string, value any, ttl *time.Duration) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "update", Key: key, Value: &db.StringOrSlice{Val: value}, TTL: durationOf(ttl)})
+}
+
+// Remove queues the removal of the key.
+func (p *Pipeline) Remove(key string) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "remove", Key: key})
+}
+
+// Push queues the append of the value to the slice stored at the key.
+func (p *Pipeline) Push(key string, value string, ttl *time.Duration) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "push", Key: key, Value: &db.StringOrSlice{Val: value}, TTL: durationOf(ttl)})
+}
+
+// Pop queues the removal of the last value of the slice stored at the key.
+func (p *Pipeline) Pop(key string) *Pipeline {
+	return p.queue(schemas.PipelineCommand{Op: "pop", Key: key})
+}
+
+// Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.commands)
+}
+
+// Exec sends the queued commands and returns their results in the same order. The queue is emptied, even if the
+// request fails, so the pipeline can be reused.
+func (p *Pipeline) Exec() ([]PipelineResult, error) {
+	commands := p.commands
+	p.commands = nil
+	if len(commands) == 0 {
+		return nil, nil
+	}
+	return p.exec(commands)
+}
+
+// queue adds the command to the pipeline.
+func (p *Pipeline) queue(cmd schemas.PipelineCommand) *Pipeline {
+	p.commands = append(p.commands, cmd)
+	return p
+}
+
+// Pipeline returns an empty pipeline of the server.
+func (c *client) Pipeline() *Pipeline {
+	return &Pipeline{exec: c.execPipeline}
+}
+
+// execPipeline sends the commands to the server as NDJSON, which the server runs and answers one line at a time,
+// and returns their results in the same order.
+func (c *client) execPipeline(commands []schemas.PipelineCommand) ([]PipelineResult, error) {
+	endpoint, err := url.JoinPath(c.url, c.keyspace, "pipeline")
+	if err != nil {
+		return nil, fmt.Errorf("failed to join path for pipeline: %w", err)
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, cmd := range commands {
+		if err := encoder.Encode(cmd); err != nil {
+			return nil, fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
+		}
+	}
+
+	resp, err := c.post(endpoint, "application/x-ndjson", &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send pipeline to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to send pipeline to %s: received status code %d", endpoint, resp.StatusCode)
+	}
+
+	results := make([]PipelineResult, 0, len(commands))
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var result keyResult
+		if err := decoder.Decode(&result); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+		}
+		results = append(results, PipelineResult{Item: result.Item, Err: result.err()})
+	}
+	if len(results) != len(commands) {
+		return nil, fmt.Errorf("received %d results for %d commands from %s", len(results), len(commands), endpoint)
+	}
+	return results, nil
+}
+
+// execSplit sends the commands at the indexes to the servers returned by nodeOf for their keys: a single pipeline
+// to every server, with its commands in order, concurrently. The results are stored at the indexes of their commands.
+//
+// The commands are grouped by the HTTP client of their client, since nodeOf may return a copy of the client of a
+// server for every key, with the namespace and the context of the caller.
+func execSplit(commands []schemas.PipelineCommand, indexes []int, nodeOf func(key string) (*client, error), results []PipelineResult) error {
+	parts := make(map[*http.Client][]int)
+	nodes := make(map[*http.Client]*client)
+	for _, i := range indexes {
+		node, err := nodeOf(commands[i].Key)
+		if err != nil {
+			return err
+		}
+		parts[node.client] = append(parts[node.client], i)
+		nodes[node.client] = node
+	}
+
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for httpClient, part := range parts {
+		node := nodes[httpClient]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeCommands := make([]schemas.PipelineCommand, len(part))
+			for j, i := range part {
+				nodeCommands[j] = commands[i]
+			}
+
+			nodeResults, err := node.execPipeline(nodeCommands)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			// every command has its own slot of the results, so they are written without a lock
+			for j, i := range part {
+				results[i] = nodeResults[j]
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// indexes returns the indexes of n commands.
+func indexes(n int) []int {
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// durationOf returns the TTL of a request, nil if it is not set.
+func durationOf(ttl *time.Duration) *schemas.Duration {
+	if ttl == nil {
+		return nil
+	}
+	return &schemas.Duration{Duration: *ttl}
+}
@@ -0,0 +1,83 @@
+package godb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/url"
+)
+
+const (
+	messageBufferSize = 256 // number of messages buffered in the channel returned by Subscribe
+)
+
+// Publish sends a message to a pub/sub channel.
+// It returns the number of subscribers that received the message, or an error if it fails.
+func (c *client) Publish(channel string, message string) (*schemas.PublishResponse, error) {
+	endpoint, err := url.JoinPath(c.url, c.prefix, "publish")
+	if err != nil {
+		return nil, fmt.Errorf("failed to join path for channel %s: %w", channel, err)
+	}
+
+	body, err := json.Marshal(schemas.PublishRequest{Channel: channel, Message: message})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
+	}
+
+	resp, err := c.post(endpoint, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish message to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to publish message to %s: received status code %d", endpoint, resp.StatusCode)
+	}
+
+	var response schemas.PublishResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return &response, nil
+}
+
+// Subscribe streams the messages published to the given channels and to the channels that match the glob patterns.
+//
+// Delivery is at-most-once: messages published while the client is not connected are lost. The returned channel is
+// closed when the context is cancelled or when the server drops the subscriber because it did not keep up.
+func (c *client) Subscribe(ctx context.Context, channels []string, patterns []string) (<-chan Message, error) {
+	endpoint, err := url.JoinPath(c.url, c.prefix, "subscribe")
+	if err != nil {
+		return nil, fmt.Errorf("failed to join path for subscribe: %w", err)
+	}
+	endpoint += "?" + url.Values{"channel": channels, "pattern": patterns}.Encode()
+
+	body, err := c.openStream(ctx, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(chan Message, messageBufferSize)
+	go func() {
+		defer close(messages)
+		defer body.Close()
+
+		readSSE(body, func(e sseEvent) bool {
+			var msg Message
+			if err := json.Unmarshal([]byte(e.data), &msg); err != nil {
+				return true // skip malformed messages
+			}
+			select {
+			case messages <- msg:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	return messages, nil
+}
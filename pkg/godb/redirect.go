@@ -0,0 +1,55 @@
+package godb
+
+import (
+	"fmt"
+	"memorydb/internal/slots"
+	"net/http"
+)
+
+// RedirectError is returned when a server that uses hash slots does not serve the slot of the key,
+// and names the server that does.
+type RedirectError struct {
+	Ask  bool   // whether the slot is migrating and only this request must be sent to the other server
+	Slot int    // hash slot of the key
+	URL  string // base URL of the server that serves the slot
+}
+
+func (e *RedirectError) Error() string {
+	kind := slots.RedirectMoved
+	if e.Ask {
+		kind = slots.RedirectAsk
+	}
+	return fmt.Sprintf("redirected: %s %d %s", kind, e.Slot, e.URL)
+}
+
+// redirectTransport turns the redirects of the hash slots into a *RedirectError, instead of following them,
+// so the client can learn the topology from them.
+type redirectTransport struct {
+	next   http.RoundTripper // transport the requests are sent with
+	asking bool              // whether the requests are sent after an ASK redirect
+}
+
+// RoundTrip sends the request with the next transport.
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.asking {
+		req = req.Clone(req.Context())
+		req.Header.Set(slots.AskingHeader, "1")
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	header := resp.Header.Get(slots.RedirectHeader)
+	if header == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	redirect, err := slots.ParseRedirect(header)
+	if err != nil {
+		return nil, err
+	}
+	return nil, &RedirectError{Ask: redirect.Kind == slots.RedirectAsk, Slot: redirect.Slot, URL: redirect.URL}
+}
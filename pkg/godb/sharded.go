@@ -0,0 +1,530 @@
+package godb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"memorydb/internal/hashring"
+	"memorydb/internal/transport/schemas"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ ApiClient = (*ShardedClient)(nil)
+)
+
+var (
+	// ErrNoNodes is returned when the sharded client has no node to send a request to.
+	ErrNoNodes = errors.New("the sharded client has no nodes")
+
+	// ErrResumeNotSupported is returned when a sharded Watch is resumed from an event ID, since every node numbers its events.
+	ErrResumeNotSupported = errors.New("the sharded client cannot resume a watch from an event ID")
+
+	// ErrCrossShard is returned when the keys of an atomic batch are not owned by the same server.
+	ErrCrossShard = errors.New("the keys of an atomic batch are not owned by the same server")
+)
+
+// ShardedClientOptions defines an interface for applying options to the ShardedClient.
+type ShardedClientOptions interface {
+	apply(*ShardedClient)
+}
+
+// WithVirtualNodes sets the number of points of every node in the hash ring, 160 by default.
+// More points spread the keys more evenly at the cost of a bigger ring.
+type WithVirtualNodes int
+
+func (o WithVirtualNodes) apply(c *ShardedClient) {
+	c.virtualNodes = int(o)
+}
+
+// ShardedClient spreads the keys across several servers with consistent hashing. It implements the same
+// ApiClient interface as the client of a single server.
+//
+// Every key is owned by one server, which receives all the requests of the key. The requests that are not
+// about a single key, such as Watch or Subscribe, are sent to every server involved and their results are merged.
+// Nodes can be added or removed at runtime, which moves about 1/n of the keys to another server. The client
+// does not migrate the data of the moved keys.
+type ShardedClient struct {
+	version   string
+	nodes     *shardedNodes   // nodes of the ring, shared with the clients returned by Namespace and WithContext
+	session   *session        // credentials and tokens of the user, shared by the clients of the nodes
+	namespace string          // namespace of the keys, empty for the keys of the database
+	ctx       context.Context // context of the requests of the methods without one
+
+	// Optional settings
+	virtualNodes int
+}
+
+// shardedNodes is the ring of the servers of a sharded client, with the client of every server.
+type shardedNodes struct {
+	mu      sync.RWMutex
+	ring    *hashring.Ring
+	clients map[string]*client // client of every node, by URL
+}
+
+// NewShardedClient creates a client that spreads the keys across the servers with the given URLs.
+func NewShardedClient(urls []string, version string, opts ...ShardedClientOptions) (*ShardedClient, error) {
+	c := &ShardedClient{version: version, session: newSession(version), ctx: context.Background()}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	c.nodes = &shardedNodes{ring: hashring.New(c.virtualNodes), clients: make(map[string]*client)}
+
+	if len(urls) == 0 {
+		return nil, ErrNoNodes
+	}
+	for _, url := range urls {
+		if err := c.AddNode(url); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// AddNode adds the server with the URL to the ring. The keys it takes over are served by it from now on.
+func (c *ShardedClient) AddNode(url string) error {
+	url = strings.TrimSuffix(url, "/")
+	if url == "" {
+		return fmt.Errorf("the URL of the node cannot be empty")
+	}
+
+	c.nodes.mu.Lock()
+	defer c.nodes.mu.Unlock()
+	if !c.nodes.ring.Add(url) {
+		return fmt.Errorf("node %s is already in the ring", url)
+	}
+	c.nodes.clients[url] = newClient(url, c.version, c.session)
+	return nil
+}
+
+// RemoveNode removes the server with the URL from the ring. Its keys are served by the remaining nodes from now on.
+// The streams already opened to the node by Watch or Subscribe are not closed.
+func (c *ShardedClient) RemoveNode(url string) error {
+	url = strings.TrimSuffix(url, "/")
+
+	c.nodes.mu.Lock()
+	defer c.nodes.mu.Unlock()
+	if !c.nodes.ring.Remove(url) {
+		return fmt.Errorf("node %s is not in the ring", url)
+	}
+	delete(c.nodes.clients, url)
+	return nil
+}
+
+// Nodes returns the URLs of the servers in the ring, sorted.
+func (c *ShardedClient) Nodes() []string {
+	c.nodes.mu.RLock()
+	defer c.nodes.mu.RUnlock()
+	return c.nodes.ring.Nodes()
+}
+
+// Login logs in to every node with the credentials of a user, which must be registered in all of them.
+// The nodes added later are logged in to with the same credentials.
+func (c *ShardedClient) Login(username, password string) error {
+	return c.session.start(username, password, c.Nodes()...)
+}
+
+// Logout ends the session of the user in every node.
+func (c *ShardedClient) Logout() error {
+	return c.session.end()
+}
+
+// UseAPIKey sends the API key with every request to every node, which must all know the key.
+func (c *ShardedClient) UseAPIKey(key string) {
+	c.session.useAPIKey(key)
+}
+
+// Namespace returns a client of the keys of the namespace, which must exist in every node. The returned client
+// shares the nodes and the credentials of the client, so the nodes added or removed later apply to both.
+func (c *ShardedClient) Namespace(name string) ApiClient {
+	namespaced := *c
+	namespaced.namespace = name
+	return &namespaced
+}
+
+// WithContext returns a client whose requests are sent with the context, so they are cancelled with it and carry
+// its trace. The returned client shares the nodes and the credentials of the client.
+func (c *ShardedClient) WithContext(ctx context.Context) ApiClient {
+	scoped := *c
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// scoped returns the client of a node with the namespace and the context of the sharded client.
+func (c *ShardedClient) scoped(node *client) *client {
+	return node.inNamespace(c.namespace).withContext(c.ctx)
+}
+
+// NodeFor returns the URL of the server that owns the key.
+func (c *ShardedClient) NodeFor(key string) (string, error) {
+	c.nodes.mu.RLock()
+	defer c.nodes.mu.RUnlock()
+	node, ok := c.nodes.ring.Get(key)
+	if !ok {
+		return "", ErrNoNodes
+	}
+	return node, nil
+}
+
+// clientFor returns the client of the server that owns the key.
+func (c *ShardedClient) clientFor(key string) (*client, error) {
+	c.nodes.mu.RLock()
+	defer c.nodes.mu.RUnlock()
+	node, ok := c.nodes.ring.Get(key)
+	if !ok {
+		return nil, ErrNoNodes
+	}
+	return c.scoped(c.nodes.clients[node]), nil
+}
+
+// split groups the keys by the client of the server that owns them, keeping their order.
+func (c *ShardedClient) split(keys []string) (map[*client][]string, error) {
+	c.nodes.mu.RLock()
+	defer c.nodes.mu.RUnlock()
+
+	byNode := make(map[string][]string)
+	for _, key := range keys {
+		node, ok := c.nodes.ring.Get(key)
+		if !ok {
+			return nil, ErrNoNodes
+		}
+		byNode[node] = append(byNode[node], key)
+	}
+	groups := make(map[*client][]string, len(byNode))
+	for node, keys := range byNode {
+		groups[c.scoped(c.nodes.clients[node])] = keys
+	}
+	return groups, nil
+}
+
+// all returns the clients of every server.
+func (c *ShardedClient) all() []*client {
+	c.nodes.mu.RLock()
+	defer c.nodes.mu.RUnlock()
+
+	nodes := c.nodes.ring.Nodes()
+	clients := make([]*client, 0, len(nodes))
+	for _, node := range nodes {
+		clients = append(clients, c.scoped(c.nodes.clients[node]))
+	}
+	return clients
+}
+
+// Get retrieves the value associated with a key from the server that owns it.
+func (c *ShardedClient) Get(key string) (*ApiResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Get(key)
+}
+
+// Set stores a key-value pair in the server that owns the key.
+func (c *ShardedClient) Set(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Set(key, value, ttl)
+}
+
+// Remove deletes a key-value pair from the server that owns the key.
+func (c *ShardedClient) Remove(key string) (*schemas.OKResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Remove(key)
+}
+
+// Update modifies an existing item in the server that owns the key.
+func (c *ShardedClient) Update(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Update(key, value, ttl)
+}
+
+// Push appends a value to the slice stored at the key in the server that owns it.
+func (c *ShardedClient) Push(key string, value string, ttl *time.Duration) (*ApiResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Push(key, value, ttl)
+}
+
+// Pop removes the last value of the slice stored at the key in the server that owns it.
+func (c *ShardedClient) Pop(key string) (*ApiResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.Pop(key)
+}
+
+// MGet retrieves the items of several keys, sending a single request to every server that owns some of them.
+func (c *ShardedClient) MGet(keys ...string) (map[string]GetResult, error) {
+	return batch(c, keys, func(node *client, keys []string) (map[string]GetResult, error) { return node.MGet(keys...) })
+}
+
+// MSet stores several items, sending a single request to every server that owns some of them.
+func (c *ShardedClient) MSet(items map[string]any, ttl *time.Duration) (map[string]error, error) {
+	return batch(c, sortedKeys(items), func(node *client, keys []string) (map[string]error, error) {
+		return node.MSet(itemsOf(items, keys), ttl)
+	})
+}
+
+// MSetAtomic stores several items, all of them or none of them. Every key must be owned by the same server,
+// otherwise ErrCrossShard is returned.
+func (c *ShardedClient) MSetAtomic(items map[string]any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	keys := sortedKeys(items)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the batch has no items")
+	}
+	groups, err := c.split(keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) > 1 {
+		return nil, ErrCrossShard
+	}
+	node, err := c.clientFor(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	return node.MSetAtomic(items, ttl)
+}
+
+// MDel removes several keys, sending a single request to every server that owns some of them.
+func (c *ShardedClient) MDel(keys ...string) (map[string]error, error) {
+	return batch(c, keys, func(node *client, keys []string) (map[string]error, error) { return node.MDel(keys...) })
+}
+
+// Pipeline returns an empty pipeline whose commands are sent to the servers that own their keys: a single request
+// to every server, concurrently. The commands of every key run in order.
+func (c *ShardedClient) Pipeline() *Pipeline {
+	return &Pipeline{exec: func(commands []schemas.PipelineCommand) ([]PipelineResult, error) {
+		results := make([]PipelineResult, len(commands))
+		if err := execSplit(commands, indexes(len(commands)), c.clientFor, results); err != nil {
+			return nil, err
+		}
+		return results, nil
+	}}
+}
+
+// StreamAdd appends an entry to the stream stored at the key in the server that owns it.
+func (c *ShardedClient) StreamAdd(key string, fields map[string]string, ttl *time.Duration) (*schemas.StreamAddResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamAdd(key, fields, ttl)
+}
+
+// StreamRange returns entries of the stream stored at the key in the server that owns it.
+func (c *ShardedClient) StreamRange(key string, start, end string, count int) (*schemas.StreamEntriesResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamRange(key, start, end, count)
+}
+
+// StreamRead returns entries of the stream stored at the key in the server that owns it, waiting up to block for new entries.
+func (c *ShardedClient) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamRead(ctx, key, after, count, block)
+}
+
+// StreamTrim removes the oldest entries of the stream stored at the key in the server that owns it.
+func (c *ShardedClient) StreamTrim(key string, maxLen int, maxAge time.Duration) (*schemas.StreamTrimResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamTrim(key, maxLen, maxAge)
+}
+
+// StreamGroupCreate creates a consumer group in the stream stored at the key in the server that owns it.
+func (c *ShardedClient) StreamGroupCreate(key string, group string, start string) (*schemas.OKResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamGroupCreate(key, group, start)
+}
+
+// StreamReadGroup delivers new entries of the stream stored at the key to the consumer of the group.
+func (c *ShardedClient) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamReadGroup(ctx, key, group, consumer, count, block)
+}
+
+// StreamAck acknowledges entries pending in the group of the stream stored at the key.
+func (c *ShardedClient) StreamAck(key string, group string, ids ...string) (*schemas.StreamAckResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamAck(key, group, ids...)
+}
+
+// StreamPending returns the pending entries of the group of the stream stored at the key.
+func (c *ShardedClient) StreamPending(key string, group string, consumer string) (*schemas.StreamPendingResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamPending(key, group, consumer)
+}
+
+// StreamClaim transfers pending entries of the group of the stream stored at the key to the consumer.
+func (c *ShardedClient) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) (*schemas.StreamEntriesResponse, error) {
+	node, err := c.clientFor(key)
+	if err != nil {
+		return nil, err
+	}
+	return node.StreamClaim(key, group, consumer, minIdle, ids...)
+}
+
+// Watch streams the keyspace events of every server for the keys that match the glob pattern.
+//
+// Every server numbers its own events, so the watch cannot be resumed from an event ID: lastEventID must be 0.
+// The events of different servers are not ordered, and nodes added after the call are not watched.
+func (c *ShardedClient) Watch(ctx context.Context, match string, lastEventID uint64) (<-chan Event, error) {
+	if lastEventID != 0 {
+		return nil, ErrResumeNotSupported
+	}
+
+	nodes := c.all()
+	if len(nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	streams := make([]<-chan Event, 0, len(nodes))
+	for _, node := range nodes {
+		events, err := node.Watch(ctx, match, 0)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		streams = append(streams, events)
+	}
+	return merge(ctx, cancel, eventBufferSize, streams), nil
+}
+
+// Publish sends a message to a pub/sub channel. Channels are spread across the servers like keys,
+// so the message is sent to the server that owns the channel.
+func (c *ShardedClient) Publish(channel string, message string) (*schemas.PublishResponse, error) {
+	node, err := c.clientFor(channel)
+	if err != nil {
+		return nil, err
+	}
+	return node.Publish(channel, message)
+}
+
+// Subscribe streams the messages published to the channels, subscribing to every channel in the server that owns it,
+// and to the channels that match the patterns, subscribing to the patterns in every server.
+//
+// The returned channel is closed when the context is cancelled or when every server has dropped the subscriber.
+func (c *ShardedClient) Subscribe(ctx context.Context, channels []string, patterns []string) (<-chan Message, error) {
+	groups, err := c.split(channels)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) > 0 {
+		for _, node := range c.all() {
+			if _, ok := groups[node]; !ok {
+				groups[node] = nil
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	streams := make([]<-chan Message, 0, len(groups))
+	for node, nodeChannels := range groups {
+		messages, err := node.Subscribe(ctx, nodeChannels, patterns)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		streams = append(streams, messages)
+	}
+	return merge(ctx, cancel, messageBufferSize, streams), nil
+}
+
+// batch sends the keys of every server to it with call, concurrently, and merges the results.
+func batch[T any](c *ShardedClient, keys []string, call func(*client, []string) (map[string]T, error)) (map[string]T, error) {
+	groups, err := c.split(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]T, len(keys))
+	var errs []error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for node, nodeKeys := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeResults, err := call(node, nodeKeys)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			maps.Copy(results, nodeResults)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// merge forwards the values of the streams to a single channel, which is closed when every stream is closed
+// or the context is cancelled. cancel is called at that point to release the context of the streams.
+func merge[T any](ctx context.Context, cancel context.CancelFunc, size int, streams []<-chan T) <-chan T {
+	out := make(chan T, size)
+
+	var wg sync.WaitGroup
+	for _, stream := range streams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for value := range stream {
+				select {
+				case out <- value:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+	return out
+}
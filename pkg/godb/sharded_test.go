@@ -0,0 +1,207 @@
+package godb_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShardedClientSuite struct {
+	dbs     map[string]db.DBClient // database of every server, by URL
+	servers []*httptest.Server
+	client  *godb.ShardedClient
+	suite.Suite
+}
+
+// newServer starts a server with the key-value endpoints backed by its own database.
+func (s *ShardedClientSuite) newServer() string {
+	database := db.NewMemoryDB(slog.Default())
+	h := transport.NewHandler(slog.Default(), database)
+
+	r := chi.NewRouter()
+	r.Post("/api/v1/set", h.HandleSet)
+	r.Get("/api/v1/{key}", h.HandleGet)
+	r.Delete("/api/v1/{key}", h.HandleRemove)
+	bh := transport.NewBatchHandler(slog.Default(), database, nil)
+	r.Post("/api/v1/mget", bh.HandleMGet)
+	r.Post("/api/v1/mset", bh.HandleMSet)
+	r.Post("/api/v1/mdel", bh.HandleMDel)
+	r.Post("/api/v1/pipeline", bh.HandlePipeline)
+	server := httptest.NewServer(r)
+
+	s.servers = append(s.servers, server)
+	s.dbs[server.URL] = database
+	return server.URL
+}
+
+func (s *ShardedClientSuite) SetupTest() {
+	s.dbs = make(map[string]db.DBClient)
+	s.servers = nil
+
+	urls := []string{s.newServer(), s.newServer(), s.newServer()}
+	client, err := godb.NewShardedClient(urls, "v1")
+	s.Require().NoError(err)
+	s.client = client
+}
+
+func (s *ShardedClientSuite) TearDownTest() {
+	for _, server := range s.servers {
+		server.Close()
+	}
+	for _, database := range s.dbs {
+		database.Close()
+	}
+}
+
+func (s *ShardedClientSuite) TestRouting() {
+	for i := range 100 {
+		key := fmt.Sprintf("key:%d", i)
+		_, err := s.client.Set(key, "value", nil)
+		s.Require().NoError(err)
+
+		// the key is only stored in the server that owns it
+		owner, err := s.client.NodeFor(key)
+		s.Require().NoError(err)
+		for url, database := range s.dbs {
+			_, err := database.Get(key)
+			if url == owner {
+				s.NoError(err, "key %s should be stored in %s", key, url)
+			} else {
+				s.Error(err, "key %s should not be stored in %s", key, url)
+			}
+		}
+
+		item, err := s.client.Get(key)
+		s.Require().NoError(err)
+		s.Equal("value", item.Value)
+	}
+}
+
+func (s *ShardedClientSuite) TestBatch() {
+	items := make(map[string]any)
+	var keys []string
+	for i := range 50 {
+		key := fmt.Sprintf("key:%d", i)
+		items[key] = "value"
+		keys = append(keys, key)
+	}
+	errs, err := s.client.MSet(items, nil)
+	s.Require().NoError(err)
+	s.Len(errs, 50)
+	for key, err := range errs {
+		s.NoError(err, key)
+	}
+
+	results, err := s.client.MGet(append(keys, "missing")...)
+	s.Require().NoError(err)
+	s.Len(results, 51)
+	s.Require().NoError(results["key:0"].Err)
+	s.Equal("value", results["key:0"].Item.Value)
+	var keyErr *godb.KeyError
+	s.Require().ErrorAs(results["missing"].Err, &keyErr)
+	s.Equal("item_not_found", keyErr.Code)
+
+	errs, err = s.client.MDel("key:0", "missing")
+	s.Require().NoError(err)
+	s.NoError(errs["key:0"])
+	s.Error(errs["missing"])
+
+	_, err = s.client.MSetAtomic(items, nil)
+	s.ErrorIs(err, godb.ErrCrossShard, "the keys of an atomic batch should be owned by the same server")
+	_, err = s.client.MSetAtomic(map[string]any{"key:1": "changed"}, nil)
+	s.NoError(err)
+}
+
+func (s *ShardedClientSuite) TestPipeline() {
+	pipeline := s.client.Pipeline()
+	for i := range 20 {
+		key := fmt.Sprintf("key:%d", i)
+		pipeline.Set(key, fmt.Sprint(i), nil).Get(key)
+	}
+	pipeline.Pop("missing")
+	s.Equal(41, pipeline.Len())
+
+	results, err := pipeline.Exec()
+	s.Require().NoError(err)
+	s.Require().Len(results, 41)
+	for i := range 20 {
+		s.NoError(results[2*i].Err)
+		s.Require().NoError(results[2*i+1].Err)
+		s.Equal(fmt.Sprint(i), results[2*i+1].Item.Value, "the results should be in the order of the commands")
+	}
+	s.Error(results[40].Err)
+	s.Zero(pipeline.Len(), "the pipeline should be emptied")
+}
+
+func (s *ShardedClientSuite) TestAddRemoveNode() {
+	url := s.newServer()
+	s.Require().NoError(s.client.AddNode(url))
+	s.Error(s.client.AddNode(url+"/"), "nodes cannot be added twice")
+	s.Len(s.client.Nodes(), 4)
+
+	// the new node takes over some of the keys
+	owned := 0
+	for i := range 100 {
+		owner, err := s.client.NodeFor(fmt.Sprintf("key:%d", i))
+		s.Require().NoError(err)
+		if owner == url {
+			owned++
+		}
+	}
+	s.Positive(owned)
+
+	s.Require().NoError(s.client.RemoveNode(url))
+	s.Error(s.client.RemoveNode(url), "unknown nodes cannot be removed")
+	for i := range 100 {
+		owner, err := s.client.NodeFor(fmt.Sprintf("key:%d", i))
+		s.Require().NoError(err)
+		s.NotEqual(url, owner)
+	}
+}
+
+func (s *ShardedClientSuite) TestNoNodes() {
+	_, err := godb.NewShardedClient(nil, "v1")
+	s.ErrorIs(err, godb.ErrNoNodes)
+
+	for _, url := range s.client.Nodes() {
+		s.Require().NoError(s.client.RemoveNode(url))
+	}
+	_, err = s.client.Get("key")
+	s.ErrorIs(err, godb.ErrNoNodes)
+}
+
+func (s *ShardedClientSuite) TestWatchResume() {
+	_, err := s.client.Watch(context.Background(), "*", 10)
+	s.ErrorIs(err, godb.ErrResumeNotSupported)
+}
+
+func (s *ShardedClientSuite) TestWithContext() {
+	_, err := s.client.Set("key", "value", nil)
+	s.Require().NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scoped := s.client.WithContext(ctx)
+	_, err = scoped.Get("key")
+	s.Require().NoError(err)
+
+	cancel()
+	_, err = scoped.MGet("key", "other")
+	s.ErrorIs(err, context.Canceled)
+	_, err = s.client.Get("key")
+	s.NoError(err, "the context should only apply to the returned client")
+
+	s.Require().NoError(s.client.AddNode(s.newServer()))
+	s.Len(scoped.(*godb.ShardedClient).Nodes(), 4, "the nodes should be shared with the client")
+}
+
+func TestShardedClientSuite(t *testing.T) {
+	suite.Run(t, new(ShardedClientSuite))
+}
@@ -0,0 +1,532 @@
+package godb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ ApiClient = (*SlotClient)(nil)
+)
+
+const (
+	maxRedirects = 5 // maximum number of redirects followed by a request
+)
+
+// ErrTooManyRedirects is returned when a request is redirected more than maxRedirects times,
+// which happens when the servers do not agree on the topology.
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// SlotClient is the client of servers that spread the keys with hash slots. It implements the same ApiClient
+// interface as the client of a single server.
+//
+// The client starts knowing only the seed servers and learns which server owns every slot from the MOVED
+// redirects of the servers: when a request is redirected, the client loads the topology of the server it was
+// redirected to and sends the request there. ASK redirects, returned while a slot migrates, are followed
+// only for the request that received them.
+type SlotClient struct {
+	version   string
+	seeds     []string
+	topology  *slotOwners     // owners of the slots, shared with the clients returned by Namespace and WithContext
+	session   *session        // credentials and tokens of the user, shared by the clients of the servers
+	namespace string          // namespace of the keys, empty for the keys of the database
+	ctx       context.Context // context of the requests of the methods without one
+}
+
+// slotOwners is the server of every slot known by a slot client, with the client of every server.
+type slotOwners struct {
+	mu      sync.RWMutex
+	owners  []string           // URL of the server of every slot, empty if unknown
+	epoch   uint64             // epoch of the topology the owners were loaded from
+	clients map[string]*client // client of every server, by URL
+}
+
+// NewSlotClient creates a client of the servers that use hash slots, starting with the seed servers.
+// The options configure the TLS connections to every server.
+func NewSlotClient(seeds []string, version string, opts ...ClientOptions) (*SlotClient, error) {
+	if len(seeds) == 0 {
+		return nil, ErrNoNodes
+	}
+
+	c := &SlotClient{
+		version:  version,
+		topology: &slotOwners{owners: make([]string, slots.NumSlots), clients: make(map[string]*client)},
+		session:  newSession(version),
+		ctx:      context.Background(),
+	}
+	c.session.configure(opts...)
+	for _, seed := range seeds {
+		c.seeds = append(c.seeds, strings.TrimSuffix(seed, "/"))
+	}
+	return c, nil
+}
+
+// NodeFor returns the URL of the server the client sends the requests of the key to.
+func (c *SlotClient) NodeFor(key string) string {
+	slot := slots.KeySlot(key)
+
+	c.topology.mu.RLock()
+	defer c.topology.mu.RUnlock()
+	if owner := c.topology.owners[slot]; owner != "" {
+		return owner
+	}
+	return c.seeds[slot%len(c.seeds)]
+}
+
+// clientOf returns the client of the server with the URL, with the namespace and the context of the client.
+func (c *SlotClient) clientOf(url string) *client {
+	c.topology.mu.Lock()
+	defer c.topology.mu.Unlock()
+	node, ok := c.topology.clients[url]
+	if !ok {
+		node = newClient(url, c.version, c.session)
+		c.topology.clients[url] = node
+	}
+	return node.inNamespace(c.namespace).withContext(c.ctx)
+}
+
+// nodes returns the URLs of every server known by the client.
+func (c *SlotClient) nodes() []string {
+	c.topology.mu.RLock()
+	defer c.topology.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var urls []string
+	for _, url := range append(append([]string(nil), c.seeds...), c.topology.owners...) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// Login logs in to the seed servers with the credentials of a user, which must be registered in every server.
+// The servers learned later are logged in to with the same credentials.
+func (c *SlotClient) Login(username, password string) error {
+	return c.session.start(username, password, c.seeds...)
+}
+
+// Logout ends the session of the user in every server.
+func (c *SlotClient) Logout() error {
+	return c.session.end()
+}
+
+// UseAPIKey sends the API key with every request to every server, which must all know the key.
+func (c *SlotClient) UseAPIKey(key string) {
+	c.session.useAPIKey(key)
+}
+
+// Namespace returns a client of the keys of the namespace, which must exist in every server. The returned client
+// shares the topology and the credentials of the client.
+func (c *SlotClient) Namespace(name string) ApiClient {
+	namespaced := *c
+	namespaced.namespace = name
+	return &namespaced
+}
+
+// WithContext returns a client whose requests are sent with the context, so they are cancelled with it and carry
+// its trace. The returned client shares the topology and the credentials of the client.
+func (c *SlotClient) WithContext(ctx context.Context) ApiClient {
+	scoped := *c
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// learn records that the slot is owned by the server with the URL, and loads the topology of the server,
+// which is usually more recent than the one of the client.
+func (c *SlotClient) learn(slot int, url string) {
+	c.topology.mu.Lock()
+	c.topology.owners[slot] = url
+	c.topology.mu.Unlock()
+
+	topology, err := c.fetchTopology(url)
+	if err != nil {
+		return // the redirect alone is enough to serve the request
+	}
+
+	c.topology.mu.Lock()
+	defer c.topology.mu.Unlock()
+	if topology.Epoch <= c.topology.epoch {
+		return
+	}
+	c.topology.epoch = topology.Epoch
+	for _, node := range topology.Nodes {
+		for _, r := range node.Slots {
+			for s := r.Start; s <= r.End && s < slots.NumSlots; s++ {
+				c.topology.owners[s] = node.URL
+			}
+		}
+	}
+}
+
+// fetchTopology returns the topology known by the server with the URL.
+func (c *SlotClient) fetchTopology(url string) (*slots.Topology, error) {
+	node := c.clientOf(url)
+	resp, err := node.client.Get(url + slots.TopologyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topology from %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get topology from %s: received status code %d", url, resp.StatusCode)
+	}
+	var topology slots.Topology
+	if err := json.NewDecoder(resp.Body).Decode(&topology); err != nil {
+		return nil, fmt.Errorf("failed to decode topology from %s: %w", url, err)
+	}
+	return &topology, nil
+}
+
+// askingClient returns a client of the server with the URL whose requests follow an ASK redirect.
+func (c *SlotClient) askingClient(url string) *client {
+	node := newClient(url, c.version, c.session)
+	node.client.Transport = c.session.transport(url, &redirectTransport{next: c.session.base, asking: true})
+	node.streamClient.Transport = c.session.transport(url, &redirectTransport{next: c.session.base, asking: true})
+	return node.inNamespace(c.namespace).withContext(c.ctx)
+}
+
+// withRedirects sends the request of the key with call, following the redirects of the servers.
+func withRedirects[T any](c *SlotClient, key string, call func(*client) (T, error)) (T, error) {
+	node := c.clientOf(c.NodeFor(key))
+	for range maxRedirects {
+		result, err := call(node)
+
+		var redirect *RedirectError
+		if !errors.As(err, &redirect) {
+			return result, err
+		}
+		if redirect.Ask {
+			node = c.askingClient(redirect.URL)
+			continue
+		}
+		c.learn(redirect.Slot, redirect.URL)
+		node = c.clientOf(redirect.URL)
+	}
+
+	var zero T
+	return zero, fmt.Errorf("failed to send request for key %s: %w", key, ErrTooManyRedirects)
+}
+
+// batchWithRedirects sends the keys of every server to it with call, concurrently, and merges the results.
+// The keys redirected by a server are sent again to the server named in their redirect.
+func batchWithRedirects[T any](c *SlotClient, keys []string, call func(*client, []string) (map[string]T, error), errOf func(T) error) (map[string]T, error) {
+	results := make(map[string]T, len(keys))
+	asking := make(map[string]string) // URL of the server of the keys redirected with ASK
+	pending := keys
+	for range maxRedirects {
+		groups := make(map[*client][]string)
+		askClients := make(map[string]*client)
+		nodeClients := make(map[string]*client)
+		for _, key := range pending {
+			var node *client
+			if url, ok := asking[key]; ok {
+				if askClients[url] == nil {
+					askClients[url] = c.askingClient(url)
+				}
+				node = askClients[url]
+			} else {
+				url := c.NodeFor(key)
+				if nodeClients[url] == nil {
+					nodeClients[url] = c.clientOf(url)
+				}
+				node = nodeClients[url]
+			}
+			groups[node] = append(groups[node], key)
+		}
+
+		pending = nil
+		asking = make(map[string]string)
+		moved := make(map[int]string) // URL of the server of every slot redirected with MOVED
+		var errs []error
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for node, nodeKeys := range groups {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				nodeResults, err := call(node, nodeKeys)
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					errs = append(errs, err)
+					return
+				}
+				for key, result := range nodeResults {
+					var redirect *RedirectError
+					if !errors.As(errOf(result), &redirect) {
+						results[key] = result
+						continue
+					}
+					pending = append(pending, key)
+					if redirect.Ask {
+						asking[key] = redirect.URL
+					} else {
+						moved[redirect.Slot] = redirect.URL
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		if len(pending) == 0 {
+			return results, nil
+		}
+		for slot, url := range moved {
+			c.learn(slot, url)
+		}
+	}
+	return nil, fmt.Errorf("failed to send batch request: %w", ErrTooManyRedirects)
+}
+
+// Get retrieves the value associated with a key from the server that owns it.
+func (c *SlotClient) Get(key string) (*ApiResponse, error) {
+	return withRedirects(c, key, func(node *client) (*ApiResponse, error) { return node.Get(key) })
+}
+
+// Set stores a key-value pair in the server that owns the key.
+func (c *SlotClient) Set(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.OKResponse, error) { return node.Set(key, value, ttl) })
+}
+
+// Remove deletes a key-value pair from the server that owns the key.
+func (c *SlotClient) Remove(key string) (*schemas.OKResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.OKResponse, error) { return node.Remove(key) })
+}
+
+// Update modifies an existing item in the server that owns the key.
+func (c *SlotClient) Update(key string, value any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.OKResponse, error) { return node.Update(key, value, ttl) })
+}
+
+// Push appends a value to the slice stored at the key in the server that owns it.
+func (c *SlotClient) Push(key string, value string, ttl *time.Duration) (*ApiResponse, error) {
+	return withRedirects(c, key, func(node *client) (*ApiResponse, error) { return node.Push(key, value, ttl) })
+}
+
+// Pop removes the last value of the slice stored at the key in the server that owns it.
+func (c *SlotClient) Pop(key string) (*ApiResponse, error) {
+	return withRedirects(c, key, func(node *client) (*ApiResponse, error) { return node.Pop(key) })
+}
+
+// MGet retrieves the items of several keys, sending a single request to every server that owns some of them.
+func (c *SlotClient) MGet(keys ...string) (map[string]GetResult, error) {
+	return batchWithRedirects(c, keys,
+		func(node *client, keys []string) (map[string]GetResult, error) { return node.MGet(keys...) },
+		func(result GetResult) error { return result.Err })
+}
+
+// MSet stores several items, sending a single request to every server that owns some of them.
+func (c *SlotClient) MSet(items map[string]any, ttl *time.Duration) (map[string]error, error) {
+	return batchWithRedirects(c, sortedKeys(items),
+		func(node *client, keys []string) (map[string]error, error) {
+			return node.MSet(itemsOf(items, keys), ttl)
+		},
+		func(err error) error { return err })
+}
+
+// MSetAtomic stores several items, all of them or none of them. Every key must be in the same hash slot,
+// which can be forced with a hash tag such as {user}, otherwise ErrCrossShard is returned.
+func (c *SlotClient) MSetAtomic(items map[string]any, ttl *time.Duration) (*schemas.OKResponse, error) {
+	keys := sortedKeys(items)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the batch has no items")
+	}
+	slot := slots.KeySlot(keys[0])
+	for _, key := range keys[1:] {
+		if slots.KeySlot(key) != slot {
+			return nil, ErrCrossShard
+		}
+	}
+	return withRedirects(c, keys[0], func(node *client) (*schemas.OKResponse, error) { return node.MSetAtomic(items, ttl) })
+}
+
+// MDel removes several keys, sending a single request to every server that owns some of them.
+func (c *SlotClient) MDel(keys ...string) (map[string]error, error) {
+	return batchWithRedirects(c, keys,
+		func(node *client, keys []string) (map[string]error, error) { return node.MDel(keys...) },
+		func(err error) error { return err })
+}
+
+// Pipeline returns an empty pipeline whose commands are sent to the servers that own the slots of their keys: a single
+// request to every server, concurrently. The commands redirected by a server are sent again, in order, to the server
+// named in their redirect.
+func (c *SlotClient) Pipeline() *Pipeline {
+	return &Pipeline{exec: c.execPipeline}
+}
+
+// execPipeline sends the commands of a pipeline to the servers that own the slots of their keys, following the redirects.
+func (c *SlotClient) execPipeline(commands []schemas.PipelineCommand) ([]PipelineResult, error) {
+	results := make([]PipelineResult, len(commands))
+	asking := make(map[string]string) // URL of the server of the keys redirected with ASK
+	pending := indexes(len(commands))
+	for range maxRedirects {
+		askClients := make(map[string]*client)
+		nodeOf := func(key string) (*client, error) {
+			url, ok := asking[key]
+			if !ok {
+				return c.clientOf(c.NodeFor(key)), nil
+			}
+			if askClients[url] == nil {
+				askClients[url] = c.askingClient(url)
+			}
+			return askClients[url], nil
+		}
+		if err := execSplit(commands, pending, nodeOf, results); err != nil {
+			return nil, err
+		}
+
+		redirected := pending[:0]
+		asking = make(map[string]string)
+		moved := make(map[int]string) // URL of the server of every slot redirected with MOVED
+		for _, i := range pending {
+			var redirect *RedirectError
+			if !errors.As(results[i].Err, &redirect) {
+				continue
+			}
+			redirected = append(redirected, i)
+			if redirect.Ask {
+				asking[commands[i].Key] = redirect.URL
+			} else {
+				moved[redirect.Slot] = redirect.URL
+			}
+		}
+		if len(redirected) == 0 {
+			return results, nil
+		}
+		for slot, url := range moved {
+			c.learn(slot, url)
+		}
+		pending = redirected
+	}
+	return nil, fmt.Errorf("failed to send pipeline: %w", ErrTooManyRedirects)
+}
+
+// StreamAdd appends an entry to the stream stored at the key in the server that owns it.
+func (c *SlotClient) StreamAdd(key string, fields map[string]string, ttl *time.Duration) (*schemas.StreamAddResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamAddResponse, error) { return node.StreamAdd(key, fields, ttl) })
+}
+
+// StreamRange returns entries of the stream stored at the key in the server that owns it.
+func (c *SlotClient) StreamRange(key string, start, end string, count int) (*schemas.StreamEntriesResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamEntriesResponse, error) {
+		return node.StreamRange(key, start, end, count)
+	})
+}
+
+// StreamRead returns entries of the stream stored at the key in the server that owns it, waiting up to block for new entries.
+func (c *SlotClient) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamEntriesResponse, error) {
+		return node.StreamRead(ctx, key, after, count, block)
+	})
+}
+
+// StreamTrim removes the oldest entries of the stream stored at the key in the server that owns it.
+func (c *SlotClient) StreamTrim(key string, maxLen int, maxAge time.Duration) (*schemas.StreamTrimResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamTrimResponse, error) {
+		return node.StreamTrim(key, maxLen, maxAge)
+	})
+}
+
+// StreamGroupCreate creates a consumer group in the stream stored at the key in the server that owns it.
+func (c *SlotClient) StreamGroupCreate(key string, group string, start string) (*schemas.OKResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.OKResponse, error) {
+		return node.StreamGroupCreate(key, group, start)
+	})
+}
+
+// StreamReadGroup delivers new entries of the stream stored at the key to the consumer of the group.
+func (c *SlotClient) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamEntriesResponse, error) {
+		return node.StreamReadGroup(ctx, key, group, consumer, count, block)
+	})
+}
+
+// StreamAck acknowledges entries pending in the group of the stream stored at the key.
+func (c *SlotClient) StreamAck(key string, group string, ids ...string) (*schemas.StreamAckResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamAckResponse, error) {
+		return node.StreamAck(key, group, ids...)
+	})
+}
+
+// StreamPending returns the pending entries of the group of the stream stored at the key.
+func (c *SlotClient) StreamPending(key string, group string, consumer string) (*schemas.StreamPendingResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamPendingResponse, error) {
+		return node.StreamPending(key, group, consumer)
+	})
+}
+
+// StreamClaim transfers pending entries of the group of the stream stored at the key to the consumer.
+func (c *SlotClient) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) (*schemas.StreamEntriesResponse, error) {
+	return withRedirects(c, key, func(node *client) (*schemas.StreamEntriesResponse, error) {
+		return node.StreamClaim(key, group, consumer, minIdle, ids...)
+	})
+}
+
+// Watch streams the keyspace events of every known server for the keys that match the glob pattern.
+//
+// Every server numbers its own events, so the watch cannot be resumed from an event ID: lastEventID must be 0.
+// The events of different servers are not ordered, and servers learned after the call are not watched.
+func (c *SlotClient) Watch(ctx context.Context, match string, lastEventID uint64) (<-chan Event, error) {
+	if lastEventID != 0 {
+		return nil, ErrResumeNotSupported
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var streams []<-chan Event
+	for _, url := range c.nodes() {
+		events, err := c.clientOf(url).Watch(ctx, match, 0)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		streams = append(streams, events)
+	}
+	return merge(ctx, cancel, eventBufferSize, streams), nil
+}
+
+// Publish sends a message to a pub/sub channel, in the server that owns the slot of the channel.
+func (c *SlotClient) Publish(channel string, message string) (*schemas.PublishResponse, error) {
+	return c.clientOf(c.NodeFor(channel)).Publish(channel, message)
+}
+
+// Subscribe streams the messages published to the channels, subscribing to every channel in the server that owns
+// its slot, and to the channels that match the patterns, subscribing to the patterns in every known server.
+func (c *SlotClient) Subscribe(ctx context.Context, channels []string, patterns []string) (<-chan Message, error) {
+	groups := make(map[string][]string)
+	for _, channel := range channels {
+		url := c.NodeFor(channel)
+		groups[url] = append(groups[url], channel)
+	}
+	if len(patterns) > 0 {
+		for _, url := range c.nodes() {
+			if _, ok := groups[url]; !ok {
+				groups[url] = nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var streams []<-chan Message
+	for url, nodeChannels := range groups {
+		messages, err := c.clientOf(url).Subscribe(ctx, nodeChannels, patterns)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		streams = append(streams, messages)
+	}
+	return merge(ctx, cancel, messageBufferSize, streams), nil
+}
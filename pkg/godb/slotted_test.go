@@ -0,0 +1,236 @@
+package godb_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"memorydb/internal/db"
+	"memorydb/internal/slots"
+	"memorydb/internal/transport"
+	"memorydb/internal/transport/schemas"
+	"memorydb/pkg/godb"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SlotClientSuite struct {
+	dbs     []db.DBClient
+	servers []*httptest.Server
+	client  *godb.SlotClient
+	suite.Suite
+}
+
+func (s *SlotClientSuite) SetupTest() {
+	// the URLs must be known to create the topology, so the servers are started before their handlers
+	handlers := make([]http.Handler, 2)
+	s.servers = nil
+	var nodes []slots.Node
+	for i := range handlers {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlers[i].ServeHTTP(w, r)
+		}))
+		s.servers = append(s.servers, server)
+		nodes = append(nodes, slots.Node{ID: fmt.Sprintf("node%d", i), URL: server.URL})
+	}
+
+	s.dbs = nil
+	for i, node := range nodes {
+		database := db.NewMemoryDB(slog.Default())
+		router, err := slots.NewRouter(slog.Default(), database, node.ID, slots.NewTopology(nodes))
+		s.Require().NoError(err)
+		handlers[i] = transport.NewServer(slog.Default(), 0, 0, database, transport.WithSlotRouter{Router: router}).Handler()
+		s.dbs = append(s.dbs, database)
+	}
+
+	// the client only knows the first server, and learns the rest from the redirects
+	client, err := godb.NewSlotClient([]string{s.servers[0].URL}, "v1")
+	s.Require().NoError(err)
+	s.client = client
+}
+
+func (s *SlotClientSuite) TearDownTest() {
+	for _, server := range s.servers {
+		server.Close()
+	}
+	for _, database := range s.dbs {
+		database.Close()
+	}
+}
+
+// migrate moves the slots in the range from the first server to the second one.
+func (s *SlotClientSuite) migrate(start, end int) schemas.SlotsMigrateResponse {
+	body, err := json.Marshal(map[string]any{"start": start, "end": end, "target": "node1"})
+	s.Require().NoError(err)
+	resp, err := http.Post(s.servers[0].URL+slots.MigratePath, "application/json", bytes.NewReader(body))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	var migrated schemas.SlotsMigrateResponse
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&migrated))
+	return migrated
+}
+
+func (s *SlotClientSuite) TestRedirect() {
+	// plain HTTP clients follow the redirects, so they are disabled to check the response
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(s.servers[0].URL + "/api/v1/foo") // slot 12182, owned by the second server
+	s.Require().NoError(err)
+	resp.Body.Close()
+
+	s.Equal(http.StatusTemporaryRedirect, resp.StatusCode)
+	s.Equal("MOVED 12182 "+s.servers[1].URL, resp.Header.Get(slots.RedirectHeader))
+	s.Equal(s.servers[1].URL+"/api/v1/foo", resp.Header.Get("Location"))
+}
+
+func (s *SlotClientSuite) TestRouting() {
+	for i := range 100 {
+		key := fmt.Sprintf("key:%d", i)
+		_, err := s.client.Set(key, "value", nil)
+		s.Require().NoError(err)
+
+		// the key is only stored in the server that owns its slot
+		owner := 0
+		if slots.KeySlot(key) >= slots.NumSlots/2 {
+			owner = 1
+		}
+		_, err = s.dbs[owner].Get(key)
+		s.NoError(err, "key %s should be stored in server %d", key, owner)
+		_, err = s.dbs[1-owner].Get(key)
+		s.Error(err, "key %s should not be stored in server %d", key, 1-owner)
+
+		item, err := s.client.Get(key)
+		s.Require().NoError(err)
+		s.Equal("value", item.Value)
+	}
+
+	// the topology is learned from the first redirect
+	s.Equal(s.servers[1].URL, s.client.NodeFor("foo"))
+}
+
+func (s *SlotClientSuite) TestBatch() {
+	items := make(map[string]any)
+	var keys []string
+	for i := range 50 {
+		key := fmt.Sprintf("key:%d", i)
+		items[key] = "value"
+		keys = append(keys, key)
+	}
+
+	// the client only knows the first server, so the keys of the second one are redirected and sent again
+	errs, err := s.client.MSet(items, nil)
+	s.Require().NoError(err)
+	s.Len(errs, 50)
+	for key, err := range errs {
+		s.NoError(err, key)
+		owner := 0
+		if slots.KeySlot(key) >= slots.NumSlots/2 {
+			owner = 1
+		}
+		_, err = s.dbs[owner].Get(key)
+		s.NoError(err, "key %s should be stored in server %d", key, owner)
+	}
+
+	results, err := s.client.MGet(append(keys, "missing")...)
+	s.Require().NoError(err)
+	s.Len(results, 51)
+	for _, key := range keys {
+		s.Require().NoError(results[key].Err, key)
+		s.Equal("value", results[key].Item.Value)
+	}
+	var keyErr *godb.KeyError
+	s.Require().ErrorAs(results["missing"].Err, &keyErr)
+	s.Equal("item_not_found", keyErr.Code)
+
+	errs, err = s.client.MDel(keys...)
+	s.Require().NoError(err)
+	for key, err := range errs {
+		s.NoError(err, key)
+	}
+
+	_, err = s.client.MSetAtomic(map[string]any{"a": "1", "b": "2"}, nil)
+	s.ErrorIs(err, godb.ErrCrossShard)
+	_, err = s.client.MSetAtomic(map[string]any{"{user}:a": "1", "{user}:b": "2"}, nil)
+	s.Require().NoError(err)
+	item, err := s.client.Get("{user}:b")
+	s.Require().NoError(err)
+	s.Equal("2", item.Value)
+}
+
+func (s *SlotClientSuite) TestPipeline() {
+	// the client only knows the first server, so the commands of the second one are redirected and sent again
+	pipeline := s.client.Pipeline()
+	for i := range 20 {
+		key := fmt.Sprintf("key:%d", i)
+		pipeline.Set(key, []string{"a"}, nil).Push(key, "b", nil).Get(key)
+	}
+	results, err := pipeline.Exec()
+	s.Require().NoError(err)
+	s.Require().Len(results, 60)
+	for i := range 20 {
+		s.NoError(results[3*i].Err)
+		s.Require().NoError(results[3*i+1].Err)
+		s.Require().NoError(results[3*i+2].Err)
+		s.Equal([]string{"a", "b"}, results[3*i+2].Item.Value)
+	}
+
+	results, err = pipeline.Remove("key:0").Get("key:0").Exec()
+	s.Require().NoError(err)
+	s.NoError(results[0].Err)
+	var keyErr *godb.KeyError
+	s.Require().ErrorAs(results[1].Err, &keyErr)
+	s.Equal("item_not_found", keyErr.Code)
+}
+
+func (s *SlotClientSuite) TestMigrate() {
+	const keys = 500
+	for i := range keys {
+		_, err := s.client.Set(fmt.Sprintf("key:%d", i), "before", nil)
+		s.Require().NoError(err)
+	}
+
+	// keep writing while the slots move
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range keys {
+			_, err := s.client.Update(fmt.Sprintf("key:%d", i), "after", nil)
+			s.NoError(err)
+			_, err = s.client.Set(fmt.Sprintf("new:%d", i), "value", nil)
+			s.NoError(err)
+		}
+	}()
+
+	migrated := s.migrate(0, slots.NumSlots/2-1)
+	wg.Wait()
+	s.Equal(uint64(2), migrated.Epoch)
+	s.Positive(migrated.Moved)
+	s.Empty(s.dbs[0].Keys("*"), "every key should have moved")
+
+	for i := range keys {
+		item, err := s.client.Get(fmt.Sprintf("key:%d", i))
+		s.Require().NoError(err)
+		s.Equal("after", item.Value)
+		_, err = s.client.Get(fmt.Sprintf("new:%d", i))
+		s.Require().NoError(err)
+	}
+	s.Len(s.dbs[1].Keys("*"), 2*keys)
+
+	// the other server received the new topology
+	resp, err := http.Get(s.servers[1].URL + slots.TopologyPath)
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	var topology slots.Topology
+	s.Require().NoError(json.NewDecoder(resp.Body).Decode(&topology))
+	s.Equal(uint64(2), topology.Epoch)
+}
+
+func TestSlotClientSuite(t *testing.T) {
+	suite.Run(t, new(SlotClientSuite))
+}
@@ -0,0 +1,82 @@
+package godb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxSSELineSizeKiB = 64 // maximum size of a single line of an event stream
+)
+
+// sseEvent is a single event read from a Server-Sent Events stream.
+type sseEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// openStream sends a GET request to a streaming endpoint and returns the body of the response.
+func (c *client) openStream(ctx context.Context, endpoint string, header http.Header) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stream request for %s: %w", endpoint, err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream from %s: %w", endpoint, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to open stream from %s: received status code %d", endpoint, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// readSSE parses the Server-Sent Events of the stream and calls handle for every event,
+// until the stream ends or handle returns false.
+func readSSE(body io.Reader, handle func(sseEvent) bool) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxSSELineSizeKiB*1024)
+
+	var (
+		current sseEvent
+		data    strings.Builder
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id:"):
+			current.id = strings.TrimSpace(strings.TrimPrefix(line, "id:"))
+		case strings.HasPrefix(line, "event:"):
+			current.event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		case line == "":
+			// a blank line terminates the event, lines without data are comments or keep-alives
+			if data.Len() == 0 {
+				current = sseEvent{}
+				continue
+			}
+			current.data = data.String()
+			if !handle(current) {
+				return
+			}
+			current = sseEvent{}
+			data.Reset()
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package godb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"memorydb/internal/transport/schemas"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StreamAdd appends an entry with the given fields to the stream stored at the key, creating the stream if needed.
+// It returns the ID assigned to the entry, or an error if it fails.
+func (c *client) StreamAdd(key string, fields map[string]string, ttl *time.Duration) (*schemas.StreamAddResponse, error) {
+	data := schemas.StreamAddRequest{Fields: fields}
+	if ttl != nil {
+		data.TTL = &schemas.Duration{Duration: *ttl}
+	}
+
+	var response schemas.StreamAddResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodPost, nil, data, &response, key); err != nil {
+		return nil, fmt.Errorf("failed to add entry to stream %s: %w", key, err)
+	}
+	return &response, nil
+}
+
+// StreamRange returns up to count entries of the stream with IDs between start and end, both included.
+// The special IDs "-" and "+" are the first and the last entry of the stream. A count of 0 means no limit.
+func (c *client) StreamRange(key string, start, end string, count int) (*schemas.StreamEntriesResponse, error) {
+	query := url.Values{"start": {start}, "end": {end}, "count": {strconv.Itoa(count)}}
+
+	var response schemas.StreamEntriesResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodGet, query, nil, &response, key); err != nil {
+		return nil, fmt.Errorf("failed to read range of stream %s: %w", key, err)
+	}
+	return &response, nil
+}
+
+// StreamRead returns up to count entries of the stream after the given ID, where "$" means only new entries.
+// If block is positive, the server waits up to that duration for new entries before returning an empty list.
+func (c *client) StreamRead(ctx context.Context, key string, after string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	query := url.Values{"after": {after}, "count": {strconv.Itoa(count)}}
+	if block > 0 {
+		query.Set("block", block.String())
+	}
+
+	// blocking reads can take longer than the timeout of the default client
+	var response schemas.StreamEntriesResponse
+	if err := c.doStreamRequest(ctx, c.streamClient, http.MethodGet, query, nil, &response, key, "read"); err != nil {
+		return nil, fmt.Errorf("failed to read stream %s: %w", key, err)
+	}
+	return &response, nil
+}
+
+// StreamTrim removes the oldest entries of the stream so it has at most maxLen entries and no entry older than maxAge.
+// A maxLen or a maxAge of 0 disables the corresponding criterion.
+func (c *client) StreamTrim(key string, maxLen int, maxAge time.Duration) (*schemas.StreamTrimResponse, error) {
+	data := schemas.StreamTrimRequest{MaxLen: maxLen}
+	if maxAge > 0 {
+		data.MaxAge = &schemas.Duration{Duration: maxAge}
+	}
+
+	var response schemas.StreamTrimResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodPost, nil, data, &response, key, "trim"); err != nil {
+		return nil, fmt.Errorf("failed to trim stream %s: %w", key, err)
+	}
+	return &response, nil
+}
+
+// StreamGroupCreate creates a consumer group in the stream that delivers the entries after start,
+// where "$" means only new entries and "0" means every entry.
+func (c *client) StreamGroupCreate(key string, group string, start string) (*schemas.OKResponse, error) {
+	data := schemas.StreamGroupCreateRequest{Group: group, Start: start}
+
+	var response schemas.OKResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodPost, nil, data, &response, key, "groups"); err != nil {
+		return nil, fmt.Errorf("failed to create group %s in stream %s: %w", group, key, err)
+	}
+	return &response, nil
+}
+
+// StreamReadGroup delivers up to count new entries of the stream to the consumer of the group. The entries stay
+// pending until they are acknowledged with StreamAck. If block is positive, the server waits up to that duration
+// for new entries before returning an empty list.
+func (c *client) StreamReadGroup(ctx context.Context, key string, group string, consumer string, count int, block time.Duration) (*schemas.StreamEntriesResponse, error) {
+	query := url.Values{"consumer": {consumer}, "count": {strconv.Itoa(count)}}
+	if block > 0 {
+		query.Set("block", block.String())
+	}
+
+	var response schemas.StreamEntriesResponse
+	if err := c.doStreamRequest(ctx, c.streamClient, http.MethodGet, query, nil, &response, key, "groups", group, "read"); err != nil {
+		return nil, fmt.Errorf("failed to read group %s of stream %s: %w", group, key, err)
+	}
+	return &response, nil
+}
+
+// StreamAck acknowledges entries pending in the consumer group and returns how many of them were pending.
+func (c *client) StreamAck(key string, group string, ids ...string) (*schemas.StreamAckResponse, error) {
+	data := schemas.StreamAckRequest{IDs: ids}
+
+	var response schemas.StreamAckResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodPost, nil, data, &response, key, "groups", group, "ack"); err != nil {
+		return nil, fmt.Errorf("failed to acknowledge entries of group %s in stream %s: %w", group, key, err)
+	}
+	return &response, nil
+}
+
+// StreamPending returns the entries of the consumer group that have been delivered to the consumer but not acknowledged.
+// If consumer is empty, the pending entries of every consumer are returned.
+func (c *client) StreamPending(key string, group string, consumer string) (*schemas.StreamPendingResponse, error) {
+	query := url.Values{}
+	if consumer != "" {
+		query.Set("consumer", consumer)
+	}
+
+	var response schemas.StreamPendingResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodGet, query, nil, &response, key, "groups", group, "pending"); err != nil {
+		return nil, fmt.Errorf("failed to get pending entries of group %s in stream %s: %w", group, key, err)
+	}
+	return &response, nil
+}
+
+// StreamClaim transfers to the consumer the pending entries of the group that have been idle for at least minIdle,
+// and returns the claimed entries.
+func (c *client) StreamClaim(key string, group string, consumer string, minIdle time.Duration, ids ...string) (*schemas.StreamEntriesResponse, error) {
+	data := schemas.StreamClaimRequest{Consumer: consumer, MinIdle: &schemas.Duration{Duration: minIdle}, IDs: ids}
+
+	var response schemas.StreamEntriesResponse
+	if err := c.doStreamRequest(context.Background(), c.client, http.MethodPost, nil, data, &response, key, "groups", group, "claim"); err != nil {
+		return nil, fmt.Errorf("failed to claim entries of group %s in stream %s: %w", group, key, err)
+	}
+	return &response, nil
+}
+
+// doStreamRequest sends a request to the stream endpoint of the key made of the path elements and decodes
+// the response into out. The body is encoded as JSON if it is not nil.
+func (c *client) doStreamRequest(ctx context.Context, httpClient *http.Client, method string, query url.Values, body any, out any, key string, elem ...string) error {
+	endpoint, err := url.JoinPath(c.url, append([]string{c.keyspace, "streams", key}, elem...)...)
+	if err != nil {
+		return fmt.Errorf("failed to join path: %w", err)
+	}
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body for %s: %w", endpoint, err)
+		}
+		reader = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", endpoint, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("received status code %d from %s", resp.StatusCode, endpoint)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", endpoint, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package godb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+)
+
+// ClientOptions defines an interface for applying options to the connections of the clients.
+type ClientOptions interface {
+	apply(*tls.Config)
+}
+
+// WithRootCAs verifies the certificates of the servers against the pool, instead of the roots of the system,
+// such as the CA of a private network.
+type WithRootCAs struct{ *x509.CertPool }
+
+func (o WithRootCAs) apply(c *tls.Config) {
+	c.RootCAs = o.CertPool
+}
+
+// WithClientCertificate presents the certificate to the servers that verify the certificates of their clients.
+type WithClientCertificate struct{ tls.Certificate }
+
+func (o WithClientCertificate) apply(c *tls.Config) {
+	c.Certificates = append(c.Certificates, o.Certificate)
+}
+
+// WithServerName verifies the certificates of the servers against the name, instead of the host of their URLs,
+// such as when the servers are reached through their IP addresses.
+type WithServerName string
+
+func (o WithServerName) apply(c *tls.Config) {
+	c.ServerName = string(o)
+}
+
+// WithNodeOptions applies the options to the connections of every node of a sharded client.
+type WithNodeOptions []ClientOptions
+
+func (o WithNodeOptions) apply(c *ShardedClient) {
+	c.session.configure(o...)
+}
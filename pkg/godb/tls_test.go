@@ -0,0 +1,102 @@
+package godb_test
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"memorydb/internal/auth"
+	"memorydb/internal/certs"
+	"memorydb/internal/certs/certstest"
+	"memorydb/internal/db"
+	"memorydb/internal/enums"
+	"memorydb/internal/transport"
+	"memorydb/pkg/godb"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TLSClientSuite struct {
+	db      db.DBClient
+	manager *auth.Manager
+	ca      *certstest.CA
+	server  *httptest.Server
+	url     string
+	suite.Suite
+}
+
+func (s *TLSClientSuite) SetupTest() {
+	s.db = db.NewMemoryDB(slog.Default())
+	var err error
+	s.manager, err = auth.NewManager(slog.Default(), s.db, "0123456789abcdef0123456789abcdef", "admin", "admin-password")
+	s.Require().NoError(err)
+
+	dir := s.T().TempDir()
+	s.ca = certstest.NewCA(s.T(), "ca")
+	s.ca.Issue("server", filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	reloader, err := certs.NewReloader(slog.Default(), filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"),
+		certs.WithClientCA(s.ca.File), certs.WithClientAuth(tls.VerifyClientCertIfGiven))
+	s.Require().NoError(err)
+
+	server := transport.NewServer(slog.Default(), 0, 0, s.db, transport.WithAuthManager{AuthManager: s.manager}, transport.WithTLS{Reloader: reloader})
+	s.server = httptest.NewUnstartedServer(server.Handler())
+	s.server.Listener = tls.NewListener(s.server.Listener, reloader.ServerConfig(true))
+	s.server.Start()
+	s.url = strings.Replace(s.server.URL, "http://", "https://", 1)
+}
+
+func (s *TLSClientSuite) TearDownTest() {
+	s.server.Close()
+	s.db.Close()
+}
+
+func (s *TLSClientSuite) TestClientCertificate() {
+	_, _, err := s.manager.CreateAPIKey("billing", "billing-service", []db.ACLRule{
+		{Pattern: "billing:*", Permissions: []enums.Permission{enums.PermissionRead, enums.PermissionWrite}},
+	})
+	s.Require().NoError(err)
+	dir := s.T().TempDir()
+	cert := s.ca.Issue("billing-service", filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+
+	// the identity of the certificate is the subject of the API key, so its rules apply
+	client := godb.NewClient(s.url, "v1", godb.WithRootCAs{CertPool: s.ca.Pool()}, godb.WithClientCertificate{Certificate: cert})
+	_, err = client.Set("billing:1", "1", nil)
+	s.Require().NoError(err)
+	item, err := client.Get("billing:1")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value)
+	_, err = client.Set("config:timeout", "1", nil)
+	s.Error(err, "the rules of the API key should apply to the certificate")
+
+	// a certificate whose identity is not the subject of an API key does not authenticate the requests
+	unknown := s.ca.Issue("unknown", filepath.Join(dir, "unknown.crt"), filepath.Join(dir, "unknown.key"))
+	client = godb.NewClient(s.url, "v1", godb.WithRootCAs{CertPool: s.ca.Pool()}, godb.WithClientCertificate{Certificate: unknown})
+	_, err = client.Get("billing:1")
+	s.Error(err)
+}
+
+func (s *TLSClientSuite) TestRootCAs() {
+	s.Require().NoError(s.manager.Register("admin", "admin-password", "alice", "secret"))
+
+	client := godb.NewClient(s.url, "v1")
+	s.Error(client.Login("alice", "secret"), "the certificate of the server should not be trusted without the CA")
+
+	// the client certificate is optional, so the users log in as usual
+	client = godb.NewClient(s.url, "v1", godb.WithRootCAs{CertPool: s.ca.Pool()})
+	s.Require().NoError(client.Login("alice", "secret"))
+	_, err := client.Set("config:timeout", "1", nil)
+	s.NoError(err)
+
+	slotClient, err := godb.NewSlotClient([]string{s.url}, "v1", godb.WithRootCAs{CertPool: s.ca.Pool()})
+	s.Require().NoError(err)
+	s.Require().NoError(slotClient.Login("alice", "secret"))
+	item, err := slotClient.Get("config:timeout")
+	s.Require().NoError(err)
+	s.Equal("1", item.Value)
+}
+
+func TestTLSClientSuite(t *testing.T) {
+	suite.Run(t, new(TLSClientSuite))
+}
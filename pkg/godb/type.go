@@ -44,3 +44,9 @@ func (r *ApiResponse) UnmarshalJSON(data []byte) error {
 
 	return fmt.Errorf("unsupported type for value")
 }
+
+// Event is a keyspace event received from the server.
+type Event schemas.EventResponse
+
+// Message is a pub/sub message received from the server.
+type Message schemas.MessageResponse
@@ -6,6 +6,7 @@ import (
 	"memorydb/pkg/godb"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
@@ -132,6 +133,79 @@ func (s *IntegrationTestSuite) TestPop() {
 	s.Nil(getResp, "expected nil response for popped key")
 }
 
+func (s *IntegrationTestSuite) TestSubscribe() {
+	fmt.Println("Running integration test for keyspace events")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events, err := s.client.Watch(ctx, "eventKey*", 0)
+	s.Require().NoError(err, "failed to subscribe to events")
+
+	_, err = s.client.Set("eventKey", "value", nil)
+	s.Require().NoError(err, "failed to set value")
+	_, err = s.client.Remove("eventKey")
+	s.Require().NoError(err, "failed to remove value")
+
+	for _, expected := range []string{"set", "remove"} {
+		select {
+		case event := <-events:
+			s.Equal(expected, event.Type)
+			s.Equal("eventKey", event.Key)
+		case <-ctx.Done():
+			s.FailNow("timeout waiting for keyspace event")
+		}
+	}
+}
+
+func (s *IntegrationTestSuite) TestPublishSubscribe() {
+	fmt.Println("Running integration test for publish/subscribe")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	messages, err := s.client.Subscribe(ctx, []string{"integration"}, nil)
+	s.Require().NoError(err, "failed to subscribe to channel")
+
+	// the subscription is registered asynchronously, so publish until the message is received
+	s.Eventually(func() bool {
+		resp, err := s.client.Publish("integration", "hello")
+		return err == nil && resp.Receivers == 1
+	}, 5*time.Second, 100*time.Millisecond)
+
+	select {
+	case msg := <-messages:
+		s.Equal("integration", msg.Channel)
+		s.Equal("hello", msg.Payload)
+	case <-ctx.Done():
+		s.FailNow("timeout waiting for pub/sub message")
+	}
+}
+
+func (s *IntegrationTestSuite) TestStreams() {
+	fmt.Println("Running integration test for streams")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	added, err := s.client.StreamAdd("streamKey", map[string]string{"task": "resize"}, nil)
+	s.Require().NoError(err, "failed to add entry to stream")
+
+	_, err = s.client.StreamGroupCreate("streamKey", "workers", "0")
+	s.Require().NoError(err, "failed to create consumer group")
+
+	read, err := s.client.StreamReadGroup(ctx, "streamKey", "workers", "alice", 10, time.Second)
+	s.Require().NoError(err, "failed to read consumer group")
+	s.Require().Len(read.Entries, 1)
+	s.Equal(added.ID, read.Entries[0].ID)
+	s.Equal("resize", read.Entries[0].Fields["task"])
+
+	acked, err := s.client.StreamAck("streamKey", "workers", added.ID)
+	s.Require().NoError(err, "failed to acknowledge entry")
+	s.Equal(1, acked.Acknowledged)
+
+	pending, err := s.client.StreamPending("streamKey", "workers", "")
+	s.Require().NoError(err, "failed to get pending entries")
+	s.Empty(pending.Pending)
+}
+
 func TestIntegration(t *testing.T) {
 	suite.Run(t, new(IntegrationTestSuite))
 }